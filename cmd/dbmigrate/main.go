@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/traefik/paerser/file"
+	"github.com/zekroTJA/shinpuru/internal/inits"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/database/dbtransfer"
+)
+
+var (
+	fFrom  = flag.String("from", "", "config file containing the source database configuration")
+	fTo    = flag.String("to", "", "config file containing the target database configuration")
+	fDump  = flag.String("dump", "", "write all entities of the source database into this dump file")
+	fLoad  = flag.String("load", "", "read all entities from this dump file into the target database")
+	fBatch = flag.Int("batch", dbtransfer.DefaultBatchSize, "number of entities requested at once from the source database")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage:\n"+
+				"  dbmigrate -from <config> -to <config>   transfer all data between databases\n"+
+				"  dbmigrate -from <config> -dump <file>   write all data into a dump file\n"+
+				"  dbmigrate -load <file> -to <config>     read all data from a dump file\n"+
+				"  dbmigrate -from <config>                print record counts\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch {
+	case *fLoad != "" && *fTo != "" && *fFrom == "" && *fDump == "":
+		load()
+	case *fFrom != "" && *fDump != "" && *fTo == "" && *fLoad == "":
+		dump()
+	case *fFrom != "" && *fTo != "" && *fDump == "" && *fLoad == "":
+		transfer()
+	case *fFrom != "" && *fTo == "" && *fDump == "" && *fLoad == "":
+		count()
+	default:
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+func transfer() {
+	src := openDatabase(*fFrom)
+	defer src.Close()
+	dst := openDatabase(*fTo)
+	defer dst.Close()
+	ensureEmpty(dst)
+
+	counts, err := dbtransfer.Transfer(src, dst, *fBatch)
+	printCounts(counts)
+	checkErr(err)
+
+	fmt.Println("\ntransfer finished and verified")
+}
+
+func dump() {
+	src := openDatabase(*fFrom)
+	defer src.Close()
+
+	f, err := os.Create(*fDump)
+	checkErr(err)
+	defer f.Close()
+
+	dw, err := dbtransfer.NewDumpWriter(f)
+	checkErr(err)
+
+	counts, err := dbtransfer.Export(src, *fBatch, dw)
+	checkErr(err)
+	checkErr(dw.Flush())
+
+	printCounts(counts)
+	fmt.Printf("\ndump written to %s\n", *fDump)
+}
+
+func load() {
+	dst := openDatabase(*fTo)
+	defer dst.Close()
+	ensureEmpty(dst)
+
+	f, err := os.Open(*fLoad)
+	checkErr(err)
+	defer f.Close()
+
+	counts, err := dbtransfer.ReadDump(f, dbtransfer.NewDatabaseSink(dst))
+	printCounts(counts)
+	checkErr(err)
+
+	actual, err := dbtransfer.Count(dst, *fBatch)
+	checkErr(err)
+	checkErr(dbtransfer.Verify(counts, actual))
+
+	fmt.Println("\nload finished and verified")
+}
+
+func count() {
+	src := openDatabase(*fFrom)
+	defer src.Close()
+
+	counts, err := dbtransfer.Count(src, *fBatch)
+	checkErr(err)
+
+	printCounts(counts)
+}
+
+func openDatabase(cfgFile string) database.Database {
+	cfg := models.DefaultConfig
+	checkErr(file.Decode(cfgFile, &cfg))
+
+	db, err := inits.OpenDatabase(cfg.Database)
+	checkErr(err)
+
+	if m, ok := db.(database.Migration); ok {
+		checkErr(m.Migrate())
+	}
+
+	return db
+}
+
+// ensureEmpty exits when db already contains records
+// because the verification after the import would fail
+// anyways and existing records could be overwritten.
+func ensureEmpty(db database.Database) {
+	counts, err := dbtransfer.Count(db, *fBatch)
+	checkErr(err)
+	if counts.Total() != 0 {
+		checkErr(errors.New("target database is not empty"))
+	}
+}
+
+func printCounts(counts dbtransfer.Counts) {
+	if counts == nil {
+		return
+	}
+	fmt.Print(counts.String())
+	fmt.Printf("%-16s %d\n", "total", counts.Total())
+}
+
+func checkErr(err error) {
+	if err == nil {
+		return
+	}
+
+	code := 1
+	var mErr dbtransfer.MismatchError
+	if errors.As(err, &mErr) {
+		code = 2
+	}
+
+	fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+	os.Exit(code)
+}
@@ -85,28 +85,17 @@ var (
 				Image:   "redis:latest",
 				Restart: "always",
 			},
-			"mysql": {
-				Image: "mariadb:latest",
-				Environment: map[string]string{
-					// "MYSQL_ROOT_PASSWORD": "mysql_root_password" // finn in via cli
-					"MYSQL_DATABASE": "shinpuru",
-				},
-				Volumes: []string{}, // fill in via cli
-				Restart: "always",
-			},
 			"shinpuru": {
 				// Image: "shinpuru:latest", // fill in via cli
 				Volumes: []string{
 					"./shinpuru/config:/etc/config",
 					"/etc/cert:/etc/cert",
-				},
+				}, // database volume is filled in via cli
 				Environment: map[string]string{
 					"SP_CONFIGVERSIONPLEASEDONOTCHANGE": "6",
 					"SP_DISCORD_GENERALPREFIX":          "sp!",
-					"SP_DATABASE_TYPE":                  "mysql",
-					"SP_DATABASE_MYSQL_HOST":            "mysql",
-					"SP_DATABASE_MYSQL_USER":            "root",
-					"SP_DATABASE_MYSQL_DATABASE":        "shinpuru",
+					"SP_DATABASE_TYPE":                  "sqlite",
+					"SP_DATABASE_SQLITE_FILE":           "/var/lib/shinpuru/shinpuru.db",
 					"SP_CACHE_REDIS_ADDR":               "redis:6379",
 					"SP_CACHE_REDIS_TYPE":               "0",
 					"SP_CACHE_CACHEDATABASE":            "1",
@@ -117,7 +106,6 @@ var (
 				}, // fill in via cli
 				Restart: "always",
 				DependsOn: []string{
-					"mysql",
 					"redis",
 					"minio",
 				},
@@ -131,6 +119,16 @@ var (
 			},
 		},
 	}
+
+	mysqlSvc = &DockerService{
+		Image: "mariadb:latest",
+		Environment: map[string]string{
+			// "MYSQL_ROOT_PASSWORD": "mysql_root_password" // finn in via cli
+			"MYSQL_DATABASE": "shinpuru",
+		},
+		Volumes: []string{}, // fill in via cli
+		Restart: "always",
+	}
 )
 
 func main() {
@@ -462,6 +460,23 @@ func prompts() {
 	fmt.Println(promptui.Styler(promptui.FGMagenta)("\n8) Deployment"))
 	fmt.Println("\nℹ️ Last of all, now some questions about your deployment preferences.")
 
+	dbType, _ := mustVal2((&promptui.Select{
+		Label: "Which database do you want to use?",
+		Items: []string{
+			"SQLite (recommended)",
+			"MariaDB",
+		},
+	}).Run())
+	if dbType == 1 {
+		dcConfig.Services["mysql"] = mysqlSvc
+		spSvc.DependsOn = append(spSvc.DependsOn, "mysql")
+		delete(spSvc.Environment, "SP_DATABASE_SQLITE_FILE")
+		spSvc.Environment["SP_DATABASE_TYPE"] = "mysql"
+		spSvc.Environment["SP_DATABASE_MYSQL_HOST"] = "mysql"
+		spSvc.Environment["SP_DATABASE_MYSQL_USER"] = "root"
+		spSvc.Environment["SP_DATABASE_MYSQL_DATABASE"] = "shinpuru"
+	}
+
 	useVolumes, _ := mustVal2((&promptui.Select{
 		Label: "Do you want to use local bindings or Docker volumes?",
 		Items: []string{
@@ -473,22 +488,33 @@ func prompts() {
 		dcConfig.Services["minio"].Volumes = []string{
 			"./minio/data:/data",
 		}
-		dcConfig.Services["mysql"].Volumes = []string{
-			"./mysql/cfg:/etc/mysql",
-			"./mysql/lib:/var/lib/mysql",
+		if dbType == 1 {
+			mysqlSvc.Volumes = []string{
+				"./mysql/cfg:/etc/mysql",
+				"./mysql/lib:/var/lib/mysql",
+			}
+		} else {
+			spSvc.Volumes = append(spSvc.Volumes,
+				"./shinpuru/data:/var/lib/shinpuru")
 		}
 	} else {
 		dcConfig.Volumes = map[string]any{
 			"minio-data": struct{}{},
-			"mysql-cfg":  struct{}{},
-			"mysql-data": struct{}{},
 		}
 		dcConfig.Services["minio"].Volumes = []string{
 			"minio-data:/data",
 		}
-		dcConfig.Services["mysql"].Volumes = []string{
-			"mysql-cfg:/etc/mysql",
-			"mysql-data:/var/lib/mysql",
+		if dbType == 1 {
+			dcConfig.Volumes["mysql-cfg"] = struct{}{}
+			dcConfig.Volumes["mysql-data"] = struct{}{}
+			mysqlSvc.Volumes = []string{
+				"mysql-cfg:/etc/mysql",
+				"mysql-data:/var/lib/mysql",
+			}
+		} else {
+			dcConfig.Volumes["shinpuru-data"] = struct{}{}
+			spSvc.Volumes = append(spSvc.Volumes,
+				"shinpuru-data:/var/lib/shinpuru")
 		}
 	}
 
@@ -502,15 +528,16 @@ func hydrateCredentials() {
 	minioSvc.Environment["MINIO_ACCESS_KEY"] = mustGetRandomKey(32)
 	minioSvc.Environment["MINIO_SECRET_KEY"] = mustGetRandomKey(32)
 
-	msqlSvc := dcConfig.Services["mysql"]
-	msqlSvc.Environment["MYSQL_ROOT_PASSWORD"] = mustGetRandomKey(32)
-
 	spSvc := dcConfig.Services["shinpuru"]
 	spSvc.Environment["SP_WEBSERVER_APITOKENKEY"] = mustGetRandomKey(64)
 	spSvc.Environment["SP_WEBSERVER_ACCESSTOKEN_SECRET"] = mustGetRandomKey(64)
 	spSvc.Environment["SP_STORAGE_MINIO_ACCESSKEY"] = minioSvc.Environment["MINIO_ACCESS_KEY"]
 	spSvc.Environment["SP_STORAGE_MINIO_ACCESSSECRET"] = minioSvc.Environment["MINIO_SECRET_KEY"]
-	spSvc.Environment["SP_DATABASE_MYSQL_PASSWORD"] = msqlSvc.Environment["MYSQL_ROOT_PASSWORD"]
+
+	if _, ok := dcConfig.Services["mysql"]; ok {
+		mysqlSvc.Environment["MYSQL_ROOT_PASSWORD"] = mustGetRandomKey(32)
+		spSvc.Environment["SP_DATABASE_MYSQL_PASSWORD"] = mysqlSvc.Environment["MYSQL_ROOT_PASSWORD"]
+	}
 }
 
 func writeComposeFile() {
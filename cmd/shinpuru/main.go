@@ -26,12 +26,16 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/services/config"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/joinlog"
 	"github.com/zekroTJA/shinpuru/internal/services/karma"
 	"github.com/zekroTJA/shinpuru/internal/services/kvcache"
+	"github.com/zekroTJA/shinpuru/internal/services/linkresolver"
+	"github.com/zekroTJA/shinpuru/internal/services/lockdown"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/services/report"
 	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
 	"github.com/zekroTJA/shinpuru/internal/services/verification"
+	"github.com/zekroTJA/shinpuru/internal/services/votes"
 	"github.com/zekroTJA/shinpuru/internal/services/webserver/auth"
 	"github.com/zekroTJA/shinpuru/internal/util"
 	"github.com/zekroTJA/shinpuru/internal/util/embedded"
@@ -304,7 +308,7 @@ func main() {
 	diBuilder.Add(di.Def{
 		Name: static.DiBackupHandler,
 		Build: func(ctn di.Container) (interface{}, error) {
-			return backup.New(ctn), nil
+			return backup.New(ctn)
 		},
 	})
 
@@ -384,6 +388,14 @@ func main() {
 		},
 	})
 
+	// Initialize media provider
+	diBuilder.Add(di.Def{
+		Name: static.DiMediaProvider,
+		Build: func(ctn di.Container) (interface{}, error) {
+			return inits.InitMediaProvider(ctn), nil
+		},
+	})
+
 	diBuilder.Add(di.Def{
 		Name: static.DiBirthday,
 		Build: func(ctn di.Container) (interface{}, error) {
@@ -391,6 +403,38 @@ func main() {
 		},
 	})
 
+	// Initialize vote expiration service
+	diBuilder.Add(di.Def{
+		Name: static.DiVotes,
+		Build: func(ctn di.Container) (interface{}, error) {
+			return votes.New(ctn), nil
+		},
+	})
+
+	// Initialize antiraid lockdown service
+	diBuilder.Add(di.Def{
+		Name: static.DiLockdown,
+		Build: func(ctn di.Container) (interface{}, error) {
+			return lockdown.New(ctn), nil
+		},
+	})
+
+	// Initialize antiraid join log action service
+	diBuilder.Add(di.Def{
+		Name: static.DiJoinlog,
+		Build: func(ctn di.Container) (interface{}, error) {
+			return joinlog.New(ctn), nil
+		},
+	})
+
+	// Initialize link resolver
+	diBuilder.Add(di.Def{
+		Name: static.DiLinkResolver,
+		Build: func(ctn di.Container) (interface{}, error) {
+			return linkresolver.New(), nil
+		},
+	})
+
 	// Build dependency injection container
 	ctn := diBuilder.Build()
 	// Tear down dependency instances
@@ -70,6 +70,7 @@ func InitDiscordBotSession(container di.Container) (release func()) {
 	}
 
 	listenerInviteBlock := listeners.NewListenerInviteBlock(container)
+	listenerAutomod := listeners.NewListenerAutomod(container)
 	listenerGhostPing := listeners.NewListenerGhostPing(container)
 	listenerColors := listeners.NewColorListener(container)
 
@@ -84,6 +85,7 @@ func InitDiscordBotSession(container di.Container) (release func()) {
 	listenerGuilds := listeners.NewListenerGuildAdd(container)
 	listenerRoleSelects := listeners.NewListenerRoleselect(container)
 	listenerStatus := listeners.NewListenerStatus()
+	listenerAntiraid := listeners.NewListenerAntiraid(container)
 
 	session.AddHandler(listeners.NewListenerReady(container).Handler)
 	session.AddHandler(listeners.NewListenerMemberAdd(container).Handler)
@@ -92,7 +94,8 @@ func InitDiscordBotSession(container di.Container) (release func()) {
 	session.AddHandler(listeners.NewListenerChannelCreate(container).Handler)
 	session.AddHandler(listeners.NewListenerVoiceUpdate(container).Handler)
 	session.AddHandler(discordutil.WrapHandler(listeners.NewListenerKarma(container).Handler))
-	session.AddHandler(discordutil.WrapHandler(listeners.NewListenerAntiraid(container).HandlerMemberAdd))
+	session.AddHandler(discordutil.WrapHandler(listenerAntiraid.HandlerMemberAdd))
+	session.AddHandler(discordutil.WrapHandler(listenerAntiraid.HandlerMessageCreate))
 	session.AddHandler(listeners.NewListenerBotMention(container).Listener)
 	session.AddHandler(listeners.NewListenerDMSync(container).Handler)
 	session.AddHandler(discordutil.WrapHandler(listeners.NewListenerPostBan(container).Handler))
@@ -101,6 +104,8 @@ func InitDiscordBotSession(container di.Container) (release func()) {
 	session.AddHandler(listenerGhostPing.HandlerMessageDelete)
 	session.AddHandler(discordutil.WrapHandler(listenerInviteBlock.HandlerMessageSend))
 	session.AddHandler(discordutil.WrapHandler(listenerInviteBlock.HandlerMessageEdit))
+	session.AddHandler(discordutil.WrapHandler(listenerAutomod.HandlerMessageSend))
+	session.AddHandler(discordutil.WrapHandler(listenerAutomod.HandlerMessageEdit))
 
 	session.AddHandler(listenerJDoodle.HandlerMessageCreate)
 	session.AddHandler(listenerJDoodle.HandlerMessageUpdate)
@@ -98,6 +98,8 @@ func InitCommandHandler(container di.Container) (k *ken.Ken, err error) {
 		new(slashcommands.Ban),
 		new(slashcommands.Roleselect),
 		new(slashcommands.Modnot),
+		new(slashcommands.Escalation),
+		new(slashcommands.Antiraid),
 	)
 	if err != nil {
 		return
@@ -1,22 +1,23 @@
 package inits
 
 import (
+	"fmt"
 	"strings"
 
 	goredis "github.com/go-redis/redis/v8"
 	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/config"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/database/mysql"
+	"github.com/zekroTJA/shinpuru/internal/services/database/postgres"
 	"github.com/zekroTJA/shinpuru/internal/services/database/redis"
+	"github.com/zekroTJA/shinpuru/internal/services/database/sqlite"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekrotja/rogu/log"
 )
 
 func InitDatabase(container di.Container) database.Database {
-	var db database.Database
-	var err error
-
 	cfg := container.Get(static.DiConfig).(config.Provider)
 
 	log := log.Tagged("Database")
@@ -24,14 +25,7 @@ func InitDatabase(container di.Container) database.Database {
 	drv := strings.ToLower(cfg.Config().Database.Type)
 	log.Info().Field("driver", drv).Msg("Initializing database ...")
 
-	switch drv {
-	case "mysql", "mariadb":
-		db = mysql.New()
-		err = db.Connect(cfg.Config().Database.MySql)
-	default:
-		log.Fatal().Field("driver", drv).Msg("Unsupported database driver")
-	}
-
+	db, err := OpenDatabase(cfg.Config().Database)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed connecting to database")
 	}
@@ -58,3 +52,23 @@ func InitDatabase(container di.Container) database.Database {
 
 	return db
 }
+
+// OpenDatabase creates a new instance of the database
+// driver specified in cfg and connects it using the
+// credentials of the respective configuration slot.
+func OpenDatabase(cfg models.DatabaseType) (db database.Database, err error) {
+	switch strings.ToLower(cfg.Type) {
+	case "mysql", "mariadb":
+		db = mysql.New()
+		err = db.Connect(cfg.MySql)
+	case "postgres", "postgresql":
+		db = postgres.New()
+		err = db.Connect(cfg.Postgres)
+	case "sqlite", "sqlite3":
+		db = sqlite.New()
+		err = db.Connect(cfg.Sqlite)
+	default:
+		err = fmt.Errorf("unsupported database driver: %s", cfg.Type)
+	}
+	return
+}
@@ -0,0 +1,22 @@
+package inits
+
+import (
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/services/config"
+	"github.com/zekroTJA/shinpuru/internal/services/media"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekrotja/rogu/log"
+)
+
+func InitMediaProvider(container di.Container) media.Provider {
+	cfg := container.Get(static.DiConfig).(config.Provider)
+
+	log := log.Tagged("Media")
+
+	if apiKey := cfg.Config().Giphy.APIKey; apiKey != "" {
+		log.Info().Field("provider", "giphy").Msg("Initializing media provider ...")
+		return media.NewGiphy(apiKey)
+	}
+
+	return media.Noop{}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/services/config"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/karma"
+	"github.com/zekroTJA/shinpuru/internal/services/lockdown"
 	"github.com/zekroTJA/shinpuru/internal/services/report"
 	"github.com/zekroTJA/shinpuru/internal/services/scheduler"
 	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
@@ -32,9 +34,11 @@ func InitScheduler(container di.Container) scheduler.Provider {
 	gl := container.Get(static.DiGuildLog).(guildlog.Logger)
 	vs := container.Get(static.DiVerification).(verification.Provider)
 	bd := container.Get(static.DiBirthday).(*birthday.BirthdayService)
+	ks := container.Get(static.DiKarma).(*karma.Service)
 	s := container.Get(static.DiDiscordSession).(*discordgo.Session)
 	st := container.Get(static.DiState).(dgrs.IState)
 	tp := container.Get(static.DiTimeProvider).(timeprovider.Provider)
+	ld := container.Get(static.DiLockdown).(lockdown.Provider)
 
 	shardID, shardTotal := discordutil.GetShardOfSession(s)
 
@@ -83,6 +87,23 @@ func InitScheduler(container di.Container) scheduler.Provider {
 			}
 		})
 
+	twitchEventSub := tnw != nil && tnw.EventSubEnabled() && (shardTotal <= 1 || shardID == 0)
+	syncTwitchSubscriptions := func() {
+		if err := tnw.SyncSubscriptions(); err != nil {
+			log.Error().Err(err).Msg("Failed syncing twitch eventsub subscriptions")
+		}
+	}
+	schedule(log, sched, "twitch eventsub sync",
+		func() string {
+			if !twitchEventSub {
+				return ""
+			}
+			return "@every 10m"
+		}, syncTwitchSubscriptions)
+	if twitchEventSub {
+		go syncTwitchSubscriptions()
+	}
+
 	schedule(log, sched, "report expiration",
 		func() string {
 			if shardTotal > 1 && shardID != 0 {
@@ -120,6 +141,9 @@ func InitScheduler(container di.Container) scheduler.Provider {
 			return "@every 1h"
 		}, antiraid.FlushExpired(db, gl, tp))
 
+	schedule(log, sched, "antiraid lockdown rollback",
+		staticSpec("@every 30s"), ld.RollbackExpired)
+
 	schedule(log, sched, "birthday notifications",
 		func() string {
 			return "0 0 * * * *"
@@ -127,6 +151,32 @@ func InitScheduler(container di.Container) scheduler.Provider {
 			bd.Schedule()
 		})
 
+	schedule(log, sched, "karma decay",
+		func() string {
+			if shardTotal > 1 && shardID != 0 {
+				return ""
+			}
+			return "0 0 3 * * *"
+		},
+		func() {
+			if err := ks.Decay(); err != nil {
+				log.Error().Err(err).Msg("Failed applying karma decay")
+			}
+		})
+
+	schedule(log, sched, "karma temp role expiration",
+		func() string {
+			if shardTotal > 1 && shardID != 0 {
+				return ""
+			}
+			return "@every 1m"
+		},
+		func() {
+			if err := ks.ExpireTempRoles(); err != nil {
+				log.Error().Err(err).Msg("Failed expiring karma temp roles")
+			}
+		})
+
 	schedule(log, sched, "guild membercount refresh",
 		staticSpec("@every 24h"),
 		func() {
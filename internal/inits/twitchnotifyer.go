@@ -23,6 +23,19 @@ func InitTwitchNotifyWorker(container di.Container) *twitchnotify.NotifyWorker {
 	log := log.Tagged("TwitchNotify")
 	log.Info().Msg("Initializing twitch notifications ...")
 
+	var eventSub twitchnotify.EventSubConfig
+	if esCfg := cfg.Config().TwitchApp.EventSub; esCfg.Secret != "" {
+		eventSub.Secret = esCfg.Secret
+		eventSub.CallbackURL = esCfg.CallbackURL
+		if eventSub.CallbackURL == "" {
+			eventSub.CallbackURL = cfg.Config().WebServer.PublicAddr + static.EndpointTwitchEventSub
+		}
+		if !cfg.Config().WebServer.Enabled {
+			log.Warn().Msg("EventSub is configured but the web server is disabled; falling back to polling")
+			eventSub = twitchnotify.EventSubConfig{}
+		}
+	}
+
 	tnw, err := twitchnotify.New(
 		twitchnotify.Credentials{
 			ClientID:     cfg.Config().TwitchApp.ClientID,
@@ -32,11 +45,12 @@ func InitTwitchNotifyWorker(container di.Container) *twitchnotify.NotifyWorker {
 		listener.HandlerWentOffline,
 		twitchnotify.Config{
 			TimerDelay: 0,
+			EventSub:   eventSub,
 		},
 	)
 
 	if err != nil {
-		log.Fatal().Err(err).Msg("Twitch app credentials are invalid")
+		log.Fatal().Err(err).Msg("Failed initializing Twitch notify worker")
 	}
 
 	notifies, err := db.GetAllTwitchNotifies("")
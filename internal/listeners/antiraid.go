@@ -2,14 +2,17 @@ package listeners
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
 	"github.com/zekroTJA/ratelimit"
+	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/lockdown"
 	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
 	"github.com/zekroTJA/shinpuru/internal/services/verification"
 	"github.com/zekroTJA/shinpuru/internal/util/antiraid"
@@ -23,7 +26,9 @@ import (
 )
 
 const (
-	arTriggerCleanupDuration = 1 * time.Hour
+	arTriggerCleanupDuration  = 1 * time.Hour
+	arActivityCleanupDuration = 1 * time.Minute
+	arMaxRecentJoins          = 100
 )
 
 type guildState struct {
@@ -36,12 +41,17 @@ type ListenerAntiraid struct {
 	gl  guildlog.Logger
 	st  dgrs.IState
 	vs  verification.Provider
+	ld  lockdown.Provider
 	tp  timeprovider.Provider
 	log rogu.Logger
 
 	mtx         sync.Mutex
 	guildStates map[string]*guildState
 	triggers    *timedmap.TimedMap
+
+	sigMtx      sync.Mutex
+	recentJoins map[string][]antiraid.Join
+	activities  *timedmap.TimedMap
 }
 
 func NewListenerAntiraid(container di.Container) *ListenerAntiraid {
@@ -49,9 +59,12 @@ func NewListenerAntiraid(container di.Container) *ListenerAntiraid {
 		db:          container.Get(static.DiDatabase).(database.Database),
 		guildStates: make(map[string]*guildState),
 		triggers:    timedmap.New(arTriggerCleanupDuration),
+		recentJoins: make(map[string][]antiraid.Join),
+		activities:  timedmap.New(arActivityCleanupDuration),
 		gl:          container.Get(static.DiGuildLog).(guildlog.Logger).Section("antiraid"),
 		st:          container.Get(static.DiState).(dgrs.IState),
 		vs:          container.Get(static.DiVerification).(verification.Provider),
+		ld:          container.Get(static.DiLockdown).(lockdown.Provider),
 		tp:          container.Get(static.DiTimeProvider).(timeprovider.Provider),
 		log:         log.Tagged("Antiraid"),
 	}
@@ -72,6 +85,9 @@ func (l *ListenerAntiraid) addToJoinlog(e *discordgo.GuildMemberAdd) {
 }
 
 func (l *ListenerAntiraid) HandlerMemberAdd(s discordutil.ISession, e *discordgo.GuildMemberAdd) {
+	l.evaluateJoin(s, e)
+	l.recordLockdownJoin(e)
+
 	l.mtx.Lock()
 	defer l.mtx.Unlock()
 
@@ -117,12 +133,27 @@ func (l *ListenerAntiraid) HandlerMemberAdd(s discordutil.ISession, e *discordgo
 		return
 	}
 
-	verificationLvl := discordgo.VerificationLevelVeryHigh
-	_, err := s.GuildEdit(e.GuildID, &discordgo.GuildParams{
-		VerificationLevel: &verificationLvl,
-	})
+	lockdownStarted, lockdownCreated, err := l.startLockdown(e.GuildID)
 	if err != nil {
-		l.log.Error().Err(err).Fields("gid", e.GuildID).Msg("Failed setting guild verification level")
+		l.log.Error().Err(err).Fields("gid", e.GuildID).Msg("Failed starting lockdown")
+		l.gl.Errorf(e.GuildID, "Failed starting lockdown: %s", err.Error())
+	}
+
+	if lockdownCreated {
+		l.recordLockdownJoin(e)
+	} else if !lockdownStarted {
+		verificationLvl := discordgo.VerificationLevelVeryHigh
+		_, err = s.GuildEdit(e.GuildID, &discordgo.GuildParams{
+			VerificationLevel: &verificationLvl,
+		})
+		if err != nil {
+			l.log.Error().Err(err).Fields("gid", e.GuildID).Msg("Failed setting guild verification level")
+		}
+	}
+
+	measures := "the guilds verification level was raised to `very high`"
+	if lockdownStarted {
+		measures = "a lockdown has been started"
 	}
 
 	guild, err := l.st.Guild(e.GuildID, true)
@@ -135,10 +166,9 @@ func (l *ListenerAntiraid) HandlerMemberAdd(s discordutil.ISession, e *discordgo
 		"Following guild you are admin on is currently being raided!\n\n"+
 			"**%s (`%s`)**\n\n"+
 			"Because an atypical burst of members joined the guild, "+
-			"the guilds verification level was raised to `very high` and all admins "+
-			"were informed.\n\n"+
+			"%s and all admins were informed.\n\n"+
 			"Also, all joining users from now are saved in a log list for the following "+
-			"24 hours. This log is saved for 48 hours toal.", guild.Name, e.GuildID)
+			"24 hours. This log is saved for 48 hours toal.", guild.Name, e.GuildID, measures)
 	if err != nil {
 		alertDescrition = fmt.Sprintf("%s\n\n"+
 			"**Attention:** Failed to raise guilds verification level because "+
@@ -179,11 +209,10 @@ func (l *ListenerAntiraid) HandlerMemberAdd(s discordutil.ISession, e *discordgo
 		s.ChannelMessageSendEmbed(chanID, &discordgo.MessageEmbed{
 			Color: static.ColorEmbedOrange,
 			Title: "⚠ GUILD RAID ALERT",
-			Description: "Because an atypical burst of members joined the guild, " +
-				"the guilds verification level was raised to `very high` and all admins " +
-				"were informed.\n\n" +
-				"Also, all joining users from now are saved in a log list for the following " +
-				"24 hours. This log is saved for 48 hours total.",
+			Description: fmt.Sprintf("Because an atypical burst of members joined the guild, "+
+				"%s and all admins were informed.\n\n"+
+				"Also, all joining users from now are saved in a log list for the following "+
+				"24 hours. This log is saved for 48 hours total.", measures),
 		})
 	}
 
@@ -203,6 +232,237 @@ func (l *ListenerAntiraid) HandlerMemberAdd(s discordutil.ISession, e *discordgo
 	l.addToJoinlog(e)
 }
 
+// HandlerMessageCreate tracks the messages of members who
+// recently joined the guild and applies the response of the
+// spam signal when its limits are reached.
+func (l *ListenerAntiraid) HandlerMessageCreate(s discordutil.ISession, e *discordgo.MessageCreate) {
+	if e.GuildID == "" || e.Author == nil || e.Author.Bot {
+		return
+	}
+
+	key := activityKey(e.GuildID, e.Author.ID)
+	act, ok := l.activities.GetValue(key).(*antiraid.Activity)
+	if !ok {
+		return
+	}
+
+	signals, ok := l.getSignals(e.GuildID)
+	if !ok || signals.Spam.Response == models.AntiraidResponseNone {
+		l.activities.Remove(key)
+		return
+	}
+
+	mentions := len(e.Mentions) + len(e.MentionRoles)
+	if e.MentionEveryone {
+		mentions++
+	}
+
+	l.sigMtx.Lock()
+	act.Messages++
+	if mentions > act.MaxMentions {
+		act.MaxMentions = mentions
+	}
+	res := antiraid.EvaluateSpam(signals.Spam, *act)
+	l.sigMtx.Unlock()
+
+	if !res.Triggered() {
+		return
+	}
+
+	l.activities.Remove(key)
+
+	if err := s.ChannelMessageDelete(e.ChannelID, e.ID); err != nil {
+		l.log.Error().Err(err).Fields("gid", e.GuildID, "uid", e.Author.ID).Msg("Failed deleting spam message")
+	}
+
+	l.applyDecision(s, e.GuildID, antiraid.NewDecision(e.Author.ID, res), signals)
+}
+
+// evaluateJoin evaluates the account age and similarity
+// signals for the joined member and starts tracking the
+// member's messages for the spam signal.
+func (l *ListenerAntiraid) evaluateJoin(s discordutil.ISession, e *discordgo.GuildMemberAdd) {
+	if e.User == nil || e.User.Bot {
+		return
+	}
+
+	signals, ok := l.getSignals(e.GuildID)
+	if !ok {
+		l.sigMtx.Lock()
+		delete(l.recentJoins, e.GuildID)
+		l.sigMtx.Unlock()
+		return
+	}
+
+	now := l.tp.Now()
+	join := antiraid.Join{
+		UserID:   e.User.ID,
+		Username: e.User.Username,
+		Avatar:   e.User.Avatar,
+		Time:     now,
+	}
+
+	var results []antiraid.SignalResult
+
+	if signals.AccountAge.Response != models.AntiraidResponseNone {
+		created, err := discordutil.GetDiscordSnowflakeCreationTime(e.User.ID)
+		if err != nil {
+			l.log.Error().Err(err).Fields("gid", e.GuildID, "uid", e.User.ID).Msg("Failed getting creation date from user snowflake")
+		} else {
+			results = append(results, antiraid.EvaluateAccountAge(signals.AccountAge, created, now))
+		}
+	}
+
+	l.sigMtx.Lock()
+	if signals.Similarity.Response != models.AntiraidResponseNone {
+		results = append(results, antiraid.EvaluateSimilarity(signals.Similarity, join, l.recentJoins[e.GuildID]))
+	}
+	l.pushRecentJoin(e.GuildID, join, time.Duration(signals.Similarity.Window)*time.Second)
+	l.sigMtx.Unlock()
+
+	decision := antiraid.NewDecision(e.User.ID, results...)
+	if decision.Triggered() {
+		l.applyDecision(s, e.GuildID, decision, signals)
+		if decision.Response >= models.AntiraidResponseKick {
+			return
+		}
+	} else if decision.Relevant() {
+		l.gl.Infof(e.GuildID, "Signals of member %s (%s) below threshold: %s",
+			e.User.String(), e.User.ID, decision.Breakdown())
+	}
+
+	if signals.Spam.Response != models.AntiraidResponseNone {
+		l.activities.Set(activityKey(e.GuildID, e.User.ID), &antiraid.Activity{Joined: now},
+			time.Duration(signals.Spam.Window)*time.Second)
+	}
+}
+
+// pushRecentJoin adds the join to the recent joins of the
+// guild and drops all joins which are older than window.
+// sigMtx must be held by the caller.
+func (l *ListenerAntiraid) pushRecentJoin(gid string, join antiraid.Join, window time.Duration) {
+	joins := l.recentJoins[gid]
+
+	i := 0
+	for i < len(joins) && join.Time.Sub(joins[i].Time) > window {
+		i++
+	}
+	joins = append(joins[i:], join)
+	if len(joins) > arMaxRecentJoins {
+		joins = joins[len(joins)-arMaxRecentJoins:]
+	}
+
+	l.recentJoins[gid] = joins
+}
+
+// applyDecision applies the response of the decision to
+// the member and records the decision in the guild log.
+func (l *ListenerAntiraid) applyDecision(
+	s discordutil.ISession,
+	gid string,
+	decision antiraid.Decision,
+	signals models.AntiraidSignals,
+) {
+	reason := fmt.Sprintf("Antiraid: triggered %s", strings.Join(triggeredSignals(decision), ", "))
+
+	var err error
+	switch decision.Response {
+	case models.AntiraidResponseQuarantine:
+		err = s.GuildMemberRoleAdd(gid, decision.UserID, signals.QuarantineRoleID)
+	case models.AntiraidResponseTimeout:
+		until := l.tp.Now().Add(signals.TimeoutDurationValue())
+		err = s.GuildMemberTimeout(gid, decision.UserID, &until)
+	case models.AntiraidResponseKick:
+		err = s.GuildMemberDeleteWithReason(gid, decision.UserID, reason)
+	case models.AntiraidResponseBan:
+		err = s.GuildBanCreateWithReason(gid, decision.UserID, reason, 1)
+	}
+
+	if err != nil {
+		l.log.Error().Err(err).Fields("gid", gid, "uid", decision.UserID, "response", decision.Response).
+			Msg("Failed applying antiraid response")
+		l.gl.Errorf(gid, "Failed applying response %s to member %s: %s",
+			decision.Response, decision.UserID, err.Error())
+		return
+	}
+
+	l.gl.Warnf(gid, "Applied response %s to member %s: %s",
+		decision.Response, decision.UserID, decision.Breakdown())
+}
+
+// getSignals returns the configured antiraid signals of
+// the guild. ok is false when antiraid is disabled or
+// no signal is configured.
+func (l *ListenerAntiraid) getSignals(gid string) (signals models.AntiraidSignals, ok bool) {
+	state, err := l.db.GetAntiraidState(gid)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		l.log.Error().Err(err).Fields("gid", gid).Msg("Failed getting antiraid state")
+		l.gl.Errorf(gid, "Failed getting antiraid state: %s", err.Error())
+		return
+	}
+	if !state {
+		return
+	}
+
+	signals, err = l.db.GetAntiraidSignals(gid)
+	if err != nil {
+		if !database.IsErrDatabaseNotFound(err) {
+			l.log.Error().Err(err).Fields("gid", gid).Msg("Failed getting antiraid signals")
+			l.gl.Errorf(gid, "Failed getting antiraid signals: %s", err.Error())
+		}
+		return
+	}
+
+	ok = signals.Enabled()
+	return
+}
+
+// startLockdown starts the lockdown of the guild if it
+// is configured to be started automatically on raids.
+// started is true when a lockdown is active afterwards
+// and created is true when it has been started by this
+// call and was not active before.
+func (l *ListenerAntiraid) startLockdown(gid string) (started, created bool, err error) {
+	cfg, err := l.db.GetAntiraidLockdown(gid)
+	if database.IsErrDatabaseNotFound(err) {
+		return false, false, nil
+	}
+	if err != nil || !cfg.Auto {
+		return
+	}
+
+	_, err = l.ld.Start(gid, "")
+	if err == lockdown.ErrActive {
+		return true, false, nil
+	}
+
+	return err == nil, err == nil, err
+}
+
+// recordLockdownJoin records the joined member in the
+// lockdown of the guild, if one is active.
+func (l *ListenerAntiraid) recordLockdownJoin(e *discordgo.GuildMemberAdd) {
+	err := l.ld.RecordJoin(e.GuildID, e.User.ID)
+	if err != nil && err != lockdown.ErrNotActive {
+		l.log.Error().Err(err).Fields("gid", e.GuildID, "uid", e.User.ID).Msg("Failed recording lockdown join")
+		l.gl.Errorf(e.GuildID, "Failed recording lockdown join (%s): %s", e.User.ID, err.Error())
+	}
+}
+
+func triggeredSignals(d antiraid.Decision) []string {
+	names := make([]string, 0, len(d.Results))
+	for _, r := range d.Results {
+		if r.Triggered() {
+			names = append(names, r.Signal)
+		}
+	}
+	return names
+}
+
+func activityKey(gid, uid string) string {
+	return gid + ":" + uid
+}
+
 func (l *ListenerAntiraid) getGuildSettings(gid string) (ok bool, limit, burst int) {
 	var err error
 	var state bool
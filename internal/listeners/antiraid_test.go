@@ -11,6 +11,9 @@ import (
 	"github.com/sarulabs/di/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/lockdown"
 	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/mocks"
@@ -22,6 +25,7 @@ type antiraidHandlerMock struct {
 	logger  *mocks.Logger
 	state   *mocks.IState
 	vs      *mocks.VerificationProvider
+	ld      *mocks.LockdownProvider
 	tp      timeprovider.Provider
 
 	ct di.Container
@@ -37,6 +41,7 @@ func getAntiraidHandlerMock(prep ...func(t antiraidHandlerMock)) antiraidHandler
 	t.logger = &mocks.Logger{}
 	t.state = &mocks.IState{}
 	t.vs = &mocks.VerificationProvider{}
+	t.ld = &mocks.LockdownProvider{}
 	t.tp = timeprovider.Time{}
 
 	if len(prep) != 0 {
@@ -53,6 +58,8 @@ func getAntiraidHandlerMock(prep ...func(t antiraidHandlerMock)) antiraidHandler
 	t.db.On("GetGuildModLog", mock.Anything).Return("", nil)
 	t.db.On("GetAntiraidVerification", mock.Anything).Return(false, nil)
 	t.db.On("AddToAntiraidJoinList", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	t.db.On("GetAntiraidSignals", mock.Anything).Return(models.AntiraidSignals{}, database.ErrDatabaseNotFound)
+	t.db.On("GetAntiraidLockdown", mock.Anything).Return(models.AntiraidLockdown{}, database.ErrDatabaseNotFound)
 
 	t.logger.On("Errorf", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
 	t.logger.On("Section", mock.Anything).Return(t.logger)
@@ -80,6 +87,8 @@ func getAntiraidHandlerMock(prep ...func(t antiraidHandlerMock)) antiraidHandler
 
 	t.vs.On("SetEnabled", mock.Anything, mock.Anything).Return(nil)
 
+	t.ld.On("RecordJoin", mock.Anything, mock.Anything).Return(lockdown.ErrNotActive)
+
 	ct, _ := di.NewBuilder()
 	ct.Add(di.Def{
 		Name:  static.DiDatabase,
@@ -97,6 +106,10 @@ func getAntiraidHandlerMock(prep ...func(t antiraidHandlerMock)) antiraidHandler
 		Name:  static.DiVerification,
 		Build: func(ctn di.Container) (interface{}, error) { return t.vs, nil },
 	})
+	ct.Add(di.Def{
+		Name:  static.DiLockdown,
+		Build: func(ctn di.Container) (interface{}, error) { return t.ld, nil },
+	})
 	ct.Add(di.Def{
 		Name:  static.DiTimeProvider,
 		Build: func(ctn di.Container) (interface{}, error) { return t.tp, nil },
@@ -186,3 +199,127 @@ func TestHandleMemberAdd_Threadsafety(t *testing.T) {
 
 	time.Sleep(5 * time.Second)
 }
+
+func TestHandleMemberAdd_AccountAge(t *testing.T) {
+	m := getAntiraidHandlerMock(func(m antiraidHandlerMock) {
+		m.db.On("GetAntiraidBurst", mock.Anything).Return(100, nil)
+		m.db.On("GetAntiraidSignals", mock.Anything).Return(models.AntiraidSignals{
+			AccountAge: models.AntiraidAccountAgeSignal{
+				Response: models.AntiraidResponseKick,
+				MinAge:   3600,
+			},
+		}, nil)
+	})
+	m.session.On("GuildMemberDeleteWithReason", "test-guild", mock.Anything, mock.Anything).Return(nil)
+	m.logger.On("Warnf", "test-guild", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	l := NewListenerAntiraid(m.ct)
+
+	e := m.getEvent()
+	l.HandlerMemberAdd(m.session, e)
+
+	m.session.AssertCalled(t, "GuildMemberDeleteWithReason", "test-guild", e.User.ID, mock.Anything)
+	m.logger.AssertNumberOfCalls(t, "Warnf", 1)
+}
+
+func TestHandleMessageCreate_Spam(t *testing.T) {
+	m := getAntiraidHandlerMock(func(m antiraidHandlerMock) {
+		m.db.On("GetAntiraidBurst", mock.Anything).Return(100, nil)
+		m.db.On("GetAntiraidSignals", mock.Anything).Return(models.AntiraidSignals{
+			Spam: models.AntiraidSpamSignal{
+				Response: models.AntiraidResponseTimeout,
+				Window:   60,
+				Messages: 3,
+				Mentions: 5,
+			},
+			TimeoutDuration: 600,
+		}, nil)
+	})
+	m.session.On("GuildMemberTimeout", "test-guild", mock.Anything, mock.Anything).Return(nil)
+	m.session.On("ChannelMessageDelete", mock.Anything, mock.Anything).Return(nil)
+	m.logger.On("Warnf", "test-guild", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	l := NewListenerAntiraid(m.ct)
+
+	e := m.getEvent()
+	l.HandlerMemberAdd(m.session, e)
+
+	msg := func(mentions int) *discordgo.MessageCreate {
+		mc := &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID:        "test-msg",
+			ChannelID: "test-ch",
+			GuildID:   "test-guild",
+			Author:    e.User,
+		}}
+		for i := 0; i < mentions; i++ {
+			mc.Mentions = append(mc.Mentions, &discordgo.User{})
+		}
+		return mc
+	}
+
+	l.HandlerMessageCreate(m.session, msg(0))
+	l.HandlerMessageCreate(m.session, msg(1))
+	m.session.AssertNotCalled(t, "GuildMemberTimeout", mock.Anything, mock.Anything, mock.Anything)
+
+	l.HandlerMessageCreate(m.session, msg(5))
+	m.session.AssertNumberOfCalls(t, "GuildMemberTimeout", 1)
+	m.session.AssertNumberOfCalls(t, "ChannelMessageDelete", 1)
+
+	// Tracking of the member ends after the response was applied.
+	l.HandlerMessageCreate(m.session, msg(5))
+	m.session.AssertNumberOfCalls(t, "GuildMemberTimeout", 1)
+}
+
+func TestHandleMemberAdd_Lockdown(t *testing.T) {
+	m := getAntiraidHandlerMock(func(m antiraidHandlerMock) {
+		m.db.On("GetAntiraidBurst", mock.Anything).Return(1, nil)
+		m.db.On("GetAntiraidLockdown", mock.Anything).Return(models.AntiraidLockdown{Auto: true}, nil)
+	})
+
+	active := false
+	m.ld.ExpectedCalls = nil
+	m.ld.On("Start", "test-guild", "").Run(func(args mock.Arguments) {
+		active = true
+	}).Return(models.AntiraidLockdownState{}, nil)
+	m.ld.On("RecordJoin", "test-guild", mock.Anything).Return(func(string, string) error {
+		if !active {
+			return lockdown.ErrNotActive
+		}
+		return nil
+	})
+
+	l := NewListenerAntiraid(m.ct)
+
+	l.HandlerMemberAdd(m.session, m.getEvent())
+	l.HandlerMemberAdd(m.session, m.getEvent())
+	l.HandlerMemberAdd(m.session, m.getEvent())
+
+	m.ld.AssertNumberOfCalls(t, "Start", 1)
+	m.session.AssertNotCalled(t, "GuildEdit", mock.Anything, mock.Anything)
+	// The member triggering the lockdown is recorded
+	// after the lockdown has been started.
+	m.ld.AssertNumberOfCalls(t, "RecordJoin", 4)
+}
+
+func TestHandleMemberAdd_LockdownActive(t *testing.T) {
+	m := getAntiraidHandlerMock(func(m antiraidHandlerMock) {
+		m.db.On("GetAntiraidBurst", mock.Anything).Return(1, nil)
+		m.db.On("GetAntiraidLockdown", mock.Anything).Return(models.AntiraidLockdown{Auto: true}, nil)
+	})
+
+	m.ld.ExpectedCalls = nil
+	m.ld.On("Start", "test-guild", "").Return(models.AntiraidLockdownState{}, lockdown.ErrActive)
+	m.ld.On("RecordJoin", "test-guild", mock.Anything).Return(nil)
+
+	l := NewListenerAntiraid(m.ct)
+
+	l.HandlerMemberAdd(m.session, m.getEvent())
+	l.HandlerMemberAdd(m.session, m.getEvent())
+	l.HandlerMemberAdd(m.session, m.getEvent())
+
+	m.ld.AssertNumberOfCalls(t, "Start", 1)
+	m.session.AssertNotCalled(t, "GuildEdit", mock.Anything, mock.Anything)
+	// Joins are recorded only once each because the
+	// lockdown was already active before.
+	m.ld.AssertNumberOfCalls(t, "RecordJoin", 3)
+}
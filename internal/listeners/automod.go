@@ -0,0 +1,184 @@
+package listeners
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/linkresolver"
+	"github.com/zekroTJA/shinpuru/internal/services/report"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/util/automod"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
+	"github.com/zekroTJA/timedmap"
+	"github.com/zekrotja/dgrs"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
+)
+
+const (
+	amHistoryCleanupDuration = 1 * time.Minute
+	amMaxHistory             = 2 * models.MaxAutomodDuplicates
+)
+
+type ListenerAutomod struct {
+	db  database.Database
+	gl  guildlog.Logger
+	rep report.Provider
+	st  dgrs.IState
+	tp  timeprovider.Provider
+	lr  linkresolver.Provider
+	log rogu.Logger
+
+	mtx     sync.Mutex
+	history *timedmap.TimedMap
+	regex   *automod.RegexCache
+}
+
+func NewListenerAutomod(container di.Container) *ListenerAutomod {
+	return &ListenerAutomod{
+		db:      container.Get(static.DiDatabase).(database.Database),
+		gl:      container.Get(static.DiGuildLog).(guildlog.Logger).Section("automod"),
+		rep:     container.Get(static.DiReport).(report.Provider),
+		st:      container.Get(static.DiState).(dgrs.IState),
+		tp:      container.Get(static.DiTimeProvider).(timeprovider.Provider),
+		lr:      container.Get(static.DiLinkResolver).(linkresolver.Provider),
+		log:     log.Tagged("Automod"),
+		history: timedmap.New(amHistoryCleanupDuration),
+		regex:   automod.NewRegexCache(),
+	}
+}
+
+func (l *ListenerAutomod) HandlerMessageSend(s discordutil.ISession, e *discordgo.MessageCreate) {
+	l.check(s, e.Message, true)
+}
+
+func (l *ListenerAutomod) HandlerMessageEdit(s discordutil.ISession, e *discordgo.MessageUpdate) {
+	// Edited messages are not checked for duplicates because
+	// the original message is already part of the history.
+	l.check(s, e.Message, false)
+}
+
+func (l *ListenerAutomod) check(s discordutil.ISession, msg *discordgo.Message, created bool) {
+	if msg.Author == nil || msg.Author.Bot || msg.WebhookID != "" || msg.GuildID == "" {
+		return
+	}
+
+	rules, err := l.db.GetGuildAutomodRules(msg.GuildID)
+	if database.IsErrDatabaseNotFound(err) {
+		return
+	}
+	if err != nil {
+		l.log.Error().Err(err).Field("gid", msg.GuildID).Msg("Failed getting automod rules")
+		return
+	}
+
+	now := l.tp.Now()
+	amMsg := automod.Message{
+		Content: msg.Content,
+		Time:    now,
+	}
+	if created {
+		amMsg.History = l.pushHistory(msg, now)
+	}
+
+	var roles []string
+	if msg.Member != nil {
+		roles = msg.Member.Roles
+	} else if member, err := l.st.Member(msg.GuildID, msg.Author.ID); err == nil {
+		roles = member.Roles
+	}
+
+	// Only the first matching rule is applied so that
+	// a single message does not cause multiple reports.
+	for _, rule := range rules {
+		if !rule.Enabled || !rule.AppliesToChannel(msg.ChannelID) || rule.IsExempt(roles) {
+			continue
+		}
+		if !created && rule.Matcher.Type == models.AutomodMatchDuplicates {
+			continue
+		}
+
+		ok, reason, err := l.regex.Match(rule, amMsg, l.lr.Resolve)
+		if err != nil {
+			l.log.Error().Err(err).Fields("gid", msg.GuildID, "rule", rule.ID).Msg("Failed matching automod rule")
+			l.gl.Errorf(msg.GuildID, "Failed matching automod rule '%s': %s", rule.Name, err.Error())
+			continue
+		}
+		if ok {
+			l.apply(s, msg, rule, reason)
+			return
+		}
+	}
+}
+
+// pushHistory records the message in the history of its
+// author and returns the previously recorded messages.
+func (l *ListenerAutomod) pushHistory(msg *discordgo.Message, now time.Time) []automod.HistoryEntry {
+	key := msg.GuildID + ":" + msg.Author.ID
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	prev, _ := l.history.GetValue(key).([]automod.HistoryEntry)
+
+	history := make([]automod.HistoryEntry, 0, len(prev)+1)
+	if len(prev) >= amMaxHistory {
+		prev = prev[len(prev)-amMaxHistory+1:]
+	}
+	history = append(history, prev...)
+	history = append(history, automod.HistoryEntry{Content: msg.Content, Time: now})
+	l.history.Set(key, history, models.MaxAutomodDuplicateWindow)
+
+	return prev
+}
+
+func (l *ListenerAutomod) apply(s discordutil.ISession, msg *discordgo.Message, rule models.AutomodRule, reason string) {
+	self, err := l.st.SelfUser()
+	if err != nil {
+		l.log.Error().Err(err).Msg("Failed getting self user")
+		return
+	}
+
+	rep := models.Report{
+		GuildID:    msg.GuildID,
+		ExecutorID: self.ID,
+		VictimID:   msg.Author.ID,
+		Msg:        fmt.Sprintf("Automod rule '%s': %s", rule.Name, reason),
+	}
+
+	for _, action := range rule.Actions {
+		var err error
+		switch action.Type {
+		case models.AutomodActionDelete:
+			err = s.ChannelMessageDelete(msg.ChannelID, msg.ID)
+		case models.AutomodActionWarn:
+			rep.Type = models.TypeWarn
+			_, err = l.rep.PushReport(rep)
+		case models.AutomodActionTimeout:
+			timeout := l.tp.Now().Add(action.DurationValue())
+			muteRep := rep
+			muteRep.Timeout = &timeout
+			_, err = l.rep.PushMute(muteRep)
+		case models.AutomodActionKick:
+			_, err = l.rep.PushKick(rep)
+		}
+
+		if err != nil {
+			l.log.Error().Err(err).Fields(
+				"gid", msg.GuildID,
+				"uid", msg.Author.ID,
+				"rule", rule.ID,
+				"action", action.Type,
+			).Msg("Failed applying automod action")
+			l.gl.Errorf(msg.GuildID, "Failed applying action %s of automod rule '%s' to %s: %s",
+				action.Type, rule.Name, msg.Author.ID, err.Error())
+		}
+	}
+}
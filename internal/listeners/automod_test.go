@@ -0,0 +1,215 @@
+package listeners
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sarulabs/di/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/mocks"
+)
+
+type automodMock struct {
+	session *mocks.ISession
+	db      *mocks.Database
+	logger  *mocks.Logger
+	rep     *mocks.ReportProvider
+	st      *mocks.IState
+	tp      *mocks.TimeProvider
+	lr      *mocks.LinkResolverProvider
+
+	ct di.Container
+}
+
+func getAutomodMock(f ...func(m automodMock)) automodMock {
+	var t automodMock
+
+	t.session = &mocks.ISession{}
+	t.db = &mocks.Database{}
+	t.logger = &mocks.Logger{}
+	t.rep = &mocks.ReportProvider{}
+	t.st = &mocks.IState{}
+	t.tp = &mocks.TimeProvider{}
+	t.lr = &mocks.LinkResolverProvider{}
+
+	t.logger.On("Errorf", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	t.logger.On("Section", mock.Anything).Return(t.logger)
+
+	if len(f) != 0 {
+		f[0](t)
+	}
+
+	ct, _ := di.NewBuilder()
+	ct.Add(
+		di.Def{
+			Name:  static.DiDatabase,
+			Build: func(ctn di.Container) (interface{}, error) { return t.db, nil },
+		},
+		di.Def{
+			Name:  static.DiGuildLog,
+			Build: func(ctn di.Container) (interface{}, error) { return t.logger, nil },
+		},
+		di.Def{
+			Name:  static.DiReport,
+			Build: func(ctn di.Container) (interface{}, error) { return t.rep, nil },
+		},
+		di.Def{
+			Name:  static.DiState,
+			Build: func(ctn di.Container) (interface{}, error) { return t.st, nil },
+		},
+		di.Def{
+			Name:  static.DiTimeProvider,
+			Build: func(ctn di.Container) (interface{}, error) { return t.tp, nil },
+		},
+		di.Def{
+			Name:  static.DiLinkResolver,
+			Build: func(ctn di.Container) (interface{}, error) { return t.lr, nil },
+		},
+	)
+
+	t.ct = ct.Build()
+
+	return t
+}
+
+func TestAutomodHandlerMessageSend(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	timeout := now.Add(time.Minute)
+
+	rules := []models.AutomodRule{
+		{
+			ID:   1,
+			Name: "disabled",
+			Matcher: models.AutomodMatcher{
+				Type:  models.AutomodMatchWords,
+				Words: []string{"foo"},
+			},
+			Actions: []models.AutomodAction{{Type: models.AutomodActionKick}},
+		},
+		{
+			ID:      2,
+			Name:    "words",
+			Enabled: true,
+			Matcher: models.AutomodMatcher{
+				Type:  models.AutomodMatchWords,
+				Words: []string{"badword"},
+			},
+			Channels:    []string{"channel-id"},
+			ExemptRoles: []string{"role-exempt"},
+			Actions: []models.AutomodAction{
+				{Type: models.AutomodActionDelete},
+				{Type: models.AutomodActionWarn},
+			},
+		},
+		{
+			ID:      3,
+			Name:    "duplicates",
+			Enabled: true,
+			Matcher: models.AutomodMatcher{
+				Type:      models.AutomodMatchDuplicates,
+				Threshold: 2,
+				Window:    60,
+			},
+			Actions: []models.AutomodAction{
+				{Type: models.AutomodActionTimeout, Duration: 60},
+			},
+		},
+		{
+			ID:      4,
+			Name:    "links",
+			Enabled: true,
+			Matcher: models.AutomodMatcher{
+				Type:    models.AutomodMatchLinks,
+				Domains: []string{"evil.com"},
+			},
+			Actions: []models.AutomodAction{
+				{Type: models.AutomodActionKick},
+			},
+		},
+	}
+
+	m := getAutomodMock(func(t automodMock) {
+		t.db.On("GetGuildAutomodRules", "guild-id").Return(rules, nil)
+
+		t.session.On("ChannelMessageDelete", mock.Anything, mock.Anything).Return(nil)
+
+		t.st.On("SelfUser").Return(&discordgo.User{ID: "self-id"}, nil)
+		t.st.On("Member", "guild-id", mock.Anything).Return(&discordgo.Member{}, nil)
+		t.tp.On("Now").Return(now)
+		t.lr.On("Resolve", "https://short.link/abc").
+			Return([]string{"https://short.link/abc", "https://evil.com"}, nil)
+
+		t.rep.On("PushReport", mock.Anything).Return(models.Report{}, nil)
+		t.rep.On("PushMute", mock.Anything).Return(models.Report{}, nil)
+		t.rep.On("PushKick", mock.Anything).Return(models.Report{}, nil)
+	})
+
+	l := NewListenerAutomod(m.ct)
+
+	getMessage := func(id, user, channel, content string, roles ...string) *discordgo.Message {
+		return &discordgo.Message{
+			ID:        id,
+			Content:   content,
+			ChannelID: channel,
+			GuildID:   "guild-id",
+			Author:    &discordgo.User{ID: user},
+			Member:    &discordgo.Member{Roles: roles},
+		}
+	}
+	send := func(msg *discordgo.Message) {
+		l.HandlerMessageSend(m.session, &discordgo.MessageCreate{Message: msg})
+	}
+
+	send(getMessage("1", "user-1", "channel-id", "this is a b4dw0rd"))
+	send(getMessage("2", "user-2", "other-channel-id", "this is a badword"))
+	send(getMessage("3", "user-3", "channel-id", "this is a badword", "role-exempt"))
+	send(getMessage("4", "user-4", "channel-id", "foo"))
+	send(getMessage("5", "user-5", "channel-id", "hello"))
+	send(getMessage("6", "user-5", "channel-id", "Hello"))
+	send(getMessage("7", "user-6", "channel-id", "look at https://short.link/abc"))
+
+	bot := getMessage("8", "user-bot", "channel-id", "badword")
+	bot.Author.Bot = true
+	send(bot)
+
+	send(getMessage("9", "user-7", "channel-id", "hi"))
+	edited := getMessage("9", "user-7", "channel-id", "hi")
+	edited.Member = nil
+	l.HandlerMessageEdit(m.session, &discordgo.MessageUpdate{Message: edited})
+
+	m.session.AssertNumberOfCalls(t, "ChannelMessageDelete", 1)
+	m.session.AssertCalled(t, "ChannelMessageDelete", "channel-id", "1")
+
+	m.rep.AssertNumberOfCalls(t, "PushReport", 1)
+	m.rep.AssertCalled(t, "PushReport", models.Report{
+		GuildID:    "guild-id",
+		ExecutorID: "self-id",
+		VictimID:   "user-1",
+		Msg:        "Automod rule 'words': contains blocked word \"badword\"",
+		Type:       models.TypeWarn,
+	})
+
+	m.rep.AssertNumberOfCalls(t, "PushMute", 1)
+	m.rep.AssertCalled(t, "PushMute", models.Report{
+		GuildID:    "guild-id",
+		ExecutorID: "self-id",
+		VictimID:   "user-5",
+		Msg:        "Automod rule 'duplicates': sent the same message 2 times",
+		Timeout:    &timeout,
+	})
+
+	m.rep.AssertNumberOfCalls(t, "PushKick", 1)
+	m.rep.AssertCalled(t, "PushKick", models.Report{
+		GuildID:    "guild-id",
+		ExecutorID: "self-id",
+		VictimID:   "user-6",
+		Msg:        "Automod rule 'links': contains link to blocked domain \"evil.com\"",
+	})
+
+	m.st.AssertCalled(t, "Member", "guild-id", "user-7")
+	assert.Len(t, m.lr.Calls, 1)
+}
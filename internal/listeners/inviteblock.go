@@ -2,24 +2,39 @@ package listeners
 
 import (
 	"regexp"
-	"strings"
+	"time"
 
 	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/linkresolver"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
+	"github.com/zekroTJA/shinpuru/internal/services/report"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
 	"github.com/zekroTJA/shinpuru/internal/util"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/discordutil"
-	"github.com/zekroTJA/shinpuru/pkg/httpreq"
+	"github.com/zekroTJA/timedmap"
+	"github.com/zekrotja/dgrs"
 	"github.com/zekrotja/rogu"
 	"github.com/zekrotja/rogu/log"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+const (
+	// ibMaxResolvedLinks is the maximum number of links
+	// of a single message which are resolved.
+	ibMaxResolvedLinks = 5
+	// ibHandledLifetime is the duration for which handled
+	// messages are remembered so that edits of them are
+	// not punished again.
+	ibHandledLifetime = 24 * time.Hour
+)
+
 var (
-	rxInvLink = regexp.MustCompile(`(?i)(?:https?:\/\/)?(?:www\.)?(?:discord\.gg|discord(?:app)?\.com\/invite)\/(.*)`)
+	rxInvLink = regexp.MustCompile(`(?i)(?:https?:\/\/)?(?:www\.)?(?:discord\.gg|discord(?:app)?\.com\/invite)\/([\w-]+)`)
 	rxGenLink = regexp.MustCompile(`(?i)(https?:\/\/)?(www\.)?([\w-\S]+\.)+\w{1,10}\/?[\S]+`)
 )
 
@@ -27,7 +42,13 @@ type ListenerInviteBlock struct {
 	db  database.Database
 	gl  guildlog.Logger
 	pmw permissions.Provider
+	rep report.Provider
+	st  dgrs.IState
+	tp  timeprovider.Provider
+	lr  linkresolver.Provider
 	log rogu.Logger
+
+	handled *timedmap.TimedMap
 }
 
 func NewListenerInviteBlock(container di.Container) *ListenerInviteBlock {
@@ -35,7 +56,13 @@ func NewListenerInviteBlock(container di.Container) *ListenerInviteBlock {
 		db:  container.Get(static.DiDatabase).(database.Database),
 		gl:  container.Get(static.DiGuildLog).(guildlog.Logger).Section("inviteblock"),
 		pmw: container.Get(static.DiPermissions).(permissions.Provider),
+		rep: container.Get(static.DiReport).(report.Provider),
+		st:  container.Get(static.DiState).(dgrs.IState),
+		tp:  container.Get(static.DiTimeProvider).(timeprovider.Provider),
+		lr:  container.Get(static.DiLinkResolver).(linkresolver.Provider),
 		log: log.Tagged("InviteBlock"),
+
+		handled: timedmap.New(1 * time.Hour),
 	}
 }
 
@@ -48,91 +75,169 @@ func (l *ListenerInviteBlock) HandlerMessageEdit(s discordutil.ISession, e *disc
 }
 
 func (l *ListenerInviteBlock) invokeCheck(s discordutil.ISession, msg *discordgo.Message) {
-	cont := msg.Content
-
-	ok, matches := l.checkForInviteLink(cont)
-	if ok {
-		l.detected(s, msg, matches)
+	if msg.Author == nil || msg.GuildID == "" || msg.Content == "" {
 		return
 	}
 
-	link := rxGenLink.FindString(cont)
-	if link != "" {
-		ok, matches, err := l.followLinkDeep(link, 100, 0)
-		if err != nil {
-			l.log.Debug().Err(err).Field("link", link).Msg("Failed following link")
-			return
-		}
-		if ok {
-			l.detected(s, msg, matches)
-		}
+	enabled, err := l.db.GetGuildInviteBlock(msg.GuildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		l.log.Error().Err(err).Field("gid", msg.GuildID).Msg("Failed getting invite block status")
+		return
+	}
+	if enabled == "" {
+		return
 	}
-}
 
-func (l *ListenerInviteBlock) checkForInviteLink(cont string) (bool, [][]string) {
-	matches := rxInvLink.FindAllStringSubmatch(cont, -1)
-	return matches != nil, matches
-}
+	settings, err := l.db.GetGuildInviteBlockSettings(msg.GuildID)
+	if database.IsErrDatabaseNotFound(err) {
+		settings = models.DefaultInviteBlockSettings
+	} else if err != nil {
+		l.log.Error().Err(err).Field("gid", msg.GuildID).Msg("Failed getting invite block settings")
+		l.gl.Errorf(msg.GuildID, "Failed getting invite block settings: %s", err.Error())
+		return
+	}
 
-func (l *ListenerInviteBlock) followLinkDeep(link string, maxDepth, depth int) (ok bool, matches [][]string, err error) {
-	if depth >= maxDepth {
+	if !settings.InScope(msg.ChannelID) {
 		return
 	}
 
-	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
-		link = "http://" + link
+	codes := l.findInviteCodes(msg.Content)
+	if len(codes) == 0 {
+		return
 	}
 
-	resp, err := httpreq.Get(link, nil)
+	ok, override, err := l.pmw.CheckPermissions(s, msg.GuildID, msg.Author.ID, "!sp.guild.mod.inviteblock.send")
 	if err != nil {
+		l.log.Error().Err(err).Fields("gid", msg.GuildID, "uid", msg.Author.ID).Msg("Failed checking permissions")
+		return
+	}
+	if ok || override {
 		return
 	}
-	defer resp.Release()
 
-	code := resp.StatusCode()
-	if code < 300 && code > 399 {
+	if l.allInvitesAllowed(s, msg.GuildID, settings, codes) {
 		return
 	}
 
-	location := string(resp.Header.Peek("location"))
-	ok, matches = l.checkForInviteLink(location)
+	l.respond(s, msg, settings)
+}
+
+// findInviteCodes returns the codes of all invites contained
+// in cont. Other links are resolved to find invites hidden
+// behind redirects, like links of URL shorteners.
+func (l *ListenerInviteBlock) findInviteCodes(cont string) (codes []string) {
+	seen := make(map[string]struct{})
+	add := func(s string) {
+		for _, match := range rxInvLink.FindAllStringSubmatch(s, -1) {
+			if _, ok := seen[match[1]]; !ok {
+				seen[match[1]] = struct{}{}
+				codes = append(codes, match[1])
+			}
+		}
+	}
+
+	add(cont)
 
-	if !ok {
-		return l.followLinkDeep(location, maxDepth, depth+1)
+	for _, link := range rxGenLink.FindAllString(cont, ibMaxResolvedLinks) {
+		if rxInvLink.MatchString(link) {
+			continue
+		}
+		chain, err := l.lr.Resolve(link)
+		if err != nil {
+			l.log.Debug().Err(err).Field("link", link).Msg("Failed resolving link")
+		}
+		for i := 1; i < len(chain); i++ {
+			add(chain[i])
+		}
 	}
 
-	return ok, matches, nil
+	return codes
 }
 
-func (l *ListenerInviteBlock) detected(s discordutil.ISession, e *discordgo.Message, matches [][]string) error {
-	enabled, err := l.db.GetGuildInviteBlock(e.GuildID)
-	if database.IsErrDatabaseNotFound(err) {
-		return nil
+// allInvitesAllowed returns true if all given invite codes
+// point either to the guild itself or to an allowed guild.
+func (l *ListenerInviteBlock) allInvitesAllowed(
+	s discordutil.ISession,
+	guildID string,
+	settings models.InviteBlockSettings,
+	codes []string,
+) bool {
+	ownCodes := make(map[string]struct{})
+	if invites, err := s.GuildInvites(guildID); err == nil {
+		for _, inv := range invites {
+			ownCodes[inv.Code] = struct{}{}
+		}
+	} else {
+		l.log.Error().Err(err).Field("gid", guildID).Msg("Failed getting guild invites")
+		l.gl.Errorf(guildID, "Failed getting guild invites: %s", err.Error())
 	}
-	if err != nil || enabled == "" {
-		return err
+
+	for _, code := range codes {
+		if _, ok := ownCodes[code]; ok {
+			continue
+		}
+		if len(settings.AllowedGuilds) == 0 {
+			return false
+		}
+		inv, err := s.Invite(code)
+		if err != nil {
+			l.log.Debug().Err(err).Field("code", code).Msg("Failed getting invite")
+			return false
+		}
+		if inv.Guild == nil || !settings.IsAllowed(inv.Guild.ID) {
+			return false
+		}
 	}
 
-	ok, override, err := l.pmw.CheckPermissions(s, e.GuildID, e.Author.ID, "!sp.guild.mod.inviteblock.send")
-	if err != nil || ok || override {
-		return err
+	return true
+}
+
+func (l *ListenerInviteBlock) respond(s discordutil.ISession, msg *discordgo.Message, settings models.InviteBlockSettings) {
+	// Messages are only handled once so that editing a
+	// message does not create further reports and timeouts.
+	if l.handled.Contains(msg.ID) {
+		return
 	}
+	l.handled.Set(msg.ID, struct{}{}, ibHandledLifetime)
 
-	if invites, err := s.GuildInvites(e.GuildID); err == nil {
-		inviteCode := matches[0][1]
-		for _, inv := range invites {
-			if inv.Code == inviteCode {
-				return nil
-			}
+	if settings.Delete {
+		if ch, err := s.UserChannelCreate(msg.Author.ID); err == nil {
+			util.SendEmbedError(s, ch.ID, "Your message contained an invite link to another guild so it has been deleted.")
+		}
+		if err := s.ChannelMessageDelete(msg.ChannelID, msg.ID); err != nil {
+			l.log.Error().Err(err).Fields("gid", msg.GuildID, "msg", msg.ID).Msg("Failed deleting message")
+			l.gl.Errorf(msg.GuildID, "Failed deleting message containing invite: %s", err.Error())
+		}
+	}
+
+	if settings.Warn {
+		if err := l.warn(msg); err != nil {
+			l.log.Error().Err(err).Fields("gid", msg.GuildID, "uid", msg.Author.ID).Msg("Failed creating warn report")
+			l.gl.Errorf(msg.GuildID, "Failed creating warn report for invite: %s", err.Error())
 		}
-	} else {
-		l.log.Error().Err(err).Field("gid", e.GuildID).Msg("Failed getting guild invites")
-		l.gl.Errorf(e.GuildID, "Failed getting guild invites: %s", err.Error())
 	}
 
-	if ch, err := s.UserChannelCreate(e.Author.ID); err == nil {
-		util.SendEmbedError(s, ch.ID, "Your message contained an invite link to another guild so it has been deleted.")
+	if settings.Timeout > 0 {
+		until := l.tp.Now().Add(settings.TimeoutValue())
+		if err := s.GuildMemberTimeout(msg.GuildID, msg.Author.ID, &until); err != nil {
+			l.log.Error().Err(err).Fields("gid", msg.GuildID, "uid", msg.Author.ID).Msg("Failed timing out member")
+			l.gl.Errorf(msg.GuildID, "Failed timing out member for invite: %s", err.Error())
+		}
+	}
+}
+
+func (l *ListenerInviteBlock) warn(msg *discordgo.Message) error {
+	self, err := l.st.SelfUser()
+	if err != nil {
+		return err
 	}
 
-	return s.ChannelMessageDelete(e.ChannelID, e.ID)
+	_, err = l.rep.PushReport(models.Report{
+		GuildID:    msg.GuildID,
+		ExecutorID: self.ID,
+		VictimID:   msg.Author.ID,
+		Msg:        "Automatic warn: posted an invite link to another guild.",
+		Type:       models.TypeWarn,
+	})
+	return err
 }
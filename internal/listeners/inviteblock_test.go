@@ -3,10 +3,14 @@ package listeners
 import (
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
 	"github.com/stretchr/testify/mock"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/linkresolver"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/mocks"
 )
@@ -16,6 +20,9 @@ type inviteBlockMock struct {
 	db      *mocks.Database
 	logger  *mocks.Logger
 	pmw     *mocks.PermissionsProvider
+	rep     *mocks.ReportProvider
+	st      *mocks.IState
+	tp      *mocks.TimeProvider
 
 	ct di.Container
 }
@@ -27,6 +34,9 @@ func getInviteBlockMock(f ...func(m inviteBlockMock)) inviteBlockMock {
 	t.db = &mocks.Database{}
 	t.logger = &mocks.Logger{}
 	t.pmw = &mocks.PermissionsProvider{}
+	t.rep = &mocks.ReportProvider{}
+	t.st = &mocks.IState{}
+	t.tp = &mocks.TimeProvider{}
 
 	t.logger.On("Errorf", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
 	t.logger.On("Section", mock.Anything).Return(t.logger)
@@ -49,6 +59,22 @@ func getInviteBlockMock(f ...func(m inviteBlockMock)) inviteBlockMock {
 			Name:  static.DiPermissions,
 			Build: func(ctn di.Container) (interface{}, error) { return t.pmw, nil },
 		},
+		di.Def{
+			Name:  static.DiReport,
+			Build: func(ctn di.Container) (interface{}, error) { return t.rep, nil },
+		},
+		di.Def{
+			Name:  static.DiState,
+			Build: func(ctn di.Container) (interface{}, error) { return t.st, nil },
+		},
+		di.Def{
+			Name:  static.DiTimeProvider,
+			Build: func(ctn di.Container) (interface{}, error) { return t.tp, nil },
+		},
+		di.Def{
+			Name:  static.DiLinkResolver,
+			Build: func(ctn di.Container) (interface{}, error) { return linkresolver.New(), nil },
+		},
 	)
 
 	t.ct = ct.Build()
@@ -60,6 +86,8 @@ func TestHandlerMessageSend(t *testing.T) {
 	m := getInviteBlockMock(func(t inviteBlockMock) {
 		t.db.On("GetGuildInviteBlock", "guild-id").Return("true", nil)
 		t.db.On("GetGuildInviteBlock", "guild-id-disabled").Return("", nil)
+		t.db.On("GetGuildInviteBlockSettings", mock.Anything).
+			Return(models.InviteBlockSettings{}, database.ErrDatabaseNotFound)
 
 		t.pmw.On("CheckPermissions", mock.Anything, mock.Anything, "user-allowed", "!sp.guild.mod.inviteblock.send").
 			Return(true, false, nil)
@@ -170,3 +198,131 @@ func TestHandlerMessageSend(t *testing.T) {
 		m.session.AssertNotCalled(t, "ChannelMessageDelete", e.ChannelID, e.ID)
 	}
 }
+
+func TestHandlerMessageSend_Settings(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(5 * time.Minute)
+
+	m := getInviteBlockMock(func(t inviteBlockMock) {
+		t.db.On("GetGuildInviteBlock", "guild-id").Return("true", nil)
+		t.db.On("GetGuildInviteBlockSettings", "guild-id").Return(models.InviteBlockSettings{
+			AllowedGuilds: []string{"partner-guild"},
+			Channels:      []string{"channel-id"},
+			Warn:          true,
+			Timeout:       300,
+		}, nil)
+
+		t.pmw.On("CheckPermissions", mock.Anything, mock.Anything, mock.Anything, "!sp.guild.mod.inviteblock.send").
+			Return(false, false, nil)
+
+		t.session.On("GuildInvites", mock.Anything).Return([]*discordgo.Invite{
+			{Code: "poggers"},
+		}, nil)
+		t.session.On("Invite", "partner").Return(&discordgo.Invite{
+			Code: "partner", Guild: &discordgo.Guild{ID: "partner-guild"}}, nil)
+		t.session.On("Invite", "other").Return(&discordgo.Invite{
+			Code: "other", Guild: &discordgo.Guild{ID: "other-guild"}}, nil)
+		t.session.On("Invite", "invalid").Return(nil, &discordgo.RESTError{})
+		t.session.On("GuildMemberTimeout", "guild-id", mock.Anything, &until).Return(nil)
+
+		t.st.On("SelfUser").Return(&discordgo.User{ID: "self-id"}, nil)
+		t.tp.On("Now").Return(now)
+		t.rep.On("PushReport", mock.Anything).Return(models.Report{}, nil)
+	})
+
+	l := NewListenerInviteBlock(m.ct)
+
+	getMessage := func(user, channel, content string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				Content:   content,
+				ID:        "message-" + user,
+				ChannelID: channel,
+				GuildID:   "guild-id",
+				Author: &discordgo.User{
+					ID: user,
+				},
+			},
+		}
+	}
+
+	positives := []*discordgo.MessageCreate{
+		getMessage("user-1", "channel-id", "discord.gg/other"),
+		getMessage("user-2", "channel-id", "discord.gg/partner discord.gg/other"),
+		getMessage("user-3", "channel-id", "discord.gg/invalid"),
+	}
+
+	negatives := []*discordgo.MessageCreate{
+		getMessage("user-4", "channel-id", "discord.gg/partner"),
+		getMessage("user-5", "channel-id", "discord.gg/poggers discord.gg/partner"),
+		getMessage("user-6", "other-channel-id", "discord.gg/other"),
+	}
+
+	for _, e := range append(positives, negatives...) {
+		l.HandlerMessageSend(m.session, e)
+	}
+
+	m.session.AssertNotCalled(t, "ChannelMessageDelete", mock.Anything, mock.Anything)
+	m.rep.AssertNumberOfCalls(t, "PushReport", len(positives))
+	m.session.AssertNumberOfCalls(t, "GuildMemberTimeout", len(positives))
+
+	for _, e := range positives {
+		m.rep.AssertCalled(t, "PushReport", models.Report{
+			GuildID:    "guild-id",
+			ExecutorID: "self-id",
+			VictimID:   e.Author.ID,
+			Msg:        "Automatic warn: posted an invite link to another guild.",
+			Type:       models.TypeWarn,
+		})
+		m.session.AssertCalled(t, "GuildMemberTimeout", "guild-id", e.Author.ID, &until)
+	}
+}
+
+func TestHandlerMessageEdit(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(5 * time.Minute)
+
+	m := getInviteBlockMock(func(t inviteBlockMock) {
+		t.db.On("GetGuildInviteBlock", "guild-id").Return("true", nil)
+		t.db.On("GetGuildInviteBlockSettings", "guild-id").Return(models.InviteBlockSettings{
+			Warn:    true,
+			Timeout: 300,
+		}, nil)
+
+		t.pmw.On("CheckPermissions", mock.Anything, mock.Anything, mock.Anything, "!sp.guild.mod.inviteblock.send").
+			Return(false, false, nil)
+
+		t.session.On("GuildInvites", mock.Anything).Return([]*discordgo.Invite{}, nil)
+		t.session.On("GuildMemberTimeout", "guild-id", mock.Anything, &until).Return(nil)
+
+		t.st.On("SelfUser").Return(&discordgo.User{ID: "self-id"}, nil)
+		t.tp.On("Now").Return(now)
+		t.rep.On("PushReport", mock.Anything).Return(models.Report{}, nil)
+	})
+
+	l := NewListenerInviteBlock(m.ct)
+
+	getMessage := func(id, content string) *discordgo.Message {
+		return &discordgo.Message{
+			Content:   content,
+			ID:        id,
+			ChannelID: "channel-id",
+			GuildID:   "guild-id",
+			Author: &discordgo.User{
+				ID: "user-id",
+			},
+		}
+	}
+
+	l.HandlerMessageSend(m.session, &discordgo.MessageCreate{Message: getMessage("message-1", "discord.gg/other")})
+	l.HandlerMessageEdit(m.session, &discordgo.MessageUpdate{Message: getMessage("message-1", "discord.gg/other2")})
+	l.HandlerMessageEdit(m.session, &discordgo.MessageUpdate{Message: getMessage("message-1", "discord.gg/other3")})
+
+	m.rep.AssertNumberOfCalls(t, "PushReport", 1)
+	m.session.AssertNumberOfCalls(t, "GuildMemberTimeout", 1)
+
+	l.HandlerMessageEdit(m.session, &discordgo.MessageUpdate{Message: getMessage("message-2", "discord.gg/other")})
+
+	m.rep.AssertNumberOfCalls(t, "PushReport", 2)
+	m.session.AssertNumberOfCalls(t, "GuildMemberTimeout", 2)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
 	"github.com/zekroTJA/ratelimit"
+	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
 	"github.com/zekroTJA/shinpuru/internal/services/karma"
@@ -172,8 +173,26 @@ func (l *ListenerKarma) Handler(s discordutil.ISession, e *discordgo.MessageReac
 		return
 	}
 
-	// Take a karma token from the users rate limiter
-	if !l.rateLimiterTake(e.UserID, e.GuildID) {
+	// Check if the user has already given too much karma
+	// to the author of the message recently
+	pairOk, err := l.karma.CheckPairLimit(e.GuildID, e.UserID, msg.Author.ID)
+	if err != nil {
+		l.log.Error().Err(err).Fields("gid", e.GuildID, "uid", e.UserID).Msg("Failed checking karma pair limit")
+		l.gl.Errorf(e.GuildID, "Failed checking karma pair limit (%s): %s", e.UserID, err.Error())
+		return
+	}
+	if !pairOk {
+		ch, err := s.UserChannelCreate(e.UserID)
+		if err == nil {
+			util.SendEmbedError(s, ch.ID,
+				"You have given karma to this member too often recently. Please try again later.")
+		}
+		return
+	}
+
+	// Take a karma token from the users rate limiter or
+	// from the tokens granted to the user by karma rules
+	if !l.rateLimiterTake(e.UserID, e.GuildID) && !l.karma.TakeGrantedToken(e.GuildID, e.UserID) {
 		ch, err := s.UserChannelCreate(e.UserID)
 		if err == nil {
 			util.SendEmbedError(s, ch.ID,
@@ -182,7 +201,7 @@ func (l *ListenerKarma) Handler(s discordutil.ISession, e *discordgo.MessageReac
 		return
 	}
 
-	err = l.karma.Update(e.GuildID, msg.Author.ID, e.UserID, typ)
+	err = l.karma.Update(e.GuildID, msg.Author.ID, e.UserID, e.ChannelID, typ, models.KarmaSourceReaction)
 	if err != nil {
 		l.log.Error().Err(err).Fields("gid", e.GuildID, "uid", e.UserID).Msg("Failed altering karma value")
 		l.gl.Errorf(e.GuildID, "Failed altering karma value (%s): %s", e.UserID, err.Error())
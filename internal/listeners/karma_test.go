@@ -8,6 +8,7 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
 	"github.com/stretchr/testify/mock"
+	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/mocks"
 )
@@ -70,6 +71,14 @@ func getKarmaHandlerMock(prep ...func(t karmaHandlerMock)) karmaHandlerMock {
 			ID: "user-blocked",
 		},
 	}, nil)
+	t.st.On("Message", "channel-id", "message-limited").Return(&discordgo.Message{
+		ID:        "message-limited",
+		ChannelID: "channel-id",
+		GuildID:   "guild-id",
+		Author: &discordgo.User{
+			ID: "author-limited",
+		},
+	}, nil)
 	t.st.On("Message", "channel-id", mock.Anything).Return(&discordgo.Message{
 		ID:        "message-id-" + strconv.Itoa(rand.Int()),
 		ChannelID: "channel-id",
@@ -83,7 +92,10 @@ func getKarmaHandlerMock(prep ...func(t karmaHandlerMock)) karmaHandlerMock {
 	t.karma.On("GetState", "guild-disabled").Return(false, nil)
 	t.karma.On("IsBlockListed", mock.Anything, "user-blocked").Return(true, nil)
 	t.karma.On("IsBlockListed", mock.Anything, mock.Anything).Return(false, nil)
-	t.karma.On("Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	t.karma.On("CheckPairLimit", mock.Anything, mock.Anything, "author-limited").Return(false, nil)
+	t.karma.On("CheckPairLimit", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	t.karma.On("Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	t.karma.On("TakeGrantedToken", mock.Anything, mock.Anything).Return(false)
 
 	t.session.On("User", "user-bot").Return(&discordgo.User{ID: "user-bot", Bot: true}, nil)
 	t.session.On("User", "user-id").Return(&discordgo.User{ID: "user-id"}, nil)
@@ -144,7 +156,7 @@ func TestKarmaHandler(t *testing.T) {
 		},
 	})
 
-	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "author-id", "user-bot", 1)
+	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "author-id", "user-bot", "channel-id", 1, models.KarmaSourceReaction)
 
 	// Self User
 	l.Handler(m.session, &discordgo.MessageReactionAdd{
@@ -159,7 +171,7 @@ func TestKarmaHandler(t *testing.T) {
 		},
 	})
 
-	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "user-id", "user-id", 1)
+	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "user-id", "user-id", "channel-id", 1, models.KarmaSourceReaction)
 
 	// Blocked Sender User
 	l.Handler(m.session, &discordgo.MessageReactionAdd{
@@ -174,7 +186,7 @@ func TestKarmaHandler(t *testing.T) {
 		},
 	})
 
-	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "author-id", "user-blocked", 1)
+	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "author-id", "user-blocked", "channel-id", 1, models.KarmaSourceReaction)
 
 	// Blocked Receiver User
 	l.Handler(m.session, &discordgo.MessageReactionAdd{
@@ -189,7 +201,7 @@ func TestKarmaHandler(t *testing.T) {
 		},
 	})
 
-	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "user-blocked", "user-id", 1)
+	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "user-blocked", "user-id", "channel-id", 1, models.KarmaSourceReaction)
 
 	// Disabled guild
 	l.Handler(m.session, &discordgo.MessageReactionAdd{
@@ -204,7 +216,23 @@ func TestKarmaHandler(t *testing.T) {
 		},
 	})
 
-	m.karma.AssertNotCalled(t, "Update", "guild-disabled", "author-id", "user-id", 1)
+	m.karma.AssertNotCalled(t, "Update", "guild-disabled", "author-id", "user-id", "channel-id", 1, models.KarmaSourceReaction)
+
+	// Pair limit exceeded
+	l.Handler(m.session, &discordgo.MessageReactionAdd{
+		MessageReaction: &discordgo.MessageReaction{
+			UserID:    "user-id",
+			MessageID: "message-limited",
+			ChannelID: "channel-id",
+			GuildID:   "guild-enabled",
+			Emoji: discordgo.Emoji{
+				Name: karmaUp,
+			},
+		},
+	})
+
+	m.karma.AssertCalled(t, "CheckPairLimit", "guild-enabled", "user-id", "author-limited")
+	m.karma.AssertNotCalled(t, "Update", "guild-enabled", "author-limited", "user-id", "channel-id", 1, models.KarmaSourceReaction)
 
 	// Only apply to message once
 	for i := 0; i < 3; i++ {
@@ -258,8 +286,8 @@ func TestKarmaHandler(t *testing.T) {
 		},
 	})
 
-	m.karma.AssertCalled(t, "Update", "guild-enabled", "author-id", "user-id", 1)
-	m.karma.AssertCalled(t, "Update", "guild-enabled", "author-id", "user-id", -1)
+	m.karma.AssertCalled(t, "Update", "guild-enabled", "author-id", "user-id", "channel-id", 1, models.KarmaSourceReaction)
+	m.karma.AssertCalled(t, "Update", "guild-enabled", "author-id", "user-id", "channel-id", -1, models.KarmaSourceReaction)
 
 	m.karma.AssertNumberOfCalls(t, "Update", 3)
 }
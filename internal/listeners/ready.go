@@ -6,35 +6,34 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
 	"github.com/zekrotja/dgrs"
+	"github.com/zekrotja/ken"
 	"github.com/zekrotja/rogu"
 	"github.com/zekrotja/rogu/log"
 
 	"github.com/zekroTJA/shinpuru/internal/services/database"
-	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
 	"github.com/zekroTJA/shinpuru/internal/services/scheduler"
-	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/services/votes"
 	"github.com/zekroTJA/shinpuru/internal/util"
 	"github.com/zekroTJA/shinpuru/internal/util/presence"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
-	"github.com/zekroTJA/shinpuru/internal/util/vote"
 )
 
 type ListenerReady struct {
 	db    database.Database
-	gl    guildlog.Logger
 	sched scheduler.Provider
 	st    *dgrs.State
-	tp    timeprovider.Provider
+	votes *votes.Service
+	ken   ken.IKen
 	log   rogu.Logger
 }
 
 func NewListenerReady(container di.Container) *ListenerReady {
 	return &ListenerReady{
 		db:    container.Get(static.DiDatabase).(database.Database),
-		gl:    container.Get(static.DiGuildLog).(guildlog.Logger).Section("ready"),
 		sched: container.Get(static.DiScheduler).(scheduler.Provider),
 		st:    container.Get(static.DiState).(*dgrs.State),
-		tp:    container.Get(static.DiTimeProvider).(timeprovider.Provider),
+		votes: container.Get(static.DiVotes).(*votes.Service),
+		ken:   container.Get(static.DiCommandHandler).(ken.IKen),
 		log:   log.Tagged("Ready"),
 	}
 }
@@ -59,26 +58,12 @@ func (l *ListenerReady) Handler(s *discordgo.Session, e *discordgo.Ready) {
 		}
 	}
 
-	votes, err := l.db.GetVotes()
+	running, err := l.votes.Load()
 	if err != nil {
 		l.log.Error().Err(err).Msg("Failed getting votes from DB")
-	} else {
-		vote.VotesRunning = votes
-		_, err = l.sched.Schedule("*/10 * * * * *", func() {
-			now := l.tp.Now()
-			for _, v := range vote.VotesRunning {
-				if (v.Expires != time.Time{}) && v.Expires.Before(now) {
-					v.Close(s, vote.VoteStateExpired)
-					if err = l.db.DeleteVote(v.ID); err != nil {
-						log.Error().Tag("LCT").Err(err).Fields("gid", v.GuildID, "vid", v.ID).Msg("Failed updating vote")
-						l.gl.Errorf(v.GuildID, "Failed updating vote (%s): %s", v.ID, err.Error())
-					}
-				}
-			}
-		})
-		if err != nil {
-			log.Error().Tag("LCT").Err(err).Msg("Failed scheduling votes job")
-		}
+	}
+	for _, v := range running {
+		util.RegisterVoteButtons(l.ken.Components(), l.db, v)
 	}
 
 	time.Sleep(1 * time.Second)
@@ -1,10 +1,9 @@
 package listeners
 
 import (
-	"fmt"
-
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/util"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
@@ -47,55 +46,29 @@ func (t *ListenerRoleselect) Ready(s discordutil.ISession, e *discordgo.Ready) {
 		t.log.Error().Err(err).Msg("Retrieving stored selects failed")
 	}
 
-	type perMessage struct {
-		GuildID   string
-		ChannelID string
-		MessageID string
-		RoleIDs   []string
-	}
+	groups := models.GroupRoleSelects(roleSelects)
 
-	perMessages := make(map[string]*perMessage)
-	for _, rs := range roleSelects {
-		key := fmt.Sprintf("%s:%s:%s", rs.GuildID, rs.ChannelID, rs.MessageID)
-		if _, ok := perMessages[key]; !ok {
-			perMessages[key] = &perMessage{
-				GuildID:   rs.GuildID,
-				ChannelID: rs.ChannelID,
-				MessageID: rs.MessageID,
-			}
-		}
-		perMessages[key].RoleIDs = append(perMessages[key].RoleIDs, rs.RoleID)
+	if len(groups) > 0 {
+		t.log.Info().Field("n-messages", len(groups)).Msg("Re-attaching button handlers ...")
 	}
 
-	if len(perMessages) > 0 {
-		t.log.Info().Field("n-messages", len(perMessages)).Msg("Re-attaching button handlers ...")
-	}
-
-	for _, pm := range perMessages {
-		roles := make([]*discordgo.Role, 0, len(pm.RoleIDs))
-		for _, rid := range pm.RoleIDs {
-			role, err := t.st.Role(pm.GuildID, rid)
-			if err != nil {
-				continue
-			}
-			roles = append(roles, role)
-		}
-		b := t.ken.Components().Add(pm.MessageID, pm.ChannelID)
-		_, err = util.AttachRoleSelectButtons(b, roles)
+	for _, g := range groups {
+		b := t.ken.Components().Add(g.MessageID, g.ChannelID)
+		err = util.AttachRoleSelect(b, g, t.st)
 		if err != nil {
 			if discordutil.IsErrCode(err, discordgo.ErrCodeUnknownMessage) {
 				t.log.Info().Fields(
-					"guild", pm.GuildID,
-					"channel", pm.ChannelID,
-					"message", pm.MessageID,
+					"guild", g.GuildID,
+					"channel", g.ChannelID,
+					"message", g.MessageID,
 				).Msg("Removing role select entries for deleted message")
-				t.db.RemoveRoleSelect(pm.GuildID, pm.ChannelID, pm.MessageID)
+				t.db.RemoveRoleSelect(g.GuildID, g.ChannelID, g.MessageID)
 				continue
 			}
 			t.log.Error().Fields(
-				"guild", pm.GuildID,
-				"channel", pm.ChannelID,
-				"message", pm.MessageID,
+				"guild", g.GuildID,
+				"channel", g.ChannelID,
+				"message", g.MessageID,
 			).Err(err).Msg("Re-Attaching failed")
 		}
 	}
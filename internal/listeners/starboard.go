@@ -8,6 +8,7 @@ import (
 	"image/jpeg"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -47,6 +48,9 @@ type ListenerStarboard struct {
 	karma *karma.Service
 	state *dgrs.State
 	log   rogu.Logger
+
+	mtx     sync.Mutex
+	pending map[string]struct{}
 }
 
 func NewListenerStarboard(container di.Container) *ListenerStarboard {
@@ -59,6 +63,7 @@ func NewListenerStarboard(container di.Container) *ListenerStarboard {
 		karma:      container.Get(static.DiKarma).(*karma.Service),
 		state:      container.Get(static.DiState).(*dgrs.State),
 		log:        log.Tagged("Starboard"),
+		pending:    make(map[string]struct{}),
 	}
 }
 
@@ -85,30 +90,28 @@ func (l *ListenerStarboard) ListenerReactionAdd(s *discordgo.Session, e *discord
 		return
 	}
 
-	starboardConfig, err := l.db.GetStarboardConfig(e.GuildID)
-	if database.IsErrDatabaseNotFound(err) {
-		return
-	}
-	if err != nil {
-		l.log.Error().Err(err).Msg("Failed getting guild config")
-		l.gl.Errorf(e.GuildID, "Failed getting guild config: %s", err.Error())
+	boards, err := l.db.GetStarboardConfigs(e.GuildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		l.log.Error().Err(err).Msg("Failed getting starboards")
+		l.gl.Errorf(e.GuildID, "Failed getting starboards: %s", err.Error())
 		return
 	}
-	if starboardConfig.ChannelID == "" {
+	boards = pickStarboards(boards, e.Emoji.Name)
+	if len(boards) == 0 {
 		return
 	}
 
-	if e.Emoji.Name != starboardConfig.EmojiID {
+	msg, err := l.state.Message(e.ChannelID, e.MessageID)
+
+	if err != nil {
+		l.log.Error().Err(err).Msg("Failed getting message")
+		l.gl.Errorf(e.GuildID, "Failed getting message (%s): %s", e.MessageID, err.Error())
 		return
 	}
 
-	starboardChannel, err := l.state.Channel(starboardConfig.ChannelID)
-	if err != nil {
-		starboardConfig.ChannelID = ""
-		if err = l.db.SetStarboardConfig(starboardConfig); err != nil {
-			l.log.Error().Err(err).Msg("Failed disabling starboard")
-			l.gl.Errorf(e.GuildID, "Failed disabling starboard: %s", err.Error())
-		}
+	if msg.Author == nil {
+		l.log.Error().Err(err).Msg("Message author is nil")
+		l.gl.Errorf(e.GuildID, "Message author is nil (%s)", e.MessageID)
 		return
 	}
 
@@ -119,49 +122,86 @@ func (l *ListenerStarboard) ListenerReactionAdd(s *discordgo.Session, e *discord
 		return
 	}
 
-	msg, err := l.state.Message(e.ChannelID, e.MessageID)
+	// Each board is handled independently, so a message can
+	// be posted to multiple boards voted with the same emoji.
+	for _, board := range boards {
+		l.handleVote(s, e.GuildID, msg, msgChannel, board)
+	}
+}
+
+// handleVote posts msg to board if it hits the threshold of
+// the board or updates the score of the already posted entry.
+func (l *ListenerStarboard) handleVote(
+	s *discordgo.Session,
+	guildID string,
+	msg *discordgo.Message,
+	msgChannel *discordgo.Channel,
+	board models.StarboardConfig,
+) {
+	starboardEntry, err := l.db.GetStarboardEntry(msg.ID, board.Name)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		l.log.Error().Err(err).Msg("Failed getting starboard entry")
+		l.gl.Errorf(guildID, "Failed getting starboard entry: %s", err.Error())
+		return
+	}
+	entryNotFound := database.IsErrDatabaseNotFound(err)
+
+	var posted *models.StarboardEntry
+	if !entryNotFound && !starboardEntry.Deleted {
+		posted = &starboardEntry
+	}
+	if !acceptsVote(board, posted, msg.ChannelID) {
+		return
+	}
 
+	starboardChannel, err := l.state.Channel(board.ChannelID)
 	if err != nil {
-		l.log.Error().Err(err).Msg("Failed getting message")
-		l.gl.Errorf(e.GuildID, "Failed getting message (%s): %s", e.MessageID, err.Error())
+		board.ChannelID = ""
+		if err = l.db.SetStarboardConfig(board); err != nil {
+			l.log.Error().Err(err).Msg("Failed disabling starboard")
+			l.gl.Errorf(guildID, "Failed disabling starboard %s: %s", board.Name, err.Error())
+		}
 		return
 	}
 
-	if msg.Author == nil {
-		l.log.Error().Err(err).Msg("Message author is nil")
-		l.gl.Errorf(e.GuildID, "Message author is nil (%s)", e.MessageID)
+	targetChannelID, censorMedia, ok := starboardTarget(board, msgChannel, starboardChannel)
+	if !ok {
 		return
 	}
 
-	ok, score := l.hitsThreshhold(msg, starboardConfig)
+	ok, score := l.hitsThreshhold(msg, board)
 	if !ok {
 		return
 	}
 
+	// Messages younger than the minimum age of the board are
+	// checked again once they are old enough, so that they are
+	// posted even if no further votes are added until then.
+	if posted == nil {
+		if wait := time.Until(msg.Timestamp.Add(time.Duration(board.MinAge) * time.Second)); wait > 0 {
+			l.schedulePending(s, guildID, msg.ChannelID, msg.ID, board.Name, wait)
+			return
+		}
+	}
+
 	ok, err = l.db.GetUserStarboardOptout(msg.Author.ID)
 	if err != nil && !database.IsErrDatabaseNotFound(err) {
 		l.log.Error().Err(err).Msg("Failed getting starboard user optout")
-		l.gl.Errorf(e.GuildID, "Failed getting starboard user optout: %s", err.Error())
+		l.gl.Errorf(guildID, "Failed getting starboard user optout: %s", err.Error())
 		return
 	}
 	if ok {
 		return
 	}
 
-	starboardEntry, err := l.db.GetStarboardEntry(msg.ID)
-	if err != nil && !database.IsErrDatabaseNotFound(err) {
-		l.log.Error().Err(err).Msg("Failed getting starboard entry")
-		l.gl.Errorf(e.GuildID, "Failed getting starboard entry: %s", err.Error())
-		return
-	}
-
+	// The message is copied because it is shared between
+	// the boards and its content is modified for posting.
+	msg = copyMessage(msg)
 	extractImage(msg)
 
-	censorMedia := msgChannel.NSFW && !starboardChannel.NSFW
-
 	var giveKarma bool
-	if database.IsErrDatabaseNotFound(err) || starboardEntry.Deleted {
-		giveKarma = database.IsErrDatabaseNotFound(err) && !starboardEntry.Deleted
+	if posted == nil {
+		giveKarma = entryNotFound
 
 		if censorMedia {
 			newAttachments := make([]*discordgo.MessageAttachment, len(msg.Attachments))
@@ -182,7 +222,7 @@ func (l *ListenerStarboard) ListenerReactionAdd(s *discordgo.Session, e *discord
 					newAttachment.URL, err = l.blurImage(attachment.URL)
 					if err != nil {
 						l.log.Error().Err(err).Msg("Failed bluring image")
-						l.gl.Errorf(e.GuildID, "Failed bluring NSFW image (%s): %s", attachment.URL, err.Error())
+						l.gl.Errorf(guildID, "Failed bluring NSFW image (%s): %s", attachment.URL, err.Error())
 						continue
 					}
 					newAttachments[i] = newAttachment
@@ -192,17 +232,18 @@ func (l *ListenerStarboard) ListenerReactionAdd(s *discordgo.Session, e *discord
 			msg.Attachments = newAttachments[:i]
 		}
 
-		sbMsg, err := s.ChannelMessageSendEmbed(starboardConfig.ChannelID, l.getEmbed(msg, e.GuildID, score))
+		sbMsg, err := s.ChannelMessageSendEmbed(targetChannelID, l.getEmbed(msg, guildID, score))
 		if err != nil {
 			l.log.Error().Err(err).Msg("Failed sending starboard message")
-			l.gl.Errorf(e.GuildID, "Failed sending starboard message: %s", err.Error())
+			l.gl.Errorf(guildID, "Failed sending starboard message: %s", err.Error())
 			return
 		}
 
 		starboardEntry = models.StarboardEntry{
 			MessageID:   msg.ID,
 			StarboardID: sbMsg.ID,
-			GuildID:     e.GuildID,
+			Board:       board.Name,
+			GuildID:     guildID,
 			ChannelID:   msg.ChannelID,
 			AuthorID:    msg.Author.ID,
 			Content:     msg.Content,
@@ -218,10 +259,10 @@ func (l *ListenerStarboard) ListenerReactionAdd(s *discordgo.Session, e *discord
 			starboardEntry.MediaURLs = append(starboardEntry.MediaURLs, msg.Embeds[0].Video.URL)
 		}
 	} else {
-		_, err = s.ChannelMessageEditEmbed(starboardConfig.ChannelID, starboardEntry.StarboardID, l.getEmbed(msg, e.GuildID, score))
+		_, err = s.ChannelMessageEditEmbed(targetChannelID, starboardEntry.StarboardID, l.getEmbed(msg, guildID, score))
 		if err != nil {
 			l.log.Error().Err(err).Msg("Failed updating starboard message")
-			l.gl.Errorf(e.GuildID, "Failed updating starboard message: %s", err.Error())
+			l.gl.Errorf(guildID, "Failed updating starboard message: %s", err.Error())
 			return
 		}
 
@@ -231,18 +272,85 @@ func (l *ListenerStarboard) ListenerReactionAdd(s *discordgo.Session, e *discord
 	err = l.db.SetStarboardEntry(starboardEntry)
 	if err != nil {
 		l.log.Error().Err(err).Msg("Failed setting starboard entry")
-		l.gl.Errorf(e.GuildID, "Failed getting starboard entry: %s", err.Error())
+		l.gl.Errorf(guildID, "Failed getting starboard entry: %s", err.Error())
 		return
 	}
 
 	if giveKarma {
-		if _, err = l.karma.CheckAndUpdate(e.GuildID, "", msg.Author, starboardConfig.KarmaGain); err != nil {
+		if _, err = l.karma.CheckAndUpdate(guildID, "", msg.ChannelID, msg.Author, board.KarmaGain, models.KarmaSourceStarboard); err != nil {
 			l.log.Error().Err(err).Msg("Failed updating karma")
-			l.gl.Errorf(e.GuildID, "Failed updating karma (%s): %s", msg.Author.ID, err.Error())
+			l.gl.Errorf(guildID, "Failed updating karma (%s): %s", msg.Author.ID, err.Error())
 		}
 	}
 }
 
+// schedulePending checks the vote of the message for the
+// given board again after wait. Multiple schedules for the
+// same message and board are merged into one.
+func (l *ListenerStarboard) schedulePending(
+	s *discordgo.Session,
+	guildID, channelID, messageID, boardName string,
+	wait time.Duration,
+) {
+	key := messageID + ":" + boardName
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if _, ok := l.pending[key]; ok {
+		return
+	}
+	l.pending[key] = struct{}{}
+
+	time.AfterFunc(wait, func() {
+		l.mtx.Lock()
+		delete(l.pending, key)
+		l.mtx.Unlock()
+
+		l.checkPending(s, guildID, channelID, messageID, boardName)
+	})
+}
+
+// checkPending re-evaluates the votes on a message which
+// was too young to be posted to the board when its threshold
+// has been reached.
+func (l *ListenerStarboard) checkPending(
+	s *discordgo.Session,
+	guildID, channelID, messageID, boardName string,
+) {
+	board, err := l.db.GetStarboardConfig(guildID, boardName)
+	if database.IsErrDatabaseNotFound(err) {
+		return
+	}
+	if err != nil {
+		l.log.Error().Err(err).Msg("Failed getting starboard")
+		l.gl.Errorf(guildID, "Failed getting starboard %s: %s", boardName, err.Error())
+		return
+	}
+	if board.ChannelID == "" {
+		return
+	}
+
+	msg, err := l.state.Message(channelID, messageID)
+	if err != nil {
+		l.log.Error().Err(err).Msg("Failed getting message")
+		l.gl.Errorf(guildID, "Failed getting message (%s): %s", messageID, err.Error())
+		return
+	}
+	if msg.Author == nil {
+		return
+	}
+
+	msgChannel, err := l.state.Channel(channelID)
+	if err != nil {
+		l.log.Error().Err(err).Msg("Failed getting message channel")
+		l.gl.Errorf(guildID, "Failed getting message channel (%s): %s", channelID, err.Error())
+		return
+	}
+
+	l.handleVote(s, guildID, msg, msgChannel, board)
+}
+
 func (l *ListenerStarboard) ListenerReactionRemove(s *discordgo.Session, e *discordgo.MessageReactionRemove) {
 	self, err := l.state.SelfUser()
 	if err != nil {
@@ -264,20 +372,36 @@ func (l *ListenerStarboard) ListenerReactionRemove(s *discordgo.Session, e *disc
 		return
 	}
 
-	starboardConfig, err := l.db.GetStarboardConfig(e.GuildID)
-	if database.IsErrDatabaseNotFound(err) {
+	boards, err := l.db.GetStarboardConfigs(e.GuildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		l.log.Error().Err(err).Msg("Failed getting starboards")
+		l.gl.Errorf(e.GuildID, "Failed getting starboards: %s", err.Error())
 		return
 	}
-	if err != nil {
-		l.log.Error().Err(err).Msg("Failed getting guild config")
-		l.gl.Errorf(e.GuildID, "Failed getting guild config: %s", err.Error())
-		return
+
+	var posted []models.StarboardConfig
+	var entries []models.StarboardEntry
+	for _, board := range pickStarboards(boards, e.Emoji.Name) {
+		starboardEntry, err := l.db.GetStarboardEntry(e.MessageID, board.Name)
+		if err != nil && !database.IsErrDatabaseNotFound(err) {
+			l.log.Error().Err(err).Msg("Failed getting entry")
+			l.gl.Errorf(e.GuildID, "Failed getting entry (%s): %s", e.MessageID, err.Error())
+			return
+		}
+		if database.IsErrDatabaseNotFound(err) || starboardEntry.Deleted {
+			continue
+		}
+		posted = append(posted, board)
+		entries = append(entries, starboardEntry)
 	}
-	if starboardConfig.ChannelID == "" {
+	if len(posted) == 0 {
 		return
 	}
 
-	if e.Emoji.Name != starboardConfig.EmojiID {
+	msgChannel, err := l.state.Channel(e.ChannelID)
+	if err != nil {
+		l.log.Error().Err(err).Msg("Failed getting message channel")
+		l.gl.Errorf(e.GuildID, "Failed getting message channel (%s): %s", e.ChannelID, err.Error())
 		return
 	}
 
@@ -293,46 +417,62 @@ func (l *ListenerStarboard) ListenerReactionRemove(s *discordgo.Session, e *disc
 		return
 	}
 
-	starboardEntry, err := l.db.GetStarboardEntry(msg.ID)
-	if err != nil && !database.IsErrDatabaseNotFound(err) {
-		l.log.Error().Err(err).Msg("Failed getting entry")
-		l.gl.Errorf(e.GuildID, "Failed getting entry (%s): %s", msg.ID, err.Error())
+	for i, board := range posted {
+		l.handleUnvote(s, e.GuildID, msg, msgChannel, board, entries[i])
+	}
+}
+
+// handleUnvote updates the score of the entry of msg on the
+// given board or removes it if it falls below the threshold.
+func (l *ListenerStarboard) handleUnvote(
+	s *discordgo.Session,
+	guildID string,
+	msg *discordgo.Message,
+	msgChannel *discordgo.Channel,
+	board models.StarboardConfig,
+	starboardEntry models.StarboardEntry,
+) {
+	starboardChannel, err := l.state.Channel(board.ChannelID)
+	if err != nil {
+		l.log.Error().Err(err).Msg("Failed getting starboard channel")
+		l.gl.Errorf(guildID, "Failed getting starboard channel (%s): %s", board.ChannelID, err.Error())
 		return
 	}
 
-	if database.IsErrDatabaseNotFound(err) {
+	targetChannelID, _, ok := starboardTarget(board, msgChannel, starboardChannel)
+	if !ok {
 		return
+	}
+
+	ok, score := l.hitsThreshhold(msg, board)
+	if !ok {
+		starboardEntry.Deleted = true
+		if err = s.ChannelMessageDelete(targetChannelID, starboardEntry.StarboardID); err != nil {
+			l.log.Error().Err(err).Msg("Failed removing starboard message")
+			l.gl.Errorf(guildID, "Failed removing starboard message: %s", err.Error())
+		}
 	} else {
-		ok, score := l.hitsThreshhold(msg, starboardConfig)
-		if !ok {
-			starboardEntry.Deleted = true
-			if err = s.ChannelMessageDelete(starboardConfig.ChannelID, starboardEntry.StarboardID); err != nil {
-				l.log.Error().Err(err).Msg("Failed removing starboard message")
-				l.gl.Errorf(e.GuildID, "Failed removing starboard message: %s", err.Error())
-			}
-		} else {
-			_, err = s.ChannelMessageEditEmbed(starboardConfig.ChannelID, starboardEntry.StarboardID, l.getEmbed(msg, e.GuildID, score))
-			if err != nil {
-				l.log.Error().Err(err).Msg("Failed updating starboard message")
-				l.gl.Errorf(e.GuildID, "Failed updating starboard message: %s", err.Error())
-			}
+		_, err = s.ChannelMessageEditEmbed(targetChannelID, starboardEntry.StarboardID, l.getEmbed(msg, guildID, score))
+		if err != nil {
+			l.log.Error().Err(err).Msg("Failed updating starboard message")
+			l.gl.Errorf(guildID, "Failed updating starboard message: %s", err.Error())
 		}
-
-		starboardEntry.Score = score
 	}
 
+	starboardEntry.Score = score
+
 	err = l.db.SetStarboardEntry(starboardEntry)
 	if err != nil {
 		l.log.Error().Err(err).Msg("Failed setting entry")
-		l.gl.Errorf(e.GuildID, "Failed setting entry: %s", err.Error())
+		l.gl.Errorf(guildID, "Failed setting entry: %s", err.Error())
 		return
 	}
 }
 
-func (l *ListenerStarboard) hitsThreshhold(msg *discordgo.Message, starboardConfig models.StarboardConfig) (ok bool, count int) {
+func (l *ListenerStarboard) hitsThreshhold(msg *discordgo.Message, board models.StarboardConfig) (ok bool, count int) {
 	for _, r := range msg.Reactions {
 		count = r.Count
-		ok = r.Emoji.Name == starboardConfig.EmojiID && count >= starboardConfig.Threshold
+		ok = r.Emoji.Name == board.EmojiID && count >= board.Threshold
 		if ok {
 			return
 		}
@@ -345,6 +485,8 @@ func (l *ListenerStarboard) getEmbed(
 	guildID string,
 	count int,
 ) *discordgo.MessageEmbed {
+	msg = copyMessage(msg)
+
 	var videoURL string
 	extractImage(msg)
 	videoURL, msg.Content = extractRegex(msg.Content, rxVideoURL)
@@ -411,12 +553,66 @@ func (l *ListenerStarboard) blurImage(sourceURL string) (targetURL string, err e
 	return
 }
 
+// pickStarboards returns the enabled boards which are
+// voted with the given emoji.
+func pickStarboards(boards []models.StarboardConfig, emoji string) []models.StarboardConfig {
+	var res []models.StarboardConfig
+	for _, b := range boards {
+		if b.ChannelID != "" && b.EmojiID == emoji {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// acceptsVote returns true if a vote on a message in
+// channelID counts for board. If the message has already
+// been posted to the board, the vote always counts.
+// Otherwise, the board must accept the channel.
+func acceptsVote(
+	board models.StarboardConfig,
+	posted *models.StarboardEntry,
+	channelID string,
+) bool {
+	return posted != nil || board.AppliesToChannel(channelID)
+}
+
+// starboardTarget returns the channel where messages from
+// msgChannel are posted to by board and whether their media
+// must be censored. ok is false if the message must not be
+// posted at all.
+func starboardTarget(
+	board models.StarboardConfig,
+	msgChannel, boardChannel *discordgo.Channel,
+) (channelID string, censor, ok bool) {
+	if !msgChannel.NSFW || boardChannel.NSFW {
+		return board.ChannelID, false, true
+	}
+
+	switch board.NSFW {
+	case models.StarboardNSFWSkip:
+		return "", false, false
+	case models.StarboardNSFWRoute:
+		return board.NSFWChannelID, false, true
+	default:
+		return board.ChannelID, true, true
+	}
+}
+
 func extractRegex(content string, rx *regexp.Regexp) (url string, rest string) {
 	url = rx.FindString(content)
 	rest = strings.Replace(content, url, "", 1)
 	return
 }
 
+// copyMessage returns a copy of msg which content and
+// attachments can be modified without affecting msg.
+func copyMessage(msg *discordgo.Message) *discordgo.Message {
+	c := *msg
+	c.Attachments = append([]*discordgo.MessageAttachment{}, msg.Attachments...)
+	return &c
+}
+
 func extractImage(msg *discordgo.Message) {
 	url, rest := extractRegex(msg.Content, rxImageURL)
 	if url == "" {
@@ -0,0 +1,76 @@
+package listeners
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+func TestPickStarboards(t *testing.T) {
+	boards := []models.StarboardConfig{
+		{Name: "art", ChannelID: "art-board", EmojiID: "⭐", Channels: []string{"art"}},
+		{Name: "disabled", EmojiID: "⭐"},
+		{Name: "main", ChannelID: "main-board", EmojiID: "⭐", ExcludedChannels: []string{"spam"}},
+		{Name: "memes", ChannelID: "meme-board", EmojiID: "😂"},
+	}
+
+	picked := pickStarboards(boards, "⭐")
+	assert.Len(t, picked, 2)
+	assert.Equal(t, "art", picked[0].Name)
+	assert.Equal(t, "main", picked[1].Name)
+
+	picked = pickStarboards(boards, "😂")
+	assert.Len(t, picked, 1)
+	assert.Equal(t, "memes", picked[0].Name)
+
+	assert.Empty(t, pickStarboards(boards, "👍"))
+}
+
+func TestAcceptsVote(t *testing.T) {
+	art := models.StarboardConfig{Name: "art", ChannelID: "art-board", EmojiID: "⭐", Channels: []string{"art"}}
+	mainBoard := models.StarboardConfig{Name: "main", ChannelID: "main-board", EmojiID: "⭐", ExcludedChannels: []string{"spam"}}
+
+	// Boards are independent, so a message can count for
+	// multiple boards at the same time.
+	assert.True(t, acceptsVote(art, nil, "art"))
+	assert.True(t, acceptsVote(mainBoard, nil, "art"))
+	assert.False(t, acceptsVote(art, nil, "general"))
+	assert.True(t, acceptsVote(mainBoard, nil, "general"))
+	assert.False(t, acceptsVote(mainBoard, nil, "spam"))
+
+	// Votes always count for boards a message has been posted to.
+	posted := &models.StarboardEntry{Board: "main"}
+	assert.True(t, acceptsVote(mainBoard, posted, "spam"))
+}
+
+func TestStarboardTarget(t *testing.T) {
+	sfw := &discordgo.Channel{}
+	nsfw := &discordgo.Channel{NSFW: true}
+
+	board := models.StarboardConfig{ChannelID: "board", NSFWChannelID: "nsfw-board"}
+
+	cases := []struct {
+		mode         models.StarboardNSFWMode
+		msg, target  *discordgo.Channel
+		channelID    string
+		censor, post bool
+	}{
+		{models.StarboardNSFWBlur, sfw, sfw, "board", false, true},
+		{models.StarboardNSFWBlur, nsfw, nsfw, "board", false, true},
+		{models.StarboardNSFWBlur, nsfw, sfw, "board", true, true},
+		{models.StarboardNSFWSkip, nsfw, sfw, "", false, false},
+		{models.StarboardNSFWSkip, sfw, sfw, "board", false, true},
+		{models.StarboardNSFWRoute, nsfw, sfw, "nsfw-board", false, true},
+		{models.StarboardNSFWRoute, sfw, sfw, "board", false, true},
+	}
+
+	for _, c := range cases {
+		board.NSFW = c.mode
+		channelID, censor, ok := starboardTarget(board, c.msg, c.target)
+		assert.Equal(t, c.channelID, channelID)
+		assert.Equal(t, c.censor, censor)
+		assert.Equal(t, c.post, ok)
+	}
+}
@@ -1,6 +1,8 @@
 package listeners
 
 import (
+	"errors"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
 	"github.com/zekrotja/dgrs"
@@ -39,9 +41,9 @@ func (l *ListenerVote) Handler(s *discordgo.Session, e *discordgo.MessageReactio
 	if user == nil || user.Bot || user.ID == self.ID {
 		return
 	}
-	for _, v := range vote.VotesRunning {
+	vote.VotesRunning.Range(func(v vote.Vote) bool {
 		if v.GuildID != e.GuildID || v.ChannelID != e.ChannelID || v.MsgID != e.MessageID {
-			continue
+			return true
 		}
 		tick := -1
 		for i, ve := range vote.VoteEmotes {
@@ -49,16 +51,26 @@ func (l *ListenerVote) Handler(s *discordgo.Session, e *discordgo.MessageReactio
 				tick = i
 			}
 		}
-		if tick > -1 {
-			go func() {
-				v.Tick(s, e.UserID, tick)
-				if err = l.db.AddUpdateVote(v); err != nil {
+		if tick > -1 && !v.UsesButtons() {
+			var roleIDs []string
+			if e.Member != nil {
+				roleIDs = e.Member.Roles
+			}
+			go func(v vote.Vote) {
+				if err := v.Tick(s, e.UserID, roleIDs, tick); err != nil {
+					if !errors.Is(err, vote.ErrNotAllowed) {
+						l.gl.Errorf(e.GuildID, "Failed ticking vote: %s", err.Error())
+					}
+					return
+				}
+				if err := l.db.AddUpdateVote(v); err != nil {
 					l.gl.Errorf(e.GuildID, "Failed updating vote in database: %s", err.Error())
 				}
-			}()
+			}(v)
 		}
-		if err = s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.Name, e.UserID); err != nil {
+		if err := s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.Name, e.UserID); err != nil {
 			l.gl.Errorf(e.GuildID, "Failed removing reaction: %s", err.Error())
 		}
-	}
+		return true
+	})
 }
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AntiraidResponse is the action applied to a member
+// who triggered an antiraid signal. Responses are
+// ordered by severity.
+type AntiraidResponse int
+
+const (
+	AntiraidResponseNone AntiraidResponse = iota
+	AntiraidResponseQuarantine
+	AntiraidResponseTimeout
+	AntiraidResponseKick
+	AntiraidResponseBan
+)
+
+var AntiraidResponses = []string{"none", "quarantine", "timeout", "kick", "ban"}
+
+func (r AntiraidResponse) String() string {
+	if r < 0 || int(r) >= len(AntiraidResponses) {
+		return "unknown"
+	}
+	return AntiraidResponses[r]
+}
+
+// AntiraidSignals configures the antiraid signals which
+// are evaluated for each joining member in addition to
+// the join rate limit. A signal with the response
+// AntiraidResponseNone is disabled.
+type AntiraidSignals struct {
+	AccountAge AntiraidAccountAgeSignal `json:"account_age"`
+	Similarity AntiraidSimilaritySignal `json:"similarity"`
+	Spam       AntiraidSpamSignal       `json:"spam"`
+
+	QuarantineRoleID string `json:"quarantine_role_id"`
+	TimeoutDuration  int    `json:"timeout_duration"` // seconds
+}
+
+// AntiraidAccountAgeSignal triggers when the account of
+// a joining member is younger than MinAge seconds.
+type AntiraidAccountAgeSignal struct {
+	Response AntiraidResponse `json:"response"`
+	MinAge   int              `json:"min_age"`
+}
+
+// AntiraidSimilaritySignal triggers when the username or
+// avatar of a joining member is similar to at least
+// MinMatches members who joined within the last Window
+// seconds. Two joins are similar when their similarity
+// score reaches Threshold, which is in range (0, 1].
+type AntiraidSimilaritySignal struct {
+	Response   AntiraidResponse `json:"response"`
+	Threshold  float64          `json:"threshold"`
+	Window     int              `json:"window"`
+	MinMatches int              `json:"min_matches"`
+}
+
+// AntiraidSpamSignal triggers when a member sends Messages
+// messages or a single message with Mentions mentions
+// within Window seconds after joining. A limit of 0
+// disables the corresponding check.
+type AntiraidSpamSignal struct {
+	Response AntiraidResponse `json:"response"`
+	Window   int              `json:"window"`
+	Messages int              `json:"messages"`
+	Mentions int              `json:"mentions"`
+}
+
+// Enabled returns true if at least one signal is enabled.
+func (s AntiraidSignals) Enabled() bool {
+	return s.AccountAge.Response != AntiraidResponseNone ||
+		s.Similarity.Response != AntiraidResponseNone ||
+		s.Spam.Response != AntiraidResponseNone
+}
+
+// TimeoutDurationValue returns the duration members
+// are timed out for.
+func (s AntiraidSignals) TimeoutDurationValue() time.Duration {
+	return time.Duration(s.TimeoutDuration) * time.Second
+}
+
+// Validate returns an error when one of the enabled
+// signals can not be applied as configured.
+func (s AntiraidSignals) Validate() error {
+	responses := []AntiraidResponse{s.AccountAge.Response, s.Similarity.Response, s.Spam.Response}
+	for _, r := range responses {
+		switch r {
+		case AntiraidResponseNone, AntiraidResponseKick, AntiraidResponseBan:
+		case AntiraidResponseQuarantine:
+			if s.QuarantineRoleID == "" {
+				return errors.New("quarantine response requires a quarantine role")
+			}
+		case AntiraidResponseTimeout:
+			if s.TimeoutDuration <= 0 || s.TimeoutDurationValue() > MaxMuteDuration {
+				return fmt.Errorf("timeout duration must be in range (0..%s]", formatEscalationDuration(MaxMuteDuration))
+			}
+		default:
+			return fmt.Errorf("invalid response %d", r)
+		}
+	}
+
+	if s.AccountAge.Response != AntiraidResponseNone && s.AccountAge.MinAge <= 0 {
+		return errors.New("account age: minimum age must be larger than 0")
+	}
+
+	if sim := s.Similarity; sim.Response != AntiraidResponseNone {
+		if sim.Threshold <= 0 || sim.Threshold > 1 {
+			return errors.New("similarity: threshold must be in range (0..1]")
+		}
+		if sim.Window <= 0 {
+			return errors.New("similarity: window must be larger than 0")
+		}
+		if sim.MinMatches < 1 {
+			return errors.New("similarity: minimum matches must be at least 1")
+		}
+	}
+
+	if spam := s.Spam; spam.Response != AntiraidResponseNone {
+		if spam.Window <= 0 {
+			return errors.New("spam: window must be larger than 0")
+		}
+		if spam.Messages < 0 || spam.Mentions < 0 {
+			return errors.New("spam: limits must not be negative")
+		}
+		if spam.Messages == 0 && spam.Mentions == 0 {
+			return errors.New("spam: either a message or a mention limit must be set")
+		}
+	}
+
+	return nil
+}
+
+// AntiraidLockdown configures the lockdown mode of a
+// guild. The lockdown is started either manually or,
+// when Auto is set, when a raid has been detected. It
+// is rolled back automatically when no member joined
+// for QuietPeriod seconds.
+type AntiraidLockdown struct {
+	Auto             bool     `json:"auto"`
+	PauseInvites     bool     `json:"pause_invites"`
+	LockChannels     []string `json:"lock_channels"`
+	SlowmodeChannels []string `json:"slowmode_channels"`
+	Slowmode         int      `json:"slowmode"`     // seconds
+	QuietPeriod      int      `json:"quiet_period"` // seconds
+}
+
+// DefaultAntiraidLockdown is used for guilds which
+// have not configured the lockdown mode.
+var DefaultAntiraidLockdown = AntiraidLockdown{
+	PauseInvites: true,
+	QuietPeriod:  int((30 * time.Minute).Seconds()),
+}
+
+const (
+	// MaxLockdownSlowmode is the maximum slowmode
+	// supported by Discord.
+	MaxLockdownSlowmode = 6 * time.Hour
+	// MaxLockdownQuietPeriod is the maximum time a
+	// lockdown stays active without any joins.
+	MaxLockdownQuietPeriod = 7 * 24 * time.Hour
+	// MaxLockdownChannels is the maximum number of
+	// channels locked or slowed down in a lockdown.
+	MaxLockdownChannels = 25
+)
+
+// QuietPeriodValue returns the duration without joins
+// after which the lockdown is rolled back.
+func (l AntiraidLockdown) QuietPeriodValue() time.Duration {
+	return time.Duration(l.QuietPeriod) * time.Second
+}
+
+// Validate returns an error when the lockdown
+// configuration is invalid.
+func (l AntiraidLockdown) Validate() error {
+	if l.QuietPeriod < 60 || l.QuietPeriodValue() > MaxLockdownQuietPeriod {
+		return fmt.Errorf("quiet period must be in range [1m..%s]", formatEscalationDuration(MaxLockdownQuietPeriod))
+	}
+	if l.Slowmode < 0 || time.Duration(l.Slowmode)*time.Second > MaxLockdownSlowmode {
+		return fmt.Errorf("slowmode must be in range [0..%s]", formatEscalationDuration(MaxLockdownSlowmode))
+	}
+	if len(l.SlowmodeChannels) != 0 && l.Slowmode == 0 {
+		return errors.New("slowmode channels require a slowmode duration")
+	}
+	if len(l.LockChannels) > MaxLockdownChannels || len(l.SlowmodeChannels) > MaxLockdownChannels {
+		return fmt.Errorf("at most %d channels can be locked or slowed down", MaxLockdownChannels)
+	}
+	return nil
+}
+
+// AntiraidLockdownState contains all changes applied to
+// a guild by an active lockdown so that they can be
+// rolled back afterwards.
+type AntiraidLockdownState struct {
+	GuildID     string    `json:"guild_id"`
+	StartedBy   string    `json:"started_by"` // empty when started automatically
+	Started     time.Time `json:"started"`
+	LastJoin    time.Time `json:"last_join"`
+	QuietPeriod int       `json:"quiet_period"` // seconds
+
+	// PrevVerificationLevel is the verification level
+	// before the lockdown. It is nil when the level
+	// has not been changed by the lockdown.
+	PrevVerificationLevel *discordgo.VerificationLevel `json:"prev_verification_level"`
+	InvitesPaused         bool                         `json:"invites_paused"`
+	LockedChannels        []string                     `json:"locked_channels"`
+	// PrevSlowmodes maps the IDs of the slowed down
+	// channels to their slowmode before the lockdown.
+	PrevSlowmodes map[string]int `json:"prev_slowmodes"`
+
+	Joins    []string `json:"joins"`
+	Failures []string `json:"failures"`
+}
+
+// QuietUntil returns the time at which the lockdown
+// is rolled back if no further member joins.
+func (s AntiraidLockdownState) QuietUntil() time.Time {
+	last := s.Started
+	if s.LastJoin.After(last) {
+		last = s.LastJoin
+	}
+	return last.Add(time.Duration(s.QuietPeriod) * time.Second)
+}
@@ -0,0 +1,249 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
+)
+
+const (
+	// MaxAutomodRules is the maximum number of
+	// automod rules a guild can have.
+	MaxAutomodRules = 25
+	// MaxAutomodRuleNameLen is the maximum length
+	// of the name of an automod rule.
+	MaxAutomodRuleNameLen = 64
+	// MaxAutomodWords is the maximum number of entries
+	// of the word list of a matcher.
+	MaxAutomodWords = 500
+	// MaxAutomodPatternLen is the maximum length of
+	// the regular expression of a matcher.
+	MaxAutomodPatternLen = 512
+	// MaxAutomodDomains is the maximum number of
+	// domains listed in a matcher.
+	MaxAutomodDomains = 100
+	// MaxAutomodDuplicates is the maximum number of
+	// identical messages a matcher can count.
+	MaxAutomodDuplicates = 10
+	// MaxAutomodDuplicateWindow is the longest window
+	// identical messages are counted in.
+	MaxAutomodDuplicateWindow = 10 * time.Minute
+	// MaxAutomodScopeEntries is the maximum number of
+	// channels and exempt roles of a rule each.
+	MaxAutomodScopeEntries = 100
+)
+
+// AutomodMatcherType defines what an automod
+// matcher checks messages for.
+type AutomodMatcherType string
+
+const (
+	// AutomodMatchWords matches messages containing one
+	// of Words. Leetspeak is normalized and letters
+	// repeated 3 or more times are collapsed before
+	// comparing.
+	AutomodMatchWords AutomodMatcherType = "WORDS"
+	// AutomodMatchRegex matches messages matching Pattern.
+	AutomodMatchRegex AutomodMatcherType = "REGEX"
+	// AutomodMatchLinks matches messages containing links
+	// to one of Domains or, if AllowDomains is set, to
+	// any domain not listed in Domains.
+	AutomodMatchLinks AutomodMatcherType = "LINKS"
+	// AutomodMatchCaps matches messages with at least
+	// MinLength letters of which at least Threshold
+	// percent are upper case.
+	AutomodMatchCaps AutomodMatcherType = "CAPS"
+	// AutomodMatchZalgo matches messages containing
+	// Threshold or more stacked combining marks.
+	AutomodMatchZalgo AutomodMatcherType = "ZALGO"
+	// AutomodMatchMentions matches messages containing
+	// Threshold or more mentions.
+	AutomodMatchMentions AutomodMatcherType = "MENTIONS"
+	// AutomodMatchDuplicates matches when the author sent
+	// Threshold or more identical messages within the last
+	// Window seconds.
+	AutomodMatchDuplicates AutomodMatcherType = "DUPLICATES"
+)
+
+var AutomodMatcherTypes = []AutomodMatcherType{
+	AutomodMatchWords, AutomodMatchRegex, AutomodMatchLinks, AutomodMatchCaps,
+	AutomodMatchZalgo, AutomodMatchMentions, AutomodMatchDuplicates,
+}
+
+func (t AutomodMatcherType) Valid() bool {
+	for _, v := range AutomodMatcherTypes {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// AutomodMatcher decides whether a message violates an
+// automod rule. Which fields are used depends on Type.
+type AutomodMatcher struct {
+	Type         AutomodMatcherType `json:"type"`
+	Words        []string           `json:"words"`
+	Pattern      string             `json:"pattern"`
+	Domains      []string           `json:"domains"`
+	AllowDomains bool               `json:"allow_domains"`
+	Threshold    int                `json:"threshold"`
+	MinLength    int                `json:"min_length"`
+	Window       int                `json:"window"` // seconds
+}
+
+// WindowDuration returns the window in which
+// identical messages are counted.
+func (m AutomodMatcher) WindowDuration() time.Duration {
+	return time.Duration(m.Window) * time.Second
+}
+
+func (m AutomodMatcher) Validate() error {
+	switch m.Type {
+	case AutomodMatchWords:
+		if len(m.Words) == 0 || len(m.Words) > MaxAutomodWords {
+			return fmt.Errorf("word list must contain between 1 and %d words", MaxAutomodWords)
+		}
+		for _, w := range m.Words {
+			if strings.TrimSpace(w) == "" {
+				return errors.New("word list must not contain empty words")
+			}
+		}
+	case AutomodMatchRegex:
+		if m.Pattern == "" || len(m.Pattern) > MaxAutomodPatternLen {
+			return fmt.Errorf("pattern must be between 1 and %d characters long", MaxAutomodPatternLen)
+		}
+		if _, err := regexp.Compile(m.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %s", err.Error())
+		}
+	case AutomodMatchLinks:
+		if len(m.Domains) > MaxAutomodDomains {
+			return fmt.Errorf("at most %d domains can be listed", MaxAutomodDomains)
+		}
+		if len(m.Domains) == 0 && !m.AllowDomains {
+			return errors.New("at least one domain must be listed")
+		}
+	case AutomodMatchCaps:
+		if m.Threshold < 1 || m.Threshold > 100 {
+			return errors.New("threshold must be a percentage in range [1..100]")
+		}
+		if m.MinLength < 0 {
+			return errors.New("min length must not be negative")
+		}
+	case AutomodMatchZalgo, AutomodMatchMentions:
+		if m.Threshold < 1 {
+			return errors.New("threshold must be at least 1")
+		}
+	case AutomodMatchDuplicates:
+		if m.Threshold < 2 || m.Threshold > MaxAutomodDuplicates {
+			return fmt.Errorf("threshold must be in range [2..%d]", MaxAutomodDuplicates)
+		}
+		if m.Window < 1 || m.WindowDuration() > MaxAutomodDuplicateWindow {
+			return fmt.Errorf("window must be in range [1..%d] seconds", int(MaxAutomodDuplicateWindow.Seconds()))
+		}
+	default:
+		return fmt.Errorf("invalid matcher type '%s'", m.Type)
+	}
+	return nil
+}
+
+// AutomodActionType is an action applied to a
+// message and its author when a rule matches.
+type AutomodActionType string
+
+const (
+	AutomodActionDelete  AutomodActionType = "DELETE"
+	AutomodActionWarn    AutomodActionType = "WARN"
+	AutomodActionTimeout AutomodActionType = "TIMEOUT"
+	AutomodActionKick    AutomodActionType = "KICK"
+)
+
+var AutomodActionTypes = []AutomodActionType{
+	AutomodActionDelete, AutomodActionWarn, AutomodActionTimeout, AutomodActionKick,
+}
+
+func (t AutomodActionType) Valid() bool {
+	for _, v := range AutomodActionTypes {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+type AutomodAction struct {
+	Type     AutomodActionType `json:"type"`
+	Duration int               `json:"duration"` // seconds; required for TIMEOUT
+}
+
+// DurationValue returns the duration of a timeout.
+func (a AutomodAction) DurationValue() time.Duration {
+	return time.Duration(a.Duration) * time.Second
+}
+
+// AutomodRule applies Actions in order to messages matched
+// by Matcher. Rules scoped to Channels are only evaluated
+// for messages sent in one of the channels. Members having
+// one of ExemptRoles are not affected by the rule.
+type AutomodRule struct {
+	ID          snowflake.ID    `json:"id"`
+	Name        string          `json:"name"`
+	Enabled     bool            `json:"enabled"`
+	Matcher     AutomodMatcher  `json:"matcher"`
+	Channels    []string        `json:"channels"`
+	ExemptRoles []string        `json:"exempt_roles"`
+	Actions     []AutomodAction `json:"actions"`
+}
+
+func (r AutomodRule) Validate() error {
+	if r.Name == "" || len(r.Name) > MaxAutomodRuleNameLen {
+		return fmt.Errorf("name must be between 1 and %d characters long", MaxAutomodRuleNameLen)
+	}
+	if err := r.Matcher.Validate(); err != nil {
+		return err
+	}
+	if len(r.Channels) > MaxAutomodScopeEntries || len(r.ExemptRoles) > MaxAutomodScopeEntries {
+		return fmt.Errorf("at most %d channels and exempt roles can be specified", MaxAutomodScopeEntries)
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("at least one action must be specified")
+	}
+
+	seen := make(map[AutomodActionType]struct{}, len(r.Actions))
+	for _, a := range r.Actions {
+		if !a.Type.Valid() {
+			return fmt.Errorf("invalid action type '%s'", a.Type)
+		}
+		if _, ok := seen[a.Type]; ok {
+			return fmt.Errorf("action '%s' is specified multiple times", a.Type)
+		}
+		seen[a.Type] = struct{}{}
+		if a.Type == AutomodActionTimeout && (a.Duration < 1 || a.DurationValue() > MaxMuteDuration) {
+			return fmt.Errorf("timeout duration must be in range [1s..%s]", formatEscalationDuration(MaxMuteDuration))
+		}
+	}
+
+	return nil
+}
+
+// AppliesToChannel returns true if messages sent in
+// the given channel are in the scope of the rule.
+func (r AutomodRule) AppliesToChannel(channelID string) bool {
+	return len(r.Channels) == 0 || stringutil.ContainsAny(channelID, r.Channels)
+}
+
+// IsExempt returns true if a member with the given
+// roles is not affected by the rule.
+func (r AutomodRule) IsExempt(roleIDs []string) bool {
+	for _, id := range roleIDs {
+		if stringutil.ContainsAny(id, r.ExemptRoles) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,4 +7,22 @@ type Birthday struct {
 	UserID   string    `json:"userid"`
 	Date     time.Time `json:"date"`
 	ShowYear bool      `json:"showyear"`
+
+	// LastNotified is the time when the birthday
+	// has been announced last.
+	LastNotified time.Time `json:"lastnotified,omitempty"`
+	// RoleExpires is the time when the birthday role
+	// is removed from the member again. It is zero if
+	// no birthday role is currently assigned.
+	RoleExpires time.Time `json:"roleexpires,omitempty"`
+}
+
+// UpcomingBirthday is the next occurrence
+// of the birthday of a member.
+type UpcomingBirthday struct {
+	UserID string    `json:"userid"`
+	Date   time.Time `json:"date"`
+	// Age is the age the member turns on the birthday.
+	// It is 0 if the member does not show their year.
+	Age int `json:"age,omitempty"`
 }
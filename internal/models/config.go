@@ -1,6 +1,7 @@
 package models
 
 import (
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/lokiwriter"
 	"github.com/zekroTJA/shinpuru/pkg/random"
@@ -23,6 +24,9 @@ var DefaultConfig = Config{
 	Database: DatabaseType{
 		Type:  "mysql",
 		MySql: DatabaseCreds{},
+		Sqlite: DatabaseSqlite{
+			File: "./data/shinpuru.db",
+		},
 	},
 	Cache: Cache{
 		Redis: CacheRedis{
@@ -79,6 +83,14 @@ var DefaultConfig = Config{
 		ReportsExpiration:   "@every 5m",
 		VerificationKick:    "@every 1h",
 	},
+	Backups: Backups{
+		FullInterval: 14,
+		Retention: backupmodels.Retention{
+			Hourly: 24,
+			Daily:  30,
+			Weekly: 52,
+		},
+	},
 	CodeExec: CodeExec{
 		Type: "jdoodle",
 		Ranna: CodeExecRanna{
@@ -125,6 +137,20 @@ type DatabaseCreds struct {
 	Database string `json:"database"`
 }
 
+// DatabasePostgres holds credentials and connection
+// preferences to connect to a PostgreSQL database.
+type DatabasePostgres struct {
+	DatabaseCreds
+	SSLMode string `json:"sslmode"`
+}
+
+// DatabaseSqlite holds the location of the
+// database file used by the embedded SQLite
+// database driver.
+type DatabaseSqlite struct {
+	File string `json:"file"`
+}
+
 // CacheRedis holds credentials and settings
 // to connect to a Redis instance.
 type CacheRedis struct {
@@ -139,9 +165,11 @@ type CacheRedis struct {
 // database module to be used and the seperate
 // "slots" for database configurations.
 type DatabaseType struct {
-	Type  string        `json:"type"`
-	MySql DatabaseCreds `json:"mysql"`
-	Redis CacheRedis    `json:"redis"`
+	Type     string           `json:"type"`
+	MySql    DatabaseCreds    `json:"mysql"`
+	Postgres DatabasePostgres `json:"postgres"`
+	Sqlite   DatabaseSqlite   `json:"sqlite"`
+	Redis    CacheRedis       `json:"redis"`
 }
 
 // Cache holds the preferences for caching
@@ -186,8 +214,17 @@ type Logging struct {
 // TwitchApp holds credentials to connect to
 // a Twitch API application.
 type TwitchApp struct {
-	ClientID     string `json:"clientid"`
-	ClientSecret string `json:"clientsecret"`
+	ClientID     string         `json:"clientid"`
+	ClientSecret string         `json:"clientsecret"`
+	EventSub     TwitchEventSub `json:"eventsub"`
+}
+
+// TwitchEventSub holds the configuration for
+// receiving stream notifications via Twitch
+// EventSub webhooks.
+type TwitchEventSub struct {
+	Secret      string `json:"secret"`
+	CallbackURL string `json:"callbackurl"`
 }
 
 // WebServer holds general configurations for
@@ -257,7 +294,8 @@ type StorageMinio struct {
 // StorageFile holds preferences for a local
 // file storage provider.
 type StorageFile struct {
-	Location string `json:"location"`
+	Location   string `json:"location"`
+	SigningKey string `json:"signingkey"`
 }
 
 // StorageType holds the preferences for which
@@ -286,6 +324,30 @@ type Schedules struct {
 	VerificationKick    string `json:"verificationkick"`
 }
 
+// Backups holds the defaults for guild backups.
+// FullInterval is the number of incremental backups
+// after which a new full snapshot is created. The
+// retention policy applies to all guilds which have
+// not configured their own.
+type Backups struct {
+	FullInterval int                    `json:"fullinterval"`
+	Retention    backupmodels.Retention `json:"retention"`
+	Encryption   BackupEncryption       `json:"encryption"`
+}
+
+// BackupEncryption holds the master keys used to wrap
+// the guild specific keys which encrypt stored backups.
+// Keys are base64 encoded 32 byte keys. Backups are
+// only encrypted when a MasterKey is set.
+//
+// When rotating the master key, the previous key must
+// be kept in PreviousKeys until all guild keys have
+// been re-wrapped with the new master key.
+type BackupEncryption struct {
+	MasterKey    string   `json:"masterkey"`
+	PreviousKeys []string `json:"previouskeys"`
+}
+
 // CodeExec wraps configurations for the
 // code execution API used.
 type CodeExec struct {
@@ -345,6 +407,7 @@ type Config struct {
 	WebServer   WebServer    `json:"webserver"`
 	Metrics     Metrics      `json:"metrics"`
 	Schedules   Schedules    `json:"schedules"`
+	Backups     Backups      `json:"backups"`
 	CodeExec    CodeExec     `json:"codeexec"`
 	Giphy       Giphy        `json:"giphy"`
 	Privacy     Privacy      `json:"privacy"`
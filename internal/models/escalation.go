@@ -0,0 +1,137 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxEscalationSteps is the maximum number of steps
+	// a guild's escalation ladder may consist of.
+	MaxEscalationSteps = 10
+
+	// MaxEscalationCount is the upper limit of the report
+	// count required to trigger an escalation step.
+	MaxEscalationCount = 100
+
+	// MaxMuteDuration is the longest timeout Discord
+	// allows to be applied to a member.
+	MaxMuteDuration = 28 * 24 * time.Hour
+)
+
+// EscalationStep is a single rung of an escalation ladder.
+//
+// When a report of type On is pushed for a member who has
+// collected at least Count reports of type Counted within
+// the last Window seconds, Action is applied to the member
+// automatically. Only reports created after the member's
+// latest report of type Action are counted, so a step
+// starts over after it has been applied.
+type EscalationStep struct {
+	On       ReportType `json:"on"`
+	Counted  ReportType `json:"counted"`
+	Count    int        `json:"count"`
+	Window   int        `json:"window"`   // seconds; 0 counts all reports
+	Action   ReportType `json:"action"`   // KICK, BAN or MUTE
+	Duration int        `json:"duration"` // seconds; required for MUTE, 0 bans permanently
+}
+
+// WindowDuration returns the counting window of the step.
+func (s EscalationStep) WindowDuration() time.Duration {
+	return time.Duration(s.Window) * time.Second
+}
+
+// ActionDuration returns the duration of the action
+// applied by the step.
+func (s EscalationStep) ActionDuration() time.Duration {
+	return time.Duration(s.Duration) * time.Second
+}
+
+// Validate returns an error when the step can not be
+// applied as configured.
+func (s EscalationStep) Validate() error {
+	if !isEscalationTrigger(s.On) {
+		return fmt.Errorf("invalid trigger type %d", s.On)
+	}
+	if !isEscalationTrigger(s.Counted) {
+		return fmt.Errorf("invalid counted type %d", s.Counted)
+	}
+	if s.Count < 1 || s.Count > MaxEscalationCount {
+		return fmt.Errorf("count must be in range [1..%d]", MaxEscalationCount)
+	}
+	if s.Window < 0 {
+		return errors.New("window must not be negative")
+	}
+	if s.Duration < 0 {
+		return errors.New("duration must not be negative")
+	}
+
+	switch s.Action {
+	case TypeKick:
+		if s.Duration != 0 {
+			return errors.New("kicks can not have a duration")
+		}
+	case TypeBan:
+	case TypeMute:
+		if s.Duration == 0 || s.ActionDuration() > MaxMuteDuration {
+			return fmt.Errorf("mute duration must be in range (0..%s]", formatEscalationDuration(MaxMuteDuration))
+		}
+	default:
+		return fmt.Errorf("invalid action type %d", s.Action)
+	}
+
+	return nil
+}
+
+func (s EscalationStep) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "on %s: %d× %s", ReportTypes[s.On], s.Count, ReportTypes[s.Counted])
+	if s.Window > 0 {
+		fmt.Fprintf(&sb, " within %s", formatEscalationDuration(s.WindowDuration()))
+	}
+	fmt.Fprintf(&sb, " → %s", ReportTypes[s.Action])
+	if s.Duration > 0 {
+		fmt.Fprintf(&sb, " for %s", formatEscalationDuration(s.ActionDuration()))
+	}
+
+	return sb.String()
+}
+
+// EscalationLadder is the ordered list of escalation steps
+// of a guild. When multiple steps match a report, the last
+// one takes precedence.
+type EscalationLadder []EscalationStep
+
+// Validate returns an error when the ladder has too many
+// steps or one of the steps is invalid.
+func (l EscalationLadder) Validate() error {
+	if len(l) > MaxEscalationSteps {
+		return fmt.Errorf("a ladder can consist of at most %d steps", MaxEscalationSteps)
+	}
+	for i, s := range l {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("step %d: %s", i+1, err.Error())
+		}
+	}
+	return nil
+}
+
+func isEscalationTrigger(typ ReportType) bool {
+	return typ >= TypeKick && typ <= TypeAd
+}
+
+func formatEscalationDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
+)
+
+const (
+	// MaxInviteBlockAllowedGuilds is the maximum number
+	// of guilds whose invites can be allowed.
+	MaxInviteBlockAllowedGuilds = 100
+	// MaxInviteBlockChannels is the maximum number of
+	// channels the invite block can be scoped to.
+	MaxInviteBlockChannels = 100
+)
+
+// InviteBlockSettings configures how the invite block of a
+// guild handles messages containing invites. Whether the
+// invite block is enabled at all is stored separately.
+type InviteBlockSettings struct {
+	// AllowedGuilds contains the IDs of guilds, like
+	// partner servers, whose invites are permitted.
+	AllowedGuilds []string `json:"allowed_guilds"`
+	// Channels contains the IDs of the channels the invite
+	// block applies to. Empty means all channels.
+	Channels []string `json:"channels"`
+
+	Delete  bool `json:"delete"`
+	Warn    bool `json:"warn"`
+	Timeout int  `json:"timeout"` // seconds; 0 disables the timeout
+}
+
+// DefaultInviteBlockSettings are used when no settings
+// have been stored for a guild. They match the behavior
+// of the invite block before it was configurable.
+var DefaultInviteBlockSettings = InviteBlockSettings{
+	Delete: true,
+}
+
+func (s InviteBlockSettings) TimeoutValue() time.Duration {
+	return time.Duration(s.Timeout) * time.Second
+}
+
+// InScope returns true if the invite block applies
+// to the given channel.
+func (s InviteBlockSettings) InScope(channelID string) bool {
+	return len(s.Channels) == 0 || stringutil.ContainsAny(channelID, s.Channels)
+}
+
+// IsAllowed returns true if invites to the
+// given guild are permitted.
+func (s InviteBlockSettings) IsAllowed(guildID string) bool {
+	return stringutil.ContainsAny(guildID, s.AllowedGuilds)
+}
+
+func (s InviteBlockSettings) Validate() error {
+	if !s.Delete && !s.Warn && s.Timeout == 0 {
+		return errors.New("at least one response must be enabled")
+	}
+	if s.Timeout < 0 || s.TimeoutValue() > MaxMuteDuration {
+		return fmt.Errorf("timeout must be in range [0..%s]", formatEscalationDuration(MaxMuteDuration))
+	}
+	if len(s.AllowedGuilds) > MaxInviteBlockAllowedGuilds {
+		return fmt.Errorf("at most %d guilds can be allowed", MaxInviteBlockAllowedGuilds)
+	}
+	if len(s.Channels) > MaxInviteBlockChannels {
+		return fmt.Errorf("the invite block can be scoped to at most %d channels", MaxInviteBlockChannels)
+	}
+	return nil
+}
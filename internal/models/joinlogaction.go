@@ -0,0 +1,138 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
+)
+
+const (
+	// MaxJoinlogNamePatternLen is the maximum length
+	// of the name pattern of a JoinlogFilter.
+	MaxJoinlogNamePatternLen = 256
+	// MaxJoinlogActionErrors is the maximum number of
+	// errors recorded in a JoinlogActionProgress.
+	MaxJoinlogActionErrors = 25
+)
+
+// JoinlogAction is a moderation action which is applied
+// to the members listed in the antiraid join log.
+type JoinlogAction string
+
+const (
+	JoinlogActionBan    JoinlogAction = "ban"
+	JoinlogActionKick   JoinlogAction = "kick"
+	JoinlogActionVerify JoinlogAction = "verify"
+)
+
+var JoinlogActions = []JoinlogAction{JoinlogActionBan, JoinlogActionKick, JoinlogActionVerify}
+
+func (a JoinlogAction) Valid() bool {
+	for _, v := range JoinlogActions {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinlogFilter selects a subset of the entries of the
+// antiraid join log. Empty fields do not filter. All
+// set fields must match for an entry to be selected.
+type JoinlogFilter struct {
+	UserIDs       []string `json:"user_ids"`
+	MaxAccountAge int      `json:"max_account_age"` // days
+	NamePattern   string   `json:"name_pattern"`
+}
+
+func (f JoinlogFilter) Validate() error {
+	if f.MaxAccountAge < 0 {
+		return errors.New("max account age must not be negative")
+	}
+	if len(f.NamePattern) > MaxJoinlogNamePatternLen {
+		return fmt.Errorf("name pattern must not be longer than %d characters", MaxJoinlogNamePatternLen)
+	}
+	if _, err := regexp.Compile(f.NamePattern); err != nil {
+		return fmt.Errorf("invalid name pattern: %s", err.Error())
+	}
+	return nil
+}
+
+// Apply returns all entries matching the filter.
+// now is used to calculate the account age of
+// the entries.
+func (f JoinlogFilter) Apply(now time.Time, entries []JoinLogEntry) ([]JoinLogEntry, error) {
+	var rx *regexp.Regexp
+	if f.NamePattern != "" {
+		var err error
+		if rx, err = regexp.Compile(f.NamePattern); err != nil {
+			return nil, err
+		}
+	}
+
+	maxAge := time.Duration(f.MaxAccountAge) * 24 * time.Hour
+
+	res := make([]JoinLogEntry, 0, len(entries))
+	for _, e := range entries {
+		if len(f.UserIDs) != 0 && !stringutil.ContainsAny(e.UserID, f.UserIDs) {
+			continue
+		}
+		if maxAge != 0 && now.Sub(e.Created) >= maxAge {
+			continue
+		}
+		if rx != nil && !rx.MatchString(e.Tag) {
+			continue
+		}
+		res = append(res, e)
+	}
+
+	return res, nil
+}
+
+// JoinlogActionRequest describes a moderation action
+// applied to the filtered entries of the join log.
+type JoinlogActionRequest struct {
+	Action JoinlogAction `json:"action"`
+	Filter JoinlogFilter `json:"filter"`
+	Reason string        `json:"reason"`
+}
+
+func (r JoinlogActionRequest) Validate() error {
+	if !r.Action.Valid() {
+		return fmt.Errorf("invalid action '%s'", r.Action)
+	}
+	if r.Action != JoinlogActionVerify && r.Reason == "" {
+		return errors.New("reason must be specified for bans and kicks")
+	}
+	return r.Filter.Validate()
+}
+
+// JoinlogActionProgress holds the progress of a
+// moderation action applied to the join log.
+type JoinlogActionProgress struct {
+	GuildID    string        `json:"guild_id"`
+	ExecutorID string        `json:"executor_id"`
+	Action     JoinlogAction `json:"action"`
+	Total      int           `json:"total"`
+	Done       int           `json:"done"`
+	Failed     int           `json:"failed"`
+	Errors     []string      `json:"errors"`
+	Started    time.Time     `json:"started"`
+	Finished   *time.Time    `json:"finished"` // nil while running
+}
+
+func (p JoinlogActionProgress) Running() bool {
+	return p.Finished == nil
+}
+
+// AddError records a failed entry. Only the first
+// MaxJoinlogActionErrors errors are kept.
+func (p *JoinlogActionProgress) AddError(userID string, err error) {
+	p.Failed++
+	if len(p.Errors) < MaxJoinlogActionErrors {
+		p.Errors = append(p.Errors, fmt.Sprintf("%s: %s", userID, err.Error()))
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+type KarmaSource string
+
+const (
+	KarmaSourceReaction  KarmaSource = "REACTION"
+	KarmaSourceStarboard KarmaSource = "STARBOARD"
+	KarmaSourceManual    KarmaSource = "MANUAL"
+	KarmaSourcePenalty   KarmaSource = "PENALTY"
+	KarmaSourceDecay     KarmaSource = "DECAY"
+)
+
+// KarmaLedgerEntry records a single change of the karma
+// of a member.
+type KarmaLedgerEntry struct {
+	ID         snowflake.ID `json:"id"`
+	GuildID    string       `json:"guild_id"`
+	ExecutorID string       `json:"executor_id"` // empty if not caused by a member
+	TargetID   string       `json:"target_id"`
+	Delta      int          `json:"delta"`
+	Source     KarmaSource  `json:"source"`
+	Timestamp  time.Time    `json:"timestamp"`
+	RevertedBy snowflake.ID `json:"reverted_by,omitempty"` // ID of the entry reverting this one
+}
+
+type KarmaPeriod string
+
+const (
+	KarmaPeriodAll   KarmaPeriod = "all"
+	KarmaPeriodMonth KarmaPeriod = "month"
+	KarmaPeriodWeek  KarmaPeriod = "week"
+)
+
+// Duration returns the time span covered by the period
+// up to now. For KarmaPeriodAll, 0 is returned. ok is
+// false if the period is invalid.
+func (p KarmaPeriod) Duration() (d time.Duration, ok bool) {
+	switch p {
+	case KarmaPeriodAll:
+		return 0, true
+	case KarmaPeriodMonth:
+		return 30 * 24 * time.Hour, true
+	case KarmaPeriodWeek:
+		return 7 * 24 * time.Hour, true
+	default:
+		return 0, false
+	}
+}
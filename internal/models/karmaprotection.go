@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// KarmaDecay describes how the karma of members who
+// neither gave nor received karma for a while decays
+// toward zero.
+type KarmaDecay struct {
+	After  int `json:"after"`  // seconds of inactivity; 0 disables decay
+	Amount int `json:"amount"` // karma moved toward zero per day
+	// EnabledAt is the time decay has been enabled. Members
+	// are not considered inactive before this time.
+	EnabledAt time.Time `json:"enabled_at"`
+}
+
+// Enabled returns true if decay is configured.
+func (d KarmaDecay) Enabled() bool {
+	return d.After > 0 && d.Amount > 0
+}
+
+// Track returns d with EnabledAt set to now if decay is
+// enabled by d but not by the previous settings prev.
+// If decay stays enabled, EnabledAt of prev is kept.
+// If decay is disabled, EnabledAt is reset.
+func (d KarmaDecay) Track(prev KarmaDecay, now time.Time) KarmaDecay {
+	switch {
+	case !d.Enabled():
+		d.EnabledAt = time.Time{}
+	case prev.Enabled() && !prev.EnabledAt.IsZero():
+		d.EnabledAt = prev.EnabledAt
+	default:
+		d.EnabledAt = now
+	}
+	return d
+}
+
+// InactiveSince returns the time before which members
+// must not have been active to decay at now. ok is false
+// if decay has been enabled for less than the inactivity
+// period, so no member can be inactive long enough yet.
+func (d KarmaDecay) InactiveSince(now time.Time) (t time.Time, ok bool) {
+	t = now.Add(-time.Duration(d.After) * time.Second)
+	return t, !d.EnabledAt.After(t)
+}
+
+// Validate returns an error if the decay settings
+// are invalid.
+func (d KarmaDecay) Validate() error {
+	if d.After < 0 || d.Amount < 0 {
+		return errors.New("decay values must not be negative")
+	}
+	if d.After > 0 && d.After < int((24*time.Hour).Seconds()) {
+		return errors.New("decay must not start before one day of inactivity")
+	}
+	return nil
+}
+
+// KarmaFarmingLimits holds the settings used to detect and
+// prevent members from farming karma for each other.
+//
+// A member can give at most PairLimit karma to the same member
+// within PairWindow seconds. Members who gave karma at least
+// SockPuppetThreshold times within the last 30 days, all of it
+// to the same member, are flagged as suspected sock puppets.
+type KarmaFarmingLimits struct {
+	PairLimit           int `json:"pair_limit"`            // 0 disables the limit
+	PairWindow          int `json:"pair_window"`           // seconds
+	SockPuppetThreshold int `json:"sock_puppet_threshold"` // 0 disables detection
+}
+
+// Validate returns an error if the farming limits
+// are invalid.
+func (l KarmaFarmingLimits) Validate() error {
+	if l.PairLimit < 0 || l.PairWindow < 0 || l.SockPuppetThreshold < 0 {
+		return errors.New("farming limit values must not be negative")
+	}
+	if l.PairLimit > 0 && l.PairWindow == 0 {
+		return errors.New("pair window must be set when a pair limit is set")
+	}
+	if l.SockPuppetThreshold == 1 {
+		return errors.New("sock puppet threshold must be at least 2")
+	}
+	return nil
+}
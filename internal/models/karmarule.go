@@ -2,6 +2,8 @@ package models
 
 import (
 	"errors"
+	"strconv"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/zekroTJA/shinpuru/pkg/checksum"
@@ -14,11 +16,16 @@ const (
 	KarmaActionKick        KarmaAction = "KICK"
 	KarmaActionBan         KarmaAction = "BAN"
 	KarmaActionSendMessage KarmaAction = "SEND_MESSAGE"
+	KarmaActionTimeout     KarmaAction = "TIMEOUT"
+	KarmaActionAnnounce    KarmaAction = "ANNOUNCE"
+	KarmaActionTempRole    KarmaAction = "TEMP_ROLE"
+	KarmaActionGrantTokens KarmaAction = "GRANT_TOKENS"
 )
 
 func (a KarmaAction) Validate() bool {
 	switch a {
-	case KarmaActionToggleRole, KarmaActionKick, KarmaActionBan, KarmaActionSendMessage:
+	case KarmaActionToggleRole, KarmaActionKick, KarmaActionBan, KarmaActionSendMessage,
+		KarmaActionTimeout, KarmaActionAnnounce, KarmaActionTempRole, KarmaActionGrantTokens:
 		return true
 	default:
 		return false
@@ -30,6 +37,7 @@ type KarmaTriggerType int
 const (
 	KarmaTriggerBelow KarmaTriggerType = iota
 	KarmaTriggerAbove
+	KarmaTriggerRange
 
 	karmaTriggerMax
 )
@@ -38,30 +46,125 @@ func (tt KarmaTriggerType) Validate() bool {
 	return tt >= 0 && tt < karmaTriggerMax
 }
 
+// KarmaRule executes Action when the karma of a member
+// starts matching the trigger. Toggled roles are removed
+// again when the karma stops matching the trigger.
+//
+// Rules scoped to Channels are only evaluated for karma
+// changes caused in one of the channels. Rules scoped to
+// Roles only apply to members having one of the roles.
 type KarmaRule struct {
-	ID       snowflake.ID     `json:"id"`
-	GuildID  string           `json:"guildid"`
-	Trigger  KarmaTriggerType `json:"trigger"`
-	Value    int              `json:"value"`
-	Action   KarmaAction      `json:"action"`
-	Argument string           `json:"argument"`
-	Checksum string           `json:"-"`
+	ID        snowflake.ID     `json:"id"`
+	GuildID   string           `json:"guildid"`
+	Trigger   KarmaTriggerType `json:"trigger"`
+	Value     int              `json:"value"`
+	MaxValue  int              `json:"maxvalue"` // upper bound of KarmaTriggerRange
+	Action    KarmaAction      `json:"action"`
+	Argument  string           `json:"argument"`
+	ChannelID string           `json:"channelid"` // target channel of KarmaActionAnnounce
+	Duration  int              `json:"duration"`  // seconds; used by timeouts, temporary roles and granted tokens
+	Cooldown  int              `json:"cooldown"`  // seconds until the rule can be applied to the same member again
+	Channels  []string         `json:"channels"`
+	Roles     []string         `json:"roles"`
+	Checksum  string           `json:"-"`
 }
 
 func (r *KarmaRule) Validate() error {
 	if !r.Trigger.Validate() {
 		return errors.New("invalid value for trigger")
 	}
+	if r.Trigger == KarmaTriggerRange && r.MaxValue < r.Value {
+		return errors.New("max value must not be smaller than value")
+	}
 	if !r.Action.Validate() {
 		return errors.New("invalid value for action")
 	}
+	if r.Duration < 0 || r.Cooldown < 0 {
+		return errors.New("duration and cooldown must not be negative")
+	}
+
+	switch r.Action {
+	case KarmaActionToggleRole, KarmaActionTempRole:
+		if r.Argument == "" {
+			return errors.New("argument must be a role")
+		}
+		if r.Action == KarmaActionTempRole && r.Duration == 0 {
+			return errors.New("duration must be set for temporary roles")
+		}
+	case KarmaActionTimeout:
+		if r.Duration == 0 || time.Duration(r.Duration)*time.Second > MaxMuteDuration {
+			return errors.New("timeout duration must be between 1 second and 28 days")
+		}
+	case KarmaActionAnnounce:
+		if r.ChannelID == "" || r.Argument == "" {
+			return errors.New("announcements require a channel and a message")
+		}
+	case KarmaActionGrantTokens:
+		if n, err := strconv.Atoi(r.Argument); err != nil || n < 1 {
+			return errors.New("argument must be a positive number of tokens")
+		}
+	}
 
 	return nil
 }
 
+// Matches returns true if the given karma value
+// satisfies the trigger of the rule.
+func (r *KarmaRule) Matches(value int) bool {
+	switch r.Trigger {
+	case KarmaTriggerBelow:
+		return value < r.Value
+	case KarmaTriggerAbove:
+		return value > r.Value
+	case KarmaTriggerRange:
+		return value >= r.Value && value <= r.MaxValue
+	default:
+		return false
+	}
+}
+
+// AppliesToChannel returns true if a karma change caused
+// in the given channel is in the scope of the rule.
+func (r *KarmaRule) AppliesToChannel(channelID string) bool {
+	if len(r.Channels) == 0 {
+		return true
+	}
+	for _, id := range r.Channels {
+		if id == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesToRoles returns true if a member with the given
+// roles is in the scope of the rule.
+func (r *KarmaRule) AppliesToRoles(roleIDs []string) bool {
+	if len(r.Roles) == 0 {
+		return true
+	}
+	for _, id := range r.Roles {
+		for _, roleID := range roleIDs {
+			if id == roleID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (r *KarmaRule) CalculateChecksum() string {
 	cop := *r
 	cop.ID = 0
 	r.Checksum = checksum.Must(checksum.SumMd5(&cop))
 	return r.Checksum
 }
+
+// KarmaTempRole is a role added to a member by a
+// karma rule which is removed after Expires.
+type KarmaTempRole struct {
+	GuildID string    `json:"guild_id"`
+	UserID  string    `json:"user_id"`
+	RoleID  string    `json:"role_id"`
+	Expires time.Time `json:"expires"`
+}
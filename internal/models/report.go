@@ -72,9 +72,33 @@ type Report struct {
 	Msg           string       `json:"message"`
 	AttachmentURL string       `json:"attachment_url"`
 	Timeout       *time.Time   `json:"timeout"`
+	LinkedID      snowflake.ID `json:"linked_id,omitempty"`
 	Anonymous     bool         `json:"-"`
 }
 
+// ReportRevision holds the state of a report after
+// it has been created or amended. The first revision
+// holds the original state of the report.
+type ReportRevision struct {
+	ReportID      snowflake.ID `json:"report_id"`
+	Version       int          `json:"version"`
+	GuildID       string       `json:"guild_id"`
+	EditorID      string       `json:"editor_id"`
+	Timestamp     time.Time    `json:"timestamp"`
+	Msg           string       `json:"message"`
+	AttachmentURL string       `json:"attachment_url"`
+	Timeout       *time.Time   `json:"timeout"`
+}
+
+// ReportAmendment describes the changes made to a
+// report. Nil values are left unchanged.
+type ReportAmendment struct {
+	Msg           *string    `json:"message"`
+	AttachmentURL *string    `json:"attachment_url"`
+	Timeout       *time.Time `json:"timeout"`
+	RemoveTimeout bool       `json:"remove_timeout"`
+}
+
 // GetTimestamp returns the timestamp when the
 // report was generated from the reports ID
 // snowflake.
@@ -126,6 +150,13 @@ func (r *Report) AsEmbed(publicAddr string) *discordgo.MessageEmbed {
 		})
 	}
 
+	if r.LinkedID != 0 {
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name:  "Escalated From",
+			Value: "Case " + r.LinkedID.String(),
+		})
+	}
+
 	if r.Type == TypeBan {
 		emb.Description = fmt.Sprintf(
 			"If you want to submit an unbanrequest, you can do this [here](%s/unbanme).", publicAddr)
@@ -1,8 +1,185 @@
 package models
 
+import (
+	"errors"
+	"sort"
+)
+
+// RoleSelectMode defines how the roles of a role
+// select group can be picked.
+type RoleSelectMode int
+
+const (
+	// RoleSelectModeToggle lets members add and
+	// remove any roles of the group.
+	RoleSelectModeToggle RoleSelectMode = iota
+	// RoleSelectModeUnique lets members have only one
+	// role of the group. Picking another role swaps
+	// out the current one.
+	RoleSelectModeUnique
+	// RoleSelectModeLimited lets members have up to
+	// MaxRoles roles of the group.
+	RoleSelectModeLimited
+	// RoleSelectModeVerify lets members only add
+	// roles of the group.
+	RoleSelectModeVerify
+
+	roleSelectModeMax
+)
+
+// RoleSelectMaxRoles is the maximum number of roles of
+// a role select group, which is the maximum number of
+// buttons or select menu options of a message.
+const RoleSelectMaxRoles = 25
+
+// RoleSelect is a single role of a role select
+// group attached to a message.
+//
+// The group settings are stored with each role
+// and are equal for all roles of a message.
 type RoleSelect struct {
 	GuildID   string
 	ChannelID string
 	MessageID string
 	RoleID    string
+
+	Label    string
+	Emoji    string
+	Position int
+
+	Mode          RoleSelectMode
+	MaxRoles      int
+	RequiredRoles []string
+	Menu          bool
+}
+
+// RoleSelectOption is a role of a role select group
+// with an optional custom label and emoji.
+type RoleSelectOption struct {
+	RoleID string `json:"role_id"`
+	Label  string `json:"label"`
+	Emoji  string `json:"emoji"`
+}
+
+// RoleSelectGroup contains the roles and settings
+// of the role selection attached to a message.
+//
+// If RequiredRoles is set, members must have at least
+// one of them to pick roles of the group. If Menu is
+// true, the roles are displayed as select menu instead
+// of buttons.
+type RoleSelectGroup struct {
+	GuildID       string             `json:"guild_id"`
+	ChannelID     string             `json:"channel_id"`
+	MessageID     string             `json:"message_id"`
+	Mode          RoleSelectMode     `json:"mode"`
+	MaxRoles      int                `json:"max_roles"`
+	RequiredRoles []string           `json:"required_roles"`
+	Menu          bool               `json:"menu"`
+	Roles         []RoleSelectOption `json:"roles"`
+}
+
+// Validate returns an error if the role
+// select group is invalid.
+func (g *RoleSelectGroup) Validate() error {
+	if len(g.Roles) == 0 || len(g.Roles) > RoleSelectMaxRoles {
+		return errors.New("a role select must have 1 to 25 roles")
+	}
+	if g.Mode < 0 || g.Mode >= roleSelectModeMax {
+		return errors.New("invalid value for mode")
+	}
+	if g.Mode == RoleSelectModeLimited && (g.MaxRoles < 1 || g.MaxRoles > len(g.Roles)) {
+		return errors.New("max roles must be between 1 and the number of roles")
+	}
+
+	seen := make(map[string]struct{}, len(g.Roles))
+	for _, r := range g.Roles {
+		if r.RoleID == "" {
+			return errors.New("role ID must be set")
+		}
+		if _, ok := seen[r.RoleID]; ok {
+			return errors.New("roles must be unique")
+		}
+		seen[r.RoleID] = struct{}{}
+		if len(r.Label) > 80 {
+			return errors.New("labels must not be longer than 80 characters")
+		}
+	}
+
+	return nil
+}
+
+// IndexOf returns the index of the role in the
+// group or -1 if the role is not part of the group.
+func (g *RoleSelectGroup) IndexOf(roleID string) int {
+	for i, r := range g.Roles {
+		if r.RoleID == roleID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Has returns true if the role is part of the group.
+func (g *RoleSelectGroup) Has(roleID string) bool {
+	return g.IndexOf(roleID) != -1
+}
+
+// RoleSelects returns the roles of the group
+// with the settings of the group attached.
+func (g *RoleSelectGroup) RoleSelects() []RoleSelect {
+	rs := make([]RoleSelect, len(g.Roles))
+	for i, r := range g.Roles {
+		rs[i] = RoleSelect{
+			GuildID:       g.GuildID,
+			ChannelID:     g.ChannelID,
+			MessageID:     g.MessageID,
+			RoleID:        r.RoleID,
+			Label:         r.Label,
+			Emoji:         r.Emoji,
+			Position:      i,
+			Mode:          g.Mode,
+			MaxRoles:      g.MaxRoles,
+			RequiredRoles: g.RequiredRoles,
+			Menu:          g.Menu,
+		}
+	}
+	return rs
+}
+
+// GroupRoleSelects groups the passed role selects by
+// their messages. The roles of each group are ordered
+// by their position.
+func GroupRoleSelects(rs []RoleSelect) []RoleSelectGroup {
+	rs = append([]RoleSelect{}, rs...)
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].Position < rs[j].Position
+	})
+
+	var groups []RoleSelectGroup
+	index := make(map[string]int)
+	for _, r := range rs {
+		key := r.GuildID + ":" + r.ChannelID + ":" + r.MessageID
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, RoleSelectGroup{
+				GuildID:       r.GuildID,
+				ChannelID:     r.ChannelID,
+				MessageID:     r.MessageID,
+				Mode:          r.Mode,
+				MaxRoles:      r.MaxRoles,
+				RequiredRoles: r.RequiredRoles,
+				Menu:          r.Menu,
+			})
+		}
+		groups[i].Roles = append(groups[i].Roles, RoleSelectOption{
+			RoleID: r.RoleID,
+			Label:  r.Label,
+			Emoji:  r.Emoji,
+		})
+	}
+
+	return groups
 }
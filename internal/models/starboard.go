@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"regexp"
 )
 
 type StarboardSortBy int
@@ -12,17 +14,95 @@ const (
 	StarboardSortByMostRated
 )
 
+// StarboardNSFWMode defines how messages from NSFW
+// channels are handled by a starboard which is not
+// posting into an NSFW channel itself.
+type StarboardNSFWMode int
+
+const (
+	// StarboardNSFWBlur posts the message with blurred images.
+	StarboardNSFWBlur StarboardNSFWMode = iota
+	// StarboardNSFWSkip does not post the message at all.
+	StarboardNSFWSkip
+	// StarboardNSFWRoute posts the message uncensored
+	// into the NSFW channel of the starboard.
+	StarboardNSFWRoute
+
+	starboardNSFWMax
+)
+
+// DefaultStarboardName is the name of the starboard which
+// has been migrated from the former single starboard config.
+const DefaultStarboardName = "default"
+
+var rxStarboardName = regexp.MustCompile(`^[\w\-]{1,32}$`)
+
+// StarboardConfig describes a named starboard of a guild.
+//
+// Messages are only considered when they were sent in one
+// of Channels, if set, and not in one of ExcludedChannels.
+// Messages younger than MinAge seconds are not posted.
 type StarboardConfig struct {
-	GuildID   string
-	ChannelID string
-	Threshold int
-	EmojiID   string
-	KarmaGain int
+	GuildID          string            `json:"guild_id"`
+	Name             string            `json:"name"`
+	ChannelID        string            `json:"channel_id"`
+	Threshold        int               `json:"threshold"`
+	EmojiID          string            `json:"emoji_id"`
+	KarmaGain        int               `json:"karma_gain"`
+	Channels         []string          `json:"channels"`
+	ExcludedChannels []string          `json:"excluded_channels"`
+	NSFW             StarboardNSFWMode `json:"nsfw"`
+	NSFWChannelID    string            `json:"nsfw_channel_id"`
+	MinAge           int               `json:"min_age"`
+}
+
+// Validate returns an error if the starboard
+// config is invalid.
+func (c *StarboardConfig) Validate() error {
+	if !rxStarboardName.MatchString(c.Name) {
+		return errors.New("name must consist of 1 to 32 letters, numbers, dashes or underscores")
+	}
+	if c.EmojiID == "" {
+		return errors.New("emoji must be set")
+	}
+	if c.Threshold < 0 || c.KarmaGain < 0 || c.MinAge < 0 {
+		return errors.New("threshold, karma gain and minimum age must not be negative")
+	}
+	if c.NSFW < 0 || c.NSFW >= starboardNSFWMax {
+		return errors.New("invalid value for nsfw")
+	}
+	if c.NSFW == StarboardNSFWRoute && c.NSFWChannelID == "" {
+		return errors.New("nsfw channel must be set when routing nsfw messages")
+	}
+	return nil
+}
+
+// AppliesToChannel returns true if messages sent in
+// the given channel are considered by the starboard.
+func (c *StarboardConfig) AppliesToChannel(channelID string) bool {
+	for _, id := range c.ExcludedChannels {
+		if id == channelID {
+			return false
+		}
+	}
+	if len(c.Channels) == 0 {
+		return true
+	}
+	for _, id := range c.Channels {
+		if id == channelID {
+			return true
+		}
+	}
+	return false
 }
 
+// StarboardEntry is a message posted to a starboard.
+// A message can only be posted to one of the starboards
+// of a guild at a time.
 type StarboardEntry struct {
 	MessageID   string   `json:"message_id"`
 	StarboardID string   `json:"starboard_id"`
+	Board       string   `json:"board"`
 	GuildID     string   `json:"guild_id"`
 	ChannelID   string   `json:"channel_id"`
 	AuthorID    string   `json:"author_id"`
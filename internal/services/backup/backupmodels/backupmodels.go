@@ -1,7 +1,9 @@
 package backupmodels
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -12,11 +14,28 @@ type Entry struct {
 	GuildID   string    `json:"guild_id"`
 	Timestamp time.Time `json:"timestamp"`
 	FileID    string    `json:"file_id"`
+	ParentID  string    `json:"parent_id"`
+	Changes   int       `json:"changes"`
 }
 
 func (t Entry) String() string {
-	return fmt.Sprintf("`%s` - (ID: `%s`)",
-		t.TimestampFormatted(), t.FileID)
+	return fmt.Sprintf("`%s` - %s (ID: `%s`)",
+		t.TimestampFormatted(), t.Kind(), t.FileID)
+}
+
+// IsFull returns true if the backup is a full
+// snapshot which does not depend on a parent.
+func (t Entry) IsFull() bool {
+	return t.ParentID == ""
+}
+
+// Kind returns a short description of the type
+// of the backup.
+func (t Entry) Kind() string {
+	if t.IsFull() {
+		return "full"
+	}
+	return fmt.Sprintf("+%d changes", t.Changes)
 }
 
 func (t Entry) StringIndexed(i int) string {
@@ -42,6 +61,7 @@ type Object struct {
 
 // Guild contains general properties of the guild.
 type Guild struct {
+	ID                          string `json:"id"`
 	Name                        string `json:"name"`
 	AfkChannelID                string `json:"afk_channel_id"`
 	AfkTimeout                  int    `json:"afk_timeout"`
@@ -82,3 +102,181 @@ type Member struct {
 	Mute  bool     `json:"mute"`
 	Roles []string `json:"roles"`
 }
+
+// RestoreComponent specifies a part of a guild
+// backup which can be restored selectively.
+type RestoreComponent string
+
+const (
+	RestoreComponentGuild       RestoreComponent = "guild"
+	RestoreComponentRoles       RestoreComponent = "roles"
+	RestoreComponentChannels    RestoreComponent = "channels"
+	RestoreComponentMemberRoles RestoreComponent = "memberroles"
+	RestoreComponentNicknames   RestoreComponent = "nicknames"
+)
+
+// RestoreComponents contains all restorable
+// components of a backup.
+var RestoreComponents = []RestoreComponent{
+	RestoreComponentGuild,
+	RestoreComponentRoles,
+	RestoreComponentChannels,
+	RestoreComponentMemberRoles,
+	RestoreComponentNicknames,
+}
+
+// RestoreOptions specifies which parts of a backup
+// are restored into which guild.
+type RestoreOptions struct {
+	// Components which are restored. If empty,
+	// all components are restored.
+	Components []RestoreComponent `json:"components,omitempty"`
+	// IDs of channels in the backup which are restored.
+	// Selecting a category also selects all channels in
+	// it. If empty, all channels are restored.
+	Channels []string `json:"channels,omitempty"`
+	// ID of the guild the backup is restored into. If
+	// empty, the backup is restored into the guild the
+	// backup was created from.
+	TargetGuildID string `json:"target_guild_id,omitempty"`
+}
+
+// Has returns true when the given component
+// is selected by the options.
+func (o RestoreOptions) Has(c RestoreComponent) bool {
+	if len(o.Components) == 0 {
+		return true
+	}
+	for _, oc := range o.Components {
+		if oc == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error when unknown
+// components are specified.
+func (o RestoreOptions) Validate() error {
+	for _, oc := range o.Components {
+		var ok bool
+		for _, c := range RestoreComponents {
+			if ok = c == oc; ok {
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("unknown restore component: %s", oc)
+		}
+	}
+	return nil
+}
+
+// RestoreOperationType specifies the kind of change
+// applied to a guild by a restore operation.
+type RestoreOperationType string
+
+const (
+	RestoreOpEditGuild       RestoreOperationType = "edit_guild"
+	RestoreOpCreateRole      RestoreOperationType = "create_role"
+	RestoreOpUpdateRole      RestoreOperationType = "update_role"
+	RestoreOpReorderRoles    RestoreOperationType = "reorder_roles"
+	RestoreOpCreateChannel   RestoreOperationType = "create_channel"
+	RestoreOpUpdateChannel   RestoreOperationType = "update_channel"
+	RestoreOpReorderChannels RestoreOperationType = "reorder_channels"
+	RestoreOpUpdateMember    RestoreOperationType = "update_member"
+)
+
+// RestoreOperation describes a single change which is
+// applied to a guild when restoring a backup.
+type RestoreOperation struct {
+	// ID identifies the operation across multiple
+	// plans of the same backup so that already
+	// completed operations can be skipped.
+	ID   string               `json:"id"`
+	Type RestoreOperationType `json:"type"`
+	// Name of the affected object.
+	Target string `json:"target"`
+	// ID of the object in the backup.
+	BackupID string `json:"backup_id,omitempty"`
+	// ID of the object in the target guild. Empty
+	// when the object will be created.
+	CurrentID string `json:"current_id,omitempty"`
+	// Human readable list of changed properties.
+	Changes []string `json:"changes,omitempty"`
+}
+
+func (op RestoreOperation) String() string {
+	s := fmt.Sprintf("%s `%s`", op.Type, op.Target)
+	if len(op.Changes) != 0 {
+		s += " (" + strings.Join(op.Changes, ", ") + ")"
+	}
+	return s
+}
+
+// RestorePlan contains all operations required to
+// restore a backup into a guild.
+type RestorePlan struct {
+	GuildID       string             `json:"guild_id"`
+	TargetGuildID string             `json:"target_guild_id"`
+	FileID        string             `json:"file_id"`
+	Options       RestoreOptions     `json:"options"`
+	Operations    []RestoreOperation `json:"operations"`
+	// Number of operations skipped because they have
+	// been completed by a previous, interrupted
+	// restore.
+	Completed int `json:"completed"`
+}
+
+// Summary returns the number of planned
+// operations by their type.
+func (p *RestorePlan) Summary() map[RestoreOperationType]int {
+	res := make(map[RestoreOperationType]int)
+	for _, op := range p.Operations {
+		res[op.Type]++
+	}
+	return res
+}
+
+// RestoreCheckpoint holds the progress of a restore
+// into a guild so that an interrupted restore can be
+// resumed.
+type RestoreCheckpoint struct {
+	GuildID string    `json:"guild_id"`
+	FileID  string    `json:"file_id"`
+	Updated time.Time `json:"updated"`
+
+	Options   RestoreOptions    `json:"options"`
+	Completed []string          `json:"completed"`
+	IDs       map[string]string `json:"ids"`
+}
+
+type restoreCheckpointState struct {
+	Options   RestoreOptions    `json:"options"`
+	Completed []string          `json:"completed"`
+	IDs       map[string]string `json:"ids"`
+}
+
+// StateEncoded returns the options, completed operations
+// and ID mappings of the checkpoint encoded as JSON.
+func (c *RestoreCheckpoint) StateEncoded() (string, error) {
+	data, err := json.Marshal(restoreCheckpointState{
+		Options:   c.Options,
+		Completed: c.Completed,
+		IDs:       c.IDs,
+	})
+	return string(data), err
+}
+
+// SetState decodes the given state created by
+// StateEncoded into the checkpoint.
+func (c *RestoreCheckpoint) SetState(encoded string) error {
+	var state restoreCheckpointState
+	if err := json.Unmarshal([]byte(encoded), &state); err != nil {
+		return err
+	}
+	c.Options = state.Options
+	c.Completed = state.Completed
+	c.IDs = state.IDs
+	return nil
+}
@@ -0,0 +1,25 @@
+package backupmodels
+
+import "time"
+
+// DataKey is a guild specific key which is used to
+// encrypt the stored objects of the guilds backups.
+//
+// Key contains the base64 encoded data key wrapped
+// by the master key with the ID MasterKeyID.
+type DataKey struct {
+	ID          string    `json:"id"`
+	GuildID     string    `json:"guild_id"`
+	MasterKeyID string    `json:"master_key_id"`
+	Key         string    `json:"-"`
+	Created     time.Time `json:"created"`
+}
+
+// EncryptionStatus describes the encryption
+// of the backups of a guild.
+type EncryptionStatus struct {
+	Enabled    bool      `json:"enabled"`
+	KeyID      string    `json:"key_id,omitempty"`
+	KeyCreated time.Time `json:"key_created"`
+	Keys       int       `json:"keys"`
+}
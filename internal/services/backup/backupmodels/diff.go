@@ -0,0 +1,84 @@
+package backupmodels
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// FieldChange describes the change of a single
+// property of a guild, role, channel or member.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// RoleDiff contains the changes of a role which
+// exists in both compared backups.
+type RoleDiff struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// OverwriteDiff describes the change of a permission
+// overwrite of a channel. Old is nil if the overwrite
+// has been added and New is nil if it has been removed.
+type OverwriteDiff struct {
+	ID   string                            `json:"id"`
+	Type discordgo.PermissionOverwriteType `json:"type"`
+	Old  *discordgo.PermissionOverwrite    `json:"old"`
+	New  *discordgo.PermissionOverwrite    `json:"new"`
+}
+
+// ChannelDiff contains the changes of a channel
+// which exists in both compared backups.
+type ChannelDiff struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Changes    []FieldChange   `json:"changes"`
+	Overwrites []OverwriteDiff `json:"overwrites"`
+}
+
+// MemberDiff contains the changes of a member
+// which exists in both compared backups.
+type MemberDiff struct {
+	ID           string        `json:"id"`
+	AddedRoles   []string      `json:"added_roles"`
+	RemovedRoles []string      `json:"removed_roles"`
+	Changes      []FieldChange `json:"changes"`
+}
+
+// Diff contains all changes of a guild between
+// two backups.
+type Diff struct {
+	GuildID       string    `json:"guild_id"`
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	FromTimestamp time.Time `json:"from_timestamp"`
+	ToTimestamp   time.Time `json:"to_timestamp"`
+
+	Guild []FieldChange `json:"guild"`
+
+	RolesAdded   []*Role    `json:"roles_added"`
+	RolesRemoved []*Role    `json:"roles_removed"`
+	RolesChanged []RoleDiff `json:"roles_changed"`
+
+	ChannelsAdded   []*Channel    `json:"channels_added"`
+	ChannelsRemoved []*Channel    `json:"channels_removed"`
+	ChannelsChanged []ChannelDiff `json:"channels_changed"`
+
+	MembersAdded   []string     `json:"members_added"`
+	MembersRemoved []string     `json:"members_removed"`
+	MembersChanged []MemberDiff `json:"members_changed"`
+}
+
+// IsEmpty returns true if there are no
+// changes between the backups.
+func (d *Diff) IsEmpty() bool {
+	return len(d.Guild) == 0 &&
+		len(d.RolesAdded) == 0 && len(d.RolesRemoved) == 0 && len(d.RolesChanged) == 0 &&
+		len(d.ChannelsAdded) == 0 && len(d.ChannelsRemoved) == 0 && len(d.ChannelsChanged) == 0 &&
+		len(d.MembersAdded) == 0 && len(d.MembersRemoved) == 0 && len(d.MembersChanged) == 0
+}
@@ -0,0 +1,108 @@
+package backupmodels
+
+import (
+	"time"
+)
+
+// ManifestVersion is the format version of backup
+// manifests. Legacy backups, which contain the whole
+// Object instead of a manifest, carry no version.
+const ManifestVersion = 2
+
+// Kinds of objects referenced in a manifest.
+const (
+	KindGuild    = "guild"
+	KindChannels = "channels"
+	KindRoles    = "roles"
+	KindMembers  = "members"
+)
+
+// Refs maps the content hashes of backed up objects
+// by their ID for each kind of object.
+type Refs map[string]map[string]string
+
+// Set sets the hash of the object with the
+// given kind and ID.
+func (r Refs) Set(kind, id, hash string) {
+	m, ok := r[kind]
+	if !ok {
+		m = make(map[string]string)
+		r[kind] = m
+	}
+	m[id] = hash
+}
+
+// Len returns the total number of referenced objects.
+func (r Refs) Len() (n int) {
+	for _, m := range r {
+		n += len(m)
+	}
+	return n
+}
+
+// Clone returns a deep copy of the refs.
+func (r Refs) Clone() Refs {
+	c := make(Refs, len(r))
+	for kind, m := range r {
+		for id, hash := range m {
+			c.Set(kind, id, hash)
+		}
+	}
+	return c
+}
+
+// Hashes returns the set of all referenced hashes.
+func (r Refs) Hashes() map[string]struct{} {
+	hashes := make(map[string]struct{})
+	for _, m := range r {
+		for _, hash := range m {
+			hashes[hash] = struct{}{}
+		}
+	}
+	return hashes
+}
+
+// Manifest describes a stored backup. Instead of the
+// objects themselves, it references content-addressed
+// blobs by their hash.
+//
+// A manifest without parent is a full snapshot. Otherwise,
+// it only contains the objects which were added or changed
+// since the parent backup and the IDs of the objects which
+// were removed since then.
+type Manifest struct {
+	Version   int                 `json:"version"`
+	ID        string              `json:"id"`
+	GuildID   string              `json:"guild_id"`
+	ParentID  string              `json:"parent_id,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+	Objects   Refs                `json:"objects"`
+	Removed   map[string][]string `json:"removed,omitempty"`
+}
+
+// IsFull returns true if the manifest is a full
+// snapshot which does not depend on a parent.
+func (m *Manifest) IsFull() bool {
+	return m.ParentID == ""
+}
+
+// Changes returns the number of changed and
+// removed objects of the manifest.
+func (m *Manifest) Changes() int {
+	n := m.Objects.Len()
+	for _, ids := range m.Removed {
+		n += len(ids)
+	}
+	return n
+}
+
+// Entry returns the database entry of the manifest.
+func (m *Manifest) Entry() Entry {
+	return Entry{
+		GuildID:   m.GuildID,
+		Timestamp: m.Timestamp,
+		FileID:    m.ID,
+		ParentID:  m.ParentID,
+		Changes:   m.Changes(),
+	}
+}
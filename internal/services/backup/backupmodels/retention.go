@@ -0,0 +1,84 @@
+package backupmodels
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Upper limits of the retention periods.
+const (
+	MaxRetentionHourly = 7 * 24
+	MaxRetentionDaily  = 365
+	MaxRetentionWeekly = 5 * 52
+)
+
+// Retention describes for how long backups of a guild
+// are kept. The older backups get, the more they are
+// thinned out: one backup per hour is kept for the given
+// number of hours, one per day for the given number of
+// days and one per week for the given number of weeks.
+// The latest backup is always kept.
+type Retention struct {
+	Hourly int `json:"hourly"`
+	Daily  int `json:"daily"`
+	Weekly int `json:"weekly"`
+}
+
+// Validate returns an error when a period is
+// negative or exceeds its limit.
+func (r Retention) Validate() error {
+	if err := validatePeriod("hourly", r.Hourly, MaxRetentionHourly); err != nil {
+		return err
+	}
+	if err := validatePeriod("daily", r.Daily, MaxRetentionDaily); err != nil {
+		return err
+	}
+	return validatePeriod("weekly", r.Weekly, MaxRetentionWeekly)
+}
+
+func (r Retention) String() string {
+	var parts []string
+	if r.Hourly > 0 {
+		parts = append(parts, fmt.Sprintf("hourly for %dh", r.Hourly))
+	}
+	if r.Daily > 0 {
+		parts = append(parts, fmt.Sprintf("daily for %dd", r.Daily))
+	}
+	if r.Weekly > 0 {
+		parts = append(parts, fmt.Sprintf("weekly for %dw", r.Weekly))
+	}
+	if len(parts) == 0 {
+		return "latest only"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Encoded returns the retention encoded as
+// comma separated list of periods.
+func (r Retention) Encoded() string {
+	return fmt.Sprintf("%d,%d,%d", r.Hourly, r.Daily, r.Weekly)
+}
+
+// DecodeRetention parses a retention encoded
+// with Retention.Encoded.
+func DecodeRetention(s string) (r Retention, err error) {
+	split := strings.Split(s, ",")
+	if len(split) != 3 {
+		return r, fmt.Errorf("invalid retention encoding: %s", s)
+	}
+	periods := []*int{&r.Hourly, &r.Daily, &r.Weekly}
+	for i, v := range split {
+		if *periods[i], err = strconv.Atoi(v); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func validatePeriod(name string, v, max int) error {
+	if v < 0 || v > max {
+		return fmt.Errorf("%s retention must be in range [0, %d]", name, max)
+	}
+	return nil
+}
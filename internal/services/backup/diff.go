@@ -0,0 +1,262 @@
+package backup
+
+import (
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+)
+
+// Diff returns the changes of the guild between the
+// backups with the IDs from and to. ErrBackupNotFound
+// is returned if one of the backups does not exist.
+func (bck *GuildBackups) Diff(guildID, from, to string) (*backupmodels.Diff, error) {
+	fromBackup, err := bck.readBackup(guildID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toBackup, err := bck.readBackup(guildID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffObjects(fromBackup, toBackup)
+	diff.GuildID = guildID
+	diff.From = from
+	diff.To = to
+
+	return diff, nil
+}
+
+// fieldChanges collects the changes of
+// compared properties.
+type fieldChanges []backupmodels.FieldChange
+
+func (fc *fieldChanges) compare(field string, old, new any) {
+	if old != new {
+		*fc = append(*fc, backupmodels.FieldChange{Field: field, Old: old, New: new})
+	}
+}
+
+// diffObjects returns the changes between the
+// backup objects from and to.
+func diffObjects(from, to *backupmodels.Object) *backupmodels.Diff {
+	diff := &backupmodels.Diff{
+		From:          from.ID,
+		To:            to.ID,
+		FromTimestamp: from.Timestamp,
+		ToTimestamp:   to.Timestamp,
+	}
+
+	if from.Guild != nil && to.Guild != nil {
+		diff.Guild = diffGuild(from.Guild, to.Guild)
+	}
+
+	diffRoles(diff, from.Roles, to.Roles)
+	diffChannels(diff, from.Channels, to.Channels)
+	diffMembers(diff, from.Members, to.Members)
+
+	return diff
+}
+
+func diffGuild(from, to *backupmodels.Guild) []backupmodels.FieldChange {
+	var changes fieldChanges
+	changes.compare("name", from.Name, to.Name)
+	changes.compare("afk_channel_id", from.AfkChannelID, to.AfkChannelID)
+	changes.compare("afk_timeout", from.AfkTimeout, to.AfkTimeout)
+	changes.compare("verification_level", from.VerificationLevel, to.VerificationLevel)
+	changes.compare("default_message_notifications",
+		from.DefaultMessageNotifications, to.DefaultMessageNotifications)
+	return changes
+}
+
+func diffRoles(diff *backupmodels.Diff, from, to []*backupmodels.Role) {
+	fromRoles := make(map[string]*backupmodels.Role, len(from))
+	for _, r := range from {
+		fromRoles[r.ID] = r
+	}
+
+	for _, r := range to {
+		old, ok := fromRoles[r.ID]
+		if !ok {
+			diff.RolesAdded = append(diff.RolesAdded, r)
+			continue
+		}
+		delete(fromRoles, r.ID)
+
+		// Positions are not compared because they shift
+		// for all roles above whenever a role is created
+		// or removed.
+		var changes fieldChanges
+		changes.compare("name", old.Name, r.Name)
+		changes.compare("permissions", old.Permissions, r.Permissions)
+		changes.compare("color", old.Color, r.Color)
+		changes.compare("hoist", old.Hoist, r.Hoist)
+		changes.compare("mentionable", old.Mentionable, r.Mentionable)
+		if len(changes) != 0 {
+			diff.RolesChanged = append(diff.RolesChanged, backupmodels.RoleDiff{
+				ID:      r.ID,
+				Name:    r.Name,
+				Changes: changes,
+			})
+		}
+	}
+
+	for _, r := range fromRoles {
+		diff.RolesRemoved = append(diff.RolesRemoved, r)
+	}
+	sort.Slice(diff.RolesRemoved, func(i, j int) bool {
+		return diff.RolesRemoved[i].Position > diff.RolesRemoved[j].Position
+	})
+}
+
+func diffChannels(diff *backupmodels.Diff, from, to []*backupmodels.Channel) {
+	fromChannels := make(map[string]*backupmodels.Channel, len(from))
+	for _, c := range from {
+		fromChannels[c.ID] = c
+	}
+
+	for _, c := range to {
+		old, ok := fromChannels[c.ID]
+		if !ok {
+			diff.ChannelsAdded = append(diff.ChannelsAdded, c)
+			continue
+		}
+		delete(fromChannels, c.ID)
+
+		// As for roles, positions are not compared.
+		var changes fieldChanges
+		changes.compare("name", old.Name, c.Name)
+		changes.compare("type", old.Type, c.Type)
+		changes.compare("parent_id", old.ParentID, c.ParentID)
+		changes.compare("topic", old.Topic, c.Topic)
+		changes.compare("nsfw", old.NSFW, c.NSFW)
+		changes.compare("bitrate", old.Bitrate, c.Bitrate)
+		changes.compare("user_limit", old.UserLimit, c.UserLimit)
+
+		overwrites := diffOverwrites(old.PermissionOverwrites, c.PermissionOverwrites)
+
+		if len(changes) != 0 || len(overwrites) != 0 {
+			diff.ChannelsChanged = append(diff.ChannelsChanged, backupmodels.ChannelDiff{
+				ID:         c.ID,
+				Name:       c.Name,
+				Changes:    changes,
+				Overwrites: overwrites,
+			})
+		}
+	}
+
+	for _, c := range fromChannels {
+		diff.ChannelsRemoved = append(diff.ChannelsRemoved, c)
+	}
+	sort.Slice(diff.ChannelsRemoved, func(i, j int) bool {
+		return diff.ChannelsRemoved[i].Position < diff.ChannelsRemoved[j].Position
+	})
+}
+
+func diffOverwrites(from, to []*discordgo.PermissionOverwrite) (diffs []backupmodels.OverwriteDiff) {
+	fromOverwrites := make(map[string]*discordgo.PermissionOverwrite, len(from))
+	for _, o := range from {
+		fromOverwrites[o.ID] = o
+	}
+
+	for _, o := range to {
+		old, ok := fromOverwrites[o.ID]
+		if ok {
+			delete(fromOverwrites, o.ID)
+			if old.Allow == o.Allow && old.Deny == o.Deny {
+				continue
+			}
+		}
+		diffs = append(diffs, backupmodels.OverwriteDiff{
+			ID:   o.ID,
+			Type: o.Type,
+			Old:  old,
+			New:  o,
+		})
+	}
+
+	for _, o := range fromOverwrites {
+		diffs = append(diffs, backupmodels.OverwriteDiff{
+			ID:   o.ID,
+			Type: o.Type,
+			Old:  o,
+		})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].ID < diffs[j].ID
+	})
+
+	return diffs
+}
+
+func diffMembers(diff *backupmodels.Diff, from, to []*backupmodels.Member) {
+	fromMembers := make(map[string]*backupmodels.Member, len(from))
+	for _, m := range from {
+		fromMembers[m.ID] = m
+	}
+
+	for _, m := range to {
+		old, ok := fromMembers[m.ID]
+		if !ok {
+			diff.MembersAdded = append(diff.MembersAdded, m.ID)
+			continue
+		}
+		delete(fromMembers, m.ID)
+
+		var changes fieldChanges
+		changes.compare("nick", old.Nick, m.Nick)
+		changes.compare("deaf", old.Deaf, m.Deaf)
+		changes.compare("mute", old.Mute, m.Mute)
+
+		added, removed := diffStrings(old.Roles, m.Roles)
+
+		if len(changes) != 0 || len(added) != 0 || len(removed) != 0 {
+			diff.MembersChanged = append(diff.MembersChanged, backupmodels.MemberDiff{
+				ID:           m.ID,
+				AddedRoles:   added,
+				RemovedRoles: removed,
+				Changes:      changes,
+			})
+		}
+	}
+
+	for id := range fromMembers {
+		diff.MembersRemoved = append(diff.MembersRemoved, id)
+	}
+
+	sort.Strings(diff.MembersAdded)
+	sort.Strings(diff.MembersRemoved)
+	sort.Slice(diff.MembersChanged, func(i, j int) bool {
+		return diff.MembersChanged[i].ID < diff.MembersChanged[j].ID
+	})
+}
+
+// diffStrings returns the elements which are only
+// contained in to (added) and the elements which
+// are only contained in from (removed).
+func diffStrings(from, to []string) (added, removed []string) {
+	fromSet := make(map[string]struct{}, len(from))
+	for _, v := range from {
+		fromSet[v] = struct{}{}
+	}
+
+	for _, v := range to {
+		if _, ok := fromSet[v]; ok {
+			delete(fromSet, v)
+			continue
+		}
+		added = append(added, v)
+	}
+
+	for v := range fromSet {
+		removed = append(removed, v)
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	return added, removed
+}
@@ -0,0 +1,77 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+)
+
+func TestDiffObjects(t *testing.T) {
+	from := testBackup()
+	from.Channels[1].PermissionOverwrites = []*discordgo.PermissionOverwrite{
+		{ID: "role-a", Type: discordgo.PermissionOverwriteTypeRole, Allow: discordgo.PermissionSendMessages},
+		{ID: "role-b", Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionViewChannel},
+	}
+
+	to := testBackup()
+	to.ID = "backup-2"
+	to.Roles[0].Permissions = discordgo.PermissionAdministrator
+	to.Roles = append(to.Roles[:1], &backupmodels.Role{ID: "role-c", Name: "C", Position: 3})
+	to.Channels[1].Name = "renamed"
+	to.Channels[1].PermissionOverwrites = []*discordgo.PermissionOverwrite{
+		{ID: "role-a", Type: discordgo.PermissionOverwriteTypeRole, Allow: discordgo.PermissionSendMessages},
+		{ID: "user-1", Type: discordgo.PermissionOverwriteTypeMember, Allow: discordgo.PermissionManageMessages},
+	}
+	to.Channels[2].Position = 5
+	to.Members[0].Roles = []string{"role-a", "role-c"}
+	to.Members = append(to.Members[:1], &backupmodels.Member{ID: "user-3"})
+
+	diff := diffObjects(from, to)
+
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, "backup", diff.From)
+	assert.Equal(t, "backup-2", diff.To)
+	assert.Empty(t, diff.Guild)
+
+	assert.Equal(t, []*backupmodels.Role{to.Roles[1]}, diff.RolesAdded)
+	assert.Equal(t, []*backupmodels.Role{from.Roles[1]}, diff.RolesRemoved)
+	assert.Equal(t, []backupmodels.RoleDiff{{
+		ID:   "role-a",
+		Name: "A",
+		Changes: []backupmodels.FieldChange{
+			{Field: "permissions", Old: int64(0), New: int64(discordgo.PermissionAdministrator)},
+		},
+	}}, diff.RolesChanged)
+
+	assert.Empty(t, diff.ChannelsAdded)
+	assert.Empty(t, diff.ChannelsRemoved)
+	assert.Equal(t, []backupmodels.ChannelDiff{{
+		ID:      "text",
+		Name:    "renamed",
+		Changes: []backupmodels.FieldChange{{Field: "name", Old: "text", New: "renamed"}},
+		Overwrites: []backupmodels.OverwriteDiff{
+			{
+				ID:   "role-b",
+				Type: discordgo.PermissionOverwriteTypeRole,
+				Old:  from.Channels[1].PermissionOverwrites[1],
+			},
+			{
+				ID:   "user-1",
+				Type: discordgo.PermissionOverwriteTypeMember,
+				New:  to.Channels[1].PermissionOverwrites[1],
+			},
+		},
+	}}, diff.ChannelsChanged)
+
+	assert.Equal(t, []string{"user-3"}, diff.MembersAdded)
+	assert.Equal(t, []string{"user-2"}, diff.MembersRemoved)
+	assert.Equal(t, []backupmodels.MemberDiff{{
+		ID:           "user-1",
+		AddedRoles:   []string{"role-c"},
+		RemovedRoles: []string{"role-b"},
+	}}, diff.MembersChanged)
+
+	assert.True(t, diffObjects(testBackup(), testBackup()).IsEmpty())
+}
@@ -0,0 +1,537 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/storage"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/multierror"
+	"github.com/zekrotja/rogu"
+)
+
+// sealedMagic prefixes all stored objects which
+// are encrypted with a guild data key.
+var sealedMagic = []byte("SPBK\x01")
+
+// nonceSize is the size of the nonces
+// used for AES-GCM encryption.
+const nonceSize = 12
+
+var (
+	// ErrEncryptionDisabled is returned when an operation
+	// requires backup encryption but no master key is
+	// configured.
+	ErrEncryptionDisabled = errors.New("backup encryption is disabled")
+	// ErrEncryptionEnabled is returned when an operation
+	// would store backups unencrypted while backup
+	// encryption is enabled.
+	ErrEncryptionEnabled = errors.New("backup encryption is enabled")
+
+	errMissingMasterKey = errors.New("backup object is encrypted but no matching master key is configured")
+	errMalformedSealed  = errors.New("malformed encrypted backup object")
+)
+
+// objectStore reads and writes the objects of guild
+// backups. When a key ring is set, written objects are
+// encrypted with the current data key of the guild.
+// Encrypted objects are decrypted transparently on read
+// while unencrypted objects are returned as they are.
+type objectStore struct {
+	st   storage.Storage
+	keys *keyRing
+}
+
+// read returns the decrypted content of the
+// stored object with the given name.
+func (s *objectStore) read(name string) ([]byte, error) {
+	reader, _, err := s.st.GetObject(static.StorageBucketBackups, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(data, sealedMagic) {
+		return data, nil
+	}
+	if s.keys == nil {
+		return nil, errMissingMasterKey
+	}
+	return s.keys.open(name, data)
+}
+
+// writer returns a function which writes objects of
+// the guild. Objects are encrypted with the current
+// data key of the guild when encryption is enabled.
+func (s *objectStore) writer(guildID string) (func(name string, data []byte) error, error) {
+	var key *dataKey
+	if s.keys.enabled() {
+		var err error
+		if key, err = s.keys.current(guildID); err != nil {
+			return nil, err
+		}
+	}
+	return s.writerWithKey(key), nil
+}
+
+func (s *objectStore) writerWithKey(key *dataKey) func(name string, data []byte) error {
+	return func(name string, data []byte) (err error) {
+		mime := "application/json"
+		if key != nil {
+			mime = "application/octet-stream"
+			if data, err = key.seal(name, data); err != nil {
+				return err
+			}
+		}
+		return s.st.PutObject(static.StorageBucketBackups, name,
+			bytes.NewReader(data), int64(len(data)), mime)
+	}
+}
+
+// write writes a single object of the guild.
+func (s *objectStore) write(guildID, name string, data []byte) error {
+	w, err := s.writer(guildID)
+	if err != nil {
+		return err
+	}
+	return w(name, data)
+}
+
+func (s *objectStore) delete(name string) error {
+	return s.st.DeleteObject(static.StorageBucketBackups, name)
+}
+
+// dataKey is an unwrapped guild data key.
+type dataKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// seal encrypts data with the key. The name of the
+// object is authenticated as additional data so that
+// encrypted objects can not be swapped.
+func (k *dataKey) seal(name string, data []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(sealedMagic)+1+len(k.id)+len(nonce))
+	header = append(header, sealedMagic...)
+	header = append(header, byte(len(k.id)))
+	header = append(header, k.id...)
+	header = append(header, nonce...)
+
+	return k.aead.Seal(header, nonce, data, []byte(name)), nil
+}
+
+// parseSealed returns the data key ID, nonce and
+// cipher text of an encrypted object.
+func parseSealed(data []byte) (keyID string, nonce, ciphertext []byte, err error) {
+	data = data[len(sealedMagic):]
+	if len(data) < 1 || len(data) < 1+int(data[0])+nonceSize {
+		return "", nil, nil, errMalformedSealed
+	}
+	idLen := int(data[0])
+	keyID = string(data[1 : 1+idLen])
+	nonce = data[1+idLen : 1+idLen+nonceSize]
+	ciphertext = data[1+idLen+nonceSize:]
+	return keyID, nonce, ciphertext, nil
+}
+
+// keyRing manages the data keys of guilds. Data keys
+// are stored in the database wrapped by the master key.
+//
+// Keys wrapped by one of the previous master keys are
+// re-wrapped with the current master key on access.
+type keyRing struct {
+	db  database.Database
+	tp  timeprovider.Provider
+	log rogu.Logger
+
+	masterID string
+	masters  map[string]cipher.AEAD
+
+	mtx       sync.Mutex
+	unwrapped sync.Map // data key ID -> *dataKey
+}
+
+// newKeyRing returns a new key ring with the configured
+// master keys. nil is returned if no keys are configured.
+func newKeyRing(
+	cfg models.BackupEncryption,
+	db database.Database,
+	tp timeprovider.Provider,
+	log rogu.Logger,
+) (*keyRing, error) {
+	if cfg.MasterKey == "" && len(cfg.PreviousKeys) == 0 {
+		return nil, nil
+	}
+
+	kr := &keyRing{
+		db:      db,
+		tp:      tp,
+		log:     log,
+		masters: make(map[string]cipher.AEAD),
+	}
+
+	for i, enc := range append([]string{cfg.MasterKey}, cfg.PreviousKeys...) {
+		if enc == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid backup master key %d: must be a base64 encoded 32 byte key", i)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		id := masterKeyID(key)
+		kr.masters[id] = aead
+		if i == 0 {
+			kr.masterID = id
+		}
+	}
+
+	return kr, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// masterKeyID returns an identifier of the master key
+// which does not reveal the key itself.
+func masterKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// enabled returns true if new objects
+// shall be encrypted.
+func (kr *keyRing) enabled() bool {
+	return kr != nil && kr.masterID != ""
+}
+
+// current returns the newest data key of the guild.
+// If the guild has no data key yet, a new one is
+// created.
+func (kr *keyRing) current(guildID string) (*dataKey, error) {
+	kr.mtx.Lock()
+	defer kr.mtx.Unlock()
+
+	newest, err := newestKey(kr.db, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	if newest == nil {
+		return kr.create(guildID, time.Time{})
+	}
+	return kr.unwrap(*newest)
+}
+
+// rotate creates a new data key for the guild which
+// replaces the current data key for new objects.
+func (kr *keyRing) rotate(guildID string) (*dataKey, error) {
+	kr.mtx.Lock()
+	defer kr.mtx.Unlock()
+
+	newest, err := newestKey(kr.db, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous time.Time
+	if newest != nil {
+		previous = newest.Created
+	}
+	return kr.create(guildID, previous)
+}
+
+// newestKey returns the latest created data key of
+// the guild or nil if the guild has no data keys.
+func newestKey(db database.Database, guildID string) (*backupmodels.DataKey, error) {
+	keys, err := db.GetBackupKeys(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return nil, err
+	}
+
+	var newest *backupmodels.DataKey
+	for i, k := range keys {
+		if newest == nil || k.Created.After(newest.Created) {
+			newest = &keys[i]
+		}
+	}
+	return newest, nil
+}
+
+// create generates a new data key for the guild which
+// becomes its current data key. The creation time of the
+// key is set after the passed time of the previous key.
+func (kr *keyRing) create(guildID string, previous time.Time) (*dataKey, error) {
+	if !kr.enabled() {
+		return nil, ErrEncryptionDisabled
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	created := kr.tp.Now()
+	if !created.After(previous) {
+		created = previous.Add(time.Second)
+	}
+
+	k := backupmodels.DataKey{
+		ID:      hex.EncodeToString(id),
+		GuildID: guildID,
+		Created: created,
+	}
+	if err := kr.wrap(&k, raw); err != nil {
+		return nil, err
+	}
+	if err := kr.db.AddBackupKey(k); err != nil {
+		return nil, err
+	}
+
+	return kr.cache(k.ID, raw)
+}
+
+func (kr *keyRing) wrap(k *backupmodels.DataKey, raw []byte) error {
+	master := kr.masters[kr.masterID]
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	wrapped := master.Seal(nonce, nonce, raw, wrapAD(*k))
+	k.MasterKeyID = kr.masterID
+	k.Key = base64.StdEncoding.EncodeToString(wrapped)
+	return nil
+}
+
+// unwrap decrypts the data key. If the key was wrapped
+// by a previous master key, it is re-wrapped with the
+// current master key.
+func (kr *keyRing) unwrap(k backupmodels.DataKey) (*dataKey, error) {
+	if v, ok := kr.unwrapped.Load(k.ID); ok && k.MasterKeyID == kr.masterID {
+		return v.(*dataKey), nil
+	}
+
+	master, ok := kr.masters[k.MasterKeyID]
+	if !ok {
+		return nil, errMissingMasterKey
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < nonceSize {
+		return nil, errMalformedSealed
+	}
+	nonce, ciphertext := wrapped[:nonceSize], wrapped[nonceSize:]
+	raw, err := master.Open(nil, nonce, ciphertext, wrapAD(k))
+	if err != nil {
+		return nil, fmt.Errorf("failed unwrapping data key %s: %w", k.ID, err)
+	}
+
+	if kr.enabled() && k.MasterKeyID != kr.masterID {
+		if err = kr.wrap(&k, raw); err == nil {
+			err = kr.db.UpdateBackupKey(k)
+		}
+		if err != nil {
+			kr.log.Error().Err(err).Field("gid", k.GuildID).Field("key", k.ID).
+				Msg("Failed re-wrapping backup data key")
+		}
+	}
+
+	return kr.cache(k.ID, raw)
+}
+
+func (kr *keyRing) cache(id string, raw []byte) (*dataKey, error) {
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	key := &dataKey{id: id, aead: aead}
+	kr.unwrapped.Store(id, key)
+	return key, nil
+}
+
+// rewrap re-wraps all data keys of the guild which are
+// not wrapped by the current master key.
+func (kr *keyRing) rewrap(guildID string) error {
+	if !kr.enabled() {
+		return nil
+	}
+
+	keys, err := kr.db.GetBackupKeys(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	for _, k := range keys {
+		if k.MasterKeyID == kr.masterID {
+			continue
+		}
+		if _, err = kr.unwrap(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// open decrypts the encrypted object with the
+// given name.
+func (kr *keyRing) open(name string, data []byte) ([]byte, error) {
+	keyID, nonce, ciphertext, err := parseSealed(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var key *dataKey
+	if v, ok := kr.unwrapped.Load(keyID); ok {
+		key = v.(*dataKey)
+	} else {
+		k, err := kr.db.GetBackupKey(keyID)
+		if database.IsErrDatabaseNotFound(err) {
+			return nil, fmt.Errorf("data key %s of backup object %s does not exist", keyID, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if key, err = kr.unwrap(k); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err = key.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed decrypting backup object %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// wrapAD returns the additional data authenticated
+// when wrapping the data key.
+func wrapAD(k backupmodels.DataKey) []byte {
+	return []byte(k.GuildID + "/" + k.ID)
+}
+
+// Encryption returns the encryption status
+// of the backups of the guild.
+func (bck *GuildBackups) Encryption(guildID string) (status backupmodels.EncryptionStatus, err error) {
+	status.Enabled = bck.st.keys.enabled()
+
+	keys, err := bck.db.GetBackupKeys(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return status, err
+	}
+	status.Keys = len(keys)
+
+	newest, err := newestKey(bck.db, guildID)
+	if err != nil || newest == nil {
+		return status, err
+	}
+	status.KeyID = newest.ID
+	status.KeyCreated = newest.Created
+
+	return status, nil
+}
+
+// RotateKey creates a new data key for the guild and
+// re-encrypts all stored objects of its backups with it,
+// including objects which were stored unencrypted.
+// Afterwards, all previous data keys of the guild are
+// deleted. The number of re-encrypted objects is returned.
+//
+// ErrEncryptionDisabled is returned if no master
+// key is configured.
+func (bck *GuildBackups) RotateKey(guildID string) (n int, err error) {
+	if !bck.st.keys.enabled() {
+		return 0, ErrEncryptionDisabled
+	}
+
+	defer bck.lockGuild(guildID)()
+
+	entries, err := bck.db.GetBackups(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return 0, err
+	}
+
+	// All objects must be known because objects which are
+	// not re-encrypted would become unreadable when the
+	// previous keys are deleted.
+	names, err := storedObjects(bck.st, entries)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := bck.st.keys.rotate(guildID)
+	if err != nil {
+		return 0, err
+	}
+
+	write := bck.st.writerWithKey(key)
+	for _, name := range names {
+		data, err := bck.st.read(name)
+		if err != nil {
+			return n, fmt.Errorf("failed reading backup object %s: %w", name, err)
+		}
+		if err = write(name, data); err != nil {
+			return n, fmt.Errorf("failed writing backup object %s: %w", name, err)
+		}
+		n++
+	}
+
+	return n, bck.deleteKeys(guildID, key.id)
+}
+
+// deleteKeys deletes all data keys of the
+// guild except the key with the ID keep.
+func (bck *GuildBackups) deleteKeys(guildID, keep string) error {
+	keys, err := bck.db.GetBackupKeys(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	mErr := multierror.New()
+	for _, k := range keys {
+		if k.ID != keep {
+			mErr.Append(bck.db.DeleteBackupKey(k.ID))
+		}
+	}
+
+	return mErr.Nillify()
+}
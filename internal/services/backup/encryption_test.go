@@ -0,0 +1,156 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+)
+
+func testMasterKey(t *testing.T) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func setKeyRing(t *testing.T, bck *GuildBackups, cfg models.BackupEncryption) {
+	keys, err := newKeyRing(cfg, bck.db, timeprovider.Time{}, bck.log)
+	require.NoError(t, err)
+	bck.st.keys = keys
+}
+
+func readRaw(t *testing.T, bck *GuildBackups, name string) []byte {
+	reader, _, err := bck.st.st.GetObject(static.StorageBucketBackups, name)
+	require.NoError(t, err)
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return data
+}
+
+func TestNewKeyRing(t *testing.T) {
+	keys, err := newKeyRing(models.BackupEncryption{}, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, keys)
+	assert.False(t, keys.enabled())
+
+	_, err = newKeyRing(models.BackupEncryption{MasterKey: "c2hvcnQ="}, nil, nil, nil)
+	assert.Error(t, err)
+
+	keys, err = newKeyRing(models.BackupEncryption{PreviousKeys: []string{testMasterKey(t)}}, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, keys.enabled())
+}
+
+func TestEncryptedBackups(t *testing.T) {
+	bck := testBackups(t)
+	master1 := testMasterKey(t)
+	setKeyRing(t, bck, models.BackupEncryption{MasterKey: master1})
+
+	b := testBackup()
+	b.ID = "1"
+	b.Timestamp = time.Unix(1700000000, 0)
+	_, err := bck.storeSnapshot(b)
+	require.NoError(t, err)
+
+	entries, err := bck.Timeline("guild")
+	require.NoError(t, err)
+	names, err := bck.StoredObjects(entries)
+	require.NoError(t, err)
+
+	for _, name := range names {
+		data := readRaw(t, bck, name)
+		assert.True(t, bytes.HasPrefix(data, sealedMagic))
+		assert.NotContains(t, string(data), "role-a")
+	}
+
+	loaded, err := bck.GetBackup("guild", "1")
+	require.NoError(t, err)
+	assertRefs(t, b, loaded)
+
+	// Encrypted objects can not be moved to
+	// another name.
+	sealed := readRaw(t, bck, "1")
+	require.NoError(t, bck.st.st.PutObject(static.StorageBucketBackups, "moved",
+		bytes.NewReader(sealed), int64(len(sealed)), ""))
+	_, err = bck.st.read("moved")
+	assert.Error(t, err)
+
+	// Without master key, encrypted backups
+	// can not be read.
+	setKeyRing(t, bck, models.BackupEncryption{})
+	_, err = bck.GetBackup("guild", "1")
+	assert.ErrorIs(t, err, errMissingMasterKey)
+
+	// After rotating the master key, the data keys are
+	// re-wrapped when accessed with the previous key.
+	master2 := testMasterKey(t)
+	setKeyRing(t, bck, models.BackupEncryption{MasterKey: master2, PreviousKeys: []string{master1}})
+	require.NoError(t, bck.st.keys.rewrap("guild"))
+
+	keys, err := bck.db.GetBackupKeys("guild")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, bck.st.keys.masterID, keys[0].MasterKeyID)
+
+	setKeyRing(t, bck, models.BackupEncryption{MasterKey: master2})
+	loaded, err = bck.GetBackup("guild", "1")
+	require.NoError(t, err)
+	assertRefs(t, b, loaded)
+
+	// Rotating the data key re-encrypts all objects
+	// and removes the previous data key.
+	n, err := bck.RotateKey("guild")
+	require.NoError(t, err)
+	assert.Equal(t, len(names), n)
+
+	status, err := bck.Encryption("guild")
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, 1, status.Keys)
+	assert.NotEqual(t, keys[0].ID, status.KeyID)
+
+	setKeyRing(t, bck, models.BackupEncryption{MasterKey: master2})
+	loaded, err = bck.GetBackup("guild", "1")
+	require.NoError(t, err)
+	assertRefs(t, b, loaded)
+
+	_, err = bck.PurgeBackups("guild")
+	require.NoError(t, err)
+	keys, err = bck.db.GetBackupKeys("guild")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestRotateKeyUnencrypted(t *testing.T) {
+	bck := testBackups(t)
+
+	b := testBackup()
+	b.ID = "1"
+	b.Timestamp = time.Unix(1700000000, 0)
+	_, err := bck.storeSnapshot(b)
+	require.NoError(t, err)
+
+	_, err = bck.RotateKey("guild")
+	assert.ErrorIs(t, err, ErrEncryptionDisabled)
+
+	// Enabling encryption and rotating the key
+	// encrypts existing backups.
+	setKeyRing(t, bck, models.BackupEncryption{MasterKey: testMasterKey(t)})
+	_, err = bck.RotateKey("guild")
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(readRaw(t, bck, "1"), sealedMagic))
+
+	loaded, err := bck.GetBackup("guild", "1")
+	require.NoError(t, err)
+	assertRefs(t, b, loaded)
+}
@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/multierror"
+)
+
+// exportExpiry is the duration presigned URLs of
+// backup exports are valid. Afterwards, the export
+// is deleted from the storage.
+const exportExpiry = 10 * time.Minute
+
+// exportsPrefix is the prefix of the storage
+// object names of all backup exports.
+const exportsPrefix = "export_"
+
+// exportPrefix returns the prefix of the storage
+// object names of the guilds backup exports.
+func exportPrefix(guildID string) string {
+	return exportsPrefix + guildID + "_"
+}
+
+// ExportFileName returns the file name of
+// the export of the given backup.
+func ExportFileName(guildID, fileID string) string {
+	return fmt.Sprintf("backup_%s_%s.gz", guildID, fileID)
+}
+
+// WriteExport writes the backup as gzip
+// compressed JSON document to w.
+func WriteExport(w io.Writer, guildID string, backup *backupmodels.Object) error {
+	zf := gzip.NewWriter(w)
+	zf.Name = fmt.Sprintf("backup_%s_%s.json", guildID, backup.ID)
+
+	enc := json.NewEncoder(zf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(backup); err != nil {
+		return err
+	}
+
+	return zf.Close()
+}
+
+// EncryptionEnabled returns true if stored
+// backups are encrypted.
+func (bck *GuildBackups) EncryptionEnabled() bool {
+	return bck.st.keys.enabled()
+}
+
+// ExportURL stores an export of the backup as created by
+// WriteExport and returns a presigned URL to download it
+// directly from the storage. The export is deleted after
+// the URL has expired.
+//
+// Because exports are stored unencrypted, ErrEncryptionEnabled
+// is returned when backup encryption is enabled.
+func (bck *GuildBackups) ExportURL(guildID, fileID string) (string, error) {
+	if bck.EncryptionEnabled() {
+		return "", ErrEncryptionEnabled
+	}
+
+	backup, err := bck.readBackup(guildID, fileID)
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err = rand.Read(suffix); err != nil {
+		return "", err
+	}
+	name := exportPrefix(guildID) + fileID + "_" + hex.EncodeToString(suffix)
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(WriteExport(pw, guildID, backup))
+	}()
+
+	err = bck.st.st.PutObjectStream(static.StorageBucketBackups, name, pr, "application/gzip")
+	pr.Close()
+	if err != nil {
+		return "", err
+	}
+
+	time.AfterFunc(exportExpiry, func() {
+		if err := bck.st.delete(name); err != nil {
+			bck.log.Error().Err(err).Field("name", name).Msg("Failed deleting backup export")
+		}
+	})
+
+	return bck.st.st.PresignGetObject(static.StorageBucketBackups, name,
+		exportExpiry, ExportFileName(guildID, fileID))
+}
+
+// cleanupExports deletes all backup exports with
+// the given prefix which are older than the given
+// duration.
+func (bck *GuildBackups) cleanupExports(prefix string, olderThan time.Duration) error {
+	objects, err := bck.st.st.ListObjects(static.StorageBucketBackups, prefix)
+	if err != nil {
+		return err
+	}
+
+	mErr := multierror.New()
+	for _, obj := range objects {
+		if bck.tp.Now().Sub(obj.LastModified) >= olderThan {
+			mErr.Append(bck.st.delete(obj.Name))
+		}
+	}
+
+	return mErr.Nillify()
+}
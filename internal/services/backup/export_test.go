@@ -0,0 +1,83 @@
+package backup
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/url"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+)
+
+func TestExportURL(t *testing.T) {
+	bck := testBackups(t)
+	bck.tp = timeprovider.Time{}
+
+	b := testBackup()
+	b.ID = "1"
+	b.Timestamp = time.Unix(1700000000, 0)
+	_, err := bck.storeSnapshot(b)
+	require.NoError(t, err)
+
+	rawURL, err := bck.ExportURL("guild", "1")
+	require.NoError(t, err)
+
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	assert.Equal(t, ExportFileName("guild", "1"), u.Query().Get("filename"))
+
+	name := path.Base(u.Path)
+	reader, _, err := bck.st.st.GetObject(static.StorageBucketBackups, name)
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(reader)
+	require.NoError(t, err)
+	var exported backupmodels.Object
+	require.NoError(t, json.NewDecoder(zr).Decode(&exported))
+	reader.Close()
+	assertRefs(t, b, &exported)
+
+	objects, err := bck.st.st.ListObjects(static.StorageBucketBackups, exportsPrefix)
+	require.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	// Exports are only removed after they expired.
+	require.NoError(t, bck.cleanupExports(exportsPrefix, exportExpiry))
+	objects, err = bck.st.st.ListObjects(static.StorageBucketBackups, exportsPrefix)
+	require.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	_, err = bck.PurgeBackups("guild")
+	require.NoError(t, err)
+	objects, err = bck.st.st.ListObjects(static.StorageBucketBackups, "")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	setKeyRing(t, bck, models.BackupEncryption{MasterKey: testMasterKey(t)})
+	_, err = bck.ExportURL("guild", "1")
+	assert.ErrorIs(t, err, ErrEncryptionEnabled)
+}
+
+func TestWriteExport(t *testing.T) {
+	b := testBackup()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(WriteExport(pw, "guild", b))
+	}()
+
+	zr, err := gzip.NewReader(pr)
+	require.NoError(t, err)
+	assert.Equal(t, "backup_guild_backup.json", zr.Name)
+
+	var exported backupmodels.Object
+	require.NoError(t, json.NewDecoder(zr).Decode(&exported))
+	assert.Equal(t, b.Roles, exported.Roles)
+}
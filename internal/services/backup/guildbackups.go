@@ -1,13 +1,14 @@
 package backup
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/sarulabs/di/v2"
 	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/config"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
 	"github.com/zekroTJA/shinpuru/internal/services/storage"
@@ -15,7 +16,7 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/util/snowflakenodes"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/discordutil"
-	"github.com/zekroTJA/shinpuru/pkg/inline"
+	"github.com/zekroTJA/shinpuru/pkg/multierror"
 	"github.com/zekrotja/dgrs"
 	"github.com/zekrotja/rogu"
 	"github.com/zekrotja/rogu/log"
@@ -26,14 +27,31 @@ import (
 
 // GuildBackups provides functionalities to backup
 // and restore a guild to and from a JSON file.
+//
+// Backups are stored incrementally: each backup only
+// references the objects which changed since its parent
+// backup. The objects themselves are stored as blobs
+// addressed by the hash of their content.
+//
+// When a backup master key is configured, all stored
+// objects are encrypted with guild specific data keys.
 type GuildBackups struct {
 	session *discordgo.Session
+	cfg     config.Provider
 	db      database.Database
 	gl      guildlog.Logger
-	st      storage.Storage
+	st      *objectStore
 	state   *dgrs.State
 	tp      timeprovider.Provider
 	log     rogu.Logger
+
+	// IDs of guilds a backup is currently
+	// restored into
+	restoring sync.Map
+
+	// mutexes of guilds which prevent writing
+	// backups while the data key is rotated
+	guildLocks sync.Map
 }
 
 // writeStatus writes the passed status to the
@@ -62,18 +80,37 @@ func (bck *GuildBackups) guilds() (guilds []string, err error) {
 
 // New initializes a new GuildBackups instance using
 // the passed discordgo Session, database provider,
-// and storage provider. Also, the ticker loop is
-// initialized.
-func New(container di.Container) *GuildBackups {
+// and storage provider. An error is returned if the
+// configured backup master keys are invalid.
+func New(container di.Container) (*GuildBackups, error) {
 	bck := new(GuildBackups)
+	bck.cfg = container.Get(static.DiConfig).(config.Provider)
 	bck.db = container.Get(static.DiDatabase).(database.Database)
 	bck.gl = container.Get(static.DiGuildLog).(guildlog.Logger).Section("backup")
-	bck.st = container.Get(static.DiObjectStorage).(storage.Storage)
 	bck.session = container.Get(static.DiDiscordSession).(*discordgo.Session)
 	bck.state = container.Get(static.DiState).(*dgrs.State)
 	bck.tp = container.Get(static.DiTimeProvider).(timeprovider.Provider)
 	bck.log = log.Tagged("GuildBackup")
-	return bck
+
+	keys, err := newKeyRing(bck.cfg.Config().Backups.Encryption, bck.db, bck.tp, bck.log)
+	if err != nil {
+		return nil, err
+	}
+	bck.st = &objectStore{
+		st:   container.Get(static.DiObjectStorage).(storage.Storage),
+		keys: keys,
+	}
+
+	return bck, nil
+}
+
+// lockGuild locks the mutex of the guild and
+// returns a function to unlock it.
+func (bck *GuildBackups) lockGuild(guildID string) func() {
+	v, _ := bck.guildLocks.LoadOrStore(guildID, &sync.Mutex{})
+	mtx := v.(*sync.Mutex)
+	mtx.Lock()
+	return mtx.Unlock
 }
 
 // BackupAllGuilds iterates through all guilds
@@ -91,6 +128,10 @@ func (bck *GuildBackups) BackupAllGuilds() {
 		return
 	}
 
+	if err = bck.cleanupExports(exportsPrefix, exportExpiry); err != nil {
+		bck.log.Error().Err(err).Msg("Failed cleaning up expired backup exports")
+	}
+
 	for _, g := range guilds {
 		err = bck.BackupGuild(g)
 		if err != nil {
@@ -101,10 +142,11 @@ func (bck *GuildBackups) BackupAllGuilds() {
 	}
 }
 
-// BackupGuild creates a backup of a single guild
-// and writes the resulting JSON file to the specified
-// storage. If the backup creation fails, the error is
-// returned.
+// BackupGuild creates a backup of a single guild,
+// stores it as increment of the previous backup and
+// removes the backups which are no more retained by
+// the guilds retention policy. If the backup creation
+// fails, the error is returned.
 func (bck *GuildBackups) BackupGuild(guildID string) error {
 	if bck.session == nil {
 		return errors.New("session is nil")
@@ -119,6 +161,7 @@ func (bck *GuildBackups) BackupGuild(guildID string) error {
 	backup.Timestamp = bck.tp.Now()
 
 	backup.Guild = &backupmodels.Guild{
+		ID:                          g.ID,
 		AfkChannelID:                g.AfkChannelID,
 		AfkTimeout:                  g.AfkTimeout,
 		DefaultMessageNotifications: int(g.DefaultMessageNotifications),
@@ -133,15 +176,16 @@ func (bck *GuildBackups) BackupGuild(guildID string) error {
 
 	for _, c := range chans {
 		backup.Channels = append(backup.Channels, &backupmodels.Channel{
-			Bitrate:   c.Bitrate,
-			ID:        c.ID,
-			NSFW:      c.NSFW,
-			Name:      c.Name,
-			ParentID:  c.ParentID,
-			Position:  c.Position,
-			Topic:     c.Topic,
-			Type:      c.Type,
-			UserLimit: c.UserLimit,
+			Bitrate:              c.Bitrate,
+			ID:                   c.ID,
+			NSFW:                 c.NSFW,
+			Name:                 c.Name,
+			ParentID:             c.ParentID,
+			PermissionOverwrites: c.PermissionOverwrites,
+			Position:             c.Position,
+			Topic:                c.Topic,
+			Type:                 c.Type,
+			UserLimit:            c.UserLimit,
 		})
 	}
 
@@ -176,302 +220,186 @@ func (bck *GuildBackups) BackupGuild(guildID string) error {
 
 	backup.ID = snowflakenodes.NodeBackup.Generate().String()
 
-	buff := bytes.NewBuffer([]byte{})
+	defer bck.lockGuild(g.ID)()
 
-	enc := json.NewEncoder(buff)
-	enc.SetIndent("", "  ")
-	err = enc.Encode(backup)
-	if err != nil {
-		return err
+	if err = bck.st.keys.rewrap(g.ID); err != nil {
+		bck.log.Warn().Err(err).Field("gid", g.ID).Msg("Failed re-wrapping backup data keys")
 	}
 
-	err = bck.st.PutObject(static.StorageBucketBackups, backup.ID, buff, int64(buff.Len()), "application/json")
-	if err != nil {
+	if _, err = bck.storeSnapshot(backup); err != nil {
 		return err
 	}
 
-	err = bck.db.AddBackup(g.ID, backup.ID)
+	return bck.applyRetention(g.ID)
+}
+
+// storeSnapshot stores the blobs and the manifest of
+// the backup and adds the backup to the database.
+//
+// The backup is stored as increment of the latest backup
+// of the guild. When there is no previous backup or the
+// configured amount of incremental backups since the last
+// full snapshot is reached, a full snapshot is stored.
+func (bck *GuildBackups) storeSnapshot(backup *backupmodels.Object) (m *backupmodels.Manifest, err error) {
+	guildID := backup.Guild.ID
+
+	refs, blobs, err := snapshotRefs(backup)
 	if err != nil {
-		bck.st.DeleteObject(static.StorageBucketBackups, backup.ID)
-		return err
+		return nil, err
 	}
 
-	cBackups, err := bck.db.GetBackups(g.ID)
-	if err != nil {
-		return err
+	m = &backupmodels.Manifest{
+		Version:   backupmodels.ManifestVersion,
+		ID:        backup.ID,
+		GuildID:   guildID,
+		Timestamp: backup.Timestamp,
+		Objects:   refs,
 	}
 
-	if len(cBackups) > 10 {
-		var lastEntry *backupmodels.Entry
-		for _, b := range cBackups {
-			if lastEntry == nil || b.Timestamp.Before(lastEntry.Timestamp) {
-				lastEntry = &b
+	// Hashes of blobs which are already stored
+	// and must not be written again.
+	stored := map[string]struct{}{}
+
+	latest, err := bck.latestBackup(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	if latest != nil {
+		parent, err := readManifest(bck.st, latest.FileID)
+		if err == nil {
+			var prev backupmodels.Refs
+			var depth int
+			prev, depth, err = resolveRefs(bck.st, parent)
+			if err == nil {
+				stored = prev.Hashes()
+				if depth < bck.cfg.Config().Backups.FullInterval {
+					m.ParentID = parent.ID
+					m.Objects, m.Removed = diffRefs(prev, refs)
+				}
 			}
 		}
-
-		err = bck.st.DeleteObject(static.StorageBucketBackups, lastEntry.FileID)
-		if err != nil {
-			return err
-		}
-
-		err = bck.db.DeleteBackup(g.ID, lastEntry.FileID)
-		if err != nil {
-			return err
+		if err != nil && !errors.Is(err, errLegacyBackup) {
+			bck.log.Warn().Err(err).Field("gid", guildID).Field("parent", latest.FileID).
+				Msg("Failed resolving latest backup; creating full snapshot")
 		}
 	}
 
-	return err
-}
+	write, err := bck.st.writer(guildID)
+	if err != nil {
+		return nil, err
+	}
 
-// RestoreBackup tries to restore a guild structure by
-// backup file specified via fileID. The current status
-// is sent into the statusC channel and occured errors
-// are pushed into the errorsC channel.
-// Only if the initialization of this method has failed,
-// an error is returned. The returned error does not
-// represent the success result of the backup restore
-// process.
-func (bck *GuildBackups) RestoreBackup(guildID, fileID string, statusC chan string, errorsC chan error) error {
+	var written []string
 	defer func() {
-		close(statusC)
-		close(errorsC)
+		if err == nil {
+			return
+		}
+		for _, name := range written {
+			bck.st.delete(name)
+		}
 	}()
 
-	bck.log.Debug().Field("guildId", guildID).Msg("Starting backup restoration ...")
-
-	if bck.session == nil {
-		return errors.New("session is nil")
-	}
-
-	// READING BACKUP FILE
-	bck.log.Debug().Field("guildId", guildID).Msg("Reading backup file ...")
-	writeStatus(statusC, "reading backup file")
-	reader, _, err := bck.st.GetObject(static.StorageBucketBackups, fileID)
-	if err != nil {
-		return err
+	for hash, data := range blobs {
+		if _, ok := stored[hash]; ok {
+			continue
+		}
+		name := blobName(guildID, hash)
+		if err = write(name, data); err != nil {
+			return nil, err
+		}
+		written = append(written, name)
 	}
-	defer reader.Close()
-
-	var backup backupmodels.Object
 
-	dec := json.NewDecoder(reader)
-	err = dec.Decode(&backup)
-	if err != nil {
-		return err
+	if err = writeManifest(bck.st, m); err != nil {
+		return nil, err
 	}
+	written = append(written, m.ID)
 
-	if bck.session == nil {
-		return errors.New("session is nil")
+	if err = bck.db.AddBackup(m.Entry()); err != nil {
+		return nil, err
 	}
 
-	// EDIT GUILD
-	bck.log.Debug().Field("guildId", guildID).Msg("Editing guild ...")
-	writeStatus(statusC, "editing guild")
-	_verificationLevel := discordgo.VerificationLevel(backup.Guild.VerificationLevel)
-	_, err = bck.session.GuildEdit(guildID, &discordgo.GuildParams{
-		Name:                        backup.Guild.Name,
-		AfkChannelID:                backup.Guild.AfkChannelID,
-		AfkTimeout:                  backup.Guild.AfkTimeout,
-		VerificationLevel:           &_verificationLevel,
-		DefaultMessageNotifications: backup.Guild.DefaultMessageNotifications,
-	})
-	if err != nil {
-		return err
-	}
-
-	// backup ID - created ID
-	ids := make(map[string]string)
-	channelsPos := make(map[string]int)
-	orderedRoles := make(map[int]*discordgo.Role)
+	return m, nil
+}
 
-	// CREATE ROLES
-	bck.log.Debug().Field("guildId", guildID).Msg("Create roles ...")
-	writeStatus(statusC, "updating and creating roles")
-	roles, err := bck.session.GuildRoles(guildID)
-	if err != nil {
-		return err
+// latestBackup returns the latest backup entry of
+// the guild or nil if the guild has no backups.
+func (bck *GuildBackups) latestBackup(guildID string) (*backupmodels.Entry, error) {
+	entries, err := bck.Timeline(guildID)
+	if err != nil || len(entries) == 0 {
+		return nil, err
 	}
-	for _, r := range backup.Roles {
-		var rObj *discordgo.Role
-		for _, crObj := range roles {
-			if crObj.ID == r.ID {
-				rObj = crObj
-			}
-		}
-		if rObj == nil {
-			rObj, err = bck.session.GuildRoleCreate(guildID, &discordgo.RoleParams{
-				Name:        r.Name,
-				Color:       &r.Color,
-				Hoist:       &r.Hoist,
-				Permissions: &r.Permissions,
-				Mentionable: &r.Mentionable,
-			})
-			if err != nil {
-				writeError(errorsC, err)
-				continue
-			}
-		}
+	return &entries[0], nil
+}
 
-		ids[r.ID] = rObj.ID
-		orderedRoles[r.Position-1] = rObj
+// Timeline returns the backups of the guild
+// sorted from the newest to the oldest one.
+func (bck *GuildBackups) Timeline(guildID string) ([]backupmodels.Entry, error) {
+	entries, err := bck.db.GetBackups(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return nil, err
 	}
 
-	// RE-POSITION ROLES
-	bck.log.Debug().Field("guildId", guildID).Msg("Re-positioning roles ...")
-	writeStatus(statusC, "re-position roles")
-	or := sop.Map(sop.MapFlat(orderedRoles).Sort(func(p, q sop.Tuple[int, *discordgo.Role], i int) bool {
-		return p.V1 < q.V1
-	}), func(v sop.Tuple[int, *discordgo.Role], i int) *discordgo.Role {
-		return v.V2
-	}).Unwrap()
-	_, err = bck.session.GuildRoleReorder(guildID, or)
-	if err != nil {
-		writeError(errorsC, err)
-	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
 
-	// CREATE CATEGORIES
-	bck.log.Debug().Field("guildId", guildID).Msg("Updating and eracting guild categories ...")
-	writeStatus(statusC, "updating and creating categories")
-	for _, c := range backup.Channels {
-		if c.Type != discordgo.ChannelTypeGuildCategory {
-			continue
-		}
+	return entries, nil
+}
 
-		cObj, _ := bck.session.Channel(c.ID)
-		if cObj != nil && cObj.GuildID != guildID {
-			cObj = nil
-		}
+// GetBackup returns the backup object of the backup
+// with the given file ID. ErrBackupNotFound is returned
+// if the guild has no backup with the given ID.
+func (bck *GuildBackups) GetBackup(guildID, fileID string) (*backupmodels.Object, error) {
+	return bck.readBackup(guildID, fileID)
+}
 
-		for _, po := range c.PermissionOverwrites {
-			po.ID = ids[po.ID]
-		}
+// StoredObjects returns the names of all objects in the
+// backups storage bucket which belong to the given backups,
+// including the blobs referenced by them.
+//
+// When manifests can not be read, the names of all other
+// objects are returned alongside the error.
+func (bck *GuildBackups) StoredObjects(entries []backupmodels.Entry) ([]string, error) {
+	return storedObjects(bck.st, entries)
+}
 
-		if cObj == nil {
-			cObj, err = bck.session.GuildChannelCreateComplex(guildID,
-				discordgo.GuildChannelCreateData{
-					Bitrate:              c.Bitrate,
-					NSFW:                 c.NSFW,
-					Name:                 c.Name,
-					ParentID:             ids[c.ParentID],
-					PermissionOverwrites: c.PermissionOverwrites,
-					Topic:                c.Topic,
-					Type:                 discordgo.ChannelType(c.Type),
-					UserLimit:            c.UserLimit,
-				})
-		} else {
-			_, err = bck.session.ChannelEditComplex(cObj.ID,
-				&discordgo.ChannelEdit{
-					Bitrate:              c.Bitrate,
-					NSFW:                 &c.NSFW,
-					Name:                 c.Name,
-					ParentID:             ids[c.ParentID],
-					PermissionOverwrites: c.PermissionOverwrites,
-					Topic:                c.Topic,
-					UserLimit:            c.UserLimit,
-				})
-		}
-		if err != nil {
-			writeError(errorsC, err)
-			continue
-		}
+// PurgeBackups deletes all backups of the guild
+// including all stored blobs and data keys. The
+// number of deleted backups is returned.
+func (bck *GuildBackups) PurgeBackups(guildID string) (int, error) {
+	defer bck.lockGuild(guildID)()
 
-		channelsPos[cObj.ID] = c.Position
-		ids[c.ID] = cObj.ID
+	entries, err := bck.db.GetBackups(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return 0, err
 	}
 
-	// CREATE CATEGORIES
-	bck.log.Debug().Field("guildId", guildID).Msg("Updating and eracting guild channels ...")
-	writeStatus(statusC, "updating and creating channels")
-	for _, c := range backup.Channels {
-		if c.Type == discordgo.ChannelTypeGuildCategory {
-			continue
-		}
-
-		cObj, _ := bck.session.Channel(c.ID)
-		if cObj != nil && cObj.GuildID != guildID {
-			cObj = nil
-		}
+	mErr := multierror.New()
 
-		for _, po := range c.PermissionOverwrites {
-			po.ID = ids[po.ID]
-		}
+	names, err := storedObjects(bck.st, entries)
+	mErr.Append(err)
 
-		if cObj == nil {
-			cObj, err = bck.session.GuildChannelCreateComplex(guildID,
-				discordgo.GuildChannelCreateData{
-					Bitrate:              c.Bitrate,
-					NSFW:                 c.NSFW,
-					Name:                 c.Name,
-					ParentID:             ids[c.ParentID],
-					PermissionOverwrites: c.PermissionOverwrites,
-					Topic:                c.Topic,
-					Type:                 discordgo.ChannelType(c.Type),
-					UserLimit:            c.UserLimit,
-				})
-		} else {
-			_, err = bck.session.ChannelEditComplex(cObj.ID,
-				&discordgo.ChannelEdit{
-					Bitrate:              c.Bitrate,
-					NSFW:                 &c.NSFW,
-					Name:                 c.Name,
-					ParentID:             ids[c.ParentID],
-					PermissionOverwrites: c.PermissionOverwrites,
-					Topic:                c.Topic,
-					UserLimit:            c.UserLimit,
-				})
-		}
-		if err != nil {
-			writeError(errorsC, err)
+	var deleted int
+	for _, e := range entries {
+		if err = bck.db.DeleteBackup(guildID, e.FileID); err != nil {
+			mErr.Append(err)
 			continue
 		}
-
-		channelsPos[cObj.ID] = c.Position
+		deleted++
 	}
 
-	// RE-POSITION CHANNELS
-	bck.log.Debug().Field("guildId", guildID).Msg("Re-positioning channels ...")
-	writeStatus(statusC, "re-positioning channels")
-	for cID, pos := range channelsPos {
-		_, err = bck.session.ChannelEditComplex(cID, &discordgo.ChannelEdit{
-			Position: &pos,
-		})
-		if err != nil {
-			return err
-		}
+	for _, name := range names {
+		mErr.Append(bck.st.delete(name))
 	}
+	mErr.Append(bck.cleanupExports(exportPrefix(guildID), 0))
 
-	// UPDATE MEMBERS
-	bck.log.Debug().Field("guildId", guildID).Msg("Updating members ...")
-	writeStatus(statusC, "updating members")
-	for _, m := range backup.Members {
-		mObj, _ := bck.session.GuildMember(guildID, m.ID)
-
-		newRoles := make([]string, len(m.Roles))
-		for i, r := range m.Roles {
-			newRoles[i] = inline.NC(ids[r], r)
-		}
-
-		if mObj != nil {
-			_, err = bck.session.GuildMemberEdit(guildID, m.ID, &discordgo.GuildMemberParams{
-				Roles: &newRoles,
-			})
-			if err != nil {
-				writeError(errorsC, err)
-				continue
-			}
-
-			err = bck.session.GuildMemberNickname(guildID, m.ID, m.Nick)
-			if err != nil {
-				writeError(errorsC, err)
-				continue
-			}
-		}
+	if mErr.Len() == 0 {
+		mErr.Append(bck.deleteKeys(guildID, ""))
 	}
 
-	bck.log.Debug().Field("guildId", guildID).Msg("Finished backup restoration")
-	writeStatus(statusC, "Finished ✅")
-
-	return nil
+	return deleted, mErr.Nillify()
 }
 
 // HardFlush removes all roles and channels
@@ -0,0 +1,880 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+)
+
+// restorePlanner computes the operations required to
+// restore a backup into a guild by comparing the backup
+// against the current state of the target guild.
+//
+// The planner itself does not perform any requests so
+// that planning can also be used for dry runs.
+type restorePlanner struct {
+	backup *backupmodels.Object
+	opts   backupmodels.RestoreOptions
+	cp     *backupmodels.RestoreCheckpoint
+
+	guild    *discordgo.Guild
+	roles    map[string]*discordgo.Role
+	channels map[string]*discordgo.Channel
+	members  map[string]*discordgo.Member
+
+	backupRoles    map[string]*backupmodels.Role
+	backupChannels map[string]*backupmodels.Channel
+	backupMembers  map[string]*backupmodels.Member
+
+	// backup IDs of roles and channels which
+	// will be created by the plan
+	pending   map[string]bool
+	completed map[string]bool
+}
+
+func newRestorePlanner(
+	backup *backupmodels.Object,
+	opts backupmodels.RestoreOptions,
+	cp *backupmodels.RestoreCheckpoint,
+	guild *discordgo.Guild,
+	channels []*discordgo.Channel,
+	members []*discordgo.Member,
+) *restorePlanner {
+	p := &restorePlanner{
+		backup:         backup,
+		opts:           opts,
+		cp:             cp,
+		guild:          guild,
+		roles:          make(map[string]*discordgo.Role),
+		channels:       make(map[string]*discordgo.Channel),
+		members:        make(map[string]*discordgo.Member),
+		backupRoles:    make(map[string]*backupmodels.Role),
+		backupChannels: make(map[string]*backupmodels.Channel),
+		backupMembers:  make(map[string]*backupmodels.Member),
+		pending:        make(map[string]bool),
+		completed:      make(map[string]bool),
+	}
+
+	if p.cp.IDs == nil {
+		p.cp.IDs = make(map[string]string)
+	}
+	for _, id := range cp.Completed {
+		p.completed[id] = true
+	}
+
+	for _, r := range guild.Roles {
+		p.roles[r.ID] = r
+	}
+	for _, c := range channels {
+		p.channels[c.ID] = c
+	}
+	for _, m := range members {
+		if m.User != nil {
+			p.members[m.User.ID] = m
+		}
+	}
+
+	for _, r := range backup.Roles {
+		p.backupRoles[r.ID] = r
+	}
+	for _, c := range backup.Channels {
+		p.backupChannels[c.ID] = c
+	}
+	for _, m := range backup.Members {
+		p.backupMembers[m.ID] = m
+	}
+
+	return p
+}
+
+// plan returns all operations required to restore the
+// backup which have not been completed yet.
+func (p *restorePlanner) plan() (ops []backupmodels.RestoreOperation, nCompleted int) {
+	var all []backupmodels.RestoreOperation
+
+	if p.opts.Has(backupmodels.RestoreComponentRoles) {
+		all = append(all, p.planRoles()...)
+	}
+	if p.opts.Has(backupmodels.RestoreComponentChannels) {
+		all = append(all, p.planChannels()...)
+	}
+	if p.opts.Has(backupmodels.RestoreComponentGuild) {
+		all = append(all, p.planGuild()...)
+	}
+	if p.opts.Has(backupmodels.RestoreComponentMemberRoles) ||
+		p.opts.Has(backupmodels.RestoreComponentNicknames) {
+		all = append(all, p.planMembers()...)
+	}
+
+	ops = make([]backupmodels.RestoreOperation, 0, len(all))
+	for _, op := range all {
+		if p.completed[op.ID] {
+			nCompleted++
+			continue
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, nCompleted
+}
+
+// resolveRole returns the ID of the role in the target
+// guild which corresponds to the given backup role ID.
+// If the role does not exist (yet), an empty string is
+// returned.
+func (p *restorePlanner) resolveRole(id string) string {
+	if rid, ok := p.cp.IDs[id]; ok {
+		return rid
+	}
+	if _, ok := p.roles[id]; ok {
+		return id
+	}
+	return ""
+}
+
+// resolveChannel returns the ID of the channel in the
+// target guild which corresponds to the given backup
+// channel ID. If the channel does not exist (yet), an
+// empty string is returned.
+func (p *restorePlanner) resolveChannel(id string) string {
+	if cid, ok := p.cp.IDs[id]; ok {
+		return cid
+	}
+	if _, ok := p.channels[id]; ok {
+		return id
+	}
+	return ""
+}
+
+func (p *restorePlanner) sortedBackupRoles() []*backupmodels.Role {
+	roles := make([]*backupmodels.Role, len(p.backup.Roles))
+	copy(roles, p.backup.Roles)
+	sort.SliceStable(roles, func(i, j int) bool {
+		return roles[i].Position < roles[j].Position
+	})
+	return roles
+}
+
+func (p *restorePlanner) planRoles() (ops []backupmodels.RestoreOperation) {
+	roles := p.sortedBackupRoles()
+
+	for _, r := range roles {
+		cur := p.roles[p.resolveRole(r.ID)]
+		if cur == nil {
+			p.pending[r.ID] = true
+			ops = append(ops, backupmodels.RestoreOperation{
+				ID:       "role.create." + r.ID,
+				Type:     backupmodels.RestoreOpCreateRole,
+				Target:   r.Name,
+				BackupID: r.ID,
+			})
+			continue
+		}
+
+		var changes []string
+		changes = diff(changes, "name", cur.Name, r.Name)
+		changes = diff(changes, "color", cur.Color, r.Color)
+		changes = diff(changes, "hoist", cur.Hoist, r.Hoist)
+		changes = diff(changes, "mentionable", cur.Mentionable, r.Mentionable)
+		changes = diff(changes, "permissions", cur.Permissions, r.Permissions)
+		if len(changes) != 0 {
+			ops = append(ops, backupmodels.RestoreOperation{
+				ID:        "role.update." + r.ID,
+				Type:      backupmodels.RestoreOpUpdateRole,
+				Target:    r.Name,
+				BackupID:  r.ID,
+				CurrentID: cur.ID,
+				Changes:   changes,
+			})
+		}
+	}
+
+	if p.rolesNeedReorder(roles) {
+		ops = append(ops, backupmodels.RestoreOperation{
+			ID:     "roles.reorder",
+			Type:   backupmodels.RestoreOpReorderRoles,
+			Target: "roles",
+		})
+	}
+
+	return ops
+}
+
+func (p *restorePlanner) rolesNeedReorder(sorted []*backupmodels.Role) bool {
+	lastPos := -1
+	for _, r := range sorted {
+		if p.pending[r.ID] {
+			return true
+		}
+		cur := p.roles[p.resolveRole(r.ID)]
+		if cur.Position < lastPos {
+			return true
+		}
+		lastPos = cur.Position
+	}
+	return false
+}
+
+// selectedChannels returns all channels of the backup
+// selected by the restore options ordered so that
+// categories come before all other channels.
+func (p *restorePlanner) selectedChannels() []*backupmodels.Channel {
+	selected := make(map[string]bool)
+	if len(p.opts.Channels) == 0 {
+		for _, c := range p.backup.Channels {
+			selected[c.ID] = true
+		}
+	} else {
+		explicit := make(map[string]bool)
+		for _, id := range p.opts.Channels {
+			explicit[id] = true
+		}
+		for _, c := range p.backup.Channels {
+			if !explicit[c.ID] && !explicit[c.ParentID] {
+				continue
+			}
+			selected[c.ID] = true
+			// Parent categories must exist for the selected
+			// channels to be restored at the correct place.
+			if _, ok := p.backupChannels[c.ParentID]; ok {
+				selected[c.ParentID] = true
+			}
+		}
+	}
+
+	res := make([]*backupmodels.Channel, 0, len(selected))
+	for _, c := range p.backup.Channels {
+		if selected[c.ID] {
+			res = append(res, c)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		ci := res[i].Type == discordgo.ChannelTypeGuildCategory
+		cj := res[j].Type == discordgo.ChannelTypeGuildCategory
+		if ci != cj {
+			return ci
+		}
+		return res[i].Position < res[j].Position
+	})
+
+	return res
+}
+
+func (p *restorePlanner) planChannels() (ops []backupmodels.RestoreOperation) {
+	channels := p.selectedChannels()
+
+	var reorder bool
+	for _, c := range channels {
+		cur := p.channels[p.resolveChannel(c.ID)]
+		if cur == nil {
+			p.pending[c.ID] = true
+			reorder = true
+			ops = append(ops, backupmodels.RestoreOperation{
+				ID:       "channel.create." + c.ID,
+				Type:     backupmodels.RestoreOpCreateChannel,
+				Target:   c.Name,
+				BackupID: c.ID,
+			})
+			continue
+		}
+
+		var changes []string
+		changes = diff(changes, "name", cur.Name, c.Name)
+		changes = diff(changes, "topic", cur.Topic, c.Topic)
+		changes = diff(changes, "nsfw", cur.NSFW, c.NSFW)
+		changes = diff(changes, "bitrate", cur.Bitrate, c.Bitrate)
+		changes = diff(changes, "user limit", cur.UserLimit, c.UserLimit)
+		if p.pending[c.ParentID] || p.resolveChannel(c.ParentID) != cur.ParentID {
+			changes = append(changes, "parent")
+		}
+		if c.PermissionOverwrites != nil && !p.overwritesEqual(cur.PermissionOverwrites, c.PermissionOverwrites) {
+			changes = append(changes, "permission overwrites")
+		}
+		if len(changes) != 0 {
+			ops = append(ops, backupmodels.RestoreOperation{
+				ID:        "channel.update." + c.ID,
+				Type:      backupmodels.RestoreOpUpdateChannel,
+				Target:    c.Name,
+				BackupID:  c.ID,
+				CurrentID: cur.ID,
+				Changes:   changes,
+			})
+		}
+
+		if cur.Position != c.Position {
+			reorder = true
+		}
+	}
+
+	if reorder {
+		ops = append(ops, backupmodels.RestoreOperation{
+			ID:     "channels.reorder",
+			Type:   backupmodels.RestoreOpReorderChannels,
+			Target: "channels",
+		})
+	}
+
+	return ops
+}
+
+// overwrites returns the permission overwrites of the
+// given backup channel with role IDs resolved to the
+// roles of the target guild. Overwrites for roles which
+// do not exist in the target guild are dropped.
+func (p *restorePlanner) overwrites(c *backupmodels.Channel) []*discordgo.PermissionOverwrite {
+	if c.PermissionOverwrites == nil {
+		return nil
+	}
+
+	res := make([]*discordgo.PermissionOverwrite, 0, len(c.PermissionOverwrites))
+	for _, po := range c.PermissionOverwrites {
+		id := po.ID
+		if po.Type == discordgo.PermissionOverwriteTypeRole {
+			if po.ID == p.backupGuildID() {
+				id = p.guild.ID
+			} else if id = p.resolveRole(po.ID); id == "" {
+				continue
+			}
+		}
+		res = append(res, &discordgo.PermissionOverwrite{
+			ID:    id,
+			Type:  po.Type,
+			Allow: po.Allow,
+			Deny:  po.Deny,
+		})
+	}
+
+	return res
+}
+
+func (p *restorePlanner) overwritesEqual(
+	current []*discordgo.PermissionOverwrite,
+	backup []*discordgo.PermissionOverwrite,
+) bool {
+	for _, po := range backup {
+		if po.Type == discordgo.PermissionOverwriteTypeRole && p.pending[po.ID] {
+			return false
+		}
+	}
+
+	wanted := p.overwrites(&backupmodels.Channel{PermissionOverwrites: backup})
+	if len(wanted) != len(current) {
+		return false
+	}
+
+	cm := make(map[string]discordgo.PermissionOverwrite, len(current))
+	for _, po := range current {
+		cm[po.ID] = *po
+	}
+	for _, po := range wanted {
+		if cur, ok := cm[po.ID]; !ok || cur != *po {
+			return false
+		}
+	}
+
+	return true
+}
+
+// backupGuildID returns the ID of the guild the backup
+// was created from, which is also the ID of its
+// @everyone role.
+func (p *restorePlanner) backupGuildID() string {
+	if p.backup.Guild == nil {
+		return ""
+	}
+	return p.backup.Guild.ID
+}
+
+func (p *restorePlanner) planGuild() (ops []backupmodels.RestoreOperation) {
+	g := p.backup.Guild
+	if g == nil {
+		return nil
+	}
+
+	var changes []string
+	changes = diff(changes, "name", p.guild.Name, g.Name)
+	changes = diff(changes, "afk timeout", p.guild.AfkTimeout, g.AfkTimeout)
+	changes = diff(changes, "verification level", int(p.guild.VerificationLevel), g.VerificationLevel)
+	changes = diff(changes, "default notifications",
+		int(p.guild.DefaultMessageNotifications), g.DefaultMessageNotifications)
+	if p.pending[g.AfkChannelID] || p.resolveChannel(g.AfkChannelID) != p.guild.AfkChannelID {
+		changes = append(changes, "afk channel")
+	}
+
+	if len(changes) != 0 {
+		ops = append(ops, backupmodels.RestoreOperation{
+			ID:        "guild.edit",
+			Type:      backupmodels.RestoreOpEditGuild,
+			Target:    g.Name,
+			CurrentID: p.guild.ID,
+			Changes:   changes,
+		})
+	}
+
+	return ops
+}
+
+// memberRoles returns the roles the given member should
+// have after the restore. Roles which can not be resolved
+// are dropped. Managed roles of the member are kept
+// because they can not be removed by the API.
+func (p *restorePlanner) memberRoles(m *backupmodels.Member, cur *discordgo.Member) (roles []string, pending bool) {
+	roles = make([]string, 0, len(m.Roles))
+	for _, rid := range m.Roles {
+		if p.pending[rid] {
+			pending = true
+		}
+		if id := p.resolveRole(rid); id != "" && !p.isManaged(id) {
+			roles = append(roles, id)
+		}
+	}
+	for _, rid := range cur.Roles {
+		if p.isManaged(rid) {
+			roles = append(roles, rid)
+		}
+	}
+	return roles, pending
+}
+
+func (p *restorePlanner) isManaged(roleID string) bool {
+	r, ok := p.roles[roleID]
+	return ok && r.Managed
+}
+
+func (p *restorePlanner) planMembers() (ops []backupmodels.RestoreOperation) {
+	for _, m := range p.backup.Members {
+		cur, ok := p.members[m.ID]
+		if !ok {
+			continue
+		}
+
+		var changes []string
+		if p.opts.Has(backupmodels.RestoreComponentMemberRoles) {
+			roles, pending := p.memberRoles(m, cur)
+			if pending || !sameElements(roles, cur.Roles) {
+				changes = append(changes, "roles")
+			}
+		}
+		if p.opts.Has(backupmodels.RestoreComponentNicknames) {
+			changes = diff(changes, "nickname", cur.Nick, m.Nick)
+		}
+
+		if len(changes) != 0 {
+			ops = append(ops, backupmodels.RestoreOperation{
+				ID:        "member." + m.ID,
+				Type:      backupmodels.RestoreOpUpdateMember,
+				Target:    cur.User.String(),
+				BackupID:  m.ID,
+				CurrentID: m.ID,
+				Changes:   changes,
+			})
+		}
+	}
+
+	return ops
+}
+
+var (
+	// ErrBackupNotFound is returned when the requested
+	// backup file does not exist for the given guild.
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrRestoreRunning is returned when a restore into
+	// the target guild is already in progress.
+	ErrRestoreRunning = errors.New("a backup restore is already running for this guild")
+)
+
+var restoreStatus = map[backupmodels.RestoreOperationType]string{
+	backupmodels.RestoreOpCreateRole:      "creating roles",
+	backupmodels.RestoreOpUpdateRole:      "updating roles",
+	backupmodels.RestoreOpReorderRoles:    "re-positioning roles",
+	backupmodels.RestoreOpCreateChannel:   "creating channels",
+	backupmodels.RestoreOpUpdateChannel:   "updating channels",
+	backupmodels.RestoreOpReorderChannels: "re-positioning channels",
+	backupmodels.RestoreOpEditGuild:       "editing guild",
+	backupmodels.RestoreOpUpdateMember:    "updating members",
+}
+
+// PlanRestore returns the operations which would be
+// performed when restoring the backup specified by
+// fileID of the given guild with the given options
+// without applying any changes (dry run).
+//
+// Operations which have already been completed by a
+// previously interrupted restore with the same backup
+// and options are not part of the plan.
+func (bck *GuildBackups) PlanRestore(
+	guildID, fileID string,
+	opts backupmodels.RestoreOptions,
+) (*backupmodels.RestorePlan, error) {
+	_, plan, err := bck.planRestore(guildID, fileID, opts)
+	return plan, err
+}
+
+// RestoreBackup restores the backup specified by fileID
+// of the given guild into the target guild specified in
+// opts (or the guild itself) following the restore plan
+// as returned by PlanRestore. The current status is sent
+// into the statusC channel and errors of single
+// operations are pushed into the errorsC channel.
+//
+// After each completed operation, a checkpoint is saved
+// so that an interrupted or partially failed restore
+// continues where it stopped when started again with
+// the same backup and options.
+//
+// Only if the initialization of this method has failed,
+// an error is returned. The returned error does not
+// represent the success result of the backup restore
+// process.
+func (bck *GuildBackups) RestoreBackup(
+	guildID, fileID string,
+	opts backupmodels.RestoreOptions,
+	statusC chan string,
+	errorsC chan error,
+) error {
+	defer func() {
+		close(statusC)
+		close(errorsC)
+	}()
+
+	bck.log.Debug().Field("guildId", guildID).Msg("Starting backup restoration ...")
+
+	if bck.session == nil {
+		return errors.New("session is nil")
+	}
+
+	targetGuildID := opts.TargetGuildID
+	if targetGuildID == "" {
+		targetGuildID = guildID
+	}
+	if _, running := bck.restoring.LoadOrStore(targetGuildID, struct{}{}); running {
+		return ErrRestoreRunning
+	}
+	defer bck.restoring.Delete(targetGuildID)
+
+	writeStatus(statusC, "reading backup file and planning restore")
+	p, plan, err := bck.planRestore(guildID, fileID, opts)
+	if err != nil {
+		return err
+	}
+
+	if plan.Completed > 0 {
+		writeStatus(statusC, fmt.Sprintf("resuming previous restore (%d operations already completed)",
+			plan.Completed))
+	}
+
+	var (
+		lastType backupmodels.RestoreOperationType
+		nFailed  int
+	)
+	for _, op := range plan.Operations {
+		if op.Type != lastType {
+			bck.log.Debug().Field("guildId", plan.TargetGuildID).Field("type", op.Type).Msg("Executing restore operations ...")
+			writeStatus(statusC, restoreStatus[op.Type])
+			lastType = op.Type
+		}
+
+		if err = bck.executeRestoreOperation(p, plan.TargetGuildID, op); err != nil {
+			nFailed++
+			writeError(errorsC, fmtOpError(op, err))
+			continue
+		}
+
+		p.cp.Completed = append(p.cp.Completed, op.ID)
+		p.cp.Updated = bck.tp.Now()
+		if err = bck.db.SetBackupRestoreCheckpoint(*p.cp); err != nil {
+			writeError(errorsC, fmt.Errorf("failed saving restore checkpoint: %w", err))
+		}
+	}
+
+	if nFailed > 0 {
+		bck.log.Debug().Field("guildId", plan.TargetGuildID).Field("failed", nFailed).Msg("Finished backup restoration with errors")
+		writeStatus(statusC, fmt.Sprintf("Finished with %d failed operations. "+
+			"Restore the backup again with the same options to retry them.", nFailed))
+		return nil
+	}
+
+	err = bck.db.DeleteBackupRestoreCheckpoint(plan.TargetGuildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		writeError(errorsC, fmt.Errorf("failed deleting restore checkpoint: %w", err))
+	}
+
+	bck.log.Debug().Field("guildId", plan.TargetGuildID).Msg("Finished backup restoration")
+	writeStatus(statusC, "Finished ✅")
+
+	return nil
+}
+
+// RestoreBackupAsync starts restoring the backup as
+// described in RestoreBackup in a new goroutine. Errors
+// occuring during the restore are written to the guild
+// log of the target guild.
+func (bck *GuildBackups) RestoreBackupAsync(guildID, fileID string, opts backupmodels.RestoreOptions) {
+	targetGuildID := opts.TargetGuildID
+	if targetGuildID == "" {
+		targetGuildID = guildID
+	}
+
+	statusC := make(chan string)
+	errorsC := make(chan error)
+
+	go func() {
+		for status := range statusC {
+			bck.log.Debug().Field("guildId", targetGuildID).Field("status", status).Msg("Backup restore status")
+		}
+	}()
+
+	go func() {
+		for err := range errorsC {
+			bck.gl.Errorf(targetGuildID, "Backup restore operation failed: %s", err.Error())
+		}
+	}()
+
+	go func() {
+		if err := bck.RestoreBackup(guildID, fileID, opts, statusC, errorsC); err != nil {
+			bck.log.Error().Err(err).Field("guildId", targetGuildID).Msg("Backup restore failed")
+			bck.gl.Errorf(targetGuildID, "Backup restore failed: %s", err.Error())
+		}
+	}()
+}
+
+func (bck *GuildBackups) readBackup(guildID, fileID string) (*backupmodels.Object, error) {
+	entries, err := bck.db.GetBackups(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return nil, err
+	}
+
+	var found bool
+	for _, e := range entries {
+		if found = e.FileID == fileID; found {
+			break
+		}
+	}
+	if !found {
+		return nil, ErrBackupNotFound
+	}
+
+	return loadObject(bck.st, fileID)
+}
+
+// loadCheckpoint returns the restore checkpoint of the
+// target guild if it belongs to a restore of the same
+// backup with the same options. Otherwise, a new, empty
+// checkpoint is returned.
+func (bck *GuildBackups) loadCheckpoint(
+	fileID string,
+	opts backupmodels.RestoreOptions,
+) (*backupmodels.RestoreCheckpoint, error) {
+	cp, err := bck.db.GetBackupRestoreCheckpoint(opts.TargetGuildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return nil, err
+	}
+
+	if err != nil || cp.FileID != fileID || !reflect.DeepEqual(cp.Options, opts) {
+		cp = backupmodels.RestoreCheckpoint{
+			GuildID: opts.TargetGuildID,
+			FileID:  fileID,
+			Options: opts,
+		}
+	}
+
+	return &cp, nil
+}
+
+func (bck *GuildBackups) planRestore(
+	guildID, fileID string,
+	opts backupmodels.RestoreOptions,
+) (*restorePlanner, *backupmodels.RestorePlan, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if opts.TargetGuildID == "" {
+		opts.TargetGuildID = guildID
+	}
+
+	backup, err := bck.readBackup(guildID, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cp, err := bck.loadCheckpoint(fileID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	guild, err := bck.state.Guild(opts.TargetGuildID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	channels, err := bck.state.Channels(opts.TargetGuildID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var members []*discordgo.Member
+	if opts.Has(backupmodels.RestoreComponentMemberRoles) || opts.Has(backupmodels.RestoreComponentNicknames) {
+		if members, err = bck.state.Members(opts.TargetGuildID, true); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	p := newRestorePlanner(backup, opts, cp, guild, channels, members)
+	ops, nCompleted := p.plan()
+
+	plan := &backupmodels.RestorePlan{
+		GuildID:       guildID,
+		TargetGuildID: opts.TargetGuildID,
+		FileID:        fileID,
+		Options:       opts,
+		Operations:    ops,
+		Completed:     nCompleted,
+	}
+
+	return p, plan, nil
+}
+
+func (bck *GuildBackups) executeRestoreOperation(
+	p *restorePlanner,
+	guildID string,
+	op backupmodels.RestoreOperation,
+) (err error) {
+	switch op.Type {
+
+	case backupmodels.RestoreOpCreateRole:
+		r := p.backupRoles[op.BackupID]
+		var role *discordgo.Role
+		role, err = bck.session.GuildRoleCreate(guildID, roleParams(r))
+		if err == nil {
+			p.roles[role.ID] = role
+			p.cp.IDs[r.ID] = role.ID
+		}
+
+	case backupmodels.RestoreOpUpdateRole:
+		r := p.backupRoles[op.BackupID]
+		_, err = bck.session.GuildRoleEdit(guildID, op.CurrentID, roleParams(r))
+
+	case backupmodels.RestoreOpReorderRoles:
+		var roles []*discordgo.Role
+		for _, r := range p.sortedBackupRoles() {
+			if id := p.resolveRole(r.ID); id != "" {
+				roles = append(roles, &discordgo.Role{ID: id, Position: r.Position})
+			}
+		}
+		_, err = bck.session.GuildRoleReorder(guildID, roles)
+
+	case backupmodels.RestoreOpCreateChannel:
+		c := p.backupChannels[op.BackupID]
+		var ch *discordgo.Channel
+		ch, err = bck.session.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
+			Bitrate:              c.Bitrate,
+			NSFW:                 c.NSFW,
+			Name:                 c.Name,
+			ParentID:             p.resolveChannel(c.ParentID),
+			PermissionOverwrites: p.overwrites(c),
+			Topic:                c.Topic,
+			Type:                 c.Type,
+			UserLimit:            c.UserLimit,
+		})
+		if err == nil {
+			p.channels[ch.ID] = ch
+			p.cp.IDs[c.ID] = ch.ID
+		}
+
+	case backupmodels.RestoreOpUpdateChannel:
+		c := p.backupChannels[op.BackupID]
+		_, err = bck.session.ChannelEditComplex(op.CurrentID, &discordgo.ChannelEdit{
+			Bitrate:              c.Bitrate,
+			NSFW:                 &c.NSFW,
+			Name:                 c.Name,
+			ParentID:             p.resolveChannel(c.ParentID),
+			PermissionOverwrites: p.overwrites(c),
+			Topic:                c.Topic,
+			UserLimit:            c.UserLimit,
+		})
+
+	case backupmodels.RestoreOpReorderChannels:
+		var channels []*discordgo.Channel
+		for _, c := range p.selectedChannels() {
+			if id := p.resolveChannel(c.ID); id != "" {
+				channels = append(channels, &discordgo.Channel{ID: id, Position: c.Position})
+			}
+		}
+		err = bck.session.GuildChannelsReorder(guildID, channels)
+
+	case backupmodels.RestoreOpEditGuild:
+		g := p.backup.Guild
+		verificationLevel := discordgo.VerificationLevel(g.VerificationLevel)
+		_, err = bck.session.GuildEdit(guildID, &discordgo.GuildParams{
+			Name:                        g.Name,
+			AfkChannelID:                p.resolveChannel(g.AfkChannelID),
+			AfkTimeout:                  g.AfkTimeout,
+			VerificationLevel:           &verificationLevel,
+			DefaultMessageNotifications: g.DefaultMessageNotifications,
+		})
+
+	case backupmodels.RestoreOpUpdateMember:
+		m := p.backupMembers[op.BackupID]
+		cur := p.members[m.ID]
+		if p.opts.Has(backupmodels.RestoreComponentMemberRoles) {
+			roles, _ := p.memberRoles(m, cur)
+			_, err = bck.session.GuildMemberEdit(guildID, m.ID, &discordgo.GuildMemberParams{
+				Roles: &roles,
+			})
+			if err != nil {
+				return
+			}
+		}
+		if p.opts.Has(backupmodels.RestoreComponentNicknames) && cur.Nick != m.Nick {
+			err = bck.session.GuildMemberNickname(guildID, m.ID, m.Nick)
+		}
+
+	default:
+		err = fmt.Errorf("unsupported operation type: %s", op.Type)
+	}
+
+	return
+}
+
+// --- HELPERS ---
+
+func roleParams(r *backupmodels.Role) *discordgo.RoleParams {
+	return &discordgo.RoleParams{
+		Name:        r.Name,
+		Color:       &r.Color,
+		Hoist:       &r.Hoist,
+		Permissions: &r.Permissions,
+		Mentionable: &r.Mentionable,
+	}
+}
+
+func diff[T comparable](changes []string, name string, current, backup T) []string {
+	if current != backup {
+		changes = append(changes, name)
+	}
+	return changes
+}
+
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	as := make([]string, len(a))
+	bs := make([]string, len(b))
+	copy(as, a)
+	copy(bs, b)
+	sort.Strings(as)
+	sort.Strings(bs)
+	return reflect.DeepEqual(as, bs)
+}
+
+func fmtOpError(op backupmodels.RestoreOperation, err error) error {
+	return fmt.Errorf("%s `%s`: %w", op.Type, op.Target, err)
+}
@@ -0,0 +1,191 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+)
+
+func testBackup() *backupmodels.Object {
+	return &backupmodels.Object{
+		ID: "backup",
+		Guild: &backupmodels.Guild{
+			ID:           "guild",
+			Name:         "Guild",
+			AfkChannelID: "voice",
+		},
+		Roles: []*backupmodels.Role{
+			{ID: "role-a", Name: "A", Position: 1},
+			{ID: "role-b", Name: "B", Position: 2},
+		},
+		Channels: []*backupmodels.Channel{
+			{ID: "cat", Name: "cat", Type: discordgo.ChannelTypeGuildCategory, Position: 0},
+			{ID: "text", Name: "text", Type: discordgo.ChannelTypeGuildText, ParentID: "cat", Position: 1},
+			{ID: "voice", Name: "voice", Type: discordgo.ChannelTypeGuildVoice, ParentID: "cat", Position: 2},
+			{ID: "other", Name: "other", Type: discordgo.ChannelTypeGuildText, Position: 3},
+		},
+		Members: []*backupmodels.Member{
+			{ID: "user-1", Nick: "one", Roles: []string{"role-a", "role-b"}},
+			{ID: "user-2", Roles: []string{"role-a"}},
+		},
+	}
+}
+
+func testGuildState() (*discordgo.Guild, []*discordgo.Channel, []*discordgo.Member) {
+	guild := &discordgo.Guild{
+		ID:           "guild",
+		Name:         "Guild",
+		AfkChannelID: "voice",
+		Roles: []*discordgo.Role{
+			{ID: "guild", Name: "@everyone"},
+			{ID: "role-a", Name: "A", Position: 1},
+			{ID: "managed", Name: "Bot", Position: 3, Managed: true},
+		},
+	}
+	channels := []*discordgo.Channel{
+		{ID: "cat", Name: "cat", Type: discordgo.ChannelTypeGuildCategory, Position: 0},
+		{ID: "text", Name: "renamed", Type: discordgo.ChannelTypeGuildText, ParentID: "cat", Position: 1},
+		{ID: "voice", Name: "voice", Type: discordgo.ChannelTypeGuildVoice, ParentID: "cat", Position: 2},
+		{ID: "other", Name: "other", Type: discordgo.ChannelTypeGuildText, Position: 3},
+	}
+	members := []*discordgo.Member{
+		{User: &discordgo.User{ID: "user-1", Username: "one"}, Nick: "one", Roles: []string{"role-a", "managed"}},
+		{User: &discordgo.User{ID: "user-2", Username: "two"}, Roles: []string{"role-a"}},
+	}
+	return guild, channels, members
+}
+
+func opIDs(ops []backupmodels.RestoreOperation) []string {
+	ids := make([]string, len(ops))
+	for i, op := range ops {
+		ids[i] = op.ID
+	}
+	return ids
+}
+
+func TestPlan(t *testing.T) {
+	guild, channels, members := testGuildState()
+	cp := &backupmodels.RestoreCheckpoint{}
+
+	p := newRestorePlanner(testBackup(), backupmodels.RestoreOptions{}, cp, guild, channels, members)
+	ops, nCompleted := p.plan()
+
+	assert.Equal(t, 0, nCompleted)
+	assert.Equal(t, []string{
+		"role.create.role-b",
+		"roles.reorder",
+		"channel.update.text",
+		"member.user-1",
+	}, opIDs(ops))
+	assert.Equal(t, []string{"name"}, ops[2].Changes)
+	assert.Equal(t, []string{"roles"}, ops[3].Changes)
+
+	// Managed roles must be kept when
+	// setting member roles.
+	p.cp.IDs["role-b"] = "new-role-b"
+	roles, _ := p.memberRoles(p.backupMembers["user-1"], p.members["user-1"])
+	assert.ElementsMatch(t, []string{"role-a", "new-role-b", "managed"}, roles)
+}
+
+func TestPlanCheckpoint(t *testing.T) {
+	guild, channels, members := testGuildState()
+	guild.Roles = append(guild.Roles, &discordgo.Role{ID: "new-role-b", Name: "B", Position: 2})
+	members[0].Roles = []string{"role-a", "new-role-b", "managed"}
+
+	cp := &backupmodels.RestoreCheckpoint{
+		Completed: []string{"role.create.role-b", "roles.reorder"},
+		IDs:       map[string]string{"role-b": "new-role-b"},
+	}
+
+	p := newRestorePlanner(testBackup(), backupmodels.RestoreOptions{}, cp, guild, channels, members)
+	ops, nCompleted := p.plan()
+
+	assert.Equal(t, 0, nCompleted)
+	assert.Equal(t, []string{"channel.update.text"}, opIDs(ops))
+
+	// When the role has been created but the checkpoint
+	// still lacks the re-ordering, the creation is
+	// skipped as completed.
+	guild.Roles[3].Position = 0
+	cp.Completed = []string{"role.create.role-b"}
+	p = newRestorePlanner(testBackup(), backupmodels.RestoreOptions{}, cp, guild, channels, members)
+	ops, _ = p.plan()
+	assert.Equal(t, []string{"roles.reorder", "channel.update.text"}, opIDs(ops))
+}
+
+func TestPlanSelective(t *testing.T) {
+	guild, channels, members := testGuildState()
+	channels = channels[:1]
+
+	opts := backupmodels.RestoreOptions{
+		Components: []backupmodels.RestoreComponent{backupmodels.RestoreComponentChannels},
+		Channels:   []string{"text"},
+	}
+	p := newRestorePlanner(testBackup(), opts, &backupmodels.RestoreCheckpoint{}, guild, channels, members)
+	ops, _ := p.plan()
+
+	assert.Equal(t, []string{"channel.create.text", "channels.reorder"}, opIDs(ops))
+
+	opts = backupmodels.RestoreOptions{
+		Components: []backupmodels.RestoreComponent{backupmodels.RestoreComponentChannels},
+		Channels:   []string{"cat"},
+	}
+	p = newRestorePlanner(testBackup(), opts, &backupmodels.RestoreCheckpoint{}, guild, channels, members)
+	ops, _ = p.plan()
+
+	assert.Equal(t, []string{"channel.create.text", "channel.create.voice", "channels.reorder"}, opIDs(ops))
+
+	opts = backupmodels.RestoreOptions{
+		Components: []backupmodels.RestoreComponent{backupmodels.RestoreComponentNicknames},
+	}
+	members[0].Nick = "changed"
+	p = newRestorePlanner(testBackup(), opts, &backupmodels.RestoreCheckpoint{}, guild, channels, members)
+	ops, _ = p.plan()
+
+	assert.Equal(t, []string{"member.user-1"}, opIDs(ops))
+	assert.Equal(t, []string{"nickname"}, ops[0].Changes)
+}
+
+func TestPlanClone(t *testing.T) {
+	guild := &discordgo.Guild{
+		ID:    "clone",
+		Name:  "Clone",
+		Roles: []*discordgo.Role{{ID: "clone", Name: "@everyone"}},
+	}
+	members := []*discordgo.Member{
+		{User: &discordgo.User{ID: "user-2", Username: "two"}},
+	}
+
+	opts := backupmodels.RestoreOptions{TargetGuildID: "clone"}
+	p := newRestorePlanner(testBackup(), opts, &backupmodels.RestoreCheckpoint{}, guild, nil, members)
+	ops, _ := p.plan()
+
+	assert.Equal(t, []string{
+		"role.create.role-a",
+		"role.create.role-b",
+		"roles.reorder",
+		"channel.create.cat",
+		"channel.create.text",
+		"channel.create.voice",
+		"channel.create.other",
+		"channels.reorder",
+		"guild.edit",
+		"member.user-2",
+	}, opIDs(ops))
+	assert.Equal(t, []string{"name", "afk channel"}, ops[8].Changes)
+}
+
+func TestRestoreOptions(t *testing.T) {
+	opts := backupmodels.RestoreOptions{}
+	assert.True(t, opts.Has(backupmodels.RestoreComponentRoles))
+	assert.Nil(t, opts.Validate())
+
+	opts.Components = []backupmodels.RestoreComponent{backupmodels.RestoreComponentRoles}
+	assert.True(t, opts.Has(backupmodels.RestoreComponentRoles))
+	assert.False(t, opts.Has(backupmodels.RestoreComponentChannels))
+
+	opts.Components = append(opts.Components, "invalid")
+	assert.NotNil(t, opts.Validate())
+}
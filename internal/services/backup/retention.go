@@ -0,0 +1,229 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/pkg/multierror"
+)
+
+type retentionTier struct {
+	window time.Duration
+	bucket func(t time.Time) string
+}
+
+// expiredBackups returns the backups which are not
+// retained by the given policy, sorted from the oldest
+// to the newest one.
+//
+// For each tier of the policy, the latest backup of each
+// hour, day or week within the tiers window is retained.
+// The latest backup overall is always retained.
+func expiredBackups(
+	entries []backupmodels.Entry,
+	r backupmodels.Retention,
+	now time.Time,
+) []backupmodels.Entry {
+	sorted := make([]backupmodels.Entry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	keep := map[string]struct{}{
+		sorted[0].FileID: {},
+	}
+
+	tiers := []retentionTier{
+		{
+			window: time.Duration(r.Hourly) * time.Hour,
+			bucket: func(t time.Time) string { return t.UTC().Format("2006-01-02T15") },
+		},
+		{
+			window: time.Duration(r.Daily) * 24 * time.Hour,
+			bucket: func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+		},
+		{
+			window: time.Duration(r.Weekly) * 7 * 24 * time.Hour,
+			bucket: func(t time.Time) string {
+				year, week := t.UTC().ISOWeek()
+				return fmt.Sprintf("%d-%d", year, week)
+			},
+		},
+	}
+
+	for _, tier := range tiers {
+		seen := make(map[string]struct{})
+		for _, e := range sorted {
+			if now.Sub(e.Timestamp) >= tier.window {
+				break
+			}
+			bucket := tier.bucket(e.Timestamp)
+			if _, ok := seen[bucket]; ok {
+				continue
+			}
+			seen[bucket] = struct{}{}
+			keep[e.FileID] = struct{}{}
+		}
+	}
+
+	var expired []backupmodels.Entry
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if _, ok := keep[sorted[i].FileID]; !ok {
+			expired = append(expired, sorted[i])
+		}
+	}
+
+	return expired
+}
+
+// Retention returns the backup retention policy of
+// the guild. If the guild has not set a policy, the
+// configured default is returned.
+func (bck *GuildBackups) Retention(guildID string) (backupmodels.Retention, error) {
+	r, err := bck.db.GetGuildBackupRetention(guildID)
+	if database.IsErrDatabaseNotFound(err) {
+		return bck.cfg.Config().Backups.Retention, nil
+	}
+	return r, err
+}
+
+// SetRetention validates and sets the backup retention
+// policy of the guild. It is applied on the next backup.
+func (bck *GuildBackups) SetRetention(guildID string, r backupmodels.Retention) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	return bck.db.SetGuildBackupRetention(guildID, r)
+}
+
+// applyRetention removes all backups of the guild which
+// are not retained by the guilds retention policy. The
+// changes of removed incremental backups are squashed into
+// their children and blobs which are no more referenced
+// are deleted.
+func (bck *GuildBackups) applyRetention(guildID string) error {
+	r, err := bck.Retention(guildID)
+	if err != nil {
+		return err
+	}
+
+	entries, err := bck.db.GetBackups(guildID)
+	if err != nil {
+		return err
+	}
+
+	expired := expiredBackups(entries, r, bck.tp.Now())
+	if len(expired) == 0 {
+		return nil
+	}
+
+	remaining := make(map[string]*backupmodels.Entry, len(entries))
+	for i := range entries {
+		remaining[entries[i].FileID] = &entries[i]
+	}
+
+	unreferenced := make(map[string]struct{})
+	for _, e := range expired {
+		hashes, err := bck.removeBackup(e, remaining)
+		if err != nil {
+			return fmt.Errorf("failed removing backup %s: %w", e.FileID, err)
+		}
+		for hash := range hashes {
+			unreferenced[hash] = struct{}{}
+		}
+	}
+
+	return bck.deleteUnreferencedBlobs(guildID, unreferenced, remaining)
+}
+
+// removeBackup deletes the backup and squashes its changes
+// into its children. The hashes of the blobs referenced by
+// the removed backup are returned.
+func (bck *GuildBackups) removeBackup(
+	e backupmodels.Entry,
+	remaining map[string]*backupmodels.Entry,
+) (map[string]struct{}, error) {
+	var hashes map[string]struct{}
+
+	m, err := readManifest(bck.st, e.FileID)
+	if err != nil && !errors.Is(err, errLegacyBackup) {
+		return nil, err
+	}
+
+	if m != nil {
+		for _, child := range remaining {
+			if child.ParentID != e.FileID {
+				continue
+			}
+
+			cm, err := readManifest(bck.st, child.FileID)
+			if err != nil {
+				return nil, err
+			}
+			squashManifest(m, cm)
+			if err = writeManifest(bck.st, cm); err != nil {
+				return nil, err
+			}
+
+			*child = cm.Entry()
+			if err = bck.db.UpdateBackup(*child); err != nil {
+				return nil, err
+			}
+		}
+
+		hashes = m.Objects.Hashes()
+	}
+
+	if err = bck.db.DeleteBackup(e.GuildID, e.FileID); err != nil {
+		return nil, err
+	}
+	delete(remaining, e.FileID)
+
+	if err = bck.st.delete(e.FileID); err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// deleteUnreferencedBlobs deletes all blobs with the given
+// hashes which are not referenced by any of the remaining
+// backups.
+func (bck *GuildBackups) deleteUnreferencedBlobs(
+	guildID string,
+	hashes map[string]struct{},
+	remaining map[string]*backupmodels.Entry,
+) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	for _, e := range remaining {
+		m, err := readManifest(bck.st, e.FileID)
+		if errors.Is(err, errLegacyBackup) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for hash := range m.Objects.Hashes() {
+			delete(hashes, hash)
+		}
+	}
+
+	mErr := multierror.New()
+	for hash := range hashes {
+		mErr.Append(bck.st.delete(blobName(guildID, hash)))
+	}
+
+	return mErr.Nillify()
+}
@@ -0,0 +1,329 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/pkg/multierror"
+)
+
+// errLegacyBackup is returned when a stored backup
+// is a legacy backup object instead of a manifest.
+var errLegacyBackup = errors.New("backup is not stored as manifest")
+
+// blobName returns the storage object name of the
+// blob with the given content hash of a guild.
+func blobName(guildID, hash string) string {
+	return fmt.Sprintf("blob_%s_%s", guildID, hash)
+}
+
+// snapshotRefs encodes each object of the backup into a
+// content-addressed blob. The references to the blobs as
+// well as the encoded blobs by their hash are returned.
+func snapshotRefs(backup *backupmodels.Object) (backupmodels.Refs, map[string][]byte, error) {
+	refs := make(backupmodels.Refs)
+	blobs := make(map[string][]byte)
+
+	add := func(kind, id string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		refs.Set(kind, id, hash)
+		blobs[hash] = data
+		return nil
+	}
+
+	if backup.Guild != nil {
+		if err := add(backupmodels.KindGuild, backup.Guild.ID, backup.Guild); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, c := range backup.Channels {
+		if err := add(backupmodels.KindChannels, c.ID, c); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, r := range backup.Roles {
+		if err := add(backupmodels.KindRoles, r.ID, r); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, m := range backup.Members {
+		if err := add(backupmodels.KindMembers, m.ID, m); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return refs, blobs, nil
+}
+
+// diffRefs returns the objects of curr which were added
+// or changed compared to prev as well as the IDs of the
+// objects of prev which are missing in curr.
+func diffRefs(prev, curr backupmodels.Refs) (changed backupmodels.Refs, removed map[string][]string) {
+	changed = make(backupmodels.Refs)
+	for kind, objects := range curr {
+		for id, hash := range objects {
+			if prev[kind][id] != hash {
+				changed.Set(kind, id, hash)
+			}
+		}
+	}
+
+	for kind, objects := range prev {
+		for id := range objects {
+			if _, ok := curr[kind][id]; ok {
+				continue
+			}
+			if removed == nil {
+				removed = make(map[string][]string)
+			}
+			removed[kind] = append(removed[kind], id)
+		}
+	}
+	sortRemoved(removed)
+
+	return changed, removed
+}
+
+// applyManifest applies the removals and changes
+// of the manifest to refs.
+func applyManifest(refs backupmodels.Refs, m *backupmodels.Manifest) {
+	for kind, ids := range m.Removed {
+		for _, id := range ids {
+			delete(refs[kind], id)
+		}
+	}
+	for kind, objects := range m.Objects {
+		for id, hash := range objects {
+			refs.Set(kind, id, hash)
+		}
+	}
+}
+
+// squashManifest merges the changes of parent into child
+// so that the parent can be deleted without breaking the
+// chain. When the parent is a full snapshot, the child
+// becomes a full snapshot as well.
+func squashManifest(parent, child *backupmodels.Manifest) {
+	objects := parent.Objects.Clone()
+	applyManifest(objects, child)
+
+	var removed map[string][]string
+	if !parent.IsFull() {
+		seen := make(map[string]struct{})
+		for _, r := range []map[string][]string{parent.Removed, child.Removed} {
+			for kind, ids := range r {
+				for _, id := range ids {
+					key := kind + "/" + id
+					if _, ok := seen[key]; ok {
+						continue
+					}
+					seen[key] = struct{}{}
+					if _, ok := objects[kind][id]; ok {
+						continue
+					}
+					if removed == nil {
+						removed = make(map[string][]string)
+					}
+					removed[kind] = append(removed[kind], id)
+				}
+			}
+		}
+		sortRemoved(removed)
+	}
+
+	child.ParentID = parent.ParentID
+	child.Objects = objects
+	child.Removed = removed
+}
+
+func sortRemoved(removed map[string][]string) {
+	for _, ids := range removed {
+		sort.Strings(ids)
+	}
+}
+
+// readManifest returns the stored manifest of the backup.
+// errLegacyBackup is returned if the backup is stored as
+// backup object.
+func readManifest(st *objectStore, fileID string) (*backupmodels.Manifest, error) {
+	data, err := st.read(fileID)
+	if err != nil {
+		return nil, err
+	}
+	return decodeManifest(data)
+}
+
+func decodeManifest(data []byte) (*backupmodels.Manifest, error) {
+	m := new(backupmodels.Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if m.Version == 0 {
+		return nil, errLegacyBackup
+	}
+	if m.Version > backupmodels.ManifestVersion {
+		return nil, fmt.Errorf("unsupported backup manifest version %d", m.Version)
+	}
+	if m.Objects == nil {
+		m.Objects = make(backupmodels.Refs)
+	}
+	return m, nil
+}
+
+func writeManifest(st *objectStore, m *backupmodels.Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return st.write(m.GuildID, m.ID, data)
+}
+
+// resolveRefs follows the chain of parents of the given
+// manifest down to the last full snapshot and returns the
+// resulting references of the backup. Also, the number of
+// incremental backups in the chain is returned.
+func resolveRefs(st *objectStore, m *backupmodels.Manifest) (backupmodels.Refs, int, error) {
+	chain := []*backupmodels.Manifest{m}
+	seen := map[string]struct{}{m.ID: {}}
+
+	for curr := m; !curr.IsFull(); {
+		if _, ok := seen[curr.ParentID]; ok {
+			return nil, 0, fmt.Errorf("backup %s has a cyclic parent chain", m.ID)
+		}
+		seen[curr.ParentID] = struct{}{}
+
+		parent, err := readManifest(st, curr.ParentID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed reading parent backup %s: %w", curr.ParentID, err)
+		}
+		chain = append(chain, parent)
+		curr = parent
+	}
+
+	refs := make(backupmodels.Refs)
+	for i := len(chain) - 1; i >= 0; i-- {
+		applyManifest(refs, chain[i])
+	}
+
+	return refs, len(chain) - 1, nil
+}
+
+// loadObject reads the stored backup and returns the
+// backup object. Backups stored as manifests are
+// reconstructed from their chain of parents.
+func loadObject(st *objectStore, fileID string) (*backupmodels.Object, error) {
+	data, err := st.read(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := decodeManifest(data)
+	if errors.Is(err, errLegacyBackup) {
+		backup := new(backupmodels.Object)
+		err = json.Unmarshal(data, backup)
+		return backup, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refs, _, err := resolveRefs(st, m)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &backupmodels.Object{
+		ID:        m.ID,
+		Timestamp: m.Timestamp,
+	}
+
+	read := func(hash string, v any) error {
+		data, err := st.read(blobName(m.GuildID, hash))
+		if err != nil {
+			return fmt.Errorf("failed reading blob %s: %w", hash, err)
+		}
+		return json.Unmarshal(data, v)
+	}
+
+	for _, hash := range refs[backupmodels.KindGuild] {
+		backup.Guild = new(backupmodels.Guild)
+		if err = read(hash, backup.Guild); err != nil {
+			return nil, err
+		}
+	}
+	for _, hash := range refs[backupmodels.KindChannels] {
+		c := new(backupmodels.Channel)
+		if err = read(hash, c); err != nil {
+			return nil, err
+		}
+		backup.Channels = append(backup.Channels, c)
+	}
+	for _, hash := range refs[backupmodels.KindRoles] {
+		r := new(backupmodels.Role)
+		if err = read(hash, r); err != nil {
+			return nil, err
+		}
+		backup.Roles = append(backup.Roles, r)
+	}
+	for _, hash := range refs[backupmodels.KindMembers] {
+		mem := new(backupmodels.Member)
+		if err = read(hash, mem); err != nil {
+			return nil, err
+		}
+		backup.Members = append(backup.Members, mem)
+	}
+
+	sort.Slice(backup.Channels, func(i, j int) bool {
+		if backup.Channels[i].Position != backup.Channels[j].Position {
+			return backup.Channels[i].Position < backup.Channels[j].Position
+		}
+		return backup.Channels[i].ID < backup.Channels[j].ID
+	})
+	sort.Slice(backup.Roles, func(i, j int) bool {
+		return backup.Roles[i].Position < backup.Roles[j].Position
+	})
+	sort.Slice(backup.Members, func(i, j int) bool {
+		return backup.Members[i].ID < backup.Members[j].ID
+	})
+
+	return backup, nil
+}
+
+// storedObjects returns the names of the stored objects
+// of the given backups. See GuildBackups.StoredObjects.
+func storedObjects(st *objectStore, entries []backupmodels.Entry) ([]string, error) {
+	names := make([]string, 0, len(entries))
+	blobs := make(map[string]struct{})
+	mErr := multierror.New()
+
+	for _, e := range entries {
+		names = append(names, e.FileID)
+		m, err := readManifest(st, e.FileID)
+		if errors.Is(err, errLegacyBackup) {
+			continue
+		}
+		if err != nil {
+			mErr.Append(err)
+			continue
+		}
+		for hash := range m.Objects.Hashes() {
+			name := blobName(e.GuildID, hash)
+			if _, ok := blobs[name]; !ok {
+				blobs[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names, mErr.Nillify()
+}
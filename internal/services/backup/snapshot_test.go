@@ -0,0 +1,194 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/storage"
+	"github.com/zekroTJA/shinpuru/internal/util/testutil"
+	"github.com/zekroTJA/shinpuru/mocks"
+	"github.com/zekrotja/rogu/log"
+)
+
+func testBackups(t *testing.T) *GuildBackups {
+	cfg := models.DefaultConfig
+	cfg.Storage.File.Location = t.TempDir()
+
+	cfgm := &mocks.ConfigProvider{}
+	cfgm.On("Config").Return(&cfg)
+
+	st := &storage.File{}
+	require.NoError(t, st.Connect(cfgm))
+
+	return &GuildBackups{
+		cfg: cfgm,
+		db:  testutil.NewTestDatabase(t),
+		st:  &objectStore{st: st},
+		log: log.Tagged("GuildBackup"),
+	}
+}
+
+func assertRefs(t *testing.T, expected *backupmodels.Object, actual *backupmodels.Object) {
+	t.Helper()
+	expectedRefs, _, err := snapshotRefs(expected)
+	require.NoError(t, err)
+	actualRefs, _, err := snapshotRefs(actual)
+	require.NoError(t, err)
+	assert.Equal(t, expectedRefs, actualRefs)
+}
+
+func TestDiffAndSquash(t *testing.T) {
+	base, _, err := snapshotRefs(testBackup())
+	require.NoError(t, err)
+
+	b := testBackup()
+	b.Roles[0].Name = "A2"
+	b.Members = b.Members[:1]
+	curr, _, err := snapshotRefs(b)
+	require.NoError(t, err)
+
+	changed, removed := diffRefs(base, curr)
+	assert.Equal(t, 1, changed.Len())
+	assert.Equal(t, curr[backupmodels.KindRoles]["role-a"], changed[backupmodels.KindRoles]["role-a"])
+	assert.Equal(t, map[string][]string{backupmodels.KindMembers: {"user-2"}}, removed)
+
+	m1 := &backupmodels.Manifest{ID: "1", Objects: base}
+	m2 := &backupmodels.Manifest{ID: "2", ParentID: "1", Objects: changed, Removed: removed}
+
+	refs := m1.Objects.Clone()
+	applyManifest(refs, m2)
+	assert.Equal(t, curr, refs)
+
+	squashManifest(m1, m2)
+	assert.True(t, m2.IsFull())
+	assert.Nil(t, m2.Removed)
+	assert.Equal(t, curr, m2.Objects)
+}
+
+func TestSquashIncremental(t *testing.T) {
+	m2 := &backupmodels.Manifest{
+		ID:       "2",
+		ParentID: "1",
+		Objects:  backupmodels.Refs{backupmodels.KindRoles: {"a": "a2", "b": "b2"}},
+		Removed:  map[string][]string{backupmodels.KindMembers: {"x"}},
+	}
+	m3 := &backupmodels.Manifest{
+		ID:       "3",
+		ParentID: "2",
+		Objects:  backupmodels.Refs{backupmodels.KindRoles: {"a": "a3"}, backupmodels.KindMembers: {"x": "x3"}},
+		Removed:  map[string][]string{backupmodels.KindRoles: {"b"}, backupmodels.KindMembers: {"y"}},
+	}
+
+	squashManifest(m2, m3)
+
+	assert.Equal(t, "1", m3.ParentID)
+	assert.Equal(t, backupmodels.Refs{
+		backupmodels.KindRoles:   {"a": "a3"},
+		backupmodels.KindMembers: {"x": "x3"},
+	}, m3.Objects)
+	assert.Equal(t, map[string][]string{
+		backupmodels.KindRoles:   {"b"},
+		backupmodels.KindMembers: {"y"},
+	}, m3.Removed)
+}
+
+func TestStoreSnapshot(t *testing.T) {
+	bck := testBackups(t)
+
+	b1 := testBackup()
+	b1.ID = "1"
+	b1.Timestamp = time.Unix(1700000000, 0)
+	m1, err := bck.storeSnapshot(b1)
+	require.NoError(t, err)
+	assert.True(t, m1.IsFull())
+
+	b2 := testBackup()
+	b2.ID = "2"
+	b2.Timestamp = b1.Timestamp.Add(time.Hour)
+	b2.Roles[0].Name = "A2"
+	b2.Members = b2.Members[:1]
+	b2.Channels = append(b2.Channels, &backupmodels.Channel{
+		ID: "new", Name: "new", Type: discordgo.ChannelTypeGuildText, Position: 4})
+	m2, err := bck.storeSnapshot(b2)
+	require.NoError(t, err)
+	assert.Equal(t, "1", m2.ParentID)
+	assert.Equal(t, 3, m2.Changes())
+
+	loaded, err := bck.GetBackup("guild", "2")
+	require.NoError(t, err)
+	assertRefs(t, b2, loaded)
+
+	loaded, err = bck.GetBackup("guild", "1")
+	require.NoError(t, err)
+	assertRefs(t, b1, loaded)
+
+	// Removing the full snapshot squashes it into the
+	// incremental backup and deletes the blobs which are
+	// no more referenced.
+	entries, err := bck.Timeline("guild")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	remaining := map[string]*backupmodels.Entry{"2": &entries[0]}
+	hashes, err := bck.removeBackup(entries[1], remaining)
+	require.NoError(t, err)
+	require.NoError(t, bck.deleteUnreferencedBlobs("guild", hashes, remaining))
+
+	entries, err = bck.Timeline("guild")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].IsFull())
+
+	loaded, err = bck.GetBackup("guild", "2")
+	require.NoError(t, err)
+	assertRefs(t, b2, loaded)
+
+	refs1, _, err := snapshotRefs(b1)
+	require.NoError(t, err)
+	_, err = bck.st.read(blobName("guild", refs1[backupmodels.KindRoles]["role-a"]))
+	assert.NotNil(t, err)
+
+	names, err := storedObjects(bck.st, entries)
+	require.NoError(t, err)
+	refs2, _, err := snapshotRefs(b2)
+	require.NoError(t, err)
+	assert.Len(t, names, len(refs2.Hashes())+1)
+}
+
+func TestExpiredBackups(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 30, 0, 0, time.UTC)
+
+	var entries []backupmodels.Entry
+	add := func(id string, age time.Duration) {
+		entries = append(entries, backupmodels.Entry{FileID: id, Timestamp: now.Add(-age)})
+	}
+
+	add("now", 0)
+	add("10m", 10*time.Minute) // same hour as "now"
+	add("2h", 2*time.Hour)     // hourly
+	add("2h10m", 2*time.Hour+10*time.Minute)
+	add("3d", 3*24*time.Hour) // daily
+	add("3d1h", 3*24*time.Hour+time.Hour)
+	add("20d", 20*24*time.Hour) // weekly
+	add("60d", 60*24*time.Hour) // expired
+
+	r := backupmodels.Retention{Hourly: 24, Daily: 7, Weekly: 4}
+	expired := expiredBackups(entries, r, now)
+
+	ids := make([]string, len(expired))
+	for i, e := range expired {
+		ids[i] = e.FileID
+	}
+	assert.Equal(t, []string{"60d", "3d1h", "2h10m", "10m"}, ids)
+
+	// Without retention, only the latest
+	// backup is kept.
+	expired = expiredBackups(entries, backupmodels.Retention{}, now)
+	assert.Len(t, expired, len(entries)-1)
+	assert.Nil(t, expiredBackups(nil, r, now))
+}
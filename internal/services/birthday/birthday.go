@@ -1,30 +1,30 @@
 package birthday
 
 import (
-	"fmt"
-	"math/rand"
-	"strconv"
-	"strings"
+	"sort"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
 	"github.com/zekroTJA/shinpuru/internal/models"
-	"github.com/zekroTJA/shinpuru/internal/services/config"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/media"
 	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/discordutil"
-	"github.com/zekroTJA/shinpuru/pkg/giphy"
 	"github.com/zekrotja/dgrs"
 	"github.com/zekrotja/rogu"
 	"github.com/zekrotja/rogu/log"
 	"github.com/zekrotja/sop"
 )
 
+// RoleDuration is the time the birthday role
+// is assigned to members on their birthday.
+const RoleDuration = 24 * time.Hour
+
 type BirthdayService struct {
-	gif     *giphy.Client
+	media   media.Provider
 	db      database.Database
 	st      *dgrs.State
 	session *discordgo.Session
@@ -34,7 +34,8 @@ type BirthdayService struct {
 }
 
 func New(ctn di.Container) *BirthdayService {
-	b := &BirthdayService{
+	return &BirthdayService{
+		media:   ctn.Get(static.DiMediaProvider).(media.Provider),
 		db:      ctn.Get(static.DiDatabase).(database.Database),
 		st:      ctn.Get(static.DiState).(*dgrs.State),
 		session: ctn.Get(static.DiDiscordSession).(*discordgo.Session),
@@ -42,15 +43,14 @@ func New(ctn di.Container) *BirthdayService {
 		tp:      ctn.Get(static.DiTimeProvider).(timeprovider.Provider),
 		log:     log.Tagged("Birthdays"),
 	}
-
-	cfg := ctn.Get(static.DiConfig).(config.Provider)
-	if apiKey := cfg.Config().Giphy.APIKey; apiKey != "" {
-		b.gif = giphy.New(apiKey, "v1")
-	}
-
-	return b
 }
 
+// Schedule announces the birthdays of all members whose
+// birthday has started in their timezone and which have
+// not been announced this year yet. Also, birthday roles
+// are removed from members after RoleDuration.
+//
+// Schedule is meant to be executed every hour.
 func (b *BirthdayService) Schedule() (err error) {
 	bdays, err := b.db.GetBirthdays("")
 	if err != nil {
@@ -82,71 +82,174 @@ func (b *BirthdayService) Schedule() (err error) {
 		return
 	}
 
+	now := b.tp.Now()
+	locs := newLocationCache(b.db, b.log)
+
 	for _, guild := range guilds {
 		gbds, ok := bdayMap[guild.ID]
 		if !ok || gbds.Len() == 0 {
 			continue
 		}
 
-		gbds = gbds.Filter(isTodayFilter(b.tp.Now))
-		if gbds.Len() == 0 {
-			continue
+		gbds.Each(func(v models.Birthday, _ int) {
+			if err := b.handleBirthday(guild, v, now, locs); err != nil {
+				b.log.Error().Err(err).Field("gid", guild.ID).Msg("Failed handling birthday")
+				b.gl.Errorf(guild.ID, "Failed handling birthday: %s", err.Error())
+			}
+		})
+	}
+
+	return
+}
+
+// Upcoming returns the next birthdays of the guild
+// ordered by their next occurrence. If limit is larger
+// than 0, at most limit birthdays are returned.
+func (b *BirthdayService) Upcoming(guildID string, limit int) ([]models.UpcomingBirthday, error) {
+	bdays, err := b.db.GetBirthdays(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return nil, err
+	}
+
+	now := b.tp.Now()
+	locs := newLocationCache(b.db, b.log)
+
+	res := make([]models.UpcomingBirthday, 0, len(bdays))
+	for _, bd := range bdays {
+		next := NextOccurrence(bd, now, locs.get(bd))
+		ub := models.UpcomingBirthday{
+			UserID: bd.UserID,
+			Date:   next,
 		}
+		if bd.ShowYear {
+			ub.Age = next.Year() - bd.Date.Year()
+		}
+		res = append(res, ub)
+	}
 
-		bdayChan, err := b.db.GetGuildBirthdayChan(guild.ID)
-		if err != nil && !database.IsErrDatabaseNotFound(err) {
-			b.log.Error().Err(err).Field("gid", guild.ID).Msg("Failed getting birthday channel")
-			b.gl.Errorf(guild.ID, "Failed getting birthday channel: %s", err.Error())
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Date.Before(res[j].Date)
+	})
+
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res, nil
+}
+
+// Remove deletes the birthday of the member and
+// removes the birthday role, if currently assigned.
+func (b *BirthdayService) Remove(guildID, userID string) (err error) {
+	bdays, err := b.db.GetBirthdays(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return
+	}
+
+	for _, bd := range bdays {
+		if bd.UserID != userID || bd.RoleExpires.IsZero() {
 			continue
 		}
-		if bdayChan == "" {
-			continue
+		err = b.removeRole(guildID, userID)
+		if err != nil && !discordutil.IsErrCode(err, discordgo.ErrCodeUnknownMember) {
+			return
 		}
+	}
 
-		ch, _ := b.st.Channel(bdayChan)
-		if ch == nil {
-			b.gl.Warnf(guild.ID, "Birthday channel has been disabled because it could not be found on the guild")
-			b.db.SetGuildBirthdayChan(guild.ID, "")
-			continue
+	return b.db.DeleteBirthday(guildID, userID)
+}
+
+func (b *BirthdayService) handleBirthday(
+	guild *discordgo.Guild,
+	bd models.Birthday,
+	now time.Time,
+	locs *locationCache,
+) (err error) {
+	loc := locs.get(bd)
+	roleDue := !bd.RoleExpires.IsZero() && !now.Before(bd.RoleExpires)
+	announceDue := IsBirthday(bd, now, loc) && !announcedThisYear(bd, now, loc)
+
+	if !roleDue && !announceDue {
+		return
+	}
+
+	memb, err := b.st.Member(bd.GuildID, bd.UserID)
+	if memb == nil || memb.User == nil {
+		if err == nil || discordutil.IsErrCode(err, discordgo.ErrCodeUnknownMember) {
+			err = b.db.DeleteBirthday(bd.GuildID, bd.UserID)
 		}
+		return
+	}
 
-		gbds.Each(func(v models.Birthday, i int) {
-			memb, err := b.st.Member(v.GuildID, v.UserID)
-			if memb == nil || memb.User == nil {
-				if err == nil || discordutil.IsErrCode(err, discordgo.ErrCodeUnknownMember) {
-					err = b.db.DeleteBirthday(v.GuildID, v.UserID)
-				}
-			} else {
-				err = b.sendMessage(memb, bdayChan, v)
-			}
-			if err != nil {
-				b.log.Error().Err(err).Field("gid", guild.ID).Msg("Failed handling birthday")
-				b.gl.Errorf(guild.ID, "Failed handling birthday: %s", err.Error())
-			}
-		})
+	if roleDue {
+		if err = b.removeRole(guild.ID, bd.UserID); err != nil {
+			return
+		}
+		bd.RoleExpires = time.Time{}
 	}
 
-	return
+	if announceDue {
+		if err = b.announce(guild, memb, bd, now.In(loc)); err != nil {
+			return
+		}
+		bd.LastNotified = now
+
+		var roleAdded bool
+		if roleAdded, err = b.addRole(guild.ID, bd.UserID); err != nil {
+			return
+		}
+		if roleAdded {
+			bd.RoleExpires = now.Add(RoleDuration)
+		}
+	}
+
+	return b.db.SetBirthday(bd)
 }
 
-func (b *BirthdayService) sendMessage(memb *discordgo.Member, chanID string, bd models.Birthday) (err error) {
-	age := ""
-	if bd.ShowYear {
-		age = suffix(b.tp.Now().Year()-bd.Date.Year()) + " "
+func (b *BirthdayService) announce(
+	guild *discordgo.Guild,
+	memb *discordgo.Member,
+	bd models.Birthday,
+	now time.Time,
+) (err error) {
+	bdayChan, err := b.db.GetGuildBirthdayChan(guild.ID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return
+	}
+	if bdayChan == "" {
+		return nil
+	}
+
+	ch, _ := b.st.Channel(bdayChan)
+	if ch == nil {
+		b.gl.Warnf(guild.ID, "Birthday channel has been disabled because it could not be found on the guild")
+		return b.db.SetGuildBirthdayChan(guild.ID, "")
 	}
 
-	userMention := memb.Mention() + "'"
-	if !strings.HasSuffix(strings.ToLower(memb.User.Username), "s") && !strings.HasSuffix(strings.ToLower(memb.User.Username), "z") {
-		userMention += "s"
+	tmpl, err := b.db.GetGuildBirthdayTemplate(guild.ID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return
 	}
 
-	desc := fmt.Sprintf(
-		"Today is %s %sbirthday!\n\nHappy birthday to you!  🥳 🎉 🎊",
-		userMention, age)
+	return b.sendMessage(memb, guild, bdayChan, bd, tmpl, now)
+}
 
+func (b *BirthdayService) sendMessage(
+	memb *discordgo.Member,
+	guild *discordgo.Guild,
+	chanID string,
+	bd models.Birthday,
+	tmpl string,
+	now time.Time,
+) (err error) {
 	emb := &discordgo.MessageEmbed{
-		Color:       static.ColorEmbedDefault,
-		Description: desc,
+		Color: static.ColorEmbedDefault,
+		Description: Render(tmpl, RenderContext{
+			Member:   memb,
+			Guild:    guild,
+			Birthday: bd,
+			Now:      now,
+		}),
 		Thumbnail: &discordgo.MessageEmbedThumbnail{
 			URL:    memb.User.AvatarURL(""),
 			Width:  24,
@@ -154,13 +257,11 @@ func (b *BirthdayService) sendMessage(memb *discordgo.Member, chanID string, bd
 		},
 	}
 
-	if gif := b.randomGif(); gif != nil {
-		width, _ := strconv.Atoi(gif.Width)
-		height, _ := strconv.Atoi(gif.Height)
+	if m := b.randomMedia(); m != nil {
 		emb.Image = &discordgo.MessageEmbedImage{
-			URL:    gif.Url,
-			Width:  width,
-			Height: height,
+			URL:    m.URL,
+			Width:  m.Width,
+			Height: m.Height,
 		}
 	}
 
@@ -168,46 +269,84 @@ func (b *BirthdayService) sendMessage(memb *discordgo.Member, chanID string, bd
 	return
 }
 
-func (b *BirthdayService) randomGif() (img *giphy.Image) {
-	if b.gif == nil {
+// addRole adds the birthday role of the guild to the
+// member. added is false if no birthday role is set.
+func (b *BirthdayService) addRole(guildID, userID string) (added bool, err error) {
+	roleID, err := b.db.GetGuildBirthdayRole(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
 		return
 	}
-	rng := rand.Intn(100)
-	res, err := b.gif.Search("birthday", 1, rng, "pg")
-	if err != nil {
-		b.log.Error().Err(err).Msg("Failed searching for birthday gif")
+	if roleID == "" {
+		return false, nil
+	}
+
+	if err = b.session.GuildMemberRoleAdd(guildID, userID, roleID); err != nil {
+		if discordutil.IsErrCode(err, discordgo.ErrCodeUnknownRole) {
+			b.gl.Warnf(guildID, "Birthday role has been disabled because it could not be found on the guild")
+			return false, b.db.SetGuildBirthdayRole(guildID, "")
+		}
+		return
+	}
+
+	return true, nil
+}
+
+func (b *BirthdayService) removeRole(guildID, userID string) (err error) {
+	roleID, err := b.db.GetGuildBirthdayRole(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
 		return
 	}
-	if len(res) != 0 {
-		img = &res[0].Images.FixedWidth
+	if roleID == "" {
+		return nil
+	}
+
+	err = b.session.GuildMemberRoleRemove(guildID, userID, roleID)
+	if discordutil.IsErrCode(err, discordgo.ErrCodeUnknownRole) {
+		err = nil
 	}
 	return
 }
 
-func isTodayFilter(now func() time.Time) func(v models.Birthday, i int) bool {
-	return func(v models.Birthday, i int) bool {
-		m1, d1, h1 := format(now().UTC())
-		m2, d2, h2 := format(v.Date)
-		return m1 == m2 && d1 == d2 && h1 == h2
+func (b *BirthdayService) randomMedia() *media.Media {
+	m, err := b.media.Random("birthday")
+	if err != nil {
+		b.log.Error().Err(err).Msg("Failed getting birthday media")
+		return nil
 	}
+	return m
 }
 
-func format(date time.Time) (m time.Month, d, h int) {
-	_, m, d = date.Date()
-	h, _, _ = date.Clock()
-	return
+// locationCache caches the timezones of users
+// for the duration of a single run.
+type locationCache struct {
+	db   database.Database
+	log  rogu.Logger
+	locs map[string]*time.Location
 }
 
-func suffix(i int) string {
-	v := strconv.Itoa(i)
-	suffix := "th"
-	switch i % 10 {
-	case 1:
-		suffix = "st"
-	case 2:
-		suffix = "nd"
-	case 3:
-		suffix = "rd"
-	}
-	return v + suffix
+func newLocationCache(db database.Database, log rogu.Logger) *locationCache {
+	return &locationCache{
+		db:   db,
+		log:  log,
+		locs: make(map[string]*time.Location),
+	}
+}
+
+func (c *locationCache) get(bd models.Birthday) *time.Location {
+	if loc, ok := c.locs[bd.UserID]; ok {
+		return loc
+	}
+
+	tz, err := c.db.GetUserTimezone(bd.UserID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		c.log.Error().Err(err).Field("uid", bd.UserID).Msg("Failed getting user timezone")
+	}
+
+	loc, err := ParseTimezone(tz)
+	if tz == "" || err != nil {
+		loc = legacyLocation(bd.Date)
+	}
+
+	c.locs[bd.UserID] = loc
+	return loc
 }
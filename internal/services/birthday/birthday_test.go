@@ -0,0 +1,126 @@
+package birthday
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+func TestParseTimezone(t *testing.T) {
+	loc, err := ParseTimezone("")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = ParseTimezone("Europe/Berlin")
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", loc.String())
+
+	for tz, offset := range map[string]int{
+		"+2":      2 * 3600,
+		"-03:30":  -(3*3600 + 30*60),
+		"UTC+545": 5*3600 + 45*60,
+	} {
+		loc, err = ParseTimezone(tz)
+		require.NoError(t, err, tz)
+		_, got := time.Date(2022, 1, 1, 0, 0, 0, 0, loc).Zone()
+		assert.Equal(t, offset, got, tz)
+	}
+
+	_, err = ParseTimezone("+15")
+	assert.Error(t, err)
+	_, err = ParseTimezone("Not/AZone")
+	assert.Error(t, err)
+}
+
+func TestIsBirthday(t *testing.T) {
+	bd := models.Birthday{Date: time.Date(1990, 5, 10, 0, 0, 0, 0, time.UTC)}
+	tokyo, _ := ParseTimezone("Asia/Tokyo")
+	la, _ := ParseTimezone("America/Los_Angeles")
+
+	// 2022-05-09 20:00 UTC is already May 10th in
+	// Tokyo but still May 9th in Los Angeles.
+	now := time.Date(2022, 5, 9, 20, 0, 0, 0, time.UTC)
+	assert.True(t, IsBirthday(bd, now, tokyo))
+	assert.False(t, IsBirthday(bd, now, la))
+	assert.False(t, IsBirthday(bd, now, time.UTC))
+
+	now = time.Date(2022, 5, 11, 3, 0, 0, 0, time.UTC)
+	assert.True(t, IsBirthday(bd, now, la))
+	assert.False(t, IsBirthday(bd, now, tokyo))
+}
+
+func TestIsBirthdayLeapDay(t *testing.T) {
+	bd := models.Birthday{Date: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC)}
+
+	assert.True(t, IsBirthday(bd, time.Date(2022, 2, 28, 12, 0, 0, 0, time.UTC), time.UTC))
+	assert.False(t, IsBirthday(bd, time.Date(2024, 2, 28, 12, 0, 0, 0, time.UTC), time.UTC))
+	assert.True(t, IsBirthday(bd, time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), time.UTC))
+}
+
+func TestLegacyDate(t *testing.T) {
+	// Formerly, "1990-05-10+2" has been stored as midnight
+	// in UTC+2 and "1990-05-10-5" as midnight in UTC+19.
+	for offset, want := range map[int]int{2: 2 * 3600, 19: -5 * 3600} {
+		bd := models.Birthday{
+			Date: time.Date(1990, 5, 10, 0, 0, 0, 0, time.FixedZone("Offset", offset*3600)).UTC(),
+		}
+
+		m, d := CalendarDate(bd)
+		assert.Equal(t, time.May, m)
+		assert.Equal(t, 10, d)
+
+		_, got := time.Date(2022, 1, 1, 0, 0, 0, 0, legacyLocation(bd.Date)).Zone()
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	bd := models.Birthday{Date: time.Date(1990, 5, 10, 0, 0, 0, 0, time.UTC)}
+
+	next := NextOccurrence(bd, time.Date(2022, 5, 10, 15, 0, 0, 0, time.UTC), time.UTC)
+	assert.Equal(t, time.Date(2022, 5, 10, 0, 0, 0, 0, time.UTC), next)
+
+	next = NextOccurrence(bd, time.Date(2022, 5, 11, 0, 0, 0, 0, time.UTC), time.UTC)
+	assert.Equal(t, time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC), next)
+}
+
+func TestAnnouncedThisYear(t *testing.T) {
+	now := time.Date(2022, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	assert.False(t, announcedThisYear(models.Birthday{}, now, time.UTC))
+	assert.False(t, announcedThisYear(models.Birthday{LastNotified: now.AddDate(-1, 0, 0)}, now, time.UTC))
+	assert.True(t, announcedThisYear(models.Birthday{LastNotified: now.Add(-time.Hour)}, now, time.UTC))
+}
+
+func TestRender(t *testing.T) {
+	rc := RenderContext{
+		Member: &discordgo.Member{User: &discordgo.User{ID: "1", Username: "zekro"}},
+		Guild:  &discordgo.Guild{Name: "shinpuru"},
+		Birthday: models.Birthday{
+			Date:     time.Date(1990, 5, 10, 0, 0, 0, 0, time.UTC),
+			ShowYear: true,
+		},
+		Now: time.Date(2022, 5, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	assert.Equal(t,
+		"Today is <@!1>'s 32nd birthday!\n\nHappy birthday to you!  🥳 🎉 🎊",
+		Render("", rc))
+	assert.Equal(t,
+		"zekro turns 32nd on shinpuru [unknown]",
+		Render("[user] turns [age] on [guild] [unknown]", rc))
+
+	rc.Birthday.ShowYear = false
+	rc.Member.User.Username = "chris"
+	assert.Equal(t, "Happy birthday, <@!1>' [birthday]!", Render("Happy birthday, [ment.possessive] [[birthday]]!", rc))
+}
+
+func TestSuffix(t *testing.T) {
+	for i, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 112: "112th"} {
+		assert.Equal(t, want, suffix(i))
+	}
+}
@@ -0,0 +1,114 @@
+package birthday
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	// Embedded so that timezones can be resolved
+	// on hosts without a zoneinfo database.
+	_ "time/tzdata"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+var rxOffset = regexp.MustCompile(`^(?:UTC|GMT)?([\+\-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// ParseTimezone returns the location of the given timezone.
+// The timezone can either be an IANA timezone name like
+// "Europe/Berlin" or an offset to UTC like "+2", "-03:30"
+// or "UTC+5". An empty timezone resolves to UTC.
+func ParseTimezone(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+
+	if m := rxOffset.FindStringSubmatch(tz); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		if hours > 14 || minutes > 59 {
+			return nil, errors.New("timezone offset must be in range [-14:00, +14:00]")
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(fmt.Sprintf("UTC%s%02d:%02d", m[1], hours, minutes), offset), nil
+	}
+
+	return time.LoadLocation(tz)
+}
+
+// CalendarDate returns the month and day of the birthday.
+func CalendarDate(bd models.Birthday) (time.Month, int) {
+	date := bd.Date.UTC()
+	// Birthdays were formerly stored at midnight in the
+	// timezone offset passed by the user, which always
+	// results in a time on the day before in UTC.
+	if date.Hour() != 0 {
+		date = date.AddDate(0, 0, 1)
+	}
+	_, m, d := date.Date()
+	return m, d
+}
+
+// IsBirthday returns true if it is the birthday of
+// the member at the given time in the timezone loc.
+//
+// Birthdays on February 29th are celebrated on
+// February 28th in non-leap years.
+func IsBirthday(bd models.Birthday, now time.Time, loc *time.Location) bool {
+	now = now.In(loc)
+	m, d := occurrence(bd, now.Year())
+	return now.Month() == m && now.Day() == d
+}
+
+// NextOccurrence returns the start of the next birthday
+// of the member in the timezone loc. If the birthday is
+// today, the start of today is returned.
+func NextOccurrence(bd models.Birthday, now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	for year := now.Year(); ; year++ {
+		m, d := occurrence(bd, year)
+		next := time.Date(year, m, d, 0, 0, 0, 0, loc)
+		if !next.Before(today) {
+			return next
+		}
+	}
+}
+
+func occurrence(bd models.Birthday, year int) (time.Month, int) {
+	m, d := CalendarDate(bd)
+	if m == time.February && d == 29 && !isLeapYear(year) {
+		d = 28
+	}
+	return m, d
+}
+
+func announcedThisYear(bd models.Birthday, now time.Time, loc *time.Location) bool {
+	return !bd.LastNotified.IsZero() && bd.LastNotified.In(loc).Year() == now.In(loc).Year()
+}
+
+// legacyLocation returns the timezone offset encoded in
+// birthdays stored with the former offset syntax. Offsets
+// of more than 12 hours can not be distinguished from
+// negative offsets and are treated as such.
+func legacyLocation(date time.Time) *time.Location {
+	h := date.UTC().Hour()
+	switch {
+	case h == 0:
+		return time.UTC
+	case h >= 12:
+		return time.FixedZone("Offset", (24-h)*3600)
+	default:
+		return time.FixedZone("Offset", -h*3600)
+	}
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
@@ -0,0 +1,102 @@
+package birthday
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+// DefaultTemplate is used for birthday announcements
+// if no template has been set for the guild.
+const DefaultTemplate = "Today is [ment.possessive] [birthday]!\n\nHappy birthday to you!  🥳 🎉 🎊"
+
+// MaxTemplateLength is the maximum length of
+// a birthday announcement template.
+const MaxTemplateLength = 1000
+
+var rxTemplateVariable = regexp.MustCompile(`\[(user|ment|ment\.possessive|age|birthday|guild)\]`)
+
+// RenderContext contains the information about a
+// birthday which is used to replace the variables
+// of an announcement template.
+type RenderContext struct {
+	Member   *discordgo.Member
+	Guild    *discordgo.Guild
+	Birthday models.Birthday
+	Now      time.Time
+}
+
+// Render returns the template with all variables replaced
+// by the values of the render context. If the template is
+// empty, DefaultTemplate is used.
+//
+// The following variables are supported:
+//   - [user]            username of the member
+//   - [ment]            mention of the member
+//   - [ment.possessive] mention of the member followed by 's
+//   - [age]             the ordinal age, i.e. "25th", if the year is shown
+//   - [birthday]        "25th birthday" or "birthday" if the year is hidden
+//   - [guild]           name of the guild
+func Render(tmpl string, rc RenderContext) string {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+
+	age := ""
+	if rc.Birthday.ShowYear {
+		age = suffix(rc.Now.Year() - rc.Birthday.Date.Year())
+	}
+
+	return rxTemplateVariable.ReplaceAllStringFunc(tmpl, func(v string) string {
+		switch v[1 : len(v)-1] {
+		case "user":
+			return rc.Member.User.Username
+		case "ment":
+			return rc.Member.Mention()
+		case "ment.possessive":
+			return rc.Member.Mention() + possessive(rc.Member.User.Username)
+		case "age":
+			return age
+		case "birthday":
+			if age == "" {
+				return "birthday"
+			}
+			return age + " birthday"
+		case "guild":
+			if rc.Guild == nil {
+				return ""
+			}
+			return rc.Guild.Name
+		}
+		return v
+	})
+}
+
+func possessive(name string) string {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, "s") || strings.HasSuffix(name, "z") {
+		return "'"
+	}
+	return "'s"
+}
+
+func suffix(i int) string {
+	v := strconv.Itoa(i)
+	suffix := "th"
+	switch i % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if i%100 >= 11 && i%100 <= 13 {
+		suffix = "th"
+	}
+	return v + suffix
+}
@@ -71,9 +71,21 @@ type Database interface {
 	GetGuildBackup(guildID string) (bool, error)
 	SetGuildBackup(guildID string, enabled bool) error
 
+	GetGuildBackupRetention(guildID string) (backupmodels.Retention, error)
+	SetGuildBackupRetention(guildID string, r backupmodels.Retention) error
+
+	GetGuildEscalationLadder(guildID string) (models.EscalationLadder, error)
+	SetGuildEscalationLadder(guildID string, l models.EscalationLadder) error
+
 	GetGuildInviteBlock(guildID string) (string, error)
 	SetGuildInviteBlock(guildID string, data string) error
 
+	GetGuildInviteBlockSettings(guildID string) (models.InviteBlockSettings, error)
+	SetGuildInviteBlockSettings(guildID string, settings models.InviteBlockSettings) error
+
+	GetGuildAutomodRules(guildID string) ([]models.AutomodRule, error)
+	SetGuildAutomodRules(guildID string, rules []models.AutomodRule) error
+
 	GetGuildJoinMsg(guildID string) (string, string, error)
 	SetGuildJoinMsg(guildID string, channelID string, msg string) error
 
@@ -95,6 +107,12 @@ type Database interface {
 	GetGuildBirthdayChan(guildID string) (string, error)
 	SetGuildBirthdayChan(guildID string, chanID string) error
 
+	GetGuildBirthdayTemplate(guildID string) (string, error)
+	SetGuildBirthdayTemplate(guildID string, template string) error
+
+	GetGuildBirthdayRole(guildID string) (string, error)
+	SetGuildBirthdayRole(guildID string, roleID string) error
+
 	GetGuildModNot(guildID string) (string, error)
 	SetGuildModNot(guildID string, chanID string) error
 
@@ -110,6 +128,9 @@ type Database interface {
 	GetUserStarboardOptout(userID string) (bool, error)
 	SetUserStarboardOptout(userID string, enabled bool) error
 
+	GetUserTimezone(userID string) (string, error)
+	SetUserTimezone(userID string, timezone string) error
+
 	GetUserByRefreshToken(token string) (string, time.Time, error)
 	SetUserRefreshToken(userID, token string, expires time.Time) error
 	RevokeUserRefreshToken(userID string) error
@@ -122,6 +143,7 @@ type Database interface {
 
 	AddReport(rep models.Report) error
 	DeleteReport(id snowflake.ID) error
+	UpdateReport(rep models.Report) error
 	GetReport(id snowflake.ID) (models.Report, error)
 	GetReportsGuild(guildID string, offset, limit int) ([]models.Report, error)
 	GetReportsFiltered(guildID, memberID string, repType models.ReportType, offset, limit int) ([]models.Report, error)
@@ -130,6 +152,9 @@ type Database interface {
 	GetExpiredReports() ([]models.Report, error)
 	ExpireReports(id ...string) (err error)
 
+	AddReportRevision(rev models.ReportRevision) error
+	GetReportRevisions(reportID snowflake.ID) ([]models.ReportRevision, error)
+
 	//////////////////////////////////////////////////////
 	//// UNBAN REQUESTS
 
@@ -144,7 +169,10 @@ type Database interface {
 	//// VOTES
 
 	GetVotes() (map[string]vote.Vote, error)
+	GetVote(id string) (vote.Vote, error)
+	GetExpiredVotes(before time.Time) ([]vote.Vote, error)
 	AddUpdateVote(votes vote.Vote) error
+	ClaimVoteClose(id string) (ok bool, err error)
 	DeleteVote(voteID string) error
 
 	//////////////////////////////////////////////////////
@@ -158,11 +186,22 @@ type Database interface {
 	//////////////////////////////////////////////////////
 	//// GUILD BACKUPS
 
-	AddBackup(guildID, fileID string) error
+	AddBackup(entry backupmodels.Entry) error
+	UpdateBackup(entry backupmodels.Entry) error
 	DeleteBackup(guildID, fileID string) error
 	GetBackups(guildID string) ([]backupmodels.Entry, error)
 	GetGuilds() ([]string, error)
 
+	GetBackupRestoreCheckpoint(guildID string) (backupmodels.RestoreCheckpoint, error)
+	SetBackupRestoreCheckpoint(cp backupmodels.RestoreCheckpoint) error
+	DeleteBackupRestoreCheckpoint(guildID string) error
+
+	GetBackupKeys(guildID string) ([]backupmodels.DataKey, error)
+	GetBackupKey(keyID string) (backupmodels.DataKey, error)
+	AddBackupKey(key backupmodels.DataKey) error
+	UpdateBackupKey(key backupmodels.DataKey) error
+	DeleteBackupKey(keyID string) error
+
 	//////////////////////////////////////////////////////
 	//// TAGS
 
@@ -172,6 +211,7 @@ type Database interface {
 	GetTagByIdent(ident string, guildID string) (tag.Tag, error)
 	GetGuildTags(guildID string) ([]tag.Tag, error)
 	DeleteTag(id snowflake.ID) error
+	IncrementTagUses(id snowflake.ID) error
 
 	//////////////////////////////////////////////////////
 	//// API TOKEN
@@ -186,6 +226,7 @@ type Database interface {
 	GetKarma(userID, guildID string) (int, error)
 	GetKarmaSum(userID string) (int, error)
 	GetKarmaGuild(guildID string, limit int) ([]models.GuildKarma, error)
+	GetKarmaGuildCount(guildID string) (int, error)
 	SetKarma(userID, guildID string, val int) error
 	UpdateKarma(userID, guildID string, diff int) error
 
@@ -201,6 +242,14 @@ type Database interface {
 	SetKarmaPenalty(guildID string, state bool) error
 	GetKarmaPenalty(guildID string) (bool, error)
 
+	SetKarmaDecay(guildID string, decay models.KarmaDecay) error
+	GetKarmaDecay(guildID string) (models.KarmaDecay, error)
+	GetKarmaDecayGuilds() ([]string, error)
+	GetKarmaDecayCandidates(guildID string, inactiveSince time.Time) ([]models.GuildKarma, error)
+
+	SetKarmaFarmingLimits(guildID string, limits models.KarmaFarmingLimits) error
+	GetKarmaFarmingLimits(guildID string) (models.KarmaFarmingLimits, error)
+
 	GetKarmaBlockList(guildID string) ([]string, error)
 	IsKarmaBlockListed(guildID, userID string) (bool, error)
 	AddKarmaBlockList(guildID, userID string) error
@@ -211,6 +260,17 @@ type Database interface {
 	AddOrUpdateKarmaRule(rule models.KarmaRule) error
 	RemoveKarmaRule(guildID string, id snowflake.ID) error
 
+	SetKarmaTempRole(tr models.KarmaTempRole) error
+	GetExpiredKarmaTempRoles(now time.Time) ([]models.KarmaTempRole, error)
+	RemoveKarmaTempRole(guildID, userID, roleID string) error
+
+	AddKarmaLedgerEntry(entry models.KarmaLedgerEntry) error
+	GetKarmaLedger(guildID, userID string, offset, limit int) ([]models.KarmaLedgerEntry, error)
+	GetKarmaLedgerGiven(guildID, executorID string, from, to time.Time) ([]models.KarmaLedgerEntry, error)
+	SetKarmaLedgerReverted(id, revertedBy snowflake.ID) error
+	GetKarmaLedgerPairCount(guildID, executorID, targetID string, since time.Time) (int, error)
+	GetKarmaLeaderboard(guildID string, since time.Time, limit int) ([]models.GuildKarma, error)
+
 	//////////////////////////////////////////////////////
 	//// CHAN LOCK
 
@@ -234,6 +294,17 @@ type Database interface {
 	SetAntiraidVerification(guildID string, state bool) error
 	GetAntiraidVerification(guildID string) (bool, error)
 
+	SetAntiraidSignals(guildID string, sig models.AntiraidSignals) error
+	GetAntiraidSignals(guildID string) (models.AntiraidSignals, error)
+
+	SetAntiraidLockdown(guildID string, cfg models.AntiraidLockdown) error
+	GetAntiraidLockdown(guildID string) (models.AntiraidLockdown, error)
+
+	SetAntiraidLockdownState(state models.AntiraidLockdownState) error
+	GetAntiraidLockdownState(guildID string) (models.AntiraidLockdownState, error)
+	GetAntiraidLockdownStates() ([]models.AntiraidLockdownState, error)
+	DeleteAntiraidLockdownState(guildID string) error
+
 	AddToAntiraidJoinList(guildID, userID, userTag string, accountCreated time.Time) error
 	GetAntiraidJoinList(guildID string) ([]models.JoinLogEntry, error)
 	FlushAntiraidJoinList(guildID string) error
@@ -243,12 +314,14 @@ type Database interface {
 	//// STARBOARD
 
 	SetStarboardConfig(config models.StarboardConfig) error
-	GetStarboardConfig(guildID string) (models.StarboardConfig, error)
+	GetStarboardConfig(guildID, name string) (models.StarboardConfig, error)
+	GetStarboardConfigs(guildID string) ([]models.StarboardConfig, error)
+	RemoveStarboardConfig(guildID, name string) error
 	SetStarboardEntry(e models.StarboardEntry) (err error)
 	RemoveStarboardEntry(msgID string) error
 	GetStarboardEntries(guildID string, sortBy models.StarboardSortBy, limit, offset int) ([]models.StarboardEntry, error)
 	GetStarboardEntriesCount(guildID string) (int, error)
-	GetStarboardEntry(messageID string) (models.StarboardEntry, error)
+	GetStarboardEntry(messageID, board string) (models.StarboardEntry, error)
 
 	//////////////////////////////////////////////////////
 	//// GUILDLOG
@@ -262,6 +335,7 @@ type Database interface {
 	//////////////////////////////////////////////////////
 	//// FUNCTIONALITIES
 
+	GetAllGuildIDs() ([]string, error)
 	FlushGuildData(guildID string) error
 
 	//////////////////////////////////////////////////////
@@ -284,6 +358,8 @@ type Database interface {
 
 	AddRoleSelects(v []models.RoleSelect) error
 	GetRoleSelects() ([]models.RoleSelect, error)
+	GetGuildRoleSelects(guildID string) ([]models.RoleSelect, error)
+	SetRoleSelects(guildID, channelID, messageID string, v []models.RoleSelect) error
 	RemoveRoleSelect(guildID, channelID, messageID string) error
 }
 
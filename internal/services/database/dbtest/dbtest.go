@@ -0,0 +1,1230 @@
+// Package dbtest provides a driver independent test
+// suite which can be run against any implementation
+// of database.Database to ensure consistent behavior
+// between the different database drivers.
+package dbtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/tag"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+	"github.com/zekroTJA/shinpuru/pkg/permissions"
+	"github.com/zekroTJA/shinpuru/pkg/twitchnotify"
+)
+
+// Run executes the test suite against the given
+// database instance. The database is expected to
+// be connected and migrated to the latest state.
+func Run(t *testing.T, db database.Database) {
+	t.Run("GuildSettings", func(t *testing.T) { testGuildSettings(t, db) })
+	t.Run("UserSettings", func(t *testing.T) { testUserSettings(t, db) })
+	t.Run("Permissions", func(t *testing.T) { testPermissions(t, db) })
+	t.Run("Reports", func(t *testing.T) { testReports(t, db) })
+	t.Run("UnbanRequests", func(t *testing.T) { testUnbanRequests(t, db) })
+	t.Run("TwitchNotify", func(t *testing.T) { testTwitchNotify(t, db) })
+	t.Run("Backups", func(t *testing.T) { testBackups(t, db) })
+	t.Run("Tags", func(t *testing.T) { testTags(t, db) })
+	t.Run("Votes", func(t *testing.T) { testVotes(t, db) })
+	t.Run("Karma", func(t *testing.T) { testKarma(t, db) })
+	t.Run("KarmaRules", func(t *testing.T) { testKarmaRules(t, db) })
+	t.Run("KarmaLedger", func(t *testing.T) { testKarmaLedger(t, db) })
+	t.Run("Antiraid", func(t *testing.T) { testAntiraid(t, db) })
+	t.Run("Starboard", func(t *testing.T) { testStarboard(t, db) })
+	t.Run("GuildLog", func(t *testing.T) { testGuildLog(t, db) })
+	t.Run("VerificationQueue", func(t *testing.T) { testVerificationQueue(t, db) })
+	t.Run("Birthdays", func(t *testing.T) { testBirthdays(t, db) })
+	t.Run("RoleSelects", func(t *testing.T) { testRoleSelects(t, db) })
+	t.Run("FlushGuildData", func(t *testing.T) { testFlushGuildData(t, db) })
+}
+
+// uid returns a unique snowflake-like ID string to
+// avoid collisions between test runs on a persistent
+// database.
+func uid() string {
+	return node.Generate().String()
+}
+
+var node, _ = snowflake.NewNode(1023)
+
+func testGuildSettings(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	_, err := db.GetGuildPrefix(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	require.NoError(t, db.SetGuildPrefix(guildID, "sp!"))
+	v, err := db.GetGuildPrefix(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, "sp!", v)
+
+	require.NoError(t, db.SetGuildPrefix(guildID, "!"))
+	v, err = db.GetGuildPrefix(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, "!", v)
+
+	require.NoError(t, db.SetGuildAutoRole(guildID, []string{"a", "b"}))
+	roles, err := db.GetGuildAutoRole(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, roles)
+
+	require.NoError(t, db.SetGuildBackup(guildID, true))
+	ok, err := db.GetGuildBackup(guildID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	guilds, err := db.GetGuilds()
+	require.NoError(t, err)
+	assert.Contains(t, guilds, guildID)
+
+	_, err = db.GetGuildEscalationLadder(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	ladder := models.EscalationLadder{
+		{On: models.TypeWarn, Counted: models.TypeWarn, Count: 3, Window: 30 * 24 * 3600, Action: models.TypeMute, Duration: 24 * 3600},
+		{On: models.TypeWarn, Counted: models.TypeKick, Count: 1, Action: models.TypeBan},
+	}
+	require.NoError(t, db.SetGuildEscalationLadder(guildID, ladder))
+	gotLadder, err := db.GetGuildEscalationLadder(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, ladder, gotLadder)
+
+	require.NoError(t, db.SetGuildEscalationLadder(guildID, nil))
+	_, err = db.GetGuildEscalationLadder(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	_, err = db.GetAntiraidSignals(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	signals := models.AntiraidSignals{
+		AccountAge:       models.AntiraidAccountAgeSignal{Response: models.AntiraidResponseQuarantine, MinAge: 3600},
+		Spam:             models.AntiraidSpamSignal{Response: models.AntiraidResponseKick, Window: 60, Mentions: 5},
+		QuarantineRoleID: "role",
+	}
+	require.NoError(t, db.SetAntiraidSignals(guildID, signals))
+	gotSignals, err := db.GetAntiraidSignals(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, signals, gotSignals)
+
+	require.NoError(t, db.SetAntiraidSignals(guildID, models.AntiraidSignals{}))
+	_, err = db.GetAntiraidSignals(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	_, err = db.GetAntiraidLockdown(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	lockdown := models.AntiraidLockdown{
+		Auto:             true,
+		PauseInvites:     true,
+		LockChannels:     []string{"c1", "c2"},
+		SlowmodeChannels: []string{"c3"},
+		Slowmode:         30,
+		QuietPeriod:      600,
+	}
+	require.NoError(t, db.SetAntiraidLockdown(guildID, lockdown))
+	gotLockdown, err := db.GetAntiraidLockdown(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, lockdown, gotLockdown)
+
+	_, err = db.GetAntiraidLockdownState(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	level := discordgo.VerificationLevelLow
+	lockdownState := models.AntiraidLockdownState{
+		GuildID:               guildID,
+		Started:               time.Unix(1700000000, 0).UTC(),
+		QuietPeriod:           600,
+		PrevVerificationLevel: &level,
+		LockedChannels:        []string{"c1"},
+		PrevSlowmodes:         map[string]int{"c3": 0},
+	}
+	require.NoError(t, db.SetAntiraidLockdownState(lockdownState))
+	lockdownState.Joins = []string{"u1"}
+	require.NoError(t, db.SetAntiraidLockdownState(lockdownState))
+	gotLockdownState, err := db.GetAntiraidLockdownState(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, lockdownState, gotLockdownState)
+	lockdownStates, err := db.GetAntiraidLockdownStates()
+	require.NoError(t, err)
+	assert.Contains(t, lockdownStates, lockdownState)
+
+	require.NoError(t, db.DeleteAntiraidLockdownState(guildID))
+	_, err = db.GetAntiraidLockdownState(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	_, err = db.GetGuildInviteBlockSettings(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	inviteBlock := models.InviteBlockSettings{
+		AllowedGuilds: []string{"g1"},
+		Channels:      []string{"c1", "c2"},
+		Warn:          true,
+		Timeout:       600,
+	}
+	require.NoError(t, db.SetGuildInviteBlockSettings(guildID, inviteBlock))
+	gotInviteBlock, err := db.GetGuildInviteBlockSettings(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, inviteBlock, gotInviteBlock)
+
+	_, err = db.GetGuildAutomodRules(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	automodRules := []models.AutomodRule{
+		{
+			ID:      1,
+			Name:    "words",
+			Enabled: true,
+			Matcher: models.AutomodMatcher{
+				Type:  models.AutomodMatchWords,
+				Words: []string{"badword"},
+			},
+			ExemptRoles: []string{"r1"},
+			Actions: []models.AutomodAction{
+				{Type: models.AutomodActionDelete},
+				{Type: models.AutomodActionTimeout, Duration: 60},
+			},
+		},
+	}
+	require.NoError(t, db.SetGuildAutomodRules(guildID, automodRules))
+	gotAutomodRules, err := db.GetGuildAutomodRules(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, automodRules, gotAutomodRules)
+
+	require.NoError(t, db.SetGuildAutomodRules(guildID, nil))
+	_, err = db.GetGuildAutomodRules(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	require.NoError(t, db.SetGuildModNot(guildID, "chan"))
+	v, err = db.GetGuildModNot(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, "chan", v)
+
+	require.NoError(t, db.SetGuildVoiceLogIngore(guildID, "vc1"))
+	require.NoError(t, db.SetGuildVoiceLogIngore(guildID, "vc1"))
+	ignored, err := db.GetGuildVoiceLogIgnores(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vc1"}, ignored)
+	ok, err = db.IsGuildVoiceLogIgnored(guildID, "vc1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, db.RemoveGuildVoiceLogIgnore(guildID, "vc1"))
+	ok, err = db.IsGuildVoiceLogIgnored(guildID, "vc1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	api := models.GuildAPISettings{Enabled: true, AllowedOrigins: "*", TokenHash: "hash"}
+	require.NoError(t, db.SetGuildAPI(guildID, api))
+	api.Enabled = false
+	require.NoError(t, db.SetGuildAPI(guildID, api))
+	gotAPI, err := db.GetGuildAPI(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, api, gotAPI)
+
+	require.NoError(t, db.SetSetting("dbtest-"+guildID, "a"))
+	require.NoError(t, db.SetSetting("dbtest-"+guildID, "b"))
+	v, err = db.GetSetting("dbtest-" + guildID)
+	require.NoError(t, err)
+	assert.Equal(t, "b", v)
+}
+
+func testUserSettings(t *testing.T, db database.Database) {
+	userID := uid()
+
+	_, err := db.GetUserVerified(userID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	require.NoError(t, db.SetUserVerified(userID, true))
+	ok, err := db.GetUserVerified(userID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, db.SetUserOTAEnabled(userID, true))
+	ok, err = db.GetUserOTAEnabled(userID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, db.SetUserTimezone(userID, "Europe/Berlin"))
+	tz, err := db.GetUserTimezone(userID)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", tz)
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, db.SetUserRefreshToken(userID, "token-"+userID, expires))
+	gotUser, gotExpires, err := db.GetUserByRefreshToken("token-" + userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, gotUser)
+	assert.True(t, expires.Equal(gotExpires))
+	require.NoError(t, db.RevokeUserRefreshToken(userID))
+	_, _, err = db.GetUserByRefreshToken("token-" + userID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	now := time.Now().Truncate(time.Second)
+	token := models.APITokenEntry{
+		UserID:     userID,
+		Salt:       "salt",
+		Created:    now,
+		Expires:    now.Add(time.Hour),
+		LastAccess: now,
+		Hits:       1,
+	}
+	require.NoError(t, db.SetAPIToken(token))
+	token.Hits = 2
+	require.NoError(t, db.SetAPIToken(token))
+	gotToken, err := db.GetAPIToken(userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, gotToken.Hits)
+	assert.True(t, token.Expires.Equal(gotToken.Expires))
+	require.NoError(t, db.DeleteAPIToken(userID))
+	_, err = db.GetAPIToken(userID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	res, err := db.FlushUserData(userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res["users"])
+}
+
+func testPermissions(t *testing.T, db database.Database) {
+	guildID := uid()
+	roleID := uid()
+
+	require.NoError(t, db.SetGuildRolePermission(guildID, roleID, permissions.PermissionArray{"+sp.a", "-sp.b"}))
+	perms, err := db.GetGuildPermissions(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, permissions.PermissionArray{"+sp.a", "-sp.b"}, perms[roleID])
+
+	require.NoError(t, db.SetGuildRolePermission(guildID, roleID, nil))
+	perms, err = db.GetGuildPermissions(guildID)
+	require.NoError(t, err)
+	assert.Empty(t, perms)
+}
+
+func testReports(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	timeout := time.Now().Add(-time.Minute).Truncate(time.Second)
+	reps := []models.Report{
+		{ID: node.Generate(), Type: models.TypeWarn, GuildID: guildID, ExecutorID: "e", VictimID: "v1", Msg: "m1"},
+		{ID: node.Generate(), Type: models.TypeMute, GuildID: guildID, ExecutorID: "e", VictimID: "v1", Msg: "m2", Timeout: &timeout},
+		{ID: node.Generate(), Type: models.TypeWarn, GuildID: guildID, ExecutorID: "e", VictimID: "v2", Msg: "m3"},
+	}
+	reps[1].LinkedID = reps[0].ID
+	for _, r := range reps {
+		require.NoError(t, db.AddReport(r))
+	}
+
+	r, err := db.GetReport(reps[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, reps[0], r)
+
+	r, err = db.GetReport(reps[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, reps[0].ID, r.LinkedID)
+
+	_, err = db.GetReport(node.Generate())
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	all, err := db.GetReportsGuild(guildID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, reps[2].ID, all[0].ID)
+
+	paged, err := db.GetReportsGuild(guildID, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, paged, 1)
+	assert.Equal(t, reps[1].ID, paged[0].ID)
+
+	filtered, err := db.GetReportsFiltered(guildID, "v1", models.TypeWarn, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, reps[0].ID, filtered[0].ID)
+
+	n, err := db.GetReportsGuildCount(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = db.GetReportsFilteredCount(guildID, "v1", -1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = db.GetReportsFilteredCount(guildID, "", int(models.TypeWarn))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	expired, err := db.GetExpiredReports()
+	require.NoError(t, err)
+	assert.Contains(t, reportIDs(expired), reps[1].ID)
+
+	require.NoError(t, db.ExpireReports(reps[1].ID.String()))
+	expired, err = db.GetExpiredReports()
+	require.NoError(t, err)
+	assert.NotContains(t, reportIDs(expired), reps[1].ID)
+
+	amended := reps[0]
+	amended.Msg = "m1 amended"
+	amended.AttachmentURL = "attachment"
+	amended.Timeout = &timeout
+	require.NoError(t, db.UpdateReport(amended))
+	r, err = db.GetReport(amended.ID)
+	require.NoError(t, err)
+	assert.Equal(t, amended.Msg, r.Msg)
+	assert.Equal(t, amended.AttachmentURL, r.AttachmentURL)
+	if assert.NotNil(t, r.Timeout) {
+		assert.True(t, timeout.Equal(*r.Timeout))
+	}
+
+	revs := []models.ReportRevision{
+		{ReportID: reps[0].ID, Version: 1, GuildID: guildID, EditorID: "e", Timestamp: reps[0].GetTimestamp(), Msg: "m1"},
+		{ReportID: reps[0].ID, Version: 2, GuildID: guildID, EditorID: "e2", Timestamp: time.Now(), Msg: "m1 amended", AttachmentURL: "attachment", Timeout: &timeout},
+	}
+	require.NoError(t, db.AddReportRevision(revs[1]))
+	require.NoError(t, db.AddReportRevision(revs[0]))
+	gotRevs, err := db.GetReportRevisions(reps[0].ID)
+	require.NoError(t, err)
+	require.Len(t, gotRevs, 2)
+	for i, rev := range revs {
+		assert.Equal(t, rev.Version, gotRevs[i].Version)
+		assert.Equal(t, rev.EditorID, gotRevs[i].EditorID)
+		assert.Equal(t, rev.Msg, gotRevs[i].Msg)
+		assert.True(t, rev.Timestamp.Truncate(time.Second).Equal(gotRevs[i].Timestamp.Truncate(time.Second)))
+	}
+	assert.Nil(t, gotRevs[0].Timeout)
+	if assert.NotNil(t, gotRevs[1].Timeout) {
+		assert.True(t, timeout.Equal(*gotRevs[1].Timeout))
+	}
+
+	require.NoError(t, db.DeleteReport(reps[0].ID))
+	_, err = db.GetReport(reps[0].ID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+	gotRevs, err = db.GetReportRevisions(reps[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, gotRevs)
+}
+
+func reportIDs(reps []models.Report) []snowflake.ID {
+	ids := make([]snowflake.ID, 0, len(reps))
+	for _, r := range reps {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func testUnbanRequests(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	rep := models.Report{ID: node.Generate(), Type: models.TypeBan, GuildID: guildID, ExecutorID: "e", VictimID: "u"}
+	require.NoError(t, db.AddReport(rep))
+
+	req := models.UnbanRequest{
+		ID:       node.Generate(),
+		UserID:   "u",
+		GuildID:  guildID,
+		UserTag:  "u#0001",
+		Message:  "please",
+		ReportID: rep.ID,
+	}
+	require.NoError(t, db.AddUnbanRequest(req))
+
+	req.Status = models.UnbanRequestStateAccepted
+	req.ProcessedBy = "mod"
+	req.Processed = time.Now().Truncate(time.Second)
+	req.ProcessedMessage = "ok"
+	require.NoError(t, db.UpdateUnbanRequest(req))
+
+	got, err := db.GetUnbanRequest(req.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, req.Status, got.Status)
+	assert.Equal(t, req.ReportID, got.ReportID)
+	assert.True(t, req.Processed.Equal(got.Processed))
+
+	list, err := db.GetGuildUnbanRequests(guildID, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	list, err = db.GetGuildUserUnbanRequests("u", guildID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	state := models.UnbanRequestStateAccepted
+	n, err := db.GetGuildUnbanRequestsCount(guildID, &state)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	state = models.UnbanRequestStatePending
+	n, err = db.GetGuildUnbanRequestsCount(guildID, &state)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func testTwitchNotify(t *testing.T, db database.Database) {
+	guildID := uid()
+	twitchID := uid()
+
+	e := twitchnotify.DBEntry{GuildID: guildID, ChannelID: "c1", TwitchUserID: twitchID}
+	require.NoError(t, db.SetTwitchNotify(e))
+	e.ChannelID = "c2"
+	require.NoError(t, db.SetTwitchNotify(e))
+
+	got, err := db.GetTwitchNotify(twitchID, guildID)
+	require.NoError(t, err)
+	assert.Equal(t, e, got)
+
+	all, err := db.GetAllTwitchNotifies(twitchID)
+	require.NoError(t, err)
+	assert.Equal(t, []twitchnotify.DBEntry{e}, all)
+
+	require.NoError(t, db.DeleteTwitchNotify(twitchID, guildID))
+	_, err = db.GetTwitchNotify(twitchID, guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+}
+
+func testBackups(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	_, err := db.GetGuildBackupRetention(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	retention := backupmodels.Retention{Hourly: 24, Daily: 30, Weekly: 52}
+	require.NoError(t, db.SetGuildBackupRetention(guildID, retention))
+	gotRetention, err := db.GetGuildBackupRetention(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, retention, gotRetention)
+
+	f1 := backupmodels.Entry{GuildID: guildID, Timestamp: time.Unix(1700000000, 0), FileID: "f1", Changes: 10}
+	f2 := backupmodels.Entry{GuildID: guildID, Timestamp: time.Unix(1700003600, 0), FileID: "f2", ParentID: "f1", Changes: 2}
+	require.NoError(t, db.AddBackup(f1))
+	require.NoError(t, db.AddBackup(f2))
+
+	backups, err := db.GetBackups(guildID)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.ElementsMatch(t, []backupmodels.Entry{f1, f2}, backups)
+
+	f2.ParentID = ""
+	f2.Changes = 11
+	require.NoError(t, db.UpdateBackup(f2))
+
+	require.NoError(t, db.DeleteBackup(guildID, "f1"))
+	backups, err = db.GetBackups(guildID)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, f2, backups[0])
+
+	_, err = db.GetBackupRestoreCheckpoint(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	cp := backupmodels.RestoreCheckpoint{
+		GuildID: guildID,
+		FileID:  "f2",
+		Updated: time.Unix(1700000000, 0),
+		Options: backupmodels.RestoreOptions{
+			Components: []backupmodels.RestoreComponent{backupmodels.RestoreComponentRoles},
+		},
+		Completed: []string{"role.create.1"},
+		IDs:       map[string]string{"1": "2"},
+	}
+	require.NoError(t, db.SetBackupRestoreCheckpoint(cp))
+	cp.Completed = append(cp.Completed, "roles.reorder")
+	require.NoError(t, db.SetBackupRestoreCheckpoint(cp))
+
+	rcp, err := db.GetBackupRestoreCheckpoint(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, cp.FileID, rcp.FileID)
+	assert.Equal(t, cp.Options, rcp.Options)
+	assert.Equal(t, cp.Completed, rcp.Completed)
+	assert.Equal(t, cp.IDs, rcp.IDs)
+	assert.True(t, cp.Updated.Equal(rcp.Updated))
+
+	require.NoError(t, db.DeleteBackupRestoreCheckpoint(guildID))
+	_, err = db.GetBackupRestoreCheckpoint(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	_, err = db.GetBackupKey(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	k1 := backupmodels.DataKey{ID: uid(), GuildID: guildID, MasterKeyID: "m1", Key: "a2V5MQ==", Created: time.Unix(1700000000, 0)}
+	k2 := backupmodels.DataKey{ID: uid(), GuildID: guildID, MasterKeyID: "m1", Key: "a2V5Mg==", Created: time.Unix(1700003600, 0)}
+	require.NoError(t, db.AddBackupKey(k1))
+	require.NoError(t, db.AddBackupKey(k2))
+
+	k1.MasterKeyID = "m2"
+	k1.Key = "a2V5MS0y"
+	require.NoError(t, db.UpdateBackupKey(k1))
+
+	key, err := db.GetBackupKey(k1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, k1, key)
+
+	keys, err := db.GetBackupKeys(guildID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []backupmodels.DataKey{k1, k2}, keys)
+
+	require.NoError(t, db.DeleteBackupKey(k2.ID))
+	keys, err = db.GetBackupKeys(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, []backupmodels.DataKey{k1}, keys)
+}
+
+func testTags(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	now := time.Now().Truncate(time.Second)
+	tg := tag.Tag{
+		ID:        node.Generate(),
+		Ident:     "hello",
+		CreatorID: "c",
+		GuildID:   guildID,
+		Content:   "world",
+		Created:   now,
+		LastEdit:  now,
+		Aliases:   []string{"hi", "hey"},
+		Roles:     []string{"r"},
+	}
+	require.NoError(t, db.AddTag(tg))
+
+	got, err := db.GetTagByIdent("hello", guildID)
+	require.NoError(t, err)
+	assert.Equal(t, tg.ID, got.ID)
+	assert.Equal(t, tg.Content, got.Content)
+	assert.Equal(t, tg.Aliases, got.Aliases)
+	assert.Equal(t, tg.Roles, got.Roles)
+
+	got, err = db.GetTagByIdent("hey", guildID)
+	require.NoError(t, err)
+	assert.Equal(t, tg.ID, got.ID)
+	_, err = db.GetTagByIdent("he", guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	tg.Content = "there"
+	tg.Aliases = nil
+	require.NoError(t, db.EditTag(tg))
+	got, err = db.GetTagByID(tg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "there", got.Content)
+	assert.Empty(t, got.Aliases)
+
+	require.NoError(t, db.IncrementTagUses(tg.ID))
+	require.NoError(t, db.IncrementTagUses(tg.ID))
+
+	tags, err := db.GetGuildTags(guildID)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, 2, tags[0].Uses)
+
+	require.NoError(t, db.DeleteTag(tg.ID))
+	_, err = db.GetTagByID(tg.ID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+}
+
+func testVotes(t *testing.T, db database.Database) {
+	v := vote.Vote{
+		ID:            uid(),
+		GuildID:       uid(),
+		Possibilities: []string{"a", "b"},
+		Mode:          vote.VoteModeMulti,
+		Roles:         []string{"r"},
+		RoleWeights:   map[string]int{"r": 2},
+		Ticks:         map[string]*vote.Tick{"u": {UserID: "u", Picks: []int{0, 1}, Weight: 2}},
+	}
+	require.NoError(t, db.AddUpdateVote(v))
+
+	got, err := db.GetVote(v.ID)
+	require.NoError(t, err)
+	assert.Equal(t, v.Mode, got.Mode)
+	assert.Equal(t, v.RoleWeights, got.RoleWeights)
+	assert.Equal(t, []int{0, 1}, got.Ticks["u"].Picks)
+
+	expired, err := db.GetExpiredVotes(time.Now())
+	require.NoError(t, err)
+	assert.NotContains(t, voteIDs(expired), v.ID)
+
+	v.Expires = time.Now().Add(-time.Minute)
+	require.NoError(t, db.AddUpdateVote(v))
+	expired, err = db.GetExpiredVotes(time.Now())
+	require.NoError(t, err)
+	assert.Contains(t, voteIDs(expired), v.ID)
+
+	ok, err := db.ClaimVoteClose(v.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.ClaimVoteClose(v.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	expired, err = db.GetExpiredVotes(time.Now())
+	require.NoError(t, err)
+	assert.NotContains(t, voteIDs(expired), v.ID)
+
+	// Open votes must not overwrite closed ones.
+	require.NoError(t, db.AddUpdateVote(v))
+	v.State = vote.VoteStateExpired
+	require.NoError(t, db.AddUpdateVote(v))
+	v.State = vote.VoteStateOpen
+	require.NoError(t, db.AddUpdateVote(v))
+	votes, err := db.GetVotes()
+	require.NoError(t, err)
+	assert.Equal(t, vote.VoteStateExpired, votes[v.ID].State)
+
+	require.NoError(t, db.DeleteVote(v.ID))
+	_, err = db.GetVote(v.ID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+}
+
+func voteIDs(votes []vote.Vote) []string {
+	ids := make([]string, len(votes))
+	for i, v := range votes {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+func testKarma(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	require.NoError(t, db.UpdateKarma("u1", guildID, 5))
+	require.NoError(t, db.UpdateKarma("u1", guildID, -2))
+	require.NoError(t, db.SetKarma("u2", guildID, 10))
+
+	v, err := db.GetKarma("u1", guildID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+
+	list, err := db.GetKarmaGuild(guildID, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "u2", list[0].UserID)
+
+	n, err := db.GetKarmaGuildCount(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	require.NoError(t, db.SetKarmaState(guildID, false))
+	state, err := db.GetKarmaState(guildID)
+	require.NoError(t, err)
+	assert.False(t, state)
+
+	require.NoError(t, db.SetKarmaEmotes(guildID, "a", "b"))
+	inc, dec, err := db.GetKarmaEmotes(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, "a", inc)
+	assert.Equal(t, "b", dec)
+
+	require.NoError(t, db.SetKarmaTokens(guildID, 3))
+	tokens, err := db.GetKarmaTokens(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, tokens)
+
+	require.NoError(t, db.SetKarmaPenalty(guildID, true))
+	penalty, err := db.GetKarmaPenalty(guildID)
+	require.NoError(t, err)
+	assert.True(t, penalty)
+
+	decay := models.KarmaDecay{After: 7 * 24 * 3600, Amount: 2}
+	require.NoError(t, db.SetKarmaDecay(guildID, decay))
+	gotDecay, err := db.GetKarmaDecay(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, decay, gotDecay)
+
+	decay.EnabledAt = time.Unix(1640995200, 0)
+	require.NoError(t, db.SetKarmaDecay(guildID, decay))
+	gotDecay, err = db.GetKarmaDecay(guildID)
+	require.NoError(t, err)
+	assert.True(t, decay.EnabledAt.Equal(gotDecay.EnabledAt))
+
+	limits := models.KarmaFarmingLimits{PairLimit: 5, PairWindow: 3600, SockPuppetThreshold: 10}
+	require.NoError(t, db.SetKarmaFarmingLimits(guildID, limits))
+	gotLimits, err := db.GetKarmaFarmingLimits(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, limits, gotLimits)
+
+	guilds, err := db.GetKarmaDecayGuilds()
+	require.NoError(t, err)
+	assert.NotContains(t, guilds, guildID)
+	require.NoError(t, db.SetKarmaState(guildID, true))
+	guilds, err = db.GetKarmaDecayGuilds()
+	require.NoError(t, err)
+	assert.Contains(t, guilds, guildID)
+
+	require.NoError(t, db.AddKarmaBlockList(guildID, "u3"))
+	ok, err := db.IsKarmaBlockListed(guildID, "u3")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, db.RemoveKarmaBlockList(guildID, "u3"))
+	ok, err = db.IsKarmaBlockListed(guildID, "u3")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testKarmaRules(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	rule := models.KarmaRule{
+		ID:       node.Generate(),
+		GuildID:  guildID,
+		Trigger:  models.KarmaTriggerAbove,
+		Value:    10,
+		Action:   models.KarmaActionToggleRole,
+		Argument: "role",
+	}
+	rule.CalculateChecksum()
+	require.NoError(t, db.AddOrUpdateKarmaRule(rule))
+
+	ok, err := db.CheckKarmaRule(guildID, rule.Checksum)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	rule.Trigger = models.KarmaTriggerRange
+	rule.Value = 20
+	rule.MaxValue = 30
+	rule.Action = models.KarmaActionTempRole
+	rule.Duration = 3600
+	rule.Cooldown = 600
+	rule.Channels = []string{"c1", "c2"}
+	rule.Roles = []string{"r1"}
+	rule.CalculateChecksum()
+	require.NoError(t, db.AddOrUpdateKarmaRule(rule))
+
+	rules, err := db.GetKarmaRules(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.KarmaRule{rule}, rules)
+
+	require.NoError(t, db.RemoveKarmaRule(guildID, rule.ID))
+	rules, err = db.GetKarmaRules(guildID)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	now := time.Now().Truncate(time.Second)
+	tr := models.KarmaTempRole{GuildID: guildID, UserID: "u1", RoleID: "role", Expires: now.Add(time.Hour)}
+	require.NoError(t, db.SetKarmaTempRole(tr))
+
+	expired, err := db.GetExpiredKarmaTempRoles(now)
+	require.NoError(t, err)
+	assert.NotContains(t, tempRoleUsers(expired, guildID), "u1")
+
+	tr.Expires = now.Add(-time.Minute)
+	require.NoError(t, db.SetKarmaTempRole(tr))
+	expired, err = db.GetExpiredKarmaTempRoles(now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1"}, tempRoleUsers(expired, guildID))
+
+	require.NoError(t, db.RemoveKarmaTempRole(guildID, "u1", "role"))
+	expired, err = db.GetExpiredKarmaTempRoles(now)
+	require.NoError(t, err)
+	assert.Empty(t, tempRoleUsers(expired, guildID))
+}
+
+func tempRoleUsers(roles []models.KarmaTempRole, guildID string) (userIDs []string) {
+	for _, tr := range roles {
+		if tr.GuildID == guildID {
+			userIDs = append(userIDs, tr.UserID)
+		}
+	}
+	return
+}
+
+func testKarmaLedger(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	now := time.Now().Truncate(time.Second)
+	entries := []models.KarmaLedgerEntry{
+		{ID: node.Generate(), GuildID: guildID, ExecutorID: "u1", TargetID: "u2", Delta: 1,
+			Source: models.KarmaSourceReaction, Timestamp: now.Add(-10 * 24 * time.Hour)},
+		{ID: node.Generate(), GuildID: guildID, ExecutorID: "u1", TargetID: "u2", Delta: 1,
+			Source: models.KarmaSourceReaction, Timestamp: now.Add(-2 * time.Hour)},
+		{ID: node.Generate(), GuildID: guildID, ExecutorID: "u1", TargetID: "u3", Delta: -1,
+			Source: models.KarmaSourceReaction, Timestamp: now.Add(-1 * time.Hour)},
+		{ID: node.Generate(), GuildID: guildID, ExecutorID: "", TargetID: "u3", Delta: 3,
+			Source: models.KarmaSourceStarboard, Timestamp: now},
+	}
+	for _, e := range entries {
+		require.NoError(t, db.AddKarmaLedgerEntry(e))
+	}
+
+	res, err := db.GetKarmaLedger(guildID, "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, res, 4)
+	assert.Equal(t, entries[3].ID, res[0].ID)
+	assert.Equal(t, models.KarmaSourceStarboard, res[0].Source)
+	assert.True(t, now.Equal(res[0].Timestamp))
+
+	res, err = db.GetKarmaLedger(guildID, "u2", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	res, err = db.GetKarmaLedger(guildID, "u1", 1, 10)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, entries[1].ID, res[0].ID)
+
+	board, err := db.GetKarmaLeaderboard(guildID, now.Add(-7*24*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, board, 2)
+	assert.Equal(t, "u3", board[0].UserID)
+	assert.Equal(t, 2, board[0].Value)
+	assert.Equal(t, "u2", board[1].UserID)
+	assert.Equal(t, 1, board[1].Value)
+
+	given, err := db.GetKarmaLedgerGiven(guildID, "u1", now.Add(-3*time.Hour), now)
+	require.NoError(t, err)
+	require.Len(t, given, 2)
+	assert.Equal(t, entries[1].ID, given[0].ID)
+
+	revert := models.KarmaLedgerEntry{ID: node.Generate(), GuildID: guildID, ExecutorID: "mod", TargetID: "u2",
+		Delta: -1, Source: models.KarmaSourceManual, Timestamp: now}
+	require.NoError(t, db.AddKarmaLedgerEntry(revert))
+	require.NoError(t, db.SetKarmaLedgerReverted(entries[1].ID, revert.ID))
+
+	given, err = db.GetKarmaLedgerGiven(guildID, "u1", now.Add(-3*time.Hour), now)
+	require.NoError(t, err)
+	require.Len(t, given, 1)
+	assert.Equal(t, entries[2].ID, given[0].ID)
+
+	board, err = db.GetKarmaLeaderboard(guildID, now.Add(-7*24*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, board, 1)
+	assert.Equal(t, "u3", board[0].UserID)
+
+	res, err = db.GetKarmaLedger(guildID, "u2", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	assert.Equal(t, revert.ID, res[1].RevertedBy)
+
+	n, err := db.GetKarmaLedgerPairCount(guildID, "u1", "u2", now.Add(-30*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = db.GetKarmaLedgerPairCount(guildID, "u1", "u3", now.Add(-30*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.NoError(t, db.SetKarma("u2", guildID, 4))
+	require.NoError(t, db.SetKarma("u3", guildID, -3))
+	require.NoError(t, db.SetKarma("u4", guildID, 0))
+	require.NoError(t, db.SetKarma("u5", guildID, 2))
+	require.NoError(t, db.AddKarmaLedgerEntry(models.KarmaLedgerEntry{ID: node.Generate(), GuildID: guildID,
+		TargetID: "u5", Delta: -1, Source: models.KarmaSourceDecay, Timestamp: now}))
+
+	candidates, err := db.GetKarmaDecayCandidates(guildID, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, candidates, 3)
+	assert.ElementsMatch(t, []string{"u2", "u3", "u5"},
+		[]string{candidates[0].UserID, candidates[1].UserID, candidates[2].UserID})
+
+	candidates, err = db.GetKarmaDecayCandidates(guildID, now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "u5", candidates[0].UserID)
+	assert.Equal(t, 2, candidates[0].Value)
+}
+
+func testAntiraid(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	require.NoError(t, db.SetAntiraidState(guildID, true))
+	require.NoError(t, db.SetAntiraidRegeneration(guildID, 10))
+	require.NoError(t, db.SetAntiraidBurst(guildID, 5))
+	require.NoError(t, db.SetAntiraidVerification(guildID, true))
+	require.NoError(t, db.SetAntiraidState(guildID, false))
+
+	state, err := db.GetAntiraidState(guildID)
+	require.NoError(t, err)
+	assert.False(t, state)
+	limit, err := db.GetAntiraidRegeneration(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, 10, limit)
+	burst, err := db.GetAntiraidBurst(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, burst)
+	verification, err := db.GetAntiraidVerification(guildID)
+	require.NoError(t, err)
+	assert.True(t, verification)
+
+	created := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	require.NoError(t, db.AddToAntiraidJoinList(guildID, "u1", "u1#0001", created))
+	require.NoError(t, db.AddToAntiraidJoinList(guildID, "u2", "u2#0001", created))
+
+	list, err := db.GetAntiraidJoinList(guildID)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.True(t, created.Equal(list[0].Created))
+
+	require.NoError(t, db.RemoveAntiraidJoinList(guildID, "u1"))
+	list, err = db.GetAntiraidJoinList(guildID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	require.NoError(t, db.FlushAntiraidJoinList(guildID))
+	list, err = db.GetAntiraidJoinList(guildID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	require.NoError(t, db.SetLockChan("chan-"+guildID, guildID, "e", "perms"))
+	gid, eid, perms, err := db.GetLockChan("chan-" + guildID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{guildID, "e", "perms"}, []string{gid, eid, perms})
+	chans, err := db.GetLockChannels(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chan-" + guildID}, chans)
+	require.NoError(t, db.DeleteLockChan("chan-"+guildID))
+	_, _, _, err = db.GetLockChan("chan-" + guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+}
+
+func testStarboard(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	cfg := models.StarboardConfig{GuildID: guildID, Name: "main", ChannelID: "c", Threshold: 3, EmojiID: "⭐", KarmaGain: 2}
+	require.NoError(t, db.SetStarboardConfig(cfg))
+	cfg.Threshold = 5
+	cfg.Channels = []string{"c1", "c2"}
+	cfg.ExcludedChannels = []string{"c3"}
+	cfg.NSFW = models.StarboardNSFWRoute
+	cfg.NSFWChannelID = "nsfw"
+	cfg.MinAge = 3600
+	require.NoError(t, db.SetStarboardConfig(cfg))
+	gotCfg, err := db.GetStarboardConfig(guildID, "main")
+	require.NoError(t, err)
+	assert.Equal(t, cfg, gotCfg)
+
+	art := models.StarboardConfig{GuildID: guildID, Name: "art", ChannelID: "a", Threshold: 2, EmojiID: "🎨"}
+	require.NoError(t, db.SetStarboardConfig(art))
+	cfgs, err := db.GetStarboardConfigs(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.StarboardConfig{art, cfg}, cfgs)
+
+	require.NoError(t, db.RemoveStarboardConfig(guildID, "art"))
+	_, err = db.GetStarboardConfig(guildID, "art")
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	e1 := models.StarboardEntry{
+		MessageID: uid(), StarboardID: "1", Board: "main", GuildID: guildID, ChannelID: "c",
+		AuthorID: "a", Content: "hi", MediaURLs: []string{"https://a"}, Score: 3,
+	}
+	e2 := e1
+	e2.MessageID = uid()
+	e2.StarboardID = "2"
+	e2.Score = 1
+	require.NoError(t, db.SetStarboardEntry(e1))
+	require.NoError(t, db.SetStarboardEntry(e2))
+
+	e1.Score = 4
+	require.NoError(t, db.SetStarboardEntry(e1))
+	got, err := db.GetStarboardEntry(e1.MessageID, e1.Board)
+	require.NoError(t, err)
+	assert.Equal(t, e1, got)
+
+	// The same message can be posted to multiple boards.
+	e3 := e1
+	e3.StarboardID = "0"
+	e3.Board = "art"
+	e3.Score = 2
+	require.NoError(t, db.SetStarboardEntry(e3))
+	got, err = db.GetStarboardEntry(e1.MessageID, "art")
+	require.NoError(t, err)
+	assert.Equal(t, e3, got)
+	got, err = db.GetStarboardEntry(e1.MessageID, "main")
+	require.NoError(t, err)
+	assert.Equal(t, e1, got)
+
+	entries, err := db.GetStarboardEntries(guildID, models.StarboardSortByLatest, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, e2.MessageID, entries[0].MessageID)
+
+	entries, err = db.GetStarboardEntries(guildID, models.StarboardSortByMostRated, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, e1.MessageID, entries[0].MessageID)
+
+	n, err := db.GetStarboardEntriesCount(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	require.NoError(t, db.RemoveStarboardEntry(e1.MessageID))
+	_, err = db.GetStarboardEntry(e1.MessageID, "main")
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+	_, err = db.GetStarboardEntry(e1.MessageID, "art")
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+}
+
+func testGuildLog(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	now := time.Now().Truncate(time.Second)
+	entries := []models.GuildLogEntry{
+		{ID: node.Generate(), GuildID: guildID, Module: "a", Message: "m1", Severity: models.GLInfo, Timestamp: now.Add(-2 * time.Minute)},
+		{ID: node.Generate(), GuildID: guildID, Module: "a", Message: "m2", Severity: models.GLWarn, Timestamp: now.Add(-1 * time.Minute)},
+		{ID: node.Generate(), GuildID: guildID, Module: "b", Message: "m3", Severity: models.GLInfo, Timestamp: now},
+	}
+	for _, e := range entries {
+		require.NoError(t, db.AddGuildLogEntry(e))
+	}
+
+	res, err := db.GetGuildLogEntries(guildID, 0, 10, models.GLAll, false)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	assert.Equal(t, entries[2].ID, res[0].ID)
+
+	res, err = db.GetGuildLogEntries(guildID, 1, 1, models.GLInfo, true)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, entries[2].ID, res[0].ID)
+
+	n, err := db.GetGuildLogEntriesCount(guildID, models.GLAll)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = db.GetGuildLogEntriesCount(guildID, models.GLWarn)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.NoError(t, db.DeleteLogEntry(guildID, entries[0].ID))
+	n, err = db.GetGuildLogEntriesCount(guildID, models.GLAll)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	require.NoError(t, db.DeleteLogEntries(guildID))
+	n, err = db.GetGuildLogEntriesCount(guildID, models.GLAll)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func testVerificationQueue(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	now := time.Now().Truncate(time.Second)
+	e := models.VerificationQueueEntry{GuildID: guildID, UserID: "u", Timestamp: now}
+	require.NoError(t, db.AddVerificationQueue(e))
+	e.Timestamp = now.Add(time.Minute)
+	require.NoError(t, db.AddVerificationQueue(e))
+
+	res, err := db.GetVerificationQueue(guildID, "")
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.True(t, e.Timestamp.Equal(res[0].Timestamp))
+
+	ok, err := db.RemoveVerificationQueue(guildID, "u")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.RemoveVerificationQueue(guildID, "u")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testBirthdays(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	date := time.Date(2000, 4, 12, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, db.SetBirthday(models.Birthday{GuildID: guildID, UserID: "u", Date: date}))
+	require.NoError(t, db.SetBirthday(models.Birthday{GuildID: guildID, UserID: "u", Date: date, ShowYear: true}))
+
+	res, err := db.GetBirthdays(guildID)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.True(t, res[0].ShowYear)
+	assert.True(t, date.Equal(res[0].Date))
+	assert.True(t, res[0].LastNotified.IsZero())
+	assert.True(t, res[0].RoleExpires.IsZero())
+
+	notified := time.Now().Truncate(time.Second)
+	res[0].LastNotified = notified
+	res[0].RoleExpires = notified.Add(24 * time.Hour)
+	require.NoError(t, db.SetBirthday(res[0]))
+	res, err = db.GetBirthdays(guildID)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.True(t, notified.Equal(res[0].LastNotified))
+	assert.True(t, notified.Add(24*time.Hour).Equal(res[0].RoleExpires))
+
+	require.NoError(t, db.SetGuildBirthdayTemplate(guildID, "Happy birthday [ment]!"))
+	tmpl, err := db.GetGuildBirthdayTemplate(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, "Happy birthday [ment]!", tmpl)
+
+	require.NoError(t, db.SetGuildBirthdayRole(guildID, "role"))
+	roleID, err := db.GetGuildBirthdayRole(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, "role", roleID)
+
+	require.NoError(t, db.DeleteBirthday(guildID, "u"))
+	res, err = db.GetBirthdays(guildID)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func testRoleSelects(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	rs := []models.RoleSelect{
+		{GuildID: guildID, ChannelID: "c", MessageID: "m", RoleID: "r1"},
+		{GuildID: guildID, ChannelID: "c", MessageID: "m", RoleID: "r2"},
+	}
+	require.NoError(t, db.AddRoleSelects(rs))
+	require.NoError(t, db.AddRoleSelects(rs[:1]))
+
+	res, err := db.GetRoleSelects()
+	require.NoError(t, err)
+	assert.Len(t, filterRoleSelects(res, guildID), 2)
+
+	group := models.RoleSelectGroup{
+		GuildID:       guildID,
+		ChannelID:     "c",
+		MessageID:     "m",
+		Mode:          models.RoleSelectModeLimited,
+		MaxRoles:      2,
+		RequiredRoles: []string{"p1", "p2"},
+		Menu:          true,
+		Roles: []models.RoleSelectOption{
+			{RoleID: "r3", Label: "Three", Emoji: "<:three:3>"},
+			{RoleID: "r1", Label: "One"},
+		},
+	}
+	require.NoError(t, db.SetRoleSelects(guildID, "c", "m", group.RoleSelects()))
+
+	res, err = db.GetGuildRoleSelects(guildID)
+	require.NoError(t, err)
+	groups := models.GroupRoleSelects(res)
+	require.Len(t, groups, 1)
+	assert.Equal(t, group, groups[0])
+
+	require.NoError(t, db.RemoveRoleSelect(guildID, "c", "m"))
+	res, err = db.GetRoleSelects()
+	require.NoError(t, err)
+	assert.Empty(t, filterRoleSelects(res, guildID))
+}
+
+func filterRoleSelects(rs []models.RoleSelect, guildID string) (res []models.RoleSelect) {
+	for _, r := range rs {
+		if r.GuildID == guildID {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+func testFlushGuildData(t *testing.T, db database.Database) {
+	guildID := uid()
+
+	require.NoError(t, db.SetGuildPrefix(guildID, "!"))
+	require.NoError(t, db.UpdateKarma("u", guildID, 1))
+	require.NoError(t, db.AddGuildLogEntry(models.GuildLogEntry{
+		ID: node.Generate(), GuildID: guildID, Timestamp: time.Now()}))
+
+	guildIDs, err := db.GetAllGuildIDs()
+	require.NoError(t, err)
+	assert.Contains(t, guildIDs, guildID)
+
+	require.NoError(t, db.FlushGuildData(guildID))
+
+	_, err = db.GetGuildPrefix(guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+	_, err = db.GetKarma("u", guildID)
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+	n, err := db.GetGuildLogEntriesCount(guildID, models.GLAll)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
@@ -0,0 +1,96 @@
+// Package dbtransfer provides functionalities to stream all
+// entities stored in a database.Database into another database
+// driver or into a driver independent JSON lines dump.
+package dbtransfer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind identifies the type of an entity which is
+// transferred between databases.
+type Kind string
+
+const (
+	KindGuildSettings  Kind = "guildsettings"
+	KindReport         Kind = "report"
+	KindReportRevision Kind = "reportrevision"
+	KindUnbanRequest   Kind = "unbanrequest"
+	KindTag            Kind = "tag"
+	KindKarma          Kind = "karma"
+	KindKarmaLedger    Kind = "karmaledger"
+	KindStarboardEntry Kind = "starboardentry"
+	KindGuildLog       Kind = "guildlog"
+	KindBirthday       Kind = "birthday"
+	KindTwitchNotify   Kind = "twitchnotify"
+	KindVote           Kind = "vote"
+	KindRoleSelect     Kind = "roleselect"
+)
+
+// Kinds contains all transferred entity kinds in the
+// order they are exported and imported. Entities
+// referencing other entities (like unban requests
+// referencing reports) must be ordered after them.
+var Kinds = []Kind{
+	KindGuildSettings,
+	KindReport,
+	KindReportRevision,
+	KindUnbanRequest,
+	KindTag,
+	KindKarma,
+	KindKarmaLedger,
+	KindStarboardEntry,
+	KindGuildLog,
+	KindBirthday,
+	KindTwitchNotify,
+	KindVote,
+	KindRoleSelect,
+}
+
+// DefaultBatchSize is the amount of entities requested
+// at once from paginated database queries when no batch
+// size has been specified.
+const DefaultBatchSize = 500
+
+// Counts maps entity kinds to the number of
+// transferred or stored records.
+type Counts map[Kind]int
+
+// Total returns the sum of all counts.
+func (c Counts) Total() (n int) {
+	for _, v := range c {
+		n += v
+	}
+	return n
+}
+
+// String returns a human readable, sorted list
+// of all counts.
+func (c Counts) String() string {
+	kinds := make([]string, 0, len(c))
+	for k := range c {
+		kinds = append(kinds, string(k))
+	}
+	sort.Strings(kinds)
+
+	var sb strings.Builder
+	for _, k := range kinds {
+		fmt.Fprintf(&sb, "%-16s %d\n", k, c[Kind(k)])
+	}
+	return sb.String()
+}
+
+// MismatchError is returned by Verify when the record
+// counts of two databases differ.
+type MismatchError struct {
+	Kind     Kind
+	Expected int
+	Actual   int
+}
+
+func (e MismatchError) Error() string {
+	return fmt.Sprintf("record count mismatch for %s: expected %d, got %d",
+		e.Kind, e.Expected, e.Actual)
+}
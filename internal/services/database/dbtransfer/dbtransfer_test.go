@@ -0,0 +1,241 @@
+package dbtransfer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/tag"
+	"github.com/zekroTJA/shinpuru/internal/util/testutil"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+	"github.com/zekroTJA/shinpuru/pkg/twitchnotify"
+)
+
+func seed(t *testing.T, db database.Database) {
+	t.Helper()
+
+	const guildID = "guild-1"
+	now := time.Now().Truncate(time.Second).UTC()
+
+	require.NoError(t, db.SetGuildPrefix(guildID, "!"))
+	require.NoError(t, db.SetGuildModLog(guildID, "modlog-chan"))
+	require.NoError(t, db.SetGuildJoinMsg(guildID, "join-chan", "hello [user]"))
+	require.NoError(t, db.SetKarmaState(guildID, false))
+	require.NoError(t, db.SetKarmaEmotes(guildID, "👍", "👎"))
+	require.NoError(t, db.SetKarmaTokens(guildID, 3))
+	require.NoError(t, db.SetKarmaPenalty(guildID, true))
+	require.NoError(t, db.SetKarmaDecay(guildID, models.KarmaDecay{After: 604800, Amount: 1}))
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.AddReport(models.Report{
+			ID:         snowflake.ID(1000 + i),
+			GuildID:    guildID,
+			ExecutorID: "executor",
+			VictimID:   "victim",
+			Msg:        "report",
+		}))
+		require.NoError(t, db.AddReportRevision(models.ReportRevision{
+			ReportID:  snowflake.ID(1000 + i),
+			Version:   1,
+			GuildID:   guildID,
+			EditorID:  "executor",
+			Timestamp: now,
+			Msg:       "report",
+		}))
+		require.NoError(t, db.AddGuildLogEntry(models.GuildLogEntry{
+			ID:        snowflake.ID(2000 + i),
+			GuildID:   guildID,
+			Module:    "test",
+			Message:   "entry",
+			Timestamp: now,
+		}))
+		require.NoError(t, db.SetKarma(string(rune('a'+i)), guildID, i+1))
+		require.NoError(t, db.AddKarmaLedgerEntry(models.KarmaLedgerEntry{
+			ID:         snowflake.ID(5000 + i),
+			GuildID:    guildID,
+			ExecutorID: "executor",
+			TargetID:   string(rune('a' + i)),
+			Delta:      1,
+			Source:     models.KarmaSourceReaction,
+			Timestamp:  now,
+		}))
+	}
+
+	timeout := now.Add(time.Hour)
+	require.NoError(t, db.AddReportRevision(models.ReportRevision{
+		ReportID:  1000,
+		Version:   2,
+		GuildID:   guildID,
+		EditorID:  "editor",
+		Timestamp: now,
+		Msg:       "amended report",
+		Timeout:   &timeout,
+	}))
+
+	require.NoError(t, db.AddUnbanRequest(models.UnbanRequest{
+		ID:       3000,
+		GuildID:  guildID,
+		UserID:   "victim",
+		Message:  "please",
+		ReportID: 1000,
+	}))
+	require.NoError(t, db.AddTag(tag.Tag{
+		ID:        4000,
+		Ident:     "hello",
+		GuildID:   guildID,
+		CreatorID: "creator",
+		Content:   "world",
+		Created:   now,
+		LastEdit:  now,
+	}))
+	require.NoError(t, db.SetStarboardEntry(models.StarboardEntry{
+		MessageID:   "msg",
+		StarboardID: "sbmsg",
+		Board:       "default",
+		GuildID:     guildID,
+		ChannelID:   "chan",
+		AuthorID:    "author",
+		Score:       4,
+		Deleted:     true,
+	}))
+	require.NoError(t, db.SetBirthday(models.Birthday{
+		GuildID: guildID,
+		UserID:  "user",
+		Date:    now,
+	}))
+	require.NoError(t, db.SetTwitchNotify(twitchnotify.DBEntry{
+		GuildID:      guildID,
+		ChannelID:    "chan",
+		TwitchUserID: "twitch",
+	}))
+	require.NoError(t, db.AddUpdateVote(vote.Vote{
+		ID:            "vote",
+		GuildID:       guildID,
+		Possibilities: []string{"a", "b"},
+		Ticks:         map[string]*vote.Tick{"user": {UserID: "user", Tick: 1}},
+	}))
+	require.NoError(t, db.AddRoleSelects([]models.RoleSelect{
+		{GuildID: guildID, ChannelID: "chan", MessageID: "msg", RoleID: "role"},
+	}))
+}
+
+func expectedCounts() Counts {
+	return Counts{
+		KindGuildSettings:  1,
+		KindReport:         5,
+		KindReportRevision: 6,
+		KindUnbanRequest:   1,
+		KindTag:            1,
+		KindKarma:          5,
+		KindKarmaLedger:    5,
+		KindStarboardEntry: 1,
+		KindGuildLog:       5,
+		KindBirthday:       1,
+		KindTwitchNotify:   1,
+		KindVote:           1,
+		KindRoleSelect:     1,
+	}
+}
+
+func assertTransferred(t *testing.T, db database.Database) {
+	t.Helper()
+
+	prefix, err := db.GetGuildPrefix("guild-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "!", prefix)
+
+	chanID, msg, err := db.GetGuildJoinMsg("guild-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "join-chan", chanID)
+	assert.Equal(t, "hello [user]", msg)
+
+	state, err := db.GetKarmaState("guild-1")
+	assert.Nil(t, err)
+	assert.False(t, state)
+
+	decay, err := db.GetKarmaDecay("guild-1")
+	assert.Nil(t, err)
+	assert.Equal(t, models.KarmaDecay{After: 604800, Amount: 1}, decay)
+
+	entry, err := db.GetStarboardEntry("msg", "default")
+	assert.Nil(t, err)
+	assert.True(t, entry.Deleted)
+	assert.Equal(t, 4, entry.Score)
+
+	req, err := db.GetUnbanRequest("3000")
+	assert.Nil(t, err)
+	assert.Equal(t, snowflake.ID(1000), req.ReportID)
+	revs, err := db.GetReportRevisions(1000)
+	assert.Nil(t, err)
+	if assert.Len(t, revs, 2) {
+		assert.Equal(t, "report", revs[0].Msg)
+		assert.Equal(t, "amended report", revs[1].Msg)
+		assert.Equal(t, "editor", revs[1].EditorID)
+		assert.NotNil(t, revs[1].Timeout)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	src := testutil.NewTestDatabase(t)
+	dst := testutil.NewTestDatabase(t)
+	seed(t, src)
+
+	// A small batch size makes sure that
+	// pagination is covered as well.
+	counts, err := Transfer(src, dst, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedCounts(), counts)
+
+	assertTransferred(t, dst)
+}
+
+func TestDump(t *testing.T) {
+	src := testutil.NewTestDatabase(t)
+	dst := testutil.NewTestDatabase(t)
+	seed(t, src)
+
+	var buf bytes.Buffer
+	dw, err := NewDumpWriter(&buf)
+	require.NoError(t, err)
+
+	counts, err := Export(src, 0, dw)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedCounts(), counts)
+	assert.Nil(t, dw.Flush())
+
+	read, err := ReadDump(&buf, NewDatabaseSink(dst))
+	assert.Nil(t, err)
+	assert.Equal(t, counts, read)
+
+	actual, err := Count(dst, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, Verify(counts, actual))
+
+	assertTransferred(t, dst)
+}
+
+func TestReadDumpInvalid(t *testing.T) {
+	_, err := ReadDump(bytes.NewBufferString(""), Discard)
+	assert.NotNil(t, err)
+
+	_, err = ReadDump(bytes.NewBufferString(`{"format":"other","version":1}`), Discard)
+	assert.NotNil(t, err)
+
+	_, err = ReadDump(bytes.NewBufferString(`{"format":"shinpuru-dump","version":99}`), Discard)
+	assert.NotNil(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	assert.Nil(t, Verify(expectedCounts(), expectedCounts()))
+
+	actual := expectedCounts()
+	actual[KindTag] = 0
+	assert.Equal(t,
+		MismatchError{Kind: KindTag, Expected: 1, Actual: 0},
+		Verify(expectedCounts(), actual))
+}
@@ -0,0 +1,201 @@
+package dbtransfer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/util/tag"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+	"github.com/zekroTJA/shinpuru/pkg/twitchnotify"
+)
+
+const (
+	// DumpFormat is the format identifier written
+	// into the header of each dump.
+	DumpFormat = "shinpuru-dump"
+	// DumpVersion is the current version of the
+	// dump format.
+	DumpVersion = 1
+)
+
+// DumpHeader is written as first line of each dump.
+type DumpHeader struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+type dumpRecord struct {
+	Kind Kind            `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// starboardEntryRecord preserves the deleted flag of
+// starboard entries, which is omitted in the models
+// JSON representation.
+type starboardEntryRecord struct {
+	models.StarboardEntry
+	Deleted bool `json:"deleted"`
+}
+
+// DumpWriter is a Sink which writes entities as
+// JSON lines into an io.Writer.
+type DumpWriter struct {
+	w *bufio.Writer
+}
+
+var _ Sink = (*DumpWriter)(nil)
+
+// NewDumpWriter returns a new DumpWriter writing
+// into w after writing the dump header.
+func NewDumpWriter(w io.Writer) (*DumpWriter, error) {
+	dw := &DumpWriter{w: bufio.NewWriter(w)}
+
+	err := dw.writeLine(DumpHeader{
+		Format:  DumpFormat,
+		Version: DumpVersion,
+		Created: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dw, nil
+}
+
+func (dw *DumpWriter) Put(kind Kind, v any) error {
+	if e, ok := v.(models.StarboardEntry); ok {
+		v = starboardEntryRecord{StarboardEntry: e, Deleted: e.Deleted}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return dw.writeLine(dumpRecord{Kind: kind, Data: data})
+}
+
+// Flush writes all buffered data into the
+// underlying io.Writer.
+func (dw *DumpWriter) Flush() error {
+	return dw.w.Flush()
+}
+
+func (dw *DumpWriter) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err = dw.w.Write(data); err != nil {
+		return err
+	}
+	return dw.w.WriteByte('\n')
+}
+
+// ReadDump reads a dump written by DumpWriter from
+// r and passes all decoded entities to the given
+// sink.
+//
+// Returned are the numbers of read entities
+// per kind.
+func ReadDump(r io.Reader, sink Sink) (Counts, error) {
+	sc := bufio.NewScanner(r)
+	// Entities like votes or guild settings can exceed
+	// the default token size of 64 KiB.
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("dump is empty")
+	}
+
+	var header DumpHeader
+	if err := json.Unmarshal(sc.Bytes(), &header); err != nil {
+		return nil, fmt.Errorf("invalid dump header: %w", err)
+	}
+	if header.Format != DumpFormat {
+		return nil, fmt.Errorf("invalid dump format: %s", header.Format)
+	}
+	if header.Version < 1 || header.Version > DumpVersion {
+		return nil, fmt.Errorf("unsupported dump version: %d", header.Version)
+	}
+
+	counts := Counts{}
+	for _, kind := range Kinds {
+		counts[kind] = 0
+	}
+
+	for line := 2; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var rec dumpRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return counts, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		v, err := decodeRecord(rec)
+		if err != nil {
+			return counts, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if err = sink.Put(rec.Kind, v); err != nil {
+			return counts, fmt.Errorf("line %d: failed putting %s: %w", line, rec.Kind, err)
+		}
+		counts[rec.Kind]++
+	}
+
+	return counts, sc.Err()
+}
+
+func decodeRecord(rec dumpRecord) (v any, err error) {
+	switch rec.Kind {
+	case KindGuildSettings:
+		v, err = unmarshal[GuildSettings](rec.Data)
+	case KindReport:
+		v, err = unmarshal[models.Report](rec.Data)
+	case KindReportRevision:
+		v, err = unmarshal[models.ReportRevision](rec.Data)
+	case KindUnbanRequest:
+		v, err = unmarshal[models.UnbanRequest](rec.Data)
+	case KindTag:
+		v, err = unmarshal[tag.Tag](rec.Data)
+	case KindKarma:
+		v, err = unmarshal[models.GuildKarma](rec.Data)
+	case KindKarmaLedger:
+		v, err = unmarshal[models.KarmaLedgerEntry](rec.Data)
+	case KindStarboardEntry:
+		var r starboardEntryRecord
+		if r, err = unmarshal[starboardEntryRecord](rec.Data); err == nil {
+			r.StarboardEntry.Deleted = r.Deleted
+			v = r.StarboardEntry
+		}
+	case KindGuildLog:
+		v, err = unmarshal[models.GuildLogEntry](rec.Data)
+	case KindBirthday:
+		v, err = unmarshal[models.Birthday](rec.Data)
+	case KindTwitchNotify:
+		v, err = unmarshal[twitchnotify.DBEntry](rec.Data)
+	case KindVote:
+		v, err = unmarshal[vote.Vote](rec.Data)
+	case KindRoleSelect:
+		v, err = unmarshal[models.RoleSelect](rec.Data)
+	default:
+		err = fmt.Errorf("unsupported kind: %s", rec.Kind)
+	}
+	return
+}
+
+func unmarshal[T any](data []byte) (v T, err error) {
+	err = json.Unmarshal(data, &v)
+	return
+}
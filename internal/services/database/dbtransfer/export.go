@@ -0,0 +1,410 @@
+package dbtransfer
+
+import (
+	"fmt"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/tag"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+	"github.com/zekroTJA/shinpuru/pkg/twitchnotify"
+)
+
+// Sink receives entities exported from a database.
+type Sink interface {
+	// Put stores the given entity v of the given kind.
+	Put(kind Kind, v any) error
+}
+
+// SinkFunc wraps a function as Sink.
+type SinkFunc func(kind Kind, v any) error
+
+func (f SinkFunc) Put(kind Kind, v any) error {
+	return f(kind, v)
+}
+
+// Discard is a Sink which drops all entities.
+var Discard Sink = SinkFunc(func(Kind, any) error { return nil })
+
+// Export reads all entities from the given database
+// in the order specified by Kinds and passes them to
+// the given sink. Paginated entities are requested in
+// batches of batchSize. If batchSize is <= 0,
+// DefaultBatchSize is used.
+//
+// Returned are the numbers of exported entities
+// per kind.
+func Export(db database.Database, batchSize int, sink Sink) (Counts, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	guildIDs, err := db.GetAllGuildIDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed getting guild IDs: %w", err)
+	}
+
+	e := exporter{
+		db:        db,
+		batchSize: batchSize,
+		guildIDs:  guildIDs,
+		counts:    Counts{},
+	}
+	e.sink = SinkFunc(func(kind Kind, v any) error {
+		if err := sink.Put(kind, v); err != nil {
+			return fmt.Errorf("failed putting %s: %w", kind, err)
+		}
+		e.counts[kind]++
+		return nil
+	})
+
+	for _, kind := range Kinds {
+		e.counts[kind] = 0
+		if err = e.export(kind); err != nil {
+			return e.counts, fmt.Errorf("failed exporting %s: %w", kind, err)
+		}
+	}
+
+	return e.counts, nil
+}
+
+// Count returns the number of stored entities per kind
+// in the given database as they would be exported by
+// Export.
+func Count(db database.Database, batchSize int) (Counts, error) {
+	return Export(db, batchSize, Discard)
+}
+
+// Verify compares the expected counts with the actual
+// counts and returns a MismatchError for the first
+// kind which differs.
+func Verify(expected, actual Counts) error {
+	for _, kind := range Kinds {
+		if expected[kind] != actual[kind] {
+			return MismatchError{
+				Kind:     kind,
+				Expected: expected[kind],
+				Actual:   actual[kind],
+			}
+		}
+	}
+	return nil
+}
+
+// Transfer exports all entities from src and imports
+// them into dst. After that, the entity counts of dst
+// are compared against the exported counts.
+func Transfer(src, dst database.Database, batchSize int) (Counts, error) {
+	counts, err := Export(src, batchSize, NewDatabaseSink(dst))
+	if err != nil {
+		return counts, err
+	}
+
+	actual, err := Count(dst, batchSize)
+	if err != nil {
+		return counts, fmt.Errorf("failed counting target records: %w", err)
+	}
+
+	return counts, Verify(counts, actual)
+}
+
+type exporter struct {
+	db        database.Database
+	batchSize int
+	guildIDs  []string
+	sink      Sink
+	counts    Counts
+}
+
+func (e *exporter) export(kind Kind) error {
+	switch kind {
+	case KindGuildSettings:
+		return e.perGuild(e.exportGuildSettings)
+	case KindReport:
+		return e.perGuild(e.exportReports)
+	case KindReportRevision:
+		return e.perGuild(e.exportReportRevisions)
+	case KindUnbanRequest:
+		return e.perGuild(e.exportUnbanRequests)
+	case KindTag:
+		return e.perGuild(e.exportTags)
+	case KindKarma:
+		return e.perGuild(e.exportKarma)
+	case KindKarmaLedger:
+		return e.perGuild(e.exportKarmaLedger)
+	case KindStarboardEntry:
+		return e.perGuild(e.exportStarboardEntries)
+	case KindGuildLog:
+		return e.perGuild(e.exportGuildLog)
+	case KindBirthday:
+		return e.exportBirthdays()
+	case KindTwitchNotify:
+		return e.exportTwitchNotifies()
+	case KindVote:
+		return e.exportVotes()
+	case KindRoleSelect:
+		return e.exportRoleSelects()
+	default:
+		return fmt.Errorf("unsupported kind: %s", kind)
+	}
+}
+
+func (e *exporter) perGuild(f func(guildID string) error) error {
+	for _, guildID := range e.guildIDs {
+		if err := f(guildID); err != nil {
+			return fmt.Errorf("guild %s: %w", guildID, err)
+		}
+	}
+	return nil
+}
+
+func (e *exporter) exportGuildSettings(guildID string) error {
+	gs, err := readGuildSettings(e.db, guildID)
+	if err != nil {
+		return err
+	}
+	if gs.IsEmpty() {
+		return nil
+	}
+	return e.sink.Put(KindGuildSettings, gs)
+}
+
+func (e *exporter) exportReports(guildID string) error {
+	return paginate(e.batchSize, func(offset, limit int) (int, error) {
+		reps, err := ignoreNotFound(e.db.GetReportsGuild(guildID, offset, limit))
+		if err != nil {
+			return 0, err
+		}
+		for _, rep := range reps {
+			if err = e.sink.Put(KindReport, rep); err != nil {
+				return 0, err
+			}
+		}
+		return len(reps), nil
+	})
+}
+
+func (e *exporter) exportReportRevisions(guildID string) error {
+	return paginate(e.batchSize, func(offset, limit int) (int, error) {
+		reps, err := ignoreNotFound(e.db.GetReportsGuild(guildID, offset, limit))
+		if err != nil {
+			return 0, err
+		}
+		for _, rep := range reps {
+			revs, err := ignoreNotFound(e.db.GetReportRevisions(rep.ID))
+			if err != nil {
+				return 0, err
+			}
+			for _, rev := range revs {
+				if err = e.sink.Put(KindReportRevision, rev); err != nil {
+					return 0, err
+				}
+			}
+		}
+		return len(reps), nil
+	})
+}
+
+func (e *exporter) exportUnbanRequests(guildID string) error {
+	return paginate(e.batchSize, func(offset, limit int) (int, error) {
+		reqs, err := ignoreNotFound(e.db.GetGuildUnbanRequests(guildID, limit, offset))
+		if err != nil {
+			return 0, err
+		}
+		for _, req := range reqs {
+			if err = e.sink.Put(KindUnbanRequest, req); err != nil {
+				return 0, err
+			}
+		}
+		return len(reqs), nil
+	})
+}
+
+func (e *exporter) exportTags(guildID string) error {
+	tags, err := ignoreNotFound(e.db.GetGuildTags(guildID))
+	if err != nil {
+		return err
+	}
+	for _, t := range tags {
+		if err = e.sink.Put(KindTag, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) exportKarma(guildID string) error {
+	n, err := ignoreNotFound(e.db.GetKarmaGuildCount(guildID))
+	if err != nil || n == 0 {
+		return err
+	}
+	karma, err := ignoreNotFound(e.db.GetKarmaGuild(guildID, n))
+	if err != nil {
+		return err
+	}
+	for _, k := range karma {
+		if err = e.sink.Put(KindKarma, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) exportKarmaLedger(guildID string) error {
+	return paginate(e.batchSize, func(offset, limit int) (int, error) {
+		entries, err := ignoreNotFound(e.db.GetKarmaLedger(guildID, "", offset, limit))
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			if err = e.sink.Put(KindKarmaLedger, entry); err != nil {
+				return 0, err
+			}
+		}
+		return len(entries), nil
+	})
+}
+
+func (e *exporter) exportStarboardEntries(guildID string) error {
+	return paginate(e.batchSize, func(offset, limit int) (int, error) {
+		entries, err := ignoreNotFound(e.db.GetStarboardEntries(
+			guildID, models.StarboardSortByLatest, limit, offset))
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			if err = e.sink.Put(KindStarboardEntry, entry); err != nil {
+				return 0, err
+			}
+		}
+		return len(entries), nil
+	})
+}
+
+func (e *exporter) exportGuildLog(guildID string) error {
+	return paginate(e.batchSize, func(offset, limit int) (int, error) {
+		entries, err := ignoreNotFound(e.db.GetGuildLogEntries(
+			guildID, offset, limit, models.GLAll, true))
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			if err = e.sink.Put(KindGuildLog, entry); err != nil {
+				return 0, err
+			}
+		}
+		return len(entries), nil
+	})
+}
+
+func (e *exporter) exportBirthdays() error {
+	bds, err := ignoreNotFound(e.db.GetBirthdays(""))
+	if err != nil {
+		return err
+	}
+	for _, bd := range bds {
+		if err = e.sink.Put(KindBirthday, bd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) exportTwitchNotifies() error {
+	nts, err := ignoreNotFound(e.db.GetAllTwitchNotifies(""))
+	if err != nil {
+		return err
+	}
+	for _, nt := range nts {
+		if err = e.sink.Put(KindTwitchNotify, nt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) exportVotes() error {
+	votes, err := ignoreNotFound(e.db.GetVotes())
+	if err != nil {
+		return err
+	}
+	for _, v := range votes {
+		if err = e.sink.Put(KindVote, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) exportRoleSelects() error {
+	rss, err := ignoreNotFound(e.db.GetRoleSelects())
+	if err != nil {
+		return err
+	}
+	for _, rs := range rss {
+		if err = e.sink.Put(KindRoleSelect, rs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paginate calls f with increasing offsets until
+// f returns less than limit elements.
+func paginate(limit int, f func(offset, limit int) (int, error)) error {
+	for offset := 0; ; offset += limit {
+		n, err := f(offset, limit)
+		if err != nil {
+			return err
+		}
+		if n < limit {
+			return nil
+		}
+	}
+}
+
+// DatabaseSink imports entities into a database.
+type DatabaseSink struct {
+	db database.Database
+}
+
+var _ Sink = (*DatabaseSink)(nil)
+
+// NewDatabaseSink returns a new DatabaseSink
+// writing into the given database.
+func NewDatabaseSink(db database.Database) *DatabaseSink {
+	return &DatabaseSink{db: db}
+}
+
+func (s *DatabaseSink) Put(kind Kind, v any) error {
+	switch e := v.(type) {
+	case GuildSettings:
+		return writeGuildSettings(s.db, e)
+	case models.Report:
+		return s.db.AddReport(e)
+	case models.ReportRevision:
+		return s.db.AddReportRevision(e)
+	case models.UnbanRequest:
+		return s.db.AddUnbanRequest(e)
+	case tag.Tag:
+		return s.db.AddTag(e)
+	case models.GuildKarma:
+		return s.db.SetKarma(e.UserID, e.GuildID, e.Value)
+	case models.KarmaLedgerEntry:
+		return s.db.AddKarmaLedgerEntry(e)
+	case models.StarboardEntry:
+		return s.db.SetStarboardEntry(e)
+	case models.GuildLogEntry:
+		return s.db.AddGuildLogEntry(e)
+	case models.Birthday:
+		return s.db.SetBirthday(e)
+	case twitchnotify.DBEntry:
+		return s.db.SetTwitchNotify(e)
+	case vote.Vote:
+		return s.db.AddUpdateVote(e)
+	case models.RoleSelect:
+		return s.db.AddRoleSelects([]models.RoleSelect{e})
+	default:
+		return fmt.Errorf("unsupported entity type %T for kind %s", v, kind)
+	}
+}
@@ -0,0 +1,465 @@
+package dbtransfer
+
+import (
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/pkg/permissions"
+)
+
+// GuildSettings bundles all settings of a single guild.
+// Settings which are not set are nil or empty and are
+// omitted on import.
+type GuildSettings struct {
+	GuildID string `json:"guildid"`
+
+	Prefix               *string                                `json:"prefix,omitempty"`
+	AutoRoles            []string                               `json:"autoroles,omitempty"`
+	AutoVCs              []string                               `json:"autovcs,omitempty"`
+	ModLog               *string                                `json:"modlog,omitempty"`
+	ModNot               *string                                `json:"modnot,omitempty"`
+	VoiceLog             *string                                `json:"voicelog,omitempty"`
+	VoiceLogIgnores      []string                               `json:"voicelogignores,omitempty"`
+	NotifyRole           *string                                `json:"notifyrole,omitempty"`
+	GhostPingMsg         *string                                `json:"ghostpingmsg,omitempty"`
+	Permissions          map[string]permissions.PermissionArray `json:"permissions,omitempty"`
+	JdoodleKey           *string                                `json:"jdoodlekey,omitempty"`
+	CodeExecEnabled      *bool                                  `json:"codeexecenabled,omitempty"`
+	Backup               *bool                                  `json:"backup,omitempty"`
+	BackupRetention      *backupmodels.Retention                `json:"backupretention,omitempty"`
+	EscalationLadder     models.EscalationLadder                `json:"escalationladder,omitempty"`
+	InviteBlock          *string                                `json:"inviteblock,omitempty"`
+	InviteBlockSettings  *models.InviteBlockSettings            `json:"inviteblocksettings,omitempty"`
+	AutomodRules         []models.AutomodRule                   `json:"automodrules,omitempty"`
+	JoinMsg              *ChannelMessage                        `json:"joinmsg,omitempty"`
+	LeaveMsg             *ChannelMessage                        `json:"leavemsg,omitempty"`
+	ColorReaction        *bool                                  `json:"colorreaction,omitempty"`
+	LogDisable           *bool                                  `json:"logdisable,omitempty"`
+	VerificationRequired *bool                                  `json:"verificationrequired,omitempty"`
+	BirthdayChan         *string                                `json:"birthdaychan,omitempty"`
+	BirthdayTemplate     *string                                `json:"birthdaytemplate,omitempty"`
+	BirthdayRole         *string                                `json:"birthdayrole,omitempty"`
+	API                  *models.GuildAPISettings               `json:"api,omitempty"`
+	LockedChannels       []LockedChannel                        `json:"lockedchannels,omitempty"`
+	Karma                *KarmaSettings                         `json:"karma,omitempty"`
+	KarmaBlockList       []string                               `json:"karmablocklist,omitempty"`
+	KarmaRules           []models.KarmaRule                     `json:"karmarules,omitempty"`
+	Antiraid             *AntiraidSettings                      `json:"antiraid,omitempty"`
+	AntiraidSignals      *models.AntiraidSignals                `json:"antiraidsignals,omitempty"`
+	AntiraidLockdown     *models.AntiraidLockdown               `json:"antiraidlockdown,omitempty"`
+	Starboards           []models.StarboardConfig               `json:"starboards,omitempty"`
+}
+
+// ChannelMessage holds a message which is sent
+// into a specific channel.
+type ChannelMessage struct {
+	ChannelID string `json:"channelid"`
+	Message   string `json:"message"`
+}
+
+// LockedChannel holds the state of a channel locked
+// via the chanlock command.
+type LockedChannel struct {
+	ChannelID   string `json:"channelid"`
+	ExecutorID  string `json:"executorid"`
+	Permissions string `json:"permissions"`
+}
+
+// KarmaSettings holds the karma configuration
+// of a guild.
+type KarmaSettings struct {
+	State     bool   `json:"state"`
+	EmotesInc string `json:"emotesinc"`
+	EmotesDec string `json:"emotesdec"`
+	Tokens    int    `json:"tokens"`
+	Penalty   bool   `json:"penalty"`
+
+	Decay   models.KarmaDecay         `json:"decay"`
+	Farming models.KarmaFarmingLimits `json:"farming"`
+}
+
+// AntiraidSettings holds the antiraid configuration
+// of a guild.
+type AntiraidSettings struct {
+	State        bool `json:"state"`
+	Regeneration int  `json:"regeneration"`
+	Burst        int  `json:"burst"`
+	Verification bool `json:"verification"`
+}
+
+// IsEmpty returns true when no setting is set.
+func (gs *GuildSettings) IsEmpty() bool {
+	return gs.Prefix == nil && len(gs.AutoRoles) == 0 && len(gs.AutoVCs) == 0 &&
+		gs.ModLog == nil && gs.ModNot == nil && gs.VoiceLog == nil &&
+		len(gs.VoiceLogIgnores) == 0 && gs.NotifyRole == nil && gs.GhostPingMsg == nil &&
+		len(gs.Permissions) == 0 && gs.JdoodleKey == nil && gs.CodeExecEnabled == nil &&
+		gs.Backup == nil && gs.BackupRetention == nil && len(gs.EscalationLadder) == 0 &&
+		gs.InviteBlock == nil && gs.InviteBlockSettings == nil && len(gs.AutomodRules) == 0 &&
+		gs.JoinMsg == nil &&
+		gs.LeaveMsg == nil && gs.ColorReaction == nil && gs.LogDisable == nil &&
+		gs.VerificationRequired == nil && gs.BirthdayChan == nil &&
+		gs.BirthdayTemplate == nil && gs.BirthdayRole == nil && gs.API == nil &&
+		len(gs.LockedChannels) == 0 && gs.Karma == nil && len(gs.KarmaBlockList) == 0 &&
+		len(gs.KarmaRules) == 0 && gs.Antiraid == nil && gs.AntiraidSignals == nil &&
+		gs.AntiraidLockdown == nil && len(gs.Starboards) == 0
+}
+
+// nonZero returns a pointer to v if err is nil and v is not
+// the zero value of T. ErrDatabaseNotFound errors are
+// swallowed.
+func nonZero[T comparable](v T, err error) (*T, error) {
+	var zero T
+	if database.IsErrDatabaseNotFound(err) || (err == nil && v == zero) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// found returns a pointer to v if err is nil. ErrDatabaseNotFound
+// errors are swallowed.
+func found[T any](v T, err error) (*T, error) {
+	if database.IsErrDatabaseNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ignoreNotFound returns v and nil if err is
+// ErrDatabaseNotFound.
+func ignoreNotFound[T any](v T, err error) (T, error) {
+	if database.IsErrDatabaseNotFound(err) {
+		err = nil
+	}
+	return v, err
+}
+
+func readGuildSettings(db database.Database, guildID string) (gs GuildSettings, err error) {
+	gs.GuildID = guildID
+
+	if gs.Prefix, err = nonZero(db.GetGuildPrefix(guildID)); err != nil {
+		return
+	}
+	if gs.AutoRoles, err = ignoreNotFound(db.GetGuildAutoRole(guildID)); err != nil {
+		return
+	}
+	if gs.AutoVCs, err = ignoreNotFound(db.GetGuildAutoVC(guildID)); err != nil {
+		return
+	}
+	if gs.ModLog, err = nonZero(db.GetGuildModLog(guildID)); err != nil {
+		return
+	}
+	if gs.ModNot, err = nonZero(db.GetGuildModNot(guildID)); err != nil {
+		return
+	}
+	if gs.VoiceLog, err = nonZero(db.GetGuildVoiceLog(guildID)); err != nil {
+		return
+	}
+	if gs.VoiceLogIgnores, err = ignoreNotFound(db.GetGuildVoiceLogIgnores(guildID)); err != nil {
+		return
+	}
+	if gs.NotifyRole, err = nonZero(db.GetGuildNotifyRole(guildID)); err != nil {
+		return
+	}
+	if gs.GhostPingMsg, err = nonZero(db.GetGuildGhostpingMsg(guildID)); err != nil {
+		return
+	}
+	if gs.Permissions, err = ignoreNotFound(db.GetGuildPermissions(guildID)); err != nil {
+		return
+	}
+	if gs.JdoodleKey, err = nonZero(db.GetGuildJdoodleKey(guildID)); err != nil {
+		return
+	}
+	if gs.CodeExecEnabled, err = nonZero(db.GetGuildCodeExecEnabled(guildID)); err != nil {
+		return
+	}
+	if gs.Backup, err = nonZero(db.GetGuildBackup(guildID)); err != nil {
+		return
+	}
+	if gs.BackupRetention, err = found(db.GetGuildBackupRetention(guildID)); err != nil {
+		return
+	}
+	if gs.EscalationLadder, err = ignoreNotFound(db.GetGuildEscalationLadder(guildID)); err != nil {
+		return
+	}
+	if gs.InviteBlock, err = nonZero(db.GetGuildInviteBlock(guildID)); err != nil {
+		return
+	}
+	if gs.InviteBlockSettings, err = found(db.GetGuildInviteBlockSettings(guildID)); err != nil {
+		return
+	}
+	if gs.AutomodRules, err = ignoreNotFound(db.GetGuildAutomodRules(guildID)); err != nil {
+		return
+	}
+	if gs.JoinMsg, err = readChannelMessage(db.GetGuildJoinMsg(guildID)); err != nil {
+		return
+	}
+	if gs.LeaveMsg, err = readChannelMessage(db.GetGuildLeaveMsg(guildID)); err != nil {
+		return
+	}
+	if gs.ColorReaction, err = nonZero(db.GetGuildColorReaction(guildID)); err != nil {
+		return
+	}
+	if gs.LogDisable, err = nonZero(db.GetGuildLogDisable(guildID)); err != nil {
+		return
+	}
+	if gs.VerificationRequired, err = nonZero(db.GetGuildVerificationRequired(guildID)); err != nil {
+		return
+	}
+	if gs.BirthdayChan, err = nonZero(db.GetGuildBirthdayChan(guildID)); err != nil {
+		return
+	}
+	if gs.BirthdayTemplate, err = nonZero(db.GetGuildBirthdayTemplate(guildID)); err != nil {
+		return
+	}
+	if gs.BirthdayRole, err = nonZero(db.GetGuildBirthdayRole(guildID)); err != nil {
+		return
+	}
+	if gs.API, err = found(db.GetGuildAPI(guildID)); err != nil {
+		return
+	}
+	if gs.LockedChannels, err = readLockedChannels(db, guildID); err != nil {
+		return
+	}
+	if gs.Karma, err = readKarmaSettings(db, guildID); err != nil {
+		return
+	}
+	if gs.KarmaBlockList, err = ignoreNotFound(db.GetKarmaBlockList(guildID)); err != nil {
+		return
+	}
+	if gs.KarmaRules, err = ignoreNotFound(db.GetKarmaRules(guildID)); err != nil {
+		return
+	}
+	if gs.Antiraid, err = readAntiraidSettings(db, guildID); err != nil {
+		return
+	}
+	if gs.AntiraidSignals, err = found(db.GetAntiraidSignals(guildID)); err != nil {
+		return
+	}
+	if gs.AntiraidLockdown, err = found(db.GetAntiraidLockdown(guildID)); err != nil {
+		return
+	}
+	if gs.Starboards, err = ignoreNotFound(db.GetStarboardConfigs(guildID)); err != nil {
+		return
+	}
+
+	return
+}
+
+func readChannelMessage(channelID, msg string, err error) (*ChannelMessage, error) {
+	if database.IsErrDatabaseNotFound(err) || (err == nil && channelID == "" && msg == "") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ChannelMessage{ChannelID: channelID, Message: msg}, nil
+}
+
+func readLockedChannels(db database.Database, guildID string) (res []LockedChannel, err error) {
+	chanIDs, err := ignoreNotFound(db.GetLockChannels(guildID))
+	if err != nil {
+		return
+	}
+
+	for _, chanID := range chanIDs {
+		var lc LockedChannel
+		lc.ChannelID = chanID
+		if _, lc.ExecutorID, lc.Permissions, err = db.GetLockChan(chanID); err != nil {
+			return
+		}
+		res = append(res, lc)
+	}
+
+	return
+}
+
+func readKarmaSettings(db database.Database, guildID string) (*KarmaSettings, error) {
+	var (
+		ks  KarmaSettings
+		err error
+	)
+
+	if ks.State, err = db.GetKarmaState(guildID); err != nil {
+		return found(ks, err)
+	}
+	if ks.EmotesInc, ks.EmotesDec, err = db.GetKarmaEmotes(guildID); err != nil {
+		return found(ks, err)
+	}
+	if ks.Tokens, err = db.GetKarmaTokens(guildID); err != nil {
+		return found(ks, err)
+	}
+	if ks.Penalty, err = db.GetKarmaPenalty(guildID); err != nil {
+		return found(ks, err)
+	}
+	if ks.Decay, err = db.GetKarmaDecay(guildID); err != nil {
+		return found(ks, err)
+	}
+	if ks.Farming, err = db.GetKarmaFarmingLimits(guildID); err != nil {
+		return found(ks, err)
+	}
+
+	return &ks, nil
+}
+
+func readAntiraidSettings(db database.Database, guildID string) (*AntiraidSettings, error) {
+	var (
+		as  AntiraidSettings
+		err error
+	)
+
+	if as.State, err = db.GetAntiraidState(guildID); err != nil {
+		return found(as, err)
+	}
+	if as.Regeneration, err = db.GetAntiraidRegeneration(guildID); err != nil {
+		return found(as, err)
+	}
+	if as.Burst, err = db.GetAntiraidBurst(guildID); err != nil {
+		return found(as, err)
+	}
+	if as.Verification, err = db.GetAntiraidVerification(guildID); err != nil {
+		return found(as, err)
+	}
+
+	return &as, nil
+}
+
+func writeGuildSettings(db database.Database, gs GuildSettings) (err error) {
+	guildID := gs.GuildID
+
+	set := func(f func() error) {
+		if err == nil {
+			err = f()
+		}
+	}
+
+	if gs.Prefix != nil {
+		set(func() error { return db.SetGuildPrefix(guildID, *gs.Prefix) })
+	}
+	if len(gs.AutoRoles) != 0 {
+		set(func() error { return db.SetGuildAutoRole(guildID, gs.AutoRoles) })
+	}
+	if len(gs.AutoVCs) != 0 {
+		set(func() error { return db.SetGuildAutoVC(guildID, gs.AutoVCs) })
+	}
+	if gs.ModLog != nil {
+		set(func() error { return db.SetGuildModLog(guildID, *gs.ModLog) })
+	}
+	if gs.ModNot != nil {
+		set(func() error { return db.SetGuildModNot(guildID, *gs.ModNot) })
+	}
+	if gs.VoiceLog != nil {
+		set(func() error { return db.SetGuildVoiceLog(guildID, *gs.VoiceLog) })
+	}
+	for _, chanID := range gs.VoiceLogIgnores {
+		chanID := chanID
+		set(func() error { return db.SetGuildVoiceLogIngore(guildID, chanID) })
+	}
+	if gs.NotifyRole != nil {
+		set(func() error { return db.SetGuildNotifyRole(guildID, *gs.NotifyRole) })
+	}
+	if gs.GhostPingMsg != nil {
+		set(func() error { return db.SetGuildGhostpingMsg(guildID, *gs.GhostPingMsg) })
+	}
+	for roleID, perms := range gs.Permissions {
+		roleID, perms := roleID, perms
+		set(func() error { return db.SetGuildRolePermission(guildID, roleID, perms) })
+	}
+	if gs.JdoodleKey != nil {
+		set(func() error { return db.SetGuildJdoodleKey(guildID, *gs.JdoodleKey) })
+	}
+	if gs.CodeExecEnabled != nil {
+		set(func() error { return db.SetGuildCodeExecEnabled(guildID, *gs.CodeExecEnabled) })
+	}
+	if gs.Backup != nil {
+		set(func() error { return db.SetGuildBackup(guildID, *gs.Backup) })
+	}
+	if gs.BackupRetention != nil {
+		set(func() error { return db.SetGuildBackupRetention(guildID, *gs.BackupRetention) })
+	}
+	if len(gs.EscalationLadder) != 0 {
+		set(func() error { return db.SetGuildEscalationLadder(guildID, gs.EscalationLadder) })
+	}
+	if gs.InviteBlock != nil {
+		set(func() error { return db.SetGuildInviteBlock(guildID, *gs.InviteBlock) })
+	}
+	if gs.InviteBlockSettings != nil {
+		set(func() error { return db.SetGuildInviteBlockSettings(guildID, *gs.InviteBlockSettings) })
+	}
+	if len(gs.AutomodRules) != 0 {
+		set(func() error { return db.SetGuildAutomodRules(guildID, gs.AutomodRules) })
+	}
+	if gs.JoinMsg != nil {
+		set(func() error { return db.SetGuildJoinMsg(guildID, gs.JoinMsg.ChannelID, gs.JoinMsg.Message) })
+	}
+	if gs.LeaveMsg != nil {
+		set(func() error { return db.SetGuildLeaveMsg(guildID, gs.LeaveMsg.ChannelID, gs.LeaveMsg.Message) })
+	}
+	if gs.ColorReaction != nil {
+		set(func() error { return db.SetGuildColorReaction(guildID, *gs.ColorReaction) })
+	}
+	if gs.LogDisable != nil {
+		set(func() error { return db.SetGuildLogDisable(guildID, *gs.LogDisable) })
+	}
+	if gs.VerificationRequired != nil {
+		set(func() error { return db.SetGuildVerificationRequired(guildID, *gs.VerificationRequired) })
+	}
+	if gs.BirthdayChan != nil {
+		set(func() error { return db.SetGuildBirthdayChan(guildID, *gs.BirthdayChan) })
+	}
+	if gs.BirthdayTemplate != nil {
+		set(func() error { return db.SetGuildBirthdayTemplate(guildID, *gs.BirthdayTemplate) })
+	}
+	if gs.BirthdayRole != nil {
+		set(func() error { return db.SetGuildBirthdayRole(guildID, *gs.BirthdayRole) })
+	}
+	if gs.API != nil {
+		set(func() error { return db.SetGuildAPI(guildID, *gs.API) })
+	}
+	for _, lc := range gs.LockedChannels {
+		lc := lc
+		set(func() error { return db.SetLockChan(lc.ChannelID, guildID, lc.ExecutorID, lc.Permissions) })
+	}
+	if ks := gs.Karma; ks != nil {
+		set(func() error { return db.SetKarmaState(guildID, ks.State) })
+		set(func() error { return db.SetKarmaEmotes(guildID, ks.EmotesInc, ks.EmotesDec) })
+		set(func() error { return db.SetKarmaTokens(guildID, ks.Tokens) })
+		set(func() error { return db.SetKarmaPenalty(guildID, ks.Penalty) })
+		set(func() error { return db.SetKarmaDecay(guildID, ks.Decay) })
+		set(func() error { return db.SetKarmaFarmingLimits(guildID, ks.Farming) })
+	}
+	for _, userID := range gs.KarmaBlockList {
+		userID := userID
+		set(func() error { return db.AddKarmaBlockList(guildID, userID) })
+	}
+	for _, rule := range gs.KarmaRules {
+		rule := rule
+		rule.GuildID = guildID
+		rule.CalculateChecksum()
+		set(func() error { return db.AddOrUpdateKarmaRule(rule) })
+	}
+	if as := gs.Antiraid; as != nil {
+		set(func() error { return db.SetAntiraidState(guildID, as.State) })
+		set(func() error { return db.SetAntiraidRegeneration(guildID, as.Regeneration) })
+		set(func() error { return db.SetAntiraidBurst(guildID, as.Burst) })
+		set(func() error { return db.SetAntiraidVerification(guildID, as.Verification) })
+	}
+	if gs.AntiraidSignals != nil {
+		set(func() error { return db.SetAntiraidSignals(guildID, *gs.AntiraidSignals) })
+	}
+	if gs.AntiraidLockdown != nil {
+		set(func() error { return db.SetAntiraidLockdown(guildID, *gs.AntiraidLockdown) })
+	}
+	for _, cfg := range gs.Starboards {
+		cfg := cfg
+		cfg.GuildID = guildID
+		set(func() error { return db.SetStarboardConfig(cfg) })
+	}
+
+	return
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"database/sql"
 	"errors"
+	"time"
 )
 
 var migrationFuncs = []migrationFunc{
@@ -20,6 +21,21 @@ var migrationFuncs = []migrationFunc{
 	migration_11,
 	migration_12,
 	migration_13,
+	migration_14,
+	migration_15,
+	migration_16,
+	migration_17,
+	migration_18,
+	migration_19,
+	migration_20,
+	migration_21,
+	migration_22,
+	migration_23,
+	migration_24,
+	migration_25,
+	migration_26,
+	migration_27,
+	migration_28,
 }
 
 // VERSION 0:
@@ -161,3 +177,224 @@ func migration_13(m *sql.Tx) (err error) {
 
 	return err
 }
+
+// VERSION 14:
+// - add property `backupRetention` to `guilds`
+// - add properties `parentID` and `changes` to `backups`
+func migration_14(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"guilds", "`backupRetention` text NOT NULL DEFAULT ''")
+	if err != nil {
+		return err
+	}
+
+	err = createTableColumnIfNotExists(m,
+		"backups", "`parentID` text NOT NULL DEFAULT ''")
+	if err != nil {
+		return err
+	}
+
+	return createTableColumnIfNotExists(m,
+		"backups", "`changes` int(11) NOT NULL DEFAULT 0")
+}
+
+// VERSION 15:
+// - add property `escalationLadder` to `guilds`
+// - add property `linkedID` to `reports`
+func migration_15(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"guilds", "`escalationLadder` text NOT NULL DEFAULT ''")
+	if err != nil {
+		return err
+	}
+
+	return createTableColumnIfNotExists(m,
+		"reports", "`linkedID` bigint(20) NOT NULL DEFAULT '0'")
+}
+
+// VERSION 16:
+// - add properties `decayAfter` and `decayAmount` to `karmaSettings`
+// - add properties `pairLimit`, `pairWindow` and
+// `sockPuppetThreshold` to `karmaSettings`
+func migration_16(m *sql.Tx) (err error) {
+	for _, col := range []string{
+		"`decayAfter` bigint(20) NOT NULL DEFAULT '0'",
+		"`decayAmount` bigint(20) NOT NULL DEFAULT '0'",
+		"`pairLimit` bigint(20) NOT NULL DEFAULT '0'",
+		"`pairWindow` bigint(20) NOT NULL DEFAULT '0'",
+		"`sockPuppetThreshold` bigint(20) NOT NULL DEFAULT '0'",
+	} {
+		if err = createTableColumnIfNotExists(m, "karmaSettings", col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VERSION 17:
+// - add properties `maxValue`, `channelID`, `duration`, `cooldown`,
+// `channels` and `roles` to `karmaRules`
+func migration_17(m *sql.Tx) (err error) {
+	for _, col := range []string{
+		"`maxValue` int(32) NOT NULL DEFAULT '0'",
+		"`channelID` varchar(25) NOT NULL DEFAULT ''",
+		"`duration` bigint(20) NOT NULL DEFAULT '0'",
+		"`cooldown` bigint(20) NOT NULL DEFAULT '0'",
+		"`channels` text NOT NULL DEFAULT ''",
+		"`roles` text NOT NULL DEFAULT ''",
+	} {
+		if err = createTableColumnIfNotExists(m, "karmaRules", col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VERSION 18:
+// - add property `board` to `starboardEntries`
+// - move the starboard config of each guild from `starboardConfig`
+// to the `default` board in `starboards`
+func migration_18(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"starboardEntries", "`board` varchar(32) NOT NULL DEFAULT ''")
+	if err != nil {
+		return
+	}
+
+	_, err = m.Exec(
+		"INSERT IGNORE INTO starboards (guildID, name, channelID, threshold, emojiID, karmaGain) " +
+			"SELECT guildID, 'default', channelID, threshold, emojiID, karmaGain " +
+			"FROM starboardConfig WHERE channelID != ''")
+	if err != nil {
+		return
+	}
+
+	_, err = m.Exec("UPDATE starboardEntries SET board = 'default' WHERE board = ''")
+	return
+}
+
+// VERSION 19:
+// - add properties `aliases`, `roles` and `uses` to `tags`
+func migration_19(m *sql.Tx) (err error) {
+	for _, col := range []string{
+		"`aliases` text NOT NULL DEFAULT ''",
+		"`roles` text NOT NULL DEFAULT ''",
+		"`uses` bigint(20) NOT NULL DEFAULT '0'",
+	} {
+		if err = createTableColumnIfNotExists(m, "tags", col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VERSION 20:
+// - add properties `guildID`, `expires` and `closed` to `votes`
+func migration_20(m *sql.Tx) (err error) {
+	for _, col := range []string{
+		"`guildID` varchar(25) NOT NULL DEFAULT ''",
+		"`expires` bigint(20) NOT NULL DEFAULT '0'",
+		"`closed` int(1) NOT NULL DEFAULT '0'",
+	} {
+		if err = createTableColumnIfNotExists(m, "votes", col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VERSION 21:
+// - add properties `label`, `emoji`, `position`, `mode`,
+// `maxRoles`, `requiredRoles` and `menu` to `roleselect`
+func migration_21(m *sql.Tx) (err error) {
+	for _, col := range []string{
+		"`label` text NOT NULL DEFAULT ''",
+		"`emoji` text NOT NULL DEFAULT ''",
+		"`position` int(11) NOT NULL DEFAULT '0'",
+		"`mode` int(11) NOT NULL DEFAULT '0'",
+		"`maxRoles` int(11) NOT NULL DEFAULT '0'",
+		"`requiredRoles` text NOT NULL DEFAULT ''",
+		"`menu` int(1) NOT NULL DEFAULT '0'",
+	} {
+		if err = createTableColumnIfNotExists(m, "roleselect", col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VERSION 22:
+// - add properties `birthdayTemplate` and `birthdayRoleID` to `guilds`
+// - add property `timezone` to `users`
+// - add properties `lastNotified` and `roleExpires` to `birthdays`
+func migration_22(m *sql.Tx) (err error) {
+	for _, col := range []struct{ table, def string }{
+		{"guilds", "`birthdayTemplate` text NOT NULL DEFAULT ''"},
+		{"guilds", "`birthdayRoleID` text NOT NULL DEFAULT ''"},
+		{"users", "`timezone` text NOT NULL DEFAULT ''"},
+		{"birthdays", "`lastNotified` bigint(20) NOT NULL DEFAULT '0'"},
+		{"birthdays", "`roleExpires` bigint(20) NOT NULL DEFAULT '0'"},
+	} {
+		if err = createTableColumnIfNotExists(m, col.table, col.def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VERSION 23:
+// - add property `antiraidSignals` to `guilds`
+func migration_23(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "`antiraidSignals` text NOT NULL DEFAULT ''")
+}
+
+// VERSION 24:
+// - add property `antiraidLockdown` to `guilds`
+func migration_24(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "`antiraidLockdown` text NOT NULL DEFAULT ''")
+}
+
+// VERSION 25:
+// - add property `inviteBlockSettings` to `guilds`
+func migration_25(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "`inviteBlockSettings` text NOT NULL DEFAULT ''")
+}
+
+// VERSION 26:
+// - add property `automodRules` to `guilds`
+func migration_26(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "`automodRules` text NOT NULL DEFAULT ''")
+}
+
+// VERSION 27:
+// - key `starboardEntries` by `messageID` and `board`
+func migration_27(m *sql.Tx) (err error) {
+	_, err = m.Exec(
+		"ALTER TABLE starboardEntries DROP PRIMARY KEY, ADD PRIMARY KEY (`messageID`, `board`)")
+	return
+}
+
+// VERSION 28:
+// - add property `decayEnabledAt` to `karmaSettings`
+//
+// Guilds which already have enabled decay are treated
+// as if decay has been enabled with this migration, so
+// that members with karma from before the karma ledger
+// existed do not decay immediately.
+func migration_28(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"karmaSettings", "`decayEnabledAt` bigint(20) NOT NULL DEFAULT '0'")
+	if err != nil {
+		return
+	}
+
+	_, err = m.Exec(
+		"UPDATE karmaSettings SET decayEnabledAt = ? "+
+			"WHERE decayAfter > 0 AND decayAmount > 0 AND decayEnabledAt = 0",
+		time.Now().Unix())
+	return
+}
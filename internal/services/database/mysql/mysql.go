@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/zekrotja/rogu/log"
 
 	"github.com/bwmarrin/snowflake"
+	"golang.org/x/exp/slices"
 	mySqlDriver "github.com/go-sql-driver/mysql"
 )
 
@@ -39,20 +41,27 @@ func New() *MysqlMiddleware {
 
 var guildTables = []string{
 	"antiraidJoinlog",
+	"antiraidLockdowns",
 	"antiraidSettings",
 	"backups",
+	"backupKeys",
+	"backupRestores",
 	"chanlock",
 	"guildapi",
 	"guildlog",
 	"guilds",
 	"karma",
 	"karmaBlocklist",
+	"karmaLedger",
 	"karmaRules",
 	"karmaSettings",
+	"karmaTempRoles",
 	"permissions",
 	"reports",
+	"reportRevisions",
 	"starboardConfig",
 	"starboardEntries",
+	"starboards",
 	"tags",
 	"twitchnotify",
 	"unbanRequests",
@@ -76,6 +85,7 @@ var userTables = []tableColumn{
 	{"unbanRequests", "processedBy"},
 	{"users", "userID"},
 	{"birthdays", "userID"},
+	{"karmaTempRoles", "userID"},
 }
 
 func (m *MysqlMiddleware) setup() (err error) {
@@ -118,7 +128,15 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`guildlogDisable` text NOT NULL DEFAULT ''," +
 		"`requireUserVerification` text NOT NULL DEFAULT ''," +
 		"`birthdaychanID` text NOT NULL DEFAULT ''," +
+		"`birthdayTemplate` text NOT NULL DEFAULT ''," +
+		"`birthdayRoleID` text NOT NULL DEFAULT ''," +
 		"`modnotchanID` varchar(25) NOT NULL DEFAULT ''," +
+		"`backupRetention` text NOT NULL DEFAULT ''," +
+		"`escalationLadder` text NOT NULL DEFAULT ''," +
+		"`antiraidSignals` text NOT NULL DEFAULT ''," +
+		"`antiraidLockdown` text NOT NULL DEFAULT ''," +
+		"`inviteBlockSettings` text NOT NULL DEFAULT ''," +
+		"`automodRules` text NOT NULL DEFAULT ''," +
 		"PRIMARY KEY (`guildID`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
@@ -130,6 +148,7 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`enableOTA` text NOT NULL DEFAULT '0'," +
 		"`verified` text NOT NULL DEFAULT '0'," +
 		"`starboardOptout` text NOT NULL DEFAULT '0'," +
+		"`timezone` text NOT NULL DEFAULT ''," +
 		"PRIMARY KEY (`userID`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
@@ -155,12 +174,28 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`msg` text NOT NULL DEFAULT ''," +
 		"`attachment` text NOT NULL DEFAULT ''," +
 		"`timeout` timestamp NULL DEFAULT NULL," +
+		"`linkedID` bigint(20) NOT NULL DEFAULT '0'," +
 		"PRIMARY KEY (`id`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
 		return
 	}
 
+	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `reportRevisions` (" +
+		"`reportID` varchar(25) NOT NULL," +
+		"`version` int(11) NOT NULL DEFAULT '0'," +
+		"`guildID` varchar(25) NOT NULL DEFAULT ''," +
+		"`editorID` varchar(25) NOT NULL DEFAULT ''," +
+		"`timestamp` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP()," +
+		"`msg` text NOT NULL DEFAULT ''," +
+		"`attachment` text NOT NULL DEFAULT ''," +
+		"`timeout` timestamp NULL DEFAULT NULL," +
+		"PRIMARY KEY (`reportID`, `version`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	if err != nil {
+		return
+	}
+
 	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `settings` (" +
 		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
 		"`setting` text NOT NULL DEFAULT ''," +
@@ -174,6 +209,9 @@ func (m *MysqlMiddleware) setup() (err error) {
 	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `votes` (" +
 		"`id` varchar(25) NOT NULL," +
 		"`data` mediumtext NOT NULL DEFAULT ''," +
+		"`guildID` varchar(25) NOT NULL DEFAULT ''," +
+		"`expires` bigint(20) NOT NULL DEFAULT '0'," +
+		"`closed` int(1) NOT NULL DEFAULT '0'," +
 		"PRIMARY KEY (`id`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
@@ -196,6 +234,8 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`guildID` text NOT NULL DEFAULT ''," +
 		"`timestamp` bigint(20) NOT NULL DEFAULT CURRENT_TIMESTAMP()," +
 		"`fileID` text NOT NULL DEFAULT ''," +
+		"`parentID` text NOT NULL DEFAULT ''," +
+		"`changes` int(11) NOT NULL DEFAULT 0," +
 		"PRIMARY KEY (`iid`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
@@ -210,6 +250,9 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`content` text NOT NULL DEFAULT ''," +
 		"`created` bigint(20) NOT NULL DEFAULT CURRENT_TIMESTAMP()," +
 		"`lastEdit` bigint(20) NOT NULL DEFAULT CURRENT_TIMESTAMP()," +
+		"`aliases` text NOT NULL DEFAULT ''," +
+		"`roles` text NOT NULL DEFAULT ''," +
+		"`uses` bigint(20) NOT NULL DEFAULT '0'," +
 		"PRIMARY KEY (`id`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
@@ -247,6 +290,12 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`emotesDec` text NOT NULL DEFAULT ''," +
 		"`tokens` bigint(20) NOT NULL DEFAULT '1'," +
 		"`penalty` int(1) NOT NULL DEFAULT '0'," +
+		"`decayAfter` bigint(20) NOT NULL DEFAULT '0'," +
+		"`decayAmount` bigint(20) NOT NULL DEFAULT '0'," +
+		"`decayEnabledAt` bigint(20) NOT NULL DEFAULT '0'," +
+		"`pairLimit` bigint(20) NOT NULL DEFAULT '0'," +
+		"`pairWindow` bigint(20) NOT NULL DEFAULT '0'," +
+		"`sockPuppetThreshold` bigint(20) NOT NULL DEFAULT '0'," +
 		"PRIMARY KEY (`guildID`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
@@ -263,6 +312,32 @@ func (m *MysqlMiddleware) setup() (err error) {
 		return
 	}
 
+	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `karmaLedger` (" +
+		"`id` varchar(25) NOT NULL," +
+		"`guildID` varchar(25) NOT NULL DEFAULT ''," +
+		"`executorID` varchar(25) NOT NULL DEFAULT ''," +
+		"`targetID` varchar(25) NOT NULL DEFAULT ''," +
+		"`delta` int(32) NOT NULL DEFAULT '0'," +
+		"`source` varchar(30) NOT NULL DEFAULT ''," +
+		"`timestamp` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP()," +
+		"`revertedBy` varchar(25) NOT NULL DEFAULT '0'," +
+		"PRIMARY KEY (`id`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `karmaTempRoles` (" +
+		"`guildID` varchar(25) NOT NULL DEFAULT ''," +
+		"`userID` varchar(25) NOT NULL DEFAULT ''," +
+		"`roleID` varchar(25) NOT NULL DEFAULT ''," +
+		"`expires` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP()," +
+		"PRIMARY KEY (`guildID`, `userID`, `roleID`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	if err != nil {
+		return
+	}
+
 	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `karmaRules` (" +
 		"`id` varchar(25) NOT NULL," +
 		"`guildID` varchar(25) NOT NULL DEFAULT ''," +
@@ -271,6 +346,12 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`action` varchar(30) NOT NULL DEFAULT ''," +
 		"`argument` text NOT NULL DEFAULT ''," +
 		"`checksum` text NOT NULL DEFAULT ''," +
+		"`maxValue` int(32) NOT NULL DEFAULT '0'," +
+		"`channelID` varchar(25) NOT NULL DEFAULT ''," +
+		"`duration` bigint(20) NOT NULL DEFAULT '0'," +
+		"`cooldown` bigint(20) NOT NULL DEFAULT '0'," +
+		"`channels` text NOT NULL DEFAULT ''," +
+		"`roles` text NOT NULL DEFAULT ''," +
 		"PRIMARY KEY (`id`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
@@ -313,6 +394,15 @@ func (m *MysqlMiddleware) setup() (err error) {
 		return
 	}
 
+	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `antiraidLockdowns` (" +
+		"`guildID` varchar(25) NOT NULL," +
+		"`data` mediumtext NOT NULL DEFAULT ''," +
+		"PRIMARY KEY (`guildID`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	if err != nil {
+		return
+	}
+
 	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `unbanRequests` (" +
 		"`id` varchar(25) NOT NULL DEFAULT ''," +
 		"`userID` varchar(25) NOT NULL DEFAULT ''," +
@@ -353,9 +443,28 @@ func (m *MysqlMiddleware) setup() (err error) {
 		return
 	}
 
+	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `starboards` (" +
+		"`guildID` varchar(25) NOT NULL DEFAULT ''," +
+		"`name` varchar(32) NOT NULL DEFAULT ''," +
+		"`channelID` varchar(25) NOT NULL DEFAULT ''," +
+		"`threshold` int(16) NOT NULL DEFAULT '0'," +
+		"`emojiID` text NOT NULL DEFAULT ''," +
+		"`karmaGain` int(16) NOT NULL DEFAULT '3'," +
+		"`channels` text NOT NULL DEFAULT ''," +
+		"`excludedChannels` text NOT NULL DEFAULT ''," +
+		"`nsfw` int(8) NOT NULL DEFAULT '0'," +
+		"`nsfwChannelID` varchar(25) NOT NULL DEFAULT ''," +
+		"`minAge` bigint(20) NOT NULL DEFAULT '0'," +
+		"PRIMARY KEY (`guildID`, `name`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	if err != nil {
+		return
+	}
+
 	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `starboardEntries` (" +
 		"`messageID` varchar(25) NOT NULL DEFAULT ''," +
 		"`starboardID` varchar(25) NOT NULL DEFAULT ''," +
+		"`board` varchar(32) NOT NULL DEFAULT ''," +
 		"`guildID` varchar(25) NOT NULL DEFAULT ''," +
 		"`channelID` varchar(25) NOT NULL DEFAULT ''," +
 		"`authorID` varchar(25) NOT NULL DEFAULT ''," +
@@ -363,7 +472,7 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`mediaURLs` text NOT NULL DEFAULT ''," +
 		"`score` int(24) NOT NULL DEFAULT '0'," +
 		"`deleted` int(1) NOT NULL DEFAULT '0'," +
-		"PRIMARY KEY (`messageID`)" +
+		"PRIMARY KEY (`messageID`, `board`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
 		return
@@ -420,6 +529,8 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`userID` varchar(25) NOT NULL DEFAULT ''," +
 		"`date` timestamp," +
 		"`showYear` int(1) NOT NULL DEFAULT '0'," +
+		"`lastNotified` bigint(20) NOT NULL DEFAULT '0'," +
+		"`roleExpires` bigint(20) NOT NULL DEFAULT '0'," +
 		"PRIMARY KEY (`iid`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
@@ -431,12 +542,42 @@ func (m *MysqlMiddleware) setup() (err error) {
 		"`channelID` varchar(25) NOT NULL," +
 		"`messageID` varchar(25) NOT NULL," +
 		"`roleID` varchar(25) NOT NULL," +
+		"`label` text NOT NULL DEFAULT ''," +
+		"`emoji` text NOT NULL DEFAULT ''," +
+		"`position` int(11) NOT NULL DEFAULT '0'," +
+		"`mode` int(11) NOT NULL DEFAULT '0'," +
+		"`maxRoles` int(11) NOT NULL DEFAULT '0'," +
+		"`requiredRoles` text NOT NULL DEFAULT ''," +
+		"`menu` int(1) NOT NULL DEFAULT '0'," +
 		"PRIMARY KEY (`guildID`, `channelID`, `messageID`, `roleID`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	if err != nil {
 		return
 	}
 
+	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `backupKeys` (" +
+		"`keyID` varchar(32) NOT NULL," +
+		"`guildID` varchar(25) NOT NULL DEFAULT ''," +
+		"`masterKeyID` text NOT NULL DEFAULT ''," +
+		"`wrappedKey` text NOT NULL DEFAULT ''," +
+		"`created` bigint(20) NOT NULL DEFAULT 0," +
+		"PRIMARY KEY (`keyID`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec("CREATE TABLE IF NOT EXISTS `backupRestores` (" +
+		"`guildID` varchar(25) NOT NULL," +
+		"`fileID` text NOT NULL DEFAULT ''," +
+		"`checkpoint` mediumtext NOT NULL DEFAULT ''," +
+		"`updated` bigint(20) NOT NULL DEFAULT 0," +
+		"PRIMARY KEY (`guildID`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	if err != nil {
+		return
+	}
+
 	err = tx.Commit()
 	return
 }
@@ -683,6 +824,45 @@ func (m *MysqlMiddleware) SetGuildBackup(guildID string, enabled bool) error {
 	return m.setGuildSetting(guildID, "backup", val)
 }
 
+func (m *MysqlMiddleware) GetGuildBackupRetention(guildID string) (r backupmodels.Retention, err error) {
+	val, err := m.getGuildSetting(guildID, "backupRetention")
+	if err != nil {
+		return r, err
+	}
+	if val == "" {
+		return r, database.ErrDatabaseNotFound
+	}
+	return backupmodels.DecodeRetention(val)
+}
+
+func (m *MysqlMiddleware) SetGuildBackupRetention(guildID string, r backupmodels.Retention) error {
+	return m.setGuildSetting(guildID, "backupRetention", r.Encoded())
+}
+
+func (m *MysqlMiddleware) GetGuildEscalationLadder(guildID string) (l models.EscalationLadder, err error) {
+	val, err := m.getGuildSetting(guildID, "escalationLadder")
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return nil, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &l)
+	return l, err
+}
+
+func (m *MysqlMiddleware) SetGuildEscalationLadder(guildID string, l models.EscalationLadder) error {
+	var val string
+	if len(l) != 0 {
+		data, err := json.Marshal(l)
+		if err != nil {
+			return err
+		}
+		val = string(data)
+	}
+	return m.setGuildSetting(guildID, "escalationLadder", val)
+}
+
 func (m *MysqlMiddleware) GetSetting(setting string) (string, error) {
 	var value string
 	err := m.Db.QueryRow("SELECT value FROM settings WHERE setting = ?", setting).Scan(&value)
@@ -709,24 +889,67 @@ func (m *MysqlMiddleware) SetSetting(setting, value string) error {
 
 func (m *MysqlMiddleware) AddReport(rep models.Report) error {
 	_, err := m.Db.Exec(`
-		INSERT INTO reports (id, type, guildID, executorID, victimID, msg, attachment, timeout)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		rep.ID, rep.Type, rep.GuildID, rep.ExecutorID, rep.VictimID, rep.Msg, rep.AttachmentURL, rep.Timeout)
+		INSERT INTO reports (id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rep.ID, rep.Type, rep.GuildID, rep.ExecutorID, rep.VictimID, rep.Msg, rep.AttachmentURL, rep.Timeout, rep.LinkedID)
 	return err
 }
 
 func (m *MysqlMiddleware) DeleteReport(id snowflake.ID) error {
-	_, err := m.Db.Exec("DELETE FROM reports WHERE id = ?", id)
+	_, err := m.Db.Exec("DELETE FROM reportRevisions WHERE reportID = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = m.Db.Exec("DELETE FROM reports WHERE id = ?", id)
+	return err
+}
+
+func (m *MysqlMiddleware) UpdateReport(rep models.Report) error {
+	_, err := m.Db.Exec(`
+		UPDATE reports SET msg = ?, attachment = ?, timeout = ?
+		WHERE id = ?`,
+		rep.Msg, rep.AttachmentURL, rep.Timeout, rep.ID)
+	return err
+}
+
+func (m *MysqlMiddleware) AddReportRevision(rev models.ReportRevision) error {
+	_, err := m.Db.Exec(`
+		INSERT INTO reportRevisions (reportID, version, guildID, editorID, timestamp, msg, attachment, timeout)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		rev.ReportID, rev.Version, rev.GuildID, rev.EditorID, rev.Timestamp, rev.Msg, rev.AttachmentURL, rev.Timeout)
 	return err
 }
 
+func (m *MysqlMiddleware) GetReportRevisions(reportID snowflake.ID) ([]models.ReportRevision, error) {
+	rows, err := m.Db.Query(`
+		SELECT reportID, version, guildID, editorID, timestamp, msg, attachment, timeout
+		FROM reportRevisions WHERE reportID = ?
+		ORDER BY version ASC`, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.ReportRevision, 0)
+	for rows.Next() {
+		var rev models.ReportRevision
+		err := rows.Scan(&rev.ReportID, &rev.Version, &rev.GuildID, &rev.EditorID,
+			&rev.Timestamp, &rev.Msg, &rev.AttachmentURL, &rev.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rev)
+	}
+	return results, nil
+}
+
 func (m *MysqlMiddleware) GetReport(id snowflake.ID) (models.Report, error) {
 	rep := models.Report{}
 
 	row := m.Db.QueryRow(`
-		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout
+		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID
 		FROM reports WHERE id = ?`, id)
-	err := row.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID, &rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout)
+	err := row.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID, &rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout, &rep.LinkedID)
 	if err == sql.ErrNoRows {
 		return models.Report{}, database.ErrDatabaseNotFound
 	}
@@ -740,7 +963,7 @@ func (m *MysqlMiddleware) GetReportsGuild(guildID string, offset, limit int) ([]
 	}
 
 	rows, err := m.Db.Query(`
-		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout
+		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID
 		FROM reports WHERE guildID = ?
 		ORDER BY id DESC
 		LIMIT ?, ?
@@ -752,7 +975,7 @@ func (m *MysqlMiddleware) GetReportsGuild(guildID string, offset, limit int) ([]
 	for rows.Next() {
 		var rep models.Report
 		err := rows.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID,
-			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout)
+			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout, &rep.LinkedID)
 		if err != nil {
 			return nil, err
 		}
@@ -763,7 +986,7 @@ func (m *MysqlMiddleware) GetReportsGuild(guildID string, offset, limit int) ([]
 
 func (m *MysqlMiddleware) GetReportsFiltered(guildID, memberID string, repType models.ReportType, offset, limit int) ([]models.Report, error) {
 	args := []interface{}{}
-	query := `SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout FROM reports WHERE true`
+	query := `SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID FROM reports WHERE true`
 	if guildID != "" {
 		query += " AND guildID = ?"
 		args = append(args, guildID)
@@ -787,7 +1010,7 @@ func (m *MysqlMiddleware) GetReportsFiltered(guildID, memberID string, repType m
 	for rows.Next() {
 		var rep models.Report
 		err := rows.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID,
-			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout)
+			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout, &rep.LinkedID)
 		if err != nil {
 			return nil, err
 		}
@@ -821,7 +1044,7 @@ func (m *MysqlMiddleware) GetReportsFilteredCount(guildID, memberID string, repT
 
 func (m *MysqlMiddleware) GetExpiredReports() (results []models.Report, err error) {
 	rows, err := m.Db.Query(`
-		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout
+		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID
 		FROM reports
 		WHERE timeout <= CURRENT_TIMESTAMP`)
 	if err != nil {
@@ -835,7 +1058,7 @@ func (m *MysqlMiddleware) GetExpiredReports() (results []models.Report, err erro
 	for rows.Next() {
 		var rep models.Report
 		err := rows.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID,
-			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout)
+			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout, &rep.LinkedID)
 		if err != nil {
 			return nil, err
 		}
@@ -885,19 +1108,74 @@ func (m *MysqlMiddleware) GetVotes() (map[string]vote.Vote, error) {
 	return results, err
 }
 
-func (m *MysqlMiddleware) AddUpdateVote(vote vote.Vote) error {
-	rawData, err := vote.Marshal()
+func (m *MysqlMiddleware) GetVote(id string) (v vote.Vote, err error) {
+	var rawData string
+	err = m.Db.QueryRow("SELECT data FROM votes WHERE id = ?", id).Scan(&rawData)
+	if err == sql.ErrNoRows {
+		err = database.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return
+	}
+	return vote.Unmarshal(rawData)
+}
+
+func (m *MysqlMiddleware) GetExpiredVotes(before time.Time) ([]vote.Vote, error) {
+	rows, err := m.Db.Query("SELECT data FROM votes WHERE closed = 0 AND expires > 0 AND expires <= ?", before.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []vote.Vote
+	for rows.Next() {
+		var rawData string
+		if err = rows.Scan(&rawData); err != nil {
+			return nil, err
+		}
+		v, err := vote.Unmarshal(rawData)
+		if err != nil {
+			m.log.Error().Err(err).Msg("An error occured reading vote from database")
+			continue
+		}
+		votes = append(votes, v)
+	}
+	return votes, rows.Err()
+}
+
+func (m *MysqlMiddleware) AddUpdateVote(v vote.Vote) error {
+	rawData, err := v.Marshal()
 	if err != nil {
 		return err
 	}
 
+	expires := toUnix(v.Expires)
+	closed := v.State != vote.VoteStateOpen
+
+	// The data of closed votes is not overwritten by open
+	// ones so that outdated instances can not reopen votes.
 	_, err = m.Db.Exec(
-		"INSERT INTO votes (id, data) VALUES (?, ?) "+
-			"ON DUPLICATE KEY UPDATE data = ?", vote.ID, rawData, rawData)
+		"INSERT INTO votes (id, data, guildID, expires, closed) VALUES (?, ?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE "+
+			"data = IF(closed = 1 AND ? = 0, data, ?), "+
+			"expires = IF(closed = 1 AND ? = 0, expires, ?), "+
+			"guildID = ?, closed = GREATEST(closed, ?)",
+		v.ID, rawData, v.GuildID, expires, closed,
+		closed, rawData, closed, expires, v.GuildID, closed)
 
 	return err
 }
 
+func (m *MysqlMiddleware) ClaimVoteClose(id string) (ok bool, err error) {
+	res, err := m.Db.Exec("UPDATE votes SET closed = 1 WHERE id = ? AND closed = 0", id)
+	if err != nil {
+		return
+	}
+	n, err := res.RowsAffected()
+	ok = n > 0
+	return
+}
+
 func (m *MysqlMiddleware) DeleteVote(voteID string) error {
 	_, err := m.Db.Exec("DELETE FROM votes WHERE id = ?", voteID)
 	return err
@@ -956,9 +1234,15 @@ func (m *MysqlMiddleware) GetAllTwitchNotifies(twitchUserID string) ([]twitchnot
 	return results, nil
 }
 
-func (m *MysqlMiddleware) AddBackup(guildID, fileID string) error {
-	timestamp := time.Now().Unix()
-	_, err := m.Db.Exec("INSERT INTO backups (guildID, timestamp, fileID) VALUES (?, ?, ?)", guildID, timestamp, fileID)
+func (m *MysqlMiddleware) AddBackup(entry backupmodels.Entry) error {
+	_, err := m.Db.Exec("INSERT INTO backups (guildID, timestamp, fileID, parentID, changes) VALUES (?, ?, ?, ?, ?)",
+		entry.GuildID, entry.Timestamp.Unix(), entry.FileID, entry.ParentID, entry.Changes)
+	return err
+}
+
+func (m *MysqlMiddleware) UpdateBackup(entry backupmodels.Entry) error {
+	_, err := m.Db.Exec("UPDATE backups SET parentID = ?, changes = ? WHERE guildID = ? AND fileID = ?",
+		entry.ParentID, entry.Changes, entry.GuildID, entry.FileID)
 	return err
 }
 
@@ -975,6 +1259,50 @@ func (m *MysqlMiddleware) SetGuildInviteBlock(guildID string, data string) error
 	return m.setGuildSetting(guildID, "inviteBlock", data)
 }
 
+func (m *MysqlMiddleware) GetGuildInviteBlockSettings(guildID string) (settings models.InviteBlockSettings, err error) {
+	val, err := m.getGuildSetting(guildID, "inviteBlockSettings")
+	if err != nil {
+		return
+	}
+	if val == "" {
+		return settings, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &settings)
+	return
+}
+
+func (m *MysqlMiddleware) SetGuildInviteBlockSettings(guildID string, settings models.InviteBlockSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "inviteBlockSettings", string(data))
+}
+
+func (m *MysqlMiddleware) GetGuildAutomodRules(guildID string) (rules []models.AutomodRule, err error) {
+	val, err := m.getGuildSetting(guildID, "automodRules")
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return nil, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &rules)
+	return rules, err
+}
+
+func (m *MysqlMiddleware) SetGuildAutomodRules(guildID string, rules []models.AutomodRule) error {
+	var val string
+	if len(rules) != 0 {
+		data, err := json.Marshal(rules)
+		if err != nil {
+			return err
+		}
+		val = string(data)
+	}
+	return m.setGuildSetting(guildID, "automodRules", val)
+}
+
 func (m *MysqlMiddleware) GetGuildJoinMsg(guildID string) (string, string, error) {
 	data, err := m.getGuildSetting(guildID, "joinMsg")
 	if err != nil {
@@ -1018,7 +1346,7 @@ func (m *MysqlMiddleware) SetGuildLeaveMsg(guildID string, channelID string, msg
 }
 
 func (m *MysqlMiddleware) GetBackups(guildID string) ([]backupmodels.Entry, error) {
-	rows, err := m.Db.Query("SELECT guildID, timestamp, fileID FROM backups WHERE guildID = ?", guildID)
+	rows, err := m.Db.Query("SELECT guildID, timestamp, fileID, parentID, changes FROM backups WHERE guildID = ?", guildID)
 	if err == sql.ErrNoRows {
 		return nil, database.ErrDatabaseNotFound
 	}
@@ -1030,7 +1358,7 @@ func (m *MysqlMiddleware) GetBackups(guildID string) ([]backupmodels.Entry, erro
 	for rows.Next() {
 		var be backupmodels.Entry
 		var timeStampUnix int64
-		err = rows.Scan(&be.GuildID, &timeStampUnix, &be.FileID)
+		err = rows.Scan(&be.GuildID, &timeStampUnix, &be.FileID, &be.ParentID, &be.Changes)
 		if err != nil {
 			return nil, err
 		}
@@ -1041,6 +1369,102 @@ func (m *MysqlMiddleware) GetBackups(guildID string) ([]backupmodels.Entry, erro
 	return backups, nil
 }
 
+func (m *MysqlMiddleware) GetBackupRestoreCheckpoint(guildID string) (cp backupmodels.RestoreCheckpoint, err error) {
+	var state string
+	var updatedUnix int64
+	err = m.Db.QueryRow("SELECT guildID, fileID, checkpoint, updated FROM backupRestores WHERE guildID = ?", guildID).
+		Scan(&cp.GuildID, &cp.FileID, &state, &updatedUnix)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	cp.Updated = time.Unix(updatedUnix, 0)
+	err = cp.SetState(state)
+	return
+}
+
+func (m *MysqlMiddleware) SetBackupRestoreCheckpoint(cp backupmodels.RestoreCheckpoint) (err error) {
+	state, err := cp.StateEncoded()
+	if err != nil {
+		return
+	}
+
+	res, err := m.Db.Exec("UPDATE backupRestores SET fileID = ?, checkpoint = ?, updated = ? WHERE guildID = ?",
+		cp.FileID, state, cp.Updated.Unix(), cp.GuildID)
+	if err != nil {
+		return
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec("INSERT INTO backupRestores (guildID, fileID, checkpoint, updated) VALUES (?, ?, ?, ?)",
+			cp.GuildID, cp.FileID, state, cp.Updated.Unix())
+	}
+
+	return
+}
+
+func (m *MysqlMiddleware) DeleteBackupRestoreCheckpoint(guildID string) error {
+	_, err := m.Db.Exec("DELETE FROM backupRestores WHERE guildID = ?", guildID)
+	return err
+}
+
+func (m *MysqlMiddleware) GetBackupKeys(guildID string) ([]backupmodels.DataKey, error) {
+	rows, err := m.Db.Query("SELECT keyID, guildID, masterKeyID, wrappedKey, created FROM backupKeys WHERE guildID = ?", guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]backupmodels.DataKey, 0)
+	for rows.Next() {
+		var key backupmodels.DataKey
+		var createdUnix int64
+		err = rows.Scan(&key.ID, &key.GuildID, &key.MasterKeyID, &key.Key, &createdUnix)
+		if err != nil {
+			return nil, err
+		}
+		key.Created = time.Unix(createdUnix, 0)
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (m *MysqlMiddleware) GetBackupKey(keyID string) (key backupmodels.DataKey, err error) {
+	var createdUnix int64
+	err = m.Db.QueryRow("SELECT keyID, guildID, masterKeyID, wrappedKey, created FROM backupKeys WHERE keyID = ?", keyID).
+		Scan(&key.ID, &key.GuildID, &key.MasterKeyID, &key.Key, &createdUnix)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	key.Created = time.Unix(createdUnix, 0)
+	return
+}
+
+func (m *MysqlMiddleware) AddBackupKey(key backupmodels.DataKey) error {
+	_, err := m.Db.Exec("INSERT INTO backupKeys (keyID, guildID, masterKeyID, wrappedKey, created) VALUES (?, ?, ?, ?, ?)",
+		key.ID, key.GuildID, key.MasterKeyID, key.Key, key.Created.Unix())
+	return err
+}
+
+func (m *MysqlMiddleware) UpdateBackupKey(key backupmodels.DataKey) error {
+	_, err := m.Db.Exec("UPDATE backupKeys SET masterKeyID = ?, wrappedKey = ? WHERE keyID = ?",
+		key.MasterKeyID, key.Key, key.ID)
+	return err
+}
+
+func (m *MysqlMiddleware) DeleteBackupKey(keyID string) error {
+	_, err := m.Db.Exec("DELETE FROM backupKeys WHERE keyID = ?", keyID)
+	return err
+}
+
 func (m *MysqlMiddleware) GetGuilds() ([]string, error) {
 	rows, err := m.Db.Query("SELECT guildID FROM guilds WHERE backup = '1'")
 	if err == sql.ErrNoRows {
@@ -1064,15 +1488,19 @@ func (m *MysqlMiddleware) GetGuilds() ([]string, error) {
 }
 
 func (m *MysqlMiddleware) AddTag(tag tag.Tag) error {
-	_, err := m.Db.Exec("INSERT INTO tags (id, ident, creatorID, guildID, content, created, lastEdit) VALUES "+
-		"(?, ?, ?, ?, ?, ?, ?)", tag.ID, tag.Ident, tag.CreatorID, tag.GuildID, tag.Content, tag.Created.Unix(), tag.LastEdit.Unix())
+	_, err := m.Db.Exec("INSERT INTO tags (id, ident, creatorID, guildID, content, created, lastEdit, aliases, roles, uses) VALUES "+
+		"(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		tag.ID, tag.Ident, tag.CreatorID, tag.GuildID, tag.Content, tag.Created.Unix(), tag.LastEdit.Unix(),
+		strings.Join(tag.Aliases, ";"), strings.Join(tag.Roles, ";"), tag.Uses)
 	return err
 }
 
 func (m *MysqlMiddleware) EditTag(tag tag.Tag) error {
 	_, err := m.Db.Exec("UPDATE tags SET "+
-		"ident = ?, creatorID = ?, guildID = ?, content = ?, created = ?, lastEdit = ? "+
-		"WHERE id = ?", tag.Ident, tag.CreatorID, tag.GuildID, tag.Content, tag.Created.Unix(), tag.LastEdit.Unix(), tag.ID)
+		"ident = ?, creatorID = ?, guildID = ?, content = ?, created = ?, lastEdit = ?, "+
+		"aliases = ?, roles = ? "+
+		"WHERE id = ?", tag.Ident, tag.CreatorID, tag.GuildID, tag.Content, tag.Created.Unix(), tag.LastEdit.Unix(),
+		strings.Join(tag.Aliases, ";"), strings.Join(tag.Roles, ";"), tag.ID)
 	if err == sql.ErrNoRows {
 		return database.ErrDatabaseNotFound
 	}
@@ -1080,55 +1508,31 @@ func (m *MysqlMiddleware) EditTag(tag tag.Tag) error {
 }
 
 func (m *MysqlMiddleware) GetTagByID(id snowflake.ID) (tag.Tag, error) {
-	var tag tag.Tag
-
-	var timestampCreated int64
-	var timestampLastEdit int64
-
-	row := m.Db.QueryRow("SELECT id, ident, creatorID, guildID, content, created, lastEdit FROM tags "+
+	row := m.Db.QueryRow("SELECT id, ident, creatorID, guildID, content, created, lastEdit, aliases, roles, uses FROM tags "+
 		"WHERE id = ?", id)
 
-	err := row.Scan(&tag.ID, &tag.Ident, &tag.CreatorID, &tag.GuildID,
-		&tag.Content, &timestampCreated, &timestampLastEdit)
+	tag, err := scanTag(row)
 	if err == sql.ErrNoRows {
 		return tag, database.ErrDatabaseNotFound
 	}
-	if err != nil {
-		return tag, err
-	}
-
-	tag.Created = time.Unix(timestampCreated, 0)
-	tag.LastEdit = time.Unix(timestampLastEdit, 0)
-
-	return tag, nil
+	return tag, err
 }
 
 func (m *MysqlMiddleware) GetTagByIdent(ident string, guildID string) (tag.Tag, error) {
-	var tag tag.Tag
-	var timestampCreated int64
-	var timestampLastEdit int64
-
-	row := m.Db.QueryRow("SELECT id, ident, creatorID, guildID, content, created, lastEdit FROM tags "+
-		"WHERE ident = ? AND guildID = ?", ident, guildID)
+	row := m.Db.QueryRow("SELECT id, ident, creatorID, guildID, content, created, lastEdit, aliases, roles, uses FROM tags "+
+		"WHERE guildID = ? AND (ident = ? OR LOCATE(CONCAT(';', ?, ';'), CONCAT(';', aliases, ';')) > 0) "+
+		"ORDER BY ident = ? DESC LIMIT 1", guildID, ident, ident, ident)
 
-	err := row.Scan(&tag.ID, &tag.Ident, &tag.CreatorID, &tag.GuildID,
-		&tag.Content, &timestampCreated, &timestampLastEdit)
+	tag, err := scanTag(row)
 	if err == sql.ErrNoRows {
 		return tag, database.ErrDatabaseNotFound
 	}
-	if err != nil {
-		return tag, err
-	}
-
-	tag.Created = time.Unix(timestampCreated, 0)
-	tag.LastEdit = time.Unix(timestampLastEdit, 0)
-
-	return tag, nil
+	return tag, err
 }
 
 func (m *MysqlMiddleware) GetGuildTags(guildID string) ([]tag.Tag, error) {
-	rows, err := m.Db.Query("SELECT id, ident, creatorID, guildID, content, created, lastEdit FROM tags "+
-		"WHERE guildID = ?", guildID)
+	rows, err := m.Db.Query("SELECT id, ident, creatorID, guildID, content, created, lastEdit, aliases, roles, uses FROM tags "+
+		"WHERE guildID = ? ORDER BY uses DESC", guildID)
 	if err == sql.ErrNoRows {
 		return nil, database.ErrDatabaseNotFound
 	}
@@ -1137,17 +1541,11 @@ func (m *MysqlMiddleware) GetGuildTags(guildID string) ([]tag.Tag, error) {
 	}
 
 	tags := make([]tag.Tag, 0)
-	var timestampCreated int64
-	var timestampLastEdit int64
 	for rows.Next() {
-		var tag tag.Tag
-		err = rows.Scan(&tag.ID, &tag.Ident, &tag.CreatorID, &tag.GuildID,
-			&tag.Content, &timestampCreated, &timestampLastEdit)
+		tag, err := scanTag(rows)
 		if err != nil {
 			return nil, err
 		}
-		tag.Created = time.Unix(timestampCreated, 0)
-		tag.LastEdit = time.Unix(timestampLastEdit, 0)
 		tags = append(tags, tag)
 	}
 
@@ -1162,6 +1560,11 @@ func (m *MysqlMiddleware) DeleteTag(id snowflake.ID) error {
 	return err
 }
 
+func (m *MysqlMiddleware) IncrementTagUses(id snowflake.ID) error {
+	_, err := m.Db.Exec("UPDATE tags SET uses = uses + 1 WHERE id = ?", id)
+	return err
+}
+
 func (m *MysqlMiddleware) SetAPIToken(token models.APITokenEntry) (err error) {
 	res, err := m.Db.Exec(
 		"UPDATE apitokens SET "+
@@ -1249,6 +1652,12 @@ func (m *MysqlMiddleware) GetKarmaGuild(guildID string, limit int) ([]models.Gui
 	return res[:i], nil
 }
 
+func (m *MysqlMiddleware) GetKarmaGuildCount(guildID string) (n int, err error) {
+	err = m.Db.QueryRow("SELECT COUNT(iid) FROM karma WHERE guildID = ?",
+		guildID).Scan(&n)
+	return
+}
+
 func (m *MysqlMiddleware) SetKarma(userID, guildID string, val int) (err error) {
 	res, err := m.Db.Exec("UPDATE karma SET value = ? WHERE userID = ? AND guildID = ?",
 		val, userID, guildID)
@@ -1358,12 +1767,98 @@ func (m *MysqlMiddleware) GetKarmaPenalty(guildID string) (state bool, err error
 	return
 }
 
-func (m *MysqlMiddleware) GetKarmaBlockList(guildID string) (list []string, err error) {
-	row, err := m.Db.Query("SELECT userID FROM karmaBlocklist WHERE guildID = ?", guildID)
+func (m *MysqlMiddleware) SetKarmaDecay(guildID string, decay models.KarmaDecay) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO karmaSettings (guildID, decayAfter, decayAmount, decayEnabledAt) "+
+			"VALUES (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE decayAfter = ?, decayAmount = ?, decayEnabledAt = ?",
+		guildID, decay.After, decay.Amount, toUnix(decay.EnabledAt),
+		decay.After, decay.Amount, toUnix(decay.EnabledAt))
+
+	return
+}
+
+func (m *MysqlMiddleware) GetKarmaDecay(guildID string) (decay models.KarmaDecay, err error) {
+	var enabledAt int64
+	err = m.Db.QueryRow("SELECT decayAfter, decayAmount, decayEnabledAt FROM karmaSettings WHERE guildID = ?",
+		guildID).Scan(&decay.After, &decay.Amount, &enabledAt)
 	err = wrapNotFoundError(err)
-	if err != nil {
-		return
-	}
+	decay.EnabledAt = fromUnix(enabledAt)
+
+	return
+}
+
+func (m *MysqlMiddleware) GetKarmaDecayGuilds() ([]string, error) {
+	rows, err := m.Db.Query(
+		"SELECT guildID FROM karmaSettings WHERE state = 1 AND decayAfter > 0 AND decayAmount > 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]string, 0)
+	for rows.Next() {
+		var guildID string
+		if err = rows.Scan(&guildID); err != nil {
+			return nil, err
+		}
+		res = append(res, guildID)
+	}
+	return res, nil
+}
+
+func (m *MysqlMiddleware) GetKarmaDecayCandidates(guildID string, inactiveSince time.Time) ([]models.GuildKarma, error) {
+	rows, err := m.Db.Query(`
+		SELECT k.userID, k.value
+		FROM karma k
+		WHERE k.guildID = ? AND k.value != 0
+		AND NOT EXISTS (
+			SELECT 1 FROM karmaLedger l
+			WHERE l.guildID = k.guildID AND (l.targetID = k.userID OR l.executorID = k.userID)
+			AND l.source != 'DECAY' AND l.timestamp >= ?
+		)`,
+		guildID, inactiveSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]models.GuildKarma, 0)
+	for rows.Next() {
+		v := models.GuildKarma{GuildID: guildID}
+		if err = rows.Scan(&v.UserID, &v.Value); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+func (m *MysqlMiddleware) SetKarmaFarmingLimits(guildID string, limits models.KarmaFarmingLimits) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO karmaSettings (guildID, pairLimit, pairWindow, sockPuppetThreshold) "+
+			"VALUES (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE pairLimit = ?, pairWindow = ?, sockPuppetThreshold = ?",
+		guildID, limits.PairLimit, limits.PairWindow, limits.SockPuppetThreshold,
+		limits.PairLimit, limits.PairWindow, limits.SockPuppetThreshold)
+
+	return
+}
+
+func (m *MysqlMiddleware) GetKarmaFarmingLimits(guildID string) (limits models.KarmaFarmingLimits, err error) {
+	err = m.Db.QueryRow("SELECT pairLimit, pairWindow, sockPuppetThreshold FROM karmaSettings WHERE guildID = ?",
+		guildID).Scan(&limits.PairLimit, &limits.PairWindow, &limits.SockPuppetThreshold)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *MysqlMiddleware) GetKarmaBlockList(guildID string) (list []string, err error) {
+	row, err := m.Db.Query("SELECT userID FROM karmaBlocklist WHERE guildID = ?", guildID)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
 
 	list = make([]string, 0)
 	var id string
@@ -1518,6 +2013,99 @@ func (m *MysqlMiddleware) GetAntiraidVerification(guildID string) (state bool, e
 	return
 }
 
+func (m *MysqlMiddleware) GetAntiraidSignals(guildID string) (sig models.AntiraidSignals, err error) {
+	val, err := m.getGuildSetting(guildID, "antiraidSignals")
+	if err != nil {
+		return
+	}
+	if val == "" {
+		return sig, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &sig)
+	return
+}
+
+func (m *MysqlMiddleware) SetAntiraidSignals(guildID string, sig models.AntiraidSignals) error {
+	var val string
+	if sig.Enabled() {
+		data, err := json.Marshal(sig)
+		if err != nil {
+			return err
+		}
+		val = string(data)
+	}
+	return m.setGuildSetting(guildID, "antiraidSignals", val)
+}
+
+func (m *MysqlMiddleware) GetAntiraidLockdown(guildID string) (cfg models.AntiraidLockdown, err error) {
+	val, err := m.getGuildSetting(guildID, "antiraidLockdown")
+	if err != nil {
+		return
+	}
+	if val == "" {
+		return cfg, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &cfg)
+	return
+}
+
+func (m *MysqlMiddleware) SetAntiraidLockdown(guildID string, cfg models.AntiraidLockdown) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "antiraidLockdown", string(data))
+}
+
+func (m *MysqlMiddleware) SetAntiraidLockdownState(state models.AntiraidLockdownState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = m.Db.Exec(
+		"INSERT INTO antiraidLockdowns (guildID, data) VALUES (?, ?) "+
+			"ON DUPLICATE KEY UPDATE data = ?", state.GuildID, string(data), string(data))
+	return err
+}
+
+func (m *MysqlMiddleware) GetAntiraidLockdownState(guildID string) (state models.AntiraidLockdownState, err error) {
+	var data string
+	err = m.Db.QueryRow("SELECT data FROM antiraidLockdowns WHERE guildID = ?", guildID).Scan(&data)
+	if err = wrapNotFoundError(err); err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(data), &state)
+	return
+}
+
+func (m *MysqlMiddleware) GetAntiraidLockdownStates() ([]models.AntiraidLockdownState, error) {
+	rows, err := m.Db.Query("SELECT data FROM antiraidLockdowns")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []models.AntiraidLockdownState
+	for rows.Next() {
+		var data string
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var state models.AntiraidLockdownState
+		if err = json.Unmarshal([]byte(data), &state); err != nil {
+			m.log.Error().Err(err).Msg("An error occured reading lockdown state from database")
+			continue
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+func (m *MysqlMiddleware) DeleteAntiraidLockdownState(guildID string) error {
+	_, err := m.Db.Exec("DELETE FROM antiraidLockdowns WHERE guildID = ?", guildID)
+	return err
+}
+
 func (m *MysqlMiddleware) GetAntiraidJoinList(guildID string) (res []models.JoinLogEntry, err error) {
 	query := "SELECT `userID`, `tag`, `accountCreated`, `timestamp`, `guildID` FROM antiraidJoinlog"
 	var args []interface{}
@@ -1712,6 +2300,14 @@ func (m *MysqlMiddleware) SetUserStarboardOptout(userID string, enabled bool) er
 	return m.setUserSetting(userID, "starboardOptout", v)
 }
 
+func (m *MysqlMiddleware) GetUserTimezone(userID string) (string, error) {
+	return m.getUserSetting(userID, "timezone")
+}
+
+func (m *MysqlMiddleware) SetUserTimezone(userID string, timezone string) error {
+	return m.setUserSetting(userID, "timezone", timezone)
+}
+
 func (m *MysqlMiddleware) GetGuildVoiceLogIgnores(guildID string) (res []string, err error) {
 	row, err := m.Db.Query("SELECT channelID FROM voicelogBlocklist WHERE guildID = ?", guildID)
 	err = wrapNotFoundError(err)
@@ -1763,51 +2359,90 @@ func (m *MysqlMiddleware) RemoveGuildVoiceLogIgnore(guildID, channelID string) (
 
 func (m *MysqlMiddleware) SetStarboardConfig(config models.StarboardConfig) (err error) {
 	var ok bool
-	m.Db.QueryRow("SELECT 1 FROM starboardConfig WHERE guildID = ?",
-		config.GuildID).Scan(&ok)
+	m.Db.QueryRow("SELECT 1 FROM starboards WHERE guildID = ? AND name = ?",
+		config.GuildID, config.Name).Scan(&ok)
+
+	channels := strings.Join(config.Channels, ";")
+	excludedChannels := strings.Join(config.ExcludedChannels, ";")
 
 	if ok {
 		_, err = m.Db.Exec(
-			"UPDATE starboardConfig SET "+
-				"channelID = ?, threshold = ?, emojiID = ?, karmaGain = ? "+
-				"WHERE guildID = ?",
-			config.ChannelID, config.Threshold, config.EmojiID, config.KarmaGain, config.GuildID)
+			"UPDATE starboards SET "+
+				"channelID = ?, threshold = ?, emojiID = ?, karmaGain = ?, channels = ?, "+
+				"excludedChannels = ?, nsfw = ?, nsfwChannelID = ?, minAge = ? "+
+				"WHERE guildID = ? AND name = ?",
+			config.ChannelID, config.Threshold, config.EmojiID, config.KarmaGain, channels,
+			excludedChannels, config.NSFW, config.NSFWChannelID, config.MinAge,
+			config.GuildID, config.Name)
 	} else {
 		_, err = m.Db.Exec(
-			"INSERT INTO starboardConfig "+
-				"(guildID, channelID, threshold, emojiID, karmaGain) "+
-				"VALUES (?, ?, ?, ?, ?)",
-			config.GuildID, config.ChannelID, config.Threshold, config.EmojiID, config.KarmaGain)
+			"INSERT INTO starboards "+
+				"(guildID, name, channelID, threshold, emojiID, karmaGain, channels, "+
+				"excludedChannels, nsfw, nsfwChannelID, minAge) "+
+				"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			config.GuildID, config.Name, config.ChannelID, config.Threshold, config.EmojiID, config.KarmaGain,
+			channels, excludedChannels, config.NSFW, config.NSFWChannelID, config.MinAge)
 	}
 
 	return
 }
 
-func (m *MysqlMiddleware) GetStarboardConfig(guildID string) (config models.StarboardConfig, err error) {
-	err = m.Db.QueryRow("SELECT channelID, threshold, emojiID, karmaGain FROM starboardConfig WHERE guildID = ?", guildID).
-		Scan(&config.ChannelID, &config.Threshold, &config.EmojiID, &config.KarmaGain)
+func (m *MysqlMiddleware) GetStarboardConfig(guildID, name string) (config models.StarboardConfig, err error) {
+	row := m.Db.QueryRow(
+		"SELECT guildID, name, channelID, threshold, emojiID, karmaGain, channels, "+
+			"excludedChannels, nsfw, nsfwChannelID, minAge "+
+			"FROM starboards WHERE guildID = ? AND name = ?", guildID, name)
+	config, err = scanStarboardConfig(row)
 	err = wrapNotFoundError(err)
 
 	return
 }
 
+func (m *MysqlMiddleware) GetStarboardConfigs(guildID string) (res []models.StarboardConfig, err error) {
+	rows, err := m.Db.Query(
+		"SELECT guildID, name, channelID, threshold, emojiID, karmaGain, channels, "+
+			"excludedChannels, nsfw, nsfwChannelID, minAge "+
+			"FROM starboards WHERE guildID = ? ORDER BY name ASC", guildID)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	res = make([]models.StarboardConfig, 0)
+	for rows.Next() {
+		var config models.StarboardConfig
+		if config, err = scanStarboardConfig(rows); err != nil {
+			return
+		}
+		res = append(res, config)
+	}
+
+	return
+}
+
+func (m *MysqlMiddleware) RemoveStarboardConfig(guildID, name string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM starboards WHERE guildID = ? AND name = ?", guildID, name)
+	return
+}
+
 func (m *MysqlMiddleware) SetStarboardEntry(e models.StarboardEntry) (err error) {
 	var ok bool
-	m.Db.QueryRow("SELECT 1 FROM starboardEntries WHERE messageID = ?",
-		e.MessageID).Scan(&ok)
+	m.Db.QueryRow("SELECT 1 FROM starboardEntries WHERE messageID = ? AND board = ?",
+		e.MessageID, e.Board).Scan(&ok)
 
 	if ok {
 		_, err = m.Db.Exec(
 			"UPDATE starboardEntries SET "+
 				"score = ?, deleted = ?, starboardID = ? "+
-				"WHERE messageID = ?",
-			e.Score, e.Deleted, e.StarboardID, e.MessageID)
+				"WHERE messageID = ? AND board = ?",
+			e.Score, e.Deleted, e.StarboardID, e.MessageID, e.Board)
 	} else {
 		_, err = m.Db.Exec(
 			"INSERT INTO starboardEntries "+
-				"(messageID, starboardID, guildID, channelID, authorID, content, mediaURLs, score, deleted) "+
-				"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-			e.MessageID, e.StarboardID, e.GuildID, e.ChannelID, e.AuthorID, e.Content, e.MediaURLsEncoded(), e.Score, e.Deleted)
+				"(messageID, starboardID, board, guildID, channelID, authorID, content, mediaURLs, score, deleted) "+
+				"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			e.MessageID, e.StarboardID, e.Board, e.GuildID, e.ChannelID, e.AuthorID, e.Content, e.MediaURLsEncoded(), e.Score, e.Deleted)
 	}
 	return
 }
@@ -1830,7 +2465,7 @@ func (m *MysqlMiddleware) GetStarboardEntries(
 		sort = "ORDER BY score DESC"
 	}
 
-	query := fmt.Sprintf("SELECT messageID, starboardID, guildID, channelID, authorID, content, mediaURLs, score, deleted "+
+	query := fmt.Sprintf("SELECT messageID, starboardID, board, guildID, channelID, authorID, content, mediaURLs, score, deleted "+
 		"FROM starboardEntries "+
 		"WHERE guildID = ? %s LIMIT %d OFFSET %d", sort, limit, offset)
 	row, err := m.Db.Query(query, guildID)
@@ -1843,7 +2478,7 @@ func (m *MysqlMiddleware) GetStarboardEntries(
 	for row.Next() {
 		var e models.StarboardEntry
 		var mediaURLencoded string
-		err = row.Scan(&e.MessageID, &e.StarboardID, &e.GuildID, &e.ChannelID, &e.AuthorID, &e.Content, &mediaURLencoded, &e.Score, &e.Deleted)
+		err = row.Scan(&e.MessageID, &e.StarboardID, &e.Board, &e.GuildID, &e.ChannelID, &e.AuthorID, &e.Content, &mediaURLencoded, &e.Score, &e.Deleted)
 		if err != nil {
 			return
 		}
@@ -1862,14 +2497,14 @@ func (m *MysqlMiddleware) GetStarboardEntriesCount(guildID string) (n int, err e
 	return
 }
 
-func (m *MysqlMiddleware) GetStarboardEntry(messageID string) (e models.StarboardEntry, err error) {
+func (m *MysqlMiddleware) GetStarboardEntry(messageID, board string) (e models.StarboardEntry, err error) {
 	var mediaURLencoded string
 	err = m.Db.QueryRow(
-		"SELECT messageID, starboardID, guildID, channelID, authorID, content, mediaURLs, score, deleted "+
+		"SELECT messageID, starboardID, board, guildID, channelID, authorID, content, mediaURLs, score, deleted "+
 			"FROM starboardEntries "+
-			"WHERE messageID = ?",
-		messageID).
-		Scan(&e.MessageID, &e.StarboardID, &e.GuildID, &e.ChannelID, &e.AuthorID, &e.Content, &mediaURLencoded, &e.Score, &e.Deleted)
+			"WHERE messageID = ? AND board = ?",
+		messageID, board).
+		Scan(&e.MessageID, &e.StarboardID, &e.Board, &e.GuildID, &e.ChannelID, &e.AuthorID, &e.Content, &mediaURLencoded, &e.Score, &e.Deleted)
 	err = wrapNotFoundError(err)
 	if err != nil {
 		return
@@ -1927,7 +2562,8 @@ func (m *MysqlMiddleware) CleanupExpiredRefreshTokens() (n int64, err error) {
 }
 
 func (m *MysqlMiddleware) GetKarmaRules(guildID string) (res []models.KarmaRule, err error) {
-	rows, err := m.Db.Query("SELECT id, `trigger`, value, action, argument, checksum "+
+	rows, err := m.Db.Query("SELECT id, `trigger`, value, action, argument, checksum, "+
+		"`maxValue`, channelID, duration, cooldown, channels, roles "+
 		"FROM karmaRules WHERE guildID = ?", guildID)
 	err = wrapNotFoundError(err)
 	if err != nil {
@@ -1936,11 +2572,17 @@ func (m *MysqlMiddleware) GetKarmaRules(guildID string) (res []models.KarmaRule,
 
 	res = make([]models.KarmaRule, 0)
 	for rows.Next() {
-		var r models.KarmaRule
+		var (
+			r               models.KarmaRule
+			channels, roles string
+		)
 		r.GuildID = guildID
-		if err = rows.Scan(&r.ID, &r.Trigger, &r.Value, &r.Action, &r.Argument, &r.Checksum); err != nil {
+		if err = rows.Scan(&r.ID, &r.Trigger, &r.Value, &r.Action, &r.Argument, &r.Checksum,
+			&r.MaxValue, &r.ChannelID, &r.Duration, &r.Cooldown, &channels, &roles); err != nil {
 			return
 		}
+		r.Channels = splitIDs(channels)
+		r.Roles = splitIDs(roles)
 		res = append(res, r)
 	}
 
@@ -1967,17 +2609,24 @@ func (m *MysqlMiddleware) AddOrUpdateKarmaRule(rule models.KarmaRule) (err error
 		return
 	}
 
+	channels := strings.Join(rule.Channels, ";")
+	roles := strings.Join(rule.Roles, ";")
+
 	if exists {
 		_, err = m.Db.Exec("UPDATE karmaRules "+
-			"SET `trigger` = ?, value = ?, action = ?, argument = ?, checksum = ? "+
+			"SET `trigger` = ?, value = ?, action = ?, argument = ?, checksum = ?, "+
+			"`maxValue` = ?, channelID = ?, duration = ?, cooldown = ?, channels = ?, roles = ? "+
 			"WHERE guildID = ? AND id = ?",
 			rule.Trigger, rule.Value, rule.Action, rule.Argument, rule.Checksum,
+			rule.MaxValue, rule.ChannelID, rule.Duration, rule.Cooldown, channels, roles,
 			rule.GuildID, rule.ID)
 	} else {
 		_, err = m.Db.Exec("INSERT INTO karmaRules "+
-			"(id, guildID, `trigger`, value, action, argument, checksum) "+
-			"VALUES (?, ?, ?, ?, ?, ?, ?)",
-			rule.ID, rule.GuildID, rule.Trigger, rule.Value, rule.Action, rule.Argument, rule.Checksum)
+			"(id, guildID, `trigger`, value, action, argument, checksum, "+
+			"`maxValue`, channelID, duration, cooldown, channels, roles) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			rule.ID, rule.GuildID, rule.Trigger, rule.Value, rule.Action, rule.Argument, rule.Checksum,
+			rule.MaxValue, rule.ChannelID, rule.Duration, rule.Cooldown, channels, roles)
 	}
 
 	return
@@ -1989,6 +2638,120 @@ func (m *MysqlMiddleware) RemoveKarmaRule(guildID string, id snowflake.ID) (err
 	return
 }
 
+func (m *MysqlMiddleware) SetKarmaTempRole(tr models.KarmaTempRole) error {
+	_, err := m.Db.Exec("DELETE FROM karmaTempRoles WHERE guildID = ? AND userID = ? AND roleID = ?",
+		tr.GuildID, tr.UserID, tr.RoleID)
+	if err != nil {
+		return err
+	}
+	_, err = m.Db.Exec("INSERT INTO karmaTempRoles (guildID, userID, roleID, expires) VALUES (?, ?, ?, ?)",
+		tr.GuildID, tr.UserID, tr.RoleID, tr.Expires)
+	return err
+}
+
+func (m *MysqlMiddleware) GetExpiredKarmaTempRoles(now time.Time) ([]models.KarmaTempRole, error) {
+	rows, err := m.Db.Query("SELECT guildID, userID, roleID, expires FROM karmaTempRoles WHERE expires <= ?",
+		now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]models.KarmaTempRole, 0)
+	for rows.Next() {
+		var tr models.KarmaTempRole
+		if err = rows.Scan(&tr.GuildID, &tr.UserID, &tr.RoleID, &tr.Expires); err != nil {
+			return nil, err
+		}
+		res = append(res, tr)
+	}
+	return res, nil
+}
+
+func (m *MysqlMiddleware) RemoveKarmaTempRole(guildID, userID, roleID string) error {
+	_, err := m.Db.Exec("DELETE FROM karmaTempRoles WHERE guildID = ? AND userID = ? AND roleID = ?",
+		guildID, userID, roleID)
+	return err
+}
+
+func (m *MysqlMiddleware) AddKarmaLedgerEntry(entry models.KarmaLedgerEntry) error {
+	_, err := m.Db.Exec(`
+		INSERT INTO karmaLedger (id, guildID, executorID, targetID, delta, source, timestamp, revertedBy)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.GuildID, entry.ExecutorID, entry.TargetID, entry.Delta, entry.Source, entry.Timestamp, entry.RevertedBy)
+	return err
+}
+
+func (m *MysqlMiddleware) GetKarmaLedger(guildID, userID string, offset, limit int) ([]models.KarmaLedgerEntry, error) {
+	rows, err := m.Db.Query(`
+		SELECT id, guildID, executorID, targetID, delta, source, timestamp, revertedBy
+		FROM karmaLedger
+		WHERE guildID = ? AND (? = '' OR targetID = ? OR executorID = ?)
+		ORDER BY timestamp DESC
+		LIMIT ? OFFSET ?`,
+		guildID, userID, userID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanKarmaLedger(rows)
+}
+
+func (m *MysqlMiddleware) GetKarmaLedgerGiven(guildID, executorID string, from, to time.Time) ([]models.KarmaLedgerEntry, error) {
+	rows, err := m.Db.Query(`
+		SELECT id, guildID, executorID, targetID, delta, source, timestamp, revertedBy
+		FROM karmaLedger
+		WHERE guildID = ? AND executorID = ? AND revertedBy = '0'
+		AND timestamp >= ? AND timestamp <= ?
+		ORDER BY timestamp ASC`,
+		guildID, executorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return scanKarmaLedger(rows)
+}
+
+func (m *MysqlMiddleware) SetKarmaLedgerReverted(id, revertedBy snowflake.ID) error {
+	_, err := m.Db.Exec("UPDATE karmaLedger SET revertedBy = ? WHERE id = ?",
+		revertedBy, id)
+	return err
+}
+
+func (m *MysqlMiddleware) GetKarmaLedgerPairCount(guildID, executorID, targetID string, since time.Time) (count int, err error) {
+	err = m.Db.QueryRow(`
+		SELECT COUNT(*)
+		FROM karmaLedger
+		WHERE guildID = ? AND executorID = ? AND targetID = ?
+		AND source = 'REACTION' AND revertedBy = '0' AND timestamp >= ?`,
+		guildID, executorID, targetID, since).Scan(&count)
+	return
+}
+
+func (m *MysqlMiddleware) GetKarmaLeaderboard(guildID string, since time.Time, limit int) ([]models.GuildKarma, error) {
+	rows, err := m.Db.Query(`
+		SELECT targetID, SUM(delta) AS total
+		FROM karmaLedger
+		WHERE guildID = ? AND timestamp >= ? AND revertedBy = '0'
+		AND id NOT IN (SELECT revertedBy FROM karmaLedger WHERE guildID = ? AND revertedBy != '0')
+		GROUP BY targetID
+		ORDER BY total DESC
+		LIMIT ?`,
+		guildID, since, guildID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]models.GuildKarma, 0)
+	for rows.Next() {
+		v := models.GuildKarma{GuildID: guildID}
+		if err = rows.Scan(&v.UserID, &v.Value); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
 func (m *MysqlMiddleware) GetGuildLogDisable(guildID string) (bool, error) {
 	val, err := m.getGuildSetting(guildID, "guildlogDisable")
 	return val == "1", err
@@ -2066,6 +2829,32 @@ func (m *MysqlMiddleware) DeleteLogEntries(guildID string) (err error) {
 	return
 }
 
+func (m *MysqlMiddleware) GetAllGuildIDs() (guildIDs []string, err error) {
+	tables := append(slices.Clone(guildTables), "roleselect")
+	queries := make([]string, len(tables))
+	for i, table := range tables {
+		queries[i] = "SELECT guildID FROM " + table
+	}
+
+	rows, err := m.Db.Query(strings.Join(queries, " UNION "))
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	guildIDs = make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+		guildIDs = append(guildIDs, id)
+	}
+
+	err = rows.Err()
+	return
+}
+
 func (m *MysqlMiddleware) FlushGuildData(guildID string) (err error) {
 	tx, err := m.Db.Begin()
 	if err != nil {
@@ -2230,6 +3019,20 @@ func (m *MysqlMiddleware) FlushUserData(userID string) (res map[string]int, err
 	}
 	res["reports"] = int(affected)
 
+	r, err = m.Db.Exec(`
+		UPDATE reportRevisions
+		SET editorID = "000000000000000000"
+		WHERE editorID = ?
+	`, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	affected, err = r.RowsAffected()
+	if err != nil {
+		return
+	}
+	res["reportRevisions"] = int(affected)
+
 	r, err = m.Db.Exec(`
 		DELETE FROM karma
 		WHERE userID = ?
@@ -2244,6 +3047,38 @@ func (m *MysqlMiddleware) FlushUserData(userID string) (res map[string]int, err
 	}
 	res["karma"] = int(affected)
 
+	r, err = m.Db.Exec(`
+		UPDATE karmaLedger
+		SET executorID = "000000000000000000"
+		WHERE executorID = ?
+	`, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	affected, err = r.RowsAffected()
+	if err != nil {
+		return
+	}
+	res["karmaLedger"] = int(affected)
+
+	r, err = m.Db.Exec(`
+		DELETE FROM karmaLedger
+		WHERE targetID = ?
+		AND NOT EXISTS (
+			SELECT 1 FROM karma
+			WHERE karma.userID = karmaLedger.targetID
+			AND karma.guildID = karmaLedger.guildID
+		)
+	`, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	affected, err = r.RowsAffected()
+	if err != nil {
+		return
+	}
+	res["karmaLedger"] += int(affected)
+
 	for _, tc := range userTables {
 		r, err = m.Db.Exec(fmt.Sprintf(`
 			DELETE FROM %s
@@ -2272,8 +3107,24 @@ func (m *MysqlMiddleware) SetGuildBirthdayChan(guildID string, chanID string) (e
 	return
 }
 
+func (m *MysqlMiddleware) GetGuildBirthdayTemplate(guildID string) (string, error) {
+	return m.getGuildSetting(guildID, "birthdayTemplate")
+}
+
+func (m *MysqlMiddleware) SetGuildBirthdayTemplate(guildID string, template string) error {
+	return m.setGuildSetting(guildID, "birthdayTemplate", template)
+}
+
+func (m *MysqlMiddleware) GetGuildBirthdayRole(guildID string) (string, error) {
+	return m.getGuildSetting(guildID, "birthdayRoleID")
+}
+
+func (m *MysqlMiddleware) SetGuildBirthdayRole(guildID string, roleID string) error {
+	return m.setGuildSetting(guildID, "birthdayRoleID", roleID)
+}
+
 func (m *MysqlMiddleware) GetBirthdays(guildID string) (bd []models.Birthday, err error) {
-	query := "SELECT guildID, userID, `date`, showYear FROM birthdays"
+	query := "SELECT guildID, userID, `date`, showYear, lastNotified, roleExpires FROM birthdays"
 	var params []interface{}
 
 	if guildID != "" {
@@ -2288,11 +3139,16 @@ func (m *MysqlMiddleware) GetBirthdays(guildID string) (bd []models.Birthday, er
 	}
 
 	for rows.Next() {
-		var b models.Birthday
-		err = rows.Scan(&b.GuildID, &b.UserID, &b.Date, &b.ShowYear)
+		var (
+			b                         models.Birthday
+			lastNotified, roleExpires int64
+		)
+		err = rows.Scan(&b.GuildID, &b.UserID, &b.Date, &b.ShowYear, &lastNotified, &roleExpires)
 		if err != nil {
 			return
 		}
+		b.LastNotified = fromUnix(lastNotified)
+		b.RoleExpires = fromUnix(roleExpires)
 		bd = append(bd, b)
 	}
 
@@ -2301,17 +3157,18 @@ func (m *MysqlMiddleware) GetBirthdays(guildID string) (bd []models.Birthday, er
 
 func (m *MysqlMiddleware) SetBirthday(bd models.Birthday) (err error) {
 	res, err := m.Db.Exec(
-		"UPDATE birthdays SET `date` = ?, showYear = ? "+
+		"UPDATE birthdays SET `date` = ?, showYear = ?, lastNotified = ?, roleExpires = ? "+
 			"WHERE guildID = ? AND userID = ?",
-		bd.Date, bd.ShowYear, bd.GuildID, bd.UserID)
+		bd.Date, bd.ShowYear, toUnix(bd.LastNotified), toUnix(bd.RoleExpires), bd.GuildID, bd.UserID)
 	if err != nil {
 		return wrapNotFoundError(err)
 	}
 	ar, err := res.RowsAffected()
 	if ar == 0 {
 		_, err = m.Db.Exec(
-			"INSERT INTO birthdays (guildID, userID, `date`, showYear) "+
-				"VALUES (?, ?, ?, ?)", bd.GuildID, bd.UserID, bd.Date, bd.ShowYear)
+			"INSERT INTO birthdays (guildID, userID, `date`, showYear, lastNotified, roleExpires) "+
+				"VALUES (?, ?, ?, ?, ?, ?)", bd.GuildID, bd.UserID, bd.Date, bd.ShowYear,
+			toUnix(bd.LastNotified), toUnix(bd.RoleExpires))
 	}
 	return wrapNotFoundError(err)
 }
@@ -2330,9 +3187,10 @@ func (m *MysqlMiddleware) AddRoleSelects(v []models.RoleSelect) error {
 
 	for _, rs := range v {
 		_, err = m.Db.Exec(`
-			INSERT INTO roleselect (guildID, channelID, messageID, roleID)
-			VALUES (?, ?, ?, ?)
-		`, rs.GuildID, rs.ChannelID, rs.MessageID, rs.RoleID)
+			INSERT INTO roleselect (guildID, channelID, messageID, roleID, label, emoji, position, mode, maxRoles, requiredRoles, menu)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, rs.GuildID, rs.ChannelID, rs.MessageID, rs.RoleID, rs.Label, rs.Emoji, rs.Position,
+			rs.Mode, rs.MaxRoles, strings.Join(rs.RequiredRoles, ";"), rs.Menu)
 		if err != nil {
 			if mErr, ok := err.(*mySqlDriver.MySQLError); ok && mErr.Number == 1062 {
 				continue
@@ -2349,17 +3207,40 @@ func (m *MysqlMiddleware) AddRoleSelects(v []models.RoleSelect) error {
 
 func (m *MysqlMiddleware) GetRoleSelects() ([]models.RoleSelect, error) {
 	rows, err := m.Db.Query(`
-		SELECT guildID, channelID, messageID, roleID
+		SELECT guildID, channelID, messageID, roleID, label, emoji, position, mode, maxRoles, requiredRoles, menu
 		FROM roleselect
 	`)
 	if err != nil {
 		return nil, wrapNotFoundError(err)
 	}
+	defer rows.Close()
+
+	var rs []models.RoleSelect
+	for rows.Next() {
+		r, err := scanRoleSelect(rows)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+
+	return rs, nil
+}
+
+func (m *MysqlMiddleware) GetGuildRoleSelects(guildID string) ([]models.RoleSelect, error) {
+	rows, err := m.Db.Query(`
+		SELECT guildID, channelID, messageID, roleID, label, emoji, position, mode, maxRoles, requiredRoles, menu
+		FROM roleselect
+		WHERE guildID = ?
+	`, guildID)
+	if err != nil {
+		return nil, wrapNotFoundError(err)
+	}
+	defer rows.Close()
 
 	var rs []models.RoleSelect
 	for rows.Next() {
-		var r models.RoleSelect
-		err = rows.Scan(&r.GuildID, &r.ChannelID, &r.MessageID, &r.RoleID)
+		r, err := scanRoleSelect(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -2369,6 +3250,36 @@ func (m *MysqlMiddleware) GetRoleSelects() ([]models.RoleSelect, error) {
 	return rs, nil
 }
 
+func (m *MysqlMiddleware) SetRoleSelects(guildID, channelID, messageID string, v []models.RoleSelect) error {
+	tx, err := m.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM roleselect
+		WHERE guildID = ? AND channelID = ? AND messageID = ?
+	`, guildID, channelID, messageID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, rs := range v {
+		_, err = tx.Exec(`
+			INSERT INTO roleselect (guildID, channelID, messageID, roleID, label, emoji, position, mode, maxRoles, requiredRoles, menu)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, rs.GuildID, rs.ChannelID, rs.MessageID, rs.RoleID, rs.Label, rs.Emoji, rs.Position,
+			rs.Mode, rs.MaxRoles, strings.Join(rs.RequiredRoles, ";"), rs.Menu)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (m *MysqlMiddleware) RemoveRoleSelect(guildID, channelID, messageID string) error {
 	_, err := m.Db.Exec(`
 		DELETE FROM roleselect
@@ -2394,3 +3305,93 @@ func wrapNotFoundError(err error) error {
 	}
 	return err
 }
+
+func scanKarmaLedger(rows *sql.Rows) ([]models.KarmaLedgerEntry, error) {
+	defer rows.Close()
+
+	res := make([]models.KarmaLedgerEntry, 0)
+	for rows.Next() {
+		var e models.KarmaLedgerEntry
+		err := rows.Scan(&e.ID, &e.GuildID, &e.ExecutorID, &e.TargetID,
+			&e.Delta, &e.Source, &e.Timestamp, &e.RevertedBy)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+// splitIDs splits a list of IDs joined by ";".
+func splitIDs(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ";")
+}
+
+// scanStarboardConfig scans a starboard config from a
+// single row or the current row of a result set.
+func scanStarboardConfig(row interface{ Scan(...interface{}) error }) (config models.StarboardConfig, err error) {
+	var channels, excludedChannels string
+	err = row.Scan(&config.GuildID, &config.Name, &config.ChannelID, &config.Threshold,
+		&config.EmojiID, &config.KarmaGain, &channels, &excludedChannels,
+		&config.NSFW, &config.NSFWChannelID, &config.MinAge)
+	if err != nil {
+		return
+	}
+	config.Channels = splitIDs(channels)
+	config.ExcludedChannels = splitIDs(excludedChannels)
+	return
+}
+
+// scanTag scans a tag from a single row or
+// the current row of a result set.
+func scanTag(row interface{ Scan(...interface{}) error }) (tg tag.Tag, err error) {
+	var (
+		timestampCreated  int64
+		timestampLastEdit int64
+		aliases, roles    string
+	)
+	err = row.Scan(&tg.ID, &tg.Ident, &tg.CreatorID, &tg.GuildID, &tg.Content,
+		&timestampCreated, &timestampLastEdit, &aliases, &roles, &tg.Uses)
+	if err != nil {
+		return
+	}
+
+	tg.Created = time.Unix(timestampCreated, 0)
+	tg.LastEdit = time.Unix(timestampLastEdit, 0)
+	tg.Aliases = splitIDs(aliases)
+	tg.Roles = splitIDs(roles)
+	return
+}
+
+func scanRoleSelect(row interface{ Scan(...interface{}) error }) (r models.RoleSelect, err error) {
+	var requiredRoles string
+	err = row.Scan(&r.GuildID, &r.ChannelID, &r.MessageID, &r.RoleID, &r.Label, &r.Emoji,
+		&r.Position, &r.Mode, &r.MaxRoles, &requiredRoles, &r.Menu)
+	if err != nil {
+		return
+	}
+
+	r.RequiredRoles = splitIDs(requiredRoles)
+	return
+}
+
+// toUnix returns the unix timestamp of t in
+// seconds or 0 if t is the zero time.
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// fromUnix returns the time of the unix timestamp
+// v in seconds or the zero time if v is 0.
+func fromUnix(v int64) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(v, 0)
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/util/embedded"
+)
+
+type migrationFunc func(*sql.Tx) error
+
+type migration struct {
+	Version       int
+	Applied       time.Time
+	ReleaseTag    string
+	ReleaseCommit string
+}
+
+func (m *PostgresMiddleware) Migrate() (err error) {
+	mig, err := m.getLatestMigration()
+	if err == sql.ErrNoRows {
+		mig = &migration{
+			Version: -1,
+		}
+	} else if err != nil {
+		return err
+	}
+
+	tx, err := m.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := mig.Version + 1; i < len(migrationFuncs); i++ {
+		m.log.Info().Field("version", i).Msg("Applying migration ...")
+		if err = migrationFuncs[i](tx); err != nil {
+			return err
+		}
+		if err = putMigrationVersion(tx, i); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *PostgresMiddleware) getLatestMigration() (mig *migration, err error) {
+	mig = new(migration)
+	row := m.Db.QueryRow(
+		`SELECT version, applied, releaseTag, releaseCommit
+		FROM migrations
+		ORDER BY version DESC
+		LIMIT 1`)
+	err = row.Scan(&mig.Version, &mig.Applied, &mig.ReleaseTag, &mig.ReleaseCommit)
+	return
+}
+
+func putMigrationVersion(tx *sql.Tx, i int) (err error) {
+	_, err = tx.Exec(
+		`INSERT INTO migrations (version, applied, releaseTag, releaseCommit)
+		VALUES ($1, $2, $3, $4)`,
+		i, time.Now(), embedded.AppVersion, embedded.AppCommit)
+	return
+}
+
+// --- UTILITIES ---
+
+func createTableColumnIfNotExists(m *sql.Tx, table, definition string) (err error) {
+	_, err = m.Exec(
+		"ALTER TABLE " + table +
+			" ADD COLUMN IF NOT EXISTS " + definition)
+	return err
+}
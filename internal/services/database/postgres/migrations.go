@@ -0,0 +1,363 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// The migration chain is kept in sync with the
+// versions of the MySQL driver so that both
+// drivers share the same schema revision numbers.
+var migrationFuncs = []migrationFunc{
+	migration_0,
+	migration_1,
+	migration_2,
+	migration_3,
+	migration_4,
+	migration_5,
+	migration_6,
+	migration_7,
+	migration_8,
+	migration_9,
+	migration_10,
+	migration_11,
+	migration_12,
+	migration_13,
+	migration_14,
+	migration_15,
+	migration_16,
+	migration_17,
+	migration_18,
+	migration_19,
+	migration_20,
+	migration_21,
+	migration_22,
+	migration_23,
+	migration_24,
+	migration_25,
+	migration_26,
+	migration_27,
+	migration_28,
+}
+
+// VERSION 0:
+// - base state
+func migration_0(m *sql.Tx) (err error) {
+	return
+}
+
+// VERSION 1:
+// - add property `deleted` to `starboardEntries`
+func migration_1(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"starboardEntries", "deleted boolean NOT NULL DEFAULT false")
+}
+
+// VERSION 2:
+// - add property `karmaGain` to `starboardConfig`
+func migration_2(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"starboardConfig", "karmaGain integer NOT NULL DEFAULT 3")
+}
+
+// VERSION 3:
+// - add property `guildlog` to `guilds`
+func migration_3(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "guildlogDisable text NOT NULL DEFAULT '0'")
+}
+
+// VERSION 4:
+// - add property `penalty` to `karmaSettings`
+func migration_4(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"karmaSettings", "penalty boolean NOT NULL DEFAULT false")
+}
+
+// VERSION 5:
+// - add property `timeout` to `reports`
+func migration_5(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"reports", "timeout timestamptz NULL DEFAULT NULL")
+}
+
+// VERSION 6:
+// - add serial primary key `iid` to `antiraidJoinlog`
+func migration_6(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"antiraidJoinlog", "iid serial PRIMARY KEY")
+}
+
+// VERSION 7:
+// - add property `accountCreated` to `antiraidJoinlog`
+func migration_7(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"antiraidJoinlog", "accountCreated timestamptz NOT NULL DEFAULT 'epoch'")
+}
+
+// VERSION 8:
+// - add property `verified` to `users`
+// - add property `requireUserVerification` to `guilds`
+func migration_8(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"users", "verified text NOT NULL DEFAULT '0'")
+	if err != nil {
+		return
+	}
+	err = createTableColumnIfNotExists(m,
+		"guilds", "requireUserVerification text NOT NULL DEFAULT ''")
+	if err != nil {
+		return
+	}
+	err = createTableColumnIfNotExists(m,
+		"antiraidSettings", "verification boolean NOT NULL DEFAULT false")
+	return
+}
+
+// VERSION 9:
+// - add property `codeExecEnabled` to `guilds`
+// - add property `starboardOptout` to `users`
+func migration_9(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"guilds", "codeExecEnabled text NOT NULL DEFAULT ''")
+	err2 := createTableColumnIfNotExists(m,
+		"users", "starboardOptout text NOT NULL DEFAULT '0'")
+	return errors.Join(err1, err2)
+}
+
+// VERSION 10:
+// - add property `birthdaychanID` to `guilds`
+func migration_10(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"guilds", "birthdaychanID text NOT NULL DEFAULT ''")
+	return
+}
+
+// VERSION 11:
+// - add property `autovc` to `guilds`
+func migration_11(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"guilds", "autovc text NOT NULL DEFAULT ''")
+	return
+}
+
+// VERSION 12:
+// - add relation `reportID` to `unbanRequests`
+func migration_12(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"unbanRequests", "reportID varchar(25) NOT NULL REFERENCES reports(id)")
+}
+
+// VERSION 13:
+// - add property `modnotchanID` to `guilds`
+func migration_13(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "modnotchanID varchar(25) NOT NULL DEFAULT ''")
+}
+
+// VERSION 14:
+// - add property `backupRetention` to `guilds`
+// - add properties `parentID` and `changes` to `backups`
+func migration_14(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"guilds", "backupRetention text NOT NULL DEFAULT ''")
+	err2 := createTableColumnIfNotExists(m,
+		"backups", "parentID text NOT NULL DEFAULT ''")
+	err3 := createTableColumnIfNotExists(m,
+		"backups", "changes integer NOT NULL DEFAULT 0")
+	return errors.Join(err1, err2, err3)
+}
+
+// VERSION 15:
+// - add property `escalationLadder` to `guilds`
+// - add property `linkedID` to `reports`
+func migration_15(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"guilds", "escalationLadder text NOT NULL DEFAULT ''")
+	err2 := createTableColumnIfNotExists(m,
+		"reports", "linkedID bigint NOT NULL DEFAULT 0")
+	return errors.Join(err1, err2)
+}
+
+// VERSION 16:
+// - add properties `decayAfter` and `decayAmount` to `karmaSettings`
+// - add properties `pairLimit`, `pairWindow` and
+// `sockPuppetThreshold` to `karmaSettings`
+func migration_16(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"karmaSettings", "decayAfter bigint NOT NULL DEFAULT 0")
+	err2 := createTableColumnIfNotExists(m,
+		"karmaSettings", "decayAmount bigint NOT NULL DEFAULT 0")
+	err3 := createTableColumnIfNotExists(m,
+		"karmaSettings", "pairLimit bigint NOT NULL DEFAULT 0")
+	err4 := createTableColumnIfNotExists(m,
+		"karmaSettings", "pairWindow bigint NOT NULL DEFAULT 0")
+	err5 := createTableColumnIfNotExists(m,
+		"karmaSettings", "sockPuppetThreshold bigint NOT NULL DEFAULT 0")
+	return errors.Join(err1, err2, err3, err4, err5)
+}
+
+// VERSION 17:
+// - add properties `maxValue`, `channelID`, `duration`, `cooldown`,
+// `channels` and `roles` to `karmaRules`
+func migration_17(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"karmaRules", "maxValue integer NOT NULL DEFAULT 0")
+	err2 := createTableColumnIfNotExists(m,
+		"karmaRules", "channelID varchar(25) NOT NULL DEFAULT ''")
+	err3 := createTableColumnIfNotExists(m,
+		"karmaRules", "duration bigint NOT NULL DEFAULT 0")
+	err4 := createTableColumnIfNotExists(m,
+		"karmaRules", "cooldown bigint NOT NULL DEFAULT 0")
+	err5 := createTableColumnIfNotExists(m,
+		"karmaRules", "channels text NOT NULL DEFAULT ''")
+	err6 := createTableColumnIfNotExists(m,
+		"karmaRules", "roles text NOT NULL DEFAULT ''")
+	return errors.Join(err1, err2, err3, err4, err5, err6)
+}
+
+// VERSION 18:
+// - add property `board` to `starboardEntries`
+// - move the starboard config of each guild from `starboardConfig`
+// to the `default` board in `starboards`
+func migration_18(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"starboardEntries", "board varchar(32) NOT NULL DEFAULT ''")
+	if err != nil {
+		return
+	}
+
+	_, err = m.Exec(
+		"INSERT INTO starboards (guildID, name, channelID, threshold, emojiID, karmaGain) " +
+			"SELECT guildID, 'default', channelID, threshold, emojiID, karmaGain " +
+			"FROM starboardConfig WHERE channelID != '' " +
+			"ON CONFLICT DO NOTHING")
+	if err != nil {
+		return
+	}
+
+	_, err = m.Exec("UPDATE starboardEntries SET board = 'default' WHERE board = ''")
+	return
+}
+
+// VERSION 19:
+// - add properties `aliases`, `roles` and `uses` to `tags`
+func migration_19(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"tags", "aliases text NOT NULL DEFAULT ''")
+	err2 := createTableColumnIfNotExists(m,
+		"tags", "roles text NOT NULL DEFAULT ''")
+	err3 := createTableColumnIfNotExists(m,
+		"tags", "uses bigint NOT NULL DEFAULT 0")
+	return errors.Join(err1, err2, err3)
+}
+
+// VERSION 20:
+// - add properties `guildID`, `expires` and `closed` to `votes`
+func migration_20(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"votes", "guildID varchar(25) NOT NULL DEFAULT ''")
+	err2 := createTableColumnIfNotExists(m,
+		"votes", "expires bigint NOT NULL DEFAULT 0")
+	err3 := createTableColumnIfNotExists(m,
+		"votes", "closed boolean NOT NULL DEFAULT false")
+	return errors.Join(err1, err2, err3)
+}
+
+// VERSION 21:
+// - add properties `label`, `emoji`, `position`, `mode`,
+// `maxRoles`, `requiredRoles` and `menu` to `roleselect`
+func migration_21(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"roleselect", "label text NOT NULL DEFAULT ''")
+	err2 := createTableColumnIfNotExists(m,
+		"roleselect", "emoji text NOT NULL DEFAULT ''")
+	err3 := createTableColumnIfNotExists(m,
+		"roleselect", "position integer NOT NULL DEFAULT 0")
+	err4 := createTableColumnIfNotExists(m,
+		"roleselect", "mode integer NOT NULL DEFAULT 0")
+	err5 := createTableColumnIfNotExists(m,
+		"roleselect", "maxRoles integer NOT NULL DEFAULT 0")
+	err6 := createTableColumnIfNotExists(m,
+		"roleselect", "requiredRoles text NOT NULL DEFAULT ''")
+	err7 := createTableColumnIfNotExists(m,
+		"roleselect", "menu boolean NOT NULL DEFAULT false")
+	return errors.Join(err1, err2, err3, err4, err5, err6, err7)
+}
+
+// VERSION 22:
+// - add properties `birthdayTemplate` and `birthdayRoleID` to `guilds`
+// - add property `timezone` to `users`
+// - add properties `lastNotified` and `roleExpires` to `birthdays`
+func migration_22(m *sql.Tx) (err error) {
+	err1 := createTableColumnIfNotExists(m,
+		"guilds", "birthdayTemplate text NOT NULL DEFAULT ''")
+	err2 := createTableColumnIfNotExists(m,
+		"guilds", "birthdayRoleID text NOT NULL DEFAULT ''")
+	err3 := createTableColumnIfNotExists(m,
+		"users", "timezone text NOT NULL DEFAULT ''")
+	err4 := createTableColumnIfNotExists(m,
+		"birthdays", "lastNotified bigint NOT NULL DEFAULT 0")
+	err5 := createTableColumnIfNotExists(m,
+		"birthdays", "roleExpires bigint NOT NULL DEFAULT 0")
+	return errors.Join(err1, err2, err3, err4, err5)
+}
+
+// VERSION 23:
+// - add property `antiraidSignals` to `guilds`
+func migration_23(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "antiraidSignals text NOT NULL DEFAULT ''")
+}
+
+// VERSION 24:
+// - add property `antiraidLockdown` to `guilds`
+func migration_24(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "antiraidLockdown text NOT NULL DEFAULT ''")
+}
+
+// VERSION 25:
+// - add property `inviteBlockSettings` to `guilds`
+func migration_25(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "inviteBlockSettings text NOT NULL DEFAULT ''")
+}
+
+// VERSION 26:
+// - add property `automodRules` to `guilds`
+func migration_26(m *sql.Tx) (err error) {
+	return createTableColumnIfNotExists(m,
+		"guilds", "automodRules text NOT NULL DEFAULT ''")
+}
+
+// VERSION 27:
+// - key `starboardEntries` by `messageID` and `board`
+func migration_27(m *sql.Tx) (err error) {
+	_, err = m.Exec(
+		"ALTER TABLE starboardEntries DROP CONSTRAINT IF EXISTS starboardentries_pkey, " +
+			"ADD PRIMARY KEY (messageID, board)")
+	return
+}
+
+// VERSION 28:
+// - add property `decayEnabledAt` to `karmaSettings`
+//
+// Guilds which already have enabled decay are treated
+// as if decay has been enabled with this migration, so
+// that members with karma from before the karma ledger
+// existed do not decay immediately.
+func migration_28(m *sql.Tx) (err error) {
+	err = createTableColumnIfNotExists(m,
+		"karmaSettings", "decayEnabledAt bigint NOT NULL DEFAULT 0")
+	if err != nil {
+		return
+	}
+
+	_, err = m.Exec(
+		"UPDATE karmaSettings SET decayEnabledAt = $1 "+
+			"WHERE decayAfter > 0 AND decayAmount > 0 AND decayEnabledAt = 0",
+		time.Now().Unix())
+	return
+}
@@ -0,0 +1,3409 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/tag"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+	"github.com/zekroTJA/shinpuru/pkg/multierror"
+	"github.com/zekroTJA/shinpuru/pkg/permissions"
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
+	"github.com/zekroTJA/shinpuru/pkg/twitchnotify"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
+
+	"github.com/bwmarrin/snowflake"
+	"golang.org/x/exp/slices"
+	_ "github.com/lib/pq"
+)
+
+// PostgresMiddleware implements the Database interface for
+// PostgreSQL.
+type PostgresMiddleware struct {
+	Db  *sql.DB
+	log rogu.Logger
+}
+
+var _ database.Database = (*PostgresMiddleware)(nil)
+
+func New() *PostgresMiddleware {
+	return &PostgresMiddleware{
+		log: log.Tagged("Database"),
+	}
+}
+
+var guildTables = []string{
+	"antiraidJoinlog",
+	"antiraidLockdowns",
+	"antiraidSettings",
+	"backups",
+	"backupKeys",
+	"backupRestores",
+	"chanlock",
+	"guildapi",
+	"guildlog",
+	"guilds",
+	"karma",
+	"karmaBlocklist",
+	"karmaLedger",
+	"karmaRules",
+	"karmaSettings",
+	"karmaTempRoles",
+	"permissions",
+	"reports",
+	"reportRevisions",
+	"starboardConfig",
+	"starboardEntries",
+	"starboards",
+	"tags",
+	"twitchnotify",
+	"unbanRequests",
+	"verificationQueue",
+	"voicelogBlocklist",
+	"birthdays",
+}
+
+type tableColumn struct {
+	Table  string
+	Column string
+}
+
+var userTables = []tableColumn{
+	{"antiraidJoinlog", "userID"},
+	{"apitokens", "userID"},
+	{"refreshTokens", "userID"},
+	{"starboardEntries", "authorID"},
+	{"tags", "creatorID"},
+	{"unbanRequests", "userID"},
+	{"unbanRequests", "processedBy"},
+	{"users", "userID"},
+	{"birthdays", "userID"},
+	{"karmaTempRoles", "userID"},
+}
+
+func (m *PostgresMiddleware) setup() (err error) {
+	if err = m.Status(); err != nil {
+		return
+	}
+
+	tx, err := m.Db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS migrations (
+		version integer NOT NULL DEFAULT 0,
+		applied timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		releaseTag text NOT NULL DEFAULT '',
+		releaseCommit text NOT NULL DEFAULT '',
+		PRIMARY KEY (version)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS guilds (
+		guildID varchar(25) NOT NULL,
+		prefix text NOT NULL DEFAULT '',
+		autorole text NOT NULL DEFAULT '',
+		autovc text NOT NULL DEFAULT '',
+		modlogchanID text NOT NULL DEFAULT '',
+		voicelogchanID text NOT NULL DEFAULT '',
+		notifyRoleID text NOT NULL DEFAULT '',
+		ghostPingMsg text NOT NULL DEFAULT '',
+		jdoodleToken text NOT NULL DEFAULT '',
+		codeExecEnabled text NOT NULL DEFAULT '',
+		backup text NOT NULL DEFAULT '',
+		inviteBlock text NOT NULL DEFAULT '',
+		joinMsg text NOT NULL DEFAULT '',
+		leaveMsg text NOT NULL DEFAULT '',
+		colorReaction text NOT NULL DEFAULT '',
+		guildlogDisable text NOT NULL DEFAULT '',
+		requireUserVerification text NOT NULL DEFAULT '',
+		birthdaychanID text NOT NULL DEFAULT '',
+		birthdayTemplate text NOT NULL DEFAULT '',
+		birthdayRoleID text NOT NULL DEFAULT '',
+		modnotchanID varchar(25) NOT NULL DEFAULT '',
+		backupRetention text NOT NULL DEFAULT '',
+		escalationLadder text NOT NULL DEFAULT '',
+		antiraidSignals text NOT NULL DEFAULT '',
+		antiraidLockdown text NOT NULL DEFAULT '',
+		inviteBlockSettings text NOT NULL DEFAULT '',
+		automodRules text NOT NULL DEFAULT '',
+		PRIMARY KEY (guildID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS users (
+		userID varchar(25) NOT NULL,
+		enableOTA text NOT NULL DEFAULT '0',
+		verified text NOT NULL DEFAULT '0',
+		starboardOptout text NOT NULL DEFAULT '0',
+		timezone text NOT NULL DEFAULT '',
+		PRIMARY KEY (userID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS permissions (
+		roleID varchar(25) NOT NULL,
+		guildID text NOT NULL DEFAULT '',
+		permission text NOT NULL DEFAULT '',
+		PRIMARY KEY (roleID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS reports (
+		id varchar(25) NOT NULL,
+		type integer NOT NULL DEFAULT 0,
+		guildID text NOT NULL DEFAULT '',
+		executorID text NOT NULL DEFAULT '',
+		victimID text NOT NULL DEFAULT '',
+		msg text NOT NULL DEFAULT '',
+		attachment text NOT NULL DEFAULT '',
+		timeout timestamptz NULL DEFAULT NULL,
+		linkedID bigint NOT NULL DEFAULT 0,
+		PRIMARY KEY (id)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS reportRevisions (
+		reportID varchar(25) NOT NULL,
+		version integer NOT NULL DEFAULT 0,
+		guildID varchar(25) NOT NULL DEFAULT '',
+		editorID varchar(25) NOT NULL DEFAULT '',
+		timestamp timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		msg text NOT NULL DEFAULT '',
+		attachment text NOT NULL DEFAULT '',
+		timeout timestamptz NULL DEFAULT NULL,
+		PRIMARY KEY (reportID, version)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS settings (
+		iid serial NOT NULL,
+		setting text NOT NULL DEFAULT '',
+		value text NOT NULL DEFAULT '',
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS votes (
+		id varchar(25) NOT NULL,
+		data text NOT NULL DEFAULT '',
+		guildID varchar(25) NOT NULL DEFAULT '',
+		expires bigint NOT NULL DEFAULT 0,
+		closed boolean NOT NULL DEFAULT false,
+		PRIMARY KEY (id)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS twitchnotify (
+		iid serial NOT NULL,
+		guildID text NOT NULL DEFAULT '',
+		channelID text NOT NULL DEFAULT '',
+		twitchUserID text NOT NULL DEFAULT '',
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS backups (
+		iid serial NOT NULL,
+		guildID text NOT NULL DEFAULT '',
+		timestamp bigint NOT NULL DEFAULT 0,
+		fileID text NOT NULL DEFAULT '',
+		parentID text NOT NULL DEFAULT '',
+		changes integer NOT NULL DEFAULT 0,
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS tags (
+		id varchar(25) NOT NULL,
+		ident text NOT NULL DEFAULT '',
+		creatorID text NOT NULL DEFAULT '',
+		guildID text NOT NULL DEFAULT '',
+		content text NOT NULL DEFAULT '',
+		created bigint NOT NULL DEFAULT 0,
+		lastEdit bigint NOT NULL DEFAULT 0,
+		aliases text NOT NULL DEFAULT '',
+		roles text NOT NULL DEFAULT '',
+		uses bigint NOT NULL DEFAULT 0,
+		PRIMARY KEY (id)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS apitokens (
+		userID varchar(25) NOT NULL,
+		salt text NOT NULL,
+		created timestamptz NOT NULL,
+		expires timestamptz NOT NULL,
+		lastAccess timestamptz NOT NULL,
+		hits bigint NOT NULL,
+		PRIMARY KEY (userID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS karma (
+		iid serial NOT NULL,
+		guildID text NOT NULL DEFAULT '',
+		userID text NOT NULL DEFAULT '',
+		value bigint NOT NULL DEFAULT 0,
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS karmaSettings (
+		guildID varchar(25) NOT NULL DEFAULT '',
+		state boolean NOT NULL DEFAULT true,
+		emotesInc text NOT NULL DEFAULT '',
+		emotesDec text NOT NULL DEFAULT '',
+		tokens bigint NOT NULL DEFAULT 1,
+		penalty boolean NOT NULL DEFAULT false,
+		decayAfter bigint NOT NULL DEFAULT 0,
+		decayAmount bigint NOT NULL DEFAULT 0,
+		decayEnabledAt bigint NOT NULL DEFAULT 0,
+		pairLimit bigint NOT NULL DEFAULT 0,
+		pairWindow bigint NOT NULL DEFAULT 0,
+		sockPuppetThreshold bigint NOT NULL DEFAULT 0,
+		PRIMARY KEY (guildID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS karmaBlocklist (
+		iid serial NOT NULL,
+		userID varchar(25) NOT NULL DEFAULT '',
+		guildID varchar(25) NOT NULL DEFAULT '',
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS karmaLedger (
+		id varchar(25) NOT NULL,
+		guildID varchar(25) NOT NULL DEFAULT '',
+		executorID varchar(25) NOT NULL DEFAULT '',
+		targetID varchar(25) NOT NULL DEFAULT '',
+		delta integer NOT NULL DEFAULT 0,
+		source varchar(30) NOT NULL DEFAULT '',
+		timestamp timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		revertedBy varchar(25) NOT NULL DEFAULT '0',
+		PRIMARY KEY (id)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS karmaTempRoles (
+		guildID varchar(25) NOT NULL DEFAULT '',
+		userID varchar(25) NOT NULL DEFAULT '',
+		roleID varchar(25) NOT NULL DEFAULT '',
+		expires timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (guildID, userID, roleID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS karmaRules (
+		id varchar(25) NOT NULL,
+		guildID varchar(25) NOT NULL DEFAULT '',
+		trigger integer NOT NULL DEFAULT 0,
+		value integer NOT NULL DEFAULT 0,
+		action varchar(30) NOT NULL DEFAULT '',
+		argument text NOT NULL DEFAULT '',
+		checksum text NOT NULL DEFAULT '',
+		maxValue integer NOT NULL DEFAULT 0,
+		channelID varchar(25) NOT NULL DEFAULT '',
+		duration bigint NOT NULL DEFAULT 0,
+		cooldown bigint NOT NULL DEFAULT 0,
+		channels text NOT NULL DEFAULT '',
+		roles text NOT NULL DEFAULT '',
+		PRIMARY KEY (id)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS chanlock (
+		chanID varchar(25) NOT NULL,
+		guildID text NOT NULL DEFAULT '',
+		executorID text NOT NULL DEFAULT '',
+		permissions text NOT NULL DEFAULT '',
+		PRIMARY KEY (chanID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS antiraidSettings (
+		guildID varchar(25) NOT NULL DEFAULT '',
+		state boolean NOT NULL DEFAULT true,
+		"limit" bigint NOT NULL DEFAULT 0,
+		burst bigint NOT NULL DEFAULT 0,
+		verification boolean NOT NULL DEFAULT false,
+		PRIMARY KEY (guildID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS antiraidJoinlog (
+		iid serial NOT NULL,
+		userID varchar(25) NOT NULL DEFAULT '',
+		guildID varchar(25) NOT NULL DEFAULT '',
+		tag text NOT NULL DEFAULT '',
+		accountCreated timestamptz NOT NULL DEFAULT 'epoch',
+		timestamp timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS antiraidLockdowns (
+		guildID varchar(25) NOT NULL,
+		data text NOT NULL DEFAULT '',
+		PRIMARY KEY (guildID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS unbanRequests (
+		id varchar(25) NOT NULL DEFAULT '',
+		userID varchar(25) NOT NULL DEFAULT '',
+		guildID varchar(25) NOT NULL DEFAULT '',
+		userTag text NOT NULL DEFAULT '',
+		message text NOT NULL DEFAULT '',
+		processedBy varchar(25) NOT NULL DEFAULT '',
+		status integer NOT NULL DEFAULT 0,
+		processed timestamptz,
+		processedMessage text NOT NULL DEFAULT '',
+		reportID varchar(25) NOT NULL,
+		PRIMARY KEY (id),
+		FOREIGN KEY (reportID) REFERENCES reports(id)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS voicelogBlocklist (
+		iid serial NOT NULL,
+		guildID varchar(25) NOT NULL DEFAULT '',
+		channelID varchar(25) NOT NULL DEFAULT '',
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS starboardConfig (
+		guildID varchar(25) NOT NULL DEFAULT '',
+		channelID varchar(25) NOT NULL DEFAULT '',
+		threshold integer NOT NULL DEFAULT 0,
+		emojiID text NOT NULL DEFAULT '',
+		karmaGain integer NOT NULL DEFAULT 3,
+		PRIMARY KEY (guildID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS starboards (
+		guildID varchar(25) NOT NULL DEFAULT '',
+		name varchar(32) NOT NULL DEFAULT '',
+		channelID varchar(25) NOT NULL DEFAULT '',
+		threshold integer NOT NULL DEFAULT 0,
+		emojiID text NOT NULL DEFAULT '',
+		karmaGain integer NOT NULL DEFAULT 3,
+		channels text NOT NULL DEFAULT '',
+		excludedChannels text NOT NULL DEFAULT '',
+		nsfw integer NOT NULL DEFAULT 0,
+		nsfwChannelID varchar(25) NOT NULL DEFAULT '',
+		minAge bigint NOT NULL DEFAULT 0,
+		PRIMARY KEY (guildID, name)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS starboardEntries (
+		messageID varchar(25) NOT NULL DEFAULT '',
+		starboardID varchar(25) NOT NULL DEFAULT '',
+		board varchar(32) NOT NULL DEFAULT '',
+		guildID varchar(25) NOT NULL DEFAULT '',
+		channelID varchar(25) NOT NULL DEFAULT '',
+		authorID varchar(25) NOT NULL DEFAULT '',
+		content text NOT NULL DEFAULT '',
+		mediaURLs text NOT NULL DEFAULT '',
+		score integer NOT NULL DEFAULT 0,
+		deleted boolean NOT NULL DEFAULT false,
+		PRIMARY KEY (messageID, board)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS refreshTokens (
+		userID varchar(25) NOT NULL DEFAULT '',
+		token text NOT NULL DEFAULT '',
+		expires timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (userID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS guildlog (
+		id varchar(25) NOT NULL DEFAULT '',
+		guildID varchar(25) NOT NULL DEFAULT '',
+		module varchar(30) NOT NULL DEFAULT '',
+		message text NOT NULL DEFAULT '',
+		severity integer NOT NULL DEFAULT 0,
+		timestamp timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (id)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS guildapi (
+		guildID varchar(25) NOT NULL DEFAULT '',
+		enabled boolean NOT NULL DEFAULT false,
+		origins text NOT NULL DEFAULT '',
+		tokenHash text NOT NULL DEFAULT '',
+		PRIMARY KEY (guildID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS verificationQueue (
+		iid serial NOT NULL,
+		guildID varchar(25) NOT NULL DEFAULT '',
+		userID varchar(25) NOT NULL DEFAULT '',
+		timestamp timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS birthdays (
+		iid serial NOT NULL,
+		guildID varchar(25) NOT NULL DEFAULT '',
+		userID varchar(25) NOT NULL DEFAULT '',
+		date timestamptz,
+		showYear boolean NOT NULL DEFAULT false,
+		lastNotified bigint NOT NULL DEFAULT 0,
+		roleExpires bigint NOT NULL DEFAULT 0,
+		PRIMARY KEY (iid)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS roleselect (
+		guildID varchar(25) NOT NULL,
+		channelID varchar(25) NOT NULL,
+		messageID varchar(25) NOT NULL,
+		roleID varchar(25) NOT NULL,
+		label text NOT NULL DEFAULT '',
+		emoji text NOT NULL DEFAULT '',
+		position integer NOT NULL DEFAULT 0,
+		mode integer NOT NULL DEFAULT 0,
+		maxRoles integer NOT NULL DEFAULT 0,
+		requiredRoles text NOT NULL DEFAULT '',
+		menu boolean NOT NULL DEFAULT false,
+		PRIMARY KEY (guildID, channelID, messageID, roleID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS backupKeys (
+		keyID varchar(32) NOT NULL,
+		guildID varchar(25) NOT NULL DEFAULT '',
+		masterKeyID text NOT NULL DEFAULT '',
+		wrappedKey text NOT NULL DEFAULT '',
+		created bigint NOT NULL DEFAULT 0,
+		PRIMARY KEY (keyID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS backupRestores (
+		guildID varchar(25) NOT NULL,
+		fileID text NOT NULL DEFAULT '',
+		checkpoint text NOT NULL DEFAULT '',
+		updated bigint NOT NULL DEFAULT 0,
+		PRIMARY KEY (guildID)
+	)`)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (m *PostgresMiddleware) Connect(credentials ...interface{}) (err error) {
+	creds := credentials[0].(models.DatabasePostgres)
+
+	sslMode := creds.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(creds.User, creds.Password),
+		Host:     creds.Host,
+		Path:     creds.Database,
+		RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+	}
+
+	if m.Db, err = sql.Open("postgres", dsn.String()); err != nil {
+		return
+	}
+
+	err = m.setup()
+	return
+}
+
+func (m *PostgresMiddleware) Close() {
+	if m.Db != nil {
+		m.Db.Close()
+	}
+}
+
+func (m *PostgresMiddleware) Status() error {
+	return m.Db.Ping()
+}
+
+func (m *PostgresMiddleware) getGuildSetting(guildID, key string) (string, error) {
+	var value string
+	err := m.Db.QueryRow(
+		fmt.Sprintf("SELECT %s FROM guilds WHERE guildID = $1", key),
+		guildID).Scan(&value)
+	err = wrapNotFoundError(err)
+	return value, err
+}
+
+func (m *PostgresMiddleware) setGuildSetting(guildID, key string, value string) (err error) {
+	res, err := m.Db.Exec(
+		fmt.Sprintf("UPDATE guilds SET %s = $1 WHERE guildID = $2", key),
+		value, guildID)
+	if err != nil {
+		return
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec(
+			fmt.Sprintf("INSERT INTO guilds (guildID, %s) VALUES ($1, $2)", key),
+			guildID, value)
+	}
+
+	return err
+}
+
+func (m *PostgresMiddleware) getUserSetting(userID, key string) (string, error) {
+	var value string
+	err := m.Db.QueryRow(
+		fmt.Sprintf("SELECT %s FROM users WHERE userID = $1", key),
+		userID).Scan(&value)
+	err = wrapNotFoundError(err)
+	return value, err
+}
+
+func (m *PostgresMiddleware) setUserSetting(userID, key string, value string) (err error) {
+	res, err := m.Db.Exec(
+		fmt.Sprintf("UPDATE users SET %s = $1 WHERE userID = $2", key),
+		value, userID)
+	if err != nil {
+		return
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec(
+			fmt.Sprintf("INSERT INTO users (userID, %s) VALUES ($1, $2)", key),
+			userID, value)
+	}
+
+	return err
+}
+
+func (m *PostgresMiddleware) GetGuildPrefix(guildID string) (string, error) {
+	val, err := m.getGuildSetting(guildID, "prefix")
+	return val, err
+}
+
+func (m *PostgresMiddleware) SetGuildPrefix(guildID, newPrefix string) error {
+	return m.setGuildSetting(guildID, "prefix", newPrefix)
+}
+
+func (m *PostgresMiddleware) GetGuildAutoRole(guildID string) ([]string, error) {
+	val, err := m.getGuildSetting(guildID, "autorole")
+	if val == "" {
+		return []string{}, err
+	}
+	return strings.Split(val, ";"), err
+}
+
+func (m *PostgresMiddleware) SetGuildAutoRole(guildID string, autoRoleIDs []string) error {
+	return m.setGuildSetting(guildID, "autorole", strings.Join(autoRoleIDs, ";"))
+}
+
+func (m *PostgresMiddleware) GetGuildAutoVC(guildID string) ([]string, error) {
+	val, err := m.getGuildSetting(guildID, "autovc")
+	if val == "" {
+		return []string{}, err
+	}
+	return strings.Split(val, ";"), err
+}
+
+func (m *PostgresMiddleware) SetGuildAutoVC(guildID string, autoVCIDs []string) error {
+	return m.setGuildSetting(guildID, "autovc", strings.Join(autoVCIDs, ";"))
+}
+
+func (m *PostgresMiddleware) GetGuildModLog(guildID string) (string, error) {
+	val, err := m.getGuildSetting(guildID, "modlogchanID")
+	return val, err
+}
+
+func (m *PostgresMiddleware) SetGuildModLog(guildID, chanID string) error {
+	return m.setGuildSetting(guildID, "modlogchanID", chanID)
+}
+
+func (m *PostgresMiddleware) GetGuildVoiceLog(guildID string) (string, error) {
+	val, err := m.getGuildSetting(guildID, "voicelogchanID")
+	return val, err
+}
+
+func (m *PostgresMiddleware) SetGuildVoiceLog(guildID, chanID string) error {
+	return m.setGuildSetting(guildID, "voicelogchanID", chanID)
+}
+
+func (m *PostgresMiddleware) GetGuildNotifyRole(guildID string) (string, error) {
+	val, err := m.getGuildSetting(guildID, "notifyRoleID")
+	return val, err
+}
+
+func (m *PostgresMiddleware) SetGuildNotifyRole(guildID, roleID string) error {
+	return m.setGuildSetting(guildID, "notifyRoleID", roleID)
+}
+
+func (m *PostgresMiddleware) GetGuildGhostpingMsg(guildID string) (string, error) {
+	val, err := m.getGuildSetting(guildID, "ghostPingMsg")
+	return val, err
+}
+
+func (m *PostgresMiddleware) SetGuildGhostpingMsg(guildID, msg string) error {
+	return m.setGuildSetting(guildID, "ghostPingMsg", msg)
+}
+
+func (m *PostgresMiddleware) GetGuildColorReaction(guildID string) (enabled bool, err error) {
+	val, err := m.getGuildSetting(guildID, "colorReaction")
+	return val == "1", err
+}
+
+func (m *PostgresMiddleware) SetGuildColorReaction(guildID string, enabled bool) error {
+	var val string
+	if enabled {
+		val = "1"
+	}
+	return m.setGuildSetting(guildID, "colorReaction", val)
+}
+
+func (m *PostgresMiddleware) GetGuildPermissions(guildID string) (map[string]permissions.PermissionArray, error) {
+	results := make(map[string]permissions.PermissionArray)
+	rows, err := m.Db.Query("SELECT roleID, permission FROM permissions WHERE guildID = $1",
+		guildID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var roleID string
+		var permission string
+		err := rows.Scan(&roleID, &permission)
+		if err != nil {
+			return nil, err
+		}
+		results[roleID] = strings.Split(permission, ",")
+	}
+	return results, nil
+}
+
+func (m *PostgresMiddleware) SetGuildRolePermission(guildID, roleID string, p permissions.PermissionArray) error {
+	if len(p) == 0 {
+		_, err := m.Db.Exec("DELETE FROM permissions WHERE roleID = $1", roleID)
+		return err
+	}
+
+	pStr := strings.Join(p, ",")
+	res, err := m.Db.Exec("UPDATE permissions SET permission = $1 WHERE roleID = $2 AND guildID = $3",
+		pStr, roleID, guildID)
+	if err != nil {
+		return err
+	}
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec("INSERT INTO permissions (roleID, guildID, permission) VALUES ($1, $2, $3)",
+			roleID, guildID, pStr)
+	}
+	return err
+}
+
+func (m *PostgresMiddleware) GetGuildJdoodleKey(guildID string) (string, error) {
+	val, err := m.getGuildSetting(guildID, "jdoodleToken")
+	return val, err
+}
+
+func (m *PostgresMiddleware) SetGuildJdoodleKey(guildID, key string) error {
+	return m.setGuildSetting(guildID, "jdoodleToken", key)
+}
+
+func (m *PostgresMiddleware) GetGuildCodeExecEnabled(guildID string) (bool, error) {
+	val, err := m.getGuildSetting(guildID, "codeExecEnabled")
+	return val == "1", err
+}
+
+func (m *PostgresMiddleware) SetGuildCodeExecEnabled(guildID string, enabled bool) error {
+	var val string
+	if enabled {
+		val = "1"
+	}
+	return m.setGuildSetting(guildID, "codeExecEnabled", val)
+}
+
+func (m *PostgresMiddleware) GetGuildBackup(guildID string) (bool, error) {
+	val, err := m.getGuildSetting(guildID, "backup")
+	return val == "1", err
+}
+
+func (m *PostgresMiddleware) SetGuildBackup(guildID string, enabled bool) error {
+	var val string
+	if enabled {
+		val = "1"
+	}
+	return m.setGuildSetting(guildID, "backup", val)
+}
+
+func (m *PostgresMiddleware) GetGuildBackupRetention(guildID string) (r backupmodels.Retention, err error) {
+	val, err := m.getGuildSetting(guildID, "backupRetention")
+	if err != nil {
+		return r, err
+	}
+	if val == "" {
+		return r, database.ErrDatabaseNotFound
+	}
+	return backupmodels.DecodeRetention(val)
+}
+
+func (m *PostgresMiddleware) SetGuildBackupRetention(guildID string, r backupmodels.Retention) error {
+	return m.setGuildSetting(guildID, "backupRetention", r.Encoded())
+}
+
+func (m *PostgresMiddleware) GetGuildEscalationLadder(guildID string) (l models.EscalationLadder, err error) {
+	val, err := m.getGuildSetting(guildID, "escalationLadder")
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return nil, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &l)
+	return l, err
+}
+
+func (m *PostgresMiddleware) SetGuildEscalationLadder(guildID string, l models.EscalationLadder) error {
+	var val string
+	if len(l) != 0 {
+		data, err := json.Marshal(l)
+		if err != nil {
+			return err
+		}
+		val = string(data)
+	}
+	return m.setGuildSetting(guildID, "escalationLadder", val)
+}
+
+func (m *PostgresMiddleware) GetSetting(setting string) (string, error) {
+	var value string
+	err := m.Db.QueryRow("SELECT value FROM settings WHERE setting = $1", setting).Scan(&value)
+	err = wrapNotFoundError(err)
+	return value, err
+}
+
+func (m *PostgresMiddleware) SetSetting(setting, value string) error {
+	res, err := m.Db.Exec("UPDATE settings SET value = $1 WHERE setting = $2", value, setting)
+	if err != nil {
+		return err
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec("INSERT INTO settings (setting, value) VALUES ($1, $2)", setting, value)
+	}
+
+	return err
+}
+
+func (m *PostgresMiddleware) AddReport(rep models.Report) error {
+	_, err := m.Db.Exec(`
+		INSERT INTO reports (id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		rep.ID, rep.Type, rep.GuildID, rep.ExecutorID, rep.VictimID, rep.Msg, rep.AttachmentURL, rep.Timeout, rep.LinkedID)
+	return err
+}
+
+func (m *PostgresMiddleware) DeleteReport(id snowflake.ID) error {
+	_, err := m.Db.Exec("DELETE FROM reportRevisions WHERE reportID = $1", id)
+	if err != nil {
+		return err
+	}
+	_, err = m.Db.Exec("DELETE FROM reports WHERE id = $1", id)
+	return err
+}
+
+func (m *PostgresMiddleware) UpdateReport(rep models.Report) error {
+	_, err := m.Db.Exec(`
+		UPDATE reports SET msg = $1, attachment = $2, timeout = $3
+		WHERE id = $4`,
+		rep.Msg, rep.AttachmentURL, rep.Timeout, rep.ID)
+	return err
+}
+
+func (m *PostgresMiddleware) AddReportRevision(rev models.ReportRevision) error {
+	_, err := m.Db.Exec(`
+		INSERT INTO reportRevisions (reportID, version, guildID, editorID, timestamp, msg, attachment, timeout)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		rev.ReportID, rev.Version, rev.GuildID, rev.EditorID, rev.Timestamp, rev.Msg, rev.AttachmentURL, rev.Timeout)
+	return err
+}
+
+func (m *PostgresMiddleware) GetReportRevisions(reportID snowflake.ID) ([]models.ReportRevision, error) {
+	rows, err := m.Db.Query(`
+		SELECT reportID, version, guildID, editorID, timestamp, msg, attachment, timeout
+		FROM reportRevisions WHERE reportID = $1
+		ORDER BY version ASC`, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.ReportRevision, 0)
+	for rows.Next() {
+		var rev models.ReportRevision
+		err := rows.Scan(&rev.ReportID, &rev.Version, &rev.GuildID, &rev.EditorID,
+			&rev.Timestamp, &rev.Msg, &rev.AttachmentURL, &rev.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rev)
+	}
+	return results, nil
+}
+
+func (m *PostgresMiddleware) GetReport(id snowflake.ID) (models.Report, error) {
+	rep := models.Report{}
+
+	row := m.Db.QueryRow(`
+		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID
+		FROM reports WHERE id = $1`, id)
+	err := row.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID, &rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout, &rep.LinkedID)
+	if err == sql.ErrNoRows {
+		return models.Report{}, database.ErrDatabaseNotFound
+	}
+
+	return rep, err
+}
+
+func (m *PostgresMiddleware) GetReportsGuild(guildID string, offset, limit int) ([]models.Report, error) {
+	if limit == 0 {
+		limit = 1000
+	}
+
+	rows, err := m.Db.Query(`
+		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID
+		FROM reports WHERE guildID = $1
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $2
+	`, guildID, offset, limit)
+	var results []models.Report
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var rep models.Report
+		err := rows.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID,
+			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout, &rep.LinkedID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rep)
+	}
+	return results, nil
+}
+
+func (m *PostgresMiddleware) GetReportsFiltered(guildID, memberID string, repType models.ReportType, offset, limit int) ([]models.Report, error) {
+	args := []interface{}{}
+	query := `SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID FROM reports WHERE true`
+	if guildID != "" {
+		args = append(args, guildID)
+		query += fmt.Sprintf(" AND guildID = $%d", len(args))
+	}
+	if memberID != "" {
+		args = append(args, memberID)
+		query += fmt.Sprintf(" AND victimID = $%d", len(args))
+	}
+	if repType > -1 {
+		args = append(args, repType)
+		query += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := m.Db.Query(query, args...)
+	var results []models.Report
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var rep models.Report
+		err := rows.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID,
+			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout, &rep.LinkedID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rep)
+	}
+	return results, nil
+}
+
+func (m *PostgresMiddleware) GetReportsGuildCount(guildID string) (count int, err error) {
+	err = m.Db.QueryRow("SELECT COUNT(id) FROM reports WHERE guildID = $1", guildID).Scan(&count)
+	return
+}
+
+func (m *PostgresMiddleware) GetReportsFilteredCount(guildID, memberID string, repType int) (count int, err error) {
+	if !stringutil.IsInteger(guildID) {
+		err = fmt.Errorf("invalid argument type")
+		return
+	}
+
+	query := `SELECT COUNT(id) FROM reports WHERE guildID = $1`
+	args := []interface{}{guildID}
+	if memberID != "" {
+		args = append(args, memberID)
+		query += fmt.Sprintf(" AND victimID = $%d", len(args))
+	}
+	if repType != -1 {
+		args = append(args, repType)
+		query += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+
+	err = m.Db.QueryRow(query, args...).Scan(&count)
+	return
+}
+
+func (m *PostgresMiddleware) GetExpiredReports() (results []models.Report, err error) {
+	rows, err := m.Db.Query(`
+		SELECT id, type, guildID, executorID, victimID, msg, attachment, timeout, linkedID
+		FROM reports
+		WHERE timeout <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+		return
+	}
+
+	results = make([]models.Report, 0)
+	for rows.Next() {
+		var rep models.Report
+		err := rows.Scan(&rep.ID, &rep.Type, &rep.GuildID, &rep.ExecutorID,
+			&rep.VictimID, &rep.Msg, &rep.AttachmentURL, &rep.Timeout, &rep.LinkedID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rep)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) ExpireReports(ids ...string) (err error) {
+	tx, err := m.Db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		_, err = tx.Exec(`
+			UPDATE reports SET timeout = NULL
+			WHERE id = $1`, id)
+		if err != nil {
+			return
+		}
+	}
+	err = tx.Commit()
+	return
+}
+
+func (m *PostgresMiddleware) GetVotes() (map[string]vote.Vote, error) {
+	rows, err := m.Db.Query("SELECT id, data FROM votes")
+	results := make(map[string]vote.Vote)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var voteID, rawData string
+		err := rows.Scan(&voteID, &rawData)
+		if err != nil {
+			m.log.Error().Err(err).Msg("An error occured reading vote from database")
+			continue
+		}
+		vote, err := vote.Unmarshal(rawData)
+		if err != nil {
+			m.DeleteVote(rawData)
+		} else {
+			results[vote.ID] = vote
+		}
+	}
+	return results, err
+}
+
+func (m *PostgresMiddleware) GetVote(id string) (v vote.Vote, err error) {
+	var rawData string
+	err = m.Db.QueryRow("SELECT data FROM votes WHERE id = $1", id).Scan(&rawData)
+	if err == sql.ErrNoRows {
+		err = database.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return
+	}
+	return vote.Unmarshal(rawData)
+}
+
+func (m *PostgresMiddleware) GetExpiredVotes(before time.Time) ([]vote.Vote, error) {
+	rows, err := m.Db.Query("SELECT data FROM votes WHERE closed = false AND expires > 0 AND expires <= $1", before.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []vote.Vote
+	for rows.Next() {
+		var rawData string
+		if err = rows.Scan(&rawData); err != nil {
+			return nil, err
+		}
+		v, err := vote.Unmarshal(rawData)
+		if err != nil {
+			m.log.Error().Err(err).Msg("An error occured reading vote from database")
+			continue
+		}
+		votes = append(votes, v)
+	}
+	return votes, rows.Err()
+}
+
+func (m *PostgresMiddleware) AddUpdateVote(v vote.Vote) error {
+	rawData, err := v.Marshal()
+	if err != nil {
+		return err
+	}
+
+	expires := toUnix(v.Expires)
+	closed := v.State != vote.VoteStateOpen
+
+	// The data of closed votes is not overwritten by open
+	// ones so that outdated instances can not reopen votes.
+	_, err = m.Db.Exec(
+		"INSERT INTO votes (id, data, guildID, expires, closed) VALUES ($1, $2, $3, $4, $5) "+
+			"ON CONFLICT (id) DO UPDATE SET data = $2, guildID = $3, expires = $4, closed = $5 "+
+			"WHERE votes.closed = false OR $5 = true",
+		v.ID, rawData, v.GuildID, expires, closed)
+
+	return err
+}
+
+func (m *PostgresMiddleware) ClaimVoteClose(id string) (ok bool, err error) {
+	res, err := m.Db.Exec("UPDATE votes SET closed = true WHERE id = $1 AND closed = false", id)
+	if err != nil {
+		return
+	}
+	n, err := res.RowsAffected()
+	ok = n > 0
+	return
+}
+
+func (m *PostgresMiddleware) DeleteVote(voteID string) error {
+	_, err := m.Db.Exec("DELETE FROM votes WHERE id = $1", voteID)
+	return err
+}
+
+func (m *PostgresMiddleware) GetTwitchNotify(twitchUserID, guildID string) (twitchnotify.DBEntry, error) {
+	t := twitchnotify.DBEntry{
+		TwitchUserID: twitchUserID,
+		GuildID:      guildID,
+	}
+	err := m.Db.QueryRow("SELECT channelID FROM twitchnotify WHERE twitchUserID = $1 AND guildID = $2",
+		twitchUserID, guildID).Scan(&t.ChannelID)
+	err = wrapNotFoundError(err)
+	return t, err
+}
+
+func (m *PostgresMiddleware) SetTwitchNotify(twitchNotify twitchnotify.DBEntry) error {
+	res, err := m.Db.Exec("UPDATE twitchnotify SET channelID = $1 WHERE twitchUserID = $2 AND guildID = $3",
+		twitchNotify.ChannelID, twitchNotify.TwitchUserID, twitchNotify.GuildID)
+	if err != nil {
+		return err
+	}
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec("INSERT INTO twitchnotify (twitchUserID, guildID, channelID) VALUES ($1, $2, $3)",
+			twitchNotify.TwitchUserID, twitchNotify.GuildID, twitchNotify.ChannelID)
+	}
+	return err
+}
+
+func (m *PostgresMiddleware) DeleteTwitchNotify(twitchUserID, guildID string) error {
+	_, err := m.Db.Exec("DELETE FROM twitchnotify WHERE twitchUserID = $1 AND guildID = $2", twitchUserID, guildID)
+	return err
+}
+
+func (m *PostgresMiddleware) GetAllTwitchNotifies(twitchUserID string) ([]twitchnotify.DBEntry, error) {
+	query := "SELECT twitchUserID, guildID, channelID FROM twitchnotify"
+	var args []interface{}
+	if twitchUserID != "" {
+		query += " WHERE twitchUserID = $1"
+		args = []interface{}{twitchUserID}
+	}
+	rows, err := m.Db.Query(query, args...)
+	results := make([]twitchnotify.DBEntry, 0)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var t twitchnotify.DBEntry
+		err = rows.Scan(&t.TwitchUserID, &t.GuildID, &t.ChannelID)
+		if err == nil {
+			results = append(results, t)
+		}
+	}
+	return results, nil
+}
+
+func (m *PostgresMiddleware) AddBackup(entry backupmodels.Entry) error {
+	_, err := m.Db.Exec(`INSERT INTO backups (guildID, "timestamp", fileID, parentID, changes) VALUES ($1, $2, $3, $4, $5)`,
+		entry.GuildID, entry.Timestamp.Unix(), entry.FileID, entry.ParentID, entry.Changes)
+	return err
+}
+
+func (m *PostgresMiddleware) UpdateBackup(entry backupmodels.Entry) error {
+	_, err := m.Db.Exec("UPDATE backups SET parentID = $1, changes = $2 WHERE guildID = $3 AND fileID = $4",
+		entry.ParentID, entry.Changes, entry.GuildID, entry.FileID)
+	return err
+}
+
+func (m *PostgresMiddleware) DeleteBackup(guildID, fileID string) error {
+	_, err := m.Db.Exec("DELETE FROM backups WHERE guildID = $1 AND fileID = $2", guildID, fileID)
+	return err
+}
+
+func (m *PostgresMiddleware) GetGuildInviteBlock(guildID string) (string, error) {
+	return m.getGuildSetting(guildID, "inviteBlock")
+}
+
+func (m *PostgresMiddleware) SetGuildInviteBlock(guildID string, data string) error {
+	return m.setGuildSetting(guildID, "inviteBlock", data)
+}
+
+func (m *PostgresMiddleware) GetGuildInviteBlockSettings(guildID string) (settings models.InviteBlockSettings, err error) {
+	val, err := m.getGuildSetting(guildID, "inviteBlockSettings")
+	if err != nil {
+		return
+	}
+	if val == "" {
+		return settings, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &settings)
+	return
+}
+
+func (m *PostgresMiddleware) SetGuildInviteBlockSettings(guildID string, settings models.InviteBlockSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "inviteBlockSettings", string(data))
+}
+
+func (m *PostgresMiddleware) GetGuildAutomodRules(guildID string) (rules []models.AutomodRule, err error) {
+	val, err := m.getGuildSetting(guildID, "automodRules")
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return nil, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &rules)
+	return rules, err
+}
+
+func (m *PostgresMiddleware) SetGuildAutomodRules(guildID string, rules []models.AutomodRule) error {
+	var val string
+	if len(rules) != 0 {
+		data, err := json.Marshal(rules)
+		if err != nil {
+			return err
+		}
+		val = string(data)
+	}
+	return m.setGuildSetting(guildID, "automodRules", val)
+}
+
+func (m *PostgresMiddleware) GetGuildJoinMsg(guildID string) (string, string, error) {
+	data, err := m.getGuildSetting(guildID, "joinMsg")
+	if err != nil {
+		return "", "", err
+	}
+	if data == "" {
+		return "", "", nil
+	}
+
+	i := strings.Index(data, "|")
+	if i < 0 || len(data) < i+1 {
+		return "", "", nil
+	}
+
+	return data[:i], data[i+1:], nil
+}
+
+func (m *PostgresMiddleware) SetGuildJoinMsg(guildID string, msg string, channelID string) error {
+	return m.setGuildSetting(guildID, "joinMsg", fmt.Sprintf("%s|%s", msg, channelID))
+}
+
+func (m *PostgresMiddleware) GetGuildLeaveMsg(guildID string) (string, string, error) {
+	data, err := m.getGuildSetting(guildID, "leaveMsg")
+	if err != nil {
+		return "", "", err
+	}
+	if data == "" {
+		return "", "", nil
+	}
+
+	i := strings.Index(data, "|")
+	if i < 0 || len(data) < i+1 {
+		return "", "", nil
+	}
+
+	return data[:i], data[i+1:], nil
+}
+
+func (m *PostgresMiddleware) SetGuildLeaveMsg(guildID string, channelID string, msg string) error {
+	return m.setGuildSetting(guildID, "leaveMsg", fmt.Sprintf("%s|%s", channelID, msg))
+}
+
+func (m *PostgresMiddleware) GetBackups(guildID string) ([]backupmodels.Entry, error) {
+	rows, err := m.Db.Query(`SELECT guildID, "timestamp", fileID, parentID, changes FROM backups WHERE guildID = $1`, guildID)
+	if err == sql.ErrNoRows {
+		return nil, database.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]backupmodels.Entry, 0)
+	for rows.Next() {
+		var be backupmodels.Entry
+		var timeStampUnix int64
+		err = rows.Scan(&be.GuildID, &timeStampUnix, &be.FileID, &be.ParentID, &be.Changes)
+		if err != nil {
+			return nil, err
+		}
+		be.Timestamp = time.Unix(timeStampUnix, 0)
+		backups = append(backups, be)
+	}
+
+	return backups, nil
+}
+
+func (m *PostgresMiddleware) GetBackupRestoreCheckpoint(guildID string) (cp backupmodels.RestoreCheckpoint, err error) {
+	var state string
+	var updatedUnix int64
+	err = m.Db.QueryRow("SELECT guildID, fileID, checkpoint, updated FROM backupRestores WHERE guildID = $1", guildID).
+		Scan(&cp.GuildID, &cp.FileID, &state, &updatedUnix)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	cp.Updated = time.Unix(updatedUnix, 0)
+	err = cp.SetState(state)
+	return
+}
+
+func (m *PostgresMiddleware) SetBackupRestoreCheckpoint(cp backupmodels.RestoreCheckpoint) (err error) {
+	state, err := cp.StateEncoded()
+	if err != nil {
+		return
+	}
+
+	res, err := m.Db.Exec("UPDATE backupRestores SET fileID = $1, checkpoint = $2, updated = $3 WHERE guildID = $4",
+		cp.FileID, state, cp.Updated.Unix(), cp.GuildID)
+	if err != nil {
+		return
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec("INSERT INTO backupRestores (guildID, fileID, checkpoint, updated) VALUES ($1, $2, $3, $4)",
+			cp.GuildID, cp.FileID, state, cp.Updated.Unix())
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) DeleteBackupRestoreCheckpoint(guildID string) error {
+	_, err := m.Db.Exec("DELETE FROM backupRestores WHERE guildID = $1", guildID)
+	return err
+}
+
+func (m *PostgresMiddleware) GetBackupKeys(guildID string) ([]backupmodels.DataKey, error) {
+	rows, err := m.Db.Query("SELECT keyID, guildID, masterKeyID, wrappedKey, created FROM backupKeys WHERE guildID = $1", guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]backupmodels.DataKey, 0)
+	for rows.Next() {
+		var key backupmodels.DataKey
+		var createdUnix int64
+		err = rows.Scan(&key.ID, &key.GuildID, &key.MasterKeyID, &key.Key, &createdUnix)
+		if err != nil {
+			return nil, err
+		}
+		key.Created = time.Unix(createdUnix, 0)
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (m *PostgresMiddleware) GetBackupKey(keyID string) (key backupmodels.DataKey, err error) {
+	var createdUnix int64
+	err = m.Db.QueryRow("SELECT keyID, guildID, masterKeyID, wrappedKey, created FROM backupKeys WHERE keyID = $1", keyID).
+		Scan(&key.ID, &key.GuildID, &key.MasterKeyID, &key.Key, &createdUnix)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	key.Created = time.Unix(createdUnix, 0)
+	return
+}
+
+func (m *PostgresMiddleware) AddBackupKey(key backupmodels.DataKey) error {
+	_, err := m.Db.Exec("INSERT INTO backupKeys (keyID, guildID, masterKeyID, wrappedKey, created) VALUES ($1, $2, $3, $4, $5)",
+		key.ID, key.GuildID, key.MasterKeyID, key.Key, key.Created.Unix())
+	return err
+}
+
+func (m *PostgresMiddleware) UpdateBackupKey(key backupmodels.DataKey) error {
+	_, err := m.Db.Exec("UPDATE backupKeys SET masterKeyID = $1, wrappedKey = $2 WHERE keyID = $3",
+		key.MasterKeyID, key.Key, key.ID)
+	return err
+}
+
+func (m *PostgresMiddleware) DeleteBackupKey(keyID string) error {
+	_, err := m.Db.Exec("DELETE FROM backupKeys WHERE keyID = $1", keyID)
+	return err
+}
+
+func (m *PostgresMiddleware) GetGuilds() ([]string, error) {
+	rows, err := m.Db.Query("SELECT guildID FROM guilds WHERE backup = '1'")
+	if err == sql.ErrNoRows {
+		return nil, database.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	guilds := make([]string, 0)
+	for rows.Next() {
+		var s string
+		err = rows.Scan(&s)
+		if err != nil {
+			return nil, err
+		}
+		guilds = append(guilds, s)
+	}
+
+	return guilds, err
+}
+
+func (m *PostgresMiddleware) AddTag(tag tag.Tag) error {
+	_, err := m.Db.Exec("INSERT INTO tags (id, ident, creatorID, guildID, content, created, lastEdit, aliases, roles, uses) VALUES "+
+		"($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		tag.ID, tag.Ident, tag.CreatorID, tag.GuildID, tag.Content, tag.Created.Unix(), tag.LastEdit.Unix(),
+		strings.Join(tag.Aliases, ";"), strings.Join(tag.Roles, ";"), tag.Uses)
+	return err
+}
+
+func (m *PostgresMiddleware) EditTag(tag tag.Tag) error {
+	_, err := m.Db.Exec("UPDATE tags SET "+
+		"ident = $1, creatorID = $2, guildID = $3, content = $4, created = $5, lastEdit = $6, "+
+		"aliases = $7, roles = $8 "+
+		"WHERE id = $9", tag.Ident, tag.CreatorID, tag.GuildID, tag.Content, tag.Created.Unix(), tag.LastEdit.Unix(),
+		strings.Join(tag.Aliases, ";"), strings.Join(tag.Roles, ";"), tag.ID)
+	if err == sql.ErrNoRows {
+		return database.ErrDatabaseNotFound
+	}
+	return err
+}
+
+func (m *PostgresMiddleware) GetTagByID(id snowflake.ID) (tag.Tag, error) {
+	row := m.Db.QueryRow("SELECT id, ident, creatorID, guildID, content, created, lastEdit, aliases, roles, uses FROM tags "+
+		"WHERE id = $1", id)
+
+	tag, err := scanTag(row)
+	if err == sql.ErrNoRows {
+		return tag, database.ErrDatabaseNotFound
+	}
+	return tag, err
+}
+
+func (m *PostgresMiddleware) GetTagByIdent(ident string, guildID string) (tag.Tag, error) {
+	row := m.Db.QueryRow("SELECT id, ident, creatorID, guildID, content, created, lastEdit, aliases, roles, uses FROM tags "+
+		"WHERE guildID = $1 AND (ident = $2 OR strpos(';' || aliases || ';', ';' || $2 || ';') > 0) "+
+		"ORDER BY ident = $2 DESC LIMIT 1", guildID, ident)
+
+	tag, err := scanTag(row)
+	if err == sql.ErrNoRows {
+		return tag, database.ErrDatabaseNotFound
+	}
+	return tag, err
+}
+
+func (m *PostgresMiddleware) GetGuildTags(guildID string) ([]tag.Tag, error) {
+	rows, err := m.Db.Query("SELECT id, ident, creatorID, guildID, content, created, lastEdit, aliases, roles, uses FROM tags "+
+		"WHERE guildID = $1 ORDER BY uses DESC", guildID)
+	if err == sql.ErrNoRows {
+		return nil, database.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]tag.Tag, 0)
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+func (m *PostgresMiddleware) DeleteTag(id snowflake.ID) error {
+	_, err := m.Db.Exec("DELETE FROM tags WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return database.ErrDatabaseNotFound
+	}
+	return err
+}
+
+func (m *PostgresMiddleware) IncrementTagUses(id snowflake.ID) error {
+	_, err := m.Db.Exec("UPDATE tags SET uses = uses + 1 WHERE id = $1", id)
+	return err
+}
+
+func (m *PostgresMiddleware) SetAPIToken(token models.APITokenEntry) (err error) {
+	res, err := m.Db.Exec(
+		"UPDATE apitokens SET "+
+			"salt = $1, created = $2, expires = $3, lastAccess = $4, hits = $5 "+
+			"WHERE userID = $6",
+		token.Salt, token.Created, token.Expires, token.LastAccess, token.Hits, token.UserID)
+	if err != nil {
+		return
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec(
+			"INSERT INTO apitokens "+
+				"(userID, salt, created, expires, lastAccess, hits) "+
+				"VALUES ($1, $2, $3, $4, $5, $6)",
+			token.UserID, token.Salt, token.Created, token.Expires, token.LastAccess, token.Hits)
+	}
+	return
+}
+
+func (m *PostgresMiddleware) GetAPIToken(userID string) (t models.APITokenEntry, err error) {
+	err = m.Db.QueryRow(
+		"SELECT userID, salt, created, expires, lastAccess, hits "+
+			"FROM apitokens WHERE userID = $1", userID).
+		Scan(&t.UserID, &t.Salt, &t.Created, &t.Expires, &t.LastAccess, &t.Hits)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) DeleteAPIToken(userID string) error {
+	_, err := m.Db.Exec("DELETE FROM apitokens WHERE userID = $1", userID)
+	if err == sql.ErrNoRows {
+		return database.ErrDatabaseNotFound
+	}
+	return err
+}
+
+func (m *PostgresMiddleware) GetKarma(userID, guildID string) (i int, err error) {
+	err = m.Db.QueryRow("SELECT value FROM karma WHERE userID = $1 AND guildID = $2",
+		userID, guildID).Scan(&i)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaSum(userID string) (i int, err error) {
+	err = m.Db.QueryRow("SELECT COALESCE(SUM(value), 0) FROM karma WHERE userID = $1",
+		userID).Scan(&i)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaGuild(guildID string, limit int) ([]models.GuildKarma, error) {
+	if limit < 1 {
+		limit = 1000
+	}
+
+	res := make([]models.GuildKarma, limit)
+
+	rows, err := m.Db.Query(
+		`SELECT userID, value FROM karma WHERE guildID = $1
+		ORDER BY value DESC
+		LIMIT $2`,
+		guildID, limit)
+	if err == sql.ErrNoRows {
+		return nil, database.ErrDatabaseNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	i := 0
+	for rows.Next() {
+		var v models.GuildKarma
+		v.GuildID = guildID
+		if err = rows.Scan(&v.UserID, &v.Value); err != nil {
+			return nil, err
+		}
+		res[i] = v
+		i++
+	}
+
+	return res[:i], nil
+}
+
+func (m *PostgresMiddleware) GetKarmaGuildCount(guildID string) (n int, err error) {
+	err = m.Db.QueryRow("SELECT COUNT(iid) FROM karma WHERE guildID = $1",
+		guildID).Scan(&n)
+	return
+}
+
+func (m *PostgresMiddleware) SetKarma(userID, guildID string, val int) (err error) {
+	res, err := m.Db.Exec("UPDATE karma SET value = $1 WHERE userID = $2 AND guildID = $3",
+		val, userID, guildID)
+	if err != nil {
+		return
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec("INSERT INTO karma (userID, guildID, value) VALUES ($1, $2, $3)",
+			userID, guildID, val)
+	}
+	return
+}
+
+func (m *PostgresMiddleware) UpdateKarma(userID, guildID string, diff int) (err error) {
+	res, err := m.Db.Exec("UPDATE karma SET value = value + $1 WHERE userID = $2 AND guildID = $3",
+		diff, userID, guildID)
+	if err != nil {
+		return
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec("INSERT INTO karma (userID, guildID, value) VALUES ($1, $2, $3)",
+			userID, guildID, diff)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) SetKarmaState(guildID string, state bool) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO karmaSettings (guildID, state) "+
+			"VALUES ($1, $2) "+
+			"ON CONFLICT (guildID) DO UPDATE SET state = $3",
+		guildID, state, state)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaState(guildID string) (state bool, err error) {
+	err = m.Db.QueryRow("SELECT state FROM karmaSettings WHERE guildID = $1",
+		guildID).Scan(&state)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) SetKarmaEmotes(guildID, emotesInc, emotesDec string) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO karmaSettings (guildID, emotesInc, emotesDec) "+
+			"VALUES ($1, $2, $3) "+
+			"ON CONFLICT (guildID) DO UPDATE SET emotesInc = $4, emotesDec = $5",
+		guildID, emotesInc, emotesDec, emotesInc, emotesDec)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaEmotes(guildID string) (emotesInc, emotesDec string, err error) {
+	err = m.Db.QueryRow("SELECT emotesInc, emotesDec FROM karmaSettings WHERE guildID = $1",
+		guildID).Scan(&emotesInc, &emotesDec)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) SetKarmaTokens(guildID string, tokens int) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO karmaSettings (guildID, tokens) "+
+			"VALUES ($1, $2) "+
+			"ON CONFLICT (guildID) DO UPDATE SET tokens = $3",
+		guildID, tokens, tokens)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaTokens(guildID string) (tokens int, err error) {
+	err = m.Db.QueryRow("SELECT tokens FROM karmaSettings WHERE guildID = $1",
+		guildID).Scan(&tokens)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) SetKarmaPenalty(guildID string, state bool) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO karmaSettings (guildID, penalty) "+
+			"VALUES ($1, $2) "+
+			"ON CONFLICT (guildID) DO UPDATE SET penalty = $3",
+		guildID, state, state)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaPenalty(guildID string) (state bool, err error) {
+	err = m.Db.QueryRow("SELECT penalty FROM karmaSettings WHERE guildID = $1",
+		guildID).Scan(&state)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) SetKarmaDecay(guildID string, decay models.KarmaDecay) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO karmaSettings (guildID, decayAfter, decayAmount, decayEnabledAt) "+
+			"VALUES ($1, $2, $3, $4) "+
+			"ON CONFLICT (guildID) DO UPDATE SET decayAfter = $2, decayAmount = $3, decayEnabledAt = $4",
+		guildID, decay.After, decay.Amount, toUnix(decay.EnabledAt))
+
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaDecay(guildID string) (decay models.KarmaDecay, err error) {
+	var enabledAt int64
+	err = m.Db.QueryRow("SELECT decayAfter, decayAmount, decayEnabledAt FROM karmaSettings WHERE guildID = $1",
+		guildID).Scan(&decay.After, &decay.Amount, &enabledAt)
+	err = wrapNotFoundError(err)
+	decay.EnabledAt = fromUnix(enabledAt)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaDecayGuilds() ([]string, error) {
+	rows, err := m.Db.Query(
+		"SELECT guildID FROM karmaSettings WHERE state AND decayAfter > 0 AND decayAmount > 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]string, 0)
+	for rows.Next() {
+		var guildID string
+		if err = rows.Scan(&guildID); err != nil {
+			return nil, err
+		}
+		res = append(res, guildID)
+	}
+	return res, nil
+}
+
+func (m *PostgresMiddleware) GetKarmaDecayCandidates(guildID string, inactiveSince time.Time) ([]models.GuildKarma, error) {
+	rows, err := m.Db.Query(`
+		SELECT k.userID, k.value
+		FROM karma k
+		WHERE k.guildID = $1 AND k.value != 0
+		AND NOT EXISTS (
+			SELECT 1 FROM karmaLedger l
+			WHERE l.guildID = k.guildID AND (l.targetID = k.userID OR l.executorID = k.userID)
+			AND l.source != 'DECAY' AND l.timestamp >= $2
+		)`,
+		guildID, inactiveSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]models.GuildKarma, 0)
+	for rows.Next() {
+		v := models.GuildKarma{GuildID: guildID}
+		if err = rows.Scan(&v.UserID, &v.Value); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+func (m *PostgresMiddleware) SetKarmaFarmingLimits(guildID string, limits models.KarmaFarmingLimits) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO karmaSettings (guildID, pairLimit, pairWindow, sockPuppetThreshold) "+
+			"VALUES ($1, $2, $3, $4) "+
+			"ON CONFLICT (guildID) DO UPDATE SET pairLimit = $2, pairWindow = $3, sockPuppetThreshold = $4",
+		guildID, limits.PairLimit, limits.PairWindow, limits.SockPuppetThreshold)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaFarmingLimits(guildID string) (limits models.KarmaFarmingLimits, err error) {
+	err = m.Db.QueryRow("SELECT pairLimit, pairWindow, sockPuppetThreshold FROM karmaSettings WHERE guildID = $1",
+		guildID).Scan(&limits.PairLimit, &limits.PairWindow, &limits.SockPuppetThreshold)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaBlockList(guildID string) (list []string, err error) {
+	row, err := m.Db.Query("SELECT userID FROM karmaBlocklist WHERE guildID = $1", guildID)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	list = make([]string, 0)
+	var id string
+	for row.Next() {
+		if err = row.Scan(&id); err != nil {
+			return
+		}
+		list = append(list, id)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) IsKarmaBlockListed(guildID, userID string) (ok bool, err error) {
+	err = m.Db.QueryRow("SELECT 1 FROM karmaBlocklist WHERE guildID = $1 AND userID = $2",
+		guildID, userID).Scan(&ok)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+
+	err = nil
+
+	return
+}
+
+func (m *PostgresMiddleware) AddKarmaBlockList(guildID, userID string) (err error) {
+	_, err = m.Db.Exec("INSERT INTO karmaBlocklist (guildID, userID) VALUES ($1, $2)",
+		guildID, userID)
+	return
+}
+
+func (m *PostgresMiddleware) RemoveKarmaBlockList(guildID, userID string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM karmaBlocklist WHERE guildID = $1 AND userID = $2",
+		guildID, userID)
+	return
+}
+
+func (m *PostgresMiddleware) SetLockChan(chanID, guildID, executorID, permissions string) error {
+	_, err := m.Db.Exec("INSERT INTO chanlock (chanID, guildID, executorID, permissions) VALUES ($1, $2, $3, $4)",
+		chanID, guildID, executorID, permissions)
+	return err
+}
+
+func (m *PostgresMiddleware) GetLockChan(chanID string) (guildID, executorID, permissions string, err error) {
+	err = m.Db.QueryRow("SELECT guildID, executorID, permissions FROM chanlock WHERE chanID = $1", chanID).
+		Scan(&guildID, &executorID, &permissions)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) GetLockChannels(guildID string) (chanIDs []string, err error) {
+	chanIDs = make([]string, 0)
+	rows, err := m.Db.Query("SELECT chanID FROM chanlock WHERE guildID = $1", guildID)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+		chanIDs = append(chanIDs, id)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) DeleteLockChan(chanID string) error {
+	_, err := m.Db.Exec("DELETE FROM chanlock WHERE chanID = $1",
+		chanID)
+	err = wrapNotFoundError(err)
+	return err
+}
+
+func (m *PostgresMiddleware) SetAntiraidState(guildID string, state bool) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO antiraidSettings (guildID, state) "+
+			"VALUES ($1, $2) "+
+			"ON CONFLICT (guildID) DO UPDATE SET state = $3",
+		guildID, state, state)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetAntiraidState(guildID string) (state bool, err error) {
+	err = m.Db.QueryRow("SELECT state FROM antiraidSettings WHERE guildID = $1",
+		guildID).Scan(&state)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) SetAntiraidRegeneration(guildID string, limit int) (err error) {
+	_, err = m.Db.Exec(
+		`INSERT INTO antiraidSettings (guildID, "limit") `+
+			"VALUES ($1, $2) "+
+			`ON CONFLICT (guildID) DO UPDATE SET "limit" = $3`,
+		guildID, limit, limit)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetAntiraidRegeneration(guildID string) (limit int, err error) {
+	err = m.Db.QueryRow(`SELECT "limit" FROM antiraidSettings WHERE guildID = $1`,
+		guildID).Scan(&limit)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) SetAntiraidBurst(guildID string, burst int) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO antiraidSettings (guildID, burst) "+
+			"VALUES ($1, $2) "+
+			"ON CONFLICT (guildID) DO UPDATE SET burst = $3",
+		guildID, burst, burst)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetAntiraidBurst(guildID string) (burst int, err error) {
+	err = m.Db.QueryRow("SELECT burst FROM antiraidSettings WHERE guildID = $1",
+		guildID).Scan(&burst)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) AddToAntiraidJoinList(guildID, userID, userTag string, accountCreated time.Time) (err error) {
+	_, err = m.Db.Exec("INSERT INTO antiraidJoinlog (userID, guildID, tag, accountCreated) "+
+		"VALUES ($1, $2, $3, $4)", userID, guildID, userTag, accountCreated)
+	return
+}
+
+func (m *PostgresMiddleware) SetAntiraidVerification(guildID string, state bool) (err error) {
+	_, err = m.Db.Exec(
+		"INSERT INTO antiraidSettings (guildID, verification) "+
+			"VALUES ($1, $2) "+
+			"ON CONFLICT (guildID) DO UPDATE SET verification = $3",
+		guildID, state, state)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetAntiraidVerification(guildID string) (state bool, err error) {
+	err = m.Db.QueryRow("SELECT verification FROM antiraidSettings WHERE guildID = $1",
+		guildID).Scan(&state)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetAntiraidSignals(guildID string) (sig models.AntiraidSignals, err error) {
+	val, err := m.getGuildSetting(guildID, "antiraidSignals")
+	if err != nil {
+		return
+	}
+	if val == "" {
+		return sig, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &sig)
+	return
+}
+
+func (m *PostgresMiddleware) SetAntiraidSignals(guildID string, sig models.AntiraidSignals) error {
+	var val string
+	if sig.Enabled() {
+		data, err := json.Marshal(sig)
+		if err != nil {
+			return err
+		}
+		val = string(data)
+	}
+	return m.setGuildSetting(guildID, "antiraidSignals", val)
+}
+
+func (m *PostgresMiddleware) GetAntiraidLockdown(guildID string) (cfg models.AntiraidLockdown, err error) {
+	val, err := m.getGuildSetting(guildID, "antiraidLockdown")
+	if err != nil {
+		return
+	}
+	if val == "" {
+		return cfg, database.ErrDatabaseNotFound
+	}
+	err = json.Unmarshal([]byte(val), &cfg)
+	return
+}
+
+func (m *PostgresMiddleware) SetAntiraidLockdown(guildID string, cfg models.AntiraidLockdown) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "antiraidLockdown", string(data))
+}
+
+func (m *PostgresMiddleware) SetAntiraidLockdownState(state models.AntiraidLockdownState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = m.Db.Exec(
+		"INSERT INTO antiraidLockdowns (guildID, data) VALUES ($1, $2) "+
+			"ON CONFLICT (guildID) DO UPDATE SET data = $2", state.GuildID, string(data))
+	return err
+}
+
+func (m *PostgresMiddleware) GetAntiraidLockdownState(guildID string) (state models.AntiraidLockdownState, err error) {
+	var data string
+	err = m.Db.QueryRow("SELECT data FROM antiraidLockdowns WHERE guildID = $1", guildID).Scan(&data)
+	if err = wrapNotFoundError(err); err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(data), &state)
+	return
+}
+
+func (m *PostgresMiddleware) GetAntiraidLockdownStates() ([]models.AntiraidLockdownState, error) {
+	rows, err := m.Db.Query("SELECT data FROM antiraidLockdowns")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []models.AntiraidLockdownState
+	for rows.Next() {
+		var data string
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var state models.AntiraidLockdownState
+		if err = json.Unmarshal([]byte(data), &state); err != nil {
+			m.log.Error().Err(err).Msg("An error occured reading lockdown state from database")
+			continue
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+func (m *PostgresMiddleware) DeleteAntiraidLockdownState(guildID string) error {
+	_, err := m.Db.Exec("DELETE FROM antiraidLockdowns WHERE guildID = $1", guildID)
+	return err
+}
+
+func (m *PostgresMiddleware) GetAntiraidJoinList(guildID string) (res []models.JoinLogEntry, err error) {
+	query := `SELECT userID, tag, accountCreated, "timestamp", guildID FROM antiraidJoinlog`
+	var args []interface{}
+
+	if guildID != "" {
+		query += " WHERE guildID = $1"
+		args = []interface{}{guildID}
+	}
+
+	rows, err := m.Db.Query(query, args...)
+	if err != nil {
+		return
+	}
+
+	for rows.Next() {
+		entry := models.JoinLogEntry{GuildID: guildID}
+		if err = rows.Scan(&entry.UserID, &entry.Tag, &entry.Created, &entry.Timestamp, &entry.GuildID); err != nil {
+			return
+		}
+		res = append(res, entry)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) FlushAntiraidJoinList(guildID string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM antiraidJoinlog WHERE guildID = $1", guildID)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) RemoveAntiraidJoinList(guildID, userID string) (err error) {
+	_, err = m.Db.Exec(`DELETE FROM antiraidJoinlog WHERE guildID = $1 AND userID = $2`, guildID, userID)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetGuildUnbanRequests(guildID string, limit, offset int) (r []models.UnbanRequest, err error) {
+	rows, err := m.Db.Query(`
+		SELECT id, userID, guildID, userTag, message, processedBy, status, processed, processedMessage, reportID
+		FROM unbanRequests
+		WHERE guildID = $1
+		ORDER BY id DESC
+		LIMIT $2
+		OFFSET $3
+	`, guildID, limit, offset)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	r = make([]models.UnbanRequest, 0)
+	for rows.Next() {
+		var req models.UnbanRequest
+		if err = rows.Scan(
+			&req.ID, &req.UserID, &req.GuildID, &req.UserTag, &req.Message,
+			&req.ProcessedBy, &req.Status, &req.Processed, &req.ProcessedMessage,
+			&req.ReportID,
+		); err != nil {
+			return
+		}
+		r = append(r, req)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) GetGuildUnbanRequestsCount(guildID string, state *models.UnbanRequestState) (n int, err error) {
+	query := "SELECT COUNT(id) FROM unbanRequests WHERE guildID = $1"
+	params := []interface{}{guildID}
+	if state != nil {
+		query += " AND status = $2"
+		params = append(params, *state)
+	}
+	err = m.Db.QueryRow(
+		query,
+		params...).Scan(&n)
+	return n, err
+}
+
+func (m *PostgresMiddleware) GetGuildUserUnbanRequests(userID, guildID string) (r []models.UnbanRequest, err error) {
+	query := `SELECT id, userID, guildID, userTag, message, processedBy, status, processed, processedMessage, reportID
+		FROM unbanRequests
+		WHERE userID = $1`
+	params := []interface{}{userID}
+
+	if guildID != "" {
+		query += " AND guildID = $2"
+		params = append(params, guildID)
+	}
+
+	rows, err := m.Db.Query(query, params...)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	r = make([]models.UnbanRequest, 0)
+	for rows.Next() {
+		var req models.UnbanRequest
+		if err = rows.Scan(
+			&req.ID, &req.UserID, &req.GuildID, &req.UserTag, &req.Message,
+			&req.ProcessedBy, &req.Status, &req.Processed, &req.ProcessedMessage,
+			&req.ReportID,
+		); err != nil {
+			return
+		}
+		r = append(r, req)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) GetUnbanRequest(id string) (r models.UnbanRequest, err error) {
+	row := m.Db.QueryRow(
+		`SELECT id, userID, guildID, userTag, message, processedBy, status, processed, processedMessage, reportID
+		FROM unbanRequests
+		WHERE id = $1`, id)
+
+	err = row.Scan(
+		&r.ID, &r.UserID, &r.GuildID, &r.UserTag, &r.Message,
+		&r.ProcessedBy, &r.Status, &r.Processed, &r.ProcessedMessage,
+		&r.ReportID,
+	)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) AddUnbanRequest(r models.UnbanRequest) (err error) {
+	_, err = m.Db.Exec(
+		`INSERT INTO unbanRequests
+		(id, userID, guildID, userTag, message, processedBy, status, processed, processedMessage, reportID)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		r.ID, r.UserID, r.GuildID, r.UserTag, r.Message, r.ProcessedBy,
+		r.Status, r.Processed, r.ProcessedMessage, r.ReportID)
+
+	return
+}
+
+func (m *PostgresMiddleware) UpdateUnbanRequest(r models.UnbanRequest) (err error) {
+	_, err = m.Db.Exec(
+		`UPDATE unbanRequests
+		SET processedBy = $1, status = $2, processed = $3, processedMessage = $4
+		WHERE id = $5`,
+		r.ProcessedBy, r.Status, r.Processed, r.ProcessedMessage,
+		r.ID)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) GetUserOTAEnabled(userID string) (enabled bool, err error) {
+	v, err := m.getUserSetting(userID, "enableOTA")
+	enabled = v == "1"
+	return
+}
+
+func (m *PostgresMiddleware) SetUserOTAEnabled(userID string, enabled bool) error {
+	v := "0"
+	if enabled {
+		v = "1"
+	}
+	return m.setUserSetting(userID, "enableOTA", v)
+}
+
+func (m *PostgresMiddleware) GetUserVerified(userID string) (enabled bool, err error) {
+	v, err := m.getUserSetting(userID, "verified")
+	enabled = v == "1"
+	return
+}
+
+func (m *PostgresMiddleware) SetUserVerified(userID string, enabled bool) error {
+	v := "0"
+	if enabled {
+		v = "1"
+	}
+	return m.setUserSetting(userID, "verified", v)
+}
+
+func (m *PostgresMiddleware) GetUserStarboardOptout(userID string) (enabled bool, err error) {
+	v, err := m.getUserSetting(userID, "starboardOptout")
+	enabled = v == "1"
+	return
+}
+
+func (m *PostgresMiddleware) SetUserStarboardOptout(userID string, enabled bool) error {
+	v := "0"
+	if enabled {
+		v = "1"
+	}
+	return m.setUserSetting(userID, "starboardOptout", v)
+}
+
+func (m *PostgresMiddleware) GetUserTimezone(userID string) (string, error) {
+	return m.getUserSetting(userID, "timezone")
+}
+
+func (m *PostgresMiddleware) SetUserTimezone(userID string, timezone string) error {
+	return m.setUserSetting(userID, "timezone", timezone)
+}
+
+func (m *PostgresMiddleware) GetGuildVoiceLogIgnores(guildID string) (res []string, err error) {
+	row, err := m.Db.Query("SELECT channelID FROM voicelogBlocklist WHERE guildID = $1", guildID)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	res = make([]string, 0)
+	var id string
+	for row.Next() {
+		if err = row.Scan(&id); err != nil {
+			return
+		}
+		res = append(res, id)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) IsGuildVoiceLogIgnored(guildID, channelID string) (ok bool, err error) {
+	err = m.Db.QueryRow("SELECT 1 FROM voicelogBlocklist WHERE guildID = $1 AND channelID = $2",
+		guildID, channelID).Scan(&ok)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+
+	err = nil
+
+	return
+}
+
+func (m *PostgresMiddleware) SetGuildVoiceLogIngore(guildID, channelID string) (err error) {
+	if ok, err := m.IsGuildVoiceLogIgnored(guildID, channelID); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	_, err = m.Db.Exec("INSERT INTO voicelogBlocklist (guildID, channelID) VALUES ($1, $2)",
+		guildID, channelID)
+	return
+}
+
+func (m *PostgresMiddleware) RemoveGuildVoiceLogIgnore(guildID, channelID string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM voicelogBlocklist WHERE guildID = $1 AND channelID = $2",
+		guildID, channelID)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) SetStarboardConfig(config models.StarboardConfig) (err error) {
+	var ok bool
+	m.Db.QueryRow("SELECT 1 FROM starboards WHERE guildID = $1 AND name = $2",
+		config.GuildID, config.Name).Scan(&ok)
+
+	channels := strings.Join(config.Channels, ";")
+	excludedChannels := strings.Join(config.ExcludedChannels, ";")
+
+	if ok {
+		_, err = m.Db.Exec(
+			"UPDATE starboards SET "+
+				"channelID = $1, threshold = $2, emojiID = $3, karmaGain = $4, channels = $5, "+
+				"excludedChannels = $6, nsfw = $7, nsfwChannelID = $8, minAge = $9 "+
+				"WHERE guildID = $10 AND name = $11",
+			config.ChannelID, config.Threshold, config.EmojiID, config.KarmaGain, channels,
+			excludedChannels, config.NSFW, config.NSFWChannelID, config.MinAge,
+			config.GuildID, config.Name)
+	} else {
+		_, err = m.Db.Exec(
+			"INSERT INTO starboards "+
+				"(guildID, name, channelID, threshold, emojiID, karmaGain, channels, "+
+				"excludedChannels, nsfw, nsfwChannelID, minAge) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+			config.GuildID, config.Name, config.ChannelID, config.Threshold, config.EmojiID, config.KarmaGain,
+			channels, excludedChannels, config.NSFW, config.NSFWChannelID, config.MinAge)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) GetStarboardConfig(guildID, name string) (config models.StarboardConfig, err error) {
+	row := m.Db.QueryRow(
+		"SELECT guildID, name, channelID, threshold, emojiID, karmaGain, channels, "+
+			"excludedChannels, nsfw, nsfwChannelID, minAge "+
+			"FROM starboards WHERE guildID = $1 AND name = $2", guildID, name)
+	config, err = scanStarboardConfig(row)
+	err = wrapNotFoundError(err)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetStarboardConfigs(guildID string) (res []models.StarboardConfig, err error) {
+	rows, err := m.Db.Query(
+		"SELECT guildID, name, channelID, threshold, emojiID, karmaGain, channels, "+
+			"excludedChannels, nsfw, nsfwChannelID, minAge "+
+			"FROM starboards WHERE guildID = $1 ORDER BY name ASC", guildID)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	res = make([]models.StarboardConfig, 0)
+	for rows.Next() {
+		var config models.StarboardConfig
+		if config, err = scanStarboardConfig(rows); err != nil {
+			return
+		}
+		res = append(res, config)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) RemoveStarboardConfig(guildID, name string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM starboards WHERE guildID = $1 AND name = $2", guildID, name)
+	return
+}
+
+func (m *PostgresMiddleware) SetStarboardEntry(e models.StarboardEntry) (err error) {
+	var ok bool
+	m.Db.QueryRow("SELECT 1 FROM starboardEntries WHERE messageID = $1 AND board = $2",
+		e.MessageID, e.Board).Scan(&ok)
+
+	if ok {
+		_, err = m.Db.Exec(
+			"UPDATE starboardEntries SET "+
+				"score = $1, deleted = $2, starboardID = $3 "+
+				"WHERE messageID = $4 AND board = $5",
+			e.Score, e.Deleted, e.StarboardID, e.MessageID, e.Board)
+	} else {
+		_, err = m.Db.Exec(
+			"INSERT INTO starboardEntries "+
+				"(messageID, starboardID, board, guildID, channelID, authorID, content, mediaURLs, score, deleted) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+			e.MessageID, e.StarboardID, e.Board, e.GuildID, e.ChannelID, e.AuthorID, e.Content, e.MediaURLsEncoded(), e.Score, e.Deleted)
+	}
+	return
+}
+
+func (m *PostgresMiddleware) RemoveStarboardEntry(msgID string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM starboardEntries WHERE messageID = $1", msgID)
+	return
+}
+
+func (m *PostgresMiddleware) GetStarboardEntries(
+	guildID string,
+	sortBy models.StarboardSortBy,
+	limit, offset int,
+) (res []models.StarboardEntry, err error) {
+	var sort string
+	switch sortBy {
+	case models.StarboardSortByLatest:
+		sort = "ORDER BY starboardID DESC"
+	case models.StarboardSortByMostRated:
+		sort = "ORDER BY score DESC"
+	}
+
+	query := fmt.Sprintf("SELECT messageID, starboardID, board, guildID, channelID, authorID, content, mediaURLs, score, deleted "+
+		"FROM starboardEntries "+
+		"WHERE guildID = $1 %s LIMIT %d OFFSET %d", sort, limit, offset)
+	row, err := m.Db.Query(query, guildID)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	res = make([]models.StarboardEntry, 0)
+	for row.Next() {
+		var e models.StarboardEntry
+		var mediaURLencoded string
+		err = row.Scan(&e.MessageID, &e.StarboardID, &e.Board, &e.GuildID, &e.ChannelID, &e.AuthorID, &e.Content, &mediaURLencoded, &e.Score, &e.Deleted)
+		if err != nil {
+			return
+		}
+		if err = e.SetMediaURLs(mediaURLencoded); err != nil {
+			return
+		}
+		res = append(res, e)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) GetStarboardEntriesCount(guildID string) (n int, err error) {
+	err = m.Db.QueryRow(
+		"SELECT COUNT(messageID) FROM starboardEntries WHERE guildID = $1", guildID).Scan(&n)
+	return
+}
+
+func (m *PostgresMiddleware) GetStarboardEntry(messageID, board string) (e models.StarboardEntry, err error) {
+	var mediaURLencoded string
+	err = m.Db.QueryRow(
+		"SELECT messageID, starboardID, board, guildID, channelID, authorID, content, mediaURLs, score, deleted "+
+			"FROM starboardEntries "+
+			"WHERE messageID = $1 AND board = $2",
+		messageID, board).
+		Scan(&e.MessageID, &e.StarboardID, &e.Board, &e.GuildID, &e.ChannelID, &e.AuthorID, &e.Content, &mediaURLencoded, &e.Score, &e.Deleted)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+	err = e.SetMediaURLs(mediaURLencoded)
+
+	return
+}
+
+func (m *PostgresMiddleware) GetUserByRefreshToken(token string) (userID string, expires time.Time, err error) {
+	err = m.Db.QueryRow("SELECT userID, expires FROM refreshTokens WHERE token = $1", token).Scan(
+		&userID, &expires)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) SetUserRefreshToken(userID, token string, expires time.Time) (err error) {
+	res, err := m.Db.Exec(
+		"UPDATE refreshTokens SET "+
+			"token = $1, expires = $2 "+
+			"WHERE userID = $3",
+		token, expires, userID)
+	if err != nil {
+		return
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if ar == 0 {
+		_, err = m.Db.Exec(
+			"INSERT INTO refreshTokens "+
+				"(userID, token, expires) "+
+				"VALUES ($1, $2, $3)",
+			userID, token, expires)
+	}
+	return
+}
+
+func (m *PostgresMiddleware) RevokeUserRefreshToken(userID string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM refreshTokens WHERE userID = $1", userID)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) CleanupExpiredRefreshTokens() (n int64, err error) {
+	res, err := m.Db.Exec("DELETE FROM refreshTokens WHERE expires < CURRENT_TIMESTAMP")
+	if err != nil {
+		return
+	}
+
+	n, err = res.RowsAffected()
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaRules(guildID string) (res []models.KarmaRule, err error) {
+	rows, err := m.Db.Query("SELECT id, trigger, value, action, argument, checksum, "+
+		"maxValue, channelID, duration, cooldown, channels, roles "+
+		"FROM karmaRules WHERE guildID = $1", guildID)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	res = make([]models.KarmaRule, 0)
+	for rows.Next() {
+		var (
+			r               models.KarmaRule
+			channels, roles string
+		)
+		r.GuildID = guildID
+		if err = rows.Scan(&r.ID, &r.Trigger, &r.Value, &r.Action, &r.Argument, &r.Checksum,
+			&r.MaxValue, &r.ChannelID, &r.Duration, &r.Cooldown, &channels, &roles); err != nil {
+			return
+		}
+		r.Channels = splitIDs(channels)
+		r.Roles = splitIDs(roles)
+		res = append(res, r)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) CheckKarmaRule(guildID, checksum string) (ok bool, err error) {
+	err = m.Db.QueryRow("SELECT 1 FROM karmaRules WHERE guildID = $1 AND checksum = $2",
+		guildID, checksum).Scan(&ok)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+
+	err = nil
+
+	return
+}
+
+func (m *PostgresMiddleware) AddOrUpdateKarmaRule(rule models.KarmaRule) (err error) {
+	var exists bool
+	err = m.Db.QueryRow("SELECT 1 FROM karmaRules WHERE id = $1",
+		rule.ID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+
+	channels := strings.Join(rule.Channels, ";")
+	roles := strings.Join(rule.Roles, ";")
+
+	if exists {
+		_, err = m.Db.Exec("UPDATE karmaRules "+
+			"SET trigger = $1, value = $2, action = $3, argument = $4, checksum = $5, "+
+			"maxValue = $6, channelID = $7, duration = $8, cooldown = $9, channels = $10, roles = $11 "+
+			"WHERE guildID = $12 AND id = $13",
+			rule.Trigger, rule.Value, rule.Action, rule.Argument, rule.Checksum,
+			rule.MaxValue, rule.ChannelID, rule.Duration, rule.Cooldown, channels, roles,
+			rule.GuildID, rule.ID)
+	} else {
+		_, err = m.Db.Exec("INSERT INTO karmaRules "+
+			"(id, guildID, trigger, value, action, argument, checksum, "+
+			"maxValue, channelID, duration, cooldown, channels, roles) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+			rule.ID, rule.GuildID, rule.Trigger, rule.Value, rule.Action, rule.Argument, rule.Checksum,
+			rule.MaxValue, rule.ChannelID, rule.Duration, rule.Cooldown, channels, roles)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) RemoveKarmaRule(guildID string, id snowflake.ID) (err error) {
+	_, err = m.Db.Exec("DELETE FROM karmaRules WHERE guildID = $1 AND id = $2", guildID, id)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) SetKarmaTempRole(tr models.KarmaTempRole) error {
+	_, err := m.Db.Exec("DELETE FROM karmaTempRoles WHERE guildID = $1 AND userID = $2 AND roleID = $3",
+		tr.GuildID, tr.UserID, tr.RoleID)
+	if err != nil {
+		return err
+	}
+	_, err = m.Db.Exec("INSERT INTO karmaTempRoles (guildID, userID, roleID, expires) VALUES ($1, $2, $3, $4)",
+		tr.GuildID, tr.UserID, tr.RoleID, tr.Expires)
+	return err
+}
+
+func (m *PostgresMiddleware) GetExpiredKarmaTempRoles(now time.Time) ([]models.KarmaTempRole, error) {
+	rows, err := m.Db.Query("SELECT guildID, userID, roleID, expires FROM karmaTempRoles WHERE expires <= $1",
+		now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]models.KarmaTempRole, 0)
+	for rows.Next() {
+		var tr models.KarmaTempRole
+		if err = rows.Scan(&tr.GuildID, &tr.UserID, &tr.RoleID, &tr.Expires); err != nil {
+			return nil, err
+		}
+		res = append(res, tr)
+	}
+	return res, nil
+}
+
+func (m *PostgresMiddleware) RemoveKarmaTempRole(guildID, userID, roleID string) error {
+	_, err := m.Db.Exec("DELETE FROM karmaTempRoles WHERE guildID = $1 AND userID = $2 AND roleID = $3",
+		guildID, userID, roleID)
+	return err
+}
+
+func (m *PostgresMiddleware) AddKarmaLedgerEntry(entry models.KarmaLedgerEntry) error {
+	_, err := m.Db.Exec(`
+		INSERT INTO karmaLedger (id, guildID, executorID, targetID, delta, source, timestamp, revertedBy)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		entry.ID, entry.GuildID, entry.ExecutorID, entry.TargetID, entry.Delta, entry.Source, entry.Timestamp, entry.RevertedBy)
+	return err
+}
+
+func (m *PostgresMiddleware) GetKarmaLedger(guildID, userID string, offset, limit int) ([]models.KarmaLedgerEntry, error) {
+	rows, err := m.Db.Query(`
+		SELECT id, guildID, executorID, targetID, delta, source, timestamp, revertedBy
+		FROM karmaLedger
+		WHERE guildID = $1 AND ($2 = '' OR targetID = $2 OR executorID = $2)
+		ORDER BY timestamp DESC
+		LIMIT $3 OFFSET $4`,
+		guildID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanKarmaLedger(rows)
+}
+
+func (m *PostgresMiddleware) GetKarmaLedgerGiven(guildID, executorID string, from, to time.Time) ([]models.KarmaLedgerEntry, error) {
+	rows, err := m.Db.Query(`
+		SELECT id, guildID, executorID, targetID, delta, source, timestamp, revertedBy
+		FROM karmaLedger
+		WHERE guildID = $1 AND executorID = $2 AND revertedBy = '0'
+		AND timestamp >= $3 AND timestamp <= $4
+		ORDER BY timestamp ASC`,
+		guildID, executorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return scanKarmaLedger(rows)
+}
+
+func (m *PostgresMiddleware) SetKarmaLedgerReverted(id, revertedBy snowflake.ID) error {
+	_, err := m.Db.Exec("UPDATE karmaLedger SET revertedBy = $1 WHERE id = $2",
+		revertedBy, id)
+	return err
+}
+
+func (m *PostgresMiddleware) GetKarmaLedgerPairCount(guildID, executorID, targetID string, since time.Time) (count int, err error) {
+	err = m.Db.QueryRow(`
+		SELECT COUNT(*)
+		FROM karmaLedger
+		WHERE guildID = $1 AND executorID = $2 AND targetID = $3
+		AND source = 'REACTION' AND revertedBy = '0' AND timestamp >= $4`,
+		guildID, executorID, targetID, since).Scan(&count)
+	return
+}
+
+func (m *PostgresMiddleware) GetKarmaLeaderboard(guildID string, since time.Time, limit int) ([]models.GuildKarma, error) {
+	rows, err := m.Db.Query(`
+		SELECT targetID, SUM(delta) AS total
+		FROM karmaLedger
+		WHERE guildID = $1 AND timestamp >= $2 AND revertedBy = '0'
+		AND id NOT IN (SELECT revertedBy FROM karmaLedger WHERE guildID = $1 AND revertedBy != '0')
+		GROUP BY targetID
+		ORDER BY total DESC
+		LIMIT $3`,
+		guildID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]models.GuildKarma, 0)
+	for rows.Next() {
+		v := models.GuildKarma{GuildID: guildID}
+		if err = rows.Scan(&v.UserID, &v.Value); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+func (m *PostgresMiddleware) GetGuildLogDisable(guildID string) (bool, error) {
+	val, err := m.getGuildSetting(guildID, "guildlogDisable")
+	return val == "1", err
+}
+
+func (m *PostgresMiddleware) SetGuildLogDisable(guildID string, enabled bool) error {
+	var val string
+	if enabled {
+		val = "1"
+	}
+	return m.setGuildSetting(guildID, "guildlogDisable", val)
+}
+
+func (m *PostgresMiddleware) GetGuildLogEntries(
+	guildID string,
+	offset, limit int,
+	severity models.GuildLogSeverity,
+	ascending bool,
+) (res []models.GuildLogEntry, err error) {
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+	rows, err := m.Db.Query(
+		`SELECT id, module, message, severity, "timestamp" `+
+			"FROM guildlog "+
+			"WHERE guildID = $1 AND ($2 < 0 OR severity = $3) "+
+			`ORDER BY "timestamp" `+order+" "+
+			"LIMIT $5 OFFSET $4",
+		guildID, severity, severity,
+		offset, limit)
+	err = wrapNotFoundError(err)
+	if err != nil {
+		return
+	}
+
+	res = make([]models.GuildLogEntry, 0)
+	for rows.Next() {
+		var r models.GuildLogEntry
+		r.GuildID = guildID
+		if err = rows.Scan(&r.ID, &r.Module, &r.Message, &r.Severity, &r.Timestamp); err != nil {
+			return
+		}
+		res = append(res, r)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) GetGuildLogEntriesCount(guildID string, severity models.GuildLogSeverity) (n int, err error) {
+	err = m.Db.QueryRow(
+		"SELECT COUNT(id) FROM guildlog WHERE guildID = $1 AND ($2 < 0 OR severity = $3)",
+		guildID, severity, severity).Scan(&n)
+	return
+}
+
+func (m *PostgresMiddleware) AddGuildLogEntry(e models.GuildLogEntry) (err error) {
+	_, err = m.Db.Exec(
+		`INSERT INTO guildlog (id, guildID, module, message, severity, "timestamp") `+
+			"VALUES ($1, $2, $3, $4, $5, $6)",
+		e.ID, e.GuildID, e.Module, e.Message, e.Severity, e.Timestamp)
+	return
+}
+
+func (m *PostgresMiddleware) DeleteLogEntry(guildID string, id snowflake.ID) (err error) {
+	_, err = m.Db.Exec("DELETE FROM guildlog WHERE guildID = $1 AND id = $2",
+		guildID, id)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) DeleteLogEntries(guildID string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM guildlog WHERE guildID = $1", guildID)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) GetAllGuildIDs() (guildIDs []string, err error) {
+	tables := append(slices.Clone(guildTables), "roleselect")
+	queries := make([]string, len(tables))
+	for i, table := range tables {
+		queries[i] = "SELECT guildID FROM " + table
+	}
+
+	rows, err := m.Db.Query(strings.Join(queries, " UNION "))
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	guildIDs = make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+		guildIDs = append(guildIDs, id)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (m *PostgresMiddleware) FlushGuildData(guildID string) (err error) {
+	tx, err := m.Db.Begin()
+	if err != nil {
+		return
+	}
+
+	deleteFrom := func(table string) error {
+		_, err = tx.Exec(
+			fmt.Sprintf("DELETE FROM %s WHERE guildID = $1", table),
+			guildID)
+		return err
+	}
+
+	mErr := multierror.New()
+	for _, table := range guildTables {
+		mErr.Append(deleteFrom(table))
+	}
+
+	if mErr.Len() > 0 {
+		return mErr
+	}
+
+	return tx.Commit()
+}
+
+func (m *PostgresMiddleware) GetGuildAPI(guildID string) (settings models.GuildAPISettings, err error) {
+	err = m.Db.QueryRow(`SELECT enabled, origins, tokenHash FROM guildapi WHERE guildID = $1`, guildID).
+		Scan(&settings.Enabled, &settings.AllowedOrigins, &settings.TokenHash)
+	err = wrapNotFoundError(err)
+	return
+}
+
+func (m *PostgresMiddleware) SetGuildAPI(guildID string, settings models.GuildAPISettings) (err error) {
+	var ok bool
+	m.Db.QueryRow("SELECT 1 FROM guildapi WHERE guildID = $1",
+		guildID).Scan(&ok)
+
+	if ok {
+		_, err = m.Db.Exec(`
+			UPDATE guildapi
+			SET enabled = $1, origins = $2, tokenHash = $3
+			WHERE guildID = $4`,
+			settings.Enabled, settings.AllowedOrigins, settings.TokenHash, guildID)
+	} else {
+		_, err = m.Db.Exec(`
+			INSERT INTO guildapi
+			(guildID, enabled, origins, tokenHash)
+			VALUES ($1, $2, $3, $4)`,
+			guildID, settings.Enabled, settings.AllowedOrigins, settings.TokenHash)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) GetGuildVerificationRequired(guildID string) (bool, error) {
+	val, err := m.getGuildSetting(guildID, "requireUserVerification")
+	return val == "1", err
+}
+
+func (m *PostgresMiddleware) SetGuildVerificationRequired(guildID string, enable bool) error {
+	var val string
+	if enable {
+		val = "1"
+	}
+	return m.setGuildSetting(guildID, "requireUserVerification", val)
+}
+
+func (m *PostgresMiddleware) GetVerificationQueue(guildID, userID string) (res []models.VerificationQueueEntry, err error) {
+	var args []interface{}
+	query := `
+		SELECT guildID, userID, "timestamp"
+		FROM verificationQueue
+		WHERE true
+	`
+	if guildID != "" {
+		args = append(args, guildID)
+		query += fmt.Sprintf(" AND guildID = $%d", len(args))
+	}
+	if userID != "" {
+		args = append(args, userID)
+		query += fmt.Sprintf(" AND userID = $%d", len(args))
+	}
+
+	rows, err := m.Db.Query(query, args...)
+	if err != nil {
+		return
+	}
+
+	for rows.Next() {
+		var r models.VerificationQueueEntry
+		if err = rows.Scan(&r.GuildID, &r.UserID, &r.Timestamp); err != nil {
+			return
+		}
+		res = append(res, r)
+	}
+	return
+}
+
+func (m *PostgresMiddleware) FlushVerificationQueue(guildID string) (err error) {
+	var args []interface{}
+	query := `DELETE FROM verificationQueue`
+	if guildID != "" {
+		args = []interface{}{guildID}
+		query += " WHERE guildID = $1"
+	}
+	_, err = m.Db.Exec(query, args...)
+	return err
+}
+
+func (m *PostgresMiddleware) AddVerificationQueue(e models.VerificationQueueEntry) (err error) {
+	res, err := m.Db.Exec(`
+		UPDATE verificationQueue
+		SET "timestamp" = $1
+		WHERE guildID = $2 AND userID = $3
+	`, e.Timestamp, e.GuildID, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+
+	if affected == 0 {
+		_, err = m.Db.Exec(`
+			INSERT INTO verificationQueue (guildID, userID, "timestamp")
+			VALUES ($1, $2, $3)
+		`, e.GuildID, e.UserID, e.Timestamp)
+	}
+	return
+}
+
+func (m *PostgresMiddleware) RemoveVerificationQueue(guildID, userID string) (ok bool, err error) {
+	res, err := m.Db.Exec(`
+		DELETE FROM verificationQueue
+		WHERE guildID = $1 AND userID = $2
+	`, guildID, userID)
+	if err != nil {
+		return ok, err
+	}
+
+	affected, err := res.RowsAffected()
+	ok = affected > 0
+	return ok, wrapNotFoundError(err)
+}
+
+func (m *PostgresMiddleware) FlushUserData(userID string) (res map[string]int, err error) {
+	res = make(map[string]int)
+
+	r, err := m.Db.Exec(`
+		UPDATE reports
+		SET executorID = '000000000000000000'
+		WHERE executorID = $1 AND victimID != $2
+	`, userID, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	affected, err := r.RowsAffected()
+	if err != nil {
+		return
+	}
+	res["reports"] = int(affected)
+
+	r, err = m.Db.Exec(`
+		UPDATE reportRevisions
+		SET editorID = '000000000000000000'
+		WHERE editorID = $1
+	`, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	affected, err = r.RowsAffected()
+	if err != nil {
+		return
+	}
+	res["reportRevisions"] = int(affected)
+
+	r, err = m.Db.Exec(`
+		DELETE FROM karma
+		WHERE userID = $1
+		AND value >= 0
+	`, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	affected, err = r.RowsAffected()
+	if err != nil {
+		return
+	}
+	res["karma"] = int(affected)
+
+	r, err = m.Db.Exec(`
+		UPDATE karmaLedger
+		SET executorID = '000000000000000000'
+		WHERE executorID = $1
+	`, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	affected, err = r.RowsAffected()
+	if err != nil {
+		return
+	}
+	res["karmaLedger"] = int(affected)
+
+	r, err = m.Db.Exec(`
+		DELETE FROM karmaLedger
+		WHERE targetID = $1
+		AND NOT EXISTS (
+			SELECT 1 FROM karma
+			WHERE karma.userID = karmaLedger.targetID
+			AND karma.guildID = karmaLedger.guildID
+		)
+	`, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+	affected, err = r.RowsAffected()
+	if err != nil {
+		return
+	}
+	res["karmaLedger"] += int(affected)
+
+	for _, tc := range userTables {
+		r, err = m.Db.Exec(fmt.Sprintf(`
+			DELETE FROM %s
+			WHERE %s = $1
+		`, tc.Table, tc.Column), userID)
+		if err != nil && err != sql.ErrNoRows {
+			return
+		}
+		affected, err = r.RowsAffected()
+		if err != nil {
+			return
+		}
+		res[tc.Table] = int(affected)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) GetGuildBirthdayChan(guildID string) (chanID string, err error) {
+	chanID, err = m.getGuildSetting(guildID, "birthdaychanID")
+	return
+}
+
+func (m *PostgresMiddleware) SetGuildBirthdayChan(guildID string, chanID string) (err error) {
+	err = m.setGuildSetting(guildID, "birthdaychanID", chanID)
+	return
+}
+
+func (m *PostgresMiddleware) GetGuildBirthdayTemplate(guildID string) (string, error) {
+	return m.getGuildSetting(guildID, "birthdayTemplate")
+}
+
+func (m *PostgresMiddleware) SetGuildBirthdayTemplate(guildID string, template string) error {
+	return m.setGuildSetting(guildID, "birthdayTemplate", template)
+}
+
+func (m *PostgresMiddleware) GetGuildBirthdayRole(guildID string) (string, error) {
+	return m.getGuildSetting(guildID, "birthdayRoleID")
+}
+
+func (m *PostgresMiddleware) SetGuildBirthdayRole(guildID string, roleID string) error {
+	return m.setGuildSetting(guildID, "birthdayRoleID", roleID)
+}
+
+func (m *PostgresMiddleware) GetBirthdays(guildID string) (bd []models.Birthday, err error) {
+	query := `SELECT guildID, userID, "date", showYear, lastNotified, roleExpires FROM birthdays`
+	var params []interface{}
+
+	if guildID != "" {
+		query += " WHERE guildID = $1"
+		params = []interface{}{guildID}
+	}
+
+	rows, err := m.Db.Query(query, params...)
+	if err != nil {
+		err = wrapNotFoundError(err)
+		return
+	}
+
+	for rows.Next() {
+		var (
+			b                         models.Birthday
+			lastNotified, roleExpires int64
+		)
+		err = rows.Scan(&b.GuildID, &b.UserID, &b.Date, &b.ShowYear, &lastNotified, &roleExpires)
+		if err != nil {
+			return
+		}
+		b.LastNotified = fromUnix(lastNotified)
+		b.RoleExpires = fromUnix(roleExpires)
+		bd = append(bd, b)
+	}
+
+	return
+}
+
+func (m *PostgresMiddleware) SetBirthday(bd models.Birthday) (err error) {
+	res, err := m.Db.Exec(
+		`UPDATE birthdays SET "date" = $1, showYear = $2, lastNotified = $3, roleExpires = $4 `+
+			"WHERE guildID = $5 AND userID = $6",
+		bd.Date, bd.ShowYear, toUnix(bd.LastNotified), toUnix(bd.RoleExpires), bd.GuildID, bd.UserID)
+	if err != nil {
+		return wrapNotFoundError(err)
+	}
+	ar, err := res.RowsAffected()
+	if ar == 0 {
+		_, err = m.Db.Exec(
+			`INSERT INTO birthdays (guildID, userID, "date", showYear, lastNotified, roleExpires) `+
+				"VALUES ($1, $2, $3, $4, $5, $6)", bd.GuildID, bd.UserID, bd.Date, bd.ShowYear,
+			toUnix(bd.LastNotified), toUnix(bd.RoleExpires))
+	}
+	return wrapNotFoundError(err)
+}
+
+func (m *PostgresMiddleware) DeleteBirthday(guildID, userID string) (err error) {
+	_, err = m.Db.Exec("DELETE FROM birthdays WHERE guildID = $1 AND userID = $2",
+		guildID, userID)
+	return wrapNotFoundError(err)
+}
+
+func (m *PostgresMiddleware) AddRoleSelects(v []models.RoleSelect) error {
+	tx, err := m.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, rs := range v {
+		_, err = tx.Exec(`
+			INSERT INTO roleselect (guildID, channelID, messageID, roleID, label, emoji, position, mode, maxRoles, requiredRoles, menu)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT DO NOTHING
+		`, rs.GuildID, rs.ChannelID, rs.MessageID, rs.RoleID, rs.Label, rs.Emoji, rs.Position,
+			rs.Mode, rs.MaxRoles, strings.Join(rs.RequiredRoles, ";"), rs.Menu)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+
+	return wrapNotFoundError(err)
+}
+
+func (m *PostgresMiddleware) GetRoleSelects() ([]models.RoleSelect, error) {
+	rows, err := m.Db.Query(`
+		SELECT guildID, channelID, messageID, roleID, label, emoji, position, mode, maxRoles, requiredRoles, menu
+		FROM roleselect
+	`)
+	if err != nil {
+		return nil, wrapNotFoundError(err)
+	}
+	defer rows.Close()
+
+	var rs []models.RoleSelect
+	for rows.Next() {
+		r, err := scanRoleSelect(rows)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+
+	return rs, nil
+}
+
+func (m *PostgresMiddleware) GetGuildRoleSelects(guildID string) ([]models.RoleSelect, error) {
+	rows, err := m.Db.Query(`
+		SELECT guildID, channelID, messageID, roleID, label, emoji, position, mode, maxRoles, requiredRoles, menu
+		FROM roleselect
+		WHERE guildID = $1
+	`, guildID)
+	if err != nil {
+		return nil, wrapNotFoundError(err)
+	}
+	defer rows.Close()
+
+	var rs []models.RoleSelect
+	for rows.Next() {
+		r, err := scanRoleSelect(rows)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+
+	return rs, nil
+}
+
+func (m *PostgresMiddleware) SetRoleSelects(guildID, channelID, messageID string, v []models.RoleSelect) error {
+	tx, err := m.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM roleselect
+		WHERE guildID = $1 AND channelID = $2 AND messageID = $3
+	`, guildID, channelID, messageID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, rs := range v {
+		_, err = tx.Exec(`
+			INSERT INTO roleselect (guildID, channelID, messageID, roleID, label, emoji, position, mode, maxRoles, requiredRoles, menu)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, rs.GuildID, rs.ChannelID, rs.MessageID, rs.RoleID, rs.Label, rs.Emoji, rs.Position,
+			rs.Mode, rs.MaxRoles, strings.Join(rs.RequiredRoles, ";"), rs.Menu)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *PostgresMiddleware) RemoveRoleSelect(guildID, channelID, messageID string) error {
+	_, err := m.Db.Exec(`
+		DELETE FROM roleselect
+		WHERE guildID = $1 AND channelID = $2 AND messageID = $3
+	`, guildID, channelID, messageID)
+	return wrapNotFoundError(err)
+}
+
+func (m *PostgresMiddleware) GetGuildModNot(guildID string) (string, error) {
+	val, err := m.getGuildSetting(guildID, "modnotchanID")
+	return val, err
+}
+
+func (m *PostgresMiddleware) SetGuildModNot(guildID, chanID string) error {
+	return m.setGuildSetting(guildID, "modnotchanID", chanID)
+}
+
+/////////// HELPER ///////////////
+
+func wrapNotFoundError(err error) error {
+	if err == sql.ErrNoRows {
+		err = database.ErrDatabaseNotFound
+	}
+	return err
+}
+
+func scanKarmaLedger(rows *sql.Rows) ([]models.KarmaLedgerEntry, error) {
+	defer rows.Close()
+
+	res := make([]models.KarmaLedgerEntry, 0)
+	for rows.Next() {
+		var e models.KarmaLedgerEntry
+		err := rows.Scan(&e.ID, &e.GuildID, &e.ExecutorID, &e.TargetID,
+			&e.Delta, &e.Source, &e.Timestamp, &e.RevertedBy)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+// splitIDs splits a list of IDs joined by ";".
+func splitIDs(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ";")
+}
+
+// scanStarboardConfig scans a starboard config from a
+// single row or the current row of a result set.
+func scanStarboardConfig(row interface{ Scan(...interface{}) error }) (config models.StarboardConfig, err error) {
+	var channels, excludedChannels string
+	err = row.Scan(&config.GuildID, &config.Name, &config.ChannelID, &config.Threshold,
+		&config.EmojiID, &config.KarmaGain, &channels, &excludedChannels,
+		&config.NSFW, &config.NSFWChannelID, &config.MinAge)
+	if err != nil {
+		return
+	}
+	config.Channels = splitIDs(channels)
+	config.ExcludedChannels = splitIDs(excludedChannels)
+	return
+}
+
+// scanTag scans a tag from a single row or
+// the current row of a result set.
+func scanTag(row interface{ Scan(...interface{}) error }) (tg tag.Tag, err error) {
+	var (
+		timestampCreated  int64
+		timestampLastEdit int64
+		aliases, roles    string
+	)
+	err = row.Scan(&tg.ID, &tg.Ident, &tg.CreatorID, &tg.GuildID, &tg.Content,
+		&timestampCreated, &timestampLastEdit, &aliases, &roles, &tg.Uses)
+	if err != nil {
+		return
+	}
+
+	tg.Created = time.Unix(timestampCreated, 0)
+	tg.LastEdit = time.Unix(timestampLastEdit, 0)
+	tg.Aliases = splitIDs(aliases)
+	tg.Roles = splitIDs(roles)
+	return
+}
+
+func scanRoleSelect(row interface{ Scan(...interface{}) error }) (r models.RoleSelect, err error) {
+	var requiredRoles string
+	err = row.Scan(&r.GuildID, &r.ChannelID, &r.MessageID, &r.RoleID, &r.Label, &r.Emoji,
+		&r.Position, &r.Mode, &r.MaxRoles, &requiredRoles, &r.Menu)
+	if err != nil {
+		return
+	}
+
+	r.RequiredRoles = splitIDs(requiredRoles)
+	return
+}
+
+// toUnix returns the unix timestamp of t in
+// seconds or 0 if t is the zero time.
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// fromUnix returns the time of the unix timestamp
+// v in seconds or the zero time if v is 0.
+func fromUnix(v int64) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(v, 0)
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database/dbtest"
+)
+
+// These tests require a running PostgreSQL instance. You can start
+// one using the `postgres` service in the docker-compose.dev.yml
+// and pass its address via SP_TEST_POSTGRES_HOST, for example:
+//
+//	SP_TEST_POSTGRES_HOST=localhost:5432 go test ./internal/services/database/postgres/...
+func getTestDatabase(t *testing.T) *PostgresMiddleware {
+	host := os.Getenv("SP_TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("SP_TEST_POSTGRES_HOST is not set")
+	}
+
+	creds := models.DatabasePostgres{
+		DatabaseCreds: models.DatabaseCreds{
+			Host:     host,
+			User:     envOr("SP_TEST_POSTGRES_USER", "postgres"),
+			Password: envOr("SP_TEST_POSTGRES_PASSWORD", "dev"),
+			Database: envOr("SP_TEST_POSTGRES_DATABASE", "shinpuru"),
+		},
+	}
+
+	db := New()
+	require.NoError(t, db.Connect(creds))
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func TestMigrate(t *testing.T) {
+	db := getTestDatabase(t)
+
+	require.NoError(t, db.Migrate())
+	mig, err := db.getLatestMigration()
+	require.NoError(t, err)
+	require.Equal(t, len(migrationFuncs)-1, mig.Version)
+
+	// Applying migrations on an up to date
+	// database must be a no-op.
+	require.NoError(t, db.Migrate())
+}
+
+func TestDatabase(t *testing.T) {
+	db := getTestDatabase(t)
+	require.NoError(t, db.Migrate())
+
+	dbtest.Run(t, db)
+}
@@ -25,11 +25,13 @@ const (
 	keyGuildJDoodleKey             = "GUILD:JDOODLE"
 	keyGuildCodeExecEnabled        = "GUILD:CODEXECE"
 	keyGuildInviteBlock            = "GUILD:INVBLOCK"
+	keyGuildInviteBlockSettings    = "GUILD:INVBLOCKSETTINGS"
+	keyGuildAutomodRules           = "GUILD:AUTOMODRULES"
 	keyGuildBackupEnabled          = "GUILD:BACKUP"
 	keyGuildJoinMsg                = "GUILD:JOINMSG"
 	keyGuildLeaveMsg               = "GUILD:LEAVEMSG"
 	keyGuildColorReaction          = "GUILD:COLORREACTION"
-	keyGuildStarboardConfig        = "GUILD:STARBOARDCONFIG"
+	keyGuildStarboards             = "GUILD:STARBOARDS"
 	keyGuildLogEnable              = "GUILD:GUILDLOG"
 	keyGuildAPI                    = "GUILD:API"
 	keyGuildRequireVerificationAPI = "GUILD:REQVER"
@@ -40,6 +42,7 @@ const (
 	keyKarmaEmotesDec   = "KARMA:EMOTES:DEC"
 	keyKarmaTokens      = "KARMA:TOKENS"
 	keyKarmaPenalty     = "KARMA:PENALTY"
+	keyKarmaFarming     = "KARMA:FARMING"
 	keyKarmaBlockListed = "KARMA:BLOCKLISTED"
 
 	keyAntiraidState = "ANTIRAID:STATE"
@@ -310,6 +313,77 @@ func (r *RedisMiddleware) SetGuildInviteBlock(guildID string, data string) error
 	return r.Database.SetGuildInviteBlock(guildID, data)
 }
 
+func (r *RedisMiddleware) GetGuildInviteBlockSettings(guildID string) (settings models.InviteBlockSettings, err error) {
+	var key = fmt.Sprintf("%s:%s", keyGuildInviteBlockSettings, guildID)
+
+	resStr, err := r.client.Get(context.Background(), key).Result()
+	if err == redis.Nil {
+		if settings, err = r.Database.GetGuildInviteBlockSettings(guildID); err != nil {
+			return
+		}
+		var resB []byte
+		if resB, err = json.Marshal(settings); err != nil {
+			return
+		}
+		err = r.client.Set(context.Background(), key, resB, 0).Err()
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(resStr), &settings)
+	return
+}
+
+func (r *RedisMiddleware) SetGuildInviteBlockSettings(guildID string, settings models.InviteBlockSettings) (err error) {
+	var key = fmt.Sprintf("%s:%s", keyGuildInviteBlockSettings, guildID)
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return
+	}
+
+	if err = r.client.Set(context.Background(), key, data, 0).Err(); err != nil {
+		return
+	}
+
+	return r.Database.SetGuildInviteBlockSettings(guildID, settings)
+}
+
+func (r *RedisMiddleware) GetGuildAutomodRules(guildID string) (rules []models.AutomodRule, err error) {
+	var key = fmt.Sprintf("%s:%s", keyGuildAutomodRules, guildID)
+
+	resStr, err := r.client.Get(context.Background(), key).Result()
+	if err == redis.Nil {
+		if rules, err = r.Database.GetGuildAutomodRules(guildID); err != nil {
+			return
+		}
+		var resB []byte
+		if resB, err = json.Marshal(rules); err != nil {
+			return
+		}
+		err = r.client.Set(context.Background(), key, resB, 0).Err()
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(resStr), &rules)
+	return
+}
+
+func (r *RedisMiddleware) SetGuildAutomodRules(guildID string, rules []models.AutomodRule) (err error) {
+	var key = fmt.Sprintf("%s:%s", keyGuildAutomodRules, guildID)
+
+	if err = r.Database.SetGuildAutomodRules(guildID, rules); err != nil {
+		return
+	}
+
+	return r.client.Del(context.Background(), key).Err()
+}
+
 func (r *RedisMiddleware) GetGuildJoinMsg(guildID string) (string, string, error) {
 	var key = fmt.Sprintf("%s:%s", keyGuildJoinMsg, guildID)
 
@@ -555,6 +629,43 @@ func (r *RedisMiddleware) GetKarmaPenalty(guildID string) (bool, error) {
 	})
 }
 
+func (r *RedisMiddleware) SetKarmaFarmingLimits(guildID string, limits models.KarmaFarmingLimits) (err error) {
+	var key = fmt.Sprintf("%s:%s", keyKarmaFarming, guildID)
+	limitsB, err := json.Marshal(limits)
+	if err != nil {
+		return
+	}
+	if err = r.client.Set(context.Background(), key, limitsB, 0).Err(); err != nil {
+		return
+	}
+	err = r.Database.SetKarmaFarmingLimits(guildID, limits)
+	return
+}
+
+func (r *RedisMiddleware) GetKarmaFarmingLimits(guildID string) (limits models.KarmaFarmingLimits, err error) {
+	var key = fmt.Sprintf("%s:%s", keyKarmaFarming, guildID)
+
+	var limitsB []byte
+	err = r.client.Get(context.Background(), key).Scan(&limitsB)
+	if err == redis.Nil {
+		limits, err = r.Database.GetKarmaFarmingLimits(guildID)
+		if err != nil {
+			return
+		}
+		if limitsB, err = json.Marshal(limits); err != nil {
+			return
+		}
+		err = r.client.Set(context.Background(), key, limitsB, 0).Err()
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(limitsB, &limits)
+	return
+}
+
 func (r *RedisMiddleware) IsKarmaBlockListed(guildID, userID string) (ok bool, err error) {
 	var key = fmt.Sprintf("%s:%s:%s", keyKarmaBlockListed, guildID, userID)
 	return Get(r, key, func() (bool, error) {
@@ -650,40 +761,45 @@ func (r *RedisMiddleware) SetUserOTAEnabled(userID string, enabled bool) error {
 	return r.Database.SetUserOTAEnabled(userID, enabled)
 }
 
-func (r *RedisMiddleware) GetStarboardConfig(guildID string) (config models.StarboardConfig, err error) {
-	var key = fmt.Sprintf("%s:%s", keyGuildStarboardConfig, guildID)
+func (r *RedisMiddleware) GetStarboardConfigs(guildID string) (configs []models.StarboardConfig, err error) {
+	var key = fmt.Sprintf("%s:%s", keyGuildStarboards, guildID)
 
-	var configB []byte
-	err = r.client.Get(context.Background(), key).Scan(&configB)
+	var configsB []byte
+	err = r.client.Get(context.Background(), key).Scan(&configsB)
 	if err == redis.Nil {
-		config, err = r.Database.GetStarboardConfig(guildID)
+		configs, err = r.Database.GetStarboardConfigs(guildID)
 		if err != nil {
 			return
 		}
-		if configB, err = json.Marshal(config); err != nil {
+		if configsB, err = json.Marshal(configs); err != nil {
 			return
 		}
-		err = r.client.Set(context.Background(), key, configB, 0).Err()
+		err = r.client.Set(context.Background(), key, configsB, 0).Err()
 		return
 	}
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(configB, &config)
+	err = json.Unmarshal(configsB, &configs)
 	return
 }
 
 func (r *RedisMiddleware) SetStarboardConfig(config models.StarboardConfig) (err error) {
-	var key = fmt.Sprintf("%s:%s", keyGuildStarboardConfig, config.GuildID)
-	configB, err := json.Marshal(config)
-	if err != nil {
+	var key = fmt.Sprintf("%s:%s", keyGuildStarboards, config.GuildID)
+	if err = r.client.Del(context.Background(), key).Err(); err != nil {
 		return
 	}
-	if err = r.client.Set(context.Background(), key, configB, 0).Err(); err != nil {
+	err = r.Database.SetStarboardConfig(config)
+	return
+}
+
+func (r *RedisMiddleware) RemoveStarboardConfig(guildID, name string) (err error) {
+	var key = fmt.Sprintf("%s:%s", keyGuildStarboards, guildID)
+	if err = r.client.Del(context.Background(), key).Err(); err != nil {
 		return
 	}
-	err = r.Database.SetStarboardConfig(config)
+	err = r.Database.RemoveStarboardConfig(guildID, name)
 	return
 }
 
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database/dbtest"
+)
+
+func getTestDatabase(t *testing.T) *SqliteMiddleware {
+	db := New()
+	require.NoError(t, db.Connect(models.DatabaseSqlite{
+		File: filepath.Join(t.TempDir(), "shinpuru.db"),
+	}))
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+func TestMigrate(t *testing.T) {
+	db := getTestDatabase(t)
+
+	require.NoError(t, db.Migrate())
+	mig, err := db.getLatestMigration()
+	require.NoError(t, err)
+	require.Equal(t, len(migrationFuncs)-1, mig.Version)
+
+	// Applying migrations on an up to date
+	// database must be a no-op.
+	require.NoError(t, db.Migrate())
+}
+
+func TestDatabase(t *testing.T) {
+	db := getTestDatabase(t)
+	require.NoError(t, db.Migrate())
+
+	dbtest.Run(t, db)
+}
@@ -0,0 +1,235 @@
+package joinlog
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/report"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/services/verification"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
+)
+
+const (
+	// moderationInterval is the pause between two bans or
+	// kicks. Each of them results in multiple API requests
+	// for the report, the modlog and the DM to the victim.
+	moderationInterval = 1 * time.Second
+	// verifyInterval is the pause between two verifications.
+	verifyInterval = 250 * time.Millisecond
+	// maxRetries is the maximum number of retries of an
+	// action which has been rate limited.
+	maxRetries = 3
+	// defaultRetryAfter is used when a rate limit
+	// response does not specify a retry delay.
+	defaultRetryAfter = 5 * time.Second
+	// progressEvery is the number of processed entries
+	// after which the progress is reported.
+	progressEvery = 5
+)
+
+type impl struct {
+	db  database.Database
+	rep report.Provider
+	vs  verification.Provider
+	gl  guildlog.Logger
+	tp  timeprovider.Provider
+	log rogu.Logger
+
+	sleep func(time.Duration)
+
+	mtx  sync.Mutex
+	jobs map[string]*models.JoinlogActionProgress
+}
+
+var _ Provider = (*impl)(nil)
+
+func New(ctn di.Container) Provider {
+	return &impl{
+		db:    ctn.Get(static.DiDatabase).(database.Database),
+		rep:   ctn.Get(static.DiReport).(report.Provider),
+		vs:    ctn.Get(static.DiVerification).(verification.Provider),
+		gl:    ctn.Get(static.DiGuildLog).(guildlog.Logger).Section("antiraid"),
+		tp:    ctn.Get(static.DiTimeProvider).(timeprovider.Provider),
+		log:   log.Tagged("Joinlog"),
+		sleep: time.Sleep,
+		jobs:  make(map[string]*models.JoinlogActionProgress),
+	}
+}
+
+func (p *impl) Matching(guildID string, filter models.JoinlogFilter) (entries []models.JoinLogEntry, err error) {
+	entries, err = p.db.GetAntiraidJoinList(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return nil, err
+	}
+	return filter.Apply(p.tp.Now(), entries)
+}
+
+func (p *impl) Start(
+	guildID, executorID string,
+	req models.JoinlogActionRequest,
+	onProgress func(models.JoinlogActionProgress),
+) (progress models.JoinlogActionProgress, err error) {
+	if err = req.Validate(); err != nil {
+		return
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if job, ok := p.jobs[guildID]; ok && job.Running() {
+		return *job, ErrRunning
+	}
+
+	entries, err := p.Matching(guildID, req.Filter)
+	if err != nil {
+		return
+	}
+	if len(entries) == 0 {
+		return progress, ErrNoEntries
+	}
+
+	job := &models.JoinlogActionProgress{
+		GuildID:    guildID,
+		ExecutorID: executorID,
+		Action:     req.Action,
+		Total:      len(entries),
+		Started:    p.tp.Now(),
+	}
+	p.jobs[guildID] = job
+
+	go p.run(job, req, entries, onProgress)
+
+	return *job, nil
+}
+
+func (p *impl) Progress(guildID string) (progress models.JoinlogActionProgress, ok bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	job, ok := p.jobs[guildID]
+	if !ok {
+		return
+	}
+	return *job, true
+}
+
+func (p *impl) run(
+	job *models.JoinlogActionProgress,
+	req models.JoinlogActionRequest,
+	entries []models.JoinLogEntry,
+	onProgress func(models.JoinlogActionProgress),
+) {
+	interval := moderationInterval
+	if req.Action == models.JoinlogActionVerify {
+		interval = verifyInterval
+	}
+
+	for i, e := range entries {
+		if i != 0 {
+			p.sleep(interval)
+		}
+
+		err := p.apply(job.ExecutorID, req, e)
+
+		p.mtx.Lock()
+		job.Done++
+		if err != nil {
+			job.AddError(e.UserID, err)
+		}
+		progress := *job
+		p.mtx.Unlock()
+
+		if onProgress != nil && progress.Done%progressEvery == 0 && progress.Done != progress.Total {
+			onProgress(progress)
+		}
+	}
+
+	p.mtx.Lock()
+	now := p.tp.Now()
+	job.Finished = &now
+	progress := *job
+	p.mtx.Unlock()
+
+	p.gl.Infof(progress.GuildID, "Join log action %s by <@%s> finished: %d of %d entries processed successfully",
+		progress.Action, progress.ExecutorID, progress.Total-progress.Failed, progress.Total)
+
+	if onProgress != nil {
+		onProgress(progress)
+	}
+}
+
+// apply applies the requested action to the given join log
+// entry and removes the entry from the join log on success.
+// Rate limited actions are retried after the requested
+// delay up to maxRetries times.
+func (p *impl) apply(executorID string, req models.JoinlogActionRequest, e models.JoinLogEntry) (err error) {
+	for i := 0; ; i++ {
+		err = p.applyOnce(executorID, req, e)
+		retryAfter, limited := rateLimited(err)
+		if !limited || i == maxRetries {
+			break
+		}
+		p.log.Warn().Field("gid", e.GuildID).Field("retryAfter", retryAfter).Msg("Join log action has been rate limited")
+		p.sleep(retryAfter)
+	}
+
+	if err != nil {
+		p.log.Error().Err(err).Field("gid", e.GuildID).Field("uid", e.UserID).Msgf("Failed applying join log action %s", req.Action)
+		return err
+	}
+
+	if err = p.db.RemoveAntiraidJoinList(e.GuildID, e.UserID); database.IsErrDatabaseNotFound(err) {
+		err = nil
+	}
+	return err
+}
+
+func (p *impl) applyOnce(executorID string, req models.JoinlogActionRequest, e models.JoinLogEntry) (err error) {
+	rep := models.Report{
+		GuildID:    e.GuildID,
+		ExecutorID: executorID,
+		VictimID:   e.UserID,
+		Msg:        req.Reason,
+	}
+
+	switch req.Action {
+	case models.JoinlogActionBan:
+		rep.Type = models.TypeBan
+		_, err = p.rep.PushBan(rep)
+	case models.JoinlogActionKick:
+		rep.Type = models.TypeKick
+		_, err = p.rep.PushKick(rep)
+	case models.JoinlogActionVerify:
+		err = p.vs.Verify(e.UserID)
+	}
+
+	return err
+}
+
+func rateLimited(err error) (retryAfter time.Duration, ok bool) {
+	var rlErr *discordgo.RateLimitError
+	if errors.As(err, &rlErr) {
+		if rlErr.RateLimit != nil && rlErr.TooManyRequests != nil && rlErr.RetryAfter > 0 {
+			return rlErr.RetryAfter, true
+		}
+		return defaultRetryAfter, true
+	}
+
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil &&
+		restErr.Response.StatusCode == http.StatusTooManyRequests {
+		return defaultRetryAfter, true
+	}
+
+	return 0, false
+}
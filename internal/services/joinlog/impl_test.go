@@ -0,0 +1,216 @@
+package joinlog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/testutil"
+	"github.com/zekroTJA/shinpuru/mocks"
+	"github.com/zekrotja/rogu/log"
+)
+
+var now = time.Date(2022, 1, 10, 0, 0, 0, 0, time.UTC)
+
+type joinlogMock struct {
+	db  database.Database
+	rep *mocks.ReportProvider
+	vs  *mocks.VerificationProvider
+	gl  *mocks.Logger
+	tp  *mocks.TimeProvider
+
+	sleeps []time.Duration
+}
+
+func newJoinlog(t *testing.T) (*impl, *joinlogMock) {
+	m := &joinlogMock{
+		db:  testutil.NewTestDatabase(t),
+		rep: &mocks.ReportProvider{},
+		vs:  &mocks.VerificationProvider{},
+		gl:  &mocks.Logger{},
+		tp:  &mocks.TimeProvider{},
+	}
+
+	require.NoError(t, m.db.AddToAntiraidJoinList("guild", "young", "raider#0001", now.Add(-24*time.Hour)))
+	require.NoError(t, m.db.AddToAntiraidJoinList("guild", "younger", "raider#0002", now.Add(-time.Hour)))
+	require.NoError(t, m.db.AddToAntiraidJoinList("guild", "old", "regular#0001", now.Add(-365*24*time.Hour)))
+
+	m.gl.On("Infof", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything).Return(nil)
+	m.tp.On("Now").Return(now)
+
+	p := &impl{
+		db:    m.db,
+		rep:   m.rep,
+		vs:    m.vs,
+		gl:    m.gl,
+		tp:    m.tp,
+		log:   log.Tagged("Joinlog"),
+		sleep: func(d time.Duration) { m.sleeps = append(m.sleeps, d) },
+		jobs:  make(map[string]*models.JoinlogActionProgress),
+	}
+
+	return p, m
+}
+
+func startAndWait(t *testing.T, p *impl, req models.JoinlogActionRequest) models.JoinlogActionProgress {
+	done := make(chan models.JoinlogActionProgress, 1)
+	_, err := p.Start("guild", "executor", req, func(progress models.JoinlogActionProgress) {
+		if !progress.Running() {
+			done <- progress
+		}
+	})
+	require.NoError(t, err)
+
+	select {
+	case progress := <-done:
+		return progress
+	case <-time.After(5 * time.Second):
+		t.Fatal("join log action did not finish")
+	}
+	return models.JoinlogActionProgress{}
+}
+
+func TestMatching(t *testing.T) {
+	p, _ := newJoinlog(t)
+
+	entries, err := p.Matching("guild", models.JoinlogFilter{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	entries, err = p.Matching("guild", models.JoinlogFilter{MaxAccountAge: 7})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"young", "younger"}, userIDs(entries))
+
+	entries, err = p.Matching("guild", models.JoinlogFilter{NamePattern: `^regular#\d+$`})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old"}, userIDs(entries))
+
+	entries, err = p.Matching("guild", models.JoinlogFilter{
+		UserIDs:     []string{"young", "old"},
+		NamePattern: "^raider",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"young"}, userIDs(entries))
+}
+
+func TestStart_Ban(t *testing.T) {
+	p, m := newJoinlog(t)
+
+	m.rep.On("PushBan", mock.Anything).Return(models.Report{}, nil)
+
+	progress := startAndWait(t, p, models.JoinlogActionRequest{
+		Action: models.JoinlogActionBan,
+		Filter: models.JoinlogFilter{MaxAccountAge: 7},
+		Reason: "raid",
+	})
+
+	assert.Equal(t, 2, progress.Total)
+	assert.Equal(t, 2, progress.Done)
+	assert.Equal(t, 0, progress.Failed)
+	assert.Equal(t, []time.Duration{moderationInterval}, m.sleeps)
+
+	for _, id := range []string{"young", "younger"} {
+		m.rep.AssertCalled(t, "PushBan", models.Report{
+			Type:       models.TypeBan,
+			GuildID:    "guild",
+			ExecutorID: "executor",
+			VictimID:   id,
+			Msg:        "raid",
+		})
+	}
+
+	entries, err := m.db.GetAntiraidJoinList("guild")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old"}, userIDs(entries))
+}
+
+func TestStart_Failures(t *testing.T) {
+	p, m := newJoinlog(t)
+
+	m.vs.On("Verify", "young").Return(nil)
+	m.vs.On("Verify", "younger").Return(errors.New("test error"))
+
+	progress := startAndWait(t, p, models.JoinlogActionRequest{
+		Action: models.JoinlogActionVerify,
+		Filter: models.JoinlogFilter{NamePattern: "^raider"},
+	})
+
+	assert.Equal(t, 2, progress.Done)
+	assert.Equal(t, 1, progress.Failed)
+	assert.Equal(t, []string{"younger: test error"}, progress.Errors)
+
+	entries, err := m.db.GetAntiraidJoinList("guild")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"younger", "old"}, userIDs(entries))
+
+	stored, ok := p.Progress("guild")
+	assert.True(t, ok)
+	assert.Equal(t, progress, stored)
+}
+
+func TestStart_RateLimited(t *testing.T) {
+	p, m := newJoinlog(t)
+
+	rlErr := &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{
+		TooManyRequests: &discordgo.TooManyRequests{RetryAfter: 3 * time.Second},
+	}}
+	m.rep.On("PushKick", mock.Anything).Return(models.Report{}, rlErr).Once()
+	m.rep.On("PushKick", mock.Anything).Return(models.Report{}, nil).Once()
+
+	progress := startAndWait(t, p, models.JoinlogActionRequest{
+		Action: models.JoinlogActionKick,
+		Filter: models.JoinlogFilter{UserIDs: []string{"old"}},
+		Reason: "raid",
+	})
+
+	assert.Equal(t, 0, progress.Failed)
+	assert.Equal(t, []time.Duration{3 * time.Second}, m.sleeps)
+	m.rep.AssertNumberOfCalls(t, "PushKick", 2)
+}
+
+func TestStart_Errors(t *testing.T) {
+	p, _ := newJoinlog(t)
+
+	_, err := p.Start("guild", "executor", models.JoinlogActionRequest{
+		Action: "mute",
+	}, nil)
+	assert.Error(t, err)
+
+	_, err = p.Start("guild", "executor", models.JoinlogActionRequest{
+		Action: models.JoinlogActionBan,
+	}, nil)
+	assert.Error(t, err)
+
+	_, err = p.Start("guild", "executor", models.JoinlogActionRequest{
+		Action: models.JoinlogActionVerify,
+		Filter: models.JoinlogFilter{NamePattern: "("},
+	}, nil)
+	assert.Error(t, err)
+
+	_, err = p.Start("guild", "executor", models.JoinlogActionRequest{
+		Action: models.JoinlogActionVerify,
+		Filter: models.JoinlogFilter{UserIDs: []string{"unknown"}},
+	}, nil)
+	assert.ErrorIs(t, err, ErrNoEntries)
+
+	p.jobs["guild"] = &models.JoinlogActionProgress{GuildID: "guild"}
+	_, err = p.Start("guild", "executor", models.JoinlogActionRequest{
+		Action: models.JoinlogActionVerify,
+	}, nil)
+	assert.ErrorIs(t, err, ErrRunning)
+}
+
+func userIDs(entries []models.JoinLogEntry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.UserID)
+	}
+	return ids
+}
@@ -0,0 +1,33 @@
+// Package joinlog applies moderation actions in bulk
+// to the members recorded in the antiraid join log.
+package joinlog
+
+import (
+	"errors"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+var (
+	ErrRunning   = errors.New("a join log action is already running for this guild")
+	ErrNoEntries = errors.New("no join log entries match the filter")
+)
+
+type Provider interface {
+	// Matching returns all join log entries of the
+	// given guild which match the passed filter.
+	Matching(guildID string, filter models.JoinlogFilter) (entries []models.JoinLogEntry, err error)
+	// Start applies the requested action to all matching
+	// join log entries in the background and returns the
+	// initial progress. onProgress, if not nil, is called
+	// periodically while the action is running and once
+	// after it has finished.
+	Start(
+		guildID, executorID string,
+		req models.JoinlogActionRequest,
+		onProgress func(models.JoinlogActionProgress),
+	) (progress models.JoinlogActionProgress, err error)
+	// Progress returns the progress of the current or
+	// last action applied in the given guild.
+	Progress(guildID string) (progress models.JoinlogActionProgress, ok bool)
+}
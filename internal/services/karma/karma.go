@@ -1,15 +1,22 @@
 package karma
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/sarulabs/di/v2"
 	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/report"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/util/snowflakenodes"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
 	"github.com/zekroTJA/shinpuru/pkg/embedbuilder"
+	"github.com/zekroTJA/timedmap"
 	"github.com/zekrotja/dgrs"
 	"github.com/zekrotja/rogu"
 	"github.com/zekrotja/rogu/log"
@@ -18,11 +25,17 @@ import (
 // Service provides functionalities to check karma state,
 // karma blocklist and alter karma of a user.
 type Service struct {
-	s   *discordgo.Session
+	s   discordutil.ISession
 	db  database.Database
 	gl  guildlog.Logger
-	st  *dgrs.State
+	st  dgrs.IState
+	tp  timeprovider.Provider
+	rep report.Provider
 	log rogu.Logger
+
+	flagged       timedmap.Section
+	cooldowns     timedmap.Section
+	grantedTokens timedmap.Section
 }
 
 var _ Provider = (*Service)(nil)
@@ -36,8 +49,15 @@ func NewKarmaService(container di.Container) (k *Service) {
 	k.db = container.Get(static.DiDatabase).(database.Database)
 	k.gl = container.Get(static.DiGuildLog).(guildlog.Logger).Section("karma")
 	k.st = container.Get(static.DiState).(*dgrs.State)
+	k.tp = container.Get(static.DiTimeProvider).(timeprovider.Provider)
+	k.rep = container.Get(static.DiReport).(report.Provider)
 	k.log = log.Tagged("Karma")
 
+	cache := timedmap.New(10 * time.Minute)
+	k.flagged = cache.Section(0)
+	k.cooldowns = cache.Section(1)
+	k.grantedTokens = cache.Section(2)
+
 	return
 }
 
@@ -63,92 +83,117 @@ func (k *Service) IsBlockListed(guildID, userID string) (isBlocklisted bool, err
 }
 
 // Update adds or removes karma of the given value of the
-// specified user.
-func (k *Service) Update(guildID, userID, executorID string, value int) (err error) {
-	err = k.db.UpdateKarma(userID, guildID, value)
-	if err != nil {
-		return err
+// specified user and records the change in the karma ledger.
+// channelID is the channel where the change has been caused
+// and may be empty.
+//
+// Karma given by reactions is checked for sock puppet
+// activity of the executor.
+func (k *Service) Update(guildID, userID, executorID, channelID string, value int, source models.KarmaSource) (err error) {
+	if _, err = k.record(guildID, userID, executorID, value, source); err != nil {
+		return
+	}
+
+	err = k.applyRules(guildID, userID, channelID, value)
+
+	if source == models.KarmaSourceReaction && executorID != "" {
+		err = errors.Join(err, k.checkSockPuppet(guildID, executorID))
+		if value < 0 {
+			err = errors.Join(err, k.applyPenalty(guildID, executorID, channelID))
+		}
 	}
 
-	rules, err := k.db.GetKarmaRules(guildID)
-	if err != nil && !database.IsErrDatabaseNotFound(err) {
+	return
+}
+
+// Revert reverts all karma given by reactions of executorID on
+// the specified guild between from and to. For each member whose
+// karma is affected, a manual ledger entry executed by moderatorID
+// is created which compensates the reverted entries.
+//
+// The created compensation entries are returned.
+func (k *Service) Revert(guildID, executorID, moderatorID string, from, to time.Time) (reverts []models.KarmaLedgerEntry, err error) {
+	entries, err := k.db.GetKarmaLedgerGiven(guildID, executorID, from, to)
+	if err != nil {
 		return
 	}
-	if len(rules) > 0 {
-		var valAfter int
-		valAfter, err = k.db.GetKarma(userID, guildID)
+
+	var targets []string
+	byTarget := make(map[string][]models.KarmaLedgerEntry)
+	for _, e := range entries {
+		if e.Source != models.KarmaSourceReaction {
+			continue
+		}
+		if _, ok := byTarget[e.TargetID]; !ok {
+			targets = append(targets, e.TargetID)
+		}
+		byTarget[e.TargetID] = append(byTarget[e.TargetID], e)
+	}
+
+	reverts = make([]models.KarmaLedgerEntry, 0, len(targets))
+	var errs []error
+	for _, targetID := range targets {
+		var sum int
+		for _, e := range byTarget[targetID] {
+			sum += e.Delta
+		}
+		if sum == 0 {
+			continue
+		}
+
+		var rev models.KarmaLedgerEntry
+		rev, err = k.record(guildID, targetID, moderatorID, -sum, models.KarmaSourceManual)
 		if err != nil {
 			return
 		}
-		valBefore := valAfter - value
-
-		for _, rule := range rules {
-			if value > 0 {
-				switch rule.Action {
-				case models.KarmaActionToggleRole:
-					if rule.Trigger == models.KarmaTriggerAbove && valBefore <= rule.Value && valAfter > rule.Value {
-						if err = k.s.GuildMemberRoleAdd(guildID, userID, rule.Argument); err != nil {
-							k.log.Error().Err(err).Fields("gid", guildID, "uid", userID).Msg("Failed adding role")
-							k.gl.Errorf(guildID, "Failed adding role to user (%s): %s", userID, err.Error())
-						}
-					} else if rule.Trigger == models.KarmaTriggerBelow && valBefore < rule.Value && valAfter >= rule.Value {
-						if err = k.s.GuildMemberRoleRemove(guildID, userID, rule.Argument); err != nil {
-							k.log.Error().Err(err).Fields("gid", guildID, "uid", userID).Msg("Failed removing role")
-							k.gl.Errorf(guildID, "Failed removing role to user (%s): %s", userID, err.Error())
-						}
-					}
-				case models.KarmaActionSendMessage:
-					if rule.Trigger == models.KarmaTriggerAbove && valBefore <= rule.Value && valAfter > rule.Value {
-						k.trySendKarmaMessage(userID, guildID, true, rule.Value, rule.Argument)
-					}
-				case models.KarmaActionKick:
-					if rule.Trigger == models.KarmaTriggerAbove && valBefore <= rule.Value && valAfter > rule.Value {
-						k.tryKick(userID, guildID, true, rule.Value)
-					}
-				case models.KarmaActionBan:
-					if rule.Trigger == models.KarmaTriggerAbove && valBefore <= rule.Value && valAfter > rule.Value {
-						k.tryBan(userID, guildID, true, rule.Value)
-					}
-				}
-			} else if value < 0 {
-				switch rule.Action {
-				case models.KarmaActionToggleRole:
-					if rule.Trigger == models.KarmaTriggerAbove && valBefore > rule.Value && valAfter <= rule.Value {
-						if err = k.s.GuildMemberRoleRemove(guildID, userID, rule.Argument); err != nil {
-							k.log.Error().Err(err).Fields("gid", guildID, "uid", userID).Msg("Failed removing role")
-							k.gl.Errorf(guildID, "Failed removing role to user (%s): %s", userID, err.Error())
-						}
-					} else if rule.Trigger == models.KarmaTriggerBelow && valBefore >= rule.Value && valAfter < rule.Value {
-						if err = k.s.GuildMemberRoleAdd(guildID, userID, rule.Argument); err != nil {
-							k.log.Error().Err(err).Fields("gid", guildID, "uid", userID).Msg("Failed adding role")
-							k.gl.Errorf(guildID, "Failed adding role to user (%s): %s", userID, err.Error())
-						}
-					}
-				case models.KarmaActionSendMessage:
-					if rule.Trigger == models.KarmaTriggerBelow && valBefore >= rule.Value && valAfter < rule.Value {
-						k.trySendKarmaMessage(userID, guildID, false, rule.Value, rule.Argument)
-					}
-				case models.KarmaActionKick:
-					if rule.Trigger == models.KarmaTriggerBelow && valBefore >= rule.Value && valAfter < rule.Value {
-						k.tryKick(userID, guildID, false, rule.Value)
-					}
-				case models.KarmaActionBan:
-					if rule.Trigger == models.KarmaTriggerBelow && valBefore >= rule.Value && valAfter < rule.Value {
-						k.tryBan(userID, guildID, false, rule.Value)
-					}
-				}
+		for _, e := range byTarget[targetID] {
+			if err = k.db.SetKarmaLedgerReverted(e.ID, rev.ID); err != nil {
+				return
 			}
 		}
+		reverts = append(reverts, rev)
+
+		if err = k.applyRules(guildID, targetID, "", -sum); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(reverts) > 0 {
+		k.gl.Infof(guildID, "Karma given by user (%s) between %s and %s has been reverted for %d members by user (%s)",
+			executorID, from.Format(time.RFC3339), to.Format(time.RFC3339), len(reverts), moderatorID)
 	}
 
-	if value < 0 {
-		err = k.ApplyPenalty(guildID, executorID)
+	err = errors.Join(errs...)
+	return
+}
+
+// record alters the karma of the specified user and adds
+// an entry for the change to the karma ledger.
+func (k *Service) record(guildID, userID, executorID string, value int, source models.KarmaSource) (entry models.KarmaLedgerEntry, err error) {
+	if err = k.db.UpdateKarma(userID, guildID, value); err != nil {
+		return
 	}
 
+	entry = models.KarmaLedgerEntry{
+		ID:         snowflakenodes.NodeKarmaLedger.Generate(),
+		GuildID:    guildID,
+		ExecutorID: executorID,
+		TargetID:   userID,
+		Delta:      value,
+		Source:     source,
+		Timestamp:  k.tp.Now(),
+	}
+	err = k.db.AddKarmaLedgerEntry(entry)
 	return
 }
 
+// ApplyPenalty removes one karma from the specified user
+// if karma penalty is enabled on the guild.
 func (k *Service) ApplyPenalty(guildID, userID string) (err error) {
+	return k.applyPenalty(guildID, userID, "")
+}
+
+func (k *Service) applyPenalty(guildID, userID, channelID string) (err error) {
 	if userID == "" {
 		return
 	}
@@ -161,13 +206,13 @@ func (k *Service) ApplyPenalty(guildID, userID string) (err error) {
 		return
 	}
 
-	err = k.Update(guildID, userID, "", -1)
+	err = k.Update(guildID, userID, "", channelID, -1, models.KarmaSourcePenalty)
 	return
 }
 
 // CheckAndUpdate is shorthand for GetState, IsBlockListed
 // and Update in one single pipe.
-func (k *Service) CheckAndUpdate(guildID, executorID string, object *discordgo.User, value int) (ok bool, err error) {
+func (k *Service) CheckAndUpdate(guildID, executorID, channelID string, object *discordgo.User, value int, source models.KarmaSource) (ok bool, err error) {
 	if object.Bot {
 		return
 	}
@@ -182,12 +227,12 @@ func (k *Service) CheckAndUpdate(guildID, executorID string, object *discordgo.U
 		return
 	}
 
-	err = k.Update(guildID, object.ID, executorID, value)
+	err = k.Update(guildID, object.ID, executorID, channelID, value, source)
 	ok = err == nil
 	return
 }
 
-func (k *Service) trySendKarmaMessage(userID, guildID string, added bool, value int, content string) {
+func (k *Service) trySendKarmaMessage(userID, guildID string, added bool, desc, content string) {
 	ch, err := k.s.UserChannelCreate(userID)
 	if err != nil {
 		k.log.Error().Err(err).Fields("uid", userID, "gid", guildID).Msg("Failed opening dm channel")
@@ -201,14 +246,9 @@ func (k *Service) trySendKarmaMessage(userID, guildID string, added bool, value
 		return
 	}
 
-	pre := "dropped below"
-	if added {
-		pre = "rised above"
-	}
-
 	emb := embedbuilder.New().
-		WithDescription(fmt.Sprintf("Your karma %s %d points on guild %s.\n\n%s",
-			pre, value, guild.Name, content))
+		WithDescription(fmt.Sprintf("Your karma %s on guild %s.\n\n%s",
+			desc, guild.Name, content))
 
 	if added {
 		emb.WithColor(static.ColorEmbedGreen)
@@ -223,15 +263,11 @@ func (k *Service) trySendKarmaMessage(userID, guildID string, added bool, value
 	}
 }
 
-func (k *Service) tryKick(userID, guildID string, added bool, value int) {
-	k.trySendKarmaMessage(userID, guildID, added, value,
+func (k *Service) tryKick(userID, guildID string, added bool, desc string) {
+	k.trySendKarmaMessage(userID, guildID, added, desc,
 		"Because of that, you have been automatically kicked from the guild.")
 
-	pre := "dropped below"
-	if added {
-		pre = "rised above"
-	}
-	reason := fmt.Sprintf("Karma %s %d points", pre, value)
+	reason := fmt.Sprintf("Karma %s", desc)
 
 	if err := k.s.GuildMemberDeleteWithReason(guildID, userID, reason); err != nil {
 		k.log.Error().Err(err).Fields("uid", userID, "gid", guildID).Msg("Failed kicking member")
@@ -239,15 +275,11 @@ func (k *Service) tryKick(userID, guildID string, added bool, value int) {
 	}
 }
 
-func (k *Service) tryBan(userID, guildID string, added bool, value int) {
-	k.trySendKarmaMessage(userID, guildID, added, value,
+func (k *Service) tryBan(userID, guildID string, added bool, desc string) {
+	k.trySendKarmaMessage(userID, guildID, added, desc,
 		"Because of that, you have been automatically banned from the guild.")
 
-	pre := "dropped below"
-	if added {
-		pre = "rised above"
-	}
-	reason := fmt.Sprintf("Karma %s %d points", pre, value)
+	reason := fmt.Sprintf("Karma %s", desc)
 
 	if err := k.s.GuildBanCreateWithReason(guildID, userID, reason, 7); err != nil {
 		k.log.Error().Err(err).Fields("uid", userID, "gid", guildID).Msg("Failed banning member")
@@ -0,0 +1,246 @@
+package karma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/snowflakenodes"
+	"github.com/zekroTJA/shinpuru/mocks"
+	"github.com/zekroTJA/timedmap"
+	"github.com/zekrotja/rogu/log"
+)
+
+func TestRevert(t *testing.T) {
+	snowflakenodes.Setup()
+
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	from, to := now.Add(-24*time.Hour), now
+
+	db := &mocks.Database{}
+	gl := &mocks.Logger{}
+	tp := &mocks.TimeProvider{}
+
+	k := &Service{db: db, gl: gl, tp: tp, log: log.Tagged("Karma")}
+
+	tp.On("Now").Return(now)
+	gl.On("Infof", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	db.On("GetKarmaLedgerGiven", "guild", "farmer", from, to).Return([]models.KarmaLedgerEntry{
+		{ID: 1, GuildID: "guild", ExecutorID: "farmer", TargetID: "a", Delta: 1, Source: models.KarmaSourceReaction},
+		{ID: 2, GuildID: "guild", ExecutorID: "farmer", TargetID: "b", Delta: -1, Source: models.KarmaSourceReaction},
+		{ID: 3, GuildID: "guild", ExecutorID: "farmer", TargetID: "a", Delta: 1, Source: models.KarmaSourceReaction},
+		{ID: 4, GuildID: "guild", ExecutorID: "farmer", TargetID: "c", Delta: 1, Source: models.KarmaSourceReaction},
+		{ID: 5, GuildID: "guild", ExecutorID: "farmer", TargetID: "c", Delta: -1, Source: models.KarmaSourceReaction},
+		{ID: 6, GuildID: "guild", ExecutorID: "farmer", TargetID: "d", Delta: 5, Source: models.KarmaSourceManual},
+	}, nil)
+	db.On("UpdateKarma", mock.Anything, "guild", mock.Anything).Return(nil)
+	db.On("AddKarmaLedgerEntry", mock.Anything).Return(nil)
+	db.On("SetKarmaLedgerReverted", mock.Anything, mock.Anything).Return(nil)
+	db.On("GetKarmaRules", "guild").Return([]models.KarmaRule{}, nil)
+
+	reverts, err := k.Revert("guild", "farmer", "mod", from, to)
+	require.NoError(t, err)
+	require.Len(t, reverts, 2)
+
+	assert.Equal(t, "a", reverts[0].TargetID)
+	assert.Equal(t, -2, reverts[0].Delta)
+	assert.Equal(t, "mod", reverts[0].ExecutorID)
+	assert.Equal(t, models.KarmaSourceManual, reverts[0].Source)
+	assert.Equal(t, now, reverts[0].Timestamp)
+	assert.Equal(t, "b", reverts[1].TargetID)
+	assert.Equal(t, 1, reverts[1].Delta)
+
+	db.AssertCalled(t, "UpdateKarma", "a", "guild", -2)
+	db.AssertCalled(t, "UpdateKarma", "b", "guild", 1)
+	db.AssertNumberOfCalls(t, "UpdateKarma", 2)
+
+	db.AssertCalled(t, "SetKarmaLedgerReverted", snowflake.ID(1), reverts[0].ID)
+	db.AssertCalled(t, "SetKarmaLedgerReverted", snowflake.ID(3), reverts[0].ID)
+	db.AssertCalled(t, "SetKarmaLedgerReverted", snowflake.ID(2), reverts[1].ID)
+	db.AssertNumberOfCalls(t, "SetKarmaLedgerReverted", 3)
+}
+
+func TestRevertNothingGiven(t *testing.T) {
+	db := &mocks.Database{}
+	gl := &mocks.Logger{}
+
+	k := &Service{db: db, gl: gl, log: log.Tagged("Karma")}
+
+	db.On("GetKarmaLedgerGiven", "guild", "user", mock.Anything, mock.Anything).
+		Return([]models.KarmaLedgerEntry{}, nil)
+
+	reverts, err := k.Revert("guild", "user", "mod", time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, reverts)
+	gl.AssertNotCalled(t, "Infof")
+}
+
+func TestCheckPairLimit(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	db := &mocks.Database{}
+	gl := &mocks.Logger{}
+	tp := &mocks.TimeProvider{}
+
+	k := &Service{db: db, gl: gl, tp: tp, log: log.Tagged("Karma"), flagged: timedmap.New(time.Minute).Section(0)}
+
+	tp.On("Now").Return(now)
+	gl.On("Warnf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	db.On("GetKarmaFarmingLimits", "guild").Return(models.KarmaFarmingLimits{PairLimit: 3, PairWindow: 3600}, nil)
+	db.On("GetKarmaLedgerPairCount", "guild", "giver", "a", now.Add(-time.Hour)).Return(2, nil)
+	db.On("GetKarmaLedgerPairCount", "guild", "giver", "b", now.Add(-time.Hour)).Return(3, nil)
+	db.On("GetGuildModNot", "guild").Return("", nil)
+
+	ok, err := k.CheckPairLimit("guild", "giver", "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	gl.AssertNotCalled(t, "Warnf")
+
+	for i := 0; i < 2; i++ {
+		ok, err = k.CheckPairLimit("guild", "giver", "b")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	gl.AssertNumberOfCalls(t, "Warnf", 1)
+	db.AssertNumberOfCalls(t, "GetGuildModNot", 1)
+}
+
+func TestCheckPairLimitDisabled(t *testing.T) {
+	db := &mocks.Database{}
+
+	k := &Service{db: db, log: log.Tagged("Karma")}
+
+	db.On("GetKarmaFarmingLimits", "guild").Return(models.KarmaFarmingLimits{}, database.ErrDatabaseNotFound)
+
+	ok, err := k.CheckPairLimit("guild", "giver", "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	db.AssertNotCalled(t, "GetKarmaLedgerPairCount")
+}
+
+func TestUpdateSockPuppet(t *testing.T) {
+	snowflakenodes.Setup()
+
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	db := &mocks.Database{}
+	gl := &mocks.Logger{}
+	tp := &mocks.TimeProvider{}
+
+	k := &Service{db: db, gl: gl, tp: tp, log: log.Tagged("Karma"), flagged: timedmap.New(time.Minute).Section(0)}
+
+	tp.On("Now").Return(now)
+	gl.On("Warnf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	db.On("UpdateKarma", mock.Anything, "guild", mock.Anything).Return(nil)
+	db.On("AddKarmaLedgerEntry", mock.Anything).Return(nil)
+	db.On("GetKarmaRules", "guild").Return([]models.KarmaRule{}, nil)
+	db.On("GetKarmaFarmingLimits", "guild").Return(models.KarmaFarmingLimits{SockPuppetThreshold: 3}, nil)
+	db.On("GetGuildModNot", "guild").Return("", nil)
+
+	given := func(targets ...string) []models.KarmaLedgerEntry {
+		entries := make([]models.KarmaLedgerEntry, 0, len(targets))
+		for _, target := range targets {
+			entries = append(entries, models.KarmaLedgerEntry{
+				GuildID: "guild", ExecutorID: "puppet", TargetID: target, Delta: 1, Source: models.KarmaSourceReaction})
+		}
+		return entries
+	}
+
+	window := now.Add(-sockPuppetWindow)
+	db.On("GetKarmaLedgerGiven", "guild", "puppet", window, now).Return(given("main", "main"), nil).Once()
+	require.NoError(t, k.Update("guild", "main", "puppet", "", 1, models.KarmaSourceReaction))
+	gl.AssertNotCalled(t, "Warnf")
+
+	db.On("GetKarmaLedgerGiven", "guild", "other", window, now).Return(given("main", "x", "main"), nil).Once()
+	require.NoError(t, k.Update("guild", "main", "other", "", 1, models.KarmaSourceReaction))
+	gl.AssertNotCalled(t, "Warnf")
+
+	db.On("GetKarmaLedgerGiven", "guild", "puppet", window, now).Return(given("main", "main", "main"), nil).Once()
+	require.NoError(t, k.Update("guild", "main", "puppet", "", 1, models.KarmaSourceReaction))
+	gl.AssertCalled(t, "Warnf", "guild", mock.Anything, "puppet", 3, "main")
+}
+
+func TestDecay(t *testing.T) {
+	snowflakenodes.Setup()
+
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	db := &mocks.Database{}
+	gl := &mocks.Logger{}
+	tp := &mocks.TimeProvider{}
+
+	k := &Service{db: db, gl: gl, tp: tp, log: log.Tagged("Karma")}
+
+	tp.On("Now").Return(now)
+	gl.On("Infof", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	db.On("GetKarmaDecayGuilds").Return([]string{"guild"}, nil)
+	db.On("GetKarmaDecay", "guild").Return(models.KarmaDecay{After: 7 * 24 * 3600, Amount: 2}, nil)
+	db.On("GetKarmaDecayCandidates", "guild", now.Add(-7*24*time.Hour)).Return([]models.GuildKarma{
+		{GuildID: "guild", UserID: "a", Value: 5},
+		{GuildID: "guild", UserID: "b", Value: 1},
+		{GuildID: "guild", UserID: "c", Value: -3},
+	}, nil)
+	db.On("UpdateKarma", mock.Anything, "guild", mock.Anything).Return(nil)
+	db.On("AddKarmaLedgerEntry", mock.Anything).Return(nil)
+	db.On("GetKarmaRules", "guild").Return([]models.KarmaRule{}, nil)
+
+	require.NoError(t, k.Decay())
+
+	db.AssertCalled(t, "UpdateKarma", "a", "guild", -2)
+	db.AssertCalled(t, "UpdateKarma", "b", "guild", -1)
+	db.AssertCalled(t, "UpdateKarma", "c", "guild", 2)
+	db.AssertCalled(t, "AddKarmaLedgerEntry", mock.MatchedBy(func(e models.KarmaLedgerEntry) bool {
+		return e.TargetID == "a" && e.ExecutorID == "" && e.Source == models.KarmaSourceDecay
+	}))
+	gl.AssertCalled(t, "Infof", "guild", mock.Anything, 3)
+}
+
+func TestDecayRecentlyEnabled(t *testing.T) {
+	snowflakenodes.Setup()
+
+	enabledAt := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	decay := models.KarmaDecay{After: 7 * 24 * 3600, Amount: 2, EnabledAt: enabledAt}
+
+	// The member has karma from before the karma ledger
+	// existed, so there are no ledger entries for them.
+	newService := func(now time.Time) (*Service, *mocks.Database) {
+		db := &mocks.Database{}
+		gl := &mocks.Logger{}
+		tp := &mocks.TimeProvider{}
+
+		tp.On("Now").Return(now)
+		gl.On("Infof", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		db.On("GetKarmaDecayGuilds").Return([]string{"guild"}, nil)
+		db.On("GetKarmaDecay", "guild").Return(decay, nil)
+		db.On("GetKarmaDecayCandidates", "guild", mock.Anything).Return([]models.GuildKarma{
+			{GuildID: "guild", UserID: "old", Value: 5},
+		}, nil)
+		db.On("UpdateKarma", mock.Anything, "guild", mock.Anything).Return(nil)
+		db.On("AddKarmaLedgerEntry", mock.Anything).Return(nil)
+		db.On("GetKarmaRules", "guild").Return([]models.KarmaRule{}, nil)
+
+		return &Service{db: db, gl: gl, tp: tp, log: log.Tagged("Karma")}, db
+	}
+
+	k, db := newService(enabledAt.Add(24 * time.Hour))
+	require.NoError(t, k.Decay())
+	db.AssertNotCalled(t, "GetKarmaDecayCandidates", mock.Anything, mock.Anything)
+	db.AssertNotCalled(t, "UpdateKarma", mock.Anything, mock.Anything, mock.Anything)
+
+	now := enabledAt.Add(8 * 24 * time.Hour)
+	k, db = newService(now)
+	require.NoError(t, k.Decay())
+	db.AssertCalled(t, "GetKarmaDecayCandidates", "guild", now.Add(-7*24*time.Hour))
+	db.AssertCalled(t, "UpdateKarma", "old", "guild", -2)
+}
@@ -0,0 +1,191 @@
+package karma
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/modnot"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+)
+
+// sockPuppetWindow is the time span of given karma
+// which is inspected for sock puppet detection.
+const sockPuppetWindow = 30 * 24 * time.Hour
+
+// CheckPairLimit returns false if executorID has already given
+// karma to targetID as often as allowed within the pair window
+// configured for the guild. The first blocked attempt of a pair
+// within a window is flagged.
+func (k *Service) CheckPairLimit(guildID, executorID, targetID string) (ok bool, err error) {
+	limits, err := k.farmingLimits(guildID)
+	if err != nil {
+		return
+	}
+	if limits.PairLimit == 0 || limits.PairWindow == 0 {
+		return true, nil
+	}
+
+	window := time.Duration(limits.PairWindow) * time.Second
+	n, err := k.db.GetKarmaLedgerPairCount(guildID, executorID, targetID, k.tp.Now().Add(-window))
+	if err != nil {
+		return
+	}
+	if n < limits.PairLimit {
+		return true, nil
+	}
+
+	err = k.flag(guildID, fmt.Sprintf("pair:%s:%s:%s", guildID, executorID, targetID), window,
+		&discordgo.MessageEmbed{
+			Title: "Karma Pair Limit Exceeded",
+			Description: fmt.Sprintf("<@%s> tried to give karma to <@%s> more than %d times "+
+				"within the configured time window. Further karma has been blocked.",
+				executorID, targetID, limits.PairLimit),
+		},
+		"User (%s) exceeded the pair limit of %d karma given to user (%s)",
+		executorID, limits.PairLimit, targetID)
+	return
+}
+
+// checkSockPuppet flags executorID as suspected sock puppet if
+// all karma given by them within the sock puppet window went to
+// the same member and the configured threshold is reached.
+func (k *Service) checkSockPuppet(guildID, executorID string) (err error) {
+	limits, err := k.farmingLimits(guildID)
+	if err != nil || limits.SockPuppetThreshold == 0 {
+		return
+	}
+
+	now := k.tp.Now()
+	given, err := k.db.GetKarmaLedgerGiven(guildID, executorID, now.Add(-sockPuppetWindow), now)
+	if err != nil {
+		return
+	}
+
+	var (
+		targetID string
+		n        int
+	)
+	for _, e := range given {
+		if e.Source != models.KarmaSourceReaction {
+			continue
+		}
+		if targetID != "" && e.TargetID != targetID {
+			return
+		}
+		targetID = e.TargetID
+		n++
+	}
+	if n < limits.SockPuppetThreshold {
+		return
+	}
+
+	err = k.flag(guildID, fmt.Sprintf("sockpuppet:%s:%s:%s", guildID, executorID, targetID), sockPuppetWindow,
+		&discordgo.MessageEmbed{
+			Title: "Suspected Karma Sock Puppet",
+			Description: fmt.Sprintf("<@%s> has given karma %d times within the last 30 days, "+
+				"all of it to <@%s>.\n\nUse `/karma revert` to revert the given karma if this is abuse.",
+				executorID, n, targetID),
+		},
+		"User (%s) is suspected to be a sock puppet: all of their last %d karma went to user (%s)",
+		executorID, n, targetID)
+	return
+}
+
+// Decay moves the karma of members who neither gave nor
+// received karma within the inactivity period configured
+// for their guild toward zero by the configured amount.
+//
+// This is expected to be executed once per day.
+func (k *Service) Decay() (err error) {
+	guildIDs, err := k.db.GetKarmaDecayGuilds()
+	if err != nil {
+		return
+	}
+
+	var errs []error
+	for _, guildID := range guildIDs {
+		if err = k.decayGuild(guildID); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", guildID, err.Error()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (k *Service) decayGuild(guildID string) (err error) {
+	decay, err := k.db.GetKarmaDecay(guildID)
+	if err != nil || !decay.Enabled() {
+		return
+	}
+
+	// Members are only considered inactive since decay has
+	// been enabled, because activity before that might not
+	// be recorded in the karma ledger.
+	inactiveSince, ok := decay.InactiveSince(k.tp.Now())
+	if !ok {
+		return
+	}
+	candidates, err := k.db.GetKarmaDecayCandidates(guildID, inactiveSince)
+	if err != nil {
+		return
+	}
+
+	var errs []error
+	for _, c := range candidates {
+		delta := decay.Amount
+		if c.Value > 0 {
+			if delta > c.Value {
+				delta = c.Value
+			}
+			delta = -delta
+		} else if delta > -c.Value {
+			delta = -c.Value
+		}
+
+		if _, err = k.record(guildID, c.UserID, "", delta, models.KarmaSourceDecay); err != nil {
+			return
+		}
+		if err = k.applyRules(guildID, c.UserID, "", delta); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(candidates) > 0 {
+		k.gl.Infof(guildID, "Karma of %d inactive members has decayed", len(candidates))
+	}
+
+	return errors.Join(errs...)
+}
+
+// flag reports suspicious karma activity to the guild log
+// and the mod notification channel of the guild. Flags with
+// the same key are only reported once within lifetime.
+func (k *Service) flag(
+	guildID, key string,
+	lifetime time.Duration,
+	emb *discordgo.MessageEmbed,
+	logMsg string,
+	logArgs ...interface{},
+) error {
+	if k.flagged.Contains(key) {
+		return nil
+	}
+	k.flagged.Set(key, true, lifetime)
+
+	k.gl.Warnf(guildID, logMsg, logArgs...)
+
+	emb.Color = static.ColorEmbedOrange
+	return modnot.Send(k.db, k.s, guildID, emb)
+}
+
+func (k *Service) farmingLimits(guildID string) (limits models.KarmaFarmingLimits, err error) {
+	limits, err = k.db.GetKarmaFarmingLimits(guildID)
+	if database.IsErrDatabaseNotFound(err) {
+		err = nil
+	}
+	return
+}
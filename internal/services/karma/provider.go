@@ -1,11 +1,20 @@
 package karma
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
 
 type Provider interface {
 	GetState(guildID string) (ok bool, err error)
 	IsBlockListed(guildID, userID string) (isBlocklisted bool, err error)
-	Update(guildID, userID, executorID string, value int) (err error)
+	Update(guildID, userID, executorID, channelID string, value int, source models.KarmaSource) (err error)
+	CheckPairLimit(guildID, executorID, targetID string) (ok bool, err error)
+	Revert(guildID, executorID, moderatorID string, from, to time.Time) (reverts []models.KarmaLedgerEntry, err error)
 	ApplyPenalty(guildID, userID string) (err error)
-	CheckAndUpdate(guildID, executorID string, object *discordgo.User, value int) (ok bool, err error)
+	CheckAndUpdate(guildID, executorID, channelID string, object *discordgo.User, value int, source models.KarmaSource) (ok bool, err error)
+	TakeGrantedToken(guildID, userID string) bool
+	PreviewRule(rule models.KarmaRule) (res []models.GuildKarma, err error)
 }
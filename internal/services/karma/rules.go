@@ -0,0 +1,288 @@
+package karma
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+)
+
+// grantedTokensLifetime is the time span in which tokens granted
+// by a karma rule without a duration can be used.
+const grantedTokensLifetime = 24 * time.Hour
+
+// applyRules executes the actions of all karma rules of the
+// guild which are triggered by the change of the karma of the
+// specified user by value. channelID is the channel where the
+// change has been caused, if any.
+func (k *Service) applyRules(guildID, userID, channelID string, value int) (err error) {
+	rules, err := k.db.GetKarmaRules(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	valAfter, err := k.db.GetKarma(userID, guildID)
+	if err != nil {
+		return
+	}
+	valBefore := valAfter - value
+
+	var (
+		member *discordgo.Member
+		errs   []error
+	)
+	for _, rule := range rules {
+		entered := !rule.Matches(valBefore) && rule.Matches(valAfter)
+		left := rule.Matches(valBefore) && !rule.Matches(valAfter)
+		if !entered && !left {
+			continue
+		}
+
+		if !rule.AppliesToChannel(channelID) {
+			continue
+		}
+		if len(rule.Roles) > 0 {
+			if member == nil {
+				if member, err = k.st.Member(guildID, userID); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
+			if !rule.AppliesToRoles(member.Roles) {
+				continue
+			}
+		}
+
+		if left {
+			errs = append(errs, k.revertRule(rule, userID))
+			continue
+		}
+
+		if rule.Cooldown > 0 {
+			key := fmt.Sprintf("%s:%s", rule.ID, userID)
+			if k.cooldowns.Contains(key) {
+				continue
+			}
+			k.cooldowns.Set(key, true, time.Duration(rule.Cooldown)*time.Second)
+		}
+
+		errs = append(errs, k.executeRule(rule, userID, value > 0))
+	}
+
+	return errors.Join(errs...)
+}
+
+// executeRule applies the action of rule to the specified
+// user after their karma started matching the rule.
+func (k *Service) executeRule(rule models.KarmaRule, userID string, added bool) (err error) {
+	guildID := rule.GuildID
+	desc := triggerDescription(rule)
+
+	switch rule.Action {
+	case models.KarmaActionToggleRole:
+		if err = k.s.GuildMemberRoleAdd(guildID, userID, rule.Argument); err != nil {
+			k.log.Error().Err(err).Fields("gid", guildID, "uid", userID).Msg("Failed adding role")
+			k.gl.Errorf(guildID, "Failed adding role to user (%s): %s", userID, err.Error())
+		}
+	case models.KarmaActionSendMessage:
+		k.trySendKarmaMessage(userID, guildID, added, desc, rule.Argument)
+	case models.KarmaActionKick:
+		k.tryKick(userID, guildID, added, desc)
+	case models.KarmaActionBan:
+		k.tryBan(userID, guildID, added, desc)
+	case models.KarmaActionTimeout:
+		err = k.tryTimeout(rule, userID, desc)
+	case models.KarmaActionAnnounce:
+		err = k.tryAnnounce(rule, userID, added)
+	case models.KarmaActionTempRole:
+		err = k.tryAddTempRole(rule, userID)
+	case models.KarmaActionGrantTokens:
+		k.grantTokens(rule, userID)
+	}
+
+	return
+}
+
+// revertRule reverts the action of rule for the specified
+// user after their karma stopped matching the rule. Only
+// toggled roles are reverted.
+func (k *Service) revertRule(rule models.KarmaRule, userID string) (err error) {
+	if rule.Action != models.KarmaActionToggleRole {
+		return
+	}
+
+	if err = k.s.GuildMemberRoleRemove(rule.GuildID, userID, rule.Argument); err != nil {
+		k.log.Error().Err(err).Fields("gid", rule.GuildID, "uid", userID).Msg("Failed removing role")
+		k.gl.Errorf(rule.GuildID, "Failed removing role to user (%s): %s", userID, err.Error())
+	}
+	return
+}
+
+func (k *Service) tryTimeout(rule models.KarmaRule, userID, desc string) (err error) {
+	self, err := k.st.SelfUser()
+	if err != nil {
+		return
+	}
+
+	timeout := k.tp.Now().Add(time.Duration(rule.Duration) * time.Second)
+	_, err = k.rep.PushMute(models.Report{
+		GuildID:    rule.GuildID,
+		ExecutorID: self.ID,
+		VictimID:   userID,
+		Msg:        fmt.Sprintf("Karma %s", desc),
+		Timeout:    &timeout,
+	})
+	if err != nil {
+		k.log.Error().Err(err).Fields("gid", rule.GuildID, "uid", userID).Msg("Failed muting member")
+		k.gl.Errorf(rule.GuildID, "Failed muting user (%s): %s", userID, err.Error())
+	}
+	return
+}
+
+func (k *Service) tryAnnounce(rule models.KarmaRule, userID string, added bool) (err error) {
+	user, err := k.st.User(userID)
+	if err != nil {
+		return
+	}
+
+	msg := strings.ReplaceAll(rule.Argument, "[user]", user.Username)
+	msg = strings.ReplaceAll(msg, "[ment]", user.Mention())
+
+	emb := &discordgo.MessageEmbed{
+		Description: msg,
+		Color:       static.ColorEmbedOrange,
+	}
+	if added {
+		emb.Color = static.ColorEmbedGreen
+	}
+
+	if _, err = k.s.ChannelMessageSendEmbed(rule.ChannelID, emb); err != nil {
+		k.log.Error().Err(err).Fields("gid", rule.GuildID, "cid", rule.ChannelID).Msg("Failed sending announcement")
+		k.gl.Errorf(rule.GuildID, "Failed sending karma announcement to channel (%s): %s", rule.ChannelID, err.Error())
+	}
+	return
+}
+
+func (k *Service) tryAddTempRole(rule models.KarmaRule, userID string) (err error) {
+	if err = k.s.GuildMemberRoleAdd(rule.GuildID, userID, rule.Argument); err != nil {
+		k.log.Error().Err(err).Fields("gid", rule.GuildID, "uid", userID).Msg("Failed adding role")
+		k.gl.Errorf(rule.GuildID, "Failed adding role to user (%s): %s", userID, err.Error())
+		return
+	}
+
+	return k.db.SetKarmaTempRole(models.KarmaTempRole{
+		GuildID: rule.GuildID,
+		UserID:  userID,
+		RoleID:  rule.Argument,
+		Expires: k.tp.Now().Add(time.Duration(rule.Duration) * time.Second),
+	})
+}
+
+func (k *Service) grantTokens(rule models.KarmaRule, userID string) {
+	n, _ := strconv.Atoi(rule.Argument)
+
+	lifetime := grantedTokensLifetime
+	if rule.Duration > 0 {
+		lifetime = time.Duration(rule.Duration) * time.Second
+	}
+
+	key := fmt.Sprintf("%s:%s", rule.GuildID, userID)
+	if tokens, ok := k.grantedTokens.GetValue(key).(*int32); ok {
+		atomic.AddInt32(tokens, int32(n))
+		k.grantedTokens.Refresh(key, lifetime)
+		return
+	}
+
+	tokens := int32(n)
+	k.grantedTokens.Set(key, &tokens, lifetime)
+}
+
+// TakeGrantedToken takes one of the karma tokens granted to
+// the specified user by karma rules. If the user has no granted
+// tokens left, false is returned.
+func (k *Service) TakeGrantedToken(guildID, userID string) bool {
+	key := fmt.Sprintf("%s:%s", guildID, userID)
+	tokens, ok := k.grantedTokens.GetValue(key).(*int32)
+	if !ok {
+		return false
+	}
+
+	if atomic.AddInt32(tokens, -1) < 0 {
+		k.grantedTokens.Remove(key)
+		return false
+	}
+	return true
+}
+
+// ExpireTempRoles removes all temporary roles added by
+// karma rules which have expired.
+func (k *Service) ExpireTempRoles() (err error) {
+	expired, err := k.db.GetExpiredKarmaTempRoles(k.tp.Now())
+	if err != nil {
+		return
+	}
+
+	var errs []error
+	for _, tr := range expired {
+		if err = k.s.GuildMemberRoleRemove(tr.GuildID, tr.UserID, tr.RoleID); err != nil {
+			k.gl.Errorf(tr.GuildID, "Failed removing temporary role (%s) from user (%s): %s",
+				tr.RoleID, tr.UserID, err.Error())
+		}
+		if err = k.db.RemoveKarmaTempRole(tr.GuildID, tr.UserID, tr.RoleID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// PreviewRule returns the karma of all members of the guild
+// of rule whose current karma matches the trigger of the rule
+// and who are in the role scope of the rule.
+func (k *Service) PreviewRule(rule models.KarmaRule) (res []models.GuildKarma, err error) {
+	n, err := k.db.GetKarmaGuildCount(rule.GuildID)
+	if err != nil {
+		return
+	}
+	karma, err := k.db.GetKarmaGuild(rule.GuildID, n)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return
+	}
+
+	res = make([]models.GuildKarma, 0)
+	for _, e := range karma {
+		if !rule.Matches(e.Value) {
+			continue
+		}
+		if len(rule.Roles) > 0 {
+			member, err := k.st.Member(rule.GuildID, e.UserID)
+			if err != nil || !rule.AppliesToRoles(member.Roles) {
+				continue
+			}
+		}
+		res = append(res, e)
+	}
+
+	return res, nil
+}
+
+func triggerDescription(rule models.KarmaRule) string {
+	switch rule.Trigger {
+	case models.KarmaTriggerAbove:
+		return fmt.Sprintf("rised above %d points", rule.Value)
+	case models.KarmaTriggerBelow:
+		return fmt.Sprintf("dropped below %d points", rule.Value)
+	default:
+		return fmt.Sprintf("reached between %d and %d points", rule.Value, rule.MaxValue)
+	}
+}
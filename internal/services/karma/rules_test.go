@@ -0,0 +1,180 @@
+package karma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/mocks"
+	"github.com/zekroTJA/timedmap"
+	"github.com/zekrotja/rogu/log"
+)
+
+type rulesMock struct {
+	db      *mocks.Database
+	session *mocks.ISession
+	st      *mocks.IState
+	tp      *mocks.TimeProvider
+}
+
+func newRulesService(rules ...models.KarmaRule) (*Service, rulesMock) {
+	m := rulesMock{
+		db:      &mocks.Database{},
+		session: &mocks.ISession{},
+		st:      &mocks.IState{},
+		tp:      &mocks.TimeProvider{},
+	}
+
+	m.db.On("GetKarmaRules", "guild").Return(rules, nil)
+	m.session.On("GuildMemberRoleAdd", "guild", mock.Anything, mock.Anything).Return(nil)
+	m.session.On("GuildMemberRoleRemove", "guild", mock.Anything, mock.Anything).Return(nil)
+
+	cache := timedmap.New(time.Minute)
+	k := &Service{
+		s:             m.session,
+		db:            m.db,
+		gl:            &mocks.Logger{},
+		st:            m.st,
+		tp:            m.tp,
+		log:           log.Tagged("Karma"),
+		cooldowns:     cache.Section(1),
+		grantedTokens: cache.Section(2),
+	}
+
+	return k, m
+}
+
+func TestApplyRulesRange(t *testing.T) {
+	rule := models.KarmaRule{ID: 1, GuildID: "guild", Trigger: models.KarmaTriggerRange,
+		Value: 10, MaxValue: 20, Action: models.KarmaActionToggleRole, Argument: "role"}
+	k, m := newRulesService(rule)
+
+	// 9 -> 10: entering the range
+	m.db.On("GetKarma", "entering", "guild").Return(10, nil)
+	require.NoError(t, k.applyRules("guild", "entering", "", 1))
+	m.session.AssertCalled(t, "GuildMemberRoleAdd", "guild", "entering", "role")
+
+	// 15 -> 16: staying in the range
+	m.db.On("GetKarma", "staying", "guild").Return(16, nil)
+	require.NoError(t, k.applyRules("guild", "staying", "", 1))
+	m.session.AssertNotCalled(t, "GuildMemberRoleAdd", "guild", "staying", "role")
+
+	// 20 -> 21: leaving the range
+	m.db.On("GetKarma", "leaving", "guild").Return(21, nil)
+	require.NoError(t, k.applyRules("guild", "leaving", "", 1))
+	m.session.AssertCalled(t, "GuildMemberRoleRemove", "guild", "leaving", "role")
+	m.session.AssertNumberOfCalls(t, "GuildMemberRoleRemove", 1)
+}
+
+func TestApplyRulesScope(t *testing.T) {
+	rule := models.KarmaRule{ID: 1, GuildID: "guild", Trigger: models.KarmaTriggerAbove,
+		Value: 5, Action: models.KarmaActionToggleRole, Argument: "role",
+		Channels: []string{"chan"}, Roles: []string{"member-role"}}
+	k, m := newRulesService(rule)
+
+	m.db.On("GetKarma", mock.Anything, "guild").Return(6, nil)
+	m.st.On("Member", "guild", "member").Return(&discordgo.Member{Roles: []string{"member-role"}}, nil)
+	m.st.On("Member", "guild", "other").Return(&discordgo.Member{Roles: []string{"other-role"}}, nil)
+
+	require.NoError(t, k.applyRules("guild", "member", "other-chan", 1))
+	require.NoError(t, k.applyRules("guild", "other", "chan", 1))
+	m.session.AssertNotCalled(t, "GuildMemberRoleAdd", mock.Anything, mock.Anything, mock.Anything)
+
+	require.NoError(t, k.applyRules("guild", "member", "chan", 1))
+	m.session.AssertCalled(t, "GuildMemberRoleAdd", "guild", "member", "role")
+}
+
+func TestApplyRulesCooldown(t *testing.T) {
+	rule := models.KarmaRule{ID: 1, GuildID: "guild", Trigger: models.KarmaTriggerAbove,
+		Value: 5, Action: models.KarmaActionToggleRole, Argument: "role", Cooldown: 3600}
+	k, m := newRulesService(rule)
+
+	m.db.On("GetKarma", "user", "guild").Return(6, nil).Once()
+	require.NoError(t, k.applyRules("guild", "user", "", 1))
+	m.db.On("GetKarma", "user", "guild").Return(5, nil).Once()
+	require.NoError(t, k.applyRules("guild", "user", "", -1))
+	m.db.On("GetKarma", "user", "guild").Return(6, nil).Once()
+	require.NoError(t, k.applyRules("guild", "user", "", 1))
+
+	m.session.AssertNumberOfCalls(t, "GuildMemberRoleAdd", 1)
+	m.session.AssertNumberOfCalls(t, "GuildMemberRoleRemove", 1)
+}
+
+func TestApplyRulesTempRole(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	rule := models.KarmaRule{ID: 1, GuildID: "guild", Trigger: models.KarmaTriggerAbove,
+		Value: 5, Action: models.KarmaActionTempRole, Argument: "role", Duration: 3600}
+	k, m := newRulesService(rule)
+
+	m.tp.On("Now").Return(now)
+	m.db.On("GetKarma", "user", "guild").Return(6, nil)
+	m.db.On("SetKarmaTempRole", mock.Anything).Return(nil)
+
+	require.NoError(t, k.applyRules("guild", "user", "", 1))
+	m.session.AssertCalled(t, "GuildMemberRoleAdd", "guild", "user", "role")
+	m.db.AssertCalled(t, "SetKarmaTempRole", models.KarmaTempRole{
+		GuildID: "guild", UserID: "user", RoleID: "role", Expires: now.Add(time.Hour)})
+}
+
+func TestExpireTempRoles(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	k, m := newRulesService()
+
+	m.tp.On("Now").Return(now)
+	m.db.On("GetExpiredKarmaTempRoles", now).Return([]models.KarmaTempRole{
+		{GuildID: "guild", UserID: "user", RoleID: "role"},
+	}, nil)
+	m.db.On("RemoveKarmaTempRole", "guild", "user", "role").Return(nil)
+
+	require.NoError(t, k.ExpireTempRoles())
+	m.session.AssertCalled(t, "GuildMemberRoleRemove", "guild", "user", "role")
+	m.db.AssertCalled(t, "RemoveKarmaTempRole", "guild", "user", "role")
+}
+
+func TestGrantedTokens(t *testing.T) {
+	rule := models.KarmaRule{ID: 1, GuildID: "guild", Trigger: models.KarmaTriggerAbove,
+		Value: 5, Action: models.KarmaActionGrantTokens, Argument: "2"}
+	k, m := newRulesService(rule)
+
+	assert.False(t, k.TakeGrantedToken("guild", "user"))
+
+	m.db.On("GetKarma", "user", "guild").Return(6, nil)
+	require.NoError(t, k.applyRules("guild", "user", "", 1))
+
+	assert.True(t, k.TakeGrantedToken("guild", "user"))
+	assert.True(t, k.TakeGrantedToken("guild", "user"))
+	assert.False(t, k.TakeGrantedToken("guild", "user"))
+	assert.False(t, k.TakeGrantedToken("guild", "other"))
+}
+
+func TestPreviewRule(t *testing.T) {
+	k, m := newRulesService()
+
+	m.db.On("GetKarmaGuildCount", "guild").Return(4, nil)
+	m.db.On("GetKarmaGuild", "guild", 4).Return([]models.GuildKarma{
+		{GuildID: "guild", UserID: "a", Value: 30},
+		{GuildID: "guild", UserID: "b", Value: 15},
+		{GuildID: "guild", UserID: "c", Value: 12},
+		{GuildID: "guild", UserID: "d", Value: 2},
+	}, nil)
+	m.st.On("Member", "guild", "b").Return(&discordgo.Member{Roles: []string{"role"}}, nil)
+	m.st.On("Member", "guild", "c").Return(&discordgo.Member{}, nil)
+
+	res, err := k.PreviewRule(models.KarmaRule{GuildID: "guild", Trigger: models.KarmaTriggerRange,
+		Value: 10, MaxValue: 20})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "b", res[0].UserID)
+	assert.Equal(t, "c", res[1].UserID)
+
+	res, err = k.PreviewRule(models.KarmaRule{GuildID: "guild", Trigger: models.KarmaTriggerRange,
+		Value: 10, MaxValue: 20, Roles: []string{"role"}})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "b", res[0].UserID)
+}
@@ -0,0 +1,149 @@
+package linkresolver
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/zekroTJA/timedmap"
+)
+
+const (
+	// maxRedirects is the maximum number of
+	// redirects followed for a single link.
+	maxRedirects = 20
+	// requestTimeout is the timeout of a single request.
+	requestTimeout = 5 * time.Second
+	// resolvedLifetime is the duration a resolved
+	// link is cached.
+	resolvedLifetime = 6 * time.Hour
+	// failedLifetime is the duration a link which could
+	// not be resolved is cached, so that failing hosts are
+	// not requested again on every message.
+	failedLifetime = 10 * time.Minute
+)
+
+type cacheEntry struct {
+	chain []string
+	err   error
+}
+
+type call struct {
+	wg sync.WaitGroup
+	cacheEntry
+}
+
+type impl struct {
+	cache *timedmap.TimedMap
+	fetch func(link string) (statusCode int, location string, err error)
+
+	mtx      sync.Mutex
+	inflight map[string]*call
+}
+
+var _ Provider = (*impl)(nil)
+
+func New() Provider {
+	return &impl{
+		cache:    timedmap.New(5 * time.Minute),
+		fetch:    fetch,
+		inflight: make(map[string]*call),
+	}
+}
+
+func (p *impl) Resolve(link string) (chain []string, err error) {
+	link = normalize(link)
+
+	if e, ok := p.cache.GetValue(link).(cacheEntry); ok {
+		return e.chain, e.err
+	}
+
+	// Concurrent lookups of the same link, like a link
+	// spammed by multiple users at once, share a single
+	// resolution.
+	p.mtx.Lock()
+	if c, ok := p.inflight[link]; ok {
+		p.mtx.Unlock()
+		c.wg.Wait()
+		return c.chain, c.err
+	}
+	c := &call{}
+	c.wg.Add(1)
+	p.inflight[link] = c
+	p.mtx.Unlock()
+
+	c.chain, c.err = p.resolve(link)
+
+	lifetime := resolvedLifetime
+	if c.err != nil {
+		lifetime = failedLifetime
+	}
+	p.cache.Set(link, c.cacheEntry, lifetime)
+
+	p.mtx.Lock()
+	delete(p.inflight, link)
+	p.mtx.Unlock()
+	c.wg.Done()
+
+	return c.chain, c.err
+}
+
+func (p *impl) resolve(link string) (chain []string, err error) {
+	chain = []string{link}
+
+	for i := 0; i < maxRedirects; i++ {
+		code, location, err := p.fetch(link)
+		if err != nil {
+			return chain, err
+		}
+		if code < 300 || code > 399 || location == "" {
+			break
+		}
+
+		if link, err = resolveReference(link, location); err != nil {
+			return chain, err
+		}
+		chain = append(chain, link)
+	}
+
+	return chain, nil
+}
+
+func fetch(link string) (statusCode int, location string, err error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	res := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(res)
+
+	req.Header.SetMethod("GET")
+	req.SetRequestURI(link)
+
+	if err = fasthttp.DoTimeout(req, res, requestTimeout); err != nil {
+		return
+	}
+
+	return res.StatusCode(), string(res.Header.Peek("location")), nil
+}
+
+func normalize(link string) string {
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		link = "http://" + link
+	}
+	return link
+}
+
+// resolveReference resolves the location header, which
+// may be a relative reference, against the current link.
+func resolveReference(link, location string) (string, error) {
+	base, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,111 @@
+package linkresolver
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/timedmap"
+)
+
+type fetchResult struct {
+	code     int
+	location string
+	err      error
+}
+
+func newResolver(results map[string]fetchResult) (*impl, map[string]int) {
+	var mtx sync.Mutex
+	calls := make(map[string]int)
+
+	p := &impl{
+		cache:    timedmap.New(time.Minute),
+		inflight: make(map[string]*call),
+		fetch: func(link string) (int, string, error) {
+			mtx.Lock()
+			calls[link]++
+			mtx.Unlock()
+			r, ok := results[link]
+			if !ok {
+				return 200, "", nil
+			}
+			return r.code, r.location, r.err
+		},
+	}
+
+	return p, calls
+}
+
+func TestResolve(t *testing.T) {
+	p, calls := newResolver(map[string]fetchResult{
+		"http://short.link/abc":                  {code: 301, location: "https://short.link/abc"},
+		"https://short.link/abc":                 {code: 302, location: "/redirect?to=discord"},
+		"https://short.link/redirect?to=discord": {code: 307, location: "https://discord.gg/invite"},
+	})
+
+	expected := []string{
+		"http://short.link/abc",
+		"https://short.link/abc",
+		"https://short.link/redirect?to=discord",
+		"https://discord.gg/invite",
+	}
+
+	chain, err := p.Resolve("short.link/abc")
+	require.NoError(t, err)
+	assert.Equal(t, expected, chain)
+
+	chain, err = p.Resolve("http://short.link/abc")
+	require.NoError(t, err)
+	assert.Equal(t, expected, chain)
+
+	assert.Equal(t, 1, calls["http://short.link/abc"])
+	assert.Equal(t, 1, calls["https://discord.gg/invite"])
+}
+
+func TestResolve_Loop(t *testing.T) {
+	p, calls := newResolver(map[string]fetchResult{
+		"http://a.link": {code: 302, location: "http://b.link"},
+		"http://b.link": {code: 302, location: "http://a.link"},
+	})
+
+	chain, err := p.Resolve("a.link")
+	require.NoError(t, err)
+	assert.Len(t, chain, maxRedirects+1)
+	assert.Equal(t, maxRedirects/2, calls["http://a.link"])
+}
+
+func TestResolve_Error(t *testing.T) {
+	p, calls := newResolver(map[string]fetchResult{
+		"http://short.link/abc": {code: 301, location: "http://broken.link"},
+		"http://broken.link":    {err: errors.New("test error")},
+	})
+
+	for i := 0; i < 2; i++ {
+		chain, err := p.Resolve("short.link/abc")
+		assert.Error(t, err)
+		assert.Equal(t, []string{"http://short.link/abc", "http://broken.link"}, chain)
+	}
+
+	assert.Equal(t, 1, calls["http://broken.link"])
+}
+
+func TestResolve_Concurrent(t *testing.T) {
+	p, calls := newResolver(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chain, err := p.Resolve("some.link")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"http://some.link"}, chain)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, calls["http://some.link"])
+}
@@ -0,0 +1,11 @@
+// Package linkresolver follows the redirects of links, like
+// links of URL shorteners, and caches the results so that
+// the same link is not requested again on every message.
+package linkresolver
+
+type Provider interface {
+	// Resolve follows the redirects of the given link and
+	// returns all visited locations in order, starting with
+	// the link itself.
+	Resolve(link string) (chain []string, err error)
+}
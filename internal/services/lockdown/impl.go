@@ -0,0 +1,355 @@
+package lockdown
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/util/chanlock"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
+	"github.com/zekrotja/dgrs"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
+)
+
+// maxRecordedJoins is the maximum number of joined
+// members recorded during a lockdown.
+const maxRecordedJoins = 1000
+
+type impl struct {
+	s   discordutil.ISession
+	db  database.Database
+	st  dgrs.IState
+	gl  guildlog.Logger
+	tp  timeprovider.Provider
+	log rogu.Logger
+
+	mtx sync.Mutex
+}
+
+var _ Provider = (*impl)(nil)
+
+func New(ctn di.Container) Provider {
+	return &impl{
+		s:   ctn.Get(static.DiDiscordSession).(discordutil.ISession),
+		db:  ctn.Get(static.DiDatabase).(database.Database),
+		st:  ctn.Get(static.DiState).(dgrs.IState),
+		gl:  ctn.Get(static.DiGuildLog).(guildlog.Logger).Section("lockdown"),
+		tp:  ctn.Get(static.DiTimeProvider).(timeprovider.Provider),
+		log: log.Tagged("Lockdown"),
+	}
+}
+
+func (p *impl) Start(guildID, executorID string) (state models.AntiraidLockdownState, err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if ok, err := p.active(guildID); err != nil {
+		return state, err
+	} else if ok {
+		return state, ErrActive
+	}
+
+	cfg, err := p.db.GetAntiraidLockdown(guildID)
+	if database.IsErrDatabaseNotFound(err) {
+		cfg = models.DefaultAntiraidLockdown
+	} else if err != nil {
+		return
+	}
+
+	guild, err := p.st.Guild(guildID, true)
+	if err != nil {
+		return
+	}
+
+	state = models.AntiraidLockdownState{
+		GuildID:       guildID,
+		StartedBy:     executorID,
+		Started:       p.tp.Now(),
+		QuietPeriod:   cfg.QuietPeriod,
+		PrevSlowmodes: make(map[string]int),
+	}
+
+	// The state is stored before applying any changes so
+	// that the lockdown is known even if the following
+	// steps are interrupted.
+	if err = p.db.SetAntiraidLockdownState(state); err != nil {
+		return
+	}
+
+	fail := func(action string, err error) {
+		p.log.Error().Err(err).Field("gid", guildID).Msgf("Failed to %s", action)
+		state.Failures = append(state.Failures, fmt.Sprintf("Failed to %s: %s", action, err.Error()))
+	}
+
+	if guild.VerificationLevel < discordgo.VerificationLevelVeryHigh {
+		prev := guild.VerificationLevel
+		lvl := discordgo.VerificationLevelVeryHigh
+		if _, err = p.s.GuildEdit(guildID, &discordgo.GuildParams{VerificationLevel: &lvl}); err != nil {
+			fail("raise verification level", err)
+		} else {
+			state.PrevVerificationLevel = &prev
+		}
+	}
+
+	if cfg.PauseInvites && !discordutil.HasGuildFeature(guild, discordutil.GuildFeatureInvitesDisabled) {
+		features := append(append([]discordgo.GuildFeature{}, guild.Features...),
+			discordutil.GuildFeatureInvitesDisabled)
+		if err = discordutil.SetGuildFeatures(p.s, guildID, features); err != nil {
+			fail("pause invites", err)
+		} else {
+			state.InvitesPaused = true
+		}
+	}
+
+	if len(cfg.LockChannels) != 0 {
+		if err = p.lockChannels(guildID, cfg.LockChannels, &state); err != nil {
+			fail("lock channels", err)
+		}
+	}
+
+	for _, chID := range cfg.SlowmodeChannels {
+		ch, err := p.st.Channel(chID)
+		if err != nil {
+			fail(fmt.Sprintf("get channel %s", chID), err)
+			continue
+		}
+		if ch.RateLimitPerUser >= cfg.Slowmode {
+			continue
+		}
+		slowmode := cfg.Slowmode
+		if _, err = p.s.ChannelEdit(chID, &discordgo.ChannelEdit{RateLimitPerUser: &slowmode}); err != nil {
+			fail(fmt.Sprintf("enable slowmode in <#%s>", chID), err)
+			continue
+		}
+		state.PrevSlowmodes[chID] = ch.RateLimitPerUser
+	}
+
+	if err = p.db.SetAntiraidLockdownState(state); err != nil {
+		return
+	}
+
+	p.gl.Warnf(guildID, "Lockdown started by %s: %s",
+		executor(executorID, "antiraid"), ChangesText(state))
+	p.sendModlog(guildID, &discordgo.MessageEmbed{
+		Title: "🔒 Lockdown Started",
+		Color: static.ColorEmbedOrange,
+		Description: fmt.Sprintf("A lockdown has been started by %s. It ends automatically after "+
+			"no member joined for %s.", executor(executorID, "antiraid"), time.Duration(state.QuietPeriod)*time.Second),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Changes",
+				Value: ChangesText(state),
+			},
+		},
+	})
+
+	return state, nil
+}
+
+func (p *impl) End(guildID, executorID string) (summary *Summary, err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	state, err := p.db.GetAntiraidLockdownState(guildID)
+	if database.IsErrDatabaseNotFound(err) {
+		return nil, ErrNotActive
+	} else if err != nil {
+		return
+	}
+
+	summary = &Summary{
+		State:   state,
+		Ended:   p.tp.Now(),
+		EndedBy: executorID,
+	}
+
+	fail := func(action string, err error) {
+		p.log.Error().Err(err).Field("gid", guildID).Msgf("Failed to %s", action)
+		summary.Failures = append(summary.Failures, fmt.Sprintf("Failed to %s: %s", action, err.Error()))
+	}
+
+	if state.PrevVerificationLevel != nil {
+		if _, err = p.s.GuildEdit(guildID, &discordgo.GuildParams{
+			VerificationLevel: state.PrevVerificationLevel,
+		}); err != nil {
+			fail("reset verification level", err)
+		}
+	}
+
+	if state.InvitesPaused {
+		if err = p.resumeInvites(guildID); err != nil {
+			fail("resume invites", err)
+		}
+	}
+
+	for _, chID := range state.LockedChannels {
+		_, _, encodedPerms, err := p.db.GetLockChan(chID)
+		if database.IsErrDatabaseNotFound(err) {
+			// The channel has been unlocked manually
+			// in the meantime.
+			continue
+		}
+		if err != nil {
+			fail(fmt.Sprintf("unlock <#%s>", chID), err)
+			continue
+		}
+		failed, err := chanlock.Unlock(p.s, p.db, chID, encodedPerms)
+		if err != nil {
+			fail(fmt.Sprintf("unlock <#%s>", chID), err)
+		} else if failed > 0 {
+			fail(fmt.Sprintf("unlock <#%s>", chID),
+				fmt.Errorf("%d permission overwrites could not be restored", failed))
+		}
+	}
+
+	for chID, prev := range state.PrevSlowmodes {
+		prev := prev
+		if _, err = p.s.ChannelEdit(chID, &discordgo.ChannelEdit{RateLimitPerUser: &prev}); err != nil {
+			fail(fmt.Sprintf("reset slowmode in <#%s>", chID), err)
+		}
+	}
+
+	if err = p.db.DeleteAntiraidLockdownState(guildID); err != nil {
+		return nil, err
+	}
+
+	p.gl.Infof(guildID, "Lockdown ended by %s after %s; %d members joined meanwhile; %d rollback failures",
+		executor(executorID, "quiet period"), summary.Duration(), len(state.Joins), len(summary.Failures))
+	p.sendModlog(guildID, summary.Embed())
+
+	return summary, nil
+}
+
+func (p *impl) Active(guildID string) (ok bool, err error) {
+	return p.active(guildID)
+}
+
+func (p *impl) RecordJoin(guildID, userID string) (err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	state, err := p.db.GetAntiraidLockdownState(guildID)
+	if database.IsErrDatabaseNotFound(err) {
+		return ErrNotActive
+	} else if err != nil {
+		return
+	}
+
+	state.LastJoin = p.tp.Now()
+	if len(state.Joins) < maxRecordedJoins {
+		state.Joins = append(state.Joins, userID)
+	}
+
+	return p.db.SetAntiraidLockdownState(state)
+}
+
+func (p *impl) RollbackExpired() {
+	states, err := p.db.GetAntiraidLockdownStates()
+	if err != nil {
+		p.log.Error().Err(err).Msg("Failed getting lockdown states")
+		return
+	}
+
+	now := p.tp.Now()
+	for _, state := range states {
+		if !p.isOwnGuild(state.GuildID) || state.QuietUntil().After(now) {
+			continue
+		}
+		if _, err = p.End(state.GuildID, ""); err != nil && err != ErrNotActive {
+			p.log.Error().Err(err).Field("gid", state.GuildID).Msg("Failed rolling back lockdown")
+			p.gl.Errorf(state.GuildID, "Failed rolling back lockdown: %s", err.Error())
+		}
+	}
+}
+
+func (p *impl) active(guildID string) (ok bool, err error) {
+	_, err = p.db.GetAntiraidLockdownState(guildID)
+	if database.IsErrDatabaseNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// lockChannels locks the given channels as the bot user.
+// Channels which are already locked are skipped so that
+// they stay locked after the lockdown.
+func (p *impl) lockChannels(guildID string, channelIDs []string, state *models.AntiraidLockdownState) error {
+	self, err := p.st.SelfUser()
+	if err != nil {
+		return err
+	}
+
+	selfMember, err := p.st.Member(guildID, self.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, chID := range channelIDs {
+		_, _, _, err = p.db.GetLockChan(chID)
+		if err == nil {
+			continue
+		}
+		if !database.IsErrDatabaseNotFound(err) {
+			return err
+		}
+
+		ch, err := p.st.Channel(chID)
+		if err != nil {
+			return err
+		}
+		if err = chanlock.Lock(p.s, p.st, p.db, ch, self.ID, selfMember.Roles); err != nil {
+			return fmt.Errorf("<#%s>: %s", chID, err.Error())
+		}
+
+		state.LockedChannels = append(state.LockedChannels, chID)
+	}
+
+	return nil
+}
+
+func (p *impl) resumeInvites(guildID string) error {
+	guild, err := p.st.Guild(guildID, true)
+	if err != nil {
+		return err
+	}
+
+	features := make([]discordgo.GuildFeature, 0, len(guild.Features))
+	for _, f := range guild.Features {
+		if f != discordutil.GuildFeatureInvitesDisabled {
+			features = append(features, f)
+		}
+	}
+
+	return discordutil.SetGuildFeatures(p.s, guildID, features)
+}
+
+func (p *impl) sendModlog(guildID string, emb *discordgo.MessageEmbed) {
+	chanID, err := p.db.GetGuildModLog(guildID)
+	if err != nil || chanID == "" {
+		return
+	}
+	if _, err = p.s.ChannelMessageSendEmbed(chanID, emb); err != nil {
+		p.log.Error().Err(err).Field("gid", guildID).Msg("Failed sending lockdown report to modlog")
+	}
+}
+
+func (p *impl) isOwnGuild(guildID string) bool {
+	s, ok := p.s.(*discordgo.Session)
+	if !ok {
+		return true
+	}
+	shardID, shardTotal := discordutil.GetShardOfSession(s)
+	if shardTotal <= 1 {
+		return true
+	}
+	id, err := discordutil.GetShardOfGuild(guildID, shardTotal)
+	return err == nil && id == shardID
+}
@@ -0,0 +1,160 @@
+package lockdown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sarulabs/di/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/lockdown"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/internal/util/testutil"
+	"github.com/zekroTJA/shinpuru/mocks"
+)
+
+type lockdownMock struct {
+	s  *mocks.ISession
+	st *mocks.IState
+	gl *mocks.Logger
+	tp *mocks.TimeProvider
+	db database.Database
+}
+
+func newLockdown(t *testing.T, cfg models.AntiraidLockdown) (lockdown.Provider, lockdownMock) {
+	m := lockdownMock{
+		s:  &mocks.ISession{},
+		st: &mocks.IState{},
+		gl: &mocks.Logger{},
+		tp: &mocks.TimeProvider{},
+		db: testutil.NewTestDatabase(t),
+	}
+
+	require.NoError(t, m.db.SetAntiraidLockdown("guild", cfg))
+
+	m.st.On("Guild", "guild", true).Return(&discordgo.Guild{
+		ID:                "guild",
+		VerificationLevel: discordgo.VerificationLevelLow,
+	}, nil)
+	m.st.On("Channel", "slow").Return(&discordgo.Channel{
+		ID:               "slow",
+		GuildID:          "guild",
+		RateLimitPerUser: 5,
+	}, nil)
+
+	m.s.On("GuildEdit", "guild", mock.Anything).Return(nil, nil)
+	m.s.On("ChannelEdit", "slow", mock.Anything).Return(nil, nil)
+	m.s.On("RequestWithBucketID", "PATCH", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	m.gl.On("Warnf", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	m.gl.On("Infof", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything).Return(nil)
+
+	m.gl.On("Section", mock.Anything).Return(m.gl)
+
+	m.tp.On("Now").Return(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)).Once()
+
+	ct, _ := di.NewBuilder()
+	ct.Add(
+		di.Def{
+			Name:  static.DiDiscordSession,
+			Build: func(ctn di.Container) (interface{}, error) { return m.s, nil },
+		},
+		di.Def{
+			Name:  static.DiDatabase,
+			Build: func(ctn di.Container) (interface{}, error) { return m.db, nil },
+		},
+		di.Def{
+			Name:  static.DiState,
+			Build: func(ctn di.Container) (interface{}, error) { return m.st, nil },
+		},
+		di.Def{
+			Name:  static.DiGuildLog,
+			Build: func(ctn di.Container) (interface{}, error) { return m.gl, nil },
+		},
+		di.Def{
+			Name:  static.DiTimeProvider,
+			Build: func(ctn di.Container) (interface{}, error) { return m.tp, nil },
+		},
+	)
+
+	return lockdown.New(ct.Build()), m
+}
+
+func TestStartEnd(t *testing.T) {
+	p, m := newLockdown(t, models.AntiraidLockdown{
+		PauseInvites:     true,
+		SlowmodeChannels: []string{"slow"},
+		Slowmode:         60,
+		QuietPeriod:      600,
+	})
+
+	state, err := p.Start("guild", "executor")
+	require.NoError(t, err)
+	require.NotNil(t, state.PrevVerificationLevel)
+	assert.Equal(t, discordgo.VerificationLevelLow, *state.PrevVerificationLevel)
+	assert.True(t, state.InvitesPaused)
+	assert.Equal(t, map[string]int{"slow": 5}, state.PrevSlowmodes)
+	assert.Empty(t, state.Failures)
+
+	m.s.AssertCalled(t, "GuildEdit", "guild", mock.MatchedBy(func(g *discordgo.GuildParams) bool {
+		return *g.VerificationLevel == discordgo.VerificationLevelVeryHigh
+	}))
+	m.s.AssertCalled(t, "ChannelEdit", "slow", mock.MatchedBy(func(c *discordgo.ChannelEdit) bool {
+		return *c.RateLimitPerUser == 60
+	}))
+
+	_, err = p.Start("guild", "executor")
+	assert.ErrorIs(t, err, lockdown.ErrActive)
+
+	m.tp.On("Now").Return(time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC)).Once()
+	require.NoError(t, p.RecordJoin("guild", "joined"))
+
+	m.tp.On("Now").Return(time.Date(2022, 1, 1, 0, 5, 0, 0, time.UTC)).Once()
+	summary, err := p.End("guild", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"joined"}, summary.State.Joins)
+	assert.Equal(t, 5*time.Minute, summary.Duration())
+	assert.Empty(t, summary.Failures)
+
+	m.s.AssertCalled(t, "GuildEdit", "guild", mock.MatchedBy(func(g *discordgo.GuildParams) bool {
+		return *g.VerificationLevel == discordgo.VerificationLevelLow
+	}))
+	m.s.AssertCalled(t, "ChannelEdit", "slow", mock.MatchedBy(func(c *discordgo.ChannelEdit) bool {
+		return *c.RateLimitPerUser == 5
+	}))
+
+	ok, err := p.Active("guild")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = p.End("guild", "")
+	assert.ErrorIs(t, err, lockdown.ErrNotActive)
+}
+
+func TestRollbackExpired(t *testing.T) {
+	p, m := newLockdown(t, models.AntiraidLockdown{
+		QuietPeriod: 600,
+	})
+
+	_, err := p.Start("guild", "")
+	require.NoError(t, err)
+
+	m.tp.On("Now").Return(time.Date(2022, 1, 1, 0, 5, 0, 0, time.UTC)).Once()
+	p.RollbackExpired()
+
+	ok, err := p.Active("guild")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	m.tp.On("Now").Return(time.Date(2022, 1, 1, 0, 11, 0, 0, time.UTC))
+	p.RollbackExpired()
+
+	ok, err = p.Active("guild")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
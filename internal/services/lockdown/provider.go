@@ -0,0 +1,39 @@
+// Package lockdown provides the antiraid lockdown mode.
+//
+// A lockdown raises the verification level of a guild,
+// pauses its invites, locks and slows down configured
+// channels and records all members joining meanwhile.
+// All changes are persisted in the database, so that
+// they are rolled back even when the bot has been
+// restarted during the lockdown.
+package lockdown
+
+import (
+	"errors"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+var (
+	ErrActive    = errors.New("lockdown is already active")
+	ErrNotActive = errors.New("lockdown is not active")
+)
+
+type Provider interface {
+	// Start starts the lockdown for the given guild. executorID
+	// is empty when the lockdown is started automatically.
+	Start(guildID, executorID string) (state models.AntiraidLockdownState, err error)
+	// End rolls back all changes of the lockdown of the given
+	// guild and returns a summary of the lockdown. executorID
+	// is empty when the lockdown is ended automatically.
+	End(guildID, executorID string) (summary *Summary, err error)
+	// Active returns true if a lockdown is active
+	// for the given guild.
+	Active(guildID string) (ok bool, err error)
+	// RecordJoin records a member who joined during an
+	// active lockdown and defers the automatic rollback.
+	RecordJoin(guildID, userID string) (err error)
+	// RollbackExpired ends all lockdowns of guilds handled
+	// by the current shard which passed their quiet period.
+	RollbackExpired()
+}
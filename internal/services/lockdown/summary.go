@@ -0,0 +1,153 @@
+package lockdown
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+)
+
+// maxListedJoins is the maximum number of joined
+// members which are listed in the summary embed.
+const maxListedJoins = 30
+
+var verificationLevels = []string{"none", "low", "medium", "high", "very high"}
+
+// Summary contains the outcome of a lockdown.
+type Summary struct {
+	State   models.AntiraidLockdownState
+	Ended   time.Time
+	EndedBy string // empty when rolled back automatically
+
+	// Failures contains the changes which could
+	// not be rolled back.
+	Failures []string
+}
+
+// Duration returns the duration of the lockdown.
+func (s *Summary) Duration() time.Duration {
+	return s.Ended.Sub(s.State.Started).Round(time.Second)
+}
+
+// Embed returns the summary report as message embed.
+func (s *Summary) Embed() *discordgo.MessageEmbed {
+	emb := &discordgo.MessageEmbed{
+		Title:     "🔓 Lockdown Ended",
+		Color:     static.ColorEmbedGreen,
+		Timestamp: s.Ended.Format(time.RFC3339),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Started",
+				Value:  fmt.Sprintf("<t:%d:f> by %s", s.State.Started.Unix(), executor(s.State.StartedBy, "antiraid")),
+				Inline: true,
+			},
+			{
+				Name:   "Ended",
+				Value:  fmt.Sprintf("after %s by %s", s.Duration(), executor(s.EndedBy, "quiet period")),
+				Inline: true,
+			},
+			{
+				Name:  "Changes",
+				Value: ChangesText(s.State),
+			},
+			{
+				Name:  fmt.Sprintf("Joined Members (%d)", len(s.State.Joins)),
+				Value: joinsText(s.State.Joins),
+			},
+		},
+	}
+
+	failures := append(append([]string{}, s.State.Failures...), s.Failures...)
+	if len(failures) != 0 {
+		emb.Color = static.ColorEmbedOrange
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name:  "Failures",
+			Value: listText(failures),
+		})
+	}
+
+	return emb
+}
+
+// ChangesText returns a human readable listing of all
+// changes applied to the guild by the lockdown.
+func ChangesText(state models.AntiraidLockdownState) string {
+	var lines []string
+
+	if state.PrevVerificationLevel != nil {
+		lines = append(lines, fmt.Sprintf("Verification level raised from `%s` to `%s`",
+			verificationLevelName(*state.PrevVerificationLevel),
+			verificationLevelName(discordgo.VerificationLevelVeryHigh)))
+	}
+	if state.InvitesPaused {
+		lines = append(lines, "Invites paused")
+	}
+	if len(state.LockedChannels) != 0 {
+		lines = append(lines, "Locked "+channelsText(state.LockedChannels))
+	}
+	if len(state.PrevSlowmodes) != 0 {
+		ids := make([]string, 0, len(state.PrevSlowmodes))
+		for id := range state.PrevSlowmodes {
+			ids = append(ids, id)
+		}
+		lines = append(lines, "Enabled slowmode in "+channelsText(ids))
+	}
+
+	if len(lines) == 0 {
+		return "*none*"
+	}
+	return listText(lines)
+}
+
+func joinsText(joins []string) string {
+	if len(joins) == 0 {
+		return "*none*"
+	}
+
+	n := len(joins)
+	if n > maxListedJoins {
+		n = maxListedJoins
+	}
+
+	var sb strings.Builder
+	for i, id := range joins[:n] {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "<@%s>", id)
+	}
+	if n < len(joins) {
+		fmt.Fprintf(&sb, " and %d more", len(joins)-n)
+	}
+
+	return sb.String()
+}
+
+func channelsText(ids []string) string {
+	mentions := make([]string, 0, len(ids))
+	for _, id := range ids {
+		mentions = append(mentions, "<#"+id+">")
+	}
+	return strings.Join(mentions, ", ")
+}
+
+func listText(lines []string) string {
+	return "- " + strings.Join(lines, "\n- ")
+}
+
+func executor(userID, fallback string) string {
+	if userID == "" {
+		return fallback
+	}
+	return "<@" + userID + ">"
+}
+
+func verificationLevelName(lvl discordgo.VerificationLevel) string {
+	if lvl < 0 || int(lvl) >= len(verificationLevels) {
+		return "unknown"
+	}
+	return verificationLevels[lvl]
+}
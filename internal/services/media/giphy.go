@@ -0,0 +1,43 @@
+package media
+
+import (
+	"math/rand"
+	"strconv"
+
+	"github.com/zekroTJA/shinpuru/pkg/giphy"
+)
+
+// giphyMaxOffset is the upper bound of the random
+// search result offset used to pick a gif.
+const giphyMaxOffset = 100
+
+// Giphy implements Provider using the Giphy API.
+type Giphy struct {
+	client *giphy.Client
+}
+
+var _ Provider = (*Giphy)(nil)
+
+func NewGiphy(apiKey string) *Giphy {
+	return &Giphy{client: giphy.New(apiKey, "v1")}
+}
+
+func (g *Giphy) Random(query string) (*Media, error) {
+	res, err := g.client.Search(query, 1, rand.Intn(giphyMaxOffset), "pg")
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	img := res[0].Images.FixedWidth
+	width, _ := strconv.Atoi(img.Width)
+	height, _ := strconv.Atoi(img.Height)
+
+	return &Media{
+		URL:    img.Url,
+		Width:  width,
+		Height: height,
+	}, nil
+}
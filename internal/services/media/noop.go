@@ -0,0 +1,11 @@
+package media
+
+// Noop implements Provider without
+// ever returning any media.
+type Noop struct{}
+
+var _ Provider = Noop{}
+
+func (Noop) Random(string) (*Media, error) {
+	return nil, nil
+}
@@ -0,0 +1,19 @@
+// Package media provides random images and animations
+// by keyword, i.e. for birthday announcements.
+package media
+
+// Media is an image or animation which
+// can be embedded into messages.
+type Media struct {
+	URL    string
+	Width  int
+	Height int
+}
+
+// Provider returns random media by keyword.
+type Provider interface {
+	// Random returns a random media matching the
+	// given query. If no media has been found, nil
+	// is returned.
+	Random(query string) (*Media, error)
+}
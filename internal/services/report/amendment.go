@@ -0,0 +1,168 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/imgstore"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/hammertime"
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
+)
+
+// AmendReport applies the passed amendment to rep and stores
+// the resulting state as a new revision of the report. When
+// the report has not been amended before, its original state
+// is stored as the first revision.
+//
+// Changing the timeout of an active mute also changes the
+// timeout of the muted member. An embed describing the
+// amendment is sent to the mod log channel and to the victim.
+func (r *ReportService) AmendReport(
+	rep models.Report,
+	am models.ReportAmendment,
+	editorID string,
+) (amended models.Report, emb *discordgo.MessageEmbed, err error) {
+	amended = rep
+
+	if am.Msg != nil {
+		amended.Msg = *am.Msg
+	}
+	if am.AttachmentURL != nil {
+		amended.AttachmentURL = *am.AttachmentURL
+	}
+	if am.RemoveTimeout {
+		amended.Timeout = nil
+	} else if am.Timeout != nil {
+		if err = checkTimeout(r.tp.Now(), am.Timeout); err != nil {
+			return models.Report{}, nil, err
+		}
+		amended.Timeout = am.Timeout
+	}
+
+	publicAddr := r.cfg.Config().WebServer.PublicAddr
+
+	changes := reportChanges(rep, amended, publicAddr)
+	if len(changes) == 0 {
+		return models.Report{}, nil, ErrNoChanges
+	}
+
+	if rep.Type == models.TypeMute && !timeEqual(rep.Timeout, amended.Timeout) {
+		if rep.Timeout == nil || amended.Timeout == nil {
+			return models.Report{}, nil, ErrMuteTimeout
+		}
+		if err = r.s.GuildMemberTimeout(rep.GuildID, rep.VictimID, amended.Timeout); err != nil {
+			return models.Report{}, nil, err
+		}
+	}
+
+	revs, err := r.db.GetReportRevisions(rep.ID)
+	if err != nil {
+		return models.Report{}, nil, err
+	}
+	if len(revs) == 0 {
+		original := reportRevision(rep, 1, rep.ExecutorID, rep.GetTimestamp())
+		if err = r.db.AddReportRevision(original); err != nil {
+			return models.Report{}, nil, err
+		}
+		revs = append(revs, original)
+	}
+
+	rev := reportRevision(amended, revs[len(revs)-1].Version+1, editorID, r.tp.Now())
+	if err = r.db.UpdateReport(amended); err != nil {
+		return models.Report{}, nil, err
+	}
+	if err = r.db.AddReportRevision(rev); err != nil {
+		return models.Report{}, nil, err
+	}
+
+	emb = amended.AsEmbed(publicAddr)
+	emb.Title = fmt.Sprintf("Case %s (Revision %d)", amended.ID, rev.Version)
+	emb.Color = static.ColorEmbedUpdated
+	emb.Fields = append(emb.Fields,
+		&discordgo.MessageEmbedField{
+			Name:  "Amended By",
+			Value: fmt.Sprintf("<@%s>", editorID),
+		},
+		&discordgo.MessageEmbedField{
+			Name:  "Changes",
+			Value: strings.Join(changes, "\n"),
+		},
+	)
+
+	var modlogChan string
+	if modlogChan, err = r.db.GetGuildModLog(amended.GuildID); err == nil && modlogChan != "" {
+		_, err = r.s.ChannelMessageSendEmbed(modlogChan, emb)
+	}
+	if err != nil {
+		if database.IsErrDatabaseNotFound(err) {
+			err = nil
+		} else {
+			err = fmt.Errorf("failed sending message to modlog channel: %s", err)
+		}
+	}
+
+	dmChan, errDm := r.s.UserChannelCreate(amended.VictimID)
+	if errDm == nil && dmChan != nil {
+		r.s.ChannelMessageSendEmbed(dmChan.ID, emb)
+	}
+
+	return amended, emb, errors.Join(err, errDm)
+}
+
+func reportRevision(rep models.Report, version int, editorID string, timestamp time.Time) models.ReportRevision {
+	return models.ReportRevision{
+		ReportID:      rep.ID,
+		Version:       version,
+		GuildID:       rep.GuildID,
+		EditorID:      editorID,
+		Timestamp:     timestamp,
+		Msg:           rep.Msg,
+		AttachmentURL: rep.AttachmentURL,
+		Timeout:       rep.Timeout,
+	}
+}
+
+// reportChanges returns a human readable line for each
+// value which differs between before and after.
+func reportChanges(before, after models.Report, publicAddr string) (changes []string) {
+	if before.Msg != after.Msg {
+		changes = append(changes, fmt.Sprintf("Reason: ~~%s~~ → %s",
+			stringutil.Cap(before.Msg, 300), stringutil.Cap(after.Msg, 300)))
+	}
+	if before.AttachmentURL != after.AttachmentURL {
+		changes = append(changes, fmt.Sprintf("Attachment: %s → %s",
+			formatAttachment(before.AttachmentURL, publicAddr), formatAttachment(after.AttachmentURL, publicAddr)))
+	}
+	if !timeEqual(before.Timeout, after.Timeout) {
+		changes = append(changes, fmt.Sprintf("Expires: %s → %s",
+			formatTimeout(before.Timeout), formatTimeout(after.Timeout)))
+	}
+	return
+}
+
+func formatAttachment(attachment, publicAddr string) string {
+	if attachment == "" {
+		return "none"
+	}
+	return fmt.Sprintf("[[open](%s)]", imgstore.GetLink(attachment, publicAddr))
+}
+
+func formatTimeout(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return hammertime.Format(*t, hammertime.Span)
+}
+
+func timeEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,166 @@
+package report
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+)
+
+const (
+	// maxEscalationDepth limits how many escalations a
+	// single report can cause in a row, for example a
+	// WARN escalating to a MUTE which escalates to a KICK.
+	maxEscalationDepth = 3
+
+	// escalationHistoryLimit is the maximum number of
+	// past reports of a member taken into account when
+	// evaluating an escalation ladder.
+	escalationHistoryLimit = 1000
+)
+
+// escalate evaluates the escalation ladder of the guild of
+// the passed report and applies the action of the matching
+// step, if any. The applied action is recorded as its own
+// report linked to rep.
+//
+// Errors are only logged because the passed report has
+// already been pushed at this point.
+func (r *ReportService) escalate(rep models.Report, depth int) {
+	if depth >= maxEscalationDepth {
+		return
+	}
+
+	ladder, err := r.db.GetGuildEscalationLadder(rep.GuildID)
+	if database.IsErrDatabaseNotFound(err) {
+		return
+	}
+	if err != nil {
+		r.log.Error().Err(err).Field("gid", rep.GuildID).Msg("Failed getting escalation ladder")
+		return
+	}
+
+	history, err := r.db.GetReportsFiltered(rep.GuildID, rep.VictimID, -1, 0, escalationHistoryLimit)
+	if err != nil {
+		r.log.Error().Err(err).Field("gid", rep.GuildID).Msg("Failed getting report history")
+		return
+	}
+
+	now := r.tp.Now()
+	step, ok := matchEscalation(ladder, rep, history, now)
+	if !ok {
+		return
+	}
+
+	self, err := r.st.SelfUser()
+	if err != nil {
+		r.log.Error().Err(err).Msg("Failed getting self user")
+		return
+	}
+
+	esc := models.Report{
+		GuildID:    rep.GuildID,
+		ExecutorID: self.ID,
+		VictimID:   rep.VictimID,
+		Msg:        "Automatic escalation (" + step.String() + ")",
+		LinkedID:   rep.ID,
+	}
+	if d := step.ActionDuration(); d > 0 {
+		timeout := now.Add(d)
+		esc.Timeout = &timeout
+	}
+
+	switch step.Action {
+	case models.TypeKick:
+		_, err = r.pushKick(esc, depth+1)
+	case models.TypeBan:
+		_, err = r.pushBan(esc, depth+1)
+	case models.TypeMute:
+		_, err = r.pushMute(esc, depth+1)
+	}
+
+	if err != nil {
+		r.log.Error().Err(err).Fields(
+			"gid", rep.GuildID,
+			"report", rep.ID,
+			"action", models.ReportTypes[step.Action],
+		).Msg("Failed applying escalation")
+	}
+}
+
+// matchEscalation returns the last step of the ladder which
+// is triggered by rep. history contains the past reports of
+// the victim including rep, ordered from newest to oldest.
+func matchEscalation(
+	ladder models.EscalationLadder,
+	rep models.Report,
+	history []models.Report,
+	now time.Time,
+) (step models.EscalationStep, ok bool) {
+	for _, s := range ladder {
+		if s.On == rep.Type && countEscalation(s, history, now) >= s.Count {
+			step, ok = s, true
+		}
+	}
+	return
+}
+
+// countEscalation returns the number of reports in history
+// counted by the step. Counting stops at the latest report
+// of the step's action type, so a step starts over after
+// it has been applied.
+//
+// If the latest action has been applied by an escalation,
+// only reports after the linked report which triggered it
+// are counted. Because report IDs are generated by one
+// snowflake node per report type, the order of reports of
+// different types created within the same millisecond is
+// not reliable in history.
+func countEscalation(step models.EscalationStep, history []models.Report, now time.Time) (n int) {
+	var trigger *models.Report
+	for i, rep := range history {
+		if rep.Type != step.Action {
+			continue
+		}
+		if trigger = findReport(history, rep.LinkedID); trigger == nil {
+			history = history[:i]
+		}
+		break
+	}
+
+	window := step.WindowDuration()
+	for _, rep := range history {
+		if rep.Type != step.Counted || (window > 0 && now.Sub(rep.GetTimestamp()) > window) {
+			continue
+		}
+		if trigger != nil && !isAfter(rep, *trigger) {
+			continue
+		}
+		n++
+	}
+	return
+}
+
+func findReport(history []models.Report, id snowflake.ID) *models.Report {
+	if id == 0 {
+		return nil
+	}
+	for i := range history {
+		if history[i].ID == id {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+// isAfter returns true if a has been created after b.
+// IDs of the same report type share a snowflake node and
+// can be compared directly, otherwise the timestamps are
+// compared.
+func isAfter(a, b models.Report) bool {
+	if a.Type == b.Type {
+		return a.ID > b.ID
+	}
+	return a.GetTimestamp().After(b.GetTimestamp())
+}
@@ -15,6 +15,8 @@ type Provider interface {
 	RevokeReport(rep models.Report, executorID, reason,
 		wsPublicAddr string,
 	) (emb *discordgo.MessageEmbed, err error)
+	AmendReport(rep models.Report, am models.ReportAmendment, editorID string,
+	) (amended models.Report, emb *discordgo.MessageEmbed, err error)
 	UnbanReport(
 		unbanReq models.UnbanRequest,
 		executorID string,
@@ -29,6 +29,8 @@ var (
 	ErrRoleDiff       = errors.New("you can only ban or kick members with lower permissions than yours")
 	ErrMemberHasLeft  = errors.New("this user is no more a member of this guild")
 	ErrInvalidTimeout = errors.New("timeout must be in the future")
+	ErrNoChanges      = errors.New("the amendment does not change the report")
+	ErrMuteTimeout    = errors.New("the timeout of a mute can only be changed while the mute is active")
 )
 
 type ReportService struct {
@@ -68,7 +70,17 @@ func New(container di.Container) (t *ReportService, err error) {
 // using the passed db databse rpovider and an embed is created with the attachment
 // url assembled with publicAddr as image endpoint root. This embed is then sent to
 // the specified mod log channel for this guild, if existent.
+// Afterwards, the guild's escalation ladder is evaluated for
+// the victim.
 func (r *ReportService) PushReport(rep models.Report) (models.Report, error) {
+	rep, err := r.pushReport(rep)
+	if rep.ID != 0 {
+		r.escalate(rep, 0)
+	}
+	return rep, err
+}
+
+func (r *ReportService) pushReport(rep models.Report) (models.Report, error) {
 	repID := snowflakenodes.NodesReport[rep.Type].Generate()
 
 	rep.ID = repID
@@ -102,6 +114,10 @@ func (r *ReportService) PushReport(rep models.Report) (models.Report, error) {
 // kicks the member from the guild with the given reason and case ID
 // for the audit log.
 func (r *ReportService) PushKick(rep models.Report) (models.Report, error) {
+	return r.pushKick(rep, 0)
+}
+
+func (r *ReportService) pushKick(rep models.Report, depth int) (models.Report, error) {
 	const typ = 0
 	rep.Type = typ
 
@@ -128,7 +144,7 @@ func (r *ReportService) PushKick(rep models.Report) (models.Report, error) {
 		return models.Report{}, ErrRoleDiff
 	}
 
-	rep, err = r.PushReport(rep)
+	rep, err = r.pushReport(rep)
 	if err != nil {
 		return models.Report{}, err
 	}
@@ -138,6 +154,8 @@ func (r *ReportService) PushKick(rep models.Report) (models.Report, error) {
 		return models.Report{}, err
 	}
 
+	r.escalate(rep, depth)
+
 	return rep, nil
 }
 
@@ -145,6 +163,10 @@ func (r *ReportService) PushKick(rep models.Report) (models.Report, error) {
 // bans the member from the guild with the given reason and case ID
 // for the audit log.
 func (r *ReportService) PushBan(rep models.Report) (models.Report, error) {
+	return r.pushBan(rep, 0)
+}
+
+func (r *ReportService) pushBan(rep models.Report, depth int) (models.Report, error) {
 	const typ = 1
 	rep.Type = typ
 
@@ -182,7 +204,7 @@ func (r *ReportService) PushBan(rep models.Report) (models.Report, error) {
 		}
 	}
 
-	rep, err = r.PushReport(rep)
+	rep, err = r.pushReport(rep)
 	if err != nil {
 		return models.Report{}, err
 	}
@@ -192,12 +214,18 @@ func (r *ReportService) PushBan(rep models.Report) (models.Report, error) {
 		return models.Report{}, err
 	}
 
+	r.escalate(rep, depth)
+
 	return rep, nil
 }
 
 // PushMute is shorthand for PushReport as member mute action and also
 // adds the mute role to the specified victim.
 func (r *ReportService) PushMute(rep models.Report) (models.Report, error) {
+	return r.pushMute(rep, 0)
+}
+
+func (r *ReportService) pushMute(rep models.Report, depth int) (models.Report, error) {
 	const typ = 2
 	rep.Type = typ
 
@@ -233,7 +261,7 @@ func (r *ReportService) PushMute(rep models.Report) (models.Report, error) {
 		rep.Msg = "no reason specified"
 	}
 
-	rep, err = r.PushReport(rep)
+	rep, err = r.pushReport(rep)
 	if err != nil {
 		return models.Report{}, err
 	}
@@ -244,6 +272,8 @@ func (r *ReportService) PushMute(rep models.Report) (models.Report, error) {
 		return models.Report{}, err
 	}
 
+	r.escalate(rep, depth)
+
 	return rep, nil
 }
 
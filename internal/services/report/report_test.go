@@ -29,6 +29,8 @@ type reportMock struct {
 	st  *mocks.IState
 	tp  *mocks.TimeProvider
 
+	store database.Database
+
 	ct di.Container
 }
 
@@ -41,10 +43,25 @@ func (m *reportMock) Reset() {
 }
 
 func getReportMock(prep ...func(m reportMock)) reportMock {
+	return buildReportMock(nil, prep...)
+}
+
+// getReportStoreMock works like getReportMock but backs the
+// report service with a real SQLite database instead of the
+// database mock.
+func getReportStoreMock(tt *testing.T, prep ...func(m reportMock)) reportMock {
+	return buildReportMock(testutil.NewTestDatabase(tt), prep...)
+}
+
+func buildReportMock(store database.Database, prep ...func(m reportMock)) reportMock {
 	var t reportMock
 
 	t.s = &mocks.ISession{}
 	t.db = &mocks.Database{}
+	t.store = store
+	if t.store == nil {
+		t.store = t.db
+	}
 	t.cfg = &mocks.ConfigProvider{}
 	t.st = &mocks.IState{}
 	t.tp = &mocks.TimeProvider{}
@@ -55,6 +72,8 @@ func getReportMock(prep ...func(m reportMock)) reportMock {
 
 	t.cfg.On("Config").Return(&models.Config{})
 	t.tp.On("Now").Return(time.Time{})
+	t.db.On("GetGuildEscalationLadder", mock.AnythingOfType("string")).
+		Return(models.EscalationLadder(nil), database.ErrDatabaseNotFound)
 
 	ct, _ := di.NewBuilder()
 	ct.Add(
@@ -64,7 +83,7 @@ func getReportMock(prep ...func(m reportMock)) reportMock {
 		},
 		di.Def{
 			Name:  static.DiDatabase,
-			Build: func(ctn di.Container) (interface{}, error) { return t.db, nil },
+			Build: func(ctn di.Container) (interface{}, error) { return t.store, nil },
 		},
 		di.Def{
 			Name:  static.DiConfig,
@@ -1212,3 +1231,308 @@ func TestRevokeMute(t *testing.T) {
 	m.s.AssertCalled(t, "GuildMemberTimeout", "guild-id", "victim-id", testutil.Nil[time.Time]())
 	m.db.AssertNotCalled(t, "ExpireReports", "123")
 }
+
+func TestMuteExpirationStore(t *testing.T) {
+	m := getReportStoreMock(t, func(m reportMock) {
+		m.s.On("UserChannelCreate", mock.AnythingOfType("string")).
+			Return(&discordgo.Channel{
+				ID: "channel-id",
+			}, nil)
+		m.s.On("ChannelMessageSendEmbed", mock.AnythingOfType("string"), mock.AnythingOfType("*discordgo.MessageEmbed")).
+			Return(nil, nil)
+		m.s.On("GuildMemberTimeout", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*time.Time")).
+			Return(nil)
+
+		m.st.On("Guild", mock.AnythingOfType("string"), mock.AnythingOfType("bool")).
+			Return(&discordgo.Guild{
+				ID: "guild-id",
+				Roles: []*discordgo.Role{
+					{ID: "role-0", Position: 0},
+					{ID: "role-1", Position: 1},
+				},
+			}, nil)
+		m.st.On("Member", "guild-id", "victim-id").
+			Return(&discordgo.Member{
+				User: &discordgo.User{
+					ID: "victim-id",
+				},
+				Roles: []string{"role-0"},
+			}, nil)
+		m.st.On("Member", "guild-id", "executor-id").
+			Return(&discordgo.Member{
+				User: &discordgo.User{
+					ID: "executor-id",
+				},
+				Roles: []string{"role-1"},
+			}, nil)
+	})
+
+	s, err := New(m.ct)
+	assert.Nil(t, err)
+
+	// ----- Push Mute -----
+
+	timeout := time.Now().Add(-time.Minute).Truncate(time.Second)
+	rep, err := s.PushMute(models.Report{
+		VictimID:   "victim-id",
+		ExecutorID: "executor-id",
+		GuildID:    "guild-id",
+		Msg:        "Some reason",
+		Timeout:    &timeout,
+	})
+	assert.Nil(t, err)
+
+	stored, err := m.store.GetReport(rep.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, rep.ID, stored.ID)
+	assert.Equal(t, models.TypeMute, stored.Type)
+	assert.Equal(t, "Some reason", stored.Msg)
+	if assert.NotNil(t, stored.Timeout) {
+		assert.True(t, timeout.Equal(*stored.Timeout))
+	}
+
+	// ----- Expire Reports -----
+
+	mErr := s.ExpireExpiredReports()
+	assert.Nil(t, mErr.Nillify())
+	m.s.AssertCalled(t, "GuildMemberTimeout", "guild-id", "victim-id", testutil.Nil[time.Time]())
+
+	stored, err = m.store.GetReport(rep.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, stored.Timeout)
+
+	expired, err := m.store.GetExpiredReports()
+	assert.Nil(t, err)
+	assert.Empty(t, expired)
+}
+
+func TestEscalation(t *testing.T) {
+	m := getReportStoreMock(t, func(m reportMock) {
+		m.s.On("UserChannelCreate", mock.AnythingOfType("string")).
+			Return(&discordgo.Channel{
+				ID: "channel-id",
+			}, nil)
+		m.s.On("ChannelMessageSendEmbed", mock.AnythingOfType("string"), mock.AnythingOfType("*discordgo.MessageEmbed")).
+			Return(nil, nil)
+		m.s.On("GuildMemberTimeout", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*time.Time")).
+			Return(nil)
+		m.s.On("GuildMemberDeleteWithReason", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+			Return(nil)
+
+		m.st.On("SelfUser").
+			Return(&discordgo.User{ID: "self-id"}, nil)
+		m.st.On("Guild", mock.AnythingOfType("string"), mock.AnythingOfType("bool")).
+			Return(&discordgo.Guild{
+				ID: "guild-id",
+				Roles: []*discordgo.Role{
+					{ID: "role-admin", Position: 2, Permissions: 0x8},
+					{ID: "role-0", Position: 0},
+				},
+			}, nil)
+		m.st.On("Member", "guild-id", "victim-id").
+			Return(&discordgo.Member{
+				User:  &discordgo.User{ID: "victim-id"},
+				Roles: []string{"role-0"},
+			}, nil)
+		m.st.On("Member", "guild-id", "self-id").
+			Return(&discordgo.Member{
+				User:  &discordgo.User{ID: "self-id"},
+				Roles: []string{"role-admin"},
+			}, nil)
+	})
+
+	err := m.store.SetGuildEscalationLadder("guild-id", models.EscalationLadder{
+		{On: models.TypeWarn, Counted: models.TypeWarn, Count: 2, Action: models.TypeMute, Duration: 3600},
+		{On: models.TypeMute, Counted: models.TypeMute, Count: 2, Action: models.TypeKick},
+	})
+	assert.Nil(t, err)
+
+	s, err := New(m.ct)
+	assert.Nil(t, err)
+
+	warn := func() models.Report {
+		rep, err := s.PushReport(models.Report{
+			Type:       models.TypeWarn,
+			GuildID:    "guild-id",
+			ExecutorID: "executor-id",
+			VictimID:   "victim-id",
+			Msg:        "Some reason",
+		})
+		assert.Nil(t, err)
+		return rep
+	}
+	reports := func(typ models.ReportType) []models.Report {
+		reps, err := m.store.GetReportsFiltered("guild-id", "victim-id", typ, 0, 100)
+		assert.Nil(t, err)
+		return reps
+	}
+
+	// ----- Below Threshold -----
+
+	warn()
+	assert.Empty(t, reports(models.TypeMute))
+
+	// ----- Escalate to Mute -----
+
+	rep := warn()
+	mutes := reports(models.TypeMute)
+	if assert.Len(t, mutes, 1) {
+		assert.Equal(t, rep.ID, mutes[0].LinkedID)
+		assert.Equal(t, "self-id", mutes[0].ExecutorID)
+		assert.NotNil(t, mutes[0].Timeout)
+	}
+	m.s.AssertNumberOfCalls(t, "GuildMemberTimeout", 1)
+
+	// ----- Counter Starts Over -----
+
+	warn()
+	assert.Len(t, reports(models.TypeMute), 1)
+
+	// ----- Cascade to Kick -----
+
+	warn()
+	mutes = reports(models.TypeMute)
+	assert.Len(t, mutes, 2)
+	kicks := reports(models.TypeKick)
+	if assert.Len(t, kicks, 1) {
+		assert.Equal(t, mutes[0].ID, kicks[0].LinkedID)
+	}
+	m.s.AssertCalled(t, "GuildMemberDeleteWithReason", "guild-id", "victim-id", mock.AnythingOfType("string"))
+}
+
+func TestCountEscalation(t *testing.T) {
+	now := time.Now()
+	at := func(typ models.ReportType, ago time.Duration) models.Report {
+		return models.Report{
+			ID:   snowflake.ID((now.Add(-ago).UnixMilli() - snowflake.Epoch) << 22),
+			Type: typ,
+		}
+	}
+
+	history := []models.Report{
+		at(models.TypeWarn, time.Hour),
+		at(models.TypeAd, 2*time.Hour),
+		at(models.TypeWarn, 3*24*time.Hour),
+		at(models.TypeMute, 4*24*time.Hour),
+		at(models.TypeWarn, 5*24*time.Hour),
+	}
+
+	step := models.EscalationStep{Counted: models.TypeWarn, Action: models.TypeMute}
+	assert.Equal(t, 2, countEscalation(step, history, now))
+
+	step.Window = 24 * 3600
+	assert.Equal(t, 1, countEscalation(step, history, now))
+
+	step = models.EscalationStep{Counted: models.TypeWarn, Action: models.TypeBan}
+	assert.Equal(t, 3, countEscalation(step, history, now))
+
+	// An escalated mute created within the same millisecond
+	// as its triggering warn can be sorted before the warn.
+	trigger := at(models.TypeWarn, 0)
+	trigger.ID |= 3 << 12
+	mute := at(models.TypeMute, 0)
+	mute.ID |= 2 << 12
+	mute.LinkedID = trigger.ID
+	next := at(models.TypeWarn, 0)
+	next.ID = trigger.ID + 1
+
+	step = models.EscalationStep{Counted: models.TypeWarn, Action: models.TypeMute}
+	assert.Equal(t, 0, countEscalation(step, []models.Report{trigger, mute, history[0]}, now))
+	assert.Equal(t, 1, countEscalation(step, []models.Report{next, trigger, mute, history[0]}, now))
+}
+
+func TestAmendReport(t *testing.T) {
+	m := getReportStoreMock(t, func(m reportMock) {
+		m.s.On("UserChannelCreate", mock.AnythingOfType("string")).
+			Return(&discordgo.Channel{
+				ID: "channel-id",
+			}, nil)
+		m.s.On("ChannelMessageSendEmbed", mock.AnythingOfType("string"), mock.AnythingOfType("*discordgo.MessageEmbed")).
+			Return(nil, nil)
+		m.s.On("GuildMemberTimeout", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*time.Time")).
+			Return(nil)
+	})
+
+	s, err := New(m.ct)
+	assert.Nil(t, err)
+
+	rep, err := s.PushReport(models.Report{
+		Type:       models.TypeWarn,
+		GuildID:    "guild-id",
+		ExecutorID: "executor-id",
+		VictimID:   "victim-id",
+		Msg:        "Some resaon",
+	})
+	assert.Nil(t, err)
+
+	// ----- Negative Test: No Changes -----
+
+	msg := "Some resaon"
+	_, _, err = s.AmendReport(rep, models.ReportAmendment{Msg: &msg}, "editor-id")
+	assert.ErrorIs(t, err, ErrNoChanges)
+
+	// ----- Amend Reason -----
+
+	msg = "Some reason"
+	amended, emb, err := s.AmendReport(rep, models.ReportAmendment{Msg: &msg}, "editor-id")
+	assert.Nil(t, err)
+	assert.Equal(t, rep.ID, amended.ID)
+	assert.Equal(t, msg, amended.Msg)
+	assert.Equal(t, "Case "+rep.ID.String()+" (Revision 2)", emb.Title)
+	m.s.AssertCalled(t, "ChannelMessageSendEmbed", "channel-id", emb)
+
+	stored, err := m.store.GetReport(rep.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, msg, stored.Msg)
+
+	// ----- Amend Timeout -----
+
+	timeout := time.Now().Add(time.Hour).Truncate(time.Second)
+	amended, _, err = s.AmendReport(amended, models.ReportAmendment{Timeout: &timeout}, "editor-2-id")
+	assert.Nil(t, err)
+	m.s.AssertNotCalled(t, "GuildMemberTimeout", mock.Anything, mock.Anything, mock.Anything)
+
+	revs, err := m.store.GetReportRevisions(rep.ID)
+	assert.Nil(t, err)
+	if assert.Len(t, revs, 3) {
+		assert.Equal(t, "executor-id", revs[0].EditorID)
+		assert.Equal(t, "Some resaon", revs[0].Msg)
+		assert.Equal(t, "editor-id", revs[1].EditorID)
+		assert.Equal(t, "Some reason", revs[1].Msg)
+		assert.Nil(t, revs[1].Timeout)
+		assert.Equal(t, 3, revs[2].Version)
+		assert.Equal(t, "editor-2-id", revs[2].EditorID)
+		if assert.NotNil(t, revs[2].Timeout) {
+			assert.True(t, timeout.Equal(*revs[2].Timeout))
+		}
+	}
+
+	// ----- Negative Test: Invalid Timeout -----
+
+	past := time.Now().Add(-time.Hour)
+	m.tp.ExpectedCalls = nil
+	m.tp.On("Now").Return(time.Now())
+	_, _, err = s.AmendReport(amended, models.ReportAmendment{Timeout: &past}, "editor-id")
+	assert.ErrorIs(t, err, ErrInvalidTimeout)
+
+	// ----- Mute Timeout -----
+
+	muteTimeout := time.Now().Add(time.Hour)
+	mute := models.Report{
+		ID:         snowflakenodes.NodesReport[models.TypeMute].Generate(),
+		Type:       models.TypeMute,
+		GuildID:    "guild-id",
+		ExecutorID: "executor-id",
+		VictimID:   "victim-id",
+		Timeout:    &muteTimeout,
+	}
+	assert.Nil(t, m.store.AddReport(mute))
+
+	_, _, err = s.AmendReport(mute, models.ReportAmendment{RemoveTimeout: true}, "editor-id")
+	assert.ErrorIs(t, err, ErrMuteTimeout)
+
+	newTimeout := time.Now().Add(2 * time.Hour)
+	_, _, err = s.AmendReport(mute, models.ReportAmendment{Timeout: &newTimeout}, "editor-id")
+	assert.Nil(t, err)
+	m.s.AssertCalled(t, "GuildMemberTimeout", "guild-id", "victim-id", &newTimeout)
+}
@@ -1,25 +1,62 @@
 package storage
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/zekroTJA/shinpuru/internal/services/config"
 )
 
+var (
+	// ErrInvalidSignature is returned when the signature
+	// of a presigned URL does not match.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrSignatureExpired is returned when the expiration
+	// time of a presigned URL has passed.
+	ErrSignatureExpired = errors.New("signature has expired")
+)
+
 // File implements the Storage interface for a
 // local file storage provider.
+//
+// Presigned URLs point to the storage endpoint of
+// the web server which serves the objects after
+// verifying the signature of the URL.
 type File struct {
-	location string
+	location   string
+	publicAddr string
+	signingKey []byte
 }
 
 var _ (Storage) = (*File)(nil)
 
 func (f *File) Connect(cfg config.Provider) (err error) {
-	f.location = cfg.Config().Storage.File.Location
-	return nil
+	c := cfg.Config()
+	f.location = c.Storage.File.Location
+	f.publicAddr = c.WebServer.PublicAddr
+
+	if c.Storage.File.SigningKey != "" {
+		f.signingKey = []byte(c.Storage.File.SigningKey)
+	} else {
+		// Presigned URLs become invalid on restart
+		// when no signing key is configured.
+		f.signingKey = make([]byte, 32)
+		_, err = rand.Read(f.signingKey)
+	}
+
+	return err
 }
 
 func (f *File) Status() error {
@@ -71,7 +108,7 @@ func (f *File) PutObject(bucketName string, objectName string, reader io.Reader,
 	} else if stat.IsDir() {
 		return errors.New("given file dir is a location")
 	} else {
-		fh, err = os.Open(fd)
+		fh, err = os.OpenFile(fd, os.O_WRONLY|os.O_TRUNC, 0)
 	}
 
 	if err != nil {
@@ -90,7 +127,7 @@ func (f *File) GetObject(bucketName string, objectName string) (io.ReadCloser, i
 	var fh *os.File
 
 	if os.IsNotExist(err) {
-		return nil, 0, errors.New("file does not exist")
+		return nil, 0, ErrObjectNotFound
 	} else if err != nil {
 		return nil, 0, err
 	} else if stat.IsDir() {
@@ -106,3 +143,120 @@ func (f *File) DeleteObject(bucketName, objectName string) error {
 	fd := path.Join(f.location, bucketName, objectName)
 	return os.Remove(fd)
 }
+
+func (f *File) PutObjectStream(bucketName, objectName string, reader io.Reader, mimeType string) (err error) {
+	if err = f.CreateBucketIfNotExists(bucketName); err != nil {
+		return
+	}
+
+	// The object is written to a temporary file first so
+	// that readers never see a partially written object.
+	fh, err := os.CreateTemp(path.Join(f.location, bucketName), "."+objectName+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(fh.Name())
+
+	_, err = io.Copy(fh, reader)
+	if cErr := fh.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return
+	}
+
+	return os.Rename(fh.Name(), path.Join(f.location, bucketName, objectName))
+}
+
+func (f *File) StatObject(bucketName, objectName string) (info ObjectInfo, err error) {
+	fd := path.Join(f.location, bucketName, objectName)
+
+	stat, err := os.Stat(fd)
+	if os.IsNotExist(err) {
+		return info, ErrObjectNotFound
+	}
+	if err != nil {
+		return info, err
+	}
+	if stat.IsDir() {
+		return info, errors.New("given file dir is a location")
+	}
+
+	mime, err := mimetype.DetectFile(fd)
+	if err != nil {
+		return info, err
+	}
+
+	return ObjectInfo{
+		Name:         objectName,
+		Size:         stat.Size(),
+		ContentType:  mime.String(),
+		LastModified: stat.ModTime(),
+	}, nil
+}
+
+func (f *File) ListObjects(bucketName, prefix string) ([]ObjectInfo, error) {
+	entries, err := os.ReadDir(path.Join(f.location, bucketName))
+	if os.IsNotExist(err) {
+		return []ObjectInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]ObjectInfo, 0, len(entries))
+	for _, e := range entries {
+		// Hidden files are temporary files
+		// of streamed uploads.
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		stat, err := e.Info()
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, ObjectInfo{
+			Name:         e.Name(),
+			Size:         stat.Size(),
+			LastModified: stat.ModTime(),
+		})
+	}
+
+	return objects, nil
+}
+
+func (f *File) PresignGetObject(bucketName, objectName string, expires time.Duration, fileName string) (string, error) {
+	expiresAt := time.Now().Add(expires).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt, 10))
+	if fileName != "" {
+		query.Set("filename", fileName)
+	}
+	query.Set("signature", f.sign(bucketName, objectName, expiresAt, fileName))
+
+	return fmt.Sprintf("%s/storage/%s/%s?%s", f.publicAddr,
+		url.PathEscape(bucketName), url.PathEscape(objectName), query.Encode()), nil
+}
+
+// VerifySignature checks if the signature of a presigned
+// URL created by PresignGetObject is valid.
+func (f *File) VerifySignature(bucketName, objectName string, expires int64, fileName, signature string) error {
+	expected := f.sign(bucketName, objectName, expires, fileName)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+func (f *File) sign(bucketName, objectName string, expires int64, fileName string) string {
+	mac := hmac.New(sha256.New, f.signingKey)
+	fmt.Fprintf(mac, "%s/%s\n%d\n%s", bucketName, objectName, expires, fileName)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
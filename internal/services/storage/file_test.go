@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFile(t *testing.T) *File {
+	return &File{
+		location:   t.TempDir(),
+		publicAddr: "https://example.com",
+		signingKey: []byte("signing-key"),
+	}
+}
+
+func TestFileStreamAndList(t *testing.T) {
+	f := testFile(t)
+
+	require.NoError(t, f.PutObjectStream("bucket", "a_1", strings.NewReader(`{"a":1}`), "application/json"))
+	require.NoError(t, f.PutObjectStream("bucket", "a_2", strings.NewReader("hello"), "text/plain"))
+	require.NoError(t, f.PutObject("bucket", "b_1", strings.NewReader("b"), 1, "text/plain"))
+
+	reader, size, err := f.GetObject("bucket", "a_1")
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(data))
+	assert.EqualValues(t, len(data), size)
+
+	info, err := f.StatObject("bucket", "a_1")
+	require.NoError(t, err)
+	assert.Equal(t, "a_1", info.Name)
+	assert.EqualValues(t, 7, info.Size)
+	assert.Equal(t, "application/json", info.ContentType)
+
+	_, err = f.StatObject("bucket", "missing")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+	_, _, err = f.GetObject("bucket", "missing")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+
+	objects, err := f.ListObjects("bucket", "a_")
+	require.NoError(t, err)
+	names := make([]string, len(objects))
+	for i, o := range objects {
+		names[i] = o.Name
+	}
+	assert.ElementsMatch(t, []string{"a_1", "a_2"}, names)
+
+	objects, err = f.ListObjects("missing", "")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestFilePresign(t *testing.T) {
+	f := testFile(t)
+
+	rawURL, err := f.PresignGetObject("bucket", "obj", time.Minute, "obj.gz")
+	require.NoError(t, err)
+
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", u.Host)
+	assert.Equal(t, "bucket", path.Base(path.Dir(u.Path)))
+	assert.Equal(t, "obj", path.Base(u.Path))
+
+	q := u.Query()
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	require.NoError(t, err)
+
+	assert.NoError(t, f.VerifySignature("bucket", "obj", expires, "obj.gz", q.Get("signature")))
+	assert.ErrorIs(t, f.VerifySignature("bucket", "other", expires, "obj.gz", q.Get("signature")), ErrInvalidSignature)
+	assert.ErrorIs(t, f.VerifySignature("bucket", "obj", expires+1, "obj.gz", q.Get("signature")), ErrInvalidSignature)
+	assert.ErrorIs(t, f.VerifySignature("bucket", "obj", expires, "", q.Get("signature")), ErrInvalidSignature)
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	assert.ErrorIs(t, f.VerifySignature("bucket", "obj", expired, "", f.sign("bucket", "obj", expired, "")), ErrSignatureExpired)
+}
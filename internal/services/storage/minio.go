@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"fmt"
 	"io"
+	"net/url"
+	"time"
 
 	"github.com/minio/minio-go"
 	"github.com/zekroTJA/shinpuru/internal/services/config"
@@ -64,7 +67,7 @@ func (m *Minio) GetObject(bucketName, objectName string) (io.ReadCloser, int64,
 
 	stat, err := obj.Stat()
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, wrapMinioError(err)
 	}
 
 	return obj, stat.Size, err
@@ -74,9 +77,76 @@ func (m *Minio) DeleteObject(bucketName, objectName string) error {
 	return m.client.RemoveObject(bucketName, objectName)
 }
 
+func (m *Minio) PutObjectStream(bucketName, objectName string, reader io.Reader, mimeType string) (err error) {
+	if err = m.CreateBucketIfNotExists(bucketName, m.location); err != nil {
+		return
+	}
+	// A size of -1 results in a multipart upload
+	// of parts until the reader is drained.
+	_, err = m.client.PutObject(bucketName, objectName, reader, -1, minio.PutObjectOptions{
+		ContentType: mimeType,
+	})
+	return
+}
+
+func (m *Minio) StatObject(bucketName, objectName string) (ObjectInfo, error) {
+	stat, err := m.client.StatObject(bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, wrapMinioError(err)
+	}
+	return minioObjectInfo(stat), nil
+}
+
+func (m *Minio) ListObjects(bucketName, prefix string) ([]ObjectInfo, error) {
+	done := make(chan struct{})
+	defer close(done)
+
+	objects := make([]ObjectInfo, 0)
+	for obj := range m.client.ListObjectsV2(bucketName, prefix, true, done) {
+		if obj.Err != nil {
+			if minio.ToErrorResponse(obj.Err).Code == "NoSuchBucket" {
+				return objects, nil
+			}
+			return nil, obj.Err
+		}
+		objects = append(objects, minioObjectInfo(obj))
+	}
+
+	return objects, nil
+}
+
+func (m *Minio) PresignGetObject(bucketName, objectName string, expires time.Duration, fileName string) (string, error) {
+	params := url.Values{}
+	if fileName != "" {
+		params.Set("response-content-disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	}
+	u, err := m.client.PresignedGetObject(bucketName, objectName, expires, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
 func (m *Minio) getLocation(loc []string) string {
 	if len(loc) > 0 {
 		return loc[0]
 	}
 	return m.location
 }
+
+func minioObjectInfo(obj minio.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Name:         obj.Key,
+		Size:         obj.Size,
+		ContentType:  obj.ContentType,
+		LastModified: obj.LastModified,
+	}
+}
+
+func wrapMinioError(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchBucket":
+		return ErrObjectNotFound
+	}
+	return err
+}
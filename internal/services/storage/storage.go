@@ -1,11 +1,26 @@
 package storage
 
 import (
+	"errors"
 	"io"
+	"time"
 
 	"github.com/zekroTJA/shinpuru/internal/services/config"
 )
 
+// ErrObjectNotFound is returned when the
+// requested object does not exist.
+var ErrObjectNotFound = errors.New("object does not exist")
+
+// ObjectInfo contains the metadata of
+// a stored object.
+type ObjectInfo struct {
+	Name         string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
 // Storage interface provides functionalities to
 // access an object storage driver.
 type Storage interface {
@@ -19,4 +34,18 @@ type Storage interface {
 	PutObject(bucketName, objectName string, reader io.Reader, objectSize int64, mimeType string) error
 	GetObject(bucketName, objectName string) (io.ReadCloser, int64, error)
 	DeleteObject(bucketName, objectName string) error
+
+	// PutObjectStream stores the content of reader until
+	// EOF without knowing the size of the object upfront.
+	PutObjectStream(bucketName, objectName string, reader io.Reader, mimeType string) error
+	// StatObject returns the metadata of the object.
+	StatObject(bucketName, objectName string) (ObjectInfo, error)
+	// ListObjects returns the metadata of all objects in
+	// the bucket whose names start with the given prefix.
+	ListObjects(bucketName, prefix string) ([]ObjectInfo, error)
+	// PresignGetObject returns an URL which grants access to
+	// the object without further authorization until expires
+	// has passed. When fileName is not empty, the object is
+	// served as attachment with the given file name.
+	PresignGetObject(bucketName, objectName string, expires time.Duration, fileName string) (string, error)
 }
@@ -24,14 +24,31 @@ type verificationMock struct {
 	gl  *mocks.Logger
 	tp  *mocks.TimeProvider
 
+	store database.Database
+
 	ct di.Container
 }
 
 func getVerificationMock(prep ...func(m verificationMock)) verificationMock {
+	return buildVerificationMock(nil, prep...)
+}
+
+// getVerificationStoreMock works like getVerificationMock but
+// backs the provider with a real SQLite database instead of
+// the database mock.
+func getVerificationStoreMock(tt *testing.T, prep ...func(m verificationMock)) verificationMock {
+	return buildVerificationMock(testutil.NewTestDatabase(tt), prep...)
+}
+
+func buildVerificationMock(store database.Database, prep ...func(m verificationMock)) verificationMock {
 	var t verificationMock
 
 	t.s = &mocks.ISession{}
 	t.db = &mocks.Database{}
+	t.store = store
+	if t.store == nil {
+		t.store = t.db
+	}
 	t.cfg = &mocks.ConfigProvider{}
 	t.gl = &mocks.Logger{}
 	t.tp = &mocks.TimeProvider{}
@@ -52,7 +69,7 @@ func getVerificationMock(prep ...func(m verificationMock)) verificationMock {
 		},
 		di.Def{
 			Name:  static.DiDatabase,
-			Build: func(ctn di.Container) (interface{}, error) { return t.db, nil },
+			Build: func(ctn di.Container) (interface{}, error) { return t.store, nil },
 		},
 		di.Def{
 			Name:  static.DiConfig,
@@ -372,3 +389,70 @@ func TestKickRoutine(t *testing.T) {
 	m.db.AssertCalled(t, "RemoveVerificationQueue", "guild-id", "user-error")
 	m.gl.AssertCalled(t, "Errorf", "guild-id", mock.AnythingOfType("string"), testError.Error())
 }
+
+func TestVerificationStore(t *testing.T) {
+	now := time.Date(2023, 4, 12, 10, 0, 0, 0, time.UTC)
+
+	m := getVerificationStoreMock(t, func(m verificationMock) {
+		m.s.On("UserChannelCreate", mock.AnythingOfType("string")).
+			Return(&discordgo.Channel{ID: "channel-id"}, nil)
+		m.s.On("ChannelMessageSendEmbed", mock.AnythingOfType("string"), mock.AnythingOfType("*discordgo.MessageEmbed")).
+			Return(nil, nil)
+		m.s.On("GuildMemberTimeout", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("*time.Time")).
+			Return(nil)
+		m.s.On("GuildMemberDelete", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+			Return(nil)
+
+		m.cfg.On("Config").Return(&models.Config{})
+
+		// Enqueueing a member requests the current
+		// time twice.
+		m.tp.On("Now").Times(4).Return(now)
+		m.tp.On("Now").Return(now.Add(timeout + time.Minute))
+	})
+
+	p := New(m.ct)
+
+	// ----- Enqueue Members ------
+
+	for _, userID := range []string{"user-0", "user-1"} {
+		err := p.EnqueueVerification(discordgo.Member{
+			GuildID: "guild-id",
+			User: &discordgo.User{
+				ID: userID,
+			},
+		})
+		assert.Nil(t, err)
+	}
+
+	queue, err := m.store.GetVerificationQueue("guild-id", "")
+	assert.Nil(t, err)
+	assert.Len(t, queue, 2)
+
+	// ----- Verify Member ------
+
+	err = p.Verify("user-1")
+	assert.Nil(t, err)
+
+	ok, err := p.IsVerified("user-1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	queue, err = m.store.GetVerificationQueue("guild-id", "")
+	assert.Nil(t, err)
+	if assert.Len(t, queue, 1) {
+		assert.Equal(t, "user-0", queue[0].UserID)
+		assert.True(t, now.Equal(queue[0].Timestamp))
+	}
+
+	// ----- Kick Routine ------
+
+	p.KickRoutine()
+
+	m.s.AssertCalled(t, "GuildMemberDelete", "guild-id", "user-0")
+	m.s.AssertNotCalled(t, "GuildMemberDelete", "guild-id", "user-1")
+
+	queue, err = m.store.GetVerificationQueue("guild-id", "")
+	assert.Nil(t, err)
+	assert.Empty(t, queue)
+}
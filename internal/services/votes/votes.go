@@ -0,0 +1,247 @@
+// Package votes schedules the expiration of votes.
+//
+// Deadlines are registered as one-shot jobs in the
+// scheduler and are also persisted in the database,
+// so that votes which expired while the bot was not
+// running are closed on the next start. Closing a vote
+// is claimed in the database first, so that a vote is
+// never closed twice by multiple instances.
+package votes
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/scheduler"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
+)
+
+// sweepSpec is the schedule of the job which closes
+// all expired votes which have not been closed by
+// their deadline jobs.
+const sweepSpec = "0 * * * * *"
+
+type Service struct {
+	db      database.Database
+	sched   scheduler.Provider
+	session *discordgo.Session
+	gl      guildlog.Logger
+	tp      timeprovider.Provider
+	log     rogu.Logger
+
+	mtx       sync.Mutex
+	jobs      map[string]interface{}
+	sweepOnce sync.Once
+}
+
+func New(ctn di.Container) *Service {
+	return &Service{
+		db:      ctn.Get(static.DiDatabase).(database.Database),
+		sched:   ctn.Get(static.DiScheduler).(scheduler.Provider),
+		session: ctn.Get(static.DiDiscordSession).(*discordgo.Session),
+		gl:      ctn.Get(static.DiGuildLog).(guildlog.Logger).Section("votes"),
+		tp:      ctn.Get(static.DiTimeProvider).(timeprovider.Provider),
+		log:     log.Tagged("Votes"),
+		jobs:    make(map[string]interface{}),
+	}
+}
+
+// Load reads all votes from the database. Open votes of
+// guilds handled by the current shard are added to the
+// VotesRunning registry and their deadlines are scheduled.
+// Closed votes older than vote.ClosedLifetime are removed.
+//
+// The returned votes are the loaded open votes.
+func (s *Service) Load() (running []vote.Vote, err error) {
+	votes, err := s.db.GetVotes()
+	if err != nil {
+		return
+	}
+
+	now := s.tp.Now()
+	vote.VotesRunning.Clear()
+
+	for id, v := range votes {
+		if v.State != vote.VoteStateOpen {
+			if v.Closed.Add(vote.ClosedLifetime).Before(now) {
+				if err = s.db.DeleteVote(id); err != nil {
+					s.log.Error().Err(err).Field("vid", id).Msg("Failed removing closed vote")
+				}
+			}
+			continue
+		}
+
+		if !s.isOwnGuild(v.GuildID) {
+			continue
+		}
+
+		vote.VotesRunning.Set(v)
+		running = append(running, v)
+
+		if v.Expires.IsZero() {
+			continue
+		}
+		// Votes stored before the deadline has been persisted
+		// separately are re-stored so that they can be found
+		// by the expiration sweep.
+		if err = s.db.AddUpdateVote(v); err != nil {
+			s.log.Error().Err(err).Field("vid", id).Msg("Failed updating vote")
+		}
+		if err = s.Schedule(v); err != nil {
+			s.log.Error().Err(err).Field("vid", id).Msg("Failed scheduling vote expiration")
+		}
+	}
+
+	s.sweepOnce.Do(func() {
+		if _, err := s.sched.Schedule(sweepSpec, s.ExpireDue); err != nil {
+			s.log.Error().Err(err).Msg("Failed scheduling vote expiration sweep")
+		}
+	})
+
+	return running, nil
+}
+
+// Schedule registers a job which closes the vote at
+// its deadline. An already scheduled job for the vote
+// is replaced. If the vote has no deadline, only the
+// existing job is removed.
+func (s *Service) Schedule(v vote.Vote) (err error) {
+	s.Unschedule(v.ID)
+
+	if v.Expires.IsZero() {
+		return
+	}
+
+	// Deadlines closer than the resolution of the
+	// scheduler are handled by a timer instead.
+	deadline := v.Expires.Local()
+	if d := deadline.Sub(s.tp.Now()); d <= time.Second {
+		time.AfterFunc(d, func() { s.expire(v.ID) })
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	id, err := s.sched.Schedule(deadlineSpec(deadline), func() {
+		s.Unschedule(v.ID)
+		s.expire(v.ID)
+	})
+	if err != nil {
+		return
+	}
+	s.jobs[v.ID] = id
+
+	return
+}
+
+// Unschedule removes the deadline job of the
+// vote, if scheduled.
+func (s *Service) Unschedule(voteID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	id, ok := s.jobs[voteID]
+	if !ok {
+		return
+	}
+	delete(s.jobs, voteID)
+	if err := s.sched.Unschedule(id); err != nil {
+		s.log.Error().Err(err).Field("vid", voteID).Msg("Failed unscheduling vote expiration")
+	}
+}
+
+// Close claims the closing of the vote in the database,
+// closes the vote with the given state and stores it.
+//
+// ok is false if the vote has already been closed by
+// another instance.
+func (s *Service) Close(v vote.Vote, state vote.VoteState) (ok bool, err error) {
+	s.Unschedule(v.ID)
+
+	ok, err = s.db.ClaimVoteClose(v.ID)
+	if err != nil || !ok {
+		vote.VotesRunning.Delete(v.ID)
+		return
+	}
+
+	closeErr := v.Close(s.session, state)
+	if err = s.db.AddUpdateVote(v); err != nil {
+		return
+	}
+
+	return true, closeErr
+}
+
+// ExpireDue closes all expired votes of guilds handled
+// by the current shard which have not been closed yet.
+func (s *Service) ExpireDue() {
+	votes, err := s.db.GetExpiredVotes(s.tp.Now())
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed getting expired votes")
+		return
+	}
+
+	for _, v := range votes {
+		if s.isOwnGuild(v.GuildID) {
+			s.expire(v.ID)
+		}
+	}
+}
+
+func (s *Service) expire(voteID string) {
+	// The vote is read from the database because the
+	// deadline might have been changed by another instance.
+	v, err := s.db.GetVote(voteID)
+	if database.IsErrDatabaseNotFound(err) {
+		vote.VotesRunning.Delete(voteID)
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Field("vid", voteID).Msg("Failed getting vote")
+		return
+	}
+
+	if v.State != vote.VoteStateOpen {
+		vote.VotesRunning.Delete(voteID)
+		return
+	}
+
+	if v.Expires.IsZero() || v.Expires.After(s.tp.Now()) {
+		if err = s.Schedule(v); err != nil {
+			s.log.Error().Err(err).Field("vid", voteID).Msg("Failed rescheduling vote expiration")
+		}
+		return
+	}
+
+	if _, err = s.Close(v, vote.VoteStateExpired); err != nil {
+		s.log.Error().Err(err).Fields("gid", v.GuildID, "vid", v.ID).Msg("Failed closing expired vote")
+		s.gl.Errorf(v.GuildID, "Failed closing expired vote (%s): %s", v.ID, err.Error())
+	}
+}
+
+func (s *Service) isOwnGuild(guildID string) bool {
+	shardID, shardTotal := discordutil.GetShardOfSession(s.session)
+	if shardTotal <= 1 {
+		return true
+	}
+	id, err := discordutil.GetShardOfGuild(guildID, shardTotal)
+	return err == nil && id == shardID
+}
+
+// deadlineSpec returns a cron spec with seconds which
+// matches the given point in time.
+func deadlineSpec(t time.Time) string {
+	return fmt.Sprintf("%d %d %d %d %d *",
+		t.Second(), t.Minute(), t.Hour(), t.Day(), t.Month())
+}
@@ -0,0 +1,108 @@
+package votes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+	"github.com/zekroTJA/shinpuru/mocks"
+	"github.com/zekrotja/rogu/log"
+)
+
+type fakeScheduler struct {
+	specs map[int]string
+	jobs  map[int]func()
+}
+
+func (f *fakeScheduler) Schedule(spec interface{}, job func()) (interface{}, error) {
+	id := len(f.specs)
+	f.specs[id] = spec.(string)
+	f.jobs[id] = job
+	return id, nil
+}
+
+func (f *fakeScheduler) Unschedule(id interface{}) error {
+	delete(f.specs, id.(int))
+	delete(f.jobs, id.(int))
+	return nil
+}
+
+func (f *fakeScheduler) Start() {}
+func (f *fakeScheduler) Stop()  {}
+
+func newTestService(db *mocks.Database, now time.Time) (*Service, *fakeScheduler) {
+	tp := &mocks.TimeProvider{}
+	tp.On("Now").Return(now)
+
+	sched := &fakeScheduler{specs: map[int]string{}, jobs: map[int]func(){}}
+
+	return &Service{
+		db:      db,
+		sched:   sched,
+		session: &discordgo.Session{},
+		tp:      tp,
+		log:     log.Tagged("Votes"),
+		jobs:    map[string]interface{}{},
+	}, sched
+}
+
+func TestDeadlineSpec(t *testing.T) {
+	deadline := time.Date(2022, 3, 4, 5, 6, 7, 0, time.Local)
+
+	sched, err := cron.NewParser(
+		cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow).
+		Parse(deadlineSpec(deadline))
+	require.NoError(t, err)
+
+	assert.Equal(t, deadline, sched.Next(deadline.Add(-time.Hour)))
+}
+
+func TestScheduleAndRescheduleOnExpire(t *testing.T) {
+	now := time.Now()
+	db := &mocks.Database{}
+	s, sched := newTestService(db, now)
+
+	v := vote.Vote{ID: "v", Expires: now.Add(time.Hour)}
+	require.NoError(t, s.Schedule(v))
+	require.Len(t, sched.specs, 1)
+
+	// Scheduling again replaces the existing job.
+	require.NoError(t, s.Schedule(v))
+	require.Len(t, sched.specs, 1)
+
+	// The deadline has been extended in the meantime,
+	// so the vote is rescheduled instead of closed.
+	v.Expires = now.Add(2 * time.Hour)
+	db.On("GetVote", "v").Return(v, nil)
+	job := sched.jobs[0]
+	job()
+
+	require.Len(t, sched.specs, 1)
+	for _, spec := range sched.specs {
+		assert.Equal(t, deadlineSpec(v.Expires.Local()), spec)
+	}
+	db.AssertNotCalled(t, "ClaimVoteClose", "v")
+}
+
+func TestCloseAlreadyClaimed(t *testing.T) {
+	db := &mocks.Database{}
+	s, sched := newTestService(db, time.Now())
+
+	v := vote.Vote{ID: "v", Expires: time.Now().Add(time.Hour)}
+	vote.VotesRunning.Set(v)
+	require.NoError(t, s.Schedule(v))
+
+	db.On("ClaimVoteClose", "v").Return(false, nil)
+
+	ok, err := s.Close(v, vote.VoteStateClosed)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, sched.specs)
+	_, ok = vote.VotesRunning.Get("v")
+	assert.False(t, ok)
+	db.AssertNotCalled(t, "AddUpdateVote", v)
+}
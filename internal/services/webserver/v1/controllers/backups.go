@@ -1,8 +1,7 @@
 package controllers
 
 import (
-	"bytes"
-	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -10,37 +9,46 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sarulabs/di/v2"
-	_ "github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
+	"github.com/zekroTJA/shinpuru/internal/services/backup"
+	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
-	"github.com/zekroTJA/shinpuru/internal/services/storage"
 	"github.com/zekroTJA/shinpuru/internal/services/webserver/v1/models"
+	"github.com/zekroTJA/shinpuru/internal/services/webserver/wsutil"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/onetimeauth/v2"
 )
 
 type GuildBackupsController struct {
-	db  database.Database
-	st  storage.Storage
-	ota onetimeauth.OneTimeAuth
+	session *discordgo.Session
+	db      database.Database
+	ota     onetimeauth.OneTimeAuth
+	bck     *backup.GuildBackups
+	pmw     *permissions.Permissions
 }
 
 func (c *GuildBackupsController) Setup(container di.Container, router fiber.Router) {
 	c.db = container.Get(static.DiDatabase).(database.Database)
-	c.st = container.Get(static.DiObjectStorage).(storage.Storage)
 	c.ota = container.Get(static.DiOneTimeAuth).(onetimeauth.OneTimeAuth)
-
-	session := container.Get(static.DiDiscordSession).(*discordgo.Session)
-	pmw := container.Get(static.DiPermissions).(*permissions.Permissions)
+	c.bck = container.Get(static.DiBackupHandler).(*backup.GuildBackups)
+	c.session = container.Get(static.DiDiscordSession).(*discordgo.Session)
+	c.pmw = container.Get(static.DiPermissions).(*permissions.Permissions)
 
 	router.Get("", c.getBackups)
-	router.Post("/toggle", pmw.HandleWs(session, "sp.guild.admin.backup"), c.postToggleBackups)
-	router.Post("/:backupid/download", pmw.HandleWs(session, "sp.guild.admin.backup"), c.postDownloadBackup)
+	router.Post("/toggle", c.pmw.HandleWs(c.session, "sp.guild.admin.backup"), c.postToggleBackups)
+	router.Get("/retention", c.getRetention)
+	router.Get("/diff", c.pmw.HandleWs(c.session, "sp.guild.admin.backup"), c.getDiff)
+	router.Get("/encryption", c.pmw.HandleWs(c.session, "sp.guild.admin.backup"), c.getEncryption)
+	router.Post("/encryption/rotate", c.pmw.HandleWs(c.session, "sp.guild.admin.backup"), c.postRotateKey)
+	router.Post("/retention", c.pmw.HandleWs(c.session, "sp.guild.admin.backup"), c.postRetention)
+	router.Post("/:backupid/download", c.pmw.HandleWs(c.session, "sp.guild.admin.backup"), c.postDownloadBackup)
 	router.Get("/:backupid/download", c.getDownloadBackup)
+	router.Post("/:backupid/restore/plan", c.pmw.HandleWs(c.session, "sp.guild.admin.backup"), c.postRestorePlan)
+	router.Post("/:backupid/restore", c.pmw.HandleWs(c.session, "sp.guild.admin.backup"), c.postRestore)
 }
 
 // @Summary Get Guild Backups
-// @Description Returns a list of guild backups.
+// @Description Returns the timeline of guild backups sorted from the newest to the oldest one. Incremental backups reference their parent backup and contain the number of changes since then.
 // @Tags Guild Backups
 // @Accept json
 // @Produce json
@@ -52,10 +60,8 @@ func (c *GuildBackupsController) Setup(container di.Container, router fiber.Rout
 func (c *GuildBackupsController) getBackups(ctx *fiber.Ctx) error {
 	guildID := ctx.Params("guildid")
 
-	backupEntries, err := c.db.GetBackups(guildID)
-	if database.IsErrDatabaseNotFound(err) {
-		return fiber.ErrNotFound
-	} else if err != nil {
+	backupEntries, err := c.bck.Timeline(guildID)
+	if err != nil {
 		return err
 	}
 
@@ -91,7 +97,7 @@ func (c *GuildBackupsController) postDownloadBackup(ctx *fiber.Ctx) error {
 }
 
 // @Summary Download Backup File
-// @Description Download a single gziped backup file.
+// @Description Download a single gziped backup file. The response redirects to a presigned storage URL of the backup export. When backup encryption is enabled, the decrypted backup is served directly instead.
 // @Tags Guild Backups
 // @Accept json
 // @Produce application/gzip
@@ -99,6 +105,7 @@ func (c *GuildBackupsController) postDownloadBackup(ctx *fiber.Ctx) error {
 // @Param backupid path string true "The ID of the backup."
 // @Param ota_token query string true "The previously obtained OTA token to authorize the download."
 // @Success 200 {file} gziped bakcup file
+// @Success 302
 // @Failure 401 {object} models.Error
 // @Failure 403 {object} models.Error
 // @Failure 404 {object} models.Error
@@ -112,46 +119,30 @@ func (c *GuildBackupsController) getDownloadBackup(ctx *fiber.Ctx) error {
 		return fiber.ErrForbidden
 	}
 
-	backupEntries, err := c.db.GetBackups(guildID)
-	if database.IsErrDatabaseNotFound(err) {
-		return fiber.ErrNotFound
-	} else if err != nil {
-		return err
-	}
-
-	var found bool
-	for _, e := range backupEntries {
-		if e.FileID == backupID {
-			found = true
-			break
-		}
+	url, err := c.bck.ExportURL(guildID, backupID)
+	if err == nil {
+		return ctx.Redirect(url, fiber.StatusFound)
 	}
-
-	if !found {
-		return fiber.ErrNotFound
+	if !errors.Is(err, backup.ErrEncryptionEnabled) {
+		return backupError(err)
 	}
 
-	f, size, err := c.st.GetObject(static.StorageBucketBackups, backupID)
+	// Exports of encrypted backups are not stored
+	// in plain and thus are streamed directly.
+	obj, err := c.bck.GetBackup(guildID, backupID)
 	if err != nil {
-		return err
+		return backupError(err)
 	}
-	defer f.Close()
-
-	buff := bytes.NewBuffer([]byte{})
-	zf := gzip.NewWriter(buff)
-	zf.Name = fmt.Sprintf("backup_%s_%s.json", guildID, backupID)
 
-	_, err = io.CopyN(zf, f, size)
-	if err != nil {
-		return err
-	}
-	zf.Close()
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(backup.WriteExport(pw, guildID, obj))
+	}()
 
-	// 24 hours browser caching
-	ctx.Set("Cache-Control", "public, max-age=86400, immutable")
+	ctx.Set("Cache-Control", "private, no-store")
 	ctx.Set("Content-Type", "application/gzip")
-	ctx.Set("Content-Disposition", fmt.Sprintf(`filename="backup_%s_%s.gz"`, guildID, backupID))
-	return ctx.SendStream(buff)
+	ctx.Set("Content-Disposition", fmt.Sprintf(`filename="%s"`, backup.ExportFileName(guildID, backupID)))
+	return ctx.SendStream(pr)
 }
 
 // @Summary Toggle Guild Backup Enable
@@ -180,8 +171,249 @@ func (c *GuildBackupsController) postToggleBackups(ctx *fiber.Ctx) error {
 	return ctx.JSON(models.Ok)
 }
 
+// @Summary Get Guild Backup Retention
+// @Description Returns the backup retention policy of the guild.
+// @Tags Guild Backups
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {object} backupmodels.Retention
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/backups/retention [get]
+func (c *GuildBackupsController) getRetention(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	r, err := c.bck.Retention(guildID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(r)
+}
+
+// @Summary Set Guild Backup Retention
+// @Description Sets the backup retention policy of the guild. It is applied when the next backup is created.
+// @Tags Guild Backups
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body backupmodels.Retention true "The retention policy."
+// @Success 200 {object} backupmodels.Retention
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/backups/retention [post]
+func (c *GuildBackupsController) postRetention(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	var r backupmodels.Retention
+	if err := ctx.BodyParser(&r); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := r.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := c.bck.SetRetention(guildID, r); err != nil {
+		return err
+	}
+
+	return ctx.JSON(r)
+}
+
+// @Summary Get Backup Diff
+// @Description Returns the changes of the guild between two backups. When no target backup is specified, the latest backup is used.
+// @Tags Guild Backups
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param from query string true "The ID of the backup to compare from."
+// @Param to query string false "The ID of the backup to compare to."
+// @Success 200 {object} backupmodels.Diff
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/backups/diff [get]
+func (c *GuildBackupsController) getDiff(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	from := ctx.Query("from")
+	if from == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "from must be specified")
+	}
+
+	to := ctx.Query("to")
+	if to == "" {
+		entries, err := c.bck.Timeline(guildID)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return fiber.ErrNotFound
+		}
+		to = entries[0].FileID
+	}
+
+	diff, err := c.bck.Diff(guildID, from, to)
+	if err != nil {
+		return backupError(err)
+	}
+
+	return ctx.JSON(diff)
+}
+
+// @Summary Get Backup Encryption
+// @Description Returns whether backups are encrypted and which data key is currently used to encrypt the backups of the guild.
+// @Tags Guild Backups
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {object} backupmodels.EncryptionStatus
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Router /guilds/{id}/backups/encryption [get]
+func (c *GuildBackupsController) getEncryption(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	status, err := c.bck.Encryption(guildID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(status)
+}
+
+// @Summary Rotate Backup Data Key
+// @Description Creates a new data key for the guild and re-encrypts all stored backup objects with it. Previous data keys are deleted afterwards. The number of re-encrypted objects is returned.
+// @Tags Guild Backups
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {object} models.Count
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Router /guilds/{id}/backups/encryption/rotate [post]
+func (c *GuildBackupsController) postRotateKey(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	n, err := c.bck.RotateKey(guildID)
+	if errors.Is(err, backup.ErrEncryptionDisabled) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(models.Count{Count: n})
+}
+
+// @Summary Plan Backup Restore
+// @Description Returns the operations which would be performed when restoring the backup with the given options without applying them (dry run).
+// @Tags Guild Backups
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param backupid path string true "The ID of the backup."
+// @Param payload body backupmodels.RestoreOptions true "The restore options."
+// @Success 200 {object} backupmodels.RestorePlan
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/backups/{backupid}/restore/plan [post]
+func (c *GuildBackupsController) postRestorePlan(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+	backupID := ctx.Params("backupid")
+
+	opts, err := c.parseRestoreOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.bck.PlanRestore(guildID, backupID, opts)
+	if err != nil {
+		return backupError(err)
+	}
+
+	return ctx.JSON(plan)
+}
+
+// @Summary Restore Backup
+// @Description Starts restoring the backup with the given options in the background. The restore plan is returned. An interrupted restore is resumed when started again with the same options.
+// @Tags Guild Backups
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param backupid path string true "The ID of the backup."
+// @Param payload body backupmodels.RestoreOptions true "The restore options."
+// @Success 202 {object} backupmodels.RestorePlan
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/backups/{backupid}/restore [post]
+func (c *GuildBackupsController) postRestore(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+	backupID := ctx.Params("backupid")
+
+	opts, err := c.parseRestoreOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.bck.PlanRestore(guildID, backupID, opts)
+	if err != nil {
+		return backupError(err)
+	}
+
+	c.bck.RestoreBackupAsync(guildID, backupID, opts)
+
+	return ctx.Status(fiber.StatusAccepted).JSON(plan)
+}
+
 // --- HELPERS ---
 
+func (c *GuildBackupsController) parseRestoreOptions(ctx *fiber.Ctx) (opts backupmodels.RestoreOptions, err error) {
+	uid := ctx.Locals("uid").(string)
+	guildID := ctx.Params("guildid")
+
+	if err = ctx.BodyParser(&opts); err != nil {
+		return opts, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err = opts.Validate(); err != nil {
+		return opts, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if opts.TargetGuildID != "" && opts.TargetGuildID != guildID {
+		ok, _, err := c.pmw.CheckPermissions(c.session, opts.TargetGuildID, uid, "sp.guild.admin.backup")
+		if err != nil {
+			return opts, wsutil.ErrInternalOrNotFound(err)
+		}
+		if !ok {
+			return opts, fiber.NewError(fiber.StatusForbidden,
+				"you are not permitted to restore backups into the target guild")
+		}
+	}
+
+	return opts, nil
+}
+
+func backupError(err error) error {
+	switch {
+	case errors.Is(err, backup.ErrBackupNotFound):
+		return fiber.ErrNotFound
+	case errors.Is(err, backup.ErrRestoreRunning):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return wsutil.ErrInternalOrNotFound(err)
+	}
+}
+
 func getBackupIdent(guildID, backupID string) string {
 	return fmt.Sprintf("%s#%s", guildID, backupID)
 }
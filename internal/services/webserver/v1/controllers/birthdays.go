@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/services/birthday"
+	"github.com/zekroTJA/shinpuru/internal/services/permissions"
+	"github.com/zekroTJA/shinpuru/internal/services/webserver/v1/models"
+	"github.com/zekroTJA/shinpuru/internal/services/webserver/wsutil"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+)
+
+type GuildBirthdaysController struct {
+	session *discordgo.Session
+	bs      *birthday.BirthdayService
+	pmw     *permissions.Permissions
+}
+
+func (c *GuildBirthdaysController) Setup(container di.Container, router fiber.Router) {
+	c.session = container.Get(static.DiDiscordSession).(*discordgo.Session)
+	c.bs = container.Get(static.DiBirthday).(*birthday.BirthdayService)
+	c.pmw = container.Get(static.DiPermissions).(*permissions.Permissions)
+
+	router.Get("/upcoming", c.pmw.HandleWs(c.session, "sp.chat.birthday"), c.getUpcoming)
+}
+
+// @Summary Get Upcoming Guild Birthdays
+// @Description Returns the next birthdays of the members of the guild ordered by their next occurrence.
+// @Tags Guild Birthdays
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param limit query int false "The maximum amount of result items (max. 100)." default(10)
+// @Success 200 {array} sharedmodels.UpcomingBirthday "Wrapped in models.ListResponse"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/birthdays/upcoming [get]
+func (c *GuildBirthdaysController) getUpcoming(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	limit, err := wsutil.GetQueryInt(ctx, "limit", 10, 1, 100)
+	if err != nil {
+		return err
+	}
+
+	upcoming, err := c.bs.Upcoming(guildID, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(models.NewListResponse(upcoming))
+}
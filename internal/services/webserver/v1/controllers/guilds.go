@@ -17,6 +17,8 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/services/config"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/guildlog"
+	"github.com/zekroTJA/shinpuru/internal/services/joinlog"
+	"github.com/zekroTJA/shinpuru/internal/services/karma"
 	"github.com/zekroTJA/shinpuru/internal/services/kvcache"
 	permservice "github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/services/report"
@@ -48,6 +50,8 @@ type GuildsController struct {
 	tp      timeprovider.Provider
 	rep     report.Provider
 	gl      guildlog.Logger
+	karma   karma.Provider
+	jl      joinlog.Provider
 }
 
 func (c *GuildsController) Setup(container di.Container, router fiber.Router) {
@@ -63,14 +67,19 @@ func (c *GuildsController) Setup(container di.Container, router fiber.Router) {
 	c.tp = container.Get(static.DiTimeProvider).(timeprovider.Provider)
 	c.rep = container.Get(static.DiReport).(report.Provider)
 	c.gl = container.Get(static.DiGuildLog).(guildlog.Logger)
+	c.karma = container.Get(static.DiKarma).(karma.Provider)
+	c.jl = container.Get(static.DiJoinlog).(joinlog.Provider)
 
 	router.Get("", c.getGuilds)
 	router.Get("/:guildid", c.getGuild)
 	router.Get("/:guildid/scoreboard", c.getGuildScoreboard)
+	router.Post("/:guildid/karma/revert", c.pmw.HandleWs(c.session, "sp.guild.mod.karma.revert"), c.postGuildKarmaRevert)
 	router.Get("/:guildid/starboard", c.getGuildStarboard)
 	router.Get("/:guildid/starboard/count", c.getGuildStarboardCount)
 	router.Get("/:guildid/antiraid/joinlog", c.pmw.HandleWs(c.session, "sp.guild.config.antiraid"), c.getGuildAntiraidJoinlog)
 	router.Delete("/:guildid/antiraid/joinlog", c.pmw.HandleWs(c.session, "sp.guild.config.antiraid"), c.deleteGuildAntiraidJoinlog)
+	router.Get("/:guildid/antiraid/joinlog/action", c.pmw.HandleWs(c.session, "sp.guild.config.antiraid"), c.getGuildAntiraidJoinlogAction)
+	router.Post("/:guildid/antiraid/joinlog/action", c.pmw.HandleWs(c.session, "sp.guild.config.antiraid"), c.postGuildAntiraidJoinlogAction)
 	router.Get("/:guildid/reports", c.getReports)
 	router.Get("/:guildid/reports/count", c.getReportsCount)
 	router.Get("/:guildid/permissions", c.getGuildPermissions)
@@ -156,7 +165,9 @@ func (c *GuildsController) getGuild(ctx *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "The ID of the guild."
 // @Param limit query int false "Limit the amount of result values" default(25) minimum(1) maximum(100)
+// @Param period query string false "The time period covered by the scoreboard." Enums(all, month, week) default(all)
 // @Success 200 {array} models.GuildKarmaEntry "Wrapped in models.ListResponse"
+// @Failure 400 {object} models.Error
 // @Failure 401 {object} models.Error
 // @Failure 404 {object} models.Error
 // @Router /guilds/{id}/scoreboard [get]
@@ -167,7 +178,18 @@ func (c *GuildsController) getGuildScoreboard(ctx *fiber.Ctx) error {
 		return err
 	}
 
-	karmaList, err := c.db.GetKarmaGuild(guildID, limit)
+	period := sharedmodels.KarmaPeriod(ctx.Query("period", string(sharedmodels.KarmaPeriodAll)))
+	window, ok := period.Duration()
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid period")
+	}
+
+	var karmaList []sharedmodels.GuildKarma
+	if window == 0 {
+		karmaList, err = c.db.GetKarmaGuild(guildID, limit)
+	} else {
+		karmaList, err = c.db.GetKarmaLeaderboard(guildID, c.tp.Now().Add(-window), limit)
+	}
 
 	if err == database.ErrDatabaseNotFound {
 		return fiber.ErrNotFound
@@ -193,6 +215,39 @@ func (c *GuildsController) getGuildScoreboard(ctx *fiber.Ctx) error {
 	return ctx.JSON(models.NewListResponse(results[:i]))
 }
 
+// @Summary Revert Guild Karma
+// @Description Reverts all karma given by a member by reactions within the given time window.
+// @Tags Guilds
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body models.KarmaRevertRequest true "The revert payload."
+// @Success 200 {array} sharedmodels.KarmaLedgerEntry "Created compensation entries wrapped in models.ListResponse"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/karma/revert [post]
+func (c *GuildsController) postGuildKarmaRevert(ctx *fiber.Ctx) error {
+	uid := ctx.Locals("uid").(string)
+	guildID := ctx.Params("guildid")
+
+	var req models.KarmaRevertRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if ok, err := req.Validate(); !ok {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	reverts, err := c.karma.Revert(guildID, req.ExecutorID, uid, req.From, req.To)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(models.NewListResponse(reverts))
+}
+
 // @Summary Get Antiraid Joinlog
 // @Description Returns a list of joined members during an antiraid trigger.
 // @Tags Guilds
@@ -238,6 +293,82 @@ func (c *GuildsController) deleteGuildAntiraidJoinlog(ctx *fiber.Ctx) error {
 	return ctx.JSON(models.Ok)
 }
 
+// @Summary Get Antiraid Joinlog Action Progress
+// @Description Returns the progress of the current or last action applied to the antiraid joinlog.
+// @Tags Guilds
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {object} sharedmodels.JoinlogActionProgress
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/antiraid/joinlog/action [get]
+func (c *GuildsController) getGuildAntiraidJoinlogAction(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	progress, ok := c.jl.Progress(guildID)
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	return ctx.JSON(progress)
+}
+
+// @Summary Apply Antiraid Joinlog Action
+// @Description Bans, kicks or verifies all or the filtered members of the antiraid joinlog in the background. The initial progress is returned.
+// @Tags Guilds
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body sharedmodels.JoinlogActionRequest true "The action and filter."
+// @Success 202 {object} sharedmodels.JoinlogActionProgress
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Router /guilds/{id}/antiraid/joinlog/action [post]
+func (c *GuildsController) postGuildAntiraidJoinlogAction(ctx *fiber.Ctx) error {
+	uid := ctx.Locals("uid").(string)
+	guildID := ctx.Params("guildid")
+
+	var req sharedmodels.JoinlogActionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	var perm string
+	switch req.Action {
+	case sharedmodels.JoinlogActionBan:
+		perm = "sp.guild.mod.ban"
+	case sharedmodels.JoinlogActionKick:
+		perm = "sp.guild.mod.kick"
+	}
+	if perm != "" {
+		if ok, _, err := c.pmw.CheckPermissions(c.session, guildID, uid, perm); err != nil {
+			return wsutil.ErrInternalOrNotFound(err)
+		} else if !ok {
+			return fiber.ErrForbidden
+		}
+	}
+
+	progress, err := c.jl.Start(guildID, uid, req, nil)
+	switch err {
+	case nil:
+	case joinlog.ErrNoEntries:
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case joinlog.ErrRunning:
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return err
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(progress)
+}
+
 // @Summary Get Guild Starboard
 // @Description Returns a list of starboard entries for the given guild.
 // @Tags Guilds
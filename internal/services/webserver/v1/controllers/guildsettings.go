@@ -11,9 +11,11 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/sarulabs/di/v2"
 	sharedmodels "github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/backup"
 	"github.com/zekroTJA/shinpuru/internal/services/codeexec"
 	"github.com/zekroTJA/shinpuru/internal/services/config"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/karma"
 	"github.com/zekroTJA/shinpuru/internal/services/kvcache"
 	permservice "github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/services/storage"
@@ -21,6 +23,7 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/services/webserver/v1/models"
 	"github.com/zekroTJA/shinpuru/internal/services/webserver/wsutil"
 	"github.com/zekroTJA/shinpuru/internal/util"
+	"github.com/zekroTJA/shinpuru/internal/util/automod"
 	"github.com/zekroTJA/shinpuru/internal/util/snowflakenodes"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/fetch"
@@ -33,6 +36,7 @@ import (
 type GuildsSettingsController struct {
 	db      database.Database
 	st      storage.Storage
+	bck     *backup.GuildBackups
 	kvc     kvcache.Provider
 	session *discordgo.Session
 	cfg     config.Provider
@@ -40,6 +44,7 @@ type GuildsSettingsController struct {
 	state   *dgrs.State
 	vs      verification.Provider
 	cef     codeexec.Factory
+	karma   karma.Provider
 }
 
 func (c *GuildsSettingsController) Setup(container di.Container, router fiber.Router) {
@@ -49,9 +54,11 @@ func (c *GuildsSettingsController) Setup(container di.Container, router fiber.Ro
 	c.pmw = container.Get(static.DiPermissions).(*permservice.Permissions)
 	c.kvc = container.Get(static.DiKVCache).(kvcache.Provider)
 	c.st = container.Get(static.DiObjectStorage).(storage.Storage)
+	c.bck = container.Get(static.DiBackupHandler).(*backup.GuildBackups)
 	c.state = container.Get(static.DiState).(*dgrs.State)
 	c.vs = container.Get(static.DiVerification).(verification.Provider)
 	c.cef = container.Get(static.DiCodeExecFactory).(codeexec.Factory)
+	c.karma = container.Get(static.DiKarma).(karma.Provider)
 
 	router.Get("", c.getGuildSettings)
 	router.Post("", c.postGuildSettings)
@@ -62,6 +69,7 @@ func (c *GuildsSettingsController) Setup(container di.Container, router fiber.Ro
 	router.Delete("/karma/blocklist/:memberid", c.pmw.HandleWs(c.session, "sp.guild.config.karma"), c.deleteGuildSettingsKarmaBlocklist)
 	router.Get("/karma/rules", c.pmw.HandleWs(c.session, "sp.guild.config.karma"), c.getGuildSettingsKarmaRules)
 	router.Post("/karma/rules", c.pmw.HandleWs(c.session, "sp.guild.config.karma"), c.createGuildSettingsKrameRule)
+	router.Post("/karma/rules/preview", c.pmw.HandleWs(c.session, "sp.guild.config.karma"), c.previewGuildSettingsKarmaRule)
 	router.Post("/karma/rules/:id", c.pmw.HandleWs(c.session, "sp.guild.config.karma"), c.updateGuildSettingsKrameRule)
 	router.Delete("/karma/rules/:id", c.pmw.HandleWs(c.session, "sp.guild.config.karma"), c.deleteGuildSettingsKrameRule)
 	router.Get("/antiraid", c.pmw.HandleWs(c.session, "sp.guild.config.antiraid"), c.getGuildSettingsAntiraid)
@@ -80,6 +88,18 @@ func (c *GuildsSettingsController) Setup(container di.Container, router fiber.Ro
 	router.Post("/verification", c.pmw.HandleWs(c.session, "sp.guild.config.verification"), c.postGuildSettingsVerification)
 	router.Get("/codeexec", c.pmw.HandleWs(c.session, "sp.guild.config.exec"), c.getGuildSettingsCodeExec)
 	router.Post("/codeexec", c.pmw.HandleWs(c.session, "sp.guild.config.exec"), c.postGuildSettingsCodeExec)
+	router.Get("/escalation", c.pmw.HandleWs(c.session, "sp.guild.config.escalation"), c.getGuildSettingsEscalation)
+	router.Post("/escalation", c.pmw.HandleWs(c.session, "sp.guild.config.escalation"), c.postGuildSettingsEscalation)
+	router.Get("/inviteblock", c.pmw.HandleWs(c.session, "sp.guild.mod.inviteblock"), c.getGuildSettingsInviteBlock)
+	router.Post("/inviteblock", c.pmw.HandleWs(c.session, "sp.guild.mod.inviteblock"), c.postGuildSettingsInviteBlock)
+	router.Get("/automod", c.pmw.HandleWs(c.session, "sp.guild.config.automod"), c.getGuildSettingsAutomodRules)
+	router.Post("/automod", c.pmw.HandleWs(c.session, "sp.guild.config.automod"), c.createGuildSettingsAutomodRule)
+	router.Post("/automod/dryrun", c.pmw.HandleWs(c.session, "sp.guild.config.automod"), c.dryRunGuildSettingsAutomodRule)
+	router.Post("/automod/:id", c.pmw.HandleWs(c.session, "sp.guild.config.automod"), c.updateGuildSettingsAutomodRule)
+	router.Delete("/automod/:id", c.pmw.HandleWs(c.session, "sp.guild.config.automod"), c.deleteGuildSettingsAutomodRule)
+	router.Get("/starboards", c.pmw.HandleWs(c.session, "sp.guild.config.starboard"), c.getGuildSettingsStarboards)
+	router.Post("/starboards", c.pmw.HandleWs(c.session, "sp.guild.config.starboard"), c.postGuildSettingsStarboard)
+	router.Delete("/starboards/:name", c.pmw.HandleWs(c.session, "sp.guild.config.starboard"), c.deleteGuildSettingsStarboard)
 }
 
 // @Summary Get Guild Settings
@@ -327,6 +347,14 @@ func (c *GuildsSettingsController) getGuildSettingsKarma(ctx *fiber.Ctx) error {
 		return err
 	}
 
+	if settings.Decay, err = c.db.GetKarmaDecay(guildID); err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	if settings.Farming, err = c.db.GetKarmaFarmingLimits(guildID); err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
 	return ctx.JSON(settings)
 }
 
@@ -352,6 +380,13 @@ func (c *GuildsSettingsController) postGuildSettingsKarma(ctx *fiber.Ctx) error
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
+	if err = settings.Decay.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err = settings.Farming.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
 	if err = c.db.SetKarmaState(guildID, settings.State); err != nil {
 		return err
 	}
@@ -374,6 +409,19 @@ func (c *GuildsSettingsController) postGuildSettingsKarma(ctx *fiber.Ctx) error
 		return err
 	}
 
+	prevDecay, err := c.db.GetKarmaDecay(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+	settings.Decay = settings.Decay.Track(prevDecay, time.Now())
+	if err = c.db.SetKarmaDecay(guildID, settings.Decay); err != nil {
+		return err
+	}
+
+	if err = c.db.SetKarmaFarmingLimits(guildID, settings.Farming); err != nil {
+		return err
+	}
+
 	return ctx.JSON(models.Ok)
 }
 
@@ -513,6 +561,17 @@ func (c *GuildsSettingsController) getGuildSettingsAntiraid(ctx *fiber.Ctx) erro
 		return err
 	}
 
+	if settings.Signals, err = c.db.GetAntiraidSignals(guildID); err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	settings.Lockdown, err = c.db.GetAntiraidLockdown(guildID)
+	if database.IsErrDatabaseNotFound(err) {
+		settings.Lockdown = sharedmodels.DefaultAntiraidLockdown
+	} else if err != nil {
+		return err
+	}
+
 	return ctx.JSON(settings)
 }
 
@@ -542,6 +601,12 @@ func (c *GuildsSettingsController) postGuildSettingsAntiraid(ctx *fiber.Ctx) err
 	if settings.Burst < 1 {
 		return fiber.NewError(fiber.StatusBadRequest, "burst must be larger than 0")
 	}
+	if err := settings.Signals.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := settings.Lockdown.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
 
 	var err error
 
@@ -561,6 +626,14 @@ func (c *GuildsSettingsController) postGuildSettingsAntiraid(ctx *fiber.Ctx) err
 		return err
 	}
 
+	if err = c.db.SetAntiraidSignals(guildID, settings.Signals); err != nil {
+		return err
+	}
+
+	if err = c.db.SetAntiraidLockdown(guildID, settings.Lockdown); err != nil {
+		return err
+	}
+
 	return ctx.JSON(models.Ok)
 }
 
@@ -681,12 +754,8 @@ func (c *GuildsSettingsController) createGuildSettingsKrameRule(ctx *fiber.Ctx)
 	rule.GuildID = guildID
 	rule.ID = snowflakenodes.NodeKarmaRules.Generate()
 
-	if rule.Action == sharedmodels.KarmaActionToggleRole {
-		role, err := fetch.FetchRole(c.session, guildID, rule.Argument)
-		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-		rule.Argument = role.ID
+	if err := c.resolveKarmaRule(&rule); err != nil {
+		return err
 	}
 
 	sum := rule.CalculateChecksum()
@@ -737,12 +806,8 @@ func (c *GuildsSettingsController) updateGuildSettingsKrameRule(ctx *fiber.Ctx)
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	if rule.Action == sharedmodels.KarmaActionToggleRole {
-		role, err := fetch.FetchRole(c.session, guildID, rule.Argument)
-		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-		rule.Argument = role.ID
+	if err := c.resolveKarmaRule(&rule); err != nil {
+		return err
 	}
 
 	sum := rule.CalculateChecksum()
@@ -789,6 +854,95 @@ func (c *GuildsSettingsController) deleteGuildSettingsKrameRule(ctx *fiber.Ctx)
 	return ctx.JSON(models.Ok)
 }
 
+// @Summary Preview Guild Settings Karma Rule
+// @Description Validates the passed karma rule and returns the members whose current karma matches the rule.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body sharedmodels.KarmaRule true "The karma rule payload."
+// @Success 200 {array} models.GuildKarmaEntry "Wrapped in models.ListResponse"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/karma/rules/preview [post]
+func (c *GuildsSettingsController) previewGuildSettingsKarmaRule(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	var rule sharedmodels.KarmaRule
+	if err := ctx.BodyParser(&rule); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := rule.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	rule.GuildID = guildID
+	if err := c.resolveKarmaRule(&rule); err != nil {
+		return err
+	}
+
+	karmaList, err := c.karma.PreviewRule(rule)
+	if err != nil {
+		return err
+	}
+
+	results := make([]*models.GuildKarmaEntry, 0, len(karmaList))
+	for _, e := range karmaList {
+		member, err := c.state.Member(guildID, e.UserID)
+		if err != nil {
+			continue
+		}
+		results = append(results, &models.GuildKarmaEntry{
+			Member: models.MemberFromMember(member),
+			Value:  e.Value,
+		})
+	}
+
+	return ctx.JSON(models.NewListResponse(results))
+}
+
+// resolveKarmaRule resolves the roles and channels referenced
+// by the passed rule to their IDs and fails if any of them
+// does not exist on the guild of the rule.
+func (c *GuildsSettingsController) resolveKarmaRule(rule *sharedmodels.KarmaRule) error {
+	guildID := rule.GuildID
+
+	switch rule.Action {
+	case sharedmodels.KarmaActionToggleRole, sharedmodels.KarmaActionTempRole:
+		role, err := fetch.FetchRole(c.session, guildID, rule.Argument)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		rule.Argument = role.ID
+	case sharedmodels.KarmaActionAnnounce:
+		channel, err := fetch.FetchChannel(c.session, guildID, rule.ChannelID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		rule.ChannelID = channel.ID
+	}
+
+	for i, roleID := range rule.Roles {
+		role, err := fetch.FetchRole(c.session, guildID, roleID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		rule.Roles[i] = role.ID
+	}
+
+	for i, channelID := range rule.Channels {
+		channel, err := fetch.FetchChannel(c.session, guildID, channelID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		rule.Channels[i] = channel.ID
+	}
+
+	return nil
+}
+
 // @Summary Get Guild Log
 // @Description Returns a list of entries of the guild log.
 // @Tags Guild Settings
@@ -999,7 +1153,7 @@ func (c *GuildsSettingsController) postFlushGuildData(ctx *fiber.Ctx) (err error
 		return fiber.NewError(fiber.StatusBadRequest, "invalid validation")
 	}
 
-	if err = util.FlushAllGuildData(c.session, c.db, c.st, c.state, guildID); err != nil {
+	if err = util.FlushAllGuildData(c.session, c.db, c.st, c.bck, c.state, guildID); err != nil {
 		return
 	}
 
@@ -1219,3 +1373,494 @@ func (c *GuildsSettingsController) postGuildSettingsCodeExec(ctx *fiber.Ctx) (er
 
 	return ctx.JSON(state)
 }
+
+// @Summary Get Guild Settings Escalation Ladder
+// @Description Returns the escalation ladder applied to reported members of the guild.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {array} sharedmodels.EscalationStep
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/escalation [get]
+func (c *GuildsSettingsController) getGuildSettingsEscalation(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	ladder, err := c.db.GetGuildEscalationLadder(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+	if ladder == nil {
+		ladder = sharedmodels.EscalationLadder{}
+	}
+
+	return ctx.JSON(ladder)
+}
+
+// @Summary Set Guild Settings Escalation Ladder
+// @Description Replaces the escalation ladder applied to reported members of the guild.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body []sharedmodels.EscalationStep true "The escalation steps in ladder order."
+// @Success 200 {array} sharedmodels.EscalationStep
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/escalation [post]
+func (c *GuildsSettingsController) postGuildSettingsEscalation(ctx *fiber.Ctx) (err error) {
+	guildID := ctx.Params("guildid")
+
+	var ladder sharedmodels.EscalationLadder
+	if err = ctx.BodyParser(&ladder); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err = ladder.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err = c.db.SetGuildEscalationLadder(guildID, ladder); err != nil {
+		return
+	}
+
+	if ladder == nil {
+		ladder = sharedmodels.EscalationLadder{}
+	}
+
+	return ctx.JSON(ladder)
+}
+
+// @Summary Get Guild Settings Invite Block
+// @Description Returns the allowed guilds, the channel scope and the responses of the invite block.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {object} sharedmodels.InviteBlockSettings
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/inviteblock [get]
+func (c *GuildsSettingsController) getGuildSettingsInviteBlock(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	settings, err := c.db.GetGuildInviteBlockSettings(guildID)
+	if database.IsErrDatabaseNotFound(err) {
+		settings = sharedmodels.DefaultInviteBlockSettings
+	} else if err != nil {
+		return err
+	}
+
+	return ctx.JSON(normalizeInviteBlockSettings(settings))
+}
+
+// @Summary Set Guild Settings Invite Block
+// @Description Sets the allowed guilds, the channel scope and the responses of the invite block.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body sharedmodels.InviteBlockSettings true "The invite block settings."
+// @Success 200 {object} sharedmodels.InviteBlockSettings
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/inviteblock [post]
+func (c *GuildsSettingsController) postGuildSettingsInviteBlock(ctx *fiber.Ctx) (err error) {
+	guildID := ctx.Params("guildid")
+
+	var settings sharedmodels.InviteBlockSettings
+	if err = ctx.BodyParser(&settings); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err = settings.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	for i, channelID := range settings.Channels {
+		channel, err := fetch.FetchChannel(c.session, guildID, channelID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		settings.Channels[i] = channel.ID
+	}
+
+	if err = c.db.SetGuildInviteBlockSettings(guildID, settings); err != nil {
+		return
+	}
+
+	return ctx.JSON(normalizeInviteBlockSettings(settings))
+}
+
+// @Summary Get Guild Settings Automod Rules
+// @Description Returns the automod rules of the guild in evaluation order.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {array} sharedmodels.AutomodRule "Wrapped in models.ListResponse"
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/automod [get]
+func (c *GuildsSettingsController) getGuildSettingsAutomodRules(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	rules, err := c.db.GetGuildAutomodRules(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	return ctx.JSON(models.NewListResponse(rules))
+}
+
+// @Summary Create Guild Settings Automod Rule
+// @Description Creates an automod rule which is appended to the rules of the guild.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body sharedmodels.AutomodRule true "The automod rule payload."
+// @Success 200 {object} sharedmodels.AutomodRule
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/automod [post]
+func (c *GuildsSettingsController) createGuildSettingsAutomodRule(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	rule, err := c.parseAutomodRule(ctx, guildID)
+	if err != nil {
+		return err
+	}
+
+	rules, err := c.db.GetGuildAutomodRules(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+	if len(rules) >= sharedmodels.MaxAutomodRules {
+		return fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("a guild can not have more than %d automod rules", sharedmodels.MaxAutomodRules))
+	}
+
+	rule.ID = snowflakenodes.NodeAutomodRules.Generate()
+	rules = append(rules, rule)
+
+	if err = c.db.SetGuildAutomodRules(guildID, rules); err != nil {
+		return err
+	}
+
+	return ctx.JSON(rule)
+}
+
+// @Summary Update Guild Settings Automod Rule
+// @Description Updates an automod rule by ID.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param ruleid path string true "The ID of the rule."
+// @Param payload body sharedmodels.AutomodRule true "The automod rule update payload."
+// @Success 200 {object} sharedmodels.AutomodRule
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/automod/{ruleid} [post]
+func (c *GuildsSettingsController) updateGuildSettingsAutomodRule(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	id, err := snowflake.ParseString(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	rule, err := c.parseAutomodRule(ctx, guildID)
+	if err != nil {
+		return err
+	}
+	rule.ID = id
+
+	rules, err := c.db.GetGuildAutomodRules(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	i := findAutomodRule(rules, id)
+	if i == -1 {
+		return fiber.ErrNotFound
+	}
+	rules[i] = rule
+
+	if err = c.db.SetGuildAutomodRules(guildID, rules); err != nil {
+		return err
+	}
+
+	return ctx.JSON(rule)
+}
+
+// @Summary Remove Guild Settings Automod Rule
+// @Description Removes an automod rule by ID.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param ruleid path string true "The ID of the rule."
+// @Success 200 {object} models.State
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/automod/{ruleid} [delete]
+func (c *GuildsSettingsController) deleteGuildSettingsAutomodRule(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	id, err := snowflake.ParseString(ctx.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	rules, err := c.db.GetGuildAutomodRules(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	i := findAutomodRule(rules, id)
+	if i == -1 {
+		return fiber.ErrNotFound
+	}
+	rules = append(rules[:i], rules[i+1:]...)
+
+	if err = c.db.SetGuildAutomodRules(guildID, rules); err != nil {
+		return err
+	}
+
+	return ctx.JSON(models.Ok)
+}
+
+// @Summary Dry Run Guild Settings Automod Rule
+// @Description Evaluates the passed automod rule against the passed message content without applying any actions. Links are not resolved and the scope of the rule is ignored.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body models.AutomodDryRunRequest true "The rule and the message to evaluate."
+// @Success 200 {object} models.AutomodDryRunResponse
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/automod/dryrun [post]
+func (c *GuildsSettingsController) dryRunGuildSettingsAutomodRule(ctx *fiber.Ctx) error {
+	var req models.AutomodDryRunRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := req.Rule.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	now := time.Now()
+	msg := automod.Message{
+		Content: req.Content,
+		Time:    now,
+		History: make([]automod.HistoryEntry, 0, len(req.History)),
+	}
+	for _, content := range req.History {
+		msg.History = append(msg.History, automod.HistoryEntry{Content: content, Time: now})
+	}
+
+	ok, reason, err := automod.Match(req.Rule.Matcher, msg, nil)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	res := models.AutomodDryRunResponse{
+		Matched: ok,
+		Reason:  reason,
+		Actions: []sharedmodels.AutomodAction{},
+	}
+	if ok {
+		res.Actions = req.Rule.Actions
+	}
+
+	return ctx.JSON(res)
+}
+
+// parseAutomodRule parses and validates the rule passed in the
+// request body and resolves the channels and roles it references
+// to their IDs.
+func (c *GuildsSettingsController) parseAutomodRule(ctx *fiber.Ctx, guildID string) (rule sharedmodels.AutomodRule, err error) {
+	if err = ctx.BodyParser(&rule); err != nil {
+		return rule, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err = rule.Validate(); err != nil {
+		return rule, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	for i, channelID := range rule.Channels {
+		channel, err := fetch.FetchChannel(c.session, guildID, channelID)
+		if err != nil {
+			return rule, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		rule.Channels[i] = channel.ID
+	}
+
+	for i, roleID := range rule.ExemptRoles {
+		role, err := fetch.FetchRole(c.session, guildID, roleID)
+		if err != nil {
+			return rule, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		rule.ExemptRoles[i] = role.ID
+	}
+
+	return rule, nil
+}
+
+// @Summary Get Guild Settings Starboards
+// @Description Returns the list of starboards of the guild.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {array} sharedmodels.StarboardConfig "Wrapped in models.ListResponse"
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/starboards [get]
+func (c *GuildsSettingsController) getGuildSettingsStarboards(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	boards, err := c.db.GetStarboardConfigs(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+	if boards == nil {
+		boards = []sharedmodels.StarboardConfig{}
+	}
+
+	return ctx.JSON(models.NewListResponse(boards))
+}
+
+// @Summary Set Guild Settings Starboard
+// @Description Creates a starboard or replaces the starboard with the same name.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body sharedmodels.StarboardConfig true "The starboard payload."
+// @Success 200 {object} sharedmodels.StarboardConfig
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/starboards [post]
+func (c *GuildsSettingsController) postGuildSettingsStarboard(ctx *fiber.Ctx) (err error) {
+	guildID := ctx.Params("guildid")
+
+	var board sharedmodels.StarboardConfig
+	if err = ctx.BodyParser(&board); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err = board.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	board.GuildID = guildID
+	if err = c.resolveStarboard(&board); err != nil {
+		return
+	}
+
+	if err = c.db.SetStarboardConfig(board); err != nil {
+		return
+	}
+
+	return ctx.JSON(board)
+}
+
+// @Summary Remove Guild Settings Starboard
+// @Description Removes a starboard of the guild by name.
+// @Tags Guild Settings
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param name path string true "The name of the starboard."
+// @Success 200 {object} models.State
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/settings/starboards/{name} [delete]
+func (c *GuildsSettingsController) deleteGuildSettingsStarboard(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+	name := ctx.Params("name")
+
+	_, err := c.db.GetStarboardConfig(guildID, name)
+	if database.IsErrDatabaseNotFound(err) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = c.db.RemoveStarboardConfig(guildID, name); err != nil {
+		return err
+	}
+
+	return ctx.JSON(models.Ok)
+}
+
+// resolveStarboard resolves the channels referenced by the
+// passed starboard to their IDs and fails if any of them does
+// not exist on the guild of the starboard.
+func (c *GuildsSettingsController) resolveStarboard(board *sharedmodels.StarboardConfig) error {
+	guildID := board.GuildID
+
+	if board.ChannelID != "" {
+		channel, err := fetch.FetchChannel(c.session, guildID, board.ChannelID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		board.ChannelID = channel.ID
+	}
+
+	if board.NSFWChannelID != "" {
+		channel, err := fetch.FetchChannel(c.session, guildID, board.NSFWChannelID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if !channel.NSFW {
+			return fiber.NewError(fiber.StatusBadRequest, "nsfw channel must be marked as NSFW")
+		}
+		board.NSFWChannelID = channel.ID
+	}
+
+	for _, ids := range [][]string{board.Channels, board.ExcludedChannels} {
+		for i, channelID := range ids {
+			channel, err := fetch.FetchChannel(c.session, guildID, channelID)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+			ids[i] = channel.ID
+		}
+	}
+
+	return nil
+}
+
+func normalizeInviteBlockSettings(settings sharedmodels.InviteBlockSettings) sharedmodels.InviteBlockSettings {
+	if settings.AllowedGuilds == nil {
+		settings.AllowedGuilds = []string{}
+	}
+	if settings.Channels == nil {
+		settings.Channels = []string{}
+	}
+	return settings
+}
+
+func findAutomodRule(rules []sharedmodels.AutomodRule, id snowflake.ID) int {
+	for i, r := range rules {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
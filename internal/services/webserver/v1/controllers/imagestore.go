@@ -1,19 +1,22 @@
 package controllers
 
 import (
-	"io"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
-	"github.com/gabriel-vasile/mimetype"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sarulabs/di/v2"
 	"github.com/zekroTJA/shinpuru/internal/services/storage"
-	"github.com/zekroTJA/shinpuru/internal/util/imgstore"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
-	"github.com/zekroTJA/shinpuru/pkg/etag"
 )
 
+// imagePresignExpiry is the duration
+// presigned image URLs are valid.
+const imagePresignExpiry = 24 * time.Hour
+
 type ImagestoreController struct {
 	st storage.Storage
 }
@@ -35,29 +38,22 @@ func (c *ImagestoreController) getImage(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid snowflake ID")
 	}
 
-	reader, size, err := c.st.GetObject(static.StorageBucketImages, imageID.String())
+	_, err = c.st.StatObject(static.StorageBucketImages, imageID.String())
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return fiber.ErrNotFound
+	}
 	if err != nil {
-		return fiber.ErrBadRequest
+		return err
 	}
 
-	defer reader.Close()
-
-	img := new(imgstore.Image)
-
-	img.Size = int(size)
-	img.Data = make([]byte, img.Size)
-	_, err = reader.Read(img.Data)
-	if err != nil && err != io.EOF {
+	url, err := c.st.PresignGetObject(static.StorageBucketImages, imageID.String(), imagePresignExpiry, "")
+	if err != nil {
 		return err
 	}
 
-	img.MimeType = mimetype.Detect(img.Data).String()
-
-	etag := etag.Generate(img.Data, false)
-
-	ctx.Set("Content-Type", img.MimeType)
-	// 30 days browser caching
-	ctx.Set("Cache-Control", "public, max-age=2592000, immutable")
-	ctx.Set("ETag", etag)
-	return ctx.Send(img.Data)
+	// The redirect is cached for half of the lifetime
+	// of the presigned URL.
+	ctx.Set("Cache-Control", fmt.Sprintf("public, max-age=%d",
+		int(imagePresignExpiry.Seconds()/2)))
+	return ctx.Redirect(url, fiber.StatusFound)
 }
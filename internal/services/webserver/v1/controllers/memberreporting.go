@@ -77,7 +77,7 @@ func (c *MemberReportingController) postReport(ctx *fiber.Ctx) (err error) {
 		return err
 	}
 
-	if err = c.uploadAttachment(repReq.ReasonRequest); err != nil {
+	if err = uploadAttachment(c.st, repReq.ReasonRequest); err != nil {
 		return
 	}
 
@@ -129,7 +129,7 @@ func (c *MemberReportingController) postKick(ctx *fiber.Ctx) (err error) {
 		return err
 	}
 
-	if err = c.uploadAttachment(req); err != nil {
+	if err = uploadAttachment(c.st, req); err != nil {
 		return
 	}
 
@@ -189,7 +189,7 @@ func (c *MemberReportingController) postBan(ctx *fiber.Ctx) (err error) {
 		return err
 	}
 
-	if err = c.uploadAttachment(req); err != nil {
+	if err = uploadAttachment(c.st, req); err != nil {
 		return
 	}
 
@@ -246,7 +246,7 @@ func (c *MemberReportingController) postMute(ctx *fiber.Ctx) (err error) {
 		return err
 	}
 
-	if err = c.uploadAttachment(req); err != nil {
+	if err = uploadAttachment(c.st, req); err != nil {
 		return
 	}
 
@@ -321,7 +321,11 @@ func (c *MemberReportingController) postUnmute(ctx *fiber.Ctx) (err error) {
 
 // --- HELPERS ---
 
-func (c *MemberReportingController) uploadAttachment(repReq *models.ReasonRequest) (err error) {
+// uploadAttachment puts the attachment data or the image
+// downloaded from the attachment URL of the passed request
+// into the object storage and replaces the attachment of the
+// request with the ID of the stored image.
+func uploadAttachment(st storage.Storage, repReq *models.ReasonRequest) (err error) {
 	var img *imgstore.Image
 	if repReq.AttachmentData != "" {
 		img = new(imgstore.Image)
@@ -342,7 +346,7 @@ func (c *MemberReportingController) uploadAttachment(repReq *models.ReasonReques
 	}
 
 	if img != nil {
-		err = c.st.PutObject(static.StorageBucketImages, img.ID.String(),
+		err = st.PutObject(static.StorageBucketImages, img.ID.String(),
 			bytes.NewReader(img.Data), int64(img.Size), img.MimeType)
 		if err != nil {
 			return
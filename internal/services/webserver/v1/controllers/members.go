@@ -43,6 +43,7 @@ func (c *GuildMembersController) Setup(container di.Container, router fiber.Rout
 	router.Get("/:memberid/permissions/allowed", c.getMemberPermissionsAllowed)
 	router.Get("/:memberid/reports", c.getReports)
 	router.Get("/:memberid/reports/count", c.getReportsCount)
+	router.Get("/:memberid/karma/history", c.getKarmaHistory)
 	router.Get("/:memberid/unbanrequests", c.pmw.HandleWs(c.session, "sp.guild.mod.unbanrequests"), c.getMemberUnbanrequests)
 	router.Get("/:memberid/unbanrequests/count", c.pmw.HandleWs(c.session, "sp.guild.mod.unbanrequests"), c.getMemberUnbanrequestsCount)
 }
@@ -323,6 +324,48 @@ func (c *GuildMembersController) getReportsCount(ctx *fiber.Ctx) (err error) {
 	return ctx.JSON(&models.Count{Count: count})
 }
 
+// @Summary Get Guild Member Karma History
+// @Description Returns the karma changes received or caused by the given member, ordered from newest to oldest.
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param memberid path string true "The ID of the member."
+// @Param limit query int false "The amount of results returned." default(100) minimum(1) maxmimum(100)
+// @Param offset query int false "The amount of results to be skipped." default(0)
+// @Success 200 {array} sharedmodels.KarmaLedgerEntry "Wrapped in models.ListResponse"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/{memberid}/karma/history [get]
+func (c *GuildMembersController) getKarmaHistory(ctx *fiber.Ctx) (err error) {
+	uid := ctx.Locals("uid").(string)
+
+	guildID := ctx.Params("guildid")
+	memberID := ctx.Params("memberid")
+
+	limit, err := wsutil.GetQueryInt(ctx, "limit", 100, 1, 100)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	offset, err := wsutil.GetQueryInt(ctx, "offset", 0, 0, -1)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if memb, _ := c.session.GuildMember(guildID, uid); memb == nil {
+		return fiber.ErrNotFound
+	}
+
+	entries, err := c.db.GetKarmaLedger(guildID, memberID, offset, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(models.NewListResponse(entries))
+}
+
 // @Summary Get Guild Member Unban Requests
 // @Description Returns the list of unban requests of the given member
 // @Tags Members
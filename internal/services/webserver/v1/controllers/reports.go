@@ -1,14 +1,18 @@
 package controllers
 
 import (
+	"errors"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/bwmarrin/snowflake"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sarulabs/di/v2"
+	sharedmodels "github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/config"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/services/report"
+	"github.com/zekroTJA/shinpuru/internal/services/storage"
 	"github.com/zekroTJA/shinpuru/internal/services/webserver/v1/models"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 )
@@ -17,6 +21,7 @@ type ReportsController struct {
 	session *discordgo.Session
 	cfg     config.Provider
 	db      database.Database
+	st      storage.Storage
 	repSvc  *report.ReportService
 	pmw     *permissions.Permissions
 }
@@ -25,11 +30,14 @@ func (c *ReportsController) Setup(container di.Container, router fiber.Router) {
 	c.session = container.Get(static.DiDiscordSession).(*discordgo.Session)
 	c.cfg = container.Get(static.DiConfig).(config.Provider)
 	c.db = container.Get(static.DiDatabase).(database.Database)
+	c.st = container.Get(static.DiObjectStorage).(storage.Storage)
 	c.repSvc = container.Get(static.DiReport).(*report.ReportService)
 	c.pmw = container.Get(static.DiPermissions).(*permissions.Permissions)
 
 	router.Get("/:id", c.getReport)
 	router.Post("/:id/revoke", c.postRevoke)
+	router.Post("/:id/amend", c.postAmend)
+	router.Get("/:id/revisions", c.getRevisions)
 }
 
 // @Summary Get Report
@@ -118,3 +126,120 @@ func (c *ReportsController) postRevoke(ctx *fiber.Ctx) (err error) {
 
 	return ctx.JSON(models.Ok)
 }
+
+// @Summary Amend Report
+// @Description Amends the reason, attachment or timeout of a given report by ID.
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param id path string true "The report ID."
+// @Param payload body models.ReportAmendmentRequest true "The amendment payload."
+// @Success 200 {object} models.Report
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /reports/{id}/amend [post]
+func (c *ReportsController) postAmend(ctx *fiber.Ctx) (err error) {
+	uid := ctx.Locals("uid").(string)
+
+	rep, err := c.getPermittedReport(ctx, uid, "sp.guild.mod.report.edit")
+	if err != nil {
+		return
+	}
+
+	var req models.ReportAmendmentRequest
+	if err = ctx.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if ok, err := req.Validate(); !ok {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	am := sharedmodels.ReportAmendment{
+		Msg:           req.Reason,
+		Timeout:       req.Timeout,
+		RemoveTimeout: req.RemoveTimeout,
+	}
+
+	if req.AttachmentData != "" || (req.Attachment != nil && *req.Attachment != "") {
+		attachment := &models.ReasonRequest{AttachmentData: req.AttachmentData}
+		if req.Attachment != nil {
+			attachment.Attachment = *req.Attachment
+		}
+		if err = uploadAttachment(c.st, attachment); err != nil {
+			return
+		}
+		am.AttachmentURL = &attachment.Attachment
+	} else if req.Attachment != nil {
+		am.AttachmentURL = req.Attachment
+	}
+
+	rep, _, err = c.repSvc.AmendReport(rep, am, uid)
+	if errors.Is(err, report.ErrNoChanges) ||
+		errors.Is(err, report.ErrMuteTimeout) ||
+		errors.Is(err, report.ErrInvalidTimeout) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if rep.ID == 0 {
+		return err
+	}
+
+	return ctx.JSON(models.ReportFromReport(rep, c.cfg.Config().WebServer.PublicAddr))
+}
+
+// @Summary Get Report Revisions
+// @Description Returns the revisions of a given report by ID, ordered from oldest to newest.
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param id path string true "The report ID."
+// @Success 200 {array} sharedmodels.ReportRevision
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /reports/{id}/revisions [get]
+func (c *ReportsController) getRevisions(ctx *fiber.Ctx) (err error) {
+	uid := ctx.Locals("uid").(string)
+
+	rep, err := c.getPermittedReport(ctx, uid, "sp.guild.mod.report.list")
+	if err != nil {
+		return
+	}
+
+	revs, err := c.db.GetReportRevisions(rep.ID)
+	if err != nil {
+		return
+	}
+
+	return ctx.JSON(revs)
+}
+
+// --- HELPERS ---
+
+func (c *ReportsController) getPermittedReport(ctx *fiber.Ctx, uid, perm string) (rep sharedmodels.Report, err error) {
+	id, err := snowflake.ParseString(ctx.Params("id"))
+	if err != nil {
+		return rep, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	rep, err = c.db.GetReport(id)
+	if database.IsErrDatabaseNotFound(err) {
+		return rep, fiber.ErrNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	ok, _, err := c.pmw.CheckPermissions(c.session, rep.GuildID, uid, perm)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return rep, fiber.ErrForbidden
+	}
+
+	return
+}
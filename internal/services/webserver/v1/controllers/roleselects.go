@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sarulabs/di/v2"
+	sharedmodels "github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/permissions"
+	"github.com/zekroTJA/shinpuru/internal/services/webserver/v1/models"
+	"github.com/zekroTJA/shinpuru/internal/util"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
+	"github.com/zekrotja/dgrs"
+	"github.com/zekrotja/ken"
+)
+
+type GuildRoleSelectsController struct {
+	session *discordgo.Session
+	db      database.Database
+	pmw     *permissions.Permissions
+	st      *dgrs.State
+	ken     ken.IKen
+}
+
+func (c *GuildRoleSelectsController) Setup(container di.Container, router fiber.Router) {
+	c.session = container.Get(static.DiDiscordSession).(*discordgo.Session)
+	c.db = container.Get(static.DiDatabase).(database.Database)
+	c.pmw = container.Get(static.DiPermissions).(*permissions.Permissions)
+	c.st = container.Get(static.DiState).(*dgrs.State)
+	c.ken = container.Get(static.DiCommandHandler).(ken.IKen)
+
+	router.Get("", c.pmw.HandleWs(c.session, "sp.guild.mod.roleselect"), c.getRoleSelects)
+	router.Get("/:messageid", c.pmw.HandleWs(c.session, "sp.guild.mod.roleselect"), c.getRoleSelect)
+	router.Post("/:messageid", c.pmw.HandleWs(c.session, "sp.guild.mod.roleselect"), c.postRoleSelect)
+	router.Delete("/:messageid", c.pmw.HandleWs(c.session, "sp.guild.mod.roleselect"), c.deleteRoleSelect)
+}
+
+// @Summary Get Guild Role Selects
+// @Description Returns the role selections attached to messages of the guild.
+// @Tags Guild Role Selects
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {array} sharedmodels.RoleSelectGroup "Wrapped in models.ListResponse"
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/roleselects [get]
+func (c *GuildRoleSelectsController) getRoleSelects(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	rs, err := c.db.GetGuildRoleSelects(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	groups := sharedmodels.GroupRoleSelects(rs)
+	if groups == nil {
+		groups = []sharedmodels.RoleSelectGroup{}
+	}
+
+	return ctx.JSON(models.NewListResponse(groups))
+}
+
+// @Summary Get Guild Role Select
+// @Description Returns the role selection attached to the given message.
+// @Tags Guild Role Selects
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param messageid path string true "The ID of the message."
+// @Success 200 {object} sharedmodels.RoleSelectGroup
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/roleselects/{messageid} [get]
+func (c *GuildRoleSelectsController) getRoleSelect(ctx *fiber.Ctx) error {
+	g, err := c.getGroup(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(g)
+}
+
+// @Summary Update Guild Role Select
+// @Description Updates the roles and settings of the role selection attached to the given message. The message is edited in place.
+// @Tags Guild Role Selects
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param messageid path string true "The ID of the message."
+// @Param payload body sharedmodels.RoleSelectGroup true "The role select group."
+// @Success 200 {object} sharedmodels.RoleSelectGroup
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/roleselects/{messageid} [post]
+func (c *GuildRoleSelectsController) postRoleSelect(ctx *fiber.Ctx) (err error) {
+	current, err := c.getGroup(ctx)
+	if err != nil {
+		return
+	}
+
+	var payload sharedmodels.RoleSelectGroup
+	if err = ctx.BodyParser(&payload); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	g := payload
+	g.GuildID = current.GuildID
+	g.ChannelID = current.ChannelID
+	g.MessageID = current.MessageID
+
+	if err = g.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	for _, r := range g.Roles {
+		if err = c.checkRole(g.GuildID, r.RoleID); err != nil {
+			return
+		}
+	}
+	for _, id := range g.RequiredRoles {
+		if err = c.checkRole(g.GuildID, id); err != nil {
+			return
+		}
+	}
+
+	// Handlers of roles which have been removed from
+	// the group would be left over otherwise.
+	if err = util.DetachRoleSelect(c.ken.Components(), c.session, current, false); err != nil {
+		return
+	}
+
+	b := c.ken.Components().Add(g.MessageID, g.ChannelID)
+	if err = util.AttachRoleSelect(b, g, c.st); err != nil {
+		if discordutil.IsErrCode(err, discordgo.ErrCodeUnknownMessage) {
+			return fiber.ErrNotFound
+		}
+		return
+	}
+
+	if err = c.db.SetRoleSelects(g.GuildID, g.ChannelID, g.MessageID, g.RoleSelects()); err != nil {
+		return
+	}
+
+	return ctx.JSON(g)
+}
+
+// @Summary Delete Guild Role Select
+// @Description Removes the role selection from the given message.
+// @Tags Guild Role Selects
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param messageid path string true "The ID of the message."
+// @Success 200 {object} models.Status
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/roleselects/{messageid} [delete]
+func (c *GuildRoleSelectsController) deleteRoleSelect(ctx *fiber.Ctx) (err error) {
+	g, err := c.getGroup(ctx)
+	if err != nil {
+		return
+	}
+
+	err = util.DetachRoleSelect(c.ken.Components(), c.session, g, true)
+	if err != nil && !discordutil.IsErrCode(err, discordgo.ErrCodeUnknownMessage) {
+		return
+	}
+
+	if err = c.db.RemoveRoleSelect(g.GuildID, g.ChannelID, g.MessageID); err != nil {
+		return
+	}
+
+	return ctx.JSON(models.Ok)
+}
+
+// --- HELPERS ---
+
+func (c *GuildRoleSelectsController) getGroup(ctx *fiber.Ctx) (g sharedmodels.RoleSelectGroup, err error) {
+	guildID := ctx.Params("guildid")
+	messageID := ctx.Params("messageid")
+
+	rs, err := c.db.GetGuildRoleSelects(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return
+	}
+
+	for _, g = range sharedmodels.GroupRoleSelects(rs) {
+		if g.MessageID == messageID {
+			return g, nil
+		}
+	}
+
+	return g, fiber.ErrNotFound
+}
+
+func (c *GuildRoleSelectsController) checkRole(guildID, roleID string) error {
+	role, err := c.st.Role(guildID, roleID)
+	if err != nil || role == nil || role.ID == "" {
+		return fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("role %s does not exist on this guild", roleID))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/services/storage"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+)
+
+// StorageController serves the objects of the local
+// file storage via presigned URLs created by
+// storage.File.
+type StorageController struct {
+	st *storage.File
+}
+
+func (c *StorageController) Setup(container di.Container, router fiber.Router) {
+	st, ok := container.Get(static.DiObjectStorage).(*storage.File)
+	if !ok {
+		return
+	}
+	c.st = st
+
+	router.Get("/:bucket/:object", c.getObject)
+}
+
+func (c *StorageController) getObject(ctx *fiber.Ctx) error {
+	bucket := ctx.Params("bucket")
+	object := ctx.Params("object")
+	fileName := ctx.Query("filename")
+
+	expires, err := strconv.ParseInt(ctx.Query("expires"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid expiration time")
+	}
+
+	err = c.st.VerifySignature(bucket, object, expires, fileName, ctx.Query("signature"))
+	if errors.Is(err, storage.ErrInvalidSignature) || errors.Is(err, storage.ErrSignatureExpired) {
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := c.st.StatObject(bucket, object)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	reader, size, err := c.st.GetObject(bucket, object)
+	if err != nil {
+		return err
+	}
+
+	maxAge := time.Until(time.Unix(expires, 0)) / time.Second
+	ctx.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	ctx.Set("Content-Type", info.ContentType)
+	if fileName != "" {
+		ctx.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	}
+	return ctx.SendStream(reader, int(size))
+}
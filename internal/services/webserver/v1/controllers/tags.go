@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/bwmarrin/snowflake"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/permissions"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/services/webserver/v1/models"
+	"github.com/zekroTJA/shinpuru/internal/util/snowflakenodes"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/internal/util/tag"
+	"github.com/zekroTJA/shinpuru/pkg/fetch"
+)
+
+type GuildTagsController struct {
+	session *discordgo.Session
+	db      database.Database
+	pmw     *permissions.Permissions
+	tp      timeprovider.Provider
+}
+
+func (c *GuildTagsController) Setup(container di.Container, router fiber.Router) {
+	c.session = container.Get(static.DiDiscordSession).(*discordgo.Session)
+	c.db = container.Get(static.DiDatabase).(database.Database)
+	c.pmw = container.Get(static.DiPermissions).(*permissions.Permissions)
+	c.tp = container.Get(static.DiTimeProvider).(timeprovider.Provider)
+
+	router.Get("", c.pmw.HandleWs(c.session, "sp.chat.tag"), c.getTags)
+	router.Post("", c.pmw.HandleWs(c.session, "sp.chat.tag"), c.postTag)
+	router.Get("/export", c.pmw.HandleWs(c.session, "sp.chat.tag"), c.getExport)
+	router.Post("/import", c.pmw.HandleWs(c.session, "sp.chat.tag"), c.postImport)
+	router.Get("/:id", c.pmw.HandleWs(c.session, "sp.chat.tag"), c.getTag)
+	router.Post("/:id", c.pmw.HandleWs(c.session, "sp.chat.tag"), c.updateTag)
+	router.Delete("/:id", c.pmw.HandleWs(c.session, "sp.chat.tag"), c.deleteTag)
+}
+
+// @Summary Get Guild Tags
+// @Description Returns the tags of the guild sorted by their number of uses.
+// @Tags Guild Tags
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {array} tag.Tag "Wrapped in models.ListResponse"
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/tags [get]
+func (c *GuildTagsController) getTags(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	tags, err := c.db.GetGuildTags(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+	if tags == nil {
+		tags = []tag.Tag{}
+	}
+
+	return ctx.JSON(models.NewListResponse(tags))
+}
+
+// @Summary Get Guild Tag
+// @Description Returns a single tag of the guild by ID.
+// @Tags Guild Tags
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param tagid path string true "The ID of the tag."
+// @Success 200 {object} tag.Tag
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/tags/{tagid} [get]
+func (c *GuildTagsController) getTag(ctx *fiber.Ctx) error {
+	tg, err := c.getGuildTag(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(tg)
+}
+
+// @Summary Create Guild Tag
+// @Description Creates a new tag. Only the ident, content, aliases and roles of the payload are used.
+// @Tags Guild Tags
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param payload body tag.Tag true "The tag payload."
+// @Success 200 {object} tag.Tag
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Router /guilds/{id}/tags [post]
+func (c *GuildTagsController) postTag(ctx *fiber.Ctx) (err error) {
+	guildID := ctx.Params("guildid")
+	uid := ctx.Locals("uid").(string)
+
+	if err = c.checkSubPerm(guildID, uid, "create"); err != nil {
+		return
+	}
+
+	var payload tag.Tag
+	if err = ctx.BodyParser(&payload); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	now := c.tp.Now()
+	tg := tag.Tag{
+		ID:        snowflakenodes.NodeTags.Generate(),
+		Ident:     payload.Ident,
+		CreatorID: uid,
+		GuildID:   guildID,
+		Content:   payload.Content,
+		Created:   now,
+		LastEdit:  now,
+		Aliases:   payload.Aliases,
+		Roles:     payload.Roles,
+	}
+
+	if err = c.checkTag(&tg); err != nil {
+		return
+	}
+
+	if err = c.db.AddTag(tg); err != nil {
+		return
+	}
+
+	return ctx.JSON(tg)
+}
+
+// @Summary Update Guild Tag
+// @Description Updates the ident, content, aliases and roles of a tag. Editing tags of other users requires the permission sp.chat.tag.edit.
+// @Tags Guild Tags
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param tagid path string true "The ID of the tag."
+// @Param payload body tag.Tag true "The tag payload."
+// @Success 200 {object} tag.Tag
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/tags/{tagid} [post]
+func (c *GuildTagsController) updateTag(ctx *fiber.Ctx) (err error) {
+	guildID := ctx.Params("guildid")
+	uid := ctx.Locals("uid").(string)
+
+	tg, err := c.getGuildTag(ctx)
+	if err != nil {
+		return
+	}
+
+	if tg.CreatorID != uid {
+		if err = c.checkSubPerm(guildID, uid, "edit"); err != nil {
+			return
+		}
+	}
+
+	var payload tag.Tag
+	if err = ctx.BodyParser(&payload); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	tg.Ident = payload.Ident
+	tg.Content = payload.Content
+	tg.Aliases = payload.Aliases
+	tg.Roles = payload.Roles
+	tg.LastEdit = c.tp.Now()
+
+	if err = c.checkTag(&tg); err != nil {
+		return
+	}
+
+	if err = c.db.EditTag(tg); err != nil {
+		return
+	}
+
+	return ctx.JSON(tg)
+}
+
+// @Summary Delete Guild Tag
+// @Description Deletes a tag. Deleting tags of other users requires the permission sp.chat.tag.delete.
+// @Tags Guild Tags
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param tagid path string true "The ID of the tag."
+// @Success 200 {object} models.State
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/tags/{tagid} [delete]
+func (c *GuildTagsController) deleteTag(ctx *fiber.Ctx) (err error) {
+	guildID := ctx.Params("guildid")
+	uid := ctx.Locals("uid").(string)
+
+	tg, err := c.getGuildTag(ctx)
+	if err != nil {
+		return
+	}
+
+	if tg.CreatorID != uid {
+		if err = c.checkSubPerm(guildID, uid, "delete"); err != nil {
+			return
+		}
+	}
+
+	if err = c.db.DeleteTag(tg.ID); err != nil {
+		return
+	}
+
+	return ctx.JSON(models.Ok)
+}
+
+// @Summary Export Guild Tags
+// @Description Returns all tags of the guild as JSON file which can be imported again.
+// @Tags Guild Tags
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {array} tag.Tag
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/tags/export [get]
+func (c *GuildTagsController) getExport(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	tags, err := c.db.GetGuildTags(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+	if tags == nil {
+		tags = []tag.Tag{}
+	}
+
+	ctx.Attachment(fmt.Sprintf("tags-%s.json", guildID))
+	return ctx.JSON(tags)
+}
+
+// @Summary Import Guild Tags
+// @Description Imports a list of tags as exported before. Tags with the same name as an existing tag are skipped unless overwrite is set, which requires the permission sp.chat.tag.edit. Tags with names conflicting with other tags are skipped as well.
+// @Tags Guild Tags
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param overwrite query bool false "Overwrite existing tags with the same name."
+// @Param payload body []tag.Tag true "The tags to be imported."
+// @Success 200 {object} tag.ImportResult
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Router /guilds/{id}/tags/import [post]
+func (c *GuildTagsController) postImport(ctx *fiber.Ctx) (err error) {
+	guildID := ctx.Params("guildid")
+	uid := ctx.Locals("uid").(string)
+	overwrite := ctx.Query("overwrite") == "true"
+
+	if err = c.checkSubPerm(guildID, uid, "create"); err != nil {
+		return
+	}
+	if overwrite {
+		if err = c.checkSubPerm(guildID, uid, "edit"); err != nil {
+			return
+		}
+	}
+
+	var imported []tag.Tag
+	if err = ctx.BodyParser(&imported); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	for i := range imported {
+		if err = c.resolveRoles(guildID, imported[i].Roles); err != nil {
+			return
+		}
+	}
+
+	existing, err := c.db.GetGuildTags(guildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return
+	}
+
+	res, err := tag.PlanImport(existing, imported, overwrite)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	now := c.tp.Now()
+	for i, tg := range res.Created {
+		tg.ID = snowflakenodes.NodeTags.Generate()
+		tg.GuildID = guildID
+		tg.CreatorID = uid
+		tg.Created = now
+		tg.LastEdit = now
+		if err = c.db.AddTag(tg); err != nil {
+			return
+		}
+		res.Created[i] = tg
+	}
+	for i, tg := range res.Updated {
+		tg.LastEdit = now
+		if err = c.db.EditTag(tg); err != nil {
+			return
+		}
+		res.Updated[i] = tg
+	}
+
+	return ctx.JSON(res)
+}
+
+// getGuildTag returns the tag specified by the tag ID
+// parameter if it exists on the guild of the request.
+func (c *GuildTagsController) getGuildTag(ctx *fiber.Ctx) (tg tag.Tag, err error) {
+	id, err := snowflake.ParseString(ctx.Params("id"))
+	if err != nil {
+		return tg, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	tg, err = c.db.GetTagByID(id)
+	if database.IsErrDatabaseNotFound(err) || (err == nil && tg.GuildID != ctx.Params("guildid")) {
+		return tg, fiber.ErrNotFound
+	}
+
+	return
+}
+
+// checkSubPerm returns a forbidden error when the user does
+// not have the passed explicit sub permission of sp.chat.tag.
+func (c *GuildTagsController) checkSubPerm(guildID, userID, subDN string) error {
+	ok, override, err := c.pmw.CheckPermissions(c.session, guildID, userID, "!sp.chat.tag."+subDN)
+	if err != nil {
+		return err
+	}
+	if !ok && !override {
+		return fiber.ErrForbidden
+	}
+	return nil
+}
+
+// checkTag normalizes and validates the passed tag and
+// resolves its roles. A bad request error is returned if
+// any name of the tag is already used by another tag.
+func (c *GuildTagsController) checkTag(tg *tag.Tag) error {
+	tg.Normalize()
+	if err := tg.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := c.resolveRoles(tg.GuildID, tg.Roles); err != nil {
+		return err
+	}
+
+	tags, err := c.db.GetGuildTags(tg.GuildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+	for _, other := range tags {
+		if name := tg.ConflictsWith(other); name != "" {
+			return fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("name '%s' is already used by tag '%s'", name, other.Ident))
+		}
+	}
+
+	return nil
+}
+
+// resolveRoles resolves the passed roles to their IDs in
+// place and fails if any of them does not exist on the guild.
+func (c *GuildTagsController) resolveRoles(guildID string, roleIDs []string) error {
+	for i, roleID := range roleIDs {
+		role, err := fetch.FetchRole(c.session, guildID, roleID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		roleIDs[i] = role.ID
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/twitchnotify"
+)
+
+type TwitchController struct {
+	tnw *twitchnotify.NotifyWorker
+}
+
+func (c *TwitchController) Setup(container di.Container, router fiber.Router) {
+	c.tnw = container.Get(static.DiTwitchNotifyWorker).(*twitchnotify.NotifyWorker)
+
+	router.Post("/eventsub", c.postEventSub)
+}
+
+// postEventSub receives the Twitch EventSub webhook
+// requests for stream notifications.
+func (c *TwitchController) postEventSub(ctx *fiber.Ctx) error {
+	if c.tnw == nil {
+		return fiber.ErrNotFound
+	}
+
+	// Header values reference fasthttp's request buffer, which
+	// is re-used after the handler returns, so they are copied.
+	challenge, err := c.tnw.HandleEventSub(twitchnotify.EventSubMessage{
+		ID:        utils.CopyString(ctx.Get(twitchnotify.HeaderMessageID)),
+		Type:      utils.CopyString(ctx.Get(twitchnotify.HeaderMessageType)),
+		Timestamp: utils.CopyString(ctx.Get(twitchnotify.HeaderMessageTimestamp)),
+		Signature: utils.CopyString(ctx.Get(twitchnotify.HeaderMessageSignature)),
+		Body:      ctx.Body(),
+	})
+
+	switch err {
+	case nil:
+	case twitchnotify.ErrEventSubDisabled:
+		return fiber.ErrNotFound
+	case twitchnotify.ErrInvalidSignature, twitchnotify.ErrMessageExpired:
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if challenge != "" {
+		ctx.Set(fiber.HeaderContentType, fiber.MIMETextPlain)
+		return ctx.SendString(challenge)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"strings"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/services/birthday"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/services/webserver/v1/models"
@@ -29,6 +32,8 @@ func (c *UsersettingsController) Setup(container di.Container, router fiber.Rout
 	router.Post("/ota", c.postOTA)
 	router.Get("/privacy", c.getPrivacy)
 	router.Post("/privacy", c.postPrivacy)
+	router.Get("/timezone", c.getTimezone)
+	router.Post("/timezone", c.postTimezone)
 	router.Post("/flush", c.postFlush)
 }
 
@@ -133,6 +138,59 @@ func (c *UsersettingsController) postPrivacy(ctx *fiber.Ctx) error {
 	return ctx.JSON(res)
 }
 
+// @Summary Get Timezone Usersettings
+// @Description Returns the timezone of the user used to announce birthdays on their local date.
+// @Tags User Settings
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.UsersettingsTimezone
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /usersettings/timezone [get]
+func (c *UsersettingsController) getTimezone(ctx *fiber.Ctx) error {
+	uid := ctx.Locals("uid").(string)
+
+	tz, err := c.db.GetUserTimezone(uid)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	return ctx.JSON(&models.UsersettingsTimezone{Timezone: tz})
+}
+
+// @Summary Update Timezone Usersettings
+// @Description Update the timezone of the user. Either an IANA timezone name or an offset to UTC (i.e. +2 or -03:30) can be passed. An empty value resets the timezone.
+// @Tags User Settings
+// @Accept json
+// @Produce json
+// @Param payload body models.UsersettingsTimezone true "The timezone settings payload."
+// @Success 200 {object} models.UsersettingsTimezone
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /usersettings/timezone [post]
+func (c *UsersettingsController) postTimezone(ctx *fiber.Ctx) error {
+	uid := ctx.Locals("uid").(string)
+
+	var err error
+
+	var res models.UsersettingsTimezone
+	if err = ctx.BodyParser(&res); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	res.Timezone = strings.TrimSpace(res.Timezone)
+	if _, err = birthday.ParseTimezone(res.Timezone); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid timezone")
+	}
+
+	if err = c.db.SetUserTimezone(uid, res.Timezone); err != nil {
+		return err
+	}
+
+	return ctx.JSON(res)
+}
+
 // @Summary FLush all user data
 // @Description Flush all user data.
 // @Tags User Settings
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sarulabs/di/v2"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/permissions"
+	"github.com/zekroTJA/shinpuru/internal/services/webserver/v1/models"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+)
+
+type GuildVotesController struct {
+	session *discordgo.Session
+	db      database.Database
+	pmw     *permissions.Permissions
+}
+
+func (c *GuildVotesController) Setup(container di.Container, router fiber.Router) {
+	c.session = container.Get(static.DiDiscordSession).(*discordgo.Session)
+	c.db = container.Get(static.DiDatabase).(database.Database)
+	c.pmw = container.Get(static.DiPermissions).(*permissions.Permissions)
+
+	router.Get("", c.pmw.HandleWs(c.session, "sp.chat.vote"), c.getVotes)
+	router.Get("/:id", c.pmw.HandleWs(c.session, "sp.chat.vote"), c.getVote)
+	router.Get("/:id/export", c.pmw.HandleWs(c.session, "sp.chat.vote"), c.getExport)
+}
+
+// @Summary Get Guild Votes
+// @Description Returns the running and recently closed votes of the guild with their results.
+// @Tags Guild Votes
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Success 200 {array} vote.Export "Wrapped in models.ListResponse"
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/votes [get]
+func (c *GuildVotesController) getVotes(ctx *fiber.Ctx) error {
+	guildID := ctx.Params("guildid")
+
+	votes, err := c.db.GetVotes()
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	res := make([]vote.Export, 0, len(votes))
+	for _, v := range votes {
+		if v.GuildID == guildID {
+			res = append(res, v.Export())
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID > res[j].ID
+	})
+
+	return ctx.JSON(models.NewListResponse(res))
+}
+
+// @Summary Get Guild Vote
+// @Description Returns a single vote of the guild with its results and ballots.
+// @Tags Guild Votes
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param voteid path string true "The ID of the vote."
+// @Success 200 {object} vote.Export
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/votes/{voteid} [get]
+func (c *GuildVotesController) getVote(ctx *fiber.Ctx) error {
+	v, err := c.getGuildVote(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(v.Export())
+}
+
+// @Summary Export Guild Vote
+// @Description Returns the results and ballots of a vote as JSON file download. Voters of ballots are pseudonyms which are unique per vote and not user IDs.
+// @Tags Guild Votes
+// @Accept json
+// @Produce json
+// @Param id path string true "The ID of the guild."
+// @Param voteid path string true "The ID of the vote."
+// @Success 200 {object} vote.Export
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Router /guilds/{id}/votes/{voteid}/export [get]
+func (c *GuildVotesController) getExport(ctx *fiber.Ctx) error {
+	v, err := c.getGuildVote(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx.Attachment(fmt.Sprintf("vote-%s.json", v.ID))
+	return ctx.JSON(v.Export())
+}
+
+// --- HELPERS ---
+
+func (c *GuildVotesController) getGuildVote(ctx *fiber.Ctx) (v vote.Vote, err error) {
+	guildID := ctx.Params("guildid")
+	id := ctx.Params("id")
+
+	// Running votes are preferred because their ticks
+	// might not have been persisted yet.
+	v, ok := vote.VotesRunning.Get(id)
+	if !ok {
+		v, err = c.db.GetVote(id)
+		if database.IsErrDatabaseNotFound(err) {
+			err = fiber.ErrNotFound
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if v.GuildID != guildID {
+		err = fiber.ErrNotFound
+	}
+	return
+}
@@ -190,6 +190,26 @@ type ReportRequest struct {
 	Type sharedmodels.ReportType `json:"type"`
 }
 
+// ReportAmendmentRequest is the request model to
+// amend an existing report. Values which are not
+// set are left unchanged.
+type ReportAmendmentRequest struct {
+	Reason         *string    `json:"reason"`
+	Timeout        *time.Time `json:"timeout"`
+	RemoveTimeout  bool       `json:"remove_timeout"`
+	Attachment     *string    `json:"attachment"`
+	AttachmentData string     `json:"attachment_data"`
+}
+
+// KarmaRevertRequest is the request model to
+// revert all karma given by a member between
+// From and To.
+type KarmaRevertRequest struct {
+	ExecutorID string    `json:"executor_id"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+}
+
 // InviteSettingsRequest is the request model
 // for setting the global invite setting.
 type InviteSettingsRequest struct {
@@ -302,6 +322,9 @@ type KarmaSettings struct {
 	EmotesDecrease []string `json:"emotes_decrease"`
 	Tokens         int      `json:"tokens"`
 	Penalty        bool     `json:"penalty"`
+
+	Decay   sharedmodels.KarmaDecay         `json:"decay"`
+	Farming sharedmodels.KarmaFarmingLimits `json:"farming"`
 }
 
 // AntiraidSettings wraps settings properties for
@@ -311,6 +334,9 @@ type AntiraidSettings struct {
 	RegenerationPeriod int  `json:"regeneration_period"`
 	Burst              int  `json:"burst"`
 	Verification       bool `json:"verification"`
+
+	Signals  sharedmodels.AntiraidSignals  `json:"signals"`
+	Lockdown sharedmodels.AntiraidLockdown `json:"lockdown"`
 }
 
 type UsersettingsOTA struct {
@@ -321,6 +347,10 @@ type UsersettingsPrivacy struct {
 	StarboardOptout bool `json:"starboard_optout"`
 }
 
+type UsersettingsTimezone struct {
+	Timezone string `json:"timezone"`
+}
+
 // StarboardEntryResponse wraps a starboard entry
 // as response model containing hydrated information
 // of the author.
@@ -409,6 +439,22 @@ type RichUnbanRequest struct {
 	Processor *FlatUser `json:"processor"`
 }
 
+// AutomodDryRunRequest contains an automod rule and a
+// message the rule is evaluated against. History contains
+// previous messages of the author, which are treated as
+// sent just before the message.
+type AutomodDryRunRequest struct {
+	Rule    sharedmodels.AutomodRule `json:"rule"`
+	Content string                   `json:"content"`
+	History []string                 `json:"history"`
+}
+
+type AutomodDryRunResponse struct {
+	Matched bool                         `json:"matched"`
+	Reason  string                       `json:"reason"`
+	Actions []sharedmodels.AutomodAction `json:"actions"`
+}
+
 // Validate returns true, when the ReasonRequest is valid.
 // Otherwise, false is returned and an error response is
 // returned.
@@ -424,6 +470,36 @@ func (req *ReasonRequest) Validate(acceptEmptyReason bool) (bool, error) {
 	return true, nil
 }
 
+// Validate returns true, when the ReportAmendmentRequest
+// is valid. Otherwise, false is returned and an error
+// response is returned.
+func (req *ReportAmendmentRequest) Validate() (bool, error) {
+	if req.Reason != nil && len(*req.Reason) < 3 {
+		return false, errors.New("invalid argument")
+	}
+
+	if req.Attachment != nil && *req.Attachment != "" && !imgstore.ImgUrlSRx.MatchString(*req.Attachment) {
+		return false, fmt.Errorf("attachment must be a valid url to a file with type of png, jpg, jpeg, gif, ico, tiff, img, bmp or mp4")
+	}
+
+	return true, nil
+}
+
+// Validate returns true, when the KarmaRevertRequest
+// is valid. Otherwise, false is returned and an error
+// response is returned.
+func (req *KarmaRevertRequest) Validate() (bool, error) {
+	if req.ExecutorID == "" {
+		return false, errors.New("executor_id must be set")
+	}
+
+	if !req.From.Before(req.To) {
+		return false, errors.New("from must be before to")
+	}
+
+	return true, nil
+}
+
 // GuildFromGuild returns a Guild model from the passed
 // discordgo.Guild g, discordgo.Member m and cmdHandler.
 func GuildFromGuild(g *discordgo.Guild, m *discordgo.Member, db database.Database, botOwnerID string) (ng *Guild, err error) {
@@ -104,6 +104,10 @@ func (r *Router) Route(router fiber.Router) {
 	new(controllers.GuildsController).Setup(r.container, router.Group("/guilds"))
 	new(controllers.MemberReportingController).Setup(r.container, router.Group("/guilds/:guildid/:memberid"))
 	new(controllers.GuildBackupsController).Setup(r.container, router.Group("/guilds/:guildid/backups"))
+	new(controllers.GuildTagsController).Setup(r.container, router.Group("/guilds/:guildid/tags"))
+	new(controllers.GuildVotesController).Setup(r.container, router.Group("/guilds/:guildid/votes"))
+	new(controllers.GuildRoleSelectsController).Setup(r.container, router.Group("/guilds/:guildid/roleselects"))
+	new(controllers.GuildBirthdaysController).Setup(r.container, router.Group("/guilds/:guildid/birthdays"))
 	new(controllers.GuildsSettingsController).Setup(r.container, router.Group("/guilds/:guildid/settings"))
 	new(controllers.GuildMembersController).Setup(r.container, router.Group("/guilds/:guildid"))
 	new(controllers.ChannelController).Setup(r.container, router.Group("/channels/:guildid"))
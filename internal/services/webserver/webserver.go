@@ -76,7 +76,9 @@ func New(container di.Container) (ws *WebServer, err error) {
 	})
 
 	new(controllers.ImagestoreController).Setup(ws.container, ws.app.Group("/imagestore"))
+	new(controllers.StorageController).Setup(ws.container, ws.app.Group("/storage"))
 	new(controllers.InviteController).Setup(ws.container, ws.app.Group("/invite"))
+	new(controllers.TwitchController).Setup(ws.container, ws.app.Group("/twitch"))
 	ws.registerRouter(new(v1.Router), []string{"/api/v1", "/api"}, rlh)
 
 	fs, err := wsutil.GetFS()
@@ -0,0 +1,358 @@
+package slashcommands
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/joinlog"
+	"github.com/zekroTJA/shinpuru/internal/services/lockdown"
+	"github.com/zekroTJA/shinpuru/internal/services/permissions"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/acceptmsg/v2"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
+	"github.com/zekrotja/ken"
+)
+
+// maxListedJoinlogEntries is the maximum number of join log
+// entries listed in the confirmation of a join log action.
+const maxListedJoinlogEntries = 20
+
+type Antiraid struct{}
+
+var (
+	_ ken.SlashCommand        = (*Antiraid)(nil)
+	_ permissions.PermCommand = (*Antiraid)(nil)
+)
+
+func (c *Antiraid) Name() string {
+	return "antiraid"
+}
+
+func (c *Antiraid) Description() string {
+	return "Manage the antiraid system of the guild."
+}
+
+func (c *Antiraid) Version() string {
+	return "1.0.0"
+}
+
+func (c *Antiraid) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *Antiraid) Options() []*discordgo.ApplicationCommandOption {
+	filterOptions := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "max_account_age",
+			Description: "Only members whose accounts are younger than the given number of days.",
+			MinValue:    func() *float64 { v := 1.0; return &v }(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "name_pattern",
+			Description: "Only members whose user tags match the given regular expression.",
+		},
+	}
+
+	reasonOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "reason",
+		Description: "The reason of the reports.",
+		Required:    true,
+	}
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Name:        "lockdown",
+			Description: "Manage the lockdown mode of the guild.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "start",
+					Description: "Start a lockdown as configured in the antiraid settings.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "end",
+					Description: "End the lockdown and roll back all of its changes.",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Name:        "joinlog",
+			Description: "Apply actions to the members in the antiraid join log.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "ban",
+					Description: "Ban all or the filtered members of the join log.",
+					Options:     append([]*discordgo.ApplicationCommandOption{reasonOption}, filterOptions...),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "kick",
+					Description: "Kick all or the filtered members of the join log.",
+					Options:     append([]*discordgo.ApplicationCommandOption{reasonOption}, filterOptions...),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "verify",
+					Description: "Verify all or the filtered members of the join log.",
+					Options:     filterOptions,
+				},
+			},
+		},
+	}
+}
+
+func (c *Antiraid) Domain() string {
+	return "sp.guild.config.antiraid"
+}
+
+func (c *Antiraid) SubDomains() []permissions.SubPermission {
+	return nil
+}
+
+func (c *Antiraid) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return
+	}
+
+	err = ctx.HandleSubCommands(
+		ken.SubCommandGroup{Name: "lockdown", SubHandler: []ken.CommandHandler{
+			ken.SubCommandHandler{Name: "start", Run: c.lockdownStart},
+			ken.SubCommandHandler{Name: "end", Run: c.lockdownEnd},
+		}},
+		ken.SubCommandGroup{Name: "joinlog", SubHandler: []ken.CommandHandler{
+			ken.SubCommandHandler{Name: "ban", Run: c.joinlogBan},
+			ken.SubCommandHandler{Name: "kick", Run: c.joinlogKick},
+			ken.SubCommandHandler{Name: "verify", Run: c.joinlogVerify},
+		}},
+	)
+
+	return
+}
+
+func (c *Antiraid) lockdownStart(ctx ken.SubCommandContext) (err error) {
+	ld := ctx.Get(static.DiLockdown).(lockdown.Provider)
+
+	state, err := ld.Start(ctx.GetEvent().GuildID, ctx.User().ID)
+	if err == lockdown.ErrActive {
+		return ctx.FollowUpError("A lockdown is already active.", "").Send().Error
+	}
+	if err != nil {
+		return
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Title: "🔒 Lockdown Started",
+		Color: static.ColorEmbedOrange,
+		Description: fmt.Sprintf("The lockdown ends automatically after no member joined for %s "+
+			"or when it is ended using `/antiraid lockdown end`.", time.Duration(state.QuietPeriod)*time.Second),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Changes",
+				Value: lockdown.ChangesText(state),
+			},
+		},
+	}
+
+	if len(state.Failures) != 0 {
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name:  "Failures",
+			Value: "- " + strings.Join(state.Failures, "\n- "),
+		})
+	}
+
+	return ctx.FollowUpEmbed(emb).Send().Error
+}
+
+func (c *Antiraid) lockdownEnd(ctx ken.SubCommandContext) (err error) {
+	ld := ctx.Get(static.DiLockdown).(lockdown.Provider)
+
+	summary, err := ld.End(ctx.GetEvent().GuildID, ctx.User().ID)
+	if err == lockdown.ErrNotActive {
+		return ctx.FollowUpError("There is no active lockdown.", "").Send().Error
+	}
+	if err != nil {
+		return
+	}
+
+	return ctx.FollowUpEmbed(summary.Embed()).Send().Error
+}
+
+func (c *Antiraid) joinlogBan(ctx ken.SubCommandContext) (err error) {
+	return c.joinlogAction(ctx, models.JoinlogActionBan, "sp.guild.mod.ban")
+}
+
+func (c *Antiraid) joinlogKick(ctx ken.SubCommandContext) (err error) {
+	return c.joinlogAction(ctx, models.JoinlogActionKick, "sp.guild.mod.kick")
+}
+
+func (c *Antiraid) joinlogVerify(ctx ken.SubCommandContext) (err error) {
+	return c.joinlogAction(ctx, models.JoinlogActionVerify, "")
+}
+
+func (c *Antiraid) joinlogAction(ctx ken.SubCommandContext, action models.JoinlogAction, perm string) (err error) {
+	pmw := ctx.Get(static.DiPermissions).(*permissions.Permissions)
+	jl := ctx.Get(static.DiJoinlog).(joinlog.Provider)
+
+	guildID := ctx.GetEvent().GuildID
+
+	if perm != "" {
+		ok, _, err := pmw.CheckPermissions(ctx.GetSession(), guildID, ctx.User().ID, perm)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ctx.FollowUpError(
+				fmt.Sprintf("You need the permission `%s` to %s members.", perm, action), "").
+				Send().Error
+		}
+	}
+
+	req := models.JoinlogActionRequest{Action: action}
+	if v, ok := ctx.Options().GetByNameOptional("reason"); ok {
+		req.Reason = v.StringValue()
+	}
+	if v, ok := ctx.Options().GetByNameOptional("max_account_age"); ok {
+		req.Filter.MaxAccountAge = int(v.IntValue())
+	}
+	if v, ok := ctx.Options().GetByNameOptional("name_pattern"); ok {
+		req.Filter.NamePattern = v.StringValue()
+	}
+
+	if err = req.Validate(); err != nil {
+		return ctx.FollowUpError(err.Error(), "").Send().Error
+	}
+
+	entries, err := jl.Matching(guildID, req.Filter)
+	if err != nil {
+		return
+	}
+	if len(entries) == 0 {
+		return ctx.FollowUpError("No join log entries match the filter.", "").Send().Error
+	}
+
+	// Actions can take longer than the interaction token is
+	// valid, so the progress is posted as a channel message
+	// instead of being edited into the interaction response.
+	start := func(fctx ken.ContextResponder) (err error) {
+		s := ctx.GetSession()
+		channelID := ctx.GetEvent().ChannelID
+
+		msg, err := s.ChannelMessageSendEmbed(channelID, &discordgo.MessageEmbed{
+			Description: "Starting ...",
+		})
+		if err != nil {
+			return
+		}
+
+		// Progress updates must not be overwritten by the
+		// initial progress edited in below.
+		var mtx sync.Mutex
+		mtx.Lock()
+		progress, err := jl.Start(guildID, ctx.User().ID, req, func(progress models.JoinlogActionProgress) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			s.ChannelMessageEditEmbed(channelID, msg.ID, joinlogProgressEmbed(progress))
+		})
+		if err == nil {
+			_, err = s.ChannelMessageEditEmbed(channelID, msg.ID, joinlogProgressEmbed(progress))
+		}
+		mtx.Unlock()
+
+		if err == joinlog.ErrRunning {
+			s.ChannelMessageDelete(channelID, msg.ID)
+			return fctx.FollowUpError("Another join log action is currently running.", "").Send().Error
+		}
+		if err != nil {
+			return
+		}
+
+		return fctx.FollowUpEmbed(&discordgo.MessageEmbed{
+			Description: fmt.Sprintf("[Join log action](%s) has been started.",
+				discordutil.GetMessageLink(msg, guildID)),
+		}).Send().Error
+	}
+
+	if action == models.JoinlogActionVerify {
+		return start(ctx)
+	}
+
+	acceptMsg := acceptmsg.AcceptMessage{
+		Embed: &discordgo.MessageEmbed{
+			Color: static.ColorEmbedOrange,
+			Title: "Join Log Action",
+			Description: fmt.Sprintf("Do you really want to %s the following %d members?\n\n%s",
+				action, len(entries), joinlogEntriesText(entries)),
+		},
+		Ken:            ctx.GetKen(),
+		UserID:         ctx.User().ID,
+		DeleteMsgAfter: true,
+		AcceptFunc: func(cctx ken.ComponentContext) (err error) {
+			if err = cctx.Defer(); err != nil {
+				return
+			}
+			return start(cctx)
+		},
+	}
+
+	if _, err = acceptMsg.AsFollowUp(ctx); err != nil {
+		return
+	}
+	return acceptMsg.Error()
+}
+
+func joinlogProgressEmbed(progress models.JoinlogActionProgress) *discordgo.MessageEmbed {
+	emb := &discordgo.MessageEmbed{
+		Color: static.ColorEmbedDefault,
+		Title: fmt.Sprintf("Join Log Action: %s", progress.Action),
+		Description: fmt.Sprintf("Processing join log entries ... `%d / %d`",
+			progress.Done, progress.Total),
+	}
+
+	if !progress.Running() {
+		emb.Color = static.ColorEmbedGreen
+		emb.Description = fmt.Sprintf("Finished after %s. `%d / %d` entries have been processed successfully.",
+			progress.Finished.Sub(progress.Started).Round(time.Second), progress.Total-progress.Failed, progress.Total)
+	}
+
+	if progress.Failed != 0 {
+		emb.Color = static.ColorEmbedOrange
+		errs := "- " + strings.Join(progress.Errors, "\n- ")
+		if more := progress.Failed - len(progress.Errors); more > 0 {
+			errs += fmt.Sprintf("\n*and %d more*", more)
+		}
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("Failures (%d)", progress.Failed),
+			Value: errs,
+		})
+	}
+
+	return emb
+}
+
+func joinlogEntriesText(entries []models.JoinLogEntry) string {
+	n := len(entries)
+	if n > maxListedJoinlogEntries {
+		n = maxListedJoinlogEntries
+	}
+
+	lines := make([]string, 0, n+1)
+	for _, e := range entries[:n] {
+		lines = append(lines, fmt.Sprintf("- `%s` (<@%s>)", e.Tag, e.UserID))
+	}
+	if n < len(entries) {
+		lines = append(lines, fmt.Sprintf("*and %d more*", len(entries)-n))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -1,9 +1,12 @@
 package slashcommands
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/rs/xid"
@@ -11,17 +14,26 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/services/backup/backupmodels"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
-	"github.com/zekroTJA/shinpuru/internal/services/storage"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/acceptmsg/v2"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
 	"github.com/zekroTJA/shinpuru/pkg/logmsg"
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
 	"github.com/zekrotja/ken"
 )
 
+var snowflakeRx = regexp.MustCompile(`\d{17,20}`)
+
+const (
+	maxListedBackups = 10
+	maxSelectOptions = 25
+)
+
 type Backup struct{}
 
 var (
 	_ ken.SlashCommand        = (*Backup)(nil)
+	_ ken.AutocompleteCommand = (*Backup)(nil)
 	_ permissions.PermCommand = (*Backup)(nil)
 )
 
@@ -34,7 +46,7 @@ func (c *Backup) Description() string {
 }
 
 func (c *Backup) Version() string {
-	return "2.0.0"
+	return "3.0.0"
 }
 
 func (c *Backup) Type() discordgo.ApplicationCommandType {
@@ -42,7 +54,66 @@ func (c *Backup) Type() discordgo.ApplicationCommandType {
 }
 
 func (c *Backup) Options() []*discordgo.ApplicationCommandOption {
-	return nil
+	componentChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "all", Value: "all"},
+	}
+	for _, comp := range backupmodels.RestoreComponents {
+		componentChoices = append(componentChoices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  string(comp),
+			Value: string(comp),
+		})
+	}
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "manage",
+			Description: "List, restore and purge backups or toggle guild backups.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "component",
+					Description: "Restore only the given component of the backup.",
+					Choices:     componentChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "channels",
+					Description: "Restore only the given channels or categories (mentions or IDs).",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "target",
+					Description: "ID of another guild the backup should be restored into.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "dryrun",
+					Description: "Only show the changes a restore would apply.",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "diff",
+			Description: "Show the changes of the guild between two backups.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "from",
+					Description:  "The backup to compare from.",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "to",
+					Description:  "The backup to compare to (latest backup if not specified).",
+					Autocomplete: true,
+				},
+			},
+		},
+	}
 }
 
 func (c *Backup) Domain() string {
@@ -58,8 +129,60 @@ func (c *Backup) Run(ctx ken.Context) (err error) {
 		return
 	}
 
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{"manage", c.manage},
+		ken.SubCommandHandler{"diff", c.diff},
+	)
+
+	return
+}
+
+func (c *Backup) Autocomplete(ctx *ken.AutocompleteContext) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+	var input string
+	for _, sub := range ctx.GetData().Options {
+		for _, opt := range sub.Options {
+			if opt.Focused {
+				input = strings.ToLower(opt.StringValue())
+			}
+		}
+	}
+
+	bck := ctx.Get(static.DiBackupHandler).(*backup.GuildBackups)
+
+	entries, err := bck.Timeline(ctx.Event().GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxSelectOptions)
+	for i, entry := range entries {
+		name := fmt.Sprintf("%d - %s (%s)", i, entry.TimestampFormatted(), entry.Kind())
+		if !strings.Contains(strings.ToLower(name), input) && !strings.HasPrefix(entry.FileID, input) {
+			continue
+		}
+		results = append(results, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: entry.FileID,
+		})
+		if len(results) == maxSelectOptions {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (c *Backup) manage(ctx ken.SubCommandContext) (err error) {
 	db, _ := ctx.Get(static.DiDatabase).(database.Database)
-	st, _ := ctx.Get(static.DiObjectStorage).(storage.Storage)
+	bck, _ := ctx.Get(static.DiBackupHandler).(*backup.GuildBackups)
+
+	opts, dryRun, err := c.getRestoreOptions(ctx)
+	if err != nil {
+		return err
+	}
+	if opts == nil {
+		return nil
+	}
 
 	enabled, err := db.GetGuildBackup(ctx.GetEvent().GuildID)
 	if err != nil && database.IsErrDatabaseNotFound(err) {
@@ -71,7 +194,12 @@ func (c *Backup) Run(ctx ken.Context) (err error) {
 		strStatus = ":white_check_mark:  Backups **enabled**"
 	}
 
-	entries, strBackupAll, err := c.getBackupsList(ctx)
+	entries, strBackupAll, err := c.getBackupsList(ctx, bck)
+	if err != nil {
+		return err
+	}
+
+	retention, err := bck.Retention(ctx.GetEvent().GuildID)
 	if err != nil {
 		return err
 	}
@@ -85,6 +213,10 @@ func (c *Backup) Run(ctx ken.Context) (err error) {
 				Name:  "Saved Backups",
 				Value: strBackupAll,
 			},
+			{
+				Name:  "Retention",
+				Value: fmt.Sprintf("Backups are kept %s.", retention),
+			},
 		},
 	}
 
@@ -104,9 +236,13 @@ func (c *Backup) Run(ctx ken.Context) (err error) {
 	if len(entries) != 0 {
 		options := make([]discordgo.SelectMenuOption, 0, len(entries))
 		for i, entry := range entries {
+			if i == maxSelectOptions {
+				break
+			}
 			options = append(options, discordgo.SelectMenuOption{
-				Label: fmt.Sprintf("%d - %s", i, entry.TimestampFormatted()),
-				Value: entry.FileID,
+				Label:       fmt.Sprintf("%d - %s", i, entry.TimestampFormatted()),
+				Description: entry.Kind(),
+				Value:       entry.FileID,
 			})
 		}
 
@@ -181,7 +317,7 @@ func (c *Backup) Run(ctx ken.Context) (err error) {
 				Label:    "Purge all Backups",
 				Style:    discordgo.DangerButton,
 			}, func(ctx ken.ComponentContext) bool {
-				c.purgeBackups(ctx, db, st)
+				c.purgeBackups(ctx, bck)
 
 				cNext <- ""
 				return true
@@ -224,23 +360,42 @@ func (c *Backup) Run(ctx ken.Context) (err error) {
 			"Something went wrong. Please try again later.", "").Send().Error
 	}
 
-	bck := ctx.Get(static.DiBackupHandler).(*backup.GuildBackups)
+	plan, err := bck.PlanRestore(ctx.GetEvent().GuildID, entry.FileID, *opts)
+	if err != nil {
+		return err
+	}
+
+	planEmb := c.planEmbed(plan)
+
+	if dryRun {
+		planEmb.Title = "Backup Restore Dry Run"
+		return ctx.FollowUpEmbed(planEmb).Send().Error
+	}
+
+	if len(plan.Operations) == 0 {
+		return ctx.FollowUpEmbed(planEmb).Send().Error
+	}
+
+	target := "the structure of this guild"
+	if plan.TargetGuildID != plan.GuildID {
+		target = fmt.Sprintf("the structure of the guild `%s`", plan.TargetGuildID)
+	}
+
+	planEmb.Color = static.ColorEmbedOrange
+	planEmb.Description = fmt.Sprintf(":warning:  **WARNING**  :warning:\n\n"+
+		"By pressing \"Accept\", %s will be **reset** to the selected backup:\n\n"+
+		"%s - (ID: `%s`)\n\n%s", target, entry.TimestampFormatted(), entry.FileID, planEmb.Description)
 
 	accMsg := &acceptmsg.AcceptMessage{
 		Ken:            ctx.GetKen(),
 		DeleteMsgAfter: true,
 		UserID:         ctx.User().ID,
-		Embed: &discordgo.MessageEmbed{
-			Color: static.ColorEmbedOrange,
-			Description: fmt.Sprintf(":warning:  **WARNING**  :warning:\n\n"+
-				"By pressing \"Accept\", the structure of this guild will be **reset** to the selected backup:\n\n"+
-				"%s - (ID: `%s`)", entry.TimestampFormatted(), entry.FileID),
-		},
+		Embed:          planEmb,
 		DeclineFunc: func(cctx ken.ComponentContext) error {
 			return cctx.RespondError("Canceled.", "")
 		},
 		AcceptFunc: func(cctx ken.ComponentContext) error {
-			return c.proceedRestore(cctx, bck, entry.FileID)
+			return c.proceedRestore(cctx, bck, entry.FileID, *opts)
 		},
 	}
 
@@ -250,40 +405,178 @@ func (c *Backup) Run(ctx ken.Context) (err error) {
 	return accMsg.Error()
 }
 
+func (c *Backup) diff(ctx ken.SubCommandContext) (err error) {
+	bck, _ := ctx.Get(static.DiBackupHandler).(*backup.GuildBackups)
+	guildID := ctx.GetEvent().GuildID
+
+	from := ctx.Options().GetByName("from").StringValue()
+
+	var to string
+	if v, ok := ctx.Options().GetByNameOptional("to"); ok {
+		to = v.StringValue()
+	} else {
+		entries, err := bck.Timeline(guildID)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return ctx.FollowUpError("There are no backups saved.", "").Send().Error
+		}
+		to = entries[0].FileID
+	}
+
+	diff, err := bck.Diff(guildID, from, to)
+	if errors.Is(err, backup.ErrBackupNotFound) {
+		return ctx.FollowUpError("The backup could not be found.", "").Send().Error
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.FollowUpEmbed(c.diffEmbed(diff)).Send().Error
+}
+
 // --- HELPERS ---
 
-func (c *Backup) getBackupsList(ctx ken.Context) ([]backupmodels.Entry, string, error) {
-	db, _ := ctx.Get(static.DiDatabase).(database.Database)
+// getRestoreOptions returns the restore options passed to
+// the command and whether a dry run is requested. If the
+// options are invalid, an error message is sent and nil
+// is returned.
+func (c *Backup) getRestoreOptions(ctx ken.Context) (*backupmodels.RestoreOptions, bool, error) {
+	var (
+		opts   backupmodels.RestoreOptions
+		dryRun bool
+	)
+
+	if v, ok := ctx.Options().GetByNameOptional("component"); ok && v.StringValue() != "all" {
+		opts.Components = []backupmodels.RestoreComponent{backupmodels.RestoreComponent(v.StringValue())}
+	}
 
-	backups, err := db.GetBackups(ctx.GetEvent().GuildID)
-	if err != nil && database.IsErrDatabaseNotFound(err) {
+	if v, ok := ctx.Options().GetByNameOptional("channels"); ok {
+		opts.Channels = snowflakeRx.FindAllString(v.StringValue(), -1)
+		if len(opts.Channels) == 0 {
+			return nil, false, ctx.FollowUpError("No valid channel mentions or IDs have been passed.", "").
+				Send().Error
+		}
+		if len(opts.Components) == 0 {
+			opts.Components = []backupmodels.RestoreComponent{backupmodels.RestoreComponentChannels}
+		}
+	}
+
+	if v, ok := ctx.Options().GetByNameOptional("target"); ok && v.StringValue() != ctx.GetEvent().GuildID {
+		opts.TargetGuildID = v.StringValue()
+
+		pmw := ctx.Get(static.DiPermissions).(*permissions.Permissions)
+		ok, _, err := pmw.CheckPermissions(ctx.GetSession(), opts.TargetGuildID, ctx.User().ID, c.Domain())
+		if err != nil || !ok {
+			return nil, false, ctx.FollowUpError(
+				"You are not permitted to restore backups into the target guild or "+
+					"shinpuru is not a member of this guild.", "").
+				Send().Error
+		}
+	}
+
+	if v, ok := ctx.Options().GetByNameOptional("dryrun"); ok {
+		dryRun = v.BoolValue()
+	}
+
+	return &opts, dryRun, nil
+}
+
+// planEmbed creates an embed listing the operations
+// of the given restore plan.
+func (c *Backup) planEmbed(plan *backupmodels.RestorePlan) *discordgo.MessageEmbed {
+	const maxListedOps = 15
+
+	emb := &discordgo.MessageEmbed{
+		Title: "Backup Restore Plan",
+		Color: static.ColorEmbedDefault,
+	}
+
+	if len(plan.Operations) == 0 {
+		emb.Description = "The guild already matches the selected backup. Nothing to restore."
+		return emb
+	}
+
+	var sb strings.Builder
+	summary := plan.Summary()
+	types := make([]string, 0, len(summary))
+	for typ := range summary {
+		types = append(types, string(typ))
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		fmt.Fprintf(&sb, "`%s`: %d\n", typ, summary[backupmodels.RestoreOperationType(typ)])
+	}
+	emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+		Name:  "Summary",
+		Value: sb.String(),
+	})
+
+	sb.Reset()
+	for i, op := range plan.Operations {
+		if i == maxListedOps {
+			fmt.Fprintf(&sb, "*... and %d more*", len(plan.Operations)-maxListedOps)
+			break
+		}
+		sb.WriteString(op.String() + "\n")
+	}
+	emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+		Name:  "Operations",
+		Value: stringutil.Cap(sb.String(), 1024),
+	})
+
+	if plan.Completed > 0 {
+		emb.Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Resuming a previous restore: %d operations have already been completed.",
+				plan.Completed),
+		}
+	}
+
+	return emb
+}
+
+// getBackupsList returns the timeline of backups of the
+// guild as well as the formatted list of the latest ones.
+func (c *Backup) getBackupsList(
+	ctx ken.Context,
+	bck *backup.GuildBackups,
+) ([]backupmodels.Entry, string, error) {
+	backups, err := bck.Timeline(ctx.GetEvent().GuildID)
+	if err != nil {
 		return nil, "", err
 	}
 
 	strBackupAll := "*no backups saved*"
 
 	if len(backups) > 0 {
-		sort.Slice(backups, func(i, j int) bool {
-			return backups[i].Timestamp.Before(backups[j].Timestamp)
-		})
-
-		if len(backups) > 10 {
-			backups = backups[0:10]
+		n := len(backups)
+		if n > maxListedBackups {
+			n = maxListedBackups
 		}
 
-		strBackups := make([]string, len(backups))
-
-		for i, b := range backups {
+		strBackups := make([]string, n)
+		for i, b := range backups[:n] {
 			strBackups[i] = b.StringIndexed(i)
 		}
 
+		if len(backups) > n {
+			strBackups = append(strBackups,
+				fmt.Sprintf("*... and %d older backups*", len(backups)-n))
+		}
+
 		strBackupAll = strings.Join(strBackups, "\n")
 	}
 
 	return backups, strBackupAll, nil
 }
 
-func (c *Backup) proceedRestore(ctx ken.ComponentContext, bck *backup.GuildBackups, fileID string) (err error) {
+func (c *Backup) proceedRestore(
+	ctx ken.ComponentContext,
+	bck *backup.GuildBackups,
+	fileID string,
+	opts backupmodels.RestoreOptions,
+) (err error) {
 	if err = ctx.Defer(); err != nil {
 		return err
 	}
@@ -309,51 +602,205 @@ func (c *Backup) proceedRestore(ctx ken.ComponentContext, bck *backup.GuildBacku
 	}
 	defer statusMsg.Close("✔️ Backup restoration finished!")
 
-	err = bck.RestoreBackup(ctx.GetEvent().GuildID, fileID, statusChan, errorsChan)
+	err = bck.RestoreBackup(ctx.GetEvent().GuildID, fileID, opts, statusChan, errorsChan)
 
 	return
 }
 
-func (c *Backup) purgeBackups(ctx ken.ComponentContext, db database.Database, st storage.Storage) {
+func (c *Backup) purgeBackups(ctx ken.ComponentContext, bck *backup.GuildBackups) {
 	if err := ctx.Defer(); err != nil {
 		return
 	}
 
-	backups, err := db.GetBackups(ctx.GetEvent().GuildID)
+	purged, err := bck.PurgeBackups(ctx.GetEvent().GuildID)
 	if err != nil {
-		ctx.FollowUpError(fmt.Sprintf("Failed getting backups: ```\n%s\n```", err.Error()), "").
-			Send()
+		ctx.FollowUpError(fmt.Sprintf("Purged `%d` backups, but some objects failed to be deleted: ```\n%s\n```",
+			purged, err.Error()), "").Send()
 		return
 	}
 
-	var lnBackups = len(backups)
-	if lnBackups < 1 {
+	if purged == 0 {
 		ctx.FollowUpError("There are no backups saved to be purged.", "").
 			Send()
 		return
 	}
 
-	var success int
-	for _, backup := range backups {
-		if err = db.DeleteBackup(ctx.GetEvent().GuildID, backup.FileID); err != nil {
-			continue
+	ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Description: fmt.Sprintf("Successfully purged `%d` backups.", purged),
+		Color:       static.ColorEmbedGreen,
+	}).Send()
+}
+
+// diffEmbed renders the backup diff into
+// an embed with a field for each kind of
+// changed objects.
+func (c *Backup) diffEmbed(diff *backupmodels.Diff) *discordgo.MessageEmbed {
+	emb := &discordgo.MessageEmbed{
+		Title: "Backup Diff",
+		Color: static.ColorEmbedDefault,
+		Description: fmt.Sprintf("Changes from `%s` (%s)\nto `%s` (%s)",
+			diff.From, diff.FromTimestamp.Format(time.RFC1123),
+			diff.To, diff.ToTimestamp.Format(time.RFC1123)),
+	}
+
+	if diff.IsEmpty() {
+		emb.Description += "\n\n*No changes.*"
+		return emb
+	}
+
+	var lines []string
+
+	for _, fc := range diff.Guild {
+		lines = append(lines, fmt.Sprintf("~ %s: %s", fc.Field, formatFieldChange(fc)))
+	}
+	emb.Fields = appendDiffField(emb.Fields, "Guild", lines)
+
+	lines = nil
+	for _, r := range diff.RolesAdded {
+		lines = append(lines, fmt.Sprintf("+ <@&%s>%s", r.ID, formatPermissions(" with ", r.Permissions)))
+	}
+	for _, r := range diff.RolesRemoved {
+		lines = append(lines, fmt.Sprintf("- `%s`", r.Name))
+	}
+	for _, r := range diff.RolesChanged {
+		lines = append(lines, fmt.Sprintf("~ <@&%s>: %s", r.ID, formatFieldChanges(r.Changes)))
+	}
+	emb.Fields = appendDiffField(emb.Fields, "Roles", lines)
+
+	lines = nil
+	for _, ch := range diff.ChannelsAdded {
+		lines = append(lines, fmt.Sprintf("+ <#%s>", ch.ID))
+	}
+	for _, ch := range diff.ChannelsRemoved {
+		lines = append(lines, fmt.Sprintf("- `%s`", ch.Name))
+	}
+	for _, ch := range diff.ChannelsChanged {
+		changes := make([]string, 0, len(ch.Changes)+len(ch.Overwrites))
+		if len(ch.Changes) != 0 {
+			changes = append(changes, formatFieldChanges(ch.Changes))
+		}
+		for _, o := range ch.Overwrites {
+			changes = append(changes, formatOverwriteDiff(o))
 		}
+		lines = append(lines, fmt.Sprintf("~ <#%s>: %s", ch.ID, strings.Join(changes, "; ")))
+	}
+	emb.Fields = appendDiffField(emb.Fields, "Channels", lines)
 
-		if err = st.DeleteObject(static.StorageBucketBackups, backup.FileID); err != nil {
-			continue
+	lines = nil
+	if n := len(diff.MembersAdded); n != 0 {
+		lines = append(lines, fmt.Sprintf("+ %d members joined", n))
+	}
+	if n := len(diff.MembersRemoved); n != 0 {
+		lines = append(lines, fmt.Sprintf("- %d members left", n))
+	}
+	for _, m := range diff.MembersChanged {
+		changes := make([]string, 0, len(m.AddedRoles)+len(m.RemovedRoles)+1)
+		for _, id := range m.AddedRoles {
+			changes = append(changes, fmt.Sprintf("+<@&%s>", id))
 		}
-		success++
+		for _, id := range m.RemovedRoles {
+			changes = append(changes, fmt.Sprintf("-<@&%s>", id))
+		}
+		if len(m.Changes) != 0 {
+			changes = append(changes, formatFieldChanges(m.Changes))
+		}
+		lines = append(lines, fmt.Sprintf("~ <@%s>: %s", m.ID, strings.Join(changes, " ")))
 	}
+	emb.Fields = appendDiffField(emb.Fields, "Members", lines)
 
-	if success < lnBackups {
-		ctx.FollowUpError(fmt.Sprintf("Successfully purged `%d` of `%d` backups.\n`%d` backup purges failed.",
-			success, lnBackups, lnBackups-success), "").Send()
-		return
+	return emb
+}
+
+// appendDiffField adds a field with the given lines to fields
+// if lines is not empty. Lines exceeding the maximum field
+// length are omitted.
+func appendDiffField(fields []*discordgo.MessageEmbedField, name string, lines []string) []*discordgo.MessageEmbedField {
+	const maxLen = 1024
+
+	if len(lines) == 0 {
+		return fields
 	}
 
-	ctx.FollowUpEmbed(&discordgo.MessageEmbed{
-		Description: fmt.Sprintf("Successfully purged `%d` of `%d` backups.",
-			success, lnBackups),
-		Color: static.ColorEmbedGreen,
-	}).Send()
+	var sb strings.Builder
+	for i, line := range lines {
+		more := fmt.Sprintf("*... and %d more*", len(lines)-i)
+		if sb.Len()+len(line)+len(more)+2 > maxLen {
+			sb.WriteString(more)
+			break
+		}
+		sb.WriteString(line)
+		sb.WriteRune('\n')
+	}
+
+	return append(fields, &discordgo.MessageEmbedField{
+		Name:  name,
+		Value: sb.String(),
+	})
+}
+
+func formatFieldChanges(changes []backupmodels.FieldChange) string {
+	strs := make([]string, len(changes))
+	for i, fc := range changes {
+		strs[i] = fmt.Sprintf("%s %s", fc.Field, formatFieldChange(fc))
+	}
+	return strings.Join(strs, ", ")
+}
+
+func formatFieldChange(fc backupmodels.FieldChange) string {
+	if fc.Field == "permissions" {
+		oldPerms, _ := fc.Old.(int64)
+		newPerms, _ := fc.New.(int64)
+		return formatPermissionChange(oldPerms, newPerms)
+	}
+	return fmt.Sprintf("%s → %s", formatValue(fc.Old), formatValue(fc.New))
+}
+
+func formatValue(v any) string {
+	if s, ok := v.(string); ok && s == "" {
+		return "*none*"
+	}
+	return fmt.Sprintf("`%v`", v)
+}
+
+func formatPermissionChange(oldPerms, newPerms int64) string {
+	var strs []string
+	if granted := newPerms &^ oldPerms; granted != 0 {
+		strs = append(strs, formatPermissions("+", granted))
+	}
+	if revoked := oldPerms &^ newPerms; revoked != 0 {
+		strs = append(strs, formatPermissions("-", revoked))
+	}
+	return strings.Join(strs, " ")
+}
+
+func formatPermissions(prefix string, perms int64) string {
+	names := discordutil.PermissionNames(perms)
+	if len(names) == 0 {
+		return ""
+	}
+	return prefix + "`" + strings.Join(names, ", ") + "`"
+}
+
+func formatOverwriteDiff(o backupmodels.OverwriteDiff) string {
+	target := fmt.Sprintf("<@&%s>", o.ID)
+	if o.Type == discordgo.PermissionOverwriteTypeMember {
+		target = fmt.Sprintf("<@%s>", o.ID)
+	}
+
+	switch {
+	case o.Old == nil:
+		return fmt.Sprintf("overwrite for %s added (allow %s, deny %s)", target,
+			formatPermissions("", o.New.Allow), formatPermissions("", o.New.Deny))
+	case o.New == nil:
+		return fmt.Sprintf("overwrite for %s removed", target)
+	}
+
+	var strs []string
+	if perms := formatPermissionChange(o.Old.Allow, o.New.Allow); perms != "" {
+		strs = append(strs, "allow "+perms)
+	}
+	if perms := formatPermissionChange(o.Old.Deny, o.New.Deny); perms != "" {
+		strs = append(strs, "deny "+perms)
+	}
+	return fmt.Sprintf("overwrite for %s changed (%s)", target, strings.Join(strs, ", "))
 }
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/birthday"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
@@ -18,9 +20,12 @@ import (
 var (
 	dateRe = regexp.MustCompile(`^(?:(\d{4})[\/\-\.])?(\d{1,2})[\/\-\.](\d{1,2})([\+\-](?:\d{1,2}))?$`)
 
-	errYear = errors.New("you need to specify a year when you want to show your birthday year")
+	errYear        = errors.New("you need to specify a year when you want to show your birthday year")
+	errInvalidDate = errors.New("the passed date is invalid")
 )
 
+const nUpcomingBirthdays = 10
+
 type Birthday struct {
 	ken.EphemeralCommand
 }
@@ -39,7 +44,7 @@ func (c *Birthday) Description() string {
 }
 
 func (c *Birthday) Version() string {
-	return "1.0.0"
+	return "1.1.0"
 }
 
 func (c *Birthday) Type() discordgo.ApplicationCommandType {
@@ -69,6 +74,31 @@ func (c *Birthday) Options() []*discordgo.ApplicationCommandOption {
 			Name:        "unset-channel",
 			Description: "Unset birthday message channel.",
 		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "set-template",
+			Description: "Set the birthday message template or reset it to the default.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type: discordgo.ApplicationCommandOptionString,
+					Name: "template",
+					Description: "Variables: [user], [ment], [ment.possessive], [age], [birthday], [guild]. " +
+						"Use \\n for line breaks.",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "set-role",
+			Description: "Set the role added to members on their birthday or unset it.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "The birthday role.",
+				},
+			},
+		},
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
 			Name:        "set",
@@ -77,7 +107,7 @@ func (c *Birthday) Options() []*discordgo.ApplicationCommandOption {
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
 					Name:        "date",
-					Description: "The birthday date in format (YYYY-MM-DD+TZ).",
+					Description: "The birthday date in format (YYYY-MM-DD).",
 					Required:    true,
 				},
 				{
@@ -88,6 +118,24 @@ func (c *Birthday) Options() []*discordgo.ApplicationCommandOption {
 				},
 			},
 		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "timezone",
+			Description: "Set your timezone so that your birthday is announced on your local date.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "timezone",
+					Description: "A timezone name (i.e. Europe/Berlin) or UTC offset (i.e. +2 or -03:30).",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "upcoming",
+			Description: "List the upcoming birthdays.",
+		},
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
 			Name:        "remove",
@@ -105,7 +153,7 @@ func (c *Birthday) SubDomains() []permissions.SubPermission {
 		{
 			Term:        "/sp.guild.config.birthday",
 			Explicit:    false,
-			Description: "Allows setting the birthday channel.",
+			Description: "Allows setting the birthday channel, template and role.",
 		},
 	}
 }
@@ -118,7 +166,11 @@ func (c *Birthday) Run(ctx ken.Context) (err error) {
 	err = ctx.HandleSubCommands(
 		ken.SubCommandHandler{"set-channel", c.setChannel},
 		ken.SubCommandHandler{"unset-channel", c.unsetChannel},
+		ken.SubCommandHandler{"set-template", c.setTemplate},
+		ken.SubCommandHandler{"set-role", c.setRole},
 		ken.SubCommandHandler{"set", c.set},
+		ken.SubCommandHandler{"timezone", c.timezone},
+		ken.SubCommandHandler{"upcoming", c.upcoming},
 		ken.SubCommandHandler{"remove", c.remove},
 	)
 
@@ -172,6 +224,84 @@ func (c *Birthday) unsetChannel(ctx ken.SubCommandContext) (err error) {
 	return
 }
 
+func (c *Birthday) setTemplate(ctx ken.SubCommandContext) (err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+	pmw := ctx.Get(static.DiPermissions).(*permissions.Permissions)
+
+	ok, err := pmw.CheckSubPerm(ctx, "/sp.guild.settings.birthday", false,
+		"You are not permitted to edit the guild birthday template.")
+	if !ok {
+		return
+	}
+
+	var tmpl string
+	if v, ok := ctx.Options().GetByNameOptional("template"); ok {
+		tmpl = strings.ReplaceAll(v.StringValue(), "\\n", "\n")
+	}
+	if len(tmpl) > birthday.MaxTemplateLength {
+		return ctx.FollowUpError(fmt.Sprintf(
+			"The template must not be longer than %d characters.", birthday.MaxTemplateLength), "").
+			Send().Error
+	}
+
+	err = db.SetGuildBirthdayTemplate(ctx.GetEvent().GuildID, tmpl)
+	if err != nil {
+		return
+	}
+
+	desc := "Birthday template has been reset to the default."
+	if tmpl != "" {
+		desc = "Birthday template has been set. Preview:\n\n" + birthday.Render(tmpl, birthday.RenderContext{
+			Member: ctx.GetEvent().Member,
+			Birthday: models.Birthday{
+				Date:     time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+				ShowYear: true,
+			},
+			Now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Description: desc,
+	}).Send().Error
+
+	return
+}
+
+func (c *Birthday) setRole(ctx ken.SubCommandContext) (err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+	pmw := ctx.Get(static.DiPermissions).(*permissions.Permissions)
+
+	ok, err := pmw.CheckSubPerm(ctx, "/sp.guild.settings.birthday", false,
+		"You are not permitted to edit the guild birthday role.")
+	if !ok {
+		return
+	}
+
+	var roleID string
+	if v, ok := ctx.Options().GetByNameOptional("role"); ok {
+		roleID = v.RoleValue(ctx).ID
+	}
+
+	err = db.SetGuildBirthdayRole(ctx.GetEvent().GuildID, roleID)
+	if err != nil {
+		return
+	}
+
+	desc := "Birthday role has been reset."
+	if roleID != "" {
+		desc = fmt.Sprintf(
+			"Members will now get the role <@&%s> for %d hours on their birthday.",
+			roleID, int(birthday.RoleDuration.Hours()))
+	}
+
+	err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Description: desc,
+	}).Send().Error
+
+	return
+}
+
 func (c *Birthday) set(ctx ken.SubCommandContext) (err error) {
 	db := ctx.Get(static.DiDatabase).(database.Database)
 
@@ -184,13 +314,13 @@ func (c *Birthday) set(ctx ken.SubCommandContext) (err error) {
 			"Invalid date format.\n\n"+
 				"The expected date format is `YYYY-MM-DD` or `MM-DD`. "+
 				"You can also use `/` or `.` as delimiters.\n\n"+
-				"You might also want to attach a timezone offset in the "+
-				"format of `[+-]TZ` (in hours offset of UTC).", "").
+				"To get your birthday announced on your local date, "+
+				"set your timezone with `/birthday timezone`.", "").
 			Send().
 			Error
 	}
-	date, err := parseDate(matches[0], showYear)
-	if err == errYear {
+	date, offset, err := parseDate(matches[0], showYear)
+	if err == errYear || err == errInvalidDate {
 		err = ctx.FollowUpError(err.Error(), "").Send().Error
 		return
 	}
@@ -198,13 +328,39 @@ func (c *Birthday) set(ctx ken.SubCommandContext) (err error) {
 		return
 	}
 
-	err = db.SetBirthday(models.Birthday{
+	// The timezone offset could formerly only be passed
+	// with the date and is kept for compatibility.
+	if offset != "" {
+		if _, err = birthday.ParseTimezone(offset); err != nil {
+			return ctx.FollowUpError(err.Error(), "").Send().Error
+		}
+		if err = db.SetUserTimezone(ctx.User().ID, offset); err != nil {
+			return
+		}
+	}
+
+	bd := models.Birthday{
 		GuildID:  ctx.GetEvent().GuildID,
 		UserID:   ctx.User().ID,
 		Date:     date,
 		ShowYear: showYear,
-	})
-	if err != nil {
+	}
+
+	// The announcement state is kept so that changing the
+	// birthday does neither announce it twice a year nor
+	// leave the birthday role assigned.
+	bds, err := db.GetBirthdays(bd.GuildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return
+	}
+	for _, v := range bds {
+		if v.UserID == bd.UserID {
+			bd.LastNotified = v.LastNotified
+			bd.RoleExpires = v.RoleExpires
+		}
+	}
+
+	if err = db.SetBirthday(bd); err != nil {
 		return
 	}
 
@@ -216,10 +372,66 @@ func (c *Birthday) set(ctx ken.SubCommandContext) (err error) {
 	return
 }
 
-func (c *Birthday) remove(ctx ken.SubCommandContext) (err error) {
+func (c *Birthday) timezone(ctx ken.SubCommandContext) (err error) {
 	db := ctx.Get(static.DiDatabase).(database.Database)
 
-	err = db.DeleteBirthday(ctx.GetEvent().GuildID, ctx.User().ID)
+	tz := strings.TrimSpace(ctx.Options().GetByName("timezone").StringValue())
+	loc, err := birthday.ParseTimezone(tz)
+	if err != nil {
+		return ctx.FollowUpError(
+			"Invalid timezone. Please pass a timezone name like `Europe/Berlin` "+
+				"or an offset to UTC like `+2` or `-03:30`.", "").
+			Send().Error
+	}
+
+	if err = db.SetUserTimezone(ctx.User().ID, tz); err != nil {
+		return
+	}
+
+	err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Description: fmt.Sprintf(
+			"Your timezone has been set to `%s` (current time: %s).",
+			loc, time.Now().In(loc).Format("15:04")),
+	}).Send().Error
+
+	return
+}
+
+func (c *Birthday) upcoming(ctx ken.SubCommandContext) (err error) {
+	bs := ctx.Get(static.DiBirthday).(*birthday.BirthdayService)
+
+	upcoming, err := bs.Upcoming(ctx.GetEvent().GuildID, nUpcomingBirthdays)
+	if err != nil {
+		return
+	}
+
+	if len(upcoming) == 0 {
+		return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+			Description: "There are no birthdays registered on this guild.",
+		}).Send().Error
+	}
+
+	var sb strings.Builder
+	for _, ub := range upcoming {
+		fmt.Fprintf(&sb, "<t:%d:D> - <@%s>", ub.Date.Unix(), ub.UserID)
+		if ub.Age > 0 {
+			fmt.Fprintf(&sb, " (%d)", ub.Age)
+		}
+		sb.WriteRune('\n')
+	}
+
+	err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Title:       "Upcoming Birthdays",
+		Description: sb.String(),
+	}).Send().Error
+
+	return
+}
+
+func (c *Birthday) remove(ctx ken.SubCommandContext) (err error) {
+	bs := ctx.Get(static.DiBirthday).(*birthday.BirthdayService)
+
+	err = bs.Remove(ctx.GetEvent().GuildID, ctx.User().ID)
 	if err != nil {
 		return
 	}
@@ -231,8 +443,10 @@ func (c *Birthday) remove(ctx ken.SubCommandContext) (err error) {
 	return
 }
 
-func parseDate(matches []string, showYear bool) (date time.Time, err error) {
-	var y, m, d, offset = 1970, 0, 0, 0
+// parseDate returns the date of the birthday at midnight
+// in UTC and the timezone offset, if passed.
+func parseDate(matches []string, showYear bool) (date time.Time, offset string, err error) {
+	var y, m, d = 1970, 0, 0
 	if matches[1] != "" {
 		if y, err = strconv.Atoi(matches[1]); err != nil {
 			return
@@ -247,19 +461,11 @@ func parseDate(matches []string, showYear bool) (date time.Time, err error) {
 	if d, err = strconv.Atoi(matches[3]); err != nil {
 		return
 	}
-	if offsetStr := matches[4]; offsetStr != "" {
-		prefix := offsetStr[0]
-		if offset, err = strconv.Atoi(offsetStr[1:]); err != nil {
-			return
-		}
-		if offset < 0 || offset > 23 {
-			err = errors.New("timezone offset must be in range [-23, 23]")
-			return
-		}
-		if prefix == '-' {
-			offset = 24 - offset
-		}
+	if m < 1 || m > 12 || d < 1 || d > 31 {
+		err = errInvalidDate
+		return
 	}
-	date = time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.FixedZone("Offset", offset*3600))
+	offset = matches[4]
+	date = time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
 	return
 }
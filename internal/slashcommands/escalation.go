@@ -0,0 +1,276 @@
+package slashcommands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/permissions"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/timeutil"
+	"github.com/zekrotja/ken"
+)
+
+type Escalation struct{}
+
+var (
+	_ ken.SlashCommand        = (*Escalation)(nil)
+	_ permissions.PermCommand = (*Escalation)(nil)
+)
+
+func (c *Escalation) Name() string {
+	return "escalation"
+}
+
+func (c *Escalation) Description() string {
+	return "Manage the escalation ladder applied to reported members."
+}
+
+func (c *Escalation) Version() string {
+	return "1.0.0"
+}
+
+func (c *Escalation) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *Escalation) Options() []*discordgo.ApplicationCommandOption {
+	triggerChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "kick", Value: models.TypeKick},
+		{Name: "ban", Value: models.TypeBan},
+		{Name: "mute", Value: models.TypeMute},
+		{Name: "warn", Value: models.TypeWarn},
+		{Name: "ad", Value: models.TypeAd},
+	}
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "List the steps of the escalation ladder.",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "add",
+			Description: "Add a step to the escalation ladder.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "on",
+					Description: "The type of report triggering the evaluation of the step.",
+					Required:    true,
+					Choices:     triggerChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "counted",
+					Description: "The type of reports which are counted.",
+					Required:    true,
+					Choices:     triggerChoices,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "The number of counted reports required to apply the action.",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "action",
+					Description: "The action applied to the member.",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "mute", Value: models.TypeMute},
+						{Name: "kick", Value: models.TypeKick},
+						{Name: "ban", Value: models.TypeBan},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "window",
+					Description: "Only count reports within this time (e.g. 30d).",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duration",
+					Description: "Duration of the mute or ban (e.g. 24h).",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "position",
+					Description: "Position of the step in the ladder (appended if not specified).",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove",
+			Description: "Remove a step from the escalation ladder.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "position",
+					Description: "Position of the step in the ladder.",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "clear",
+			Description: "Remove all steps from the escalation ladder.",
+		},
+	}
+}
+
+func (c *Escalation) Domain() string {
+	return "sp.guild.config.escalation"
+}
+
+func (c *Escalation) SubDomains() []permissions.SubPermission {
+	return nil
+}
+
+func (c *Escalation) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return
+	}
+
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{"list", c.list},
+		ken.SubCommandHandler{"add", c.add},
+		ken.SubCommandHandler{"remove", c.remove},
+		ken.SubCommandHandler{"clear", c.clear},
+	)
+
+	return
+}
+
+func (c *Escalation) list(ctx ken.SubCommandContext) (err error) {
+	ladder, err := c.ladder(ctx)
+	if err != nil {
+		return
+	}
+
+	return ctx.FollowUpEmbed(ladderEmbed(ladder)).Send().Error
+}
+
+func (c *Escalation) add(ctx ken.SubCommandContext) (err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	step := models.EscalationStep{
+		On:      models.ReportType(ctx.Options().GetByName("on").IntValue()),
+		Counted: models.ReportType(ctx.Options().GetByName("counted").IntValue()),
+		Count:   int(ctx.Options().GetByName("count").IntValue()),
+		Action:  models.ReportType(ctx.Options().GetByName("action").IntValue()),
+	}
+
+	if windowV, ok := ctx.Options().GetByNameOptional("window"); ok {
+		window, err := timeutil.ParseDuration(windowV.StringValue())
+		if err != nil {
+			return ctx.FollowUpError("Invalid window duration format.", "").Send().Error
+		}
+		step.Window = int(window.Seconds())
+	}
+
+	if durationV, ok := ctx.Options().GetByNameOptional("duration"); ok {
+		duration, err := timeutil.ParseDuration(durationV.StringValue())
+		if err != nil {
+			return ctx.FollowUpError("Invalid action duration format.", "").Send().Error
+		}
+		step.Duration = int(duration.Seconds())
+	}
+
+	ladder, err := c.ladder(ctx)
+	if err != nil {
+		return
+	}
+
+	pos := len(ladder)
+	if posV, ok := ctx.Options().GetByNameOptional("position"); ok {
+		if p := int(posV.IntValue()); p >= 1 && p <= len(ladder) {
+			pos = p - 1
+		}
+	}
+	ladder = append(ladder[:pos], append(models.EscalationLadder{step}, ladder[pos:]...)...)
+
+	if err = ladder.Validate(); err != nil {
+		return ctx.FollowUpError(fmt.Sprintf("Invalid escalation step: %s.", err.Error()), "").Send().Error
+	}
+
+	if err = db.SetGuildEscalationLadder(ctx.GetEvent().GuildID, ladder); err != nil {
+		return
+	}
+
+	return ctx.FollowUpEmbed(ladderEmbed(ladder)).Send().Error
+}
+
+func (c *Escalation) remove(ctx ken.SubCommandContext) (err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	ladder, err := c.ladder(ctx)
+	if err != nil {
+		return
+	}
+
+	pos := int(ctx.Options().GetByName("position").IntValue())
+	if pos < 1 || pos > len(ladder) {
+		return ctx.FollowUpError(
+			fmt.Sprintf("There is no step at position %d.", pos), "").
+			Send().Error
+	}
+	ladder = append(ladder[:pos-1], ladder[pos:]...)
+
+	if err = db.SetGuildEscalationLadder(ctx.GetEvent().GuildID, ladder); err != nil {
+		return
+	}
+
+	return ctx.FollowUpEmbed(ladderEmbed(ladder)).Send().Error
+}
+
+func (c *Escalation) clear(ctx ken.SubCommandContext) (err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	if err = db.SetGuildEscalationLadder(ctx.GetEvent().GuildID, nil); err != nil {
+		return
+	}
+
+	return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Description: "Escalation ladder has been cleared.",
+	}).Send().Error
+}
+
+func (c *Escalation) ladder(ctx ken.SubCommandContext) (ladder models.EscalationLadder, err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	ladder, err = db.GetGuildEscalationLadder(ctx.GetEvent().GuildID)
+	if database.IsErrDatabaseNotFound(err) {
+		err = nil
+	}
+	return
+}
+
+func ladderEmbed(ladder models.EscalationLadder) *discordgo.MessageEmbed {
+	if len(ladder) == 0 {
+		return &discordgo.MessageEmbed{
+			Color:       static.ColorEmbedGray,
+			Description: "No escalation steps are configured.",
+		}
+	}
+
+	var sb strings.Builder
+	for i, step := range ladder {
+		fmt.Fprintf(&sb, "`%d` %s\n", i+1, step.String())
+	}
+
+	return &discordgo.MessageEmbed{
+		Color:       static.ColorEmbedDefault,
+		Title:       "Escalation Ladder",
+		Description: sb.String(),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "When multiple steps match a report, the last one is applied.",
+		},
+	}
+}
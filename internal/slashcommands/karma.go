@@ -2,15 +2,24 @@ package slashcommands
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/karma"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
+	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/hammertime"
+	"github.com/zekroTJA/shinpuru/pkg/timeutil"
 	"github.com/zekrotja/dgrs"
 	"github.com/zekrotja/ken"
 )
 
+const karmaHistoryLimit = 20
+
 type Karma struct {
 	ken.EphemeralCommand
 }
@@ -25,11 +34,11 @@ func (c *Karma) Name() string {
 }
 
 func (c *Karma) Description() string {
-	return "Display users karma count or the guilds karma scoreboard."
+	return "Display users karma count, history or the guilds karma scoreboard."
 }
 
 func (c *Karma) Version() string {
-	return "1.0.0"
+	return "2.0.0"
 }
 
 func (c *Karma) Type() discordgo.ApplicationCommandType {
@@ -39,9 +48,69 @@ func (c *Karma) Type() discordgo.ApplicationCommandType {
 func (c *Karma) Options() []*discordgo.ApplicationCommandOption {
 	return []*discordgo.ApplicationCommandOption{
 		{
-			Type:        discordgo.ApplicationCommandOptionUser,
-			Name:        "user",
-			Description: "Display karma stats of a specific user.",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "show",
+			Description: "Display karma stats of a user.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Display karma stats of a specific user.",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "leaderboard",
+			Description: "Display the karma scoreboard of the guild.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "period",
+					Description: "The time period covered by the scoreboard.",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "all time", Value: models.KarmaPeriodAll},
+						{Name: "month", Value: models.KarmaPeriodMonth},
+						{Name: "week", Value: models.KarmaPeriodWeek},
+					},
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "history",
+			Description: "Display the latest karma changes of a user.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Display the karma history of a specific user.",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "revert",
+			Description: "Revert all karma given by a user within a time window.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The user who gave the karma.",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "since",
+					Description: "Revert karma given since this time ago (e.g. 24h).",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "until",
+					Description: "Only revert karma given before this time ago (e.g. 1h).",
+				},
+			},
 		},
 	}
 }
@@ -59,12 +128,38 @@ func (c *Karma) Run(ctx ken.Context) (err error) {
 		return
 	}
 
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{"show", c.show},
+		ken.SubCommandHandler{"leaderboard", c.leaderboard},
+		ken.SubCommandHandler{"history", c.history},
+		ken.SubCommandHandler{"revert", c.revert},
+	)
+
+	return
+}
+
+func (c *Karma) show(ctx ken.SubCommandContext) (err error) {
+	user := ctx.User()
 	if userV, ok := ctx.Options().GetByNameOptional("user"); ok {
-		return c.userKarma(ctx, userV.UserValue(ctx))
+		user = userV.UserValue(ctx)
 	}
 
+	return c.userKarma(ctx, user)
+}
+
+func (c *Karma) leaderboard(ctx ken.SubCommandContext) (err error) {
 	db := ctx.Get(static.DiDatabase).(database.Database)
 	st := ctx.Get(static.DiState).(*dgrs.State)
+	tp := ctx.Get(static.DiTimeProvider).(timeprovider.Provider)
+
+	period := models.KarmaPeriodAll
+	if periodV, ok := ctx.Options().GetByNameOptional("period"); ok {
+		period = models.KarmaPeriod(periodV.StringValue())
+	}
+	window, ok := period.Duration()
+	if !ok {
+		return ctx.FollowUpError("Invalid scoreboard period.", "").Send().Error
+	}
 
 	karma, err := db.GetKarma(ctx.User().ID, ctx.GetEvent().GuildID)
 	if err != nil && !database.IsErrDatabaseNotFound(err) {
@@ -76,7 +171,14 @@ func (c *Karma) Run(ctx ken.Context) (err error) {
 		return err
 	}
 
-	karmaList, err := db.GetKarmaGuild(ctx.GetEvent().GuildID, 20)
+	var karmaList []models.GuildKarma
+	title := "Karma Scoreboard"
+	if window == 0 {
+		karmaList, err = db.GetKarmaGuild(ctx.GetEvent().GuildID, 20)
+	} else {
+		karmaList, err = db.GetKarmaLeaderboard(ctx.GetEvent().GuildID, tp.Now().Add(-window), 20)
+		title = fmt.Sprintf("Karma Scoreboard (last %d days)", window/(24*time.Hour))
+	}
 	if err != nil && !database.IsErrDatabaseNotFound(err) {
 		return err
 	}
@@ -106,7 +208,7 @@ func (c *Karma) Run(ctx ken.Context) (err error) {
 
 	emb := &discordgo.MessageEmbed{
 		Color: static.ColorEmbedDefault,
-		Title: "Karma Scoreboard",
+		Title: title,
 		Description: fmt.Sprintf(
 			"Your Karma on this guild: **%d**\n"+
 				"Your Global Karma: **%d**",
@@ -126,6 +228,103 @@ func (c *Karma) Run(ctx ken.Context) (err error) {
 	return ctx.FollowUpEmbed(emb).Send().Error
 }
 
+func (c *Karma) history(ctx ken.SubCommandContext) (err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	user := ctx.User()
+	if userV, ok := ctx.Options().GetByNameOptional("user"); ok {
+		user = userV.UserValue(ctx)
+	}
+
+	entries, err := db.GetKarmaLedger(ctx.GetEvent().GuildID, user.ID, 0, karmaHistoryLimit)
+	if err != nil {
+		return
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: static.ColorEmbedDefault,
+		Title: user.String() + "'s Karma History",
+	}
+
+	if len(entries) == 0 {
+		emb.Description = "*No karma changes recorded.*"
+		return ctx.FollowUpEmbed(emb).Send().Error
+	}
+
+	var sb strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "%s %s\n", hammertime.Format(e.Timestamp, hammertime.ShortDate), karmaLedgerLine(e, user.ID))
+	}
+	emb.Description = sb.String()
+	emb.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("Showing the latest %d changes.", len(entries)),
+	}
+
+	return ctx.FollowUpEmbed(emb).Send().Error
+}
+
+func (c *Karma) revert(ctx ken.SubCommandContext) (err error) {
+	pmw := ctx.Get(static.DiPermissions).(*permissions.Permissions)
+	kp := ctx.Get(static.DiKarma).(karma.Provider)
+	tp := ctx.Get(static.DiTimeProvider).(timeprovider.Provider)
+
+	ok, _, err := pmw.CheckPermissions(ctx.GetSession(), ctx.GetEvent().GuildID, ctx.User().ID, "sp.guild.mod.karma.revert")
+	if err != nil {
+		return
+	}
+	if !ok {
+		return ctx.FollowUpError("You don't have the required permissions.", "").Send().Error
+	}
+
+	user := ctx.Options().GetByName("user").UserValue(ctx)
+
+	since, err := timeutil.ParseDuration(ctx.Options().GetByName("since").StringValue())
+	if err != nil {
+		return ctx.FollowUpError("Invalid duration format for `since`.", "").Send().Error
+	}
+
+	var until time.Duration
+	if untilV, ok := ctx.Options().GetByNameOptional("until"); ok {
+		if until, err = timeutil.ParseDuration(untilV.StringValue()); err != nil {
+			return ctx.FollowUpError("Invalid duration format for `until`.", "").Send().Error
+		}
+	}
+
+	if until >= since {
+		return ctx.FollowUpError("`since` must be further in the past than `until`.", "").Send().Error
+	}
+
+	now := tp.Now()
+	from, to := now.Add(-since), now.Add(-until)
+
+	reverts, err := kp.Revert(ctx.GetEvent().GuildID, user.ID, ctx.User().ID, from, to)
+	if err != nil {
+		return
+	}
+
+	window := fmt.Sprintf("%s and %s",
+		hammertime.Format(from, hammertime.LongerDateTime), hammertime.Format(to, hammertime.LongerDateTime))
+
+	if len(reverts) == 0 {
+		return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+			Color:       static.ColorEmbedGray,
+			Description: fmt.Sprintf("%s has not given any karma between %s.", user.Mention(), window),
+		}).Send().Error
+	}
+
+	var sb strings.Builder
+	for _, rev := range reverts {
+		fmt.Fprintf(&sb, "<@%s>: **%+d**\n", rev.TargetID, rev.Delta)
+	}
+
+	return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Color: static.ColorEmbedUpdated,
+		Title: "Karma Reverted",
+		Description: fmt.Sprintf("Karma given by %s between %s has been reverted.\n\n%s",
+			user.Mention(), window, sb.String()),
+	}).Send().Error
+}
+
 func (c *Karma) userKarma(ctx ken.Context, user *discordgo.User) error {
 	st := ctx.Get(static.DiState).(*dgrs.State)
 	db := ctx.Get(static.DiDatabase).(database.Database)
@@ -150,3 +349,21 @@ func (c *Karma) userKarma(ctx ken.Context, user *discordgo.User) error {
 		Description: fmt.Sprintf("Guild Karma: **`%d`**\nGlobal Karma: **`%d`**", guildKarma, globalKarma),
 	}).Send().Error
 }
+
+// karmaLedgerLine describes the ledger entry from the
+// perspective of the user with the given ID.
+func karmaLedgerLine(e models.KarmaLedgerEntry, userID string) (line string) {
+	source := strings.ToLower(string(e.Source))
+	switch {
+	case e.TargetID != userID:
+		line = fmt.Sprintf("gave **%+d** to <@%s> (%s)", e.Delta, e.TargetID, source)
+	case e.ExecutorID != "":
+		line = fmt.Sprintf("**%+d** by <@%s> (%s)", e.Delta, e.ExecutorID, source)
+	default:
+		line = fmt.Sprintf("**%+d** (%s)", e.Delta, source)
+	}
+	if e.RevertedBy != 0 {
+		line = "~~" + line + "~~ reverted"
+	}
+	return
+}
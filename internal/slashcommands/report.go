@@ -1,7 +1,9 @@
 package slashcommands
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/bwmarrin/snowflake"
@@ -10,10 +12,15 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/services/report"
+	"github.com/zekroTJA/shinpuru/internal/services/storage"
 	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
 	"github.com/zekroTJA/shinpuru/internal/util/cmdutil"
+	"github.com/zekroTJA/shinpuru/internal/util/imgstore"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/acceptmsg/v2"
+	"github.com/zekroTJA/shinpuru/pkg/hammertime"
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
+	"github.com/zekroTJA/shinpuru/pkg/timeutil"
 	"github.com/zekrotja/ken"
 )
 
@@ -31,11 +38,11 @@ func (c *Report) Name() string {
 }
 
 func (c *Report) Description() string {
-	return "Create, revoke or list user reports."
+	return "Create, revoke, edit or list user reports."
 }
 
 func (c *Report) Version() string {
-	return "1.2.0"
+	return "1.3.0"
 }
 
 func (c *Report) Type() discordgo.ApplicationCommandType {
@@ -108,6 +115,47 @@ func (c *Report) Options() []*discordgo.ApplicationCommandOption {
 				},
 			},
 		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "edit",
+			Description: "Amend the reason, attachment or expiration of a report.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id",
+					Description: "ID of the report to be edited.",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "reason",
+					Description: "The new report reason.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "imageurl",
+					Description: "The new image url embedded into the report.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "expire",
+					Description: "Expire report after given time from now ('never' removes the expiration).",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "history",
+			Description: "Show the revisions of a report.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id",
+					Description: "ID of the report.",
+					Required:    true,
+				},
+			},
+		},
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
 			Name:        "list",
@@ -145,6 +193,11 @@ func (c *Report) SubDomains() []permissions.SubPermission {
 			Explicit:    false,
 			Description: "Revoke a report.",
 		},
+		{
+			Term:        "edit",
+			Explicit:    false,
+			Description: "Edit a report.",
+		},
 	}
 }
 
@@ -156,6 +209,8 @@ func (c *Report) Run(ctx ken.Context) (err error) {
 	err = ctx.HandleSubCommands(
 		ken.SubCommandHandler{"create", c.create},
 		ken.SubCommandHandler{"revoke", c.revoke},
+		ken.SubCommandHandler{"edit", c.edit},
+		ken.SubCommandHandler{"history", c.history},
 		ken.SubCommandHandler{"list", c.list},
 	)
 
@@ -253,6 +308,118 @@ func (c *Report) revoke(ctx ken.SubCommandContext) (err error) {
 	return aceptMsg.Error()
 }
 
+func (c *Report) edit(ctx ken.SubCommandContext) (err error) {
+	db, _ := ctx.Get(static.DiDatabase).(database.Database)
+	st, _ := ctx.Get(static.DiObjectStorage).(storage.Storage)
+	tp, _ := ctx.Get(static.DiTimeProvider).(timeprovider.Provider)
+	repSvc, _ := ctx.Get(static.DiReport).(*report.ReportService)
+	pmw := ctx.Get(static.DiPermissions).(*permissions.Permissions)
+
+	ok, err := pmw.CheckSubPerm(ctx, "edit", false)
+	if err != nil && ok {
+		return
+	}
+
+	rep, ok, err := c.getReport(ctx, db)
+	if !ok || err != nil {
+		return
+	}
+
+	var am models.ReportAmendment
+
+	if reasonV, ok := ctx.Options().GetByNameOptional("reason"); ok {
+		reason := reasonV.StringValue()
+		am.Msg = &reason
+	}
+
+	if imageurlV, ok := ctx.Options().GetByNameOptional("imageurl"); ok {
+		attachment, err := cmdutil.StoreAttachment(st, imageurlV.StringValue())
+		if err != nil {
+			return err
+		}
+		am.AttachmentURL = &attachment
+	}
+
+	if expireV, ok := ctx.Options().GetByNameOptional("expire"); ok {
+		if strings.EqualFold(expireV.StringValue(), "never") {
+			am.RemoveTimeout = true
+		} else {
+			exp, err := timeutil.ParseDuration(expireV.StringValue())
+			if err != nil {
+				return ctx.FollowUpError(
+					fmt.Sprintf("Invalid duration:\n```\n%s```", err.Error()), "").
+					Send().Error
+			}
+			expT := tp.Now().Add(exp)
+			am.Timeout = &expT
+		}
+	}
+
+	_, emb, err := repSvc.AmendReport(rep, am, ctx.User().ID)
+	if errors.Is(err, report.ErrNoChanges) ||
+		errors.Is(err, report.ErrMuteTimeout) ||
+		errors.Is(err, report.ErrInvalidTimeout) {
+		return ctx.FollowUpError(err.Error(), "").Send().Error
+	}
+	if emb == nil {
+		return err
+	}
+
+	return ctx.FollowUpEmbed(emb).Send().Error
+}
+
+func (c *Report) history(ctx ken.SubCommandContext) (err error) {
+	db, _ := ctx.Get(static.DiDatabase).(database.Database)
+	cfg, _ := ctx.Get(static.DiConfig).(config.Provider)
+	pmw := ctx.Get(static.DiPermissions).(*permissions.Permissions)
+
+	ok, err := pmw.CheckSubPerm(ctx, "list", false)
+	if err != nil && ok {
+		return
+	}
+
+	rep, ok, err := c.getReport(ctx, db)
+	if !ok || err != nil {
+		return
+	}
+
+	revs, err := db.GetReportRevisions(rep.ID)
+	if err != nil {
+		return
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: static.ColorEmbedDefault,
+		Title: fmt.Sprintf("History of Case %s", rep.ID),
+	}
+
+	if len(revs) == 0 {
+		emb.Description = "This report has not been edited."
+		emb.Fields = []*discordgo.MessageEmbedField{rep.AsEmbedField(cfg.Config().WebServer.PublicAddr)}
+		return ctx.FollowUpEmbed(emb).Send().Error
+	}
+
+	emb.Fields = make([]*discordgo.MessageEmbedField, 0, len(revs))
+	for _, rev := range revs {
+		expires := "never"
+		if rev.Timeout != nil {
+			expires = hammertime.Format(*rev.Timeout, hammertime.Span)
+		}
+		attachment := "none"
+		if rev.AttachmentURL != "" {
+			attachment = fmt.Sprintf("[[open](%s)]", imgstore.GetLink(rev.AttachmentURL, cfg.Config().WebServer.PublicAddr))
+		}
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("Revision %d", rev.Version),
+			Value: fmt.Sprintf("Time: %s\nEditor: <@%s>\nExpires: %s\nAttachment: %s\n__Reason__:\n%s",
+				hammertime.Format(rev.Timestamp, hammertime.LongerDateTime), rev.EditorID,
+				expires, attachment, stringutil.Cap(rev.Msg, 500)),
+		})
+	}
+
+	return ctx.FollowUpEmbed(emb).Send().Error
+}
+
 func (c *Report) list(ctx ken.SubCommandContext) (err error) {
 	db, _ := ctx.Get(static.DiDatabase).(database.Database)
 	cfg, _ := ctx.Get(static.DiConfig).(config.Provider)
@@ -286,3 +453,21 @@ func (c *Report) list(ctx ken.SubCommandContext) (err error) {
 	err = ctx.FollowUpEmbed(emb).Send().Error
 	return
 }
+
+func (c *Report) getReport(ctx ken.SubCommandContext, db database.Database) (rep models.Report, ok bool, err error) {
+	id, err := snowflake.ParseString(ctx.Options().GetByName("id").StringValue())
+	if err != nil {
+		return
+	}
+
+	rep, err = db.GetReport(id)
+	if database.IsErrDatabaseNotFound(err) || (err == nil && rep.GuildID != ctx.GetEvent().GuildID) {
+		err = ctx.FollowUpError(
+			fmt.Sprintf("Could not find any report with ID `%d`", id), "").
+			Send().Error
+		return
+	}
+
+	ok = err == nil
+	return
+}
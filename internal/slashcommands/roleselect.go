@@ -1,7 +1,10 @@
 package slashcommands
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -17,6 +20,11 @@ import (
 
 const nRoleOptions = 10
 
+var (
+	rxRoleSelectRoleID = regexp.MustCompile(`\d{15,}`)
+	rxRoleSelectPair   = regexp.MustCompile(`(\d{15,})>?\s*=\s*([^;]+)`)
+)
+
 type Roleselect struct{}
 
 var (
@@ -33,7 +41,7 @@ func (c *Roleselect) Description() string {
 }
 
 func (c *Roleselect) Version() string {
-	return "1.2.0"
+	return "1.3.0"
 }
 
 func (c *Roleselect) Type() discordgo.ApplicationCommandType {
@@ -41,7 +49,7 @@ func (c *Roleselect) Type() discordgo.ApplicationCommandType {
 }
 
 func (c *Roleselect) Options() []*discordgo.ApplicationCommandOption {
-	roleOptions := make([]*discordgo.ApplicationCommandOption, 0, nRoleOptions+1)
+	roleOptions := make([]*discordgo.ApplicationCommandOption, 0, nRoleOptions+6)
 
 	for i := 0; i < nRoleOptions; i++ {
 		roleOptions = append(roleOptions, &discordgo.ApplicationCommandOption{
@@ -52,6 +60,45 @@ func (c *Roleselect) Options() []*discordgo.ApplicationCommandOption {
 		})
 	}
 
+	roleOptions = append(roleOptions,
+		&discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "mode",
+			Description: "How roles can be picked (defaultly toggle).",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "toggle", Value: models.RoleSelectModeToggle},
+				{Name: "unique (pick one)", Value: models.RoleSelectModeUnique},
+				{Name: "limited (pick up to max)", Value: models.RoleSelectModeLimited},
+				{Name: "verify (add only)", Value: models.RoleSelectModeVerify},
+			},
+		},
+		&discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "max",
+			Description: "The maximum number of roles which can be picked in limited mode.",
+		},
+		&discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "required",
+			Description: "Roles (mentions or IDs) of which members need one to pick roles.",
+		},
+		&discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "menu",
+			Description: "Display the roles as select menu instead of buttons.",
+		},
+		&discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "labels",
+			Description: "Custom labels in the format `@Role=Label; @OtherRole=Other Label`.",
+		},
+		&discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "emojis",
+			Description: "Emojis in the format `@Role=🎉; @OtherRole=:custom:`.",
+		},
+	)
+
 	options := []*discordgo.ApplicationCommandOption{
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
@@ -108,23 +155,24 @@ func (c *Roleselect) create(ctx ken.SubCommandContext) error {
 		return err
 	}
 
+	g, err := c.getGroup(ctx)
+	if err != nil {
+		return ctx.FollowUpError(err.Error(), "").Send().Error
+	}
+
 	content := ctx.Options().GetByName("content").StringValue()
 
 	fum := ctx.FollowUpEmbed(&discordgo.MessageEmbed{
 		Description: content,
 	}).Send()
-
-	b := fum.AddComponents()
-
-	roleIDs, err := c.attachRoleButtons(ctx, b)
-	if err != nil {
-		return err
+	if fum.Error != nil {
+		return fum.Error
 	}
 
-	roleSelects := mapRoleSelects(ctx.GetEvent().GuildID, fum.ChannelID, fum.ID, roleIDs)
+	g.ChannelID = fum.ChannelID
+	g.MessageID = fum.ID
 
-	db := ctx.Get(static.DiDatabase).(database.Database)
-	return db.AddRoleSelects(roleSelects)
+	return c.attachGroup(ctx, fum.AddComponents(), g)
 }
 
 func (c *Roleselect) attach(ctx ken.SubCommandContext) error {
@@ -133,6 +181,11 @@ func (c *Roleselect) attach(ctx ken.SubCommandContext) error {
 		return err
 	}
 
+	g, err := c.getGroup(ctx)
+	if err != nil {
+		return ctx.FollowUpError(err.Error(), "").Send().Error
+	}
+
 	id := ctx.Options().GetByName("id").StringValue()
 
 	st := ctx.Get(static.DiState).(*dgrs.State)
@@ -146,18 +199,11 @@ func (c *Roleselect) attach(ctx ken.SubCommandContext) error {
 		return err
 	}
 
-	b := ctx.GetKen().Components().Add(msg.ID, msg.ChannelID)
-
-	roleIDs, err := c.attachRoleButtons(ctx, b)
-	if err != nil {
-		return err
-	}
+	g.ChannelID = msg.ChannelID
+	g.MessageID = msg.ID
 
-	roleSelects := mapRoleSelects(ctx.GetEvent().GuildID, msg.ChannelID, msg.ID, roleIDs)
-
-	db := ctx.Get(static.DiDatabase).(database.Database)
-	err = db.AddRoleSelects(roleSelects)
-	if err != nil {
+	b := ctx.GetKen().Components().Add(msg.ID, msg.ChannelID)
+	if err = c.attachGroup(ctx, b, g); err != nil {
 		return err
 	}
 
@@ -166,30 +212,70 @@ func (c *Roleselect) attach(ctx ken.SubCommandContext) error {
 	}).Send().DeleteAfter(6 * time.Second).Error
 }
 
-func (c *Roleselect) attachRoleButtons(
-	ctx ken.SubCommandContext,
-	b *ken.ComponentBuilder,
-) ([]string, error) {
-	roles := make([]*discordgo.Role, 0, nRoleOptions)
+// getGroup assembles and validates the role select
+// group from the passed command options.
+func (c *Roleselect) getGroup(ctx ken.SubCommandContext) (g models.RoleSelectGroup, err error) {
+	g.GuildID = ctx.GetEvent().GuildID
+
 	for i := 0; i < nRoleOptions; i++ {
 		r, ok := ctx.Options().GetByNameOptional(fmt.Sprintf("role%d", i+1))
 		if ok {
-			roles = append(roles, r.RoleValue(ctx))
+			g.Roles = append(g.Roles, models.RoleSelectOption{RoleID: r.RoleValue(ctx).ID})
+		}
+	}
+
+	if v, ok := ctx.Options().GetByNameOptional("mode"); ok {
+		g.Mode = models.RoleSelectMode(v.IntValue())
+	}
+	if v, ok := ctx.Options().GetByNameOptional("max"); ok {
+		g.MaxRoles = int(v.IntValue())
+	}
+	if v, ok := ctx.Options().GetByNameOptional("menu"); ok {
+		g.Menu = v.BoolValue()
+	}
+	if v, ok := ctx.Options().GetByNameOptional("required"); ok {
+		g.RequiredRoles = rxRoleSelectRoleID.FindAllString(v.StringValue(), -1)
+		if len(g.RequiredRoles) == 0 {
+			return g, errors.New("the required roles must be specified as role mentions or IDs")
 		}
 	}
 
-	return util.AttachRoleSelectButtons(b, roles)
+	setters := map[string]func(o *models.RoleSelectOption, v string){
+		"labels": func(o *models.RoleSelectOption, v string) { o.Label = v },
+		"emojis": func(o *models.RoleSelectOption, v string) { o.Emoji = v },
+	}
+	for name, set := range setters {
+		v, ok := ctx.Options().GetByNameOptional(name)
+		if !ok {
+			continue
+		}
+		matches := rxRoleSelectPair.FindAllStringSubmatch(v.StringValue(), -1)
+		if len(matches) == 0 {
+			return g, fmt.Errorf("%s must be specified in the format `@Role=value; @OtherRole=value`", name)
+		}
+		for _, m := range matches {
+			i := g.IndexOf(m[1])
+			if i == -1 {
+				return g, fmt.Errorf("the role <@&%s> is not part of the selection", m[1])
+			}
+			set(&g.Roles[i], strings.TrimSpace(m[2]))
+		}
+	}
+
+	err = g.Validate()
+	return g, err
 }
 
-func mapRoleSelects(guildID, channelID, msgID string, roleIDs []string) []models.RoleSelect {
-	roleSelects := make([]models.RoleSelect, 0, len(roleIDs))
-	for _, rid := range roleIDs {
-		roleSelects = append(roleSelects, models.RoleSelect{
-			GuildID:   guildID,
-			ChannelID: channelID,
-			MessageID: msgID,
-			RoleID:    rid,
-		})
+func (c *Roleselect) attachGroup(
+	ctx ken.SubCommandContext,
+	b *ken.ComponentBuilder,
+	g models.RoleSelectGroup,
+) error {
+	st := ctx.Get(static.DiState).(*dgrs.State)
+	if err := util.AttachRoleSelect(b, g, st); err != nil {
+		return err
 	}
-	return roleSelects
+
+	db := ctx.Get(static.DiDatabase).(database.Database)
+	return db.SetRoleSelects(g.GuildID, g.ChannelID, g.MessageID, g.RoleSelects())
 }
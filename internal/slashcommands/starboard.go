@@ -2,12 +2,14 @@ package slashcommands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
 	"github.com/zekrotja/ken"
 )
 
@@ -25,11 +27,11 @@ func (c *Starboard) Name() string {
 }
 
 func (c *Starboard) Description() string {
-	return "Set guild starboard settings."
+	return "Manage the starboards of the guild."
 }
 
 func (c *Starboard) Version() string {
-	return "1.0.0"
+	return "2.0.0"
 }
 
 func (c *Starboard) Type() discordgo.ApplicationCommandType {
@@ -37,12 +39,25 @@ func (c *Starboard) Type() discordgo.ApplicationCommandType {
 }
 
 func (c *Starboard) Options() []*discordgo.ApplicationCommandOption {
+	nameOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "name",
+		Description: "The name of the starboard.",
+		Required:    true,
+	}
+
 	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "List all starboards of the guild.",
+		},
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
 			Name:        "set",
-			Description: "Set starboard settings.",
+			Description: "Create a starboard or update its settings.",
 			Options: []*discordgo.ApplicationCommandOption{
+				nameOption,
 				{
 					Type:         discordgo.ApplicationCommandOptionChannel,
 					Name:         "channel",
@@ -64,12 +79,62 @@ func (c *Starboard) Options() []*discordgo.ApplicationCommandOption {
 					Name:        "karma",
 					Description: "The amount of karma gain when a users message gets into the starboard.",
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "nsfw",
+					Description: "How messages from NSFW channels are handled.",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "blur images", Value: models.StarboardNSFWBlur},
+						{Name: "skip", Value: models.StarboardNSFWSkip},
+						{Name: "route to NSFW channel", Value: models.StarboardNSFWRoute},
+					},
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "nsfw-channel",
+					Description:  "The NSFW channel where messages from NSFW channels are routed to.",
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "min-age",
+					Description: "The minimum age in minutes a message must have to get into the starboard.",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "filter",
+			Description: "Allow or deny messages from a channel for a starboard.",
+			Options: []*discordgo.ApplicationCommandOption{
+				nameOption,
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The source channel.",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Whether to allow, deny or unfilter the channel.",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "allow", Value: "allow"},
+						{Name: "deny", Value: "deny"},
+						{Name: "remove", Value: "remove"},
+					},
+				},
 			},
 		},
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
-			Name:        "disable",
-			Description: "Disable the starboard.",
+			Name:        "remove",
+			Description: "Remove a starboard.",
+			Options: []*discordgo.ApplicationCommandOption{
+				nameOption,
+			},
 		},
 	}
 }
@@ -88,99 +153,209 @@ func (c *Starboard) Run(ctx ken.Context) (err error) {
 	}
 
 	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{"list", c.list},
 		ken.SubCommandHandler{"set", c.set},
-		ken.SubCommandHandler{"disable", c.disable},
+		ken.SubCommandHandler{"filter", c.filter},
+		ken.SubCommandHandler{"remove", c.remove},
 	)
 
 	return
 }
 
+func (c *Starboard) list(ctx ken.SubCommandContext) (err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	boards, err := db.GetStarboardConfigs(ctx.GetEvent().GuildID)
+	if err != nil && !database.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	if len(boards) == 0 {
+		return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+			Description: "No starboards have been set up.",
+		}).Send().Error
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Title:  "Starboards",
+		Color:  static.ColorEmbedDefault,
+		Fields: make([]*discordgo.MessageEmbedField, len(boards)),
+	}
+	for i, board := range boards {
+		emb.Fields[i] = &discordgo.MessageEmbedField{
+			Name:  board.Name,
+			Value: describeStarboard(board),
+		}
+	}
+
+	return ctx.FollowUpEmbed(emb).Send().Error
+}
+
 func (c *Starboard) set(ctx ken.SubCommandContext) (err error) {
-	starboardConfig, err := c.getConfig(ctx)
+	board, exists, err := c.getConfig(ctx)
 	if err != nil {
 		return err
 	}
 
 	if v, ok := ctx.Options().GetByNameOptional("channel"); ok {
 		ch := v.ChannelValue(ctx)
-		starboardConfig.ChannelID = ch.ID
+		board.ChannelID = ch.ID
+	} else if !exists {
+		return ctx.FollowUpError("A channel must be set when creating a new starboard.", "").
+			Send().Error
 	}
 	if v, ok := ctx.Options().GetByNameOptional("threshold"); ok {
-		starboardConfig.Threshold = int(v.IntValue())
-		if starboardConfig.Threshold < 0 {
-			return ctx.FollowUpError("Threshold value must be equal or larger than `0`.", "").
-				Send().Error
-		}
+		board.Threshold = int(v.IntValue())
 	}
 	if v, ok := ctx.Options().GetByNameOptional("emote"); ok {
-		starboardConfig.EmojiID = v.StringValue()
+		board.EmojiID = v.StringValue()
 	}
 	if v, ok := ctx.Options().GetByNameOptional("karma"); ok {
-		starboardConfig.KarmaGain = int(v.IntValue())
-		if starboardConfig.KarmaGain < 0 {
-			return ctx.FollowUpError("Threshold value must be equal or larger than `0`.", "").
+		board.KarmaGain = int(v.IntValue())
+	}
+	if v, ok := ctx.Options().GetByNameOptional("nsfw"); ok {
+		board.NSFW = models.StarboardNSFWMode(v.IntValue())
+	}
+	if v, ok := ctx.Options().GetByNameOptional("nsfw-channel"); ok {
+		ch := v.ChannelValue(ctx)
+		if !ch.NSFW {
+			return ctx.FollowUpError("The NSFW channel must be marked as NSFW.", "").
 				Send().Error
 		}
+		board.NSFWChannelID = ch.ID
+	}
+	if v, ok := ctx.Options().GetByNameOptional("min-age"); ok {
+		board.MinAge = int(v.IntValue()) * 60
+	}
+
+	if err = board.Validate(); err != nil {
+		return ctx.FollowUpError(fmt.Sprintf("Invalid starboard settings: %s.", err.Error()), "").
+			Send().Error
+	}
+
+	return c.setConfig(ctx, board)
+}
+
+func (c *Starboard) filter(ctx ken.SubCommandContext) (err error) {
+	board, exists, err := c.getConfig(ctx)
+	if err != nil {
+		return err
 	}
+	if !exists {
+		return ctx.FollowUpError("There is no starboard with this name.", "").
+			Send().Error
+	}
+
+	channelID := ctx.Options().GetByName("channel").ChannelValue(ctx).ID
+	board.Channels = stringutil.Splice(board.Channels,
+		stringutil.IndexOf(channelID, board.Channels))
+	board.ExcludedChannels = stringutil.Splice(board.ExcludedChannels,
+		stringutil.IndexOf(channelID, board.ExcludedChannels))
 
-	return c.setConfig(ctx, starboardConfig, false)
+	switch ctx.Options().GetByName("mode").StringValue() {
+	case "allow":
+		board.Channels = append(board.Channels, channelID)
+	case "deny":
+		board.ExcludedChannels = append(board.ExcludedChannels, channelID)
+	}
+
+	return c.setConfig(ctx, board)
 }
 
-func (c *Starboard) disable(ctx ken.SubCommandContext) (err error) {
-	starboardConfig, err := c.getConfig(ctx)
+func (c *Starboard) remove(ctx ken.SubCommandContext) (err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	board, exists, err := c.getConfig(ctx)
 	if err != nil {
 		return err
 	}
+	if !exists {
+		return ctx.FollowUpError("There is no starboard with this name.", "").
+			Send().Error
+	}
 
-	starboardConfig.ChannelID = ""
+	if err = db.RemoveStarboardConfig(board.GuildID, board.Name); err != nil {
+		return err
+	}
 
-	return c.setConfig(ctx, starboardConfig, false)
+	return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Description: fmt.Sprintf("Starboard `%s` has been removed.", board.Name),
+	}).Send().Error
 }
 
-func (c *Starboard) getConfig(ctx ken.SubCommandContext) (starboardConfig models.StarboardConfig, err error) {
+func (c *Starboard) getConfig(ctx ken.SubCommandContext) (board models.StarboardConfig, exists bool, err error) {
 	db := ctx.Get(static.DiDatabase).(database.Database)
 
-	starboardConfig, err = db.GetStarboardConfig(ctx.GetEvent().GuildID)
+	guildID := ctx.GetEvent().GuildID
+	name := ctx.Options().GetByName("name").StringValue()
+
+	board, err = db.GetStarboardConfig(guildID, name)
 	if err != nil && !database.IsErrDatabaseNotFound(err) {
 		return
 	}
 
-	if database.IsErrDatabaseNotFound(err) {
-		starboardConfig = models.StarboardConfig{
+	exists = err == nil
+	if !exists {
+		board = models.StarboardConfig{
+			GuildID:   guildID,
+			Name:      name,
 			Threshold: 5,
 			EmojiID:   "⭐",
 			KarmaGain: 3,
 		}
 	}
-	starboardConfig.GuildID = ctx.GetEvent().GuildID
 
-	return
+	return board, exists, nil
 }
 
-func (c *Starboard) setConfig(
-	ctx ken.SubCommandContext,
-	cfg models.StarboardConfig,
-	outputError bool,
-) (err error) {
+func (c *Starboard) setConfig(ctx ken.SubCommandContext, board models.StarboardConfig) (err error) {
 	db := ctx.Get(static.DiDatabase).(database.Database)
 
-	if err = db.SetStarboardConfig(cfg); err != nil {
-		if outputError {
-			return ctx.FollowUpError(
-				"Failed settings starboard config to database: ```"+err.Error()+"```", "").
-				Send().Error
-		}
+	if err = db.SetStarboardConfig(board); err != nil {
 		return err
 	}
 
-	msg := fmt.Sprintf(
-		"Set starboard config:\n\nChannel: <#%s>\nThreshold: `%d`\nEmote: %s\nKarma Gain: `%d`",
-		cfg.ChannelID, cfg.Threshold, cfg.EmojiID, cfg.KarmaGain)
-	if cfg.ChannelID == "" {
-		msg = "Starboard disabled. Set a channel as starboard channel to enable the starboard."
-	}
-
 	return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
-		Description: msg,
+		Title:       fmt.Sprintf("Starboard `%s`", board.Name),
+		Description: describeStarboard(board),
 	}).Send().Error
 }
+
+func describeStarboard(board models.StarboardConfig) string {
+	if board.ChannelID == "" {
+		return "Disabled. Set a channel to enable the starboard."
+	}
+
+	var nsfw string
+	switch board.NSFW {
+	case models.StarboardNSFWSkip:
+		nsfw = "skip"
+	case models.StarboardNSFWRoute:
+		nsfw = fmt.Sprintf("route to <#%s>", board.NSFWChannelID)
+	default:
+		nsfw = "blur images"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Channel: <#%s>\nThreshold: `%d`\nEmote: %s\nKarma Gain: `%d`\nNSFW: %s",
+		board.ChannelID, board.Threshold, board.EmojiID, board.KarmaGain, nsfw)
+	if board.MinAge > 0 {
+		fmt.Fprintf(&sb, "\nMinimum Age: `%d minutes`", board.MinAge/60)
+	}
+	if len(board.Channels) > 0 {
+		fmt.Fprintf(&sb, "\nAllowed Channels: %s", joinChannelMentions(board.Channels))
+	}
+	if len(board.ExcludedChannels) > 0 {
+		fmt.Fprintf(&sb, "\nDenied Channels: %s", joinChannelMentions(board.ExcludedChannels))
+	}
+
+	return sb.String()
+}
+
+func joinChannelMentions(ids []string) string {
+	mentions := make([]string, len(ids))
+	for i, id := range ids {
+		mentions[i] = "<#" + id + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
@@ -2,6 +2,7 @@ package slashcommands
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
@@ -36,7 +37,7 @@ func (c *Tag) Description() string {
 }
 
 func (c *Tag) Version() string {
-	return "1.1.0"
+	return "1.2.0"
 }
 
 func (c *Tag) Type() discordgo.ApplicationCommandType {
@@ -57,6 +58,16 @@ func (c *Tag) Options() []*discordgo.ApplicationCommandOption {
 					Required:     true,
 					Autocomplete: true,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "target",
+					Description: "The user used as [target] in the tag.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "args",
+					Description: "Space separated arguments used as [args] and [arg1], [arg2], ... in the tag.",
+				},
 			},
 		},
 		{
@@ -81,6 +92,41 @@ func (c *Tag) Options() []*discordgo.ApplicationCommandOption {
 					Description: "The content of the tag. You can use markdown as well as `\\n` for line breaks.",
 					Required:    true,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "aliases",
+					Description: "Comma separated alternative names of the tag.",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "restrict",
+			Description: "Restrict the usage of a tag to a role or lift the restriction.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "name",
+					Description:  "The name of the Tag.",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "The role which is allowed to use the tag.",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Whether to add or remove the role.",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "add", Value: "add"},
+						{Name: "remove", Value: "remove"},
+					},
+				},
 			},
 		},
 		{
@@ -166,6 +212,12 @@ func (c *Tag) Autocomplete(ctx *ken.AutocompleteContext) ([]*discordgo.Applicati
 		}
 	}
 
+	// Matches are sorted by score, so equally often used
+	// tags stay in the order of their match score.
+	sort.SliceStable(matches, func(i, j int) bool {
+		return tags[matches[i].Index].Uses > tags[matches[j].Index].Uses
+	})
+
 	results := make([]*discordgo.ApplicationCommandOptionChoice, 0, matches.Len())
 	for i, match := range matches {
 		if i > maxResults {
@@ -187,6 +239,7 @@ func (c *Tag) Run(ctx ken.Context) (err error) {
 		ken.SubCommandHandler{"raw", c.showRaw},
 		ken.SubCommandHandler{"list", c.list},
 		ken.SubCommandHandler{"set", c.set},
+		ken.SubCommandHandler{"restrict", c.restrict},
 		ken.SubCommandHandler{"delete", c.delete},
 	)
 
@@ -201,13 +254,24 @@ func (c *Tag) show(ctx ken.SubCommandContext) (err error) {
 	db := ctx.Get(static.DiDatabase).(database.Database)
 	st := ctx.Get(static.DiState).(*dgrs.State)
 
-	ident := strings.ToLower(ctx.Options().GetByName("name").StringValue())
+	tg, ok, err := c.getUsableTag(ctx)
+	if !ok {
+		return
+	}
 
-	tg, err := db.GetTagByIdent(ident, ctx.GetEvent().GuildID)
-	if database.IsErrDatabaseNotFound(err) {
-		return ctx.FollowUpError("Tag could not be found.", "").Send().Error
+	rc := tag.RenderContext{
+		User:      ctx.User(),
+		ChannelID: ctx.GetEvent().ChannelID,
 	}
-	if err != nil {
+	if v, ok := ctx.Options().GetByNameOptional("target"); ok {
+		rc.Target = v.UserValue(ctx)
+	}
+	if v, ok := ctx.Options().GetByNameOptional("args"); ok {
+		rc.Args = strings.Fields(v.StringValue())
+	}
+	tg.Content = tg.Render(rc)
+
+	if err = db.IncrementTagUses(tg.ID); err != nil {
 		return
 	}
 
@@ -219,15 +283,8 @@ func (c *Tag) showRaw(ctx ken.SubCommandContext) (err error) {
 		return
 	}
 
-	db := ctx.Get(static.DiDatabase).(database.Database)
-
-	ident := strings.ToLower(ctx.Options().GetByName("name").StringValue())
-
-	tg, err := db.GetTagByIdent(ident, ctx.GetEvent().GuildID)
-	if database.IsErrDatabaseNotFound(err) {
-		return ctx.FollowUpError("Tag could not be found.", "").Send().Error
-	}
-	if err != nil {
+	tg, ok, err := c.getUsableTag(ctx)
+	if !ok {
 		return
 	}
 
@@ -272,6 +329,12 @@ func (c *Tag) set(ctx ken.SubCommandContext) (err error) {
 	ident := strings.ToLower(ctx.Options().GetByName("name").StringValue())
 	content := ctx.Options().GetByName("content").StringValue()
 
+	var aliases []string
+	aliasesOpt, setAliases := ctx.Options().GetByNameOptional("aliases")
+	if setAliases {
+		aliases = strings.Split(aliasesOpt.StringValue(), ",")
+	}
+
 	tg, err := db.GetTagByIdent(ident, ctx.GetEvent().GuildID)
 
 	if database.IsErrDatabaseNotFound(err) {
@@ -292,6 +355,10 @@ func (c *Tag) set(ctx ken.SubCommandContext) (err error) {
 			ID:        snowflakenodes.NodeTags.Generate(),
 			Ident:     ident,
 			LastEdit:  now,
+			Aliases:   aliases,
+		}
+		if ok, err = c.checkTag(ctx, &tg); !ok {
+			return err
 		}
 		if err = db.AddTag(tg); err != nil {
 			return err
@@ -308,6 +375,12 @@ func (c *Tag) set(ctx ken.SubCommandContext) (err error) {
 		return
 	}
 
+	if tg.Ident != ident {
+		return ctx.FollowUpError(
+			fmt.Sprintf("`%s` is already used as alias of the tag `%s`.", ident, tg.Ident), "").
+			Send().Error
+	}
+
 	if tg.CreatorID != ctx.User().ID {
 		ok, err := pmw.CheckSubPerm(ctx, "edit", true,
 			"A tag with the same nam (created by another user) already exists and you do not have the permission to edit it.")
@@ -316,6 +389,15 @@ func (c *Tag) set(ctx ken.SubCommandContext) (err error) {
 		}
 	}
 
+	edited := tg
+	edited.Content = content
+	if setAliases {
+		edited.Aliases = aliases
+	}
+	if ok, err := c.checkTag(ctx, &edited); !ok {
+		return err
+	}
+
 	var creator *discordgo.User
 	creator, err = st.User(tg.CreatorID)
 	if err != nil {
@@ -341,8 +423,7 @@ func (c *Tag) set(ctx ken.SubCommandContext) (err error) {
 			if err = cctx.Defer(); err != nil {
 				return
 			}
-			tg.Content = content
-			if err = db.EditTag(tg); err != nil {
+			if err = db.EditTag(edited); err != nil {
 				return
 			}
 			return cctx.FollowUpEmbed(&discordgo.MessageEmbed{
@@ -391,3 +472,108 @@ func (c *Tag) delete(ctx ken.SubCommandContext) (err error) {
 		Description: "Tag has been deleted.",
 	})
 }
+
+func (c *Tag) restrict(ctx ken.SubCommandContext) (err error) {
+	ctx.SetEphemeral(true)
+	if err = ctx.Defer(); err != nil {
+		return
+	}
+
+	db := ctx.Get(static.DiDatabase).(database.Database)
+	pmw := ctx.Get(static.DiPermissions).(*permissions.Permissions)
+
+	ident := strings.ToLower(ctx.Options().GetByName("name").StringValue())
+
+	tg, err := db.GetTagByIdent(ident, ctx.GetEvent().GuildID)
+	if database.IsErrDatabaseNotFound(err) {
+		return ctx.FollowUpError("Tag could not be found.", "").Send().Error
+	}
+	if err != nil {
+		return
+	}
+
+	if tg.CreatorID != ctx.User().ID {
+		ok, err := pmw.CheckSubPerm(ctx, "edit", true,
+			"You do not have the permission to edit tags of other users.")
+		if !ok {
+			return err
+		}
+	}
+
+	roleID := ctx.Options().GetByName("role").RoleValue(ctx).ID
+	tg.Roles = stringutil.Splice(tg.Roles, stringutil.IndexOf(roleID, tg.Roles))
+	if ctx.Options().GetByName("mode").StringValue() == "add" {
+		tg.Roles = append(tg.Roles, roleID)
+	}
+
+	if err = db.EditTag(tg); err != nil {
+		return
+	}
+
+	desc := fmt.Sprintf("Tag `%s` can now be used by everyone.", tg.Ident)
+	if len(tg.Roles) > 0 {
+		roles := make([]string, len(tg.Roles))
+		for i, id := range tg.Roles {
+			roles[i] = "<@&" + id + ">"
+		}
+		desc = fmt.Sprintf("Tag `%s` can now only be used by the following roles:\n%s",
+			tg.Ident, strings.Join(roles, ", "))
+	}
+
+	return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Description: desc,
+	}).Send().Error
+}
+
+// getUsableTag returns the tag specified by the name option
+// if it exists and the executing member is allowed to use it.
+// Otherwise, an error message is sent and ok is false.
+func (c *Tag) getUsableTag(ctx ken.SubCommandContext) (tg tag.Tag, ok bool, err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	ident := strings.ToLower(ctx.Options().GetByName("name").StringValue())
+
+	tg, err = db.GetTagByIdent(ident, ctx.GetEvent().GuildID)
+	if database.IsErrDatabaseNotFound(err) {
+		err = ctx.FollowUpError("Tag could not be found.", "").Send().Error
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if member := ctx.GetEvent().Member; member != nil && !tg.CanUse(member.Roles) {
+		err = ctx.FollowUpError("You are not allowed to use this tag.", "").Send().Error
+		return
+	}
+
+	return tg, true, nil
+}
+
+// checkTag normalizes and validates the passed tag and checks
+// that none of its names is used by another tag of the guild.
+// Otherwise, an error message is sent and ok is false.
+func (c *Tag) checkTag(ctx ken.SubCommandContext, tg *tag.Tag) (ok bool, err error) {
+	db := ctx.Get(static.DiDatabase).(database.Database)
+
+	tg.Normalize()
+	if err = tg.Validate(); err != nil {
+		err = ctx.FollowUpError(fmt.Sprintf("Invalid tag: %s.", err.Error()), "").Send().Error
+		return
+	}
+
+	tags, err := db.GetGuildTags(tg.GuildID)
+	if err != nil {
+		return
+	}
+	for _, other := range tags {
+		if name := tg.ConflictsWith(other); name != "" {
+			err = ctx.FollowUpError(
+				fmt.Sprintf("The name `%s` is already used by the tag `%s`.", name, other.Ident), "").
+				Send().Error
+			return
+		}
+	}
+
+	return true, nil
+}
@@ -217,6 +217,12 @@ func (c *Twitchnotify) remove(ctx ken.SubCommandContext) (err error) {
 		return err
 	}
 
+	if len(nots) == 1 {
+		if err = tnw.RemoveUser(twitchuser.ID); err != nil {
+			return err
+		}
+	}
+
 	return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
 		Description: fmt.Sprintf("Notifications for twitch user `%s` in channel <#%s> have been removed.",
 			twitchuser.DisplayName, notify.ChannelID),
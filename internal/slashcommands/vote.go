@@ -2,6 +2,8 @@ package slashcommands
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,13 +11,23 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/permissions"
 	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
+	"github.com/zekroTJA/shinpuru/internal/services/votes"
+	"github.com/zekroTJA/shinpuru/internal/util"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/internal/util/vote"
 	"github.com/zekroTJA/shinpuru/pkg/discordutil"
+	"github.com/zekroTJA/shinpuru/pkg/fetch"
 	"github.com/zekroTJA/shinpuru/pkg/timeutil"
 	"github.com/zekrotja/ken"
 )
 
+var minVotePicks = float64(1)
+
+var (
+	rxVoteRoleID     = regexp.MustCompile(`\d{15,}`)
+	rxVoteRoleWeight = regexp.MustCompile(`(\d{15,})>?\s*=\s*(\d+)`)
+)
+
 type Vote struct{}
 
 var (
@@ -32,7 +44,7 @@ func (c *Vote) Description() string {
 }
 
 func (c *Vote) Version() string {
-	return "1.0.0"
+	return "1.1.0"
 }
 
 func (c *Vote) Type() discordgo.ApplicationCommandType {
@@ -74,6 +86,37 @@ func (c *Vote) Options() []*discordgo.ApplicationCommandOption {
 					Name:        "timeout",
 					Description: "Timeout of the vote (i.e. `1h`, `30m`, ...)",
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "mode",
+					Description: "How choises can be picked (defaultly single choice).",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "single choice", Value: vote.VoteModeSingle},
+						{Name: "multiple choice", Value: vote.VoteModeMulti},
+						{Name: "ranked choice", Value: vote.VoteModeRanked},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "max-picks",
+					Description: "The maximum number of picks per user for multiple choice votes.",
+					MinValue:    &minVotePicks,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "anonymous",
+					Description: "Vote with buttons instead of reactions so that nobody can see who voted.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "roles",
+					Description: "Mentions of the roles which are allowed to vote.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "weights",
+					Description: "Vote weights of roles (i.e. `@Moderators=3, @Members=2`).",
+				},
 			},
 		},
 		{
@@ -201,6 +244,60 @@ func (c *Vote) create(ctx ken.SubCommandContext) (err error) {
 		Ticks:         make(map[string]*vote.Tick),
 	}
 
+	if modeV, ok := ctx.Options().GetByNameOptional("mode"); ok {
+		ivote.Mode = vote.VoteMode(modeV.IntValue())
+	}
+	if maxPicksV, ok := ctx.Options().GetByNameOptional("max-picks"); ok {
+		if ivote.Mode != vote.VoteModeMulti {
+			return ctx.FollowUpError(
+				"A maximum number of picks can only be set for multiple choice votes.", "").
+				Send().Error
+		}
+		ivote.MaxPicks = int(maxPicksV.IntValue())
+	}
+	if anonymousV, ok := ctx.Options().GetByNameOptional("anonymous"); ok {
+		ivote.Anonymous = anonymousV.BoolValue()
+	}
+
+	if rolesV, ok := ctx.Options().GetByNameOptional("roles"); ok {
+		ivote.Roles = rxVoteRoleID.FindAllString(rolesV.StringValue(), -1)
+		if len(ivote.Roles) == 0 {
+			return ctx.FollowUpError(
+				"Please specify the allowed roles as role mentions or IDs.", "").
+				Send().Error
+		}
+		for _, id := range ivote.Roles {
+			if _, err = fetch.FetchRole(ctx.GetSession(), ivote.GuildID, id); err != nil {
+				return ctx.FollowUpError(
+					fmt.Sprintf("The role `%s` could not be found.", id), "").
+					Send().Error
+			}
+		}
+	}
+	if weightsV, ok := ctx.Options().GetByNameOptional("weights"); ok {
+		matches := rxVoteRoleWeight.FindAllStringSubmatch(weightsV.StringValue(), -1)
+		if len(matches) == 0 {
+			return ctx.FollowUpError(
+				"Please specify the weights in the format `@Role=3, @OtherRole=2`.", "").
+				Send().Error
+		}
+		ivote.RoleWeights = make(map[string]int)
+		for _, m := range matches {
+			if _, err = fetch.FetchRole(ctx.GetSession(), ivote.GuildID, m[1]); err != nil {
+				return ctx.FollowUpError(
+					fmt.Sprintf("The role `%s` could not be found.", m[1]), "").
+					Send().Error
+			}
+			weight, _ := strconv.Atoi(m[2])
+			if weight < 1 || weight > 100 {
+				return ctx.FollowUpError(
+					"Weights must be in range of 1 to 100.", "").
+					Send().Error
+			}
+			ivote.RoleWeights[m[1]] = weight
+		}
+	}
+
 	emb, err := ivote.AsEmbed(ctx.GetSession())
 	if err != nil {
 		return err
@@ -211,10 +308,14 @@ func (c *Vote) create(ctx ken.SubCommandContext) (err error) {
 	var msg *discordgo.Message
 	if ok {
 		ch := chV.ChannelValue(ctx)
-		msg, err = ctx.GetSession().ChannelMessageSendEmbed(ch.ID, emb)
+		msg, err = ctx.GetSession().ChannelMessageSendComplex(ch.ID, &discordgo.MessageSend{
+			Embed:      emb,
+			Components: ivote.Components(),
+		})
 		if err != nil {
 			return
 		}
+		ivote.ChannelID = ch.ID
 		msgLink := discordutil.GetMessageLink(msg, ctx.GetEvent().GuildID)
 		err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
 			Description: fmt.Sprintf("[Vote](%s) created in channel <#%s>.", msgLink, ch.ID),
@@ -223,7 +324,10 @@ func (c *Vote) create(ctx ken.SubCommandContext) (err error) {
 			return
 		}
 	} else {
-		fum := ctx.FollowUpEmbed(emb).Send()
+		fum := ctx.FollowUp(true, &discordgo.WebhookParams{
+			Embeds:     []*discordgo.MessageEmbed{emb},
+			Components: ivote.Components(),
+		}).Send()
 		err = fum.Error
 		if err != nil {
 			return
@@ -242,7 +346,13 @@ func (c *Vote) create(ctx ken.SubCommandContext) (err error) {
 		return err
 	}
 
-	vote.VotesRunning[ivote.ID] = ivote
+	vs, _ := ctx.Get(static.DiVotes).(*votes.Service)
+	if err = vs.Schedule(ivote); err != nil {
+		return err
+	}
+
+	vote.VotesRunning.Set(ivote)
+	util.RegisterVoteButtons(ctx.GetKen().Components(), db, ivote)
 	return
 }
 
@@ -252,11 +362,12 @@ func (c *Vote) list(ctx ken.SubCommandContext) (err error) {
 		Color:       static.ColorEmbedDefault,
 		Fields:      make([]*discordgo.MessageEmbedField, 0),
 	}
-	for _, v := range vote.VotesRunning {
+	vote.VotesRunning.Range(func(v vote.Vote) bool {
 		if v.GuildID == ctx.GetEvent().GuildID && v.CreatorID == ctx.User().ID {
 			emb.Fields = append(emb.Fields, v.AsField())
 		}
-	}
+		return true
+	})
 	if len(emb.Fields) == 0 {
 		emb.Description = "You don't have any open votes on this guild."
 	}
@@ -276,17 +387,23 @@ func (c *Vote) expire(ctx ken.SubCommandContext) (err error) {
 	}
 
 	id := ctx.Options().Get(0).StringValue()
-	var ivote *vote.Vote
-	for _, v := range vote.VotesRunning {
-		if v.GuildID == ctx.GetEvent().GuildID && v.ID == id {
-			ivote = &v
-		}
+	ivote, ok := vote.VotesRunning.Get(id)
+	if !ok || ivote.GuildID != ctx.GetEvent().GuildID {
+		return ctx.FollowUpError(
+			"There is no running vote with this ID on this guild.", "").
+			Send().Error
 	}
 
 	tp := ctx.Get(static.DiTimeProvider).(timeprovider.Provider)
 
 	ivote.SetExpire(ctx.GetSession(), expireDuration, tp)
-	if err = db.AddUpdateVote(*ivote); err != nil {
+	if err = db.AddUpdateVote(ivote); err != nil {
+		return err
+	}
+	vote.VotesRunning.Set(ivote)
+
+	vs, _ := ctx.Get(static.DiVotes).(*votes.Service)
+	if err = vs.Schedule(ivote); err != nil {
 		return err
 	}
 
@@ -296,7 +413,7 @@ func (c *Vote) expire(ctx ken.SubCommandContext) (err error) {
 }
 
 func (c *Vote) close(ctx ken.SubCommandContext) (err error) {
-	db, _ := ctx.Get(static.DiDatabase).(database.Database)
+	vs, _ := ctx.Get(static.DiVotes).(*votes.Service)
 
 	state := vote.VoteStateClosed
 
@@ -307,27 +424,26 @@ func (c *Vote) close(ctx ken.SubCommandContext) (err error) {
 	id := ctx.Options().GetByName("id").StringValue()
 
 	if strings.ToLower(id) == "all" {
-		var i int
-		for _, v := range vote.VotesRunning {
+		var toClose []vote.Vote
+		vote.VotesRunning.Range(func(v vote.Vote) bool {
 			if v.GuildID == ctx.GetEvent().GuildID && v.CreatorID == ctx.User().ID {
-				go func(vC vote.Vote) {
-					db.DeleteVote(vC.ID)
-					vC.Close(ctx.GetSession(), state)
-				}(v)
-				i++
+				toClose = append(toClose, v)
 			}
+			return true
+		})
+		for _, v := range toClose {
+			go vs.Close(v, state)
 		}
 		return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
-			Description: fmt.Sprintf("Closed %d votes.", i),
+			Description: fmt.Sprintf("Closed %d votes.", len(toClose)),
 		}).Send().Error
 	}
 
-	var ivote *vote.Vote
-	for _, v := range vote.VotesRunning {
-		if v.GuildID == ctx.GetEvent().GuildID && v.ID == id {
-			ivote = &v
-			break
-		}
+	ivote, ok := vote.VotesRunning.Get(id)
+	if !ok || ivote.GuildID != ctx.GetEvent().GuildID {
+		return ctx.FollowUpError(
+			"There is no running vote with this ID on this guild.", "").
+			Send().Error
 	}
 
 	pmw, _ := ctx.Get(static.DiPermissions).(*permissions.Permissions)
@@ -341,14 +457,17 @@ func (c *Vote) close(ctx ken.SubCommandContext) (err error) {
 			Send().Error
 	}
 
-	err = db.DeleteVote(ivote.ID)
+	// Closed votes are kept so that their results can
+	// still be exported.
+	closed, err := vs.Close(ivote, state)
 	if err != nil {
-		return err
-	}
-
-	if err = ivote.Close(ctx.GetSession(), state); err != nil {
 		return
 	}
+	if !closed {
+		return ctx.FollowUpError(
+			"This vote has already been closed.", "").
+			Send().Error
+	}
 
 	err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
 		Description: "Vote closed.",
@@ -0,0 +1,273 @@
+package antiraid
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+const (
+	SignalAccountAge = "account age"
+	SignalSimilarity = "similarity"
+	SignalSpam       = "spam"
+)
+
+// Join contains the information about a joining
+// member which is used to compare joins.
+type Join struct {
+	UserID   string
+	Username string
+	Avatar   string
+	Time     time.Time
+}
+
+// Activity contains the messages sent by a member
+// after joining the guild.
+type Activity struct {
+	Joined      time.Time
+	Messages    int
+	MaxMentions int
+}
+
+// SignalResult is the outcome of the evaluation of
+// a single signal. Score is in range [0, 1] and the
+// signal is triggered when Score reaches 1.
+type SignalResult struct {
+	Signal   string
+	Score    float64
+	Response models.AntiraidResponse
+	Detail   string
+}
+
+// Triggered returns true if the signal has been triggered.
+func (r SignalResult) Triggered() bool {
+	return r.Score >= 1
+}
+
+// Decision wraps the results of all evaluated signals
+// for a member and the resulting response.
+type Decision struct {
+	UserID   string
+	Results  []SignalResult
+	Response models.AntiraidResponse
+}
+
+// NewDecision returns a decision for the given results.
+// The response is the most severe response of all
+// triggered signals.
+func NewDecision(userID string, results ...SignalResult) Decision {
+	d := Decision{UserID: userID, Results: results}
+	for _, r := range results {
+		if r.Triggered() && r.Response > d.Response {
+			d.Response = r.Response
+		}
+	}
+	return d
+}
+
+// Triggered returns true if at least one signal
+// has been triggered.
+func (d Decision) Triggered() bool {
+	for _, r := range d.Results {
+		if r.Triggered() {
+			return true
+		}
+	}
+	return false
+}
+
+// Relevant returns true if at least one signal
+// has a score larger than 0.
+func (d Decision) Relevant() bool {
+	for _, r := range d.Results {
+		if r.Score > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Breakdown returns a human readable listing of the
+// scores of all evaluated signals.
+func (d Decision) Breakdown() string {
+	var sb strings.Builder
+	for i, r := range d.Results {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		mark := ""
+		if r.Triggered() {
+			mark = " [triggered → " + r.Response.String() + "]"
+		}
+		fmt.Fprintf(&sb, "%s: %.2f (%s)%s", r.Signal, r.Score, r.Detail, mark)
+	}
+	return sb.String()
+}
+
+// EvaluateAccountAge scores the account age of a member
+// created at created. The signal is triggered when the
+// account is younger than the minimum age.
+func EvaluateAccountAge(sig models.AntiraidAccountAgeSignal, created, now time.Time) SignalResult {
+	minAge := time.Duration(sig.MinAge) * time.Second
+	age := now.Sub(created)
+
+	score := 0.0
+	if age < minAge {
+		score = 1
+	}
+
+	return SignalResult{
+		Signal:   SignalAccountAge,
+		Score:    score,
+		Response: sig.Response,
+		Detail:   fmt.Sprintf("account age %s, minimum %s", formatDuration(age), formatDuration(minAge)),
+	}
+}
+
+// EvaluateSimilarity scores how many of the recent joins
+// within the signal's window are similar to join.
+func EvaluateSimilarity(sig models.AntiraidSimilaritySignal, join Join, recent []Join) SignalResult {
+	window := time.Duration(sig.Window) * time.Second
+
+	var matches int
+	var highest float64
+	for _, r := range recent {
+		if r.UserID == join.UserID || join.Time.Sub(r.Time) > window {
+			continue
+		}
+		sim := JoinSimilarity(join, r)
+		if sim > highest {
+			highest = sim
+		}
+		if sim >= sig.Threshold {
+			matches++
+		}
+	}
+
+	return SignalResult{
+		Signal:   SignalSimilarity,
+		Score:    ratio(matches, sig.MinMatches),
+		Response: sig.Response,
+		Detail: fmt.Sprintf("%d of %d required similar joins, highest similarity %.2f",
+			matches, sig.MinMatches, highest),
+	}
+}
+
+// EvaluateSpam scores the activity of a member after
+// joining against the message and mention limits.
+func EvaluateSpam(sig models.AntiraidSpamSignal, act Activity) SignalResult {
+	score := ratio(act.Messages, sig.Messages)
+	if s := ratio(act.MaxMentions, sig.Mentions); s > score {
+		score = s
+	}
+
+	return SignalResult{
+		Signal:   SignalSpam,
+		Score:    score,
+		Response: sig.Response,
+		Detail: fmt.Sprintf("%d messages, up to %d mentions per message",
+			act.Messages, act.MaxMentions),
+	}
+}
+
+// JoinSimilarity returns the similarity of two joins in
+// range [0, 1]. Joins with the same custom avatar are
+// considered identical, otherwise the similarity of the
+// usernames is returned.
+func JoinSimilarity(a, b Join) float64 {
+	if a.Avatar != "" && a.Avatar == b.Avatar {
+		return 1
+	}
+	return NameSimilarity(a.Username, b.Username)
+}
+
+// NameSimilarity returns the similarity of two usernames
+// in range [0, 1] based on the Levenshtein distance of the
+// names. Digits and special characters are ignored so that
+// names like "raider_123" and "raider_456" are identical.
+func NameSimilarity(a, b string) float64 {
+	ra, rb := []rune(normalizeName(a)), []rune(normalizeName(b))
+
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	if maxLen == 0 {
+		return 0
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	letters := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return r
+		}
+		return -1
+	}, name)
+	if letters == "" {
+		return name
+	}
+	return letters
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min(v ...int) int {
+	m := v[0]
+	for _, e := range v[1:] {
+		if e < m {
+			m = e
+		}
+	}
+	return m
+}
+
+// ratio returns n/limit capped to 1. If limit
+// is 0, the check is disabled and 0 is returned.
+func ratio(n, limit int) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	r := float64(n) / float64(limit)
+	if r > 1 {
+		r = 1
+	}
+	return r
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
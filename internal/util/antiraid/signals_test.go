@@ -0,0 +1,96 @@
+package antiraid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+func TestEvaluateAccountAge(t *testing.T) {
+	sig := models.AntiraidAccountAgeSignal{
+		Response: models.AntiraidResponseKick,
+		MinAge:   int((24 * time.Hour).Seconds()),
+	}
+	now := time.Now()
+
+	res := EvaluateAccountAge(sig, now.Add(-time.Hour), now)
+	assert.True(t, res.Triggered())
+	assert.Equal(t, models.AntiraidResponseKick, res.Response)
+
+	res = EvaluateAccountAge(sig, now.Add(-48*time.Hour), now)
+	assert.False(t, res.Triggered())
+	assert.Equal(t, 0.0, res.Score)
+}
+
+func TestEvaluateSimilarity(t *testing.T) {
+	sig := models.AntiraidSimilaritySignal{
+		Response:   models.AntiraidResponseQuarantine,
+		Threshold:  0.8,
+		Window:     60,
+		MinMatches: 2,
+	}
+	now := time.Now()
+
+	join := Join{UserID: "4", Username: "raider_4", Time: now}
+	recent := []Join{
+		{UserID: "1", Username: "raider_1", Time: now.Add(-2 * time.Minute)},
+		{UserID: "2", Username: "raider_2", Time: now.Add(-30 * time.Second)},
+		{UserID: "3", Username: "someone", Time: now.Add(-10 * time.Second)},
+	}
+
+	res := EvaluateSimilarity(sig, join, recent)
+	assert.False(t, res.Triggered())
+	assert.Equal(t, 0.5, res.Score)
+
+	recent = append(recent, Join{UserID: "5", Username: "x", Avatar: "abc", Time: now})
+	join.Avatar = "abc"
+	res = EvaluateSimilarity(sig, join, recent)
+	assert.True(t, res.Triggered())
+}
+
+func TestEvaluateSpam(t *testing.T) {
+	sig := models.AntiraidSpamSignal{
+		Response: models.AntiraidResponseTimeout,
+		Window:   60,
+		Messages: 4,
+		Mentions: 5,
+	}
+
+	res := EvaluateSpam(sig, Activity{Messages: 2, MaxMentions: 1})
+	assert.False(t, res.Triggered())
+	assert.Equal(t, 0.5, res.Score)
+
+	res = EvaluateSpam(sig, Activity{Messages: 1, MaxMentions: 6})
+	assert.True(t, res.Triggered())
+
+	sig.Mentions = 0
+	res = EvaluateSpam(sig, Activity{Messages: 1, MaxMentions: 6})
+	assert.False(t, res.Triggered())
+}
+
+func TestNameSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, NameSimilarity("Raider_123", "raider456"))
+	assert.Equal(t, 0.0, NameSimilarity("", ""))
+	assert.Equal(t, 0.0, NameSimilarity("abc", "xyz"))
+	assert.InDelta(t, 0.8, NameSimilarity("zekro", "zekra"), 0.001)
+	assert.Equal(t, 1.0, NameSimilarity("1234", "1234"))
+}
+
+func TestNewDecision(t *testing.T) {
+	d := NewDecision("1",
+		SignalResult{Signal: SignalAccountAge, Score: 1, Response: models.AntiraidResponseTimeout},
+		SignalResult{Signal: SignalSimilarity, Score: 1, Response: models.AntiraidResponseBan},
+		SignalResult{Signal: SignalSpam, Score: 0.5, Response: models.AntiraidResponseKick},
+	)
+	assert.True(t, d.Triggered())
+	assert.Equal(t, models.AntiraidResponseBan, d.Response)
+	assert.Contains(t, d.Breakdown(), "similarity: 1.00")
+	assert.Contains(t, d.Breakdown(), "spam: 0.50")
+
+	d = NewDecision("1", SignalResult{Signal: SignalSpam, Score: 0})
+	assert.False(t, d.Triggered())
+	assert.False(t, d.Relevant())
+	assert.Equal(t, models.AntiraidResponseNone, d.Response)
+}
@@ -0,0 +1,323 @@
+// Package automod implements the matchers of automod
+// rules. Matching does not require any network access
+// unless a ResolveFunc is passed, so rules can be
+// evaluated offline, for example in dry runs.
+package automod
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+// maxResolvedLinks is the maximum number of links of a
+// single message which are resolved. Further links are
+// only checked by their own domain.
+const maxResolvedLinks = 5
+
+var (
+	rxLink    = regexp.MustCompile(`(?i)(?:https?:\/\/[^\s<>]+|\b(?:[a-z0-9-]+\.)+[a-z]{2,}\b(?:\/[^\s<>]*)?)`)
+	rxMention = regexp.MustCompile(`<@[!&]?\d+>|@everyone|@here`)
+	rxMarkup  = regexp.MustCompile(`<[^<>\s]+>`)
+
+	leetReplacer = strings.NewReplacer(
+		"0", "o", "1", "i", "3", "e", "4", "a", "5", "s",
+		"7", "t", "8", "b", "@", "a", "$", "s",
+	)
+)
+
+// ResolveFunc follows the redirects of a link and returns
+// all visited locations, starting with the link itself.
+type ResolveFunc func(link string) ([]string, error)
+
+// Message is a message evaluated by the matchers.
+type Message struct {
+	Content string
+	Time    time.Time
+	// History contains previous messages of the author,
+	// which are used to detect duplicate messages.
+	History []HistoryEntry
+}
+
+type HistoryEntry struct {
+	Content string
+	Time    time.Time
+}
+
+// Match returns true and a human readable reason if the
+// message matches the matcher. Links are only resolved if
+// resolve is not nil.
+//
+// Regex patterns are compiled on each call. Use a
+// RegexCache to match messages against stored rules.
+func Match(m models.AutomodMatcher, msg Message, resolve ResolveFunc) (ok bool, reason string, err error) {
+	return matchWith(m, msg, resolve, regexp.Compile)
+}
+
+func matchWith(
+	m models.AutomodMatcher,
+	msg Message,
+	resolve ResolveFunc,
+	compile func(pattern string) (*regexp.Regexp, error),
+) (ok bool, reason string, err error) {
+	switch m.Type {
+	case models.AutomodMatchWords:
+		ok, reason = matchWords(m.Words, msg.Content)
+	case models.AutomodMatchRegex:
+		var rx *regexp.Regexp
+		if rx, err = compile(m.Pattern); err == nil {
+			ok, reason = matchRegex(rx, msg.Content)
+		}
+	case models.AutomodMatchLinks:
+		ok, reason = matchLinks(m.Domains, m.AllowDomains, msg.Content, resolve)
+	case models.AutomodMatchCaps:
+		ok, reason = matchCaps(m.Threshold, m.MinLength, msg.Content)
+	case models.AutomodMatchZalgo:
+		ok, reason = matchZalgo(m.Threshold, msg.Content)
+	case models.AutomodMatchMentions:
+		ok, reason = matchMentions(m.Threshold, msg.Content)
+	case models.AutomodMatchDuplicates:
+		ok, reason = matchDuplicates(m.Threshold, m.WindowDuration(), msg)
+	default:
+		err = fmt.Errorf("invalid matcher type '%s'", m.Type)
+	}
+	return
+}
+
+// Normalize splits s into words and normalizes them by
+// lower casing, replacing leetspeak characters and removing
+// separators within words, so that "H3LL0" and "h.e.l.l.o"
+// both result in "hello".
+//
+// Repeated letters are kept, so that "as" and "ass" are
+// still different words. Stretched words like "heeello"
+// are matched by comparing the letter runs of the words.
+func Normalize(s string) []string {
+	fields := strings.Fields(strings.ToLower(s))
+	words := make([]string, 0, len(fields))
+
+	for _, f := range fields {
+		f = strings.TrimFunc(f, func(r rune) bool {
+			return unicode.IsPunct(r) && r != '@'
+		})
+		f = leetReplacer.Replace(f)
+
+		var sb strings.Builder
+		for _, r := range f {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				sb.WriteRune(r)
+			}
+		}
+
+		if sb.Len() != 0 {
+			words = append(words, sb.String())
+		}
+	}
+
+	return words
+}
+
+func matchWords(list []string, content string) (bool, string) {
+	words := Normalize(content)
+
+	for _, entry := range list {
+		seq := Normalize(entry)
+		if len(seq) == 0 {
+			continue
+		}
+		for i := 0; i+len(seq) <= len(words); i++ {
+			if equalWords(words[i:i+len(seq)], seq) {
+				return true, fmt.Sprintf("contains blocked word \"%s\"", entry)
+			}
+		}
+	}
+
+	return false, ""
+}
+
+func equalWords(words, blocked []string) bool {
+	for i := range words {
+		if !equalWord(words[i], blocked[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalWord returns true if word equals the blocked word
+// when runs of 3 or more equal letters in word are
+// collapsed to the length of the corresponding run in the
+// blocked word. Shorter runs must match exactly, so that
+// "as" does not match "ass" and "book" does not match "bok".
+func equalWord(word, blocked string) bool {
+	wr, br := letterRuns(word), letterRuns(blocked)
+	if len(wr) != len(br) {
+		return false
+	}
+	for i := range wr {
+		if wr[i].r != br[i].r {
+			return false
+		}
+		if wr[i].n != br[i].n && (wr[i].n < minStretchedRun || wr[i].n < br[i].n) {
+			return false
+		}
+	}
+	return true
+}
+
+// minStretchedRun is the minimum number of repeated
+// letters which are considered as stretched.
+const minStretchedRun = 3
+
+type letterRun struct {
+	r rune
+	n int
+}
+
+func letterRuns(s string) (runs []letterRun) {
+	for _, r := range s {
+		if len(runs) != 0 && runs[len(runs)-1].r == r {
+			runs[len(runs)-1].n++
+		} else {
+			runs = append(runs, letterRun{r: r, n: 1})
+		}
+	}
+	return
+}
+
+func matchRegex(rx *regexp.Regexp, content string) (bool, string) {
+	if rx.MatchString(content) {
+		return true, "matches blocked pattern"
+	}
+	return false, ""
+}
+
+func matchLinks(domains []string, allow bool, content string, resolve ResolveFunc) (bool, string) {
+	for i, link := range rxLink.FindAllString(content, -1) {
+		chain := []string{link}
+		if resolve != nil && i < maxResolvedLinks {
+			if resolved, _ := resolve(link); len(resolved) != 0 {
+				chain = resolved
+			}
+		}
+
+		for _, loc := range chain {
+			host := hostname(loc)
+			if host == "" {
+				continue
+			}
+			if listed := containsDomain(domains, host); listed != allow {
+				if allow {
+					return true, fmt.Sprintf("contains link to domain \"%s\" which is not allowed", host)
+				}
+				return true, fmt.Sprintf("contains link to blocked domain \"%s\"", host)
+			}
+		}
+	}
+
+	return false, ""
+}
+
+func hostname(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// containsDomain returns true if host is one of
+// domains or a subdomain of one of them.
+func containsDomain(domains []string, host string) bool {
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(d), "www.")
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCaps(threshold, minLength int, content string) (bool, string) {
+	// Mentions and custom emotes are not
+	// written by the author in upper case.
+	content = rxMarkup.ReplaceAllString(content, "")
+
+	var letters, upper int
+	for _, r := range content {
+		if unicode.IsUpper(r) {
+			upper++
+			letters++
+		} else if unicode.IsLower(r) {
+			letters++
+		}
+	}
+
+	if letters == 0 || letters < minLength {
+		return false, ""
+	}
+
+	if percentage := upper * 100 / letters; percentage >= threshold {
+		return true, fmt.Sprintf("%d%% of the letters are upper case", percentage)
+	}
+
+	return false, ""
+}
+
+func matchZalgo(threshold int, content string) (bool, string) {
+	var run, longest int
+	for _, r := range content {
+		if unicode.Is(unicode.Mn, r) {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	if longest >= threshold {
+		return true, fmt.Sprintf("contains %d stacked combining characters", longest)
+	}
+
+	return false, ""
+}
+
+func matchMentions(threshold int, content string) (bool, string) {
+	if n := len(rxMention.FindAllStringIndex(content, -1)); n >= threshold {
+		return true, fmt.Sprintf("contains %d mentions", n)
+	}
+	return false, ""
+}
+
+func matchDuplicates(threshold int, window time.Duration, msg Message) (bool, string) {
+	content := strings.ToLower(strings.TrimSpace(msg.Content))
+	if content == "" {
+		return false, ""
+	}
+
+	n := 1
+	for _, e := range msg.History {
+		if msg.Time.Sub(e.Time) > window {
+			continue
+		}
+		if strings.ToLower(strings.TrimSpace(e.Content)) == content {
+			n++
+		}
+	}
+
+	if n >= threshold {
+		return true, fmt.Sprintf("sent the same message %d times", n)
+	}
+
+	return false, ""
+}
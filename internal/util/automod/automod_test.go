@@ -0,0 +1,249 @@
+package automod
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+func match(t *testing.T, m models.AutomodMatcher, content string, resolve ...ResolveFunc) bool {
+	t.Helper()
+
+	var res ResolveFunc
+	if len(resolve) != 0 {
+		res = resolve[0]
+	}
+
+	ok, reason, err := Match(m, Message{Content: content}, res)
+	require.NoError(t, err)
+	assert.Equal(t, ok, reason != "")
+	return ok
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, []string{"hello", "world"}, Normalize("H3LL0 world!"))
+	assert.Equal(t, []string{"hello"}, Normalize("h.e.l.l.o"))
+	assert.Equal(t, []string{"heeelllooo"}, Normalize("heeelllooo"))
+	assert.Equal(t, []string{"ass"}, Normalize("@$$"))
+	assert.Empty(t, Normalize(" ... !!! "))
+}
+
+func TestMatchWords(t *testing.T) {
+	m := models.AutomodMatcher{
+		Type:  models.AutomodMatchWords,
+		Words: []string{"badword", "very bad phrase"},
+	}
+
+	assert.True(t, match(t, m, "this is a badword"))
+	assert.True(t, match(t, m, "this is a B4DW0RD!"))
+	assert.True(t, match(t, m, "b.a.d.w.o.r.d"))
+	assert.True(t, match(t, m, "baaadwooord"))
+	assert.True(t, match(t, m, "a VERY bad, phrase indeed"))
+	assert.False(t, match(t, m, "badwords are not whole words"))
+	assert.False(t, match(t, m, "very bad"))
+	assert.False(t, match(t, m, "nothing to see here"))
+}
+
+func TestMatchWordsRepeatedLetters(t *testing.T) {
+	m := models.AutomodMatcher{
+		Type:  models.AutomodMatchWords,
+		Words: []string{"ass", "bok", "hello"},
+	}
+
+	assert.True(t, match(t, m, "ass"))
+	assert.True(t, match(t, m, "@$$"))
+	assert.True(t, match(t, m, "aaassss"))
+	assert.True(t, match(t, m, "boooook"))
+	assert.True(t, match(t, m, "heeeellllooo"))
+	assert.False(t, match(t, m, "as"))
+	assert.False(t, match(t, m, "pass"))
+	assert.False(t, match(t, m, "book"))
+	assert.False(t, match(t, m, "helo"))
+
+	m.Words = []string{"book"}
+	assert.True(t, match(t, m, "book"))
+	assert.True(t, match(t, m, "boooook"))
+	assert.False(t, match(t, m, "bok"))
+}
+
+func TestMatchRegex(t *testing.T) {
+	m := models.AutomodMatcher{
+		Type:    models.AutomodMatchRegex,
+		Pattern: `(?i)free\s+nitro`,
+	}
+
+	assert.True(t, match(t, m, "get FREE   nitro here"))
+	assert.False(t, match(t, m, "nitro is not free"))
+
+	_, _, err := Match(models.AutomodMatcher{
+		Type:    models.AutomodMatchRegex,
+		Pattern: `(`,
+	}, Message{Content: "test"}, nil)
+	assert.Error(t, err)
+}
+
+func TestRegexCache(t *testing.T) {
+	c := NewRegexCache()
+	rule := models.AutomodRule{
+		ID: 1,
+		Matcher: models.AutomodMatcher{
+			Type:    models.AutomodMatchRegex,
+			Pattern: `(?i)free\s+nitro`,
+		},
+	}
+
+	ok, _, err := c.Match(rule, Message{Content: "FREE nitro"}, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	rx, err := c.compile("1", rule.Matcher.Pattern)
+	require.NoError(t, err)
+	rx2, err := c.compile("1", rule.Matcher.Pattern)
+	require.NoError(t, err)
+	assert.Same(t, rx, rx2)
+
+	rule.Matcher.Pattern = `steam\s+gift`
+	ok, _, err = c.Match(rule, Message{Content: "FREE nitro"}, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, _, err = c.Match(rule, Message{Content: "steam  gift"}, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	rule.Matcher.Pattern = `(`
+	_, _, err = c.Match(rule, Message{Content: "test"}, nil)
+	assert.Error(t, err)
+}
+
+func TestMatchLinks(t *testing.T) {
+	deny := models.AutomodMatcher{
+		Type:    models.AutomodMatchLinks,
+		Domains: []string{"evil.com"},
+	}
+
+	assert.True(t, match(t, deny, "look at https://evil.com/path"))
+	assert.True(t, match(t, deny, "look at www.EVIL.com"))
+	assert.True(t, match(t, deny, "look at sub.evil.com/test"))
+	assert.False(t, match(t, deny, "look at notevil.com"))
+	assert.False(t, match(t, deny, "look at https://example.com"))
+	assert.False(t, match(t, deny, "no links, e.g. here"))
+
+	allow := models.AutomodMatcher{
+		Type:         models.AutomodMatchLinks,
+		Domains:      []string{"github.com", "zekro.de"},
+		AllowDomains: true,
+	}
+
+	assert.True(t, match(t, allow, "look at https://example.com"))
+	assert.True(t, match(t, allow, "https://github.com and https://example.com"))
+	assert.False(t, match(t, allow, "look at https://github.com/zekroTJA/shinpuru"))
+	assert.False(t, match(t, allow, "look at docs.zekro.de"))
+	assert.False(t, match(t, allow, "no links here"))
+
+	resolve := func(link string) ([]string, error) {
+		if link == "https://short.link/abc" {
+			return []string{link, "https://evil.com/landing"}, nil
+		}
+		return []string{link}, errors.New("test error")
+	}
+
+	assert.False(t, match(t, deny, "look at https://short.link/abc"))
+	assert.True(t, match(t, deny, "look at https://short.link/abc", resolve))
+	assert.False(t, match(t, deny, "look at https://short.link/other", resolve))
+}
+
+func TestMatchLinksResolveLimit(t *testing.T) {
+	deny := models.AutomodMatcher{
+		Type:    models.AutomodMatchLinks,
+		Domains: []string{"evil.com"},
+	}
+
+	var resolved int
+	resolve := func(link string) ([]string, error) {
+		resolved++
+		return []string{link}, nil
+	}
+
+	links := make([]string, 0, 2*maxResolvedLinks)
+	for i := 0; i < 2*maxResolvedLinks; i++ {
+		links = append(links, fmt.Sprintf("https://example%d.com", i))
+	}
+
+	assert.False(t, match(t, deny, strings.Join(links, " "), resolve))
+	assert.Equal(t, maxResolvedLinks, resolved)
+
+	resolved = 0
+	links = append(links, "https://evil.com")
+	assert.True(t, match(t, deny, strings.Join(links, " "), resolve))
+	assert.Equal(t, maxResolvedLinks, resolved)
+}
+
+func TestMatchCaps(t *testing.T) {
+	m := models.AutomodMatcher{
+		Type:      models.AutomodMatchCaps,
+		Threshold: 70,
+		MinLength: 10,
+	}
+
+	assert.True(t, match(t, m, "WHY ARE YOU SHOUTING"))
+	assert.True(t, match(t, m, "THIS IS MOSTLY caps"))
+	assert.False(t, match(t, m, "SHORT"))
+	assert.False(t, match(t, m, "This Is Normal Text"))
+	assert.False(t, match(t, m, "hey <:PEPEGA:123456> <@&123456>"))
+}
+
+func TestMatchZalgo(t *testing.T) {
+	m := models.AutomodMatcher{
+		Type:      models.AutomodMatchZalgo,
+		Threshold: 3,
+	}
+
+	assert.True(t, match(t, m, "hé̂̃̄llo"))
+	assert.False(t, match(t, m, "café and café"))
+}
+
+func TestMatchMentions(t *testing.T) {
+	m := models.AutomodMatcher{
+		Type:      models.AutomodMatchMentions,
+		Threshold: 3,
+	}
+
+	assert.True(t, match(t, m, "<@123> <@!456> <@&789>"))
+	assert.True(t, match(t, m, "@everyone @here <@123>"))
+	assert.False(t, match(t, m, "<@123> <@456>"))
+}
+
+func TestMatchDuplicates(t *testing.T) {
+	m := models.AutomodMatcher{
+		Type:      models.AutomodMatchDuplicates,
+		Threshold: 3,
+		Window:    60,
+	}
+	now := time.Now()
+
+	msg := Message{
+		Content: "Spam",
+		Time:    now,
+		History: []HistoryEntry{
+			{Content: "spam ", Time: now.Add(-10 * time.Second)},
+			{Content: "something else", Time: now.Add(-20 * time.Second)},
+			{Content: "spam", Time: now.Add(-2 * time.Minute)},
+		},
+	}
+
+	ok, _, err := Match(m, msg, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	msg.History = append(msg.History, HistoryEntry{Content: "SPAM", Time: now.Add(-30 * time.Second)})
+	ok, reason, err := Match(m, msg, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "sent the same message 3 times", reason)
+}
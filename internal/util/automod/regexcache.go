@@ -0,0 +1,56 @@
+package automod
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/timedmap"
+)
+
+const regexCacheLifetime = 1 * time.Hour
+
+type regexCacheKey struct {
+	ruleID  string
+	pattern string
+}
+
+// RegexCache matches messages against automod rules while
+// compiling the regex pattern of each rule only once.
+//
+// Compiled patterns are keyed by the rule ID and pattern,
+// so changing the pattern of a rule compiles it again.
+// Unused patterns are removed after regexCacheLifetime.
+type RegexCache struct {
+	m *timedmap.TimedMap
+}
+
+// NewRegexCache returns a new empty RegexCache.
+func NewRegexCache() *RegexCache {
+	return &RegexCache{
+		m: timedmap.New(regexCacheLifetime / 2),
+	}
+}
+
+// Match works like Match, but uses the compiled pattern
+// of the given rule from the cache.
+func (c *RegexCache) Match(rule models.AutomodRule, msg Message, resolve ResolveFunc) (ok bool, reason string, err error) {
+	return matchWith(rule.Matcher, msg, resolve, func(pattern string) (*regexp.Regexp, error) {
+		return c.compile(rule.ID.String(), pattern)
+	})
+}
+
+func (c *RegexCache) compile(ruleID, pattern string) (rx *regexp.Regexp, err error) {
+	key := regexCacheKey{ruleID: ruleID, pattern: pattern}
+
+	if rx, ok := c.m.GetValue(key).(*regexp.Regexp); ok {
+		c.m.Refresh(key, regexCacheLifetime)
+		return rx, nil
+	}
+
+	if rx, err = regexp.Compile(pattern); err != nil {
+		return
+	}
+	c.m.Set(key, rx, regexCacheLifetime)
+	return
+}
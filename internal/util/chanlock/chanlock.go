@@ -0,0 +1,151 @@
+// Package chanlock provides functionalities to chat-lock
+// channels and to restore their previous permission
+// overwrites afterwards.
+package chanlock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
+	"github.com/zekrotja/dgrs"
+)
+
+const allowMask = discordgo.PermissionAll - discordgo.PermissionSendMessages
+
+// Lock denies the permission to send messages in the target
+// channel for all roles below the highest role of the executor
+// as well as for all members except the executor and the bot.
+//
+// The previous permission overwrites of the channel are stored
+// in the database so that they can be restored with Unlock.
+func Lock(
+	s discordutil.ISession,
+	st dgrs.IState,
+	db database.Database,
+	target *discordgo.Channel,
+	executorID string,
+	executorRoles []string,
+) error {
+	encodedPerms, err := EncodePermissionOverrides(target.PermissionOverwrites)
+	if err != nil {
+		return err
+	}
+
+	guildRoles, err := st.Roles(target.GuildID)
+	if err != nil {
+		return err
+	}
+	sort.Slice(guildRoles, func(i, j int) bool {
+		return guildRoles[i].Position < guildRoles[j].Position
+	})
+
+	highest := 0
+	rolesMap := make(map[string]*discordgo.Role)
+	for _, r := range guildRoles {
+		rolesMap[r.ID] = r
+		for _, mr := range executorRoles {
+			if r.ID != mr {
+				continue
+			}
+			if r.Position > highest {
+				highest = r.Position
+			}
+		}
+	}
+
+	self, err := st.SelfUser()
+	if err != nil {
+		return err
+	}
+
+	hasSetEveryone := false
+	for _, po := range target.PermissionOverwrites {
+		if po.Type == discordgo.PermissionOverwriteTypeRole {
+			if r, ok := rolesMap[po.ID]; ok && r.Position < highest {
+				if err = s.ChannelPermissionSet(
+					target.ID, po.ID, discordgo.PermissionOverwriteTypeRole, po.Allow&allowMask, po.Deny|discordgo.PermissionSendMessages); err != nil {
+					return err
+				}
+			}
+		}
+		if po.Type == discordgo.PermissionOverwriteTypeMember && executorID != po.ID && self.ID != po.ID {
+			if err = s.ChannelPermissionSet(
+				target.ID, po.ID, discordgo.PermissionOverwriteTypeMember, po.Allow&allowMask, po.Deny|discordgo.PermissionSendMessages); err != nil {
+				return err
+			}
+			if po.ID == target.GuildID {
+				hasSetEveryone = true
+			}
+		}
+	}
+
+	if err = s.ChannelPermissionSet(
+		target.ID, self.ID, discordgo.PermissionOverwriteTypeMember, discordgo.PermissionSendMessages&discordgo.PermissionViewChannel, 0); err != nil {
+		return err
+	}
+
+	if !hasSetEveryone {
+		if err = s.ChannelPermissionSet(
+			target.ID, target.GuildID, discordgo.PermissionOverwriteTypeRole, 0, discordgo.PermissionSendMessages); err != nil {
+			return err
+		}
+	}
+
+	return db.SetLockChan(target.ID, target.GuildID, executorID, encodedPerms)
+}
+
+// Unlock restores the passed encoded permission overwrites
+// of the channel and removes the lock from the database.
+//
+// failed is the number of permission overwrites which could
+// not be restored.
+func Unlock(s discordutil.ISession, db database.Database, channelID, encodedPerms string) (failed int, err error) {
+	permissionOverrides, err := DecodePermissionOverrides(encodedPerms)
+	if err != nil {
+		return
+	}
+
+	for _, po := range permissionOverrides {
+		if err = s.ChannelPermissionSet(channelID, po.ID, po.Type, po.Allow, po.Deny); err != nil {
+			failed++
+		}
+	}
+
+	err = db.DeleteLockChan(channelID)
+
+	return
+}
+
+// EncodePermissionOverrides encodes the passed permission
+// overwrites to a string which can be stored in the database.
+func EncodePermissionOverrides(po []*discordgo.PermissionOverwrite) (res string, err error) {
+	buff := bytes.NewBuffer([]byte{})
+
+	if err = json.NewEncoder(buff).Encode(po); err != nil {
+		return
+	}
+
+	res = base64.StdEncoding.EncodeToString(buff.Bytes())
+
+	return
+}
+
+// DecodePermissionOverrides decodes permission overwrites
+// encoded with EncodePermissionOverrides.
+func DecodePermissionOverrides(data string) (po []*discordgo.PermissionOverwrite, err error) {
+	po = make([]*discordgo.PermissionOverwrite, 0)
+
+	dataBytes, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return
+	}
+
+	err = json.NewDecoder(bytes.NewBuffer(dataBytes)).Decode(&po)
+
+	return
+}
@@ -33,15 +33,9 @@ func CmdReport(ctx ken.Context, typ models.ReportType, tp timeprovider.Provider)
 	}
 
 	if attachment != "" {
-		img, err := imgstore.DownloadFromURL(attachment)
-		if err == nil && img != nil {
-			st, _ := ctx.Get(static.DiObjectStorage).(storage.Storage)
-			err = st.PutObject(static.StorageBucketImages, img.ID.String(),
-				bytes.NewReader(img.Data), int64(img.Size), img.MimeType)
-			if err != nil {
-				return err
-			}
-			attachment = img.ID.String()
+		st, _ := ctx.Get(static.DiObjectStorage).(storage.Storage)
+		if attachment, err = StoreAttachment(st, attachment); err != nil {
+			return err
 		}
 	}
 
@@ -115,3 +109,22 @@ func CmdReport(ctx ken.Context, typ models.ReportType, tp timeprovider.Provider)
 	}
 	return acceptMsg.Error()
 }
+
+// StoreAttachment downloads the image from the passed url
+// and puts it into the image storage. The ID of the stored
+// image is returned. If the image can not be downloaded,
+// the url is returned as is.
+func StoreAttachment(st storage.Storage, url string) (string, error) {
+	img, err := imgstore.DownloadFromURL(url)
+	if err != nil || img == nil {
+		return url, nil
+	}
+
+	err = st.PutObject(static.StorageBucketImages, img.ID.String(),
+		bytes.NewReader(img.Data), int64(img.Size), img.MimeType)
+	if err != nil {
+		return "", err
+	}
+
+	return img.ID.String(), nil
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/services/backup"
 	"github.com/zekroTJA/shinpuru/internal/services/database"
 	"github.com/zekroTJA/shinpuru/internal/services/storage"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
@@ -14,6 +15,7 @@ func FlushAllGuildData(
 	s *discordgo.Session,
 	db database.Database,
 	st storage.Storage,
+	bck *backup.GuildBackups,
 	state *dgrs.State,
 	guildID string,
 ) (err error) {
@@ -22,6 +24,11 @@ func FlushAllGuildData(
 		return
 	}
 
+	mErr := multierror.New()
+
+	backupObjects, err := bck.StoredObjects(backups)
+	mErr.Append(err)
+
 	reportsCount, err := db.GetReportsGuildCount(guildID)
 	if err != nil {
 		return
@@ -31,10 +38,23 @@ func FlushAllGuildData(
 		return
 	}
 
-	for _, v := range vote.VotesRunning {
+	vote.VotesRunning.Range(func(v vote.Vote) bool {
 		if v.GuildID == guildID {
 			v.Close(s, vote.VoteStateClosedNC)
 		}
+		return true
+	})
+
+	// Votes are not stored per guild, so they need
+	// to be removed separately.
+	votes, err := db.GetVotes()
+	if err != nil {
+		return
+	}
+	for _, v := range votes {
+		if v.GuildID == guildID {
+			mErr.Append(db.DeleteVote(v.ID))
+		}
 	}
 
 	if err = db.FlushGuildData(guildID); err != nil {
@@ -45,9 +65,8 @@ func FlushAllGuildData(
 		return
 	}
 
-	mErr := multierror.New()
-	for _, b := range backups {
-		mErr.Append(st.DeleteObject(static.StorageBucketBackups, b.FileID))
+	for _, name := range backupObjects {
+		mErr.Append(st.DeleteObject(static.StorageBucketBackups, name))
 	}
 	for _, r := range reports {
 		if r.AttachmentURL != "" {
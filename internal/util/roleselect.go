@@ -1,98 +1,343 @@
 package util
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/rs/xid"
+	"github.com/zekroTJA/shinpuru/internal/models"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/discordutil"
 	"github.com/zekroTJA/shinpuru/pkg/stringutil"
+	"github.com/zekrotja/dgrs"
 	"github.com/zekrotja/ken"
 )
 
-func OnRoleSelect(roleID string) func(ctx ken.ComponentContext) bool {
+var (
+	ErrRoleSelectRequired      = errors.New("missing required role")
+	ErrRoleSelectLimit         = errors.New("role limit reached")
+	ErrRoleSelectNotRemovable  = errors.New("role can not be removed")
+	errRoleSelectUnknownOption = errors.New("unknown role select option")
+)
+
+var (
+	rxCustomEmoji = regexp.MustCompile(`^<(a?):(\w+):(\d+)>$`)
+	rxNumber      = regexp.MustCompile(`^\d+$`)
+)
+
+// AttachRoleSelect attaches the buttons or the select menu
+// of the role select group to the message of the builder
+// and registers their handlers. Roles which do not exist
+// anymore are skipped.
+//
+// The custom IDs of the components are derived from the
+// message and role IDs, so attaching a group again to the
+// same message replaces the previous handlers.
+func AttachRoleSelect(b *ken.ComponentBuilder, g models.RoleSelectGroup, st dgrs.IState) error {
+	type option struct {
+		roleID string
+		label  string
+		emoji  *discordgo.ComponentEmoji
+	}
+
+	options := make([]option, 0, len(g.Roles))
+	for _, r := range g.Roles {
+		role, err := st.Role(g.GuildID, r.RoleID)
+		if err != nil || role == nil || role.ID == "" {
+			continue
+		}
+		label := r.Label
+		if label == "" {
+			label = role.Name
+		}
+		options = append(options, option{
+			roleID: r.RoleID,
+			label:  label,
+			emoji:  componentEmoji(r.Emoji),
+		})
+	}
+
+	if g.Menu {
+		menuOptions := make([]discordgo.SelectMenuOption, len(options))
+		for i, o := range options {
+			menuOptions[i] = discordgo.SelectMenuOption{
+				Label: o.label,
+				Value: o.roleID,
+				Emoji: o.emoji,
+			}
+		}
+
+		minValues := 0
+		maxValues := len(menuOptions)
+		switch g.Mode {
+		case models.RoleSelectModeUnique:
+			maxValues = 1
+		case models.RoleSelectModeLimited:
+			if g.MaxRoles < maxValues {
+				maxValues = g.MaxRoles
+			}
+		}
+
+		b.AddActionsRow(func(b ken.ComponentAssembler) {
+			b.Add(discordgo.SelectMenu{
+				CustomID:    roleSelectCustomID(g.MessageID, ""),
+				Placeholder: "Select your roles",
+				MinValues:   &minValues,
+				MaxValues:   maxValues,
+				Options:     menuOptions,
+			}, onRoleSelectMenu(g))
+		})
+	} else {
+		for i := 0; i < len(options); i += 5 {
+			row := options[i:]
+			if len(row) > 5 {
+				row = row[:5]
+			}
+			b.AddActionsRow(func(b ken.ComponentAssembler) {
+				for _, o := range row {
+					b.Add(discordgo.Button{
+						Label:    o.label,
+						Emoji:    o.emoji,
+						Style:    discordgo.PrimaryButton,
+						CustomID: roleSelectCustomID(g.MessageID, o.roleID),
+					}, onRoleSelectButton(g, o.roleID))
+				}
+			})
+		}
+	}
+
+	_, err := b.Build()
+	return err
+}
+
+// DetachRoleSelect unregisters the handlers of the role
+// select group. If removeComponents is true, the components
+// are also removed from the message.
+func DetachRoleSelect(
+	ch *ken.ComponentHandler,
+	s discordutil.ISession,
+	g models.RoleSelectGroup,
+	removeComponents bool,
+) (err error) {
+	customIDs := make([]string, 0, len(g.Roles)+1)
+	customIDs = append(customIDs, roleSelectCustomID(g.MessageID, ""))
+	for _, r := range g.Roles {
+		customIDs = append(customIDs, roleSelectCustomID(g.MessageID, r.RoleID))
+	}
+	ch.Unregister(customIDs...)
+
+	if removeComponents {
+		_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         g.MessageID,
+			Channel:    g.ChannelID,
+			Components: []discordgo.MessageComponent{},
+		})
+	}
+	return err
+}
+
+func roleSelectCustomID(messageID, roleID string) string {
+	if roleID == "" {
+		return "roleselect:" + messageID
+	}
+	return "roleselect:" + messageID + ":" + roleID
+}
+
+// componentEmoji parses a custom emoji in the format
+// <:name:id>, a custom emoji ID or a unicode emoji.
+func componentEmoji(v string) *discordgo.ComponentEmoji {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil
+	}
+	if m := rxCustomEmoji.FindStringSubmatch(v); m != nil {
+		return &discordgo.ComponentEmoji{
+			Animated: m[1] == "a",
+			Name:     m[2],
+			ID:       m[3],
+		}
+	}
+	if rxNumber.MatchString(v) {
+		return &discordgo.ComponentEmoji{ID: v}
+	}
+	return &discordgo.ComponentEmoji{Name: v}
+}
+
+func onRoleSelectButton(g models.RoleSelectGroup, roleID string) ken.ComponentHandlerFunc {
 	return func(ctx ken.ComponentContext) bool {
 		ctx.SetEphemeral(true)
 		ctx.Defer()
 
-		if stringutil.ContainsAny(roleID, ctx.GetEvent().Member.Roles) {
-			err := ctx.GetSession().GuildMemberRoleRemove(ctx.GetEvent().GuildID, ctx.User().ID, roleID)
-			if err != nil {
-				err = ctx.FollowUpError("Failed removing role.", "").
-					Send().
-					DeleteAfter(10 * time.Second).Error
-				return err == nil
-			}
-			err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
-				Color:       static.ColorEmbedGreen,
-				Description: fmt.Sprintf("Role <@&%s> has been removed.", roleID),
-			}).Send().DeleteAfter(10 * time.Second).Error
-			return err == nil
-		}
+		add, remove, err := roleSelectToggle(g, ctx.GetEvent().Member.Roles, roleID)
+		return applyRoleSelect(ctx, g, add, remove, err)
+	}
+}
 
-		err := ctx.GetSession().GuildMemberRoleAdd(ctx.GetEvent().GuildID, ctx.User().ID, roleID)
-		if err != nil {
-			err = ctx.FollowUpError("Failed adding role.", "").
-				Send().
-				DeleteAfter(10 * time.Second).Error
-			return err == nil
+func onRoleSelectMenu(g models.RoleSelectGroup) ken.ComponentHandlerFunc {
+	return func(ctx ken.ComponentContext) bool {
+		ctx.SetEphemeral(true)
+		ctx.Defer()
+
+		add, remove, err := roleSelectMenu(g, ctx.GetEvent().Member.Roles, ctx.GetData().Values)
+		return applyRoleSelect(ctx, g, add, remove, err)
+	}
+}
+
+func applyRoleSelect(ctx ken.ComponentContext, g models.RoleSelectGroup, add, remove []string, err error) bool {
+	switch {
+	case errors.Is(err, ErrRoleSelectRequired):
+		return roleSelectError(ctx, fmt.Sprintf(
+			"You need one of the roles %s to pick roles here.", roleMentions(g.RequiredRoles)))
+	case errors.Is(err, ErrRoleSelectLimit):
+		return roleSelectError(ctx, fmt.Sprintf(
+			"You can only have up to %d of these roles. Remove one of your roles first.", g.MaxRoles))
+	case errors.Is(err, ErrRoleSelectNotRemovable):
+		return roleSelectError(ctx, "Roles of this selection can not be removed.")
+	case err != nil:
+		return roleSelectError(ctx, "Failed processing your selection.")
+	}
+
+	s := ctx.GetSession()
+	guildID := ctx.GetEvent().GuildID
+	userID := ctx.User().ID
+
+	for _, roleID := range remove {
+		if err = s.GuildMemberRoleRemove(guildID, userID, roleID); err != nil {
+			return roleSelectError(ctx, "Failed removing role.")
+		}
+	}
+	for _, roleID := range add {
+		if err = s.GuildMemberRoleAdd(guildID, userID, roleID); err != nil {
+			return roleSelectError(ctx, "Failed adding role.")
 		}
+	}
+
+	var lines []string
+	if len(add) > 0 {
+		lines = append(lines, fmt.Sprintf("Added %s.", roleMentions(add)))
+	}
+	if len(remove) > 0 {
+		lines = append(lines, fmt.Sprintf("Removed %s.", roleMentions(remove)))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "Your roles have not been changed.")
+	}
 
-		err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
-			Color:       static.ColorEmbedGreen,
-			Description: fmt.Sprintf("Role <@&%s> has been added.", roleID),
-		}).Send().DeleteAfter(10 * time.Second).Error
+	err = ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+		Color:       static.ColorEmbedGreen,
+		Description: strings.Join(lines, "\n"),
+	}).Send().DeleteAfter(10 * time.Second).Error
+	return err == nil
+}
 
-		return err == nil
+func roleSelectError(ctx ken.ComponentContext, msg string) bool {
+	err := ctx.FollowUpError(msg, "").
+		Send().
+		DeleteAfter(10 * time.Second).Error
+	return err == nil
+}
+
+func roleMentions(roleIDs []string) string {
+	mentions := make([]string, len(roleIDs))
+	for i, id := range roleIDs {
+		mentions[i] = "<@&" + id + ">"
 	}
+	return strings.Join(mentions, ", ")
 }
 
-func AttachRoleSelectButtons(
-	b *ken.ComponentBuilder,
-	roles []*discordgo.Role,
-) ([]string, error) {
-	type roleButton struct {
-		Button *discordgo.Button
-		RoleID string
+// roleSelectToggle returns the roles to be added and removed
+// when a member with the given roles clicks the button of
+// roleID of the role select group.
+func roleSelectToggle(g models.RoleSelectGroup, memberRoles []string, roleID string) (add, remove []string, err error) {
+	if !g.Has(roleID) {
+		return nil, nil, errRoleSelectUnknownOption
+	}
+	if !hasRequiredRole(g, memberRoles) {
+		return nil, nil, ErrRoleSelectRequired
 	}
 
-	roleButtons := map[string]*discordgo.Button{}
-	for _, role := range roles {
-		roleButtons[role.ID] = &discordgo.Button{
-			Label:    role.Name,
-			Style:    discordgo.PrimaryButton,
-			CustomID: xid.New().String(),
+	if stringutil.ContainsAny(roleID, memberRoles) {
+		if g.Mode == models.RoleSelectModeVerify {
+			return nil, nil, ErrRoleSelectNotRemovable
 		}
+		return nil, []string{roleID}, nil
+	}
 
+	current := groupRolesOf(g, memberRoles)
+	switch g.Mode {
+	case models.RoleSelectModeUnique:
+		remove = current
+	case models.RoleSelectModeLimited:
+		if len(current) >= g.MaxRoles {
+			return nil, nil, ErrRoleSelectLimit
+		}
 	}
 
-	nCols := len(roleButtons) / 5
-	if len(roleButtons)%5 > 0 {
-		nCols++
+	return []string{roleID}, remove, nil
+}
+
+// roleSelectMenu returns the roles to be added and removed
+// when a member with the given roles submits the select menu
+// of the role select group with the given values. The values
+// are the roles of the group the member wants to have.
+func roleSelectMenu(g models.RoleSelectGroup, memberRoles []string, values []string) (add, remove []string, err error) {
+	for _, v := range values {
+		if !g.Has(v) {
+			return nil, nil, errRoleSelectUnknownOption
+		}
+	}
+	if !hasRequiredRole(g, memberRoles) {
+		return nil, nil, ErrRoleSelectRequired
 	}
 
-	roleButtonsColumns := make([][]roleButton, nCols)
-	roleIDs := make([]string, 0, len(roleButtons))
-	i := 0
-	for id, b := range roleButtons {
-		roleButtonsColumns[i/5] = append(roleButtonsColumns[i/5], roleButton{
-			Button: b,
-			RoleID: id,
-		})
-		roleIDs = append(roleIDs, id)
-		i++
+	switch g.Mode {
+	case models.RoleSelectModeUnique:
+		if len(values) > 1 {
+			return nil, nil, ErrRoleSelectLimit
+		}
+	case models.RoleSelectModeLimited:
+		if len(values) > g.MaxRoles {
+			return nil, nil, ErrRoleSelectLimit
+		}
 	}
 
-	for _, rbs := range roleButtonsColumns {
-		b.AddActionsRow(func(b ken.ComponentAssembler) {
-			for _, rb := range rbs {
-				b.Add(rb.Button, OnRoleSelect(rb.RoleID))
+	for _, v := range values {
+		if !stringutil.ContainsAny(v, memberRoles) {
+			add = append(add, v)
+		}
+	}
+
+	if g.Mode != models.RoleSelectModeVerify {
+		for _, r := range groupRolesOf(g, memberRoles) {
+			if !stringutil.ContainsAny(r, values) {
+				remove = append(remove, r)
 			}
-		})
+		}
 	}
 
-	_, err := b.Build()
+	return add, remove, nil
+}
 
-	return roleIDs, err
+func hasRequiredRole(g models.RoleSelectGroup, memberRoles []string) bool {
+	if len(g.RequiredRoles) == 0 {
+		return true
+	}
+	for _, r := range g.RequiredRoles {
+		if stringutil.ContainsAny(r, memberRoles) {
+			return true
+		}
+	}
+	return false
+}
+
+func groupRolesOf(g models.RoleSelectGroup, memberRoles []string) (roles []string) {
+	for _, r := range memberRoles {
+		if g.Has(r) {
+			roles = append(roles, r)
+		}
+	}
+	return roles
 }
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zekroTJA/shinpuru/internal/models"
+)
+
+func testRoleSelectGroup(mode models.RoleSelectMode) models.RoleSelectGroup {
+	return models.RoleSelectGroup{
+		Mode:     mode,
+		MaxRoles: 2,
+		Roles: []models.RoleSelectOption{
+			{RoleID: "a"}, {RoleID: "b"}, {RoleID: "c"},
+		},
+	}
+}
+
+func TestRoleSelectToggle(t *testing.T) {
+	g := testRoleSelectGroup(models.RoleSelectModeToggle)
+
+	add, remove, err := roleSelectToggle(g, []string{"x", "a"}, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, add)
+	assert.Empty(t, remove)
+
+	add, remove, err = roleSelectToggle(g, []string{"x", "a"}, "a")
+	assert.NoError(t, err)
+	assert.Empty(t, add)
+	assert.Equal(t, []string{"a"}, remove)
+
+	_, _, err = roleSelectToggle(g, nil, "x")
+	assert.ErrorIs(t, err, errRoleSelectUnknownOption)
+
+	// Unique swaps out the other roles of the group.
+	g = testRoleSelectGroup(models.RoleSelectModeUnique)
+	add, remove, err = roleSelectToggle(g, []string{"x", "a"}, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, add)
+	assert.Equal(t, []string{"a"}, remove)
+
+	g = testRoleSelectGroup(models.RoleSelectModeLimited)
+	_, _, err = roleSelectToggle(g, []string{"a", "b"}, "c")
+	assert.ErrorIs(t, err, ErrRoleSelectLimit)
+	_, remove, err = roleSelectToggle(g, []string{"a", "b"}, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, remove)
+
+	g = testRoleSelectGroup(models.RoleSelectModeVerify)
+	_, _, err = roleSelectToggle(g, []string{"a"}, "a")
+	assert.ErrorIs(t, err, ErrRoleSelectNotRemovable)
+
+	g.RequiredRoles = []string{"p1", "p2"}
+	_, _, err = roleSelectToggle(g, []string{"x"}, "b")
+	assert.ErrorIs(t, err, ErrRoleSelectRequired)
+	add, _, err = roleSelectToggle(g, []string{"p2"}, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, add)
+}
+
+func TestRoleSelectMenu(t *testing.T) {
+	g := testRoleSelectGroup(models.RoleSelectModeToggle)
+
+	add, remove, err := roleSelectMenu(g, []string{"x", "a", "b"}, []string{"b", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, add)
+	assert.Equal(t, []string{"a"}, remove)
+
+	_, _, err = roleSelectMenu(g, nil, []string{"x"})
+	assert.ErrorIs(t, err, errRoleSelectUnknownOption)
+
+	g = testRoleSelectGroup(models.RoleSelectModeLimited)
+	_, _, err = roleSelectMenu(g, nil, []string{"a", "b", "c"})
+	assert.ErrorIs(t, err, ErrRoleSelectLimit)
+
+	g = testRoleSelectGroup(models.RoleSelectModeVerify)
+	add, remove, err = roleSelectMenu(g, []string{"a", "b"}, []string{"c"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, add)
+	assert.Empty(t, remove)
+}
+
+func TestComponentEmoji(t *testing.T) {
+	assert.Nil(t, componentEmoji(" "))
+	assert.Equal(t, &discordgo.ComponentEmoji{Name: "🎉"}, componentEmoji("🎉"))
+	assert.Equal(t, &discordgo.ComponentEmoji{ID: "123"}, componentEmoji("123"))
+	assert.Equal(t, &discordgo.ComponentEmoji{Name: "party", ID: "123", Animated: true},
+		componentEmoji("<a:party:123>"))
+}
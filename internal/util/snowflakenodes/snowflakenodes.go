@@ -27,6 +27,12 @@ var (
 	// NodeGuildLog is the snowflake node
 	// for guild logs.
 	NodeGuildLog *snowflake.Node
+	// NodeKarmaLedger is the snowflake node
+	// for karma ledger entries.
+	NodeKarmaLedger *snowflake.Node
+	// NodeAutomodRules is the snowflake node
+	// for automod rules.
+	NodeAutomodRules *snowflake.Node
 
 	// nodeMap maps snowflake node IDs with
 	// their identifier strings.
@@ -44,6 +50,8 @@ func Setup() (err error) {
 	NodeUnbanRequests, _ = RegisterNode(140, "unbanrequests")
 	NodeKarmaRules, _ = RegisterNode(150, "karmarules")
 	NodeGuildLog, _ = RegisterNode(160, "karmarules")
+	NodeKarmaLedger, _ = RegisterNode(170, "karmaledger")
+	NodeAutomodRules, _ = RegisterNode(180, "automodrules")
 
 	return
 }
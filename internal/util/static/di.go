@@ -30,5 +30,10 @@ const (
 	DiState                   = "dgstate"
 	DiVerification            = "verification"
 	DiBirthday                = "birthday"
+	DiMediaProvider           = "mediaprovider"
+	DiVotes                   = "votes"
+	DiLockdown                = "lockdown"
+	DiJoinlog                 = "joinlog"
+	DiLinkResolver            = "linkresolver"
 	DiTimeProvider            = "timeprovider"
 )
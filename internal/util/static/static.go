@@ -74,7 +74,8 @@ const (
 	PublicMainInvite   = "https://shnp.de/invite"
 	PublicCanaryInvite = "https://c.shnp.de/invite"
 
-	EndpointAuthCB = "/api/auth/oauthcallback"
+	EndpointAuthCB         = "/api/auth/oauthcallback"
+	EndpointTwitchEventSub = "/twitch/eventsub"
 
 	AuthSessionExpiration  = 7 * 24 * time.Hour // 7 Days
 	ApiTokenExpiration     = 365 * 24 * time.Hour
@@ -101,5 +102,6 @@ var (
 		"sp.chat.autochannel",
 		"sp.chat.colorreactions",
 		"sp.guild.mod.inviteblock.send",
+		"sp.guild.mod.karma.revert",
 	}
 )
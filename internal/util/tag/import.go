@@ -0,0 +1,97 @@
+package tag
+
+import "fmt"
+
+// ImportResult contains the tags which need to be
+// created or updated to import a list of tags as
+// well as the idents of the tags which are skipped.
+type ImportResult struct {
+	Created []Tag    `json:"created"`
+	Updated []Tag    `json:"updated"`
+	Skipped []string `json:"skipped"`
+}
+
+// PlanImport compares the imported tags to the existing
+// tags of a guild and returns which tags need to be created
+// and updated.
+//
+// Imported tags with the same ident as an existing tag
+// replace the content, aliases and roles of the existing
+// tag when overwrite is true and are skipped otherwise.
+// Tags with a name conflicting with another tag are skipped
+// as well.
+//
+// Created tags have no ID, guild, creator and timestamps
+// set and their usage counter is reset. This must be done
+// by the caller.
+func PlanImport(existing, imported []Tag, overwrite bool) (res ImportResult, err error) {
+	res.Created = []Tag{}
+	res.Updated = []Tag{}
+	res.Skipped = []string{}
+
+	byIdent := make(map[string]int, len(existing))
+	for i, t := range existing {
+		byIdent[t.Ident] = i
+	}
+
+	planned := make([]Tag, len(existing))
+	copy(planned, existing)
+	created := make(map[string]bool)
+
+	for _, t := range imported {
+		t.Normalize()
+		if err = t.Validate(); err != nil {
+			return ImportResult{}, fmt.Errorf("tag '%s': %s", t.Ident, err.Error())
+		}
+
+		i, exists := byIdent[t.Ident]
+		if (exists && !overwrite) || created[t.Ident] {
+			res.Skipped = append(res.Skipped, t.Ident)
+			continue
+		}
+
+		if exists {
+			t.ID = existing[i].ID
+		} else {
+			t.ID = 0
+		}
+
+		if conflicts(t, planned) {
+			res.Skipped = append(res.Skipped, t.Ident)
+			continue
+		}
+
+		if exists {
+			updated := existing[i]
+			updated.Content = t.Content
+			updated.Aliases = t.Aliases
+			updated.Roles = t.Roles
+			planned[i] = updated
+			res.Updated = append(res.Updated, updated)
+		} else {
+			newTag := Tag{
+				Ident:   t.Ident,
+				Content: t.Content,
+				Aliases: t.Aliases,
+				Roles:   t.Roles,
+			}
+			created[t.Ident] = true
+			planned = append(planned, newTag)
+			res.Created = append(res.Created, newTag)
+		}
+	}
+
+	return
+}
+
+func conflicts(t Tag, tags []Tag) bool {
+	for _, other := range tags {
+		if t.ID != 0 && t.ID == other.ID {
+			continue
+		}
+		if t.ConflictsWith(other) != "" {
+			return true
+		}
+	}
+	return false
+}
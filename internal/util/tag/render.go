@@ -0,0 +1,101 @@
+package tag
+
+import (
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var rxVariable = regexp.MustCompile(`\[(user|ment|target|target\.ment|channel|args|arg\d+|random:[^\]]*)\]`)
+
+// randIntn is used to pick random choices and can be
+// replaced in tests to get deterministic results.
+var randIntn = rand.Intn
+
+// RenderContext contains the information about a tag
+// invokation which is used to replace the variables in
+// the content of the tag.
+type RenderContext struct {
+	// User is the user invoking the tag.
+	User *discordgo.User
+	// Target is the user mentioned on invokation. If
+	// no target is specified, User is used instead.
+	Target *discordgo.User
+	// ChannelID is the ID of the channel where the tag
+	// has been invoked.
+	ChannelID string
+	// Args are the arguments passed on invokation.
+	Args []string
+}
+
+// Render returns the content of the tag with all template
+// variables replaced by the values of the render context.
+//
+// The following variables are supported:
+//   - [user]          username of the invoking user
+//   - [ment]          mention of the invoking user
+//   - [target]        username of the target user
+//   - [target.ment]   mention of the target user
+//   - [channel]       mention of the channel
+//   - [args]          all arguments separated by spaces
+//   - [argN]          the N-th argument, starting at 1
+//   - [random:a|b|c]  one randomly picked choice
+//
+// Replaced values are not parsed again, so arguments can
+// not inject further variables.
+func (t *Tag) Render(rc RenderContext) string {
+	target := rc.Target
+	if target == nil {
+		target = rc.User
+	}
+
+	return rxVariable.ReplaceAllStringFunc(t.Content, func(v string) string {
+		name := v[1 : len(v)-1]
+
+		switch name {
+		case "user":
+			return username(rc.User)
+		case "ment":
+			return mention(rc.User)
+		case "target":
+			return username(target)
+		case "target.ment":
+			return mention(target)
+		case "channel":
+			if rc.ChannelID == "" {
+				return ""
+			}
+			return "<#" + rc.ChannelID + ">"
+		case "args":
+			return strings.Join(rc.Args, " ")
+		}
+
+		if strings.HasPrefix(name, "random:") {
+			choices := strings.Split(name[len("random:"):], "|")
+			return choices[randIntn(len(choices))]
+		}
+
+		n, _ := strconv.Atoi(name[len("arg"):])
+		if n < 1 || n > len(rc.Args) {
+			return ""
+		}
+		return rc.Args[n-1]
+	})
+}
+
+func username(u *discordgo.User) string {
+	if u == nil {
+		return ""
+	}
+	return u.Username
+}
+
+func mention(u *discordgo.User) string {
+	if u == nil {
+		return ""
+	}
+	return u.Mention()
+}
@@ -1,26 +1,106 @@
 package tag
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/sahilm/fuzzy"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
 	"github.com/zekrotja/dgrs"
 
 	"github.com/bwmarrin/snowflake"
 )
 
+var rxName = regexp.MustCompile(`^[^\s;]{1,32}$`)
+
 // Tag wraps a chat tag object.
 type Tag struct {
-	ID        snowflake.ID
-	Ident     string
-	CreatorID string
-	GuildID   string
-	Content   string
-	Created   time.Time
-	LastEdit  time.Time
+	ID        snowflake.ID `json:"id"`
+	Ident     string       `json:"ident"`
+	CreatorID string       `json:"creatorid"`
+	GuildID   string       `json:"guildid"`
+	Content   string       `json:"content"`
+	Created   time.Time    `json:"created"`
+	LastEdit  time.Time    `json:"lastedit"`
+	Aliases   []string     `json:"aliases"`
+	Roles     []string     `json:"roles"`
+	Uses      int          `json:"uses"`
+}
+
+// Normalize lower-cases the ident and the aliases
+// of the tag and removes duplicate aliases as well
+// as aliases equal to the ident.
+func (t *Tag) Normalize() {
+	t.Ident = strings.ToLower(strings.TrimSpace(t.Ident))
+
+	aliases := make([]string, 0, len(t.Aliases))
+	for _, a := range t.Aliases {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" || a == t.Ident || stringutil.ContainsAny(a, aliases) {
+			continue
+		}
+		aliases = append(aliases, a)
+	}
+	t.Aliases = aliases
+}
+
+// Validate returns an error when the tag has an invalid
+// ident or alias or when its content is empty.
+func (t *Tag) Validate() error {
+	if !rxName.MatchString(t.Ident) {
+		return errors.New("name must be 1 to 32 characters long and must not contain spaces or semicolons")
+	}
+	for _, a := range t.Aliases {
+		if !rxName.MatchString(a) {
+			return fmt.Errorf("alias '%s' must be 1 to 32 characters long and must not contain spaces or semicolons", a)
+		}
+	}
+	if strings.TrimSpace(t.Content) == "" {
+		return errors.New("content must not be empty")
+	}
+	return nil
+}
+
+// Names returns the ident and all aliases of the tag.
+func (t *Tag) Names() []string {
+	return append([]string{t.Ident}, t.Aliases...)
+}
+
+// ConflictsWith returns the first name of the tag which
+// is also used as ident or alias by the other tag. If
+// there is no conflict or both are the same tag, an empty
+// string is returned.
+func (t *Tag) ConflictsWith(other Tag) string {
+	if t.ID != 0 && t.ID == other.ID {
+		return ""
+	}
+	otherNames := other.Names()
+	for _, name := range t.Names() {
+		if stringutil.ContainsAny(name, otherNames) {
+			return name
+		}
+	}
+	return ""
+}
+
+// CanUse returns true when the tag is not restricted
+// to any roles or when at least one of the passed roles
+// is allowed to use the tag.
+func (t *Tag) CanUse(roleIDs []string) bool {
+	if len(t.Roles) == 0 {
+		return true
+	}
+	for _, r := range roleIDs {
+		if stringutil.ContainsAny(r, t.Roles) {
+			return true
+		}
+	}
+	return false
 }
 
 // author wraps a name tag and avatar imageURL
@@ -63,7 +143,12 @@ func (t *Tag) AsEmbed(s *dgrs.State) *discordgo.MessageEmbed {
 func (t *Tag) AsEntry(s *dgrs.State) string {
 	author := t.formattedAuthor(s)
 
-	return fmt.Sprintf("**%s** by %s [`%s`]", t.Ident, author.nameTag, t.ID)
+	var aliases string
+	if len(t.Aliases) > 0 {
+		aliases = fmt.Sprintf(" (`%s`)", strings.Join(t.Aliases, "`, `"))
+	}
+
+	return fmt.Sprintf("**%s**%s by %s [`%s`] - %d uses", t.Ident, aliases, author.nameTag, t.ID, t.Uses)
 }
 
 // RawContent returns the content of the tags body
@@ -92,7 +177,7 @@ type SearchableTagList []Tag
 var _ fuzzy.Source = (*SearchableTagList)(nil)
 
 func (t SearchableTagList) String(i int) string {
-	return t[i].Ident
+	return strings.Join(t[i].Names(), " ")
 }
 
 func (t SearchableTagList) Len() int {
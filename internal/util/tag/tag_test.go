@@ -0,0 +1,107 @@
+package tag
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	randIntn = func(n int) int { return n - 1 }
+
+	user := &discordgo.User{ID: "1", Username: "alice"}
+	target := &discordgo.User{ID: "2", Username: "bob"}
+
+	tg := Tag{Content: "[user] [ment] [target] [target.ment] [channel] " +
+		"[args] [arg2] [arg3] [random:a|b|c] [unknown]"}
+
+	res := tg.Render(RenderContext{User: user, Target: target, ChannelID: "3", Args: []string{"x", "y"}})
+	assert.Equal(t, "alice <@1> bob <@2> <#3> x y y  c [unknown]", res)
+
+	res = tg.Render(RenderContext{User: user})
+	assert.Equal(t, "alice <@1> alice <@1>     c [unknown]", res)
+
+	// Arguments must not be able to inject variables.
+	tg = Tag{Content: "[arg1]"}
+	res = tg.Render(RenderContext{User: user, Args: []string{"[ment]"}})
+	assert.Equal(t, "[ment]", res)
+}
+
+func TestNormalizeAndValidate(t *testing.T) {
+	tg := Tag{Ident: " Foo ", Content: "bar", Aliases: []string{"F", "foo", "f", ""}}
+	tg.Normalize()
+	assert.Equal(t, "foo", tg.Ident)
+	assert.Equal(t, []string{"f"}, tg.Aliases)
+	assert.NoError(t, tg.Validate())
+
+	tg.Aliases = []string{"a;b"}
+	assert.Error(t, tg.Validate())
+
+	tg.Aliases = nil
+	tg.Ident = "foo bar"
+	assert.Error(t, tg.Validate())
+
+	tg.Ident = "foo"
+	tg.Content = " "
+	assert.Error(t, tg.Validate())
+}
+
+func TestConflictsWith(t *testing.T) {
+	a := Tag{ID: 1, Ident: "a", Aliases: []string{"x"}}
+	b := Tag{ID: 2, Ident: "b", Aliases: []string{"x"}}
+	c := Tag{ID: 3, Ident: "c"}
+
+	assert.Equal(t, "x", a.ConflictsWith(b))
+	assert.Equal(t, "", a.ConflictsWith(c))
+	assert.Equal(t, "", a.ConflictsWith(a))
+}
+
+func TestCanUse(t *testing.T) {
+	tg := Tag{}
+	assert.True(t, tg.CanUse(nil))
+
+	tg.Roles = []string{"r1", "r2"}
+	assert.False(t, tg.CanUse(nil))
+	assert.False(t, tg.CanUse([]string{"r3"}))
+	assert.True(t, tg.CanUse([]string{"r3", "r2"}))
+}
+
+func TestPlanImport(t *testing.T) {
+	existing := []Tag{
+		{ID: 1, Ident: "a", Content: "old", Aliases: []string{"x"}, Uses: 5},
+		{ID: 2, Ident: "b", Content: "old"},
+	}
+	imported := []Tag{
+		{Ident: "A", Content: "new", Aliases: []string{"y"}},
+		{Ident: "c", Content: "new", Aliases: []string{"y"}},
+		{Ident: "d", Content: "new", Aliases: []string{"x"}},
+		{Ident: "e", Content: "new"},
+		{Ident: "e", Content: "duplicate"},
+	}
+
+	res, err := PlanImport(existing, imported, false)
+	require.NoError(t, err)
+	assert.Empty(t, res.Updated)
+	assert.Equal(t, []string{"c", "e"}, identsOf(res.Created))
+	assert.Equal(t, []string{"a", "d", "e"}, res.Skipped)
+
+	res, err = PlanImport(existing, imported, true)
+	require.NoError(t, err)
+	require.Len(t, res.Updated, 1)
+	assert.Equal(t, Tag{ID: 1, Ident: "a", Content: "new", Aliases: []string{"y"}, Uses: 5}, res.Updated[0])
+	assert.Equal(t, []string{"d", "e"}, identsOf(res.Created))
+	assert.Equal(t, []string{"c", "e"}, res.Skipped)
+
+	_, err = PlanImport(existing, []Tag{{Ident: "in valid", Content: "new"}}, false)
+	assert.Error(t, err)
+}
+
+func identsOf(tags []Tag) []string {
+	idents := make([]string, len(tags))
+	for i, t := range tags {
+		idents[i] = t.Ident
+	}
+	return idents
+}
@@ -0,0 +1,31 @@
+package testutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/zekroTJA/shinpuru/internal/models"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/services/database/sqlite"
+)
+
+// NewTestDatabase creates a fully migrated SQLite database
+// in a temporary directory which is cleaned up after the
+// test has finished.
+func NewTestDatabase(t testing.TB) database.Database {
+	db := sqlite.New()
+
+	err := db.Connect(models.DatabaseSqlite{
+		File: filepath.Join(t.TempDir(), "shinpuru.db"),
+	})
+	if err != nil {
+		t.Fatalf("failed connecting to test database: %s", err.Error())
+	}
+	t.Cleanup(db.Close)
+
+	if err = db.Migrate(); err != nil {
+		t.Fatalf("failed migrating test database: %s", err.Error())
+	}
+
+	return db
+}
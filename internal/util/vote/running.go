@@ -0,0 +1,72 @@
+package vote
+
+import "sync"
+
+// Registry is a concurrency safe collection of
+// running votes mapped by their IDs.
+type Registry struct {
+	mtx   sync.RWMutex
+	votes map[string]Vote
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		votes: make(map[string]Vote),
+	}
+}
+
+// Get returns the vote with the given ID. ok is
+// false if no vote with that ID is registered.
+func (r *Registry) Get(id string) (v Vote, ok bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	v, ok = r.votes[id]
+	return
+}
+
+// Set registers the given vote by its ID or
+// replaces the already registered instance.
+func (r *Registry) Set(v Vote) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.votes[v.ID] = v
+}
+
+// Delete removes the vote with the given ID.
+func (r *Registry) Delete(id string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	delete(r.votes, id)
+}
+
+// Clear removes all registered votes.
+func (r *Registry) Clear() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.votes = make(map[string]Vote)
+}
+
+// Range calls f for each registered vote until f
+// returns false.
+//
+// f is called on a snapshot of the registered votes,
+// so it is safe to modify the registry from within f.
+func (r *Registry) Range(f func(v Vote) bool) {
+	r.mtx.RLock()
+	snapshot := make([]Vote, 0, len(r.votes))
+	for _, v := range r.votes {
+		snapshot = append(snapshot, v)
+	}
+	r.mtx.RUnlock()
+
+	for _, v := range snapshot {
+		if !f(v) {
+			return
+		}
+	}
+}
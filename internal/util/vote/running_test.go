@@ -0,0 +1,63 @@
+package vote
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	_, ok := r.Get("a")
+	assert.False(t, ok)
+
+	r.Set(Vote{ID: "a", GuildID: "g1"})
+	r.Set(Vote{ID: "b", GuildID: "g2"})
+
+	v, ok := r.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "g1", v.GuildID)
+
+	var ids []string
+	r.Range(func(v Vote) bool {
+		ids = append(ids, v.ID)
+		r.Delete(v.ID)
+		return true
+	})
+	assert.ElementsMatch(t, []string{"a", "b"}, ids)
+
+	_, ok = r.Get("b")
+	assert.False(t, ok)
+
+	r.Set(Vote{ID: "c"})
+	r.Clear()
+	_, ok = r.Get("c")
+	assert.False(t, ok)
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		id := strconv.Itoa(i)
+		go func() {
+			defer wg.Done()
+			r.Set(Vote{ID: id})
+		}()
+		go func() {
+			defer wg.Done()
+			r.Get(id)
+			r.Range(func(Vote) bool { return true })
+		}()
+		go func() {
+			defer wg.Done()
+			r.Delete(id)
+		}()
+	}
+	wg.Wait()
+}
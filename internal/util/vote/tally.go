@@ -0,0 +1,237 @@
+package vote
+
+import (
+	"sort"
+	"time"
+)
+
+// Round contains the weighted number of votes for each
+// possibility in a single instant-runoff round as well
+// as the possibilities eliminated after the round.
+type Round struct {
+	Counts     []int `json:"counts"`
+	Eliminated []int `json:"eliminated"`
+}
+
+// Results contains the tallied results of a vote.
+type Results struct {
+	// Ballots is the number of users who voted.
+	Ballots int `json:"ballots"`
+	// Totals contains the weighted number of votes for each
+	// possibility. For ranked votes, only first preferences
+	// are counted.
+	Totals []int `json:"totals"`
+	// Rounds contains the instant-runoff rounds of
+	// ranked votes.
+	Rounds []Round `json:"rounds,omitempty"`
+	// Winners contains the indices of the possibilities with
+	// the most votes or the winner of the instant-runoff. If
+	// multiple possibilities are tied, all of them are winners.
+	Winners []int `json:"winners"`
+}
+
+// Results tallies the ticks of the vote.
+func (v *Vote) Results() (res Results) {
+	ticksMtx.RLock()
+	defer ticksMtx.RUnlock()
+
+	n := len(v.Possibilities)
+	res.Totals = make([]int, n)
+	res.Winners = []int{}
+
+	for _, t := range v.Ticks {
+		picks := t.picks()
+		if len(picks) == 0 {
+			continue
+		}
+		res.Ballots++
+		if v.Mode == VoteModeRanked {
+			picks = picks[:1]
+		}
+		for _, p := range picks {
+			if p >= 0 && p < n {
+				res.Totals[p] += t.weight()
+			}
+		}
+	}
+
+	if v.Mode == VoteModeRanked {
+		res.Rounds, res.Winners = v.instantRunoff()
+		return
+	}
+
+	max := 0
+	for i, c := range res.Totals {
+		if c == 0 || c < max {
+			continue
+		}
+		if c > max {
+			max = c
+			res.Winners = res.Winners[:0]
+		}
+		res.Winners = append(res.Winners, i)
+	}
+
+	return
+}
+
+// instantRunoff counts the first preferences of all ballots
+// among the remaining possibilities in each round. When a
+// possibility has the majority of the counted votes, it wins.
+// Otherwise, the possibilities with the fewest votes are
+// eliminated. If all remaining possibilities are tied, all
+// of them are winners.
+func (v *Vote) instantRunoff() (rounds []Round, winners []int) {
+	n := len(v.Possibilities)
+	remaining := make([]bool, n)
+	for i := range remaining {
+		remaining[i] = true
+	}
+	nRemaining := n
+
+	for nRemaining > 0 {
+		round := Round{Counts: make([]int, n), Eliminated: []int{}}
+		total := 0
+		for _, t := range v.Ticks {
+			for _, p := range t.picks() {
+				if p >= 0 && p < n && remaining[p] {
+					round.Counts[p] += t.weight()
+					total += t.weight()
+					break
+				}
+			}
+		}
+
+		if total == 0 {
+			return append(rounds, round), []int{}
+		}
+
+		min, max := -1, 0
+		for i, c := range round.Counts {
+			if !remaining[i] {
+				continue
+			}
+			if c > round.Counts[max] || !remaining[max] {
+				max = i
+			}
+			if min == -1 || c < min {
+				min = c
+			}
+		}
+
+		if round.Counts[max]*2 > total {
+			return append(rounds, round), []int{max}
+		}
+
+		for i, c := range round.Counts {
+			if remaining[i] && c == min {
+				round.Eliminated = append(round.Eliminated, i)
+			}
+		}
+
+		if len(round.Eliminated) == nRemaining {
+			return append(rounds, round), round.Eliminated
+		}
+
+		for _, i := range round.Eliminated {
+			remaining[i] = false
+		}
+		nRemaining -= len(round.Eliminated)
+		rounds = append(rounds, round)
+	}
+
+	return rounds, []int{}
+}
+
+// Ballot contains the picks of a single user.
+//
+// Votes never store the user IDs of voters, so Voter is
+// a pseudonym: the hash of the user ID salted with the
+// vote ID. It identifies the same user within a vote but
+// can not be mapped back to the user ID and differs
+// between votes. For anonymous votes, the voter is
+// omitted.
+type Ballot struct {
+	// Voter is the pseudonym of the user, not their ID.
+	Voter  string `json:"voter,omitempty"`
+	Picks  []int  `json:"picks"`
+	Weight int    `json:"weight"`
+}
+
+// Export contains the settings of a vote together
+// with its results and ballots.
+type Export struct {
+	ID            string         `json:"id"`
+	GuildID       string         `json:"guild_id"`
+	ChannelID     string         `json:"channel_id"`
+	CreatorID     string         `json:"creator_id"`
+	Description   string         `json:"description"`
+	Possibilities []string       `json:"possibilities"`
+	Mode          VoteMode       `json:"mode"`
+	MaxPicks      int            `json:"max_picks"`
+	Anonymous     bool           `json:"anonymous"`
+	Roles         []string       `json:"roles"`
+	RoleWeights   map[string]int `json:"role_weights"`
+	State         VoteState      `json:"state"`
+	Expires       *time.Time     `json:"expires,omitempty"`
+	Closed        *time.Time     `json:"closed,omitempty"`
+	Results       Results        `json:"results"`
+	Ballots       []Ballot       `json:"ballots"`
+}
+
+// Export returns the vote with its results and ballots.
+func (v *Vote) Export() Export {
+	e := Export{
+		ID:            v.ID,
+		GuildID:       v.GuildID,
+		ChannelID:     v.ChannelID,
+		CreatorID:     v.CreatorID,
+		Description:   v.Description,
+		Possibilities: v.Possibilities,
+		Mode:          v.Mode,
+		MaxPicks:      v.MaxPicks,
+		Anonymous:     v.Anonymous,
+		Roles:         v.Roles,
+		RoleWeights:   v.RoleWeights,
+		State:         v.State,
+		Results:       v.Results(),
+	}
+	if !v.Expires.IsZero() {
+		e.Expires = &v.Expires
+	}
+	if !v.Closed.IsZero() {
+		e.Closed = &v.Closed
+	}
+
+	ticksMtx.RLock()
+	defer ticksMtx.RUnlock()
+
+	e.Ballots = make([]Ballot, 0, len(v.Ticks))
+	for _, t := range v.Ticks {
+		b := Ballot{
+			Picks:  t.picks(),
+			Weight: t.weight(),
+		}
+		if !v.Anonymous {
+			b.Voter = t.UserID
+		}
+		e.Ballots = append(e.Ballots, b)
+	}
+	sort.Slice(e.Ballots, func(i, j int) bool {
+		a, b := e.Ballots[i], e.Ballots[j]
+		if a.Voter != b.Voter {
+			return a.Voter < b.Voter
+		}
+		for k := 0; k < len(a.Picks) && k < len(b.Picks); k++ {
+			if a.Picks[k] != b.Picks[k] {
+				return a.Picks[k] < b.Picks[k]
+			}
+		}
+		if len(a.Picks) != len(b.Picks) {
+			return len(a.Picks) < len(b.Picks)
+		}
+		return a.Weight < b.Weight
+	})
+
+	return e
+}
@@ -0,0 +1,185 @@
+package vote
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVote(mode VoteMode, possibilities ...string) *Vote {
+	return &Vote{
+		ID:            "vote",
+		Mode:          mode,
+		Possibilities: possibilities,
+		Ticks:         make(map[string]*Tick),
+	}
+}
+
+func TestPickSingle(t *testing.T) {
+	v := newTestVote(VoteModeSingle, "a", "b")
+
+	_, err := v.Pick(uid1, nil, 0)
+	require.NoError(t, err)
+	picks, err := v.Pick(uid1, nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, picks)
+
+	_, err = v.Pick(uid1, nil, 2)
+	assert.ErrorIs(t, err, ErrInvalidPick)
+
+	res := v.Results()
+	assert.Equal(t, 1, res.Ballots)
+	assert.Equal(t, []int{0, 1}, res.Totals)
+	assert.Equal(t, []int{1}, res.Winners)
+}
+
+func TestPickMulti(t *testing.T) {
+	v := newTestVote(VoteModeMulti, "a", "b", "c")
+	v.MaxPicks = 2
+
+	_, err := v.Pick(uid1, nil, 0)
+	require.NoError(t, err)
+	picks, err := v.Pick(uid1, nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, picks)
+
+	picks, err = v.Pick(uid1, nil, 1)
+	assert.ErrorIs(t, err, ErrMaxPicks)
+	assert.Equal(t, []int{0, 2}, picks)
+
+	// Picking again removes the pick.
+	picks, err = v.Pick(uid1, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, picks)
+
+	_, err = v.Pick(uid2, nil, 2)
+	require.NoError(t, err)
+
+	res := v.Results()
+	assert.Equal(t, 2, res.Ballots)
+	assert.Equal(t, []int{0, 0, 2}, res.Totals)
+	assert.Equal(t, []int{2}, res.Winners)
+
+	_, err = v.Pick(uid1, nil, 2)
+	require.NoError(t, err)
+	assert.Len(t, v.Ticks, 1)
+}
+
+func TestPickRestrictedAndWeighted(t *testing.T) {
+	v := newTestVote(VoteModeSingle, "a", "b")
+	v.Roles = []string{"member", "mod"}
+	v.RoleWeights = map[string]int{"mod": 3}
+
+	_, err := v.Pick(uid1, []string{"other"}, 0)
+	assert.ErrorIs(t, err, ErrNotAllowed)
+
+	_, err = v.Pick(uid1, []string{"member"}, 0)
+	require.NoError(t, err)
+	_, err = v.Pick(uid2, []string{"member", "mod"}, 1)
+	require.NoError(t, err)
+
+	res := v.Results()
+	assert.Equal(t, []int{1, 3}, res.Totals)
+	assert.Equal(t, []int{1}, res.Winners)
+
+	require.NoError(t, v.Retract(uid2))
+	assert.Equal(t, []int{1, 0}, v.Results().Totals)
+
+	v.State = VoteStateClosed
+	_, err = v.Pick(uid2, []string{"mod"}, 1)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestInstantRunoff(t *testing.T) {
+	v := newTestVote(VoteModeRanked, "a", "b", "c")
+	ballots := [][]int{
+		{0, 1}, {0, 1}, {0},
+		{1, 0}, {1, 0},
+		{2, 1}, {2, 1}, {2, 1},
+	}
+	for i, b := range ballots {
+		v.Ticks[string(rune('a'+i))] = &Tick{Picks: b}
+	}
+
+	res := v.Results()
+	assert.Equal(t, 8, res.Ballots)
+	assert.Equal(t, []int{3, 2, 3}, res.Totals)
+	require.Len(t, res.Rounds, 2)
+	assert.Equal(t, []int{1}, res.Rounds[0].Eliminated)
+	assert.Equal(t, []int{5, 0, 3}, res.Rounds[1].Counts)
+	assert.Equal(t, []int{0}, res.Winners)
+}
+
+func TestInstantRunoffTie(t *testing.T) {
+	v := newTestVote(VoteModeRanked, "a", "b", "c")
+	v.Ticks["x"] = &Tick{Picks: []int{0}}
+	v.Ticks["y"] = &Tick{Picks: []int{1}, Weight: 1}
+
+	res := v.Results()
+	require.Len(t, res.Rounds, 2)
+	assert.Equal(t, []int{2}, res.Rounds[0].Eliminated)
+	assert.Equal(t, []int{0, 1}, res.Winners)
+
+	v = newTestVote(VoteModeRanked, "a", "b")
+	assert.Empty(t, v.Results().Winners)
+}
+
+func TestExportAnonymous(t *testing.T) {
+	v := newTestVote(VoteModeSingle, "a", "b")
+
+	_, err := v.Pick(uid1, nil, 0)
+	require.NoError(t, err)
+	_, err = v.Pick(uid2, nil, 1)
+	require.NoError(t, err)
+
+	e := v.Export()
+	require.Len(t, e.Ballots, 2)
+	for _, b := range e.Ballots {
+		assert.NotEmpty(t, b.Voter)
+	}
+
+	v.Anonymous = true
+	e = v.Export()
+	require.Len(t, e.Ballots, 2)
+	assert.Equal(t, []int{0}, e.Ballots[0].Picks)
+	assert.Equal(t, []int{1}, e.Ballots[1].Picks)
+
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"voter"`)
+	for _, tick := range v.Ticks {
+		assert.NotContains(t, string(data), tick.UserID)
+	}
+}
+
+func TestPickMarshalConcurrent(t *testing.T) {
+	v := newTestVote(VoteModeMulti, "a", "b", "c")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			v.Pick(uid1, nil, i%3)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			v.Pick(uid2, nil, i%3)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := v.Marshal()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	raw, err := v.Marshal()
+	require.NoError(t, err)
+	res, err := Unmarshal(raw)
+	require.NoError(t, err)
+	assert.Len(t, res.Ticks, len(v.Ticks))
+}
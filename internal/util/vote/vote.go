@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/wcharczuk/go-chart/drawing"
 	"github.com/zekroTJA/shinpuru/internal/services/timeprovider"
 	"github.com/zekroTJA/shinpuru/internal/util/static"
 	"github.com/zekroTJA/shinpuru/pkg/discordutil"
+	"github.com/zekroTJA/shinpuru/pkg/stringutil"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/wcharczuk/go-chart"
@@ -27,9 +31,44 @@ const (
 	VoteStateExpired
 )
 
-// VotesRunning maps running vote IDs to
-// their vote instances.
-var VotesRunning = map[string]Vote{}
+// VoteMode defines how users can pick the
+// possibilities of a vote.
+type VoteMode int
+
+const (
+	// VoteModeSingle lets users pick one possibility.
+	VoteModeSingle VoteMode = iota
+	// VoteModeMulti lets users pick multiple possibilities
+	// up to the maximum number of picks of the vote.
+	VoteModeMulti
+	// VoteModeRanked lets users rank the possibilities
+	// by picking them in the order of their preference.
+	// The result is tallied by instant-runoff.
+	VoteModeRanked
+)
+
+// ComponentPrefix is the prefix of the custom IDs of
+// the message components of votes.
+const ComponentPrefix = "vote:"
+
+// ClosedLifetime is the time span after which closed
+// votes are removed from the database.
+const ClosedLifetime = 30 * 24 * time.Hour
+
+var (
+	ErrClosed      = errors.New("vote is closed")
+	ErrNotAllowed  = errors.New("not allowed to vote")
+	ErrMaxPicks    = errors.New("maximum number of picks reached")
+	ErrInvalidPick = errors.New("invalid pick")
+)
+
+// VotesRunning contains the running vote
+// instances mapped by their IDs.
+var VotesRunning = NewRegistry()
+
+// ticksMtx guards the ticks of all votes which are
+// modified by concurrent reaction and button events.
+var ticksMtx sync.RWMutex
 
 // VoteEmotes contains the emotes used to tick a vote.
 var VoteEmotes = strings.Fields("\u0031\u20E3 \u0032\u20E3 \u0033\u20E3 \u0034\u20E3 \u0035\u20E3 \u0036\u20E3 \u0037\u20E3 \u0038\u20E3 \u0039\u20E3 \u0030\u20E3")
@@ -47,6 +86,19 @@ type Vote struct {
 	Expires       time.Time
 	Possibilities []string
 	Ticks         map[string]*Tick
+
+	Mode      VoteMode
+	MaxPicks  int
+	Anonymous bool
+	// Roles restricts voting to members of any
+	// of these roles, if not empty.
+	Roles []string
+	// RoleWeights maps role IDs to the weight of
+	// the votes of their members. Members without
+	// any of these roles have a weight of 1.
+	RoleWeights map[string]int
+	State       VoteState
+	Closed      time.Time
 }
 
 // Tick wraps a user ID and the index of
@@ -54,6 +106,27 @@ type Vote struct {
 type Tick struct {
 	UserID string
 	Tick   int
+	// Picks contains the picked possibilities of multiple
+	// choice votes or the ranking of ranked votes.
+	Picks  []int
+	Weight int
+}
+
+// picks returns the picked possibilities of the tick.
+func (t *Tick) picks() []int {
+	if t.Picks != nil {
+		return t.Picks
+	}
+	return []int{t.Tick}
+}
+
+// weight returns the weight of the tick, which is 1
+// for ticks created before votes were weightable.
+func (t *Tick) weight() int {
+	if t.Weight < 1 {
+		return 1
+	}
+	return t.Weight
 }
 
 // Unmarshal tries to deserialize a raw data string
@@ -69,6 +142,9 @@ func Unmarshal(data string) (Vote, error) {
 	buffer := bytes.NewBuffer(rawData)
 	gobdec := gob.NewDecoder(buffer)
 	err = gobdec.Decode(&res)
+	if res.Ticks == nil {
+		res.Ticks = make(map[string]*Tick)
+	}
 	return res, err
 }
 
@@ -77,6 +153,11 @@ func Unmarshal(data string) (Vote, error) {
 // The vote object is encoded to a byte array using
 // the gob encoder and then encoded to a base64 string.
 func (v *Vote) Marshal() (string, error) {
+	// The ticks are shared between all copies of the vote
+	// and might be modified by concurrent picks.
+	ticksMtx.RLock()
+	defer ticksMtx.RUnlock()
+
 	var buffer bytes.Buffer
 	gobenc := gob.NewEncoder(&buffer)
 	err := gobenc.Encode(v)
@@ -92,11 +173,6 @@ func (v *Vote) Marshal() (string, error) {
 // vote. If voteState is passed, the state will be
 // displayed as well. Otherwise, it will be assumed
 // that the vote is open.
-//
-// If voteState is VoteStateClosed or VoteStateExpired,
-// a pie chart will be generated representing the
-// distribution of vote ticks and sent as image to
-// the channel.
 func (v *Vote) AsEmbed(s *discordgo.Session, voteState ...VoteState) (*discordgo.MessageEmbed, error) {
 	state := VoteStateOpen
 	if len(voteState) > 0 {
@@ -119,18 +195,21 @@ func (v *Vote) AsEmbed(s *discordgo.Session, voteState ...VoteState) (*discordgo
 		color = static.ColorEmbedViolett
 	}
 
-	totalTicks := make(map[int]int)
-	for _, t := range v.Ticks {
-		if _, ok := totalTicks[t.Tick]; !ok {
-			totalTicks[t.Tick] = 1
-		} else {
-			totalTicks[t.Tick]++
-		}
-	}
+	res := v.Results()
 
 	description := v.Description + "\n\n"
 	for i, p := range v.Possibilities {
-		description += fmt.Sprintf("%s    %s  -  `%d`\n", VoteEmotes[i], p, totalTicks[i])
+		description += fmt.Sprintf("%s    %s  -  `%d`\n", VoteEmotes[i], p, res.Totals[i])
+	}
+	description += "\n" + v.rulesDescription()
+
+	if state != VoteStateOpen && v.Mode == VoteModeRanked && len(res.Winners) > 0 {
+		winners := make([]string, len(res.Winners))
+		for i, w := range res.Winners {
+			winners[i] = v.Possibilities[w]
+		}
+		description += fmt.Sprintf("\n**Winner:** %s (after %d rounds)",
+			strings.Join(winners, ", "), len(res.Rounds))
 	}
 
 	footerText := fmt.Sprintf("ID: %s", v.ID)
@@ -151,56 +230,140 @@ func (v *Vote) AsEmbed(s *discordgo.Session, voteState ...VoteState) (*discordgo
 		},
 	}
 
-	if len(totalTicks) > 0 && (state == VoteStateClosed || state == VoteStateExpired) {
+	if v.ImageURL != "" {
+		emb.Image = &discordgo.MessageEmbedImage{
+			URL: v.ImageURL,
+		}
+	}
 
-		values := make([]chart.Value, len(v.Possibilities))
+	return emb, nil
+}
 
-		for i, p := range v.Possibilities {
-			values[i] = chart.Value{
-				Value: float64(totalTicks[i]),
-				Label: p,
-			}
-		}
+// RenderChart renders a pie chart of the distribution
+// of the vote ticks as PNG image. For ranked votes, the
+// counts of the final instant-runoff round are used.
+func (v *Vote) RenderChart(res Results) (*bytes.Buffer, error) {
+	counts := res.Totals
+	if len(res.Rounds) > 0 {
+		counts = res.Rounds[len(res.Rounds)-1].Counts
+	}
 
-		pie := chart.PieChart{
-			Width:  512,
-			Height: 512,
-			Values: values,
-			Background: chart.Style{
-				FillColor: drawing.ColorTransparent,
-			},
+	values := make([]chart.Value, 0, len(v.Possibilities))
+	for i, p := range v.Possibilities {
+		if counts[i] == 0 {
+			continue
 		}
+		values = append(values, chart.Value{
+			Value: float64(counts[i]),
+			Label: p,
+		})
+	}
 
-		imgData := []byte{}
-		buff := bytes.NewBuffer(imgData)
-		err = pie.Render(chart.PNG, buff)
-		if err != nil {
-			return nil, err
-		}
+	pie := chart.PieChart{
+		Width:  512,
+		Height: 512,
+		Values: values,
+		Background: chart.Style{
+			FillColor: drawing.ColorTransparent,
+		},
+	}
 
-		_, err := s.ChannelMessageSendComplex(v.ChannelID, &discordgo.MessageSend{
-			File: &discordgo.File{
-				Name:   fmt.Sprintf("vote_chart_%s.png", v.ID),
-				Reader: buff,
-			},
-			Reference: &discordgo.MessageReference{
-				MessageID: v.MsgID,
-				ChannelID: v.ChannelID,
-				GuildID:   v.GuildID,
-			},
+	buff := &bytes.Buffer{}
+	err := pie.Render(chart.PNG, buff)
+	return buff, err
+}
+
+// PostResults sends the results of the vote as reply
+// to the vote message with a pie chart of the
+// distribution of the vote ticks attached.
+func (v *Vote) PostResults(s *discordgo.Session) error {
+	res := v.Results()
+
+	winners := make([]string, len(res.Winners))
+	for i, w := range res.Winners {
+		winners[i] = v.Possibilities[w]
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: static.ColorEmbedDefault,
+		Title: "Vote results",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Ballots", Value: strconv.Itoa(res.Ballots), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("ID: %s", v.ID),
+		},
+	}
+	if len(winners) > 0 {
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name:   "Winner",
+			Value:  strings.Join(winners, ", "),
+			Inline: true,
 		})
+	}
+
+	msg := &discordgo.MessageSend{
+		Embed: emb,
+		Reference: &discordgo.MessageReference{
+			MessageID: v.MsgID,
+			ChannelID: v.ChannelID,
+			GuildID:   v.GuildID,
+		},
+	}
+
+	if res.Ballots > 0 {
+		buff, err := v.RenderChart(res)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		fileName := fmt.Sprintf("vote_chart_%s.png", v.ID)
+		msg.File = &discordgo.File{
+			Name:   fileName,
+			Reader: buff,
+		}
+		emb.Image = &discordgo.MessageEmbedImage{
+			URL: "attachment://" + fileName,
 		}
 	}
 
-	if v.ImageURL != "" {
-		emb.Image = &discordgo.MessageEmbedImage{
-			URL: v.ImageURL,
+	_, err := s.ChannelMessageSendComplex(v.ChannelID, msg)
+	return err
+}
+
+// rulesDescription returns a short description of
+// the mode and restrictions of the vote.
+func (v *Vote) rulesDescription() string {
+	var sb strings.Builder
+
+	switch v.Mode {
+	case VoteModeMulti:
+		if v.MaxPicks > 0 {
+			fmt.Fprintf(&sb, "*Multiple choice - up to %d picks.*", v.MaxPicks)
+		} else {
+			sb.WriteString("*Multiple choice.*")
 		}
+	case VoteModeRanked:
+		sb.WriteString("*Ranked choice - pick the possibilities in order of your preference. " +
+			"Counts show first preferences.*")
+	default:
+		sb.WriteString("*Single choice.*")
+	}
+	if v.Anonymous {
+		sb.WriteString(" *Anonymous.*")
 	}
 
-	return emb, nil
+	if len(v.Roles) > 0 {
+		fmt.Fprintf(&sb, "\nOnly members of %s can vote.", roleMentions(v.Roles))
+	}
+	if len(v.RoleWeights) > 0 {
+		weights := make([]string, 0, len(v.RoleWeights))
+		for roleID, w := range v.RoleWeights {
+			weights = append(weights, fmt.Sprintf("<@&%s> `x%d`", roleID, w))
+		}
+		fmt.Fprintf(&sb, "\nWeights: %s", strings.Join(weights, ", "))
+	}
+
+	return sb.String()
 }
 
 // AsField creates a discordgo.MessageEmbedField from
@@ -226,11 +389,20 @@ func (v *Vote) AsField() *discordgo.MessageEmbedField {
 	}
 }
 
+// UsesButtons returns true when the vote is ticked by
+// message component buttons instead of reactions.
+func (v *Vote) UsesButtons() bool {
+	return v.Anonymous || v.Mode != VoteModeSingle
+}
+
 // AddReactions adds the reactions to the votes message
 // for each selection possibility.
 //
 // Vote emotes are used from VoteEmotes.
 func (v *Vote) AddReactions(s *discordgo.Session) error {
+	if v.UsesButtons() {
+		return nil
+	}
 	for i := 0; i < len(v.Possibilities); i++ {
 		err := s.MessageReactionAdd(v.ChannelID, v.MsgID, VoteEmotes[i])
 		if err != nil {
@@ -240,55 +412,274 @@ func (v *Vote) AddReactions(s *discordgo.Session) error {
 	return nil
 }
 
-// Tick sets the tick for the specified user to the vote.
-func (v *Vote) Tick(s *discordgo.Session, userID string, tick int) (err error) {
+// Components returns the buttons used to tick the vote
+// if it UsesButtons. Each possibility gets a button with
+// the custom ID ComponentPrefix + "<voteID>:<index>".
+// Additionally, there are buttons to show and retract
+// the own vote.
+func (v *Vote) Components() []discordgo.MessageComponent {
+	if !v.UsesButtons() {
+		return []discordgo.MessageComponent{}
+	}
+
+	var rows []discordgo.MessageComponent
+	var row discordgo.ActionsRow
+	for i, p := range v.Possibilities {
+		row.Components = append(row.Components, discordgo.Button{
+			Label:    stringutil.Cap(fmt.Sprintf("%d. %s", i+1, p), 80),
+			Style:    discordgo.PrimaryButton,
+			CustomID: v.ComponentID(strconv.Itoa(i)),
+		})
+		if len(row.Components) == 5 {
+			rows = append(rows, row)
+			row = discordgo.ActionsRow{}
+		}
+	}
+	if len(row.Components) > 0 {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows, discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Show my vote",
+				Style:    discordgo.SecondaryButton,
+				CustomID: v.ComponentID("show"),
+			},
+			discordgo.Button{
+				Label:    "Retract my vote",
+				Style:    discordgo.DangerButton,
+				CustomID: v.ComponentID("retract"),
+			},
+		},
+	})
+
+	return rows
+}
+
+// ComponentID returns the custom ID of the
+// vote component with the given key.
+func (v *Vote) ComponentID(key string) string {
+	return ComponentPrefix + v.ID + ":" + key
+}
+
+// CanVote returns true when the vote is not restricted
+// to any roles or when any of the passed roles is
+// allowed to vote.
+func (v *Vote) CanVote(roleIDs []string) bool {
+	if len(v.Roles) == 0 {
+		return true
+	}
+	for _, r := range roleIDs {
+		if stringutil.ContainsAny(r, v.Roles) {
+			return true
+		}
+	}
+	return false
+}
+
+// WeightOf returns the highest weight of the passed roles
+// or 1 if none of the roles has a weight assigned.
+func (v *Vote) WeightOf(roleIDs []string) int {
+	weight := 1
+	for _, r := range roleIDs {
+		if w, ok := v.RoleWeights[r]; ok && w > weight {
+			weight = w
+		}
+	}
+	return weight
+}
+
+// Pick adds the possibility with the index choice to the
+// vote of the specified user and returns the resulting
+// picks of the user.
+//
+// For single choice votes, the pick replaces the previous
+// one. For multiple choice and ranked votes, picking an
+// already picked possibility removes it again.
+func (v *Vote) Pick(userID string, roleIDs []string, choice int) (picks []int, err error) {
+	if v.State != VoteStateOpen {
+		return nil, ErrClosed
+	}
+	if choice < 0 || choice >= len(v.Possibilities) {
+		return nil, ErrInvalidPick
+	}
+	if !v.CanVote(roleIDs) {
+		return nil, ErrNotAllowed
+	}
+
 	if userID, err = HashUserID(userID, []byte(v.ID)); err != nil {
 		return
 	}
 
-	if t, ok := v.Ticks[userID]; ok {
-		t.Tick = tick
-	} else {
-		v.Ticks[userID] = &Tick{
-			UserID: userID,
-			Tick:   tick,
+	ticksMtx.Lock()
+	defer ticksMtx.Unlock()
+
+	t, ok := v.Ticks[userID]
+	if !ok {
+		t = &Tick{UserID: userID}
+	}
+	t.Weight = v.WeightOf(roleIDs)
+
+	if v.Mode == VoteModeSingle {
+		t.Tick = choice
+		t.Picks = nil
+		v.Ticks[userID] = t
+		return []int{choice}, nil
+	}
+
+	picks = make([]int, 0, len(t.Picks)+1)
+	removed := false
+	for _, p := range t.Picks {
+		if p == choice {
+			removed = true
+			continue
 		}
+		picks = append(picks, p)
+	}
+	if !removed {
+		if v.Mode == VoteModeMulti && v.MaxPicks > 0 && len(picks) >= v.MaxPicks {
+			return t.Picks, ErrMaxPicks
+		}
+		picks = append(picks, choice)
 	}
 
-	emb, err := v.AsEmbed(s)
-	if err != nil {
+	if len(picks) == 0 {
+		delete(v.Ticks, userID)
+		return picks, nil
+	}
+
+	t.Picks = picks
+	v.Ticks[userID] = t
+	return picks, nil
+}
+
+// PicksOf returns the current picks of the
+// specified user.
+func (v *Vote) PicksOf(userID string) (picks []int, err error) {
+	if userID, err = HashUserID(userID, []byte(v.ID)); err != nil {
+		return
+	}
+
+	ticksMtx.RLock()
+	defer ticksMtx.RUnlock()
+
+	if t, ok := v.Ticks[userID]; ok {
+		picks = t.picks()
+	}
+	return
+}
+
+// Retract removes the vote of the specified user.
+func (v *Vote) Retract(userID string) (err error) {
+	if v.State != VoteStateOpen {
+		return ErrClosed
+	}
+	if userID, err = HashUserID(userID, []byte(v.ID)); err != nil {
 		return
 	}
 
-	_, err = s.ChannelMessageEditEmbed(v.ChannelID, v.MsgID, emb)
+	ticksMtx.Lock()
+	defer ticksMtx.Unlock()
+
+	delete(v.Ticks, userID)
 	return
 }
 
-// SetExpire sets the expiration for a vote.
-func (v *Vote) SetExpire(s *discordgo.Session, d time.Duration, tp timeprovider.Provider) error {
-	v.Expires = tp.Now().Add(d)
+// Tick sets the tick for the specified user to the vote.
+func (v *Vote) Tick(s *discordgo.Session, userID string, roleIDs []string, tick int) (err error) {
+	if _, err = v.Pick(userID, roleIDs, tick); err != nil {
+		return
+	}
+	return v.Update(s)
+}
 
+// Update edits the message of the vote to
+// display the current state.
+func (v *Vote) Update(s *discordgo.Session) error {
 	emb, err := v.AsEmbed(s)
 	if err != nil {
 		return err
 	}
-	_, err = s.ChannelMessageEditEmbed(v.ChannelID, v.MsgID, emb)
+	return v.edit(s, emb, v.Components())
+}
 
-	return err
+// SetExpire sets the expiration for a vote.
+func (v *Vote) SetExpire(s *discordgo.Session, d time.Duration, tp timeprovider.Provider) error {
+	v.Expires = tp.Now().Add(d)
+	return v.Update(s)
 }
 
 // Close closes the vote and removes it
-// from the VotesRunning map.
+// from the VotesRunning registry.
+//
+// If voteState is VoteStateClosed or VoteStateExpired,
+// the results are posted to the channel of the vote.
 func (v *Vote) Close(s *discordgo.Session, voteState VoteState) error {
-	delete(VotesRunning, v.ID)
+	VotesRunning.Delete(v.ID)
+	v.State = voteState
+	v.Closed = time.Now()
 	emb, err := v.AsEmbed(s, voteState)
 	if err != nil {
 		return err
 	}
-	_, err = s.ChannelMessageEditEmbed(v.ChannelID, v.MsgID, emb)
+	err = v.edit(s, emb, []discordgo.MessageComponent{})
 	if err != nil {
 		return err
 	}
-	err = s.MessageReactionsRemoveAll(v.ChannelID, v.MsgID)
+	if !v.UsesButtons() {
+		if err = s.MessageReactionsRemoveAll(v.ChannelID, v.MsgID); err != nil {
+			return err
+		}
+	}
+	if voteState == VoteStateClosed || voteState == VoteStateExpired {
+		err = v.PostResults(s)
+	}
 	return err
 }
+
+func (v *Vote) edit(s *discordgo.Session, emb *discordgo.MessageEmbed, components []discordgo.MessageComponent) error {
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         v.MsgID,
+		Channel:    v.ChannelID,
+		Embeds:     []*discordgo.MessageEmbed{emb},
+		Components: components,
+	})
+	return err
+}
+
+func roleMentions(roleIDs []string) string {
+	mentions := make([]string, len(roleIDs))
+	for i, id := range roleIDs {
+		mentions[i] = "<@&" + id + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// DescribePicks returns a human readable description
+// of the passed picks of a user.
+func (v *Vote) DescribePicks(picks []int) string {
+	if len(picks) == 0 {
+		return "You have not voted yet."
+	}
+
+	names := make([]string, len(picks))
+	for i, p := range picks {
+		names[i] = v.Possibilities[p]
+	}
+
+	switch v.Mode {
+	case VoteModeMulti:
+		return fmt.Sprintf("You picked **%s**.", strings.Join(names, "**, **"))
+	case VoteModeRanked:
+		var sb strings.Builder
+		sb.WriteString("Your ranking:")
+		for i, n := range names {
+			fmt.Fprintf(&sb, "\n%d. **%s**", i+1, n)
+		}
+		sb.WriteString("\n\nPick a ranked possibility again to remove it from your ranking.")
+		return sb.String()
+	default:
+		return fmt.Sprintf("You voted for **%s**.", names[0])
+	}
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/services/database"
+	"github.com/zekroTJA/shinpuru/internal/util/static"
+	"github.com/zekroTJA/shinpuru/internal/util/vote"
+	"github.com/zekrotja/ken"
+)
+
+// RegisterVoteButtons registers the handlers for the
+// buttons of the passed vote if it UsesButtons. After
+// the vote has been closed, the handlers respond that
+// the vote is not running anymore.
+func RegisterVoteButtons(ch *ken.ComponentHandler, db database.Database, v vote.Vote) {
+	if !v.UsesButtons() {
+		return
+	}
+
+	for i := range v.Possibilities {
+		ch.Register(v.ComponentID(strconv.Itoa(i)), onVotePick(db, v.ID, i))
+	}
+	ch.Register(v.ComponentID("show"), onVoteShow(v.ID))
+	ch.Register(v.ComponentID("retract"), onVoteRetract(db, v.ID))
+}
+
+func onVotePick(db database.Database, voteID string, choice int) ken.ComponentHandlerFunc {
+	return func(ctx ken.ComponentContext) bool {
+		v, ok := deferVoteInteraction(ctx, voteID)
+		if !ok {
+			return false
+		}
+
+		var roleIDs []string
+		if member := ctx.GetEvent().Member; member != nil {
+			roleIDs = member.Roles
+		}
+
+		picks, err := v.Pick(ctx.User().ID, roleIDs, choice)
+		switch {
+		case errors.Is(err, vote.ErrNotAllowed):
+			return ctx.FollowUpError("You are not allowed to participate in this vote.", "").
+				Send().Error == nil
+		case errors.Is(err, vote.ErrMaxPicks):
+			return ctx.FollowUpError(
+				"You have reached the maximum number of picks. Pick one of your picks again to remove it.", "").
+				Send().Error == nil
+		case err != nil:
+			return ctx.FollowUpError("Failed processing your vote: "+err.Error(), "").
+				Send().Error == nil
+		}
+
+		if err = updateVote(ctx.GetSession(), db, v); err != nil {
+			return ctx.FollowUpError("Failed updating vote: "+err.Error(), "").
+				Send().Error == nil
+		}
+
+		return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+			Color:       static.ColorEmbedGreen,
+			Description: v.DescribePicks(picks),
+		}).Send().Error == nil
+	}
+}
+
+func onVoteShow(voteID string) ken.ComponentHandlerFunc {
+	return func(ctx ken.ComponentContext) bool {
+		v, ok := deferVoteInteraction(ctx, voteID)
+		if !ok {
+			return false
+		}
+
+		picks, err := v.PicksOf(ctx.User().ID)
+		if err != nil {
+			return ctx.FollowUpError("Failed getting your vote: "+err.Error(), "").
+				Send().Error == nil
+		}
+
+		return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+			Color:       static.ColorEmbedDefault,
+			Description: v.DescribePicks(picks),
+		}).Send().Error == nil
+	}
+}
+
+func onVoteRetract(db database.Database, voteID string) ken.ComponentHandlerFunc {
+	return func(ctx ken.ComponentContext) bool {
+		v, ok := deferVoteInteraction(ctx, voteID)
+		if !ok {
+			return false
+		}
+
+		if err := v.Retract(ctx.User().ID); err != nil {
+			return ctx.FollowUpError("Failed retracting your vote: "+err.Error(), "").
+				Send().Error == nil
+		}
+
+		if err := updateVote(ctx.GetSession(), db, v); err != nil {
+			return ctx.FollowUpError("Failed updating vote: "+err.Error(), "").
+				Send().Error == nil
+		}
+
+		return ctx.FollowUpEmbed(&discordgo.MessageEmbed{
+			Color:       static.ColorEmbedGreen,
+			Description: "Your vote has been retracted.",
+		}).Send().Error == nil
+	}
+}
+
+// deferVoteInteraction defers the ephemeral response to the
+// vote interaction and returns the running vote by ID. If
+// the vote is not running anymore, an error is responded.
+func deferVoteInteraction(ctx ken.ComponentContext, voteID string) (v vote.Vote, ok bool) {
+	ctx.SetEphemeral(true)
+	if err := ctx.Defer(); err != nil {
+		return
+	}
+
+	v, ok = vote.VotesRunning.Get(voteID)
+	if !ok {
+		ctx.FollowUpError("This vote is not running anymore.", "").Send()
+	}
+	return
+}
+
+func updateVote(s *discordgo.Session, db database.Database, v vote.Vote) error {
+	if err := v.Update(s); err != nil {
+		return err
+	}
+	return db.AddUpdateVote(v)
+}
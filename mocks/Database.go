@@ -27,17 +27,35 @@ type Database struct {
 	mock.Mock
 }
 
-// AddBackup provides a mock function with given fields: guildID, fileID
-func (_m *Database) AddBackup(guildID string, fileID string) error {
-	ret := _m.Called(guildID, fileID)
+// AddBackup provides a mock function with given fields: entry
+func (_m *Database) AddBackup(entry backupmodels.Entry) error {
+	ret := _m.Called(entry)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddBackup")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(guildID, fileID)
+	if rf, ok := ret.Get(0).(func(backupmodels.Entry) error); ok {
+		r0 = rf(entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddBackupKey provides a mock function with given fields: key
+func (_m *Database) AddBackupKey(key backupmodels.DataKey) error {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddBackupKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(backupmodels.DataKey) error); ok {
+		r0 = rf(key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -81,6 +99,24 @@ func (_m *Database) AddKarmaBlockList(guildID string, userID string) error {
 	return r0
 }
 
+// AddKarmaLedgerEntry provides a mock function with given fields: entry
+func (_m *Database) AddKarmaLedgerEntry(entry models.KarmaLedgerEntry) error {
+	ret := _m.Called(entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddKarmaLedgerEntry")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.KarmaLedgerEntry) error); ok {
+		r0 = rf(entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AddOrUpdateKarmaRule provides a mock function with given fields: rule
 func (_m *Database) AddOrUpdateKarmaRule(rule models.KarmaRule) error {
 	ret := _m.Called(rule)
@@ -117,6 +153,24 @@ func (_m *Database) AddReport(rep models.Report) error {
 	return r0
 }
 
+// AddReportRevision provides a mock function with given fields: rev
+func (_m *Database) AddReportRevision(rev models.ReportRevision) error {
+	ret := _m.Called(rev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddReportRevision")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.ReportRevision) error); ok {
+		r0 = rf(rev)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AddRoleSelects provides a mock function with given fields: v
 func (_m *Database) AddRoleSelects(v []models.RoleSelect) error {
 	ret := _m.Called(v)
@@ -253,6 +307,34 @@ func (_m *Database) CheckKarmaRule(guildID string, checksum string) (bool, error
 	return r0, r1
 }
 
+// ClaimVoteClose provides a mock function with given fields: id
+func (_m *Database) ClaimVoteClose(id string) (bool, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimVoteClose")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CleanupExpiredRefreshTokens provides a mock function with given fields:
 func (_m *Database) CleanupExpiredRefreshTokens() (int64, error) {
 	ret := _m.Called()
@@ -324,6 +406,24 @@ func (_m *Database) DeleteAPIToken(userID string) error {
 	return r0
 }
 
+// DeleteAntiraidLockdownState provides a mock function with given fields: guildID
+func (_m *Database) DeleteAntiraidLockdownState(guildID string) error {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAntiraidLockdownState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteBackup provides a mock function with given fields: guildID, fileID
 func (_m *Database) DeleteBackup(guildID string, fileID string) error {
 	ret := _m.Called(guildID, fileID)
@@ -342,6 +442,42 @@ func (_m *Database) DeleteBackup(guildID string, fileID string) error {
 	return r0
 }
 
+// DeleteBackupKey provides a mock function with given fields: keyID
+func (_m *Database) DeleteBackupKey(keyID string) error {
+	ret := _m.Called(keyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBackupKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteBackupRestoreCheckpoint provides a mock function with given fields: guildID
+func (_m *Database) DeleteBackupRestoreCheckpoint(guildID string) error {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBackupRestoreCheckpoint")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteBirthday provides a mock function with given fields: guildID, userID
 func (_m *Database) DeleteBirthday(guildID string, userID string) error {
 	ret := _m.Called(guildID, userID)
@@ -640,6 +776,36 @@ func (_m *Database) GetAPIToken(userID string) (models.APITokenEntry, error) {
 	return r0, r1
 }
 
+// GetAllGuildIDs provides a mock function with given fields:
+func (_m *Database) GetAllGuildIDs() ([]string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllGuildIDs")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAllTwitchNotifies provides a mock function with given fields: twitchUserID
 func (_m *Database) GetAllTwitchNotifies(twitchUserID string) ([]twitchnotify.DBEntry, error) {
 	ret := _m.Called(twitchUserID)
@@ -728,23 +894,23 @@ func (_m *Database) GetAntiraidJoinList(guildID string) ([]models.JoinLogEntry,
 	return r0, r1
 }
 
-// GetAntiraidRegeneration provides a mock function with given fields: guildID
-func (_m *Database) GetAntiraidRegeneration(guildID string) (int, error) {
+// GetAntiraidLockdown provides a mock function with given fields: guildID
+func (_m *Database) GetAntiraidLockdown(guildID string) (models.AntiraidLockdown, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAntiraidRegeneration")
+		panic("no return value specified for GetAntiraidLockdown")
 	}
 
-	var r0 int
+	var r0 models.AntiraidLockdown
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (int, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (models.AntiraidLockdown, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) int); ok {
+	if rf, ok := ret.Get(0).(func(string) models.AntiraidLockdown); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(int)
+		r0 = ret.Get(0).(models.AntiraidLockdown)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -756,23 +922,23 @@ func (_m *Database) GetAntiraidRegeneration(guildID string) (int, error) {
 	return r0, r1
 }
 
-// GetAntiraidState provides a mock function with given fields: guildID
-func (_m *Database) GetAntiraidState(guildID string) (bool, error) {
+// GetAntiraidLockdownState provides a mock function with given fields: guildID
+func (_m *Database) GetAntiraidLockdownState(guildID string) (models.AntiraidLockdownState, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAntiraidState")
+		panic("no return value specified for GetAntiraidLockdownState")
 	}
 
-	var r0 bool
+	var r0 models.AntiraidLockdownState
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (models.AntiraidLockdownState, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
+	if rf, ok := ret.Get(0).(func(string) models.AntiraidLockdownState); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Get(0).(models.AntiraidLockdownState)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -784,27 +950,29 @@ func (_m *Database) GetAntiraidState(guildID string) (bool, error) {
 	return r0, r1
 }
 
-// GetAntiraidVerification provides a mock function with given fields: guildID
-func (_m *Database) GetAntiraidVerification(guildID string) (bool, error) {
-	ret := _m.Called(guildID)
+// GetAntiraidLockdownStates provides a mock function with given fields:
+func (_m *Database) GetAntiraidLockdownStates() ([]models.AntiraidLockdownState, error) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAntiraidVerification")
+		panic("no return value specified for GetAntiraidLockdownStates")
 	}
 
-	var r0 bool
+	var r0 []models.AntiraidLockdownState
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
-		return rf(guildID)
+	if rf, ok := ret.Get(0).(func() ([]models.AntiraidLockdownState, error)); ok {
+		return rf()
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = rf(guildID)
+	if rf, ok := ret.Get(0).(func() []models.AntiraidLockdownState); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(bool)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AntiraidLockdownState)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guildID)
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -812,25 +980,23 @@ func (_m *Database) GetAntiraidVerification(guildID string) (bool, error) {
 	return r0, r1
 }
 
-// GetBackups provides a mock function with given fields: guildID
-func (_m *Database) GetBackups(guildID string) ([]backupmodels.Entry, error) {
+// GetAntiraidRegeneration provides a mock function with given fields: guildID
+func (_m *Database) GetAntiraidRegeneration(guildID string) (int, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetBackups")
+		panic("no return value specified for GetAntiraidRegeneration")
 	}
 
-	var r0 []backupmodels.Entry
+	var r0 int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]backupmodels.Entry, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (int, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) []backupmodels.Entry); ok {
+	if rf, ok := ret.Get(0).(func(string) int); ok {
 		r0 = rf(guildID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]backupmodels.Entry)
-		}
+		r0 = ret.Get(0).(int)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -842,25 +1008,23 @@ func (_m *Database) GetBackups(guildID string) ([]backupmodels.Entry, error) {
 	return r0, r1
 }
 
-// GetBirthdays provides a mock function with given fields: guildID
-func (_m *Database) GetBirthdays(guildID string) ([]models.Birthday, error) {
+// GetAntiraidSignals provides a mock function with given fields: guildID
+func (_m *Database) GetAntiraidSignals(guildID string) (models.AntiraidSignals, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetBirthdays")
+		panic("no return value specified for GetAntiraidSignals")
 	}
 
-	var r0 []models.Birthday
+	var r0 models.AntiraidSignals
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]models.Birthday, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (models.AntiraidSignals, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) []models.Birthday); ok {
+	if rf, ok := ret.Get(0).(func(string) models.AntiraidSignals); ok {
 		r0 = rf(guildID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Birthday)
-		}
+		r0 = ret.Get(0).(models.AntiraidSignals)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -872,29 +1036,27 @@ func (_m *Database) GetBirthdays(guildID string) ([]models.Birthday, error) {
 	return r0, r1
 }
 
-// GetExpiredReports provides a mock function with given fields:
-func (_m *Database) GetExpiredReports() ([]models.Report, error) {
-	ret := _m.Called()
+// GetAntiraidState provides a mock function with given fields: guildID
+func (_m *Database) GetAntiraidState(guildID string) (bool, error) {
+	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetExpiredReports")
+		panic("no return value specified for GetAntiraidState")
 	}
 
-	var r0 []models.Report
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]models.Report, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func() []models.Report); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(guildID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Report)
-		}
+		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -902,23 +1064,23 @@ func (_m *Database) GetExpiredReports() ([]models.Report, error) {
 	return r0, r1
 }
 
-// GetGuildAPI provides a mock function with given fields: guildID
-func (_m *Database) GetGuildAPI(guildID string) (models.GuildAPISettings, error) {
+// GetAntiraidVerification provides a mock function with given fields: guildID
+func (_m *Database) GetAntiraidVerification(guildID string) (bool, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildAPI")
+		panic("no return value specified for GetAntiraidVerification")
 	}
 
-	var r0 models.GuildAPISettings
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (models.GuildAPISettings, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) models.GuildAPISettings); ok {
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(models.GuildAPISettings)
+		r0 = ret.Get(0).(bool)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -930,29 +1092,27 @@ func (_m *Database) GetGuildAPI(guildID string) (models.GuildAPISettings, error)
 	return r0, r1
 }
 
-// GetGuildAutoRole provides a mock function with given fields: guildID
-func (_m *Database) GetGuildAutoRole(guildID string) ([]string, error) {
-	ret := _m.Called(guildID)
+// GetBackupKey provides a mock function with given fields: keyID
+func (_m *Database) GetBackupKey(keyID string) (backupmodels.DataKey, error) {
+	ret := _m.Called(keyID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildAutoRole")
+		panic("no return value specified for GetBackupKey")
 	}
 
-	var r0 []string
+	var r0 backupmodels.DataKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
-		return rf(guildID)
+	if rf, ok := ret.Get(0).(func(string) (backupmodels.DataKey, error)); ok {
+		return rf(keyID)
 	}
-	if rf, ok := ret.Get(0).(func(string) []string); ok {
-		r0 = rf(guildID)
+	if rf, ok := ret.Get(0).(func(string) backupmodels.DataKey); ok {
+		r0 = rf(keyID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
-		}
+		r0 = ret.Get(0).(backupmodels.DataKey)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guildID)
+		r1 = rf(keyID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -960,24 +1120,24 @@ func (_m *Database) GetGuildAutoRole(guildID string) ([]string, error) {
 	return r0, r1
 }
 
-// GetGuildAutoVC provides a mock function with given fields: guildID
-func (_m *Database) GetGuildAutoVC(guildID string) ([]string, error) {
+// GetBackupKeys provides a mock function with given fields: guildID
+func (_m *Database) GetBackupKeys(guildID string) ([]backupmodels.DataKey, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildAutoVC")
+		panic("no return value specified for GetBackupKeys")
 	}
 
-	var r0 []string
+	var r0 []backupmodels.DataKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) ([]backupmodels.DataKey, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) []string); ok {
+	if rf, ok := ret.Get(0).(func(string) []backupmodels.DataKey); ok {
 		r0 = rf(guildID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
+			r0 = ret.Get(0).([]backupmodels.DataKey)
 		}
 	}
 
@@ -990,23 +1150,23 @@ func (_m *Database) GetGuildAutoVC(guildID string) ([]string, error) {
 	return r0, r1
 }
 
-// GetGuildBackup provides a mock function with given fields: guildID
-func (_m *Database) GetGuildBackup(guildID string) (bool, error) {
+// GetBackupRestoreCheckpoint provides a mock function with given fields: guildID
+func (_m *Database) GetBackupRestoreCheckpoint(guildID string) (backupmodels.RestoreCheckpoint, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildBackup")
+		panic("no return value specified for GetBackupRestoreCheckpoint")
 	}
 
-	var r0 bool
+	var r0 backupmodels.RestoreCheckpoint
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (backupmodels.RestoreCheckpoint, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
+	if rf, ok := ret.Get(0).(func(string) backupmodels.RestoreCheckpoint); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Get(0).(backupmodels.RestoreCheckpoint)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -1018,23 +1178,25 @@ func (_m *Database) GetGuildBackup(guildID string) (bool, error) {
 	return r0, r1
 }
 
-// GetGuildBirthdayChan provides a mock function with given fields: guildID
-func (_m *Database) GetGuildBirthdayChan(guildID string) (string, error) {
+// GetBackups provides a mock function with given fields: guildID
+func (_m *Database) GetBackups(guildID string) ([]backupmodels.Entry, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildBirthdayChan")
+		panic("no return value specified for GetBackups")
 	}
 
-	var r0 string
+	var r0 []backupmodels.Entry
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) ([]backupmodels.Entry, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(string) []backupmodels.Entry); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]backupmodels.Entry)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -1046,23 +1208,25 @@ func (_m *Database) GetGuildBirthdayChan(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildCodeExecEnabled provides a mock function with given fields: guildID
-func (_m *Database) GetGuildCodeExecEnabled(guildID string) (bool, error) {
+// GetBirthdays provides a mock function with given fields: guildID
+func (_m *Database) GetBirthdays(guildID string) ([]models.Birthday, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildCodeExecEnabled")
+		panic("no return value specified for GetBirthdays")
 	}
 
-	var r0 bool
+	var r0 []models.Birthday
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) ([]models.Birthday, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
+	if rf, ok := ret.Get(0).(func(string) []models.Birthday); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(bool)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Birthday)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -1074,27 +1238,29 @@ func (_m *Database) GetGuildCodeExecEnabled(guildID string) (bool, error) {
 	return r0, r1
 }
 
-// GetGuildColorReaction provides a mock function with given fields: guildID
-func (_m *Database) GetGuildColorReaction(guildID string) (bool, error) {
-	ret := _m.Called(guildID)
+// GetExpiredKarmaTempRoles provides a mock function with given fields: now
+func (_m *Database) GetExpiredKarmaTempRoles(now time.Time) ([]models.KarmaTempRole, error) {
+	ret := _m.Called(now)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildColorReaction")
+		panic("no return value specified for GetExpiredKarmaTempRoles")
 	}
 
-	var r0 bool
+	var r0 []models.KarmaTempRole
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
-		return rf(guildID)
+	if rf, ok := ret.Get(0).(func(time.Time) ([]models.KarmaTempRole, error)); ok {
+		return rf(now)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
-		r0 = rf(guildID)
+	if rf, ok := ret.Get(0).(func(time.Time) []models.KarmaTempRole); ok {
+		r0 = rf(now)
 	} else {
-		r0 = ret.Get(0).(bool)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.KarmaTempRole)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guildID)
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(now)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1102,27 +1268,29 @@ func (_m *Database) GetGuildColorReaction(guildID string) (bool, error) {
 	return r0, r1
 }
 
-// GetGuildGhostpingMsg provides a mock function with given fields: guildID
-func (_m *Database) GetGuildGhostpingMsg(guildID string) (string, error) {
-	ret := _m.Called(guildID)
+// GetExpiredReports provides a mock function with given fields:
+func (_m *Database) GetExpiredReports() ([]models.Report, error) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildGhostpingMsg")
+		panic("no return value specified for GetExpiredReports")
 	}
 
-	var r0 string
+	var r0 []models.Report
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(guildID)
+	if rf, ok := ret.Get(0).(func() ([]models.Report, error)); ok {
+		return rf()
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(guildID)
+	if rf, ok := ret.Get(0).(func() []models.Report); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Report)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guildID)
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1130,27 +1298,29 @@ func (_m *Database) GetGuildGhostpingMsg(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildInviteBlock provides a mock function with given fields: guildID
-func (_m *Database) GetGuildInviteBlock(guildID string) (string, error) {
-	ret := _m.Called(guildID)
+// GetExpiredVotes provides a mock function with given fields: before
+func (_m *Database) GetExpiredVotes(before time.Time) ([]vote.Vote, error) {
+	ret := _m.Called(before)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildInviteBlock")
+		panic("no return value specified for GetExpiredVotes")
 	}
 
-	var r0 string
+	var r0 []vote.Vote
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(guildID)
+	if rf, ok := ret.Get(0).(func(time.Time) ([]vote.Vote, error)); ok {
+		return rf(before)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(guildID)
+	if rf, ok := ret.Get(0).(func(time.Time) []vote.Vote); ok {
+		r0 = rf(before)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]vote.Vote)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guildID)
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1158,23 +1328,23 @@ func (_m *Database) GetGuildInviteBlock(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildJdoodleKey provides a mock function with given fields: guildID
-func (_m *Database) GetGuildJdoodleKey(guildID string) (string, error) {
+// GetGuildAPI provides a mock function with given fields: guildID
+func (_m *Database) GetGuildAPI(guildID string) (models.GuildAPISettings, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildJdoodleKey")
+		panic("no return value specified for GetGuildAPI")
 	}
 
-	var r0 string
+	var r0 models.GuildAPISettings
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (models.GuildAPISettings, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(string) models.GuildAPISettings); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(models.GuildAPISettings)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -1186,93 +1356,85 @@ func (_m *Database) GetGuildJdoodleKey(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildJoinMsg provides a mock function with given fields: guildID
-func (_m *Database) GetGuildJoinMsg(guildID string) (string, string, error) {
+// GetGuildAutoRole provides a mock function with given fields: guildID
+func (_m *Database) GetGuildAutoRole(guildID string) ([]string, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildJoinMsg")
+		panic("no return value specified for GetGuildAutoRole")
 	}
 
-	var r0 string
-	var r1 string
-	var r2 error
-	if rf, ok := ret.Get(0).(func(string) (string, string, error)); ok {
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) string); ok {
+	if rf, ok := ret.Get(1).(func(string) error); ok {
 		r1 = rf(guildID)
 	} else {
-		r1 = ret.Get(1).(string)
-	}
-
-	if rf, ok := ret.Get(2).(func(string) error); ok {
-		r2 = rf(guildID)
-	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// GetGuildLeaveMsg provides a mock function with given fields: guildID
-func (_m *Database) GetGuildLeaveMsg(guildID string) (string, string, error) {
+// GetGuildAutoVC provides a mock function with given fields: guildID
+func (_m *Database) GetGuildAutoVC(guildID string) ([]string, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildLeaveMsg")
+		panic("no return value specified for GetGuildAutoVC")
 	}
 
-	var r0 string
-	var r1 string
-	var r2 error
-	if rf, ok := ret.Get(0).(func(string) (string, string, error)); ok {
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) string); ok {
+	if rf, ok := ret.Get(1).(func(string) error); ok {
 		r1 = rf(guildID)
 	} else {
-		r1 = ret.Get(1).(string)
-	}
-
-	if rf, ok := ret.Get(2).(func(string) error); ok {
-		r2 = rf(guildID)
-	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// GetGuildLogDisable provides a mock function with given fields: guildID
-func (_m *Database) GetGuildLogDisable(guildID string) (bool, error) {
+// GetGuildAutomodRules provides a mock function with given fields: guildID
+func (_m *Database) GetGuildAutomodRules(guildID string) ([]models.AutomodRule, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildLogDisable")
+		panic("no return value specified for GetGuildAutomodRules")
 	}
 
-	var r0 bool
+	var r0 []models.AutomodRule
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) ([]models.AutomodRule, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
+	if rf, ok := ret.Get(0).(func(string) []models.AutomodRule); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(bool)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AutomodRule)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -1284,29 +1446,27 @@ func (_m *Database) GetGuildLogDisable(guildID string) (bool, error) {
 	return r0, r1
 }
 
-// GetGuildLogEntries provides a mock function with given fields: guildID, offset, limit, severity, ascending
-func (_m *Database) GetGuildLogEntries(guildID string, offset int, limit int, severity models.GuildLogSeverity, ascending bool) ([]models.GuildLogEntry, error) {
-	ret := _m.Called(guildID, offset, limit, severity, ascending)
+// GetGuildBackup provides a mock function with given fields: guildID
+func (_m *Database) GetGuildBackup(guildID string) (bool, error) {
+	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildLogEntries")
+		panic("no return value specified for GetGuildBackup")
 	}
 
-	var r0 []models.GuildLogEntry
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, int, int, models.GuildLogSeverity, bool) ([]models.GuildLogEntry, error)); ok {
-		return rf(guildID, offset, limit, severity, ascending)
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string, int, int, models.GuildLogSeverity, bool) []models.GuildLogEntry); ok {
-		r0 = rf(guildID, offset, limit, severity, ascending)
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(guildID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.GuildLogEntry)
-		}
+		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, int, int, models.GuildLogSeverity, bool) error); ok {
-		r1 = rf(guildID, offset, limit, severity, ascending)
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1314,27 +1474,27 @@ func (_m *Database) GetGuildLogEntries(guildID string, offset int, limit int, se
 	return r0, r1
 }
 
-// GetGuildLogEntriesCount provides a mock function with given fields: guildID, severity
-func (_m *Database) GetGuildLogEntriesCount(guildID string, severity models.GuildLogSeverity) (int, error) {
-	ret := _m.Called(guildID, severity)
+// GetGuildBackupRetention provides a mock function with given fields: guildID
+func (_m *Database) GetGuildBackupRetention(guildID string) (backupmodels.Retention, error) {
+	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildLogEntriesCount")
+		panic("no return value specified for GetGuildBackupRetention")
 	}
 
-	var r0 int
+	var r0 backupmodels.Retention
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, models.GuildLogSeverity) (int, error)); ok {
-		return rf(guildID, severity)
+	if rf, ok := ret.Get(0).(func(string) (backupmodels.Retention, error)); ok {
+		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string, models.GuildLogSeverity) int); ok {
-		r0 = rf(guildID, severity)
+	if rf, ok := ret.Get(0).(func(string) backupmodels.Retention); ok {
+		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(int)
+		r0 = ret.Get(0).(backupmodels.Retention)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, models.GuildLogSeverity) error); ok {
-		r1 = rf(guildID, severity)
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1342,12 +1502,12 @@ func (_m *Database) GetGuildLogEntriesCount(guildID string, severity models.Guil
 	return r0, r1
 }
 
-// GetGuildModLog provides a mock function with given fields: guildID
-func (_m *Database) GetGuildModLog(guildID string) (string, error) {
+// GetGuildBirthdayChan provides a mock function with given fields: guildID
+func (_m *Database) GetGuildBirthdayChan(guildID string) (string, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildModLog")
+		panic("no return value specified for GetGuildBirthdayChan")
 	}
 
 	var r0 string
@@ -1370,12 +1530,12 @@ func (_m *Database) GetGuildModLog(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildModNot provides a mock function with given fields: guildID
-func (_m *Database) GetGuildModNot(guildID string) (string, error) {
+// GetGuildBirthdayRole provides a mock function with given fields: guildID
+func (_m *Database) GetGuildBirthdayRole(guildID string) (string, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildModNot")
+		panic("no return value specified for GetGuildBirthdayRole")
 	}
 
 	var r0 string
@@ -1398,12 +1558,12 @@ func (_m *Database) GetGuildModNot(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildNotifyRole provides a mock function with given fields: guildID
-func (_m *Database) GetGuildNotifyRole(guildID string) (string, error) {
+// GetGuildBirthdayTemplate provides a mock function with given fields: guildID
+func (_m *Database) GetGuildBirthdayTemplate(guildID string) (string, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildNotifyRole")
+		panic("no return value specified for GetGuildBirthdayTemplate")
 	}
 
 	var r0 string
@@ -1426,25 +1586,23 @@ func (_m *Database) GetGuildNotifyRole(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildPermissions provides a mock function with given fields: guildID
-func (_m *Database) GetGuildPermissions(guildID string) (map[string]permissions.PermissionArray, error) {
+// GetGuildCodeExecEnabled provides a mock function with given fields: guildID
+func (_m *Database) GetGuildCodeExecEnabled(guildID string) (bool, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildPermissions")
+		panic("no return value specified for GetGuildCodeExecEnabled")
 	}
 
-	var r0 map[string]permissions.PermissionArray
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (map[string]permissions.PermissionArray, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) map[string]permissions.PermissionArray); ok {
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
 		r0 = rf(guildID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[string]permissions.PermissionArray)
-		}
+		r0 = ret.Get(0).(bool)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -1456,15 +1614,659 @@ func (_m *Database) GetGuildPermissions(guildID string) (map[string]permissions.
 	return r0, r1
 }
 
-// GetGuildPrefix provides a mock function with given fields: guildID
-func (_m *Database) GetGuildPrefix(guildID string) (string, error) {
+// GetGuildColorReaction provides a mock function with given fields: guildID
+func (_m *Database) GetGuildColorReaction(guildID string) (bool, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildPrefix")
+		panic("no return value specified for GetGuildColorReaction")
 	}
 
-	var r0 string
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildEscalationLadder provides a mock function with given fields: guildID
+func (_m *Database) GetGuildEscalationLadder(guildID string) (models.EscalationLadder, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildEscalationLadder")
+	}
+
+	var r0 models.EscalationLadder
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.EscalationLadder, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.EscalationLadder); ok {
+		r0 = rf(guildID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(models.EscalationLadder)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildGhostpingMsg provides a mock function with given fields: guildID
+func (_m *Database) GetGuildGhostpingMsg(guildID string) (string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildGhostpingMsg")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildInviteBlock provides a mock function with given fields: guildID
+func (_m *Database) GetGuildInviteBlock(guildID string) (string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildInviteBlock")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildInviteBlockSettings provides a mock function with given fields: guildID
+func (_m *Database) GetGuildInviteBlockSettings(guildID string) (models.InviteBlockSettings, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildInviteBlockSettings")
+	}
+
+	var r0 models.InviteBlockSettings
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.InviteBlockSettings, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.InviteBlockSettings); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(models.InviteBlockSettings)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildJdoodleKey provides a mock function with given fields: guildID
+func (_m *Database) GetGuildJdoodleKey(guildID string) (string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildJdoodleKey")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildJoinMsg provides a mock function with given fields: guildID
+func (_m *Database) GetGuildJoinMsg(guildID string) (string, string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildJoinMsg")
+	}
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string) (string, string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) string); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(guildID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetGuildLeaveMsg provides a mock function with given fields: guildID
+func (_m *Database) GetGuildLeaveMsg(guildID string) (string, string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildLeaveMsg")
+	}
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string) (string, string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) string); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(guildID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetGuildLogDisable provides a mock function with given fields: guildID
+func (_m *Database) GetGuildLogDisable(guildID string) (bool, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildLogDisable")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildLogEntries provides a mock function with given fields: guildID, offset, limit, severity, ascending
+func (_m *Database) GetGuildLogEntries(guildID string, offset int, limit int, severity models.GuildLogSeverity, ascending bool) ([]models.GuildLogEntry, error) {
+	ret := _m.Called(guildID, offset, limit, severity, ascending)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildLogEntries")
+	}
+
+	var r0 []models.GuildLogEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int, int, models.GuildLogSeverity, bool) ([]models.GuildLogEntry, error)); ok {
+		return rf(guildID, offset, limit, severity, ascending)
+	}
+	if rf, ok := ret.Get(0).(func(string, int, int, models.GuildLogSeverity, bool) []models.GuildLogEntry); ok {
+		r0 = rf(guildID, offset, limit, severity, ascending)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.GuildLogEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int, int, models.GuildLogSeverity, bool) error); ok {
+		r1 = rf(guildID, offset, limit, severity, ascending)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildLogEntriesCount provides a mock function with given fields: guildID, severity
+func (_m *Database) GetGuildLogEntriesCount(guildID string, severity models.GuildLogSeverity) (int, error) {
+	ret := _m.Called(guildID, severity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildLogEntriesCount")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, models.GuildLogSeverity) (int, error)); ok {
+		return rf(guildID, severity)
+	}
+	if rf, ok := ret.Get(0).(func(string, models.GuildLogSeverity) int); ok {
+		r0 = rf(guildID, severity)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, models.GuildLogSeverity) error); ok {
+		r1 = rf(guildID, severity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildModLog provides a mock function with given fields: guildID
+func (_m *Database) GetGuildModLog(guildID string) (string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildModLog")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildModNot provides a mock function with given fields: guildID
+func (_m *Database) GetGuildModNot(guildID string) (string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildModNot")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildNotifyRole provides a mock function with given fields: guildID
+func (_m *Database) GetGuildNotifyRole(guildID string) (string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildNotifyRole")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildPermissions provides a mock function with given fields: guildID
+func (_m *Database) GetGuildPermissions(guildID string) (map[string]permissions.PermissionArray, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildPermissions")
+	}
+
+	var r0 map[string]permissions.PermissionArray
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (map[string]permissions.PermissionArray, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) map[string]permissions.PermissionArray); ok {
+		r0 = rf(guildID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]permissions.PermissionArray)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildPrefix provides a mock function with given fields: guildID
+func (_m *Database) GetGuildPrefix(guildID string) (string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildPrefix")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildRoleSelects provides a mock function with given fields: guildID
+func (_m *Database) GetGuildRoleSelects(guildID string) ([]models.RoleSelect, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildRoleSelects")
+	}
+
+	var r0 []models.RoleSelect
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.RoleSelect, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.RoleSelect); ok {
+		r0 = rf(guildID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.RoleSelect)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildTags provides a mock function with given fields: guildID
+func (_m *Database) GetGuildTags(guildID string) ([]tag.Tag, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildTags")
+	}
+
+	var r0 []tag.Tag
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]tag.Tag, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []tag.Tag); ok {
+		r0 = rf(guildID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]tag.Tag)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildUnbanRequests provides a mock function with given fields: guildID, limit, offset
+func (_m *Database) GetGuildUnbanRequests(guildID string, limit int, offset int) ([]models.UnbanRequest, error) {
+	ret := _m.Called(guildID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildUnbanRequests")
+	}
+
+	var r0 []models.UnbanRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int, int) ([]models.UnbanRequest, error)); ok {
+		return rf(guildID, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(string, int, int) []models.UnbanRequest); ok {
+		r0 = rf(guildID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UnbanRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
+		r1 = rf(guildID, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildUnbanRequestsCount provides a mock function with given fields: guildID, state
+func (_m *Database) GetGuildUnbanRequestsCount(guildID string, state *models.UnbanRequestState) (int, error) {
+	ret := _m.Called(guildID, state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildUnbanRequestsCount")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, *models.UnbanRequestState) (int, error)); ok {
+		return rf(guildID, state)
+	}
+	if rf, ok := ret.Get(0).(func(string, *models.UnbanRequestState) int); ok {
+		r0 = rf(guildID, state)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, *models.UnbanRequestState) error); ok {
+		r1 = rf(guildID, state)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildUserUnbanRequests provides a mock function with given fields: userID, guildID
+func (_m *Database) GetGuildUserUnbanRequests(userID string, guildID string) ([]models.UnbanRequest, error) {
+	ret := _m.Called(userID, guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildUserUnbanRequests")
+	}
+
+	var r0 []models.UnbanRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]models.UnbanRequest, error)); ok {
+		return rf(userID, guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []models.UnbanRequest); ok {
+		r0 = rf(userID, guildID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UnbanRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userID, guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildVerificationRequired provides a mock function with given fields: guildID
+func (_m *Database) GetGuildVerificationRequired(guildID string) (bool, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildVerificationRequired")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGuildVoiceLog provides a mock function with given fields: guildID
+func (_m *Database) GetGuildVoiceLog(guildID string) (string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGuildVoiceLog")
+	}
+
+	var r0 string
 	var r1 error
 	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
 		return rf(guildID)
@@ -1484,24 +2286,24 @@ func (_m *Database) GetGuildPrefix(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildTags provides a mock function with given fields: guildID
-func (_m *Database) GetGuildTags(guildID string) ([]tag.Tag, error) {
+// GetGuildVoiceLogIgnores provides a mock function with given fields: guildID
+func (_m *Database) GetGuildVoiceLogIgnores(guildID string) ([]string, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildTags")
+		panic("no return value specified for GetGuildVoiceLogIgnores")
 	}
 
-	var r0 []tag.Tag
+	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]tag.Tag, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) []tag.Tag); ok {
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
 		r0 = rf(guildID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]tag.Tag)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
@@ -1514,29 +2316,29 @@ func (_m *Database) GetGuildTags(guildID string) ([]tag.Tag, error) {
 	return r0, r1
 }
 
-// GetGuildUnbanRequests provides a mock function with given fields: guildID, limit, offset
-func (_m *Database) GetGuildUnbanRequests(guildID string, limit int, offset int) ([]models.UnbanRequest, error) {
-	ret := _m.Called(guildID, limit, offset)
+// GetGuilds provides a mock function with given fields:
+func (_m *Database) GetGuilds() ([]string, error) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildUnbanRequests")
+		panic("no return value specified for GetGuilds")
 	}
 
-	var r0 []models.UnbanRequest
+	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, int, int) ([]models.UnbanRequest, error)); ok {
-		return rf(guildID, limit, offset)
+	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
+		return rf()
 	}
-	if rf, ok := ret.Get(0).(func(string, int, int) []models.UnbanRequest); ok {
-		r0 = rf(guildID, limit, offset)
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.UnbanRequest)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
-		r1 = rf(guildID, limit, offset)
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1544,27 +2346,27 @@ func (_m *Database) GetGuildUnbanRequests(guildID string, limit int, offset int)
 	return r0, r1
 }
 
-// GetGuildUnbanRequestsCount provides a mock function with given fields: guildID, state
-func (_m *Database) GetGuildUnbanRequestsCount(guildID string, state *models.UnbanRequestState) (int, error) {
-	ret := _m.Called(guildID, state)
+// GetKarma provides a mock function with given fields: userID, guildID
+func (_m *Database) GetKarma(userID string, guildID string) (int, error) {
+	ret := _m.Called(userID, guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildUnbanRequestsCount")
+		panic("no return value specified for GetKarma")
 	}
 
 	var r0 int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, *models.UnbanRequestState) (int, error)); ok {
-		return rf(guildID, state)
+	if rf, ok := ret.Get(0).(func(string, string) (int, error)); ok {
+		return rf(userID, guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string, *models.UnbanRequestState) int); ok {
-		r0 = rf(guildID, state)
+	if rf, ok := ret.Get(0).(func(string, string) int); ok {
+		r0 = rf(userID, guildID)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, *models.UnbanRequestState) error); ok {
-		r1 = rf(guildID, state)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(userID, guildID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1572,29 +2374,117 @@ func (_m *Database) GetGuildUnbanRequestsCount(guildID string, state *models.Unb
 	return r0, r1
 }
 
-// GetGuildUserUnbanRequests provides a mock function with given fields: userID, guildID
-func (_m *Database) GetGuildUserUnbanRequests(userID string, guildID string) ([]models.UnbanRequest, error) {
-	ret := _m.Called(userID, guildID)
+// GetKarmaBlockList provides a mock function with given fields: guildID
+func (_m *Database) GetKarmaBlockList(guildID string) ([]string, error) {
+	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildUserUnbanRequests")
+		panic("no return value specified for GetKarmaBlockList")
 	}
 
-	var r0 []models.UnbanRequest
+	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) ([]models.UnbanRequest, error)); ok {
-		return rf(userID, guildID)
+	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
+		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) []models.UnbanRequest); ok {
-		r0 = rf(userID, guildID)
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(guildID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.UnbanRequest)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(userID, guildID)
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetKarmaDecay provides a mock function with given fields: guildID
+func (_m *Database) GetKarmaDecay(guildID string) (models.KarmaDecay, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKarmaDecay")
+	}
+
+	var r0 models.KarmaDecay
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.KarmaDecay, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.KarmaDecay); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(models.KarmaDecay)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetKarmaDecayCandidates provides a mock function with given fields: guildID, inactiveSince
+func (_m *Database) GetKarmaDecayCandidates(guildID string, inactiveSince time.Time) ([]models.GuildKarma, error) {
+	ret := _m.Called(guildID, inactiveSince)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKarmaDecayCandidates")
+	}
+
+	var r0 []models.GuildKarma
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) ([]models.GuildKarma, error)); ok {
+		return rf(guildID, inactiveSince)
+	}
+	if rf, ok := ret.Get(0).(func(string, time.Time) []models.GuildKarma); ok {
+		r0 = rf(guildID, inactiveSince)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.GuildKarma)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(guildID, inactiveSince)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetKarmaDecayGuilds provides a mock function with given fields:
+func (_m *Database) GetKarmaDecayGuilds() ([]string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKarmaDecayGuilds")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1602,23 +2492,58 @@ func (_m *Database) GetGuildUserUnbanRequests(userID string, guildID string) ([]
 	return r0, r1
 }
 
-// GetGuildVerificationRequired provides a mock function with given fields: guildID
-func (_m *Database) GetGuildVerificationRequired(guildID string) (bool, error) {
+// GetKarmaEmotes provides a mock function with given fields: guildID
+func (_m *Database) GetKarmaEmotes(guildID string) (string, string, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKarmaEmotes")
+	}
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string) (string, string, error)); ok {
+		return rf(guildID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(guildID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) string); ok {
+		r1 = rf(guildID)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(guildID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetKarmaFarmingLimits provides a mock function with given fields: guildID
+func (_m *Database) GetKarmaFarmingLimits(guildID string) (models.KarmaFarmingLimits, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildVerificationRequired")
+		panic("no return value specified for GetKarmaFarmingLimits")
 	}
 
-	var r0 bool
+	var r0 models.KarmaFarmingLimits
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (models.KarmaFarmingLimits, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
+	if rf, ok := ret.Get(0).(func(string) models.KarmaFarmingLimits); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Get(0).(models.KarmaFarmingLimits)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -1630,27 +2555,29 @@ func (_m *Database) GetGuildVerificationRequired(guildID string) (bool, error) {
 	return r0, r1
 }
 
-// GetGuildVoiceLog provides a mock function with given fields: guildID
-func (_m *Database) GetGuildVoiceLog(guildID string) (string, error) {
-	ret := _m.Called(guildID)
+// GetKarmaGuild provides a mock function with given fields: guildID, limit
+func (_m *Database) GetKarmaGuild(guildID string, limit int) ([]models.GuildKarma, error) {
+	ret := _m.Called(guildID, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildVoiceLog")
+		panic("no return value specified for GetKarmaGuild")
 	}
 
-	var r0 string
+	var r0 []models.GuildKarma
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(guildID)
+	if rf, ok := ret.Get(0).(func(string, int) ([]models.GuildKarma, error)); ok {
+		return rf(guildID, limit)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(guildID)
+	if rf, ok := ret.Get(0).(func(string, int) []models.GuildKarma); ok {
+		r0 = rf(guildID, limit)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.GuildKarma)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guildID)
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(guildID, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1658,25 +2585,23 @@ func (_m *Database) GetGuildVoiceLog(guildID string) (string, error) {
 	return r0, r1
 }
 
-// GetGuildVoiceLogIgnores provides a mock function with given fields: guildID
-func (_m *Database) GetGuildVoiceLogIgnores(guildID string) ([]string, error) {
+// GetKarmaGuildCount provides a mock function with given fields: guildID
+func (_m *Database) GetKarmaGuildCount(guildID string) (int, error) {
 	ret := _m.Called(guildID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuildVoiceLogIgnores")
+		panic("no return value specified for GetKarmaGuildCount")
 	}
 
-	var r0 []string
+	var r0 int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (int, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) []string); ok {
+	if rf, ok := ret.Get(0).(func(string) int); ok {
 		r0 = rf(guildID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
-		}
+		r0 = ret.Get(0).(int)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -1688,29 +2613,29 @@ func (_m *Database) GetGuildVoiceLogIgnores(guildID string) ([]string, error) {
 	return r0, r1
 }
 
-// GetGuilds provides a mock function with given fields:
-func (_m *Database) GetGuilds() ([]string, error) {
-	ret := _m.Called()
+// GetKarmaLeaderboard provides a mock function with given fields: guildID, since, limit
+func (_m *Database) GetKarmaLeaderboard(guildID string, since time.Time, limit int) ([]models.GuildKarma, error) {
+	ret := _m.Called(guildID, since, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetGuilds")
+		panic("no return value specified for GetKarmaLeaderboard")
 	}
 
-	var r0 []string
+	var r0 []models.GuildKarma
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(string, time.Time, int) ([]models.GuildKarma, error)); ok {
+		return rf(guildID, since, limit)
 	}
-	if rf, ok := ret.Get(0).(func() []string); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string, time.Time, int) []models.GuildKarma); ok {
+		r0 = rf(guildID, since, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
+			r0 = ret.Get(0).([]models.GuildKarma)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(string, time.Time, int) error); ok {
+		r1 = rf(guildID, since, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1718,27 +2643,29 @@ func (_m *Database) GetGuilds() ([]string, error) {
 	return r0, r1
 }
 
-// GetKarma provides a mock function with given fields: userID, guildID
-func (_m *Database) GetKarma(userID string, guildID string) (int, error) {
-	ret := _m.Called(userID, guildID)
+// GetKarmaLedger provides a mock function with given fields: guildID, userID, offset, limit
+func (_m *Database) GetKarmaLedger(guildID string, userID string, offset int, limit int) ([]models.KarmaLedgerEntry, error) {
+	ret := _m.Called(guildID, userID, offset, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetKarma")
+		panic("no return value specified for GetKarmaLedger")
 	}
 
-	var r0 int
+	var r0 []models.KarmaLedgerEntry
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (int, error)); ok {
-		return rf(userID, guildID)
+	if rf, ok := ret.Get(0).(func(string, string, int, int) ([]models.KarmaLedgerEntry, error)); ok {
+		return rf(guildID, userID, offset, limit)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) int); ok {
-		r0 = rf(userID, guildID)
+	if rf, ok := ret.Get(0).(func(string, string, int, int) []models.KarmaLedgerEntry); ok {
+		r0 = rf(guildID, userID, offset, limit)
 	} else {
-		r0 = ret.Get(0).(int)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.KarmaLedgerEntry)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(userID, guildID)
+	if rf, ok := ret.Get(1).(func(string, string, int, int) error); ok {
+		r1 = rf(guildID, userID, offset, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1746,29 +2673,29 @@ func (_m *Database) GetKarma(userID string, guildID string) (int, error) {
 	return r0, r1
 }
 
-// GetKarmaBlockList provides a mock function with given fields: guildID
-func (_m *Database) GetKarmaBlockList(guildID string) ([]string, error) {
-	ret := _m.Called(guildID)
+// GetKarmaLedgerGiven provides a mock function with given fields: guildID, executorID, from, to
+func (_m *Database) GetKarmaLedgerGiven(guildID string, executorID string, from time.Time, to time.Time) ([]models.KarmaLedgerEntry, error) {
+	ret := _m.Called(guildID, executorID, from, to)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetKarmaBlockList")
+		panic("no return value specified for GetKarmaLedgerGiven")
 	}
 
-	var r0 []string
+	var r0 []models.KarmaLedgerEntry
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
-		return rf(guildID)
+	if rf, ok := ret.Get(0).(func(string, string, time.Time, time.Time) ([]models.KarmaLedgerEntry, error)); ok {
+		return rf(guildID, executorID, from, to)
 	}
-	if rf, ok := ret.Get(0).(func(string) []string); ok {
-		r0 = rf(guildID)
+	if rf, ok := ret.Get(0).(func(string, string, time.Time, time.Time) []models.KarmaLedgerEntry); ok {
+		r0 = rf(guildID, executorID, from, to)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
+			r0 = ret.Get(0).([]models.KarmaLedgerEntry)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(guildID)
+	if rf, ok := ret.Get(1).(func(string, string, time.Time, time.Time) error); ok {
+		r1 = rf(guildID, executorID, from, to)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1776,64 +2703,27 @@ func (_m *Database) GetKarmaBlockList(guildID string) ([]string, error) {
 	return r0, r1
 }
 
-// GetKarmaEmotes provides a mock function with given fields: guildID
-func (_m *Database) GetKarmaEmotes(guildID string) (string, string, error) {
-	ret := _m.Called(guildID)
-
-	if len(ret) == 0 {
-		panic("no return value specified for GetKarmaEmotes")
-	}
-
-	var r0 string
-	var r1 string
-	var r2 error
-	if rf, ok := ret.Get(0).(func(string) (string, string, error)); ok {
-		return rf(guildID)
-	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(guildID)
-	} else {
-		r0 = ret.Get(0).(string)
-	}
-
-	if rf, ok := ret.Get(1).(func(string) string); ok {
-		r1 = rf(guildID)
-	} else {
-		r1 = ret.Get(1).(string)
-	}
-
-	if rf, ok := ret.Get(2).(func(string) error); ok {
-		r2 = rf(guildID)
-	} else {
-		r2 = ret.Error(2)
-	}
-
-	return r0, r1, r2
-}
-
-// GetKarmaGuild provides a mock function with given fields: guildID, limit
-func (_m *Database) GetKarmaGuild(guildID string, limit int) ([]models.GuildKarma, error) {
-	ret := _m.Called(guildID, limit)
+// GetKarmaLedgerPairCount provides a mock function with given fields: guildID, executorID, targetID, since
+func (_m *Database) GetKarmaLedgerPairCount(guildID string, executorID string, targetID string, since time.Time) (int, error) {
+	ret := _m.Called(guildID, executorID, targetID, since)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetKarmaGuild")
+		panic("no return value specified for GetKarmaLedgerPairCount")
 	}
 
-	var r0 []models.GuildKarma
+	var r0 int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, int) ([]models.GuildKarma, error)); ok {
-		return rf(guildID, limit)
+	if rf, ok := ret.Get(0).(func(string, string, string, time.Time) (int, error)); ok {
+		return rf(guildID, executorID, targetID, since)
 	}
-	if rf, ok := ret.Get(0).(func(string, int) []models.GuildKarma); ok {
-		r0 = rf(guildID, limit)
+	if rf, ok := ret.Get(0).(func(string, string, string, time.Time) int); ok {
+		r0 = rf(guildID, executorID, targetID, since)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.GuildKarma)
-		}
+		r0 = ret.Get(0).(int)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, int) error); ok {
-		r1 = rf(guildID, limit)
+	if rf, ok := ret.Get(1).(func(string, string, string, time.Time) error); ok {
+		r1 = rf(guildID, executorID, targetID, since)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -2083,6 +2973,36 @@ func (_m *Database) GetReport(id snowflake.ID) (models.Report, error) {
 	return r0, r1
 }
 
+// GetReportRevisions provides a mock function with given fields: reportID
+func (_m *Database) GetReportRevisions(reportID snowflake.ID) ([]models.ReportRevision, error) {
+	ret := _m.Called(reportID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReportRevisions")
+	}
+
+	var r0 []models.ReportRevision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(snowflake.ID) ([]models.ReportRevision, error)); ok {
+		return rf(reportID)
+	}
+	if rf, ok := ret.Get(0).(func(snowflake.ID) []models.ReportRevision); ok {
+		r0 = rf(reportID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ReportRevision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(snowflake.ID) error); ok {
+		r1 = rf(reportID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetReportsFiltered provides a mock function with given fields: guildID, memberID, repType, offset, limit
 func (_m *Database) GetReportsFiltered(guildID string, memberID string, repType models.ReportType, offset int, limit int) ([]models.Report, error) {
 	ret := _m.Called(guildID, memberID, repType, offset, limit)
@@ -2257,9 +3177,9 @@ func (_m *Database) GetSetting(setting string) (string, error) {
 	return r0, r1
 }
 
-// GetStarboardConfig provides a mock function with given fields: guildID
-func (_m *Database) GetStarboardConfig(guildID string) (models.StarboardConfig, error) {
-	ret := _m.Called(guildID)
+// GetStarboardConfig provides a mock function with given fields: guildID, name
+func (_m *Database) GetStarboardConfig(guildID string, name string) (models.StarboardConfig, error) {
+	ret := _m.Called(guildID, name)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetStarboardConfig")
@@ -2267,13 +3187,43 @@ func (_m *Database) GetStarboardConfig(guildID string) (models.StarboardConfig,
 
 	var r0 models.StarboardConfig
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (models.StarboardConfig, error)); ok {
+	if rf, ok := ret.Get(0).(func(string, string) (models.StarboardConfig, error)); ok {
+		return rf(guildID, name)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.StarboardConfig); ok {
+		r0 = rf(guildID, name)
+	} else {
+		r0 = ret.Get(0).(models.StarboardConfig)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(guildID, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStarboardConfigs provides a mock function with given fields: guildID
+func (_m *Database) GetStarboardConfigs(guildID string) ([]models.StarboardConfig, error) {
+	ret := _m.Called(guildID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStarboardConfigs")
+	}
+
+	var r0 []models.StarboardConfig
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.StarboardConfig, error)); ok {
 		return rf(guildID)
 	}
-	if rf, ok := ret.Get(0).(func(string) models.StarboardConfig); ok {
+	if rf, ok := ret.Get(0).(func(string) []models.StarboardConfig); ok {
 		r0 = rf(guildID)
 	} else {
-		r0 = ret.Get(0).(models.StarboardConfig)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.StarboardConfig)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
@@ -2343,9 +3293,9 @@ func (_m *Database) GetStarboardEntriesCount(guildID string) (int, error) {
 	return r0, r1
 }
 
-// GetStarboardEntry provides a mock function with given fields: messageID
-func (_m *Database) GetStarboardEntry(messageID string) (models.StarboardEntry, error) {
-	ret := _m.Called(messageID)
+// GetStarboardEntry provides a mock function with given fields: messageID, board
+func (_m *Database) GetStarboardEntry(messageID string, board string) (models.StarboardEntry, error) {
+	ret := _m.Called(messageID, board)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetStarboardEntry")
@@ -2353,17 +3303,17 @@ func (_m *Database) GetStarboardEntry(messageID string) (models.StarboardEntry,
 
 	var r0 models.StarboardEntry
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (models.StarboardEntry, error)); ok {
-		return rf(messageID)
+	if rf, ok := ret.Get(0).(func(string, string) (models.StarboardEntry, error)); ok {
+		return rf(messageID, board)
 	}
-	if rf, ok := ret.Get(0).(func(string) models.StarboardEntry); ok {
-		r0 = rf(messageID)
+	if rf, ok := ret.Get(0).(func(string, string) models.StarboardEntry); ok {
+		r0 = rf(messageID, board)
 	} else {
 		r0 = ret.Get(0).(models.StarboardEntry)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(messageID)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(messageID, board)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -2574,6 +3524,34 @@ func (_m *Database) GetUserStarboardOptout(userID string) (bool, error) {
 	return r0, r1
 }
 
+// GetUserTimezone provides a mock function with given fields: userID
+func (_m *Database) GetUserTimezone(userID string) (string, error) {
+	ret := _m.Called(userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserTimezone")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserVerified provides a mock function with given fields: userID
 func (_m *Database) GetUserVerified(userID string) (bool, error) {
 	ret := _m.Called(userID)
@@ -2632,6 +3610,34 @@ func (_m *Database) GetVerificationQueue(guildID string, userID string) ([]model
 	return r0, r1
 }
 
+// GetVote provides a mock function with given fields: id
+func (_m *Database) GetVote(id string) (vote.Vote, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVote")
+	}
+
+	var r0 vote.Vote
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (vote.Vote, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) vote.Vote); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(vote.Vote)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetVotes provides a mock function with given fields:
 func (_m *Database) GetVotes() (map[string]vote.Vote, error) {
 	ret := _m.Called()
@@ -2662,6 +3668,24 @@ func (_m *Database) GetVotes() (map[string]vote.Vote, error) {
 	return r0, r1
 }
 
+// IncrementTagUses provides a mock function with given fields: id
+func (_m *Database) IncrementTagUses(id snowflake.ID) error {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementTagUses")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(snowflake.ID) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // IsGuildVoiceLogIgnored provides a mock function with given fields: guildID, channelID
 func (_m *Database) IsGuildVoiceLogIgnored(guildID string, channelID string) (bool, error) {
 	ret := _m.Called(guildID, channelID)
@@ -2781,8 +3805,26 @@ func (_m *Database) RemoveKarmaRule(guildID string, id snowflake.ID) error {
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, snowflake.ID) error); ok {
-		r0 = rf(guildID, id)
+	if rf, ok := ret.Get(0).(func(string, snowflake.ID) error); ok {
+		r0 = rf(guildID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveKarmaTempRole provides a mock function with given fields: guildID, userID, roleID
+func (_m *Database) RemoveKarmaTempRole(guildID string, userID string, roleID string) error {
+	ret := _m.Called(guildID, userID, roleID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveKarmaTempRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(guildID, userID, roleID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -2808,6 +3850,24 @@ func (_m *Database) RemoveRoleSelect(guildID string, channelID string, messageID
 	return r0
 }
 
+// RemoveStarboardConfig provides a mock function with given fields: guildID, name
+func (_m *Database) RemoveStarboardConfig(guildID string, name string) error {
+	ret := _m.Called(guildID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveStarboardConfig")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(guildID, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemoveStarboardEntry provides a mock function with given fields: msgID
 func (_m *Database) RemoveStarboardEntry(msgID string) error {
 	ret := _m.Called(msgID)
@@ -2908,6 +3968,42 @@ func (_m *Database) SetAntiraidBurst(guildID string, burst int) error {
 	return r0
 }
 
+// SetAntiraidLockdown provides a mock function with given fields: guildID, cfg
+func (_m *Database) SetAntiraidLockdown(guildID string, cfg models.AntiraidLockdown) error {
+	ret := _m.Called(guildID, cfg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetAntiraidLockdown")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.AntiraidLockdown) error); ok {
+		r0 = rf(guildID, cfg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetAntiraidLockdownState provides a mock function with given fields: state
+func (_m *Database) SetAntiraidLockdownState(state models.AntiraidLockdownState) error {
+	ret := _m.Called(state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetAntiraidLockdownState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.AntiraidLockdownState) error); ok {
+		r0 = rf(state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetAntiraidRegeneration provides a mock function with given fields: guildID, periodSecs
 func (_m *Database) SetAntiraidRegeneration(guildID string, periodSecs int) error {
 	ret := _m.Called(guildID, periodSecs)
@@ -2926,6 +4022,24 @@ func (_m *Database) SetAntiraidRegeneration(guildID string, periodSecs int) erro
 	return r0
 }
 
+// SetAntiraidSignals provides a mock function with given fields: guildID, sig
+func (_m *Database) SetAntiraidSignals(guildID string, sig models.AntiraidSignals) error {
+	ret := _m.Called(guildID, sig)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetAntiraidSignals")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.AntiraidSignals) error); ok {
+		r0 = rf(guildID, sig)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetAntiraidState provides a mock function with given fields: guildID, state
 func (_m *Database) SetAntiraidState(guildID string, state bool) error {
 	ret := _m.Called(guildID, state)
@@ -2962,6 +4076,24 @@ func (_m *Database) SetAntiraidVerification(guildID string, state bool) error {
 	return r0
 }
 
+// SetBackupRestoreCheckpoint provides a mock function with given fields: cp
+func (_m *Database) SetBackupRestoreCheckpoint(cp backupmodels.RestoreCheckpoint) error {
+	ret := _m.Called(cp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetBackupRestoreCheckpoint")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(backupmodels.RestoreCheckpoint) error); ok {
+		r0 = rf(cp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetBirthday provides a mock function with given fields: m
 func (_m *Database) SetBirthday(m models.Birthday) error {
 	ret := _m.Called(m)
@@ -3034,6 +4166,24 @@ func (_m *Database) SetGuildAutoVC(guildID string, autoVCIDs []string) error {
 	return r0
 }
 
+// SetGuildAutomodRules provides a mock function with given fields: guildID, rules
+func (_m *Database) SetGuildAutomodRules(guildID string, rules []models.AutomodRule) error {
+	ret := _m.Called(guildID, rules)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetGuildAutomodRules")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []models.AutomodRule) error); ok {
+		r0 = rf(guildID, rules)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetGuildBackup provides a mock function with given fields: guildID, enabled
 func (_m *Database) SetGuildBackup(guildID string, enabled bool) error {
 	ret := _m.Called(guildID, enabled)
@@ -3052,6 +4202,24 @@ func (_m *Database) SetGuildBackup(guildID string, enabled bool) error {
 	return r0
 }
 
+// SetGuildBackupRetention provides a mock function with given fields: guildID, r
+func (_m *Database) SetGuildBackupRetention(guildID string, r backupmodels.Retention) error {
+	ret := _m.Called(guildID, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetGuildBackupRetention")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, backupmodels.Retention) error); ok {
+		r0 = rf(guildID, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetGuildBirthdayChan provides a mock function with given fields: guildID, chanID
 func (_m *Database) SetGuildBirthdayChan(guildID string, chanID string) error {
 	ret := _m.Called(guildID, chanID)
@@ -3070,6 +4238,42 @@ func (_m *Database) SetGuildBirthdayChan(guildID string, chanID string) error {
 	return r0
 }
 
+// SetGuildBirthdayRole provides a mock function with given fields: guildID, roleID
+func (_m *Database) SetGuildBirthdayRole(guildID string, roleID string) error {
+	ret := _m.Called(guildID, roleID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetGuildBirthdayRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(guildID, roleID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetGuildBirthdayTemplate provides a mock function with given fields: guildID, template
+func (_m *Database) SetGuildBirthdayTemplate(guildID string, template string) error {
+	ret := _m.Called(guildID, template)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetGuildBirthdayTemplate")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(guildID, template)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetGuildCodeExecEnabled provides a mock function with given fields: guildID, enabled
 func (_m *Database) SetGuildCodeExecEnabled(guildID string, enabled bool) error {
 	ret := _m.Called(guildID, enabled)
@@ -3106,6 +4310,24 @@ func (_m *Database) SetGuildColorReaction(guildID string, enable bool) error {
 	return r0
 }
 
+// SetGuildEscalationLadder provides a mock function with given fields: guildID, l
+func (_m *Database) SetGuildEscalationLadder(guildID string, l models.EscalationLadder) error {
+	ret := _m.Called(guildID, l)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetGuildEscalationLadder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.EscalationLadder) error); ok {
+		r0 = rf(guildID, l)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetGuildGhostpingMsg provides a mock function with given fields: guildID, msg
 func (_m *Database) SetGuildGhostpingMsg(guildID string, msg string) error {
 	ret := _m.Called(guildID, msg)
@@ -3142,6 +4364,24 @@ func (_m *Database) SetGuildInviteBlock(guildID string, data string) error {
 	return r0
 }
 
+// SetGuildInviteBlockSettings provides a mock function with given fields: guildID, settings
+func (_m *Database) SetGuildInviteBlockSettings(guildID string, settings models.InviteBlockSettings) error {
+	ret := _m.Called(guildID, settings)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetGuildInviteBlockSettings")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.InviteBlockSettings) error); ok {
+		r0 = rf(guildID, settings)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetGuildJdoodleKey provides a mock function with given fields: guildID, key
 func (_m *Database) SetGuildJdoodleKey(guildID string, key string) error {
 	ret := _m.Called(guildID, key)
@@ -3376,6 +4616,24 @@ func (_m *Database) SetKarma(userID string, guildID string, val int) error {
 	return r0
 }
 
+// SetKarmaDecay provides a mock function with given fields: guildID, decay
+func (_m *Database) SetKarmaDecay(guildID string, decay models.KarmaDecay) error {
+	ret := _m.Called(guildID, decay)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetKarmaDecay")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.KarmaDecay) error); ok {
+		r0 = rf(guildID, decay)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetKarmaEmotes provides a mock function with given fields: guildID, emotesInc, emotesDec
 func (_m *Database) SetKarmaEmotes(guildID string, emotesInc string, emotesDec string) error {
 	ret := _m.Called(guildID, emotesInc, emotesDec)
@@ -3394,6 +4652,42 @@ func (_m *Database) SetKarmaEmotes(guildID string, emotesInc string, emotesDec s
 	return r0
 }
 
+// SetKarmaFarmingLimits provides a mock function with given fields: guildID, limits
+func (_m *Database) SetKarmaFarmingLimits(guildID string, limits models.KarmaFarmingLimits) error {
+	ret := _m.Called(guildID, limits)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetKarmaFarmingLimits")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.KarmaFarmingLimits) error); ok {
+		r0 = rf(guildID, limits)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetKarmaLedgerReverted provides a mock function with given fields: id, revertedBy
+func (_m *Database) SetKarmaLedgerReverted(id snowflake.ID, revertedBy snowflake.ID) error {
+	ret := _m.Called(id, revertedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetKarmaLedgerReverted")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(snowflake.ID, snowflake.ID) error); ok {
+		r0 = rf(id, revertedBy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetKarmaPenalty provides a mock function with given fields: guildID, state
 func (_m *Database) SetKarmaPenalty(guildID string, state bool) error {
 	ret := _m.Called(guildID, state)
@@ -3430,6 +4724,24 @@ func (_m *Database) SetKarmaState(guildID string, state bool) error {
 	return r0
 }
 
+// SetKarmaTempRole provides a mock function with given fields: tr
+func (_m *Database) SetKarmaTempRole(tr models.KarmaTempRole) error {
+	ret := _m.Called(tr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetKarmaTempRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.KarmaTempRole) error); ok {
+		r0 = rf(tr)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetKarmaTokens provides a mock function with given fields: guildID, tokens
 func (_m *Database) SetKarmaTokens(guildID string, tokens int) error {
 	ret := _m.Called(guildID, tokens)
@@ -3466,6 +4778,24 @@ func (_m *Database) SetLockChan(chanID string, guildID string, executorID string
 	return r0
 }
 
+// SetRoleSelects provides a mock function with given fields: guildID, channelID, messageID, v
+func (_m *Database) SetRoleSelects(guildID string, channelID string, messageID string, v []models.RoleSelect) error {
+	ret := _m.Called(guildID, channelID, messageID, v)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRoleSelects")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, []models.RoleSelect) error); ok {
+		r0 = rf(guildID, channelID, messageID, v)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetSetting provides a mock function with given fields: setting, value
 func (_m *Database) SetSetting(setting string, value string) error {
 	ret := _m.Called(setting, value)
@@ -3592,6 +4922,24 @@ func (_m *Database) SetUserStarboardOptout(userID string, enabled bool) error {
 	return r0
 }
 
+// SetUserTimezone provides a mock function with given fields: userID, timezone
+func (_m *Database) SetUserTimezone(userID string, timezone string) error {
+	ret := _m.Called(userID, timezone)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserTimezone")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(userID, timezone)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetUserVerified provides a mock function with given fields: userID, enabled
 func (_m *Database) SetUserVerified(userID string, enabled bool) error {
 	ret := _m.Called(userID, enabled)
@@ -3628,6 +4976,42 @@ func (_m *Database) Status() error {
 	return r0
 }
 
+// UpdateBackup provides a mock function with given fields: entry
+func (_m *Database) UpdateBackup(entry backupmodels.Entry) error {
+	ret := _m.Called(entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBackup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(backupmodels.Entry) error); ok {
+		r0 = rf(entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateBackupKey provides a mock function with given fields: key
+func (_m *Database) UpdateBackupKey(key backupmodels.DataKey) error {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBackupKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(backupmodels.DataKey) error); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateKarma provides a mock function with given fields: userID, guildID, diff
 func (_m *Database) UpdateKarma(userID string, guildID string, diff int) error {
 	ret := _m.Called(userID, guildID, diff)
@@ -3646,6 +5030,24 @@ func (_m *Database) UpdateKarma(userID string, guildID string, diff int) error {
 	return r0
 }
 
+// UpdateReport provides a mock function with given fields: rep
+func (_m *Database) UpdateReport(rep models.Report) error {
+	ret := _m.Called(rep)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateReport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.Report) error); ok {
+		r0 = rf(rep)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateUnbanRequest provides a mock function with given fields: request
 func (_m *Database) UpdateUnbanRequest(request models.UnbanRequest) error {
 	ret := _m.Called(request)
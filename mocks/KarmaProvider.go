@@ -6,6 +6,10 @@ import (
 	discordgo "github.com/bwmarrin/discordgo"
 
 	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/zekroTJA/shinpuru/internal/models"
+
+	time "time"
 )
 
 // KarmaProvider is an autogenerated mock type for the Provider type
@@ -31,9 +35,9 @@ func (_m *KarmaProvider) ApplyPenalty(guildID string, userID string) error {
 	return r0
 }
 
-// CheckAndUpdate provides a mock function with given fields: guildID, executorID, object, value
-func (_m *KarmaProvider) CheckAndUpdate(guildID string, executorID string, object *discordgo.User, value int) (bool, error) {
-	ret := _m.Called(guildID, executorID, object, value)
+// CheckAndUpdate provides a mock function with given fields: guildID, executorID, channelID, object, value, source
+func (_m *KarmaProvider) CheckAndUpdate(guildID string, executorID string, channelID string, object *discordgo.User, value int, source models.KarmaSource) (bool, error) {
+	ret := _m.Called(guildID, executorID, channelID, object, value, source)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CheckAndUpdate")
@@ -41,17 +45,45 @@ func (_m *KarmaProvider) CheckAndUpdate(guildID string, executorID string, objec
 
 	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, *discordgo.User, int) (bool, error)); ok {
-		return rf(guildID, executorID, object, value)
+	if rf, ok := ret.Get(0).(func(string, string, string, *discordgo.User, int, models.KarmaSource) (bool, error)); ok {
+		return rf(guildID, executorID, channelID, object, value, source)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string, *discordgo.User, int, models.KarmaSource) bool); ok {
+		r0 = rf(guildID, executorID, channelID, object, value, source)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string, *discordgo.User, int, models.KarmaSource) error); ok {
+		r1 = rf(guildID, executorID, channelID, object, value, source)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckPairLimit provides a mock function with given fields: guildID, executorID, targetID
+func (_m *KarmaProvider) CheckPairLimit(guildID string, executorID string, targetID string) (bool, error) {
+	ret := _m.Called(guildID, executorID, targetID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckPairLimit")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, string) (bool, error)); ok {
+		return rf(guildID, executorID, targetID)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, *discordgo.User, int) bool); ok {
-		r0 = rf(guildID, executorID, object, value)
+	if rf, ok := ret.Get(0).(func(string, string, string) bool); ok {
+		r0 = rf(guildID, executorID, targetID)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, *discordgo.User, int) error); ok {
-		r1 = rf(guildID, executorID, object, value)
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(guildID, executorID, targetID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -115,17 +147,95 @@ func (_m *KarmaProvider) IsBlockListed(guildID string, userID string) (bool, err
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: guildID, userID, executorID, value
-func (_m *KarmaProvider) Update(guildID string, userID string, executorID string, value int) error {
-	ret := _m.Called(guildID, userID, executorID, value)
+// PreviewRule provides a mock function with given fields: rule
+func (_m *KarmaProvider) PreviewRule(rule models.KarmaRule) ([]models.GuildKarma, error) {
+	ret := _m.Called(rule)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PreviewRule")
+	}
+
+	var r0 []models.GuildKarma
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.KarmaRule) ([]models.GuildKarma, error)); ok {
+		return rf(rule)
+	}
+	if rf, ok := ret.Get(0).(func(models.KarmaRule) []models.GuildKarma); ok {
+		r0 = rf(rule)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.GuildKarma)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.KarmaRule) error); ok {
+		r1 = rf(rule)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revert provides a mock function with given fields: guildID, executorID, moderatorID, from, to
+func (_m *KarmaProvider) Revert(guildID string, executorID string, moderatorID string, from time.Time, to time.Time) ([]models.KarmaLedgerEntry, error) {
+	ret := _m.Called(guildID, executorID, moderatorID, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revert")
+	}
+
+	var r0 []models.KarmaLedgerEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, string, time.Time, time.Time) ([]models.KarmaLedgerEntry, error)); ok {
+		return rf(guildID, executorID, moderatorID, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string, time.Time, time.Time) []models.KarmaLedgerEntry); ok {
+		r0 = rf(guildID, executorID, moderatorID, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.KarmaLedgerEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string, time.Time, time.Time) error); ok {
+		r1 = rf(guildID, executorID, moderatorID, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeGrantedToken provides a mock function with given fields: guildID, userID
+func (_m *KarmaProvider) TakeGrantedToken(guildID string, userID string) bool {
+	ret := _m.Called(guildID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeGrantedToken")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(guildID, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: guildID, userID, executorID, channelID, value, source
+func (_m *KarmaProvider) Update(guildID string, userID string, executorID string, channelID string, value int, source models.KarmaSource) error {
+	ret := _m.Called(guildID, userID, executorID, channelID, value, source)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, string, int) error); ok {
-		r0 = rf(guildID, userID, executorID, value)
+	if rf, ok := ret.Get(0).(func(string, string, string, string, int, models.KarmaSource) error); ok {
+		r0 = rf(guildID, userID, executorID, channelID, value, source)
 	} else {
 		r0 = ret.Error(0)
 	}
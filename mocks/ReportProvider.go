@@ -16,6 +16,43 @@ type ReportProvider struct {
 	mock.Mock
 }
 
+// AmendReport provides a mock function with given fields: rep, am, editorID
+func (_m *ReportProvider) AmendReport(rep models.Report, am models.ReportAmendment, editorID string) (models.Report, *discordgo.MessageEmbed, error) {
+	ret := _m.Called(rep, am, editorID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AmendReport")
+	}
+
+	var r0 models.Report
+	var r1 *discordgo.MessageEmbed
+	var r2 error
+	if rf, ok := ret.Get(0).(func(models.Report, models.ReportAmendment, string) (models.Report, *discordgo.MessageEmbed, error)); ok {
+		return rf(rep, am, editorID)
+	}
+	if rf, ok := ret.Get(0).(func(models.Report, models.ReportAmendment, string) models.Report); ok {
+		r0 = rf(rep, am, editorID)
+	} else {
+		r0 = ret.Get(0).(models.Report)
+	}
+
+	if rf, ok := ret.Get(1).(func(models.Report, models.ReportAmendment, string) *discordgo.MessageEmbed); ok {
+		r1 = rf(rep, am, editorID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*discordgo.MessageEmbed)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(models.Report, models.ReportAmendment, string) error); ok {
+		r2 = rf(rep, am, editorID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // ExpireExpiredReports provides a mock function with given fields:
 func (_m *ReportProvider) ExpireExpiredReports() *multierror.MultiError {
 	ret := _m.Called()
@@ -8,6 +8,10 @@ import (
 	config "github.com/zekroTJA/shinpuru/internal/services/config"
 
 	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/zekroTJA/shinpuru/internal/services/storage"
+
+	time "time"
 )
 
 // Storage is an autogenerated mock type for the Storage type
@@ -166,6 +170,64 @@ func (_m *Storage) GetObject(bucketName string, objectName string) (io.ReadClose
 	return r0, r1, r2
 }
 
+// ListObjects provides a mock function with given fields: bucketName, prefix
+func (_m *Storage) ListObjects(bucketName string, prefix string) ([]storage.ObjectInfo, error) {
+	ret := _m.Called(bucketName, prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListObjects")
+	}
+
+	var r0 []storage.ObjectInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]storage.ObjectInfo, error)); ok {
+		return rf(bucketName, prefix)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []storage.ObjectInfo); ok {
+		r0 = rf(bucketName, prefix)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.ObjectInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(bucketName, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PresignGetObject provides a mock function with given fields: bucketName, objectName, expires, fileName
+func (_m *Storage) PresignGetObject(bucketName string, objectName string, expires time.Duration, fileName string) (string, error) {
+	ret := _m.Called(bucketName, objectName, expires, fileName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PresignGetObject")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration, string) (string, error)); ok {
+		return rf(bucketName, objectName, expires, fileName)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration, string) string); ok {
+		r0 = rf(bucketName, objectName, expires, fileName)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, time.Duration, string) error); ok {
+		r1 = rf(bucketName, objectName, expires, fileName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PutObject provides a mock function with given fields: bucketName, objectName, reader, objectSize, mimeType
 func (_m *Storage) PutObject(bucketName string, objectName string, reader io.Reader, objectSize int64, mimeType string) error {
 	ret := _m.Called(bucketName, objectName, reader, objectSize, mimeType)
@@ -184,6 +246,52 @@ func (_m *Storage) PutObject(bucketName string, objectName string, reader io.Rea
 	return r0
 }
 
+// PutObjectStream provides a mock function with given fields: bucketName, objectName, reader, mimeType
+func (_m *Storage) PutObjectStream(bucketName string, objectName string, reader io.Reader, mimeType string) error {
+	ret := _m.Called(bucketName, objectName, reader, mimeType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutObjectStream")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, io.Reader, string) error); ok {
+		r0 = rf(bucketName, objectName, reader, mimeType)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StatObject provides a mock function with given fields: bucketName, objectName
+func (_m *Storage) StatObject(bucketName string, objectName string) (storage.ObjectInfo, error) {
+	ret := _m.Called(bucketName, objectName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StatObject")
+	}
+
+	var r0 storage.ObjectInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (storage.ObjectInfo, error)); ok {
+		return rf(bucketName, objectName)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) storage.ObjectInfo); ok {
+		r0 = rf(bucketName, objectName)
+	} else {
+		r0 = ret.Get(0).(storage.ObjectInfo)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(bucketName, objectName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Status provides a mock function with given fields:
 func (_m *Storage) Status() error {
 	ret := _m.Called()
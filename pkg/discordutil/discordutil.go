@@ -120,3 +120,34 @@ func SendDMEmbed(
 	msg, err = s.ChannelMessageSendEmbed(ch.ID, emb)
 	return
 }
+
+// GuildFeatureInvitesDisabled is the guild feature which
+// pauses all invites of a guild when set.
+const GuildFeatureInvitesDisabled discordgo.GuildFeature = "INVITES_DISABLED"
+
+// HasGuildFeature returns true if the passed guild has
+// the given feature enabled.
+func HasGuildFeature(g *discordgo.Guild, feature discordgo.GuildFeature) bool {
+	for _, f := range g.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGuildFeatures sets the features of the given guild.
+//
+// In contrast to GuildEdit, the features are also sent when
+// the passed list is empty, which is required to remove the
+// last feature of a guild.
+func SetGuildFeatures(s ISession, guildID string, features []discordgo.GuildFeature) (err error) {
+	if features == nil {
+		features = []discordgo.GuildFeature{}
+	}
+	body := struct {
+		Features []discordgo.GuildFeature `json:"features"`
+	}{features}
+	_, err = s.RequestWithBucketID("PATCH", discordgo.EndpointGuild(guildID), body, discordgo.EndpointGuild(guildID))
+	return
+}
@@ -4,16 +4,51 @@ import "time"
 
 type Config struct {
 	TimerDelay time.Duration `json:"timderdelay"`
+
+	// EventSub enables notifications via EventSub
+	// webhooks instead of polling for all users which
+	// have an active subscription.
+	EventSub EventSubConfig `json:"eventsub"`
+
+	// HelixEndpoint and OAuth2Endpoint can be used to
+	// override the Twitch API endpoints. They default
+	// to the public Twitch API when empty.
+	HelixEndpoint  string `json:"helixendpoint"`
+	OAuth2Endpoint string `json:"oauth2endpoint"`
+}
+
+// EventSubConfig holds the configuration for receiving
+// stream notifications via EventSub webhooks.
+type EventSubConfig struct {
+	// CallbackURL is the public URL Twitch sends the
+	// webhook requests to.
+	CallbackURL string `json:"callbackurl"`
+	// Secret is used to sign the webhook requests and
+	// must be between 10 and 100 characters long.
+	Secret string `json:"secret"`
+}
+
+// Enabled returns true if both the callback URL
+// and the secret are set.
+func (c EventSubConfig) Enabled() bool {
+	return c.CallbackURL != "" && c.Secret != ""
 }
 
 func defaultConfig(configs []Config) Config {
-	defaultConfig := Config{
+	config := Config{
 		TimerDelay: 60 * time.Second,
 	}
 
 	if len(configs) > 0 {
-		return configs[0]
+		config = configs[0]
+	}
+
+	if config.HelixEndpoint == "" {
+		config.HelixEndpoint = helixEndpoint
+	}
+	if config.OAuth2Endpoint == "" {
+		config.OAuth2Endpoint = oAuth2Endpoint
 	}
 
-	return defaultConfig
+	return config
 }
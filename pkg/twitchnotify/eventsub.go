@@ -0,0 +1,392 @@
+package twitchnotify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/pkg/multierror"
+)
+
+// Header names of EventSub webhook requests.
+const (
+	HeaderMessageID        = "Twitch-Eventsub-Message-Id"
+	HeaderMessageType      = "Twitch-Eventsub-Message-Type"
+	HeaderMessageTimestamp = "Twitch-Eventsub-Message-Timestamp"
+	HeaderMessageSignature = "Twitch-Eventsub-Message-Signature"
+)
+
+const (
+	messageTypeVerification = "webhook_callback_verification"
+	messageTypeNotification = "notification"
+	messageTypeRevocation   = "revocation"
+
+	eventTypeStreamOnline  = "stream.online"
+	eventTypeStreamOffline = "stream.offline"
+
+	statusEnabled             = "enabled"
+	statusVerificationPending = "webhook_callback_verification_pending"
+
+	// maxMessageAge is the maximum age of webhook messages.
+	// Older messages are rejected to prevent replay attacks.
+	maxMessageAge = 10 * time.Minute
+)
+
+var eventSubStreamTypes = []string{eventTypeStreamOnline, eventTypeStreamOffline}
+
+var (
+	ErrEventSubDisabled = errors.New("eventsub is not enabled")
+	ErrInvalidSecret    = errors.New("eventsub secret must be between 10 and 100 characters long")
+	ErrInvalidSignature = errors.New("invalid message signature")
+	ErrMessageExpired   = errors.New("message timestamp is too old")
+)
+
+// EventSubMessage contains the headers and the body
+// of an EventSub webhook request.
+type EventSubMessage struct {
+	ID        string
+	Type      string
+	Timestamp string
+	Signature string
+	Body      []byte
+}
+
+// subscription is the local state of an
+// EventSub subscription.
+type subscription struct {
+	ID      string
+	Type    string
+	UserID  string
+	Enabled bool
+}
+
+// VerifySignature returns true if the signature of the
+// message matches the HMAC-SHA256 signature of the
+// message ID, timestamp and body using the given secret.
+func VerifySignature(secret string, msg EventSubMessage) bool {
+	sig := strings.TrimPrefix(msg.Signature, "sha256=")
+	if len(sig) == len(msg.Signature) {
+		return false
+	}
+
+	expected, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(expected, sign(secret, msg))
+}
+
+func sign(secret string, msg EventSubMessage) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(msg.ID))
+	mac.Write([]byte(msg.Timestamp))
+	mac.Write(msg.Body)
+	return mac.Sum(nil)
+}
+
+// HandleEventSub handles an EventSub webhook request.
+//
+// The signature and the timestamp of the message are
+// validated first. Messages which have already been
+// received are ignored. On callback verification, the
+// challenge is returned which must be sent back as
+// plain text response body.
+//
+// Notifications are handled asynchronously so that
+// the request can be answered immediately.
+func (w *NotifyWorker) HandleEventSub(msg EventSubMessage) (challenge string, err error) {
+	if !w.conf.EventSub.Enabled() {
+		return "", ErrEventSubDisabled
+	}
+
+	if !VerifySignature(w.conf.EventSub.Secret, msg) {
+		return "", ErrInvalidSignature
+	}
+
+	now := time.Now()
+	ts, err := time.Parse(time.RFC3339Nano, msg.Timestamp)
+	if err != nil || now.Sub(ts) > maxMessageAge || ts.Sub(now) > maxMessageAge {
+		return "", ErrMessageExpired
+	}
+
+	if w.isDuplicate(msg.ID, now) {
+		return "", nil
+	}
+
+	var payload eventSubPayload
+	if err = json.Unmarshal(msg.Body, &payload); err != nil {
+		return "", err
+	}
+
+	switch msg.Type {
+	case messageTypeVerification:
+		w.setSubscription(payload.Subscription, true)
+		return payload.Challenge, nil
+	case messageTypeNotification:
+		w.setSubscription(payload.Subscription, true)
+		go w.handleNotification(payload)
+	case messageTypeRevocation:
+		// The user falls back to polling until the
+		// subscription is re-created on the next sync.
+		w.mx.Lock()
+		delete(w.subscriptions, payload.Subscription.ID)
+		w.mx.Unlock()
+	}
+
+	return "", nil
+}
+
+// SyncSubscriptions reconciles the EventSub subscriptions
+// with the watched users. Subscriptions of users which are
+// not watched anymore as well as failed or revoked ones are
+// deleted and missing subscriptions are created.
+//
+// Only subscriptions with the configured callback URL are
+// taken into account.
+func (w *NotifyWorker) SyncSubscriptions() error {
+	if !w.conf.EventSub.Enabled() {
+		return nil
+	}
+
+	existing, err := w.listSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	w.mx.Lock()
+	userIDs := make([]string, 0, len(w.users))
+	for id := range w.users {
+		userIDs = append(userIDs, id)
+	}
+	w.mx.Unlock()
+
+	watched := make(map[string]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		watched[id] = struct{}{}
+	}
+
+	mErr := multierror.New()
+	subs := make(map[string]*subscription)
+	have := make(map[string]struct{})
+	for _, s := range existing {
+		if s.Transport.Callback != w.conf.EventSub.CallbackURL || !isStreamEventType(s.Type) {
+			continue
+		}
+
+		key := s.Condition.BroadcasterUserID + "/" + s.Type
+		_, isWatched := watched[s.Condition.BroadcasterUserID]
+		_, isDuplicate := have[key]
+		isActive := s.Status == statusEnabled || s.Status == statusVerificationPending
+
+		if !isWatched || isDuplicate || !isActive {
+			mErr.Append(w.deleteSubscription(s.ID))
+			continue
+		}
+
+		have[key] = struct{}{}
+		subs[s.ID] = newSubscription(*s)
+	}
+
+	w.mx.Lock()
+	w.subscriptions = subs
+	w.synced = true
+	w.mx.Unlock()
+
+	for _, id := range userIDs {
+		for _, typ := range eventSubStreamTypes {
+			if _, ok := have[id+"/"+typ]; !ok {
+				mErr.Append(w.createSubscription(typ, id))
+			}
+		}
+	}
+
+	return mErr.Nillify()
+}
+
+// subscribeUser creates all missing subscriptions
+// for the user with the given ID.
+func (w *NotifyWorker) subscribeUser(userID string) error {
+	w.mx.Lock()
+	have := make(map[string]struct{})
+	for _, sub := range w.subscriptions {
+		if sub.UserID == userID {
+			have[sub.Type] = struct{}{}
+		}
+	}
+	w.mx.Unlock()
+
+	mErr := multierror.New()
+	for _, typ := range eventSubStreamTypes {
+		if _, ok := have[typ]; !ok {
+			mErr.Append(w.createSubscription(typ, userID))
+		}
+	}
+
+	return mErr.Nillify()
+}
+
+func (w *NotifyWorker) createSubscription(typ, userID string) error {
+	body := eventSubSubscription{
+		Type:    typ,
+		Version: "1",
+		Condition: eventSubCondition{
+			BroadcasterUserID: userID,
+		},
+		Transport: eventSubTransport{
+			Method:   "webhook",
+			Callback: w.conf.EventSub.CallbackURL,
+			Secret:   w.conf.EventSub.Secret,
+		},
+	}
+
+	url := fmt.Sprintf("%s/eventsub/subscriptions", w.conf.HelixEndpoint)
+	data := new(eventSubSubscriptionsWrapper)
+	if err := w.doAuthenticated("POST", url, body, data); err != nil {
+		return err
+	}
+
+	for _, s := range data.Data {
+		w.setSubscription(*s, s.Status == statusEnabled)
+	}
+
+	return nil
+}
+
+func (w *NotifyWorker) deleteSubscription(id string) error {
+	w.mx.Lock()
+	delete(w.subscriptions, id)
+	w.mx.Unlock()
+
+	url := fmt.Sprintf("%s/eventsub/subscriptions?id=%s", w.conf.HelixEndpoint, id)
+	err := w.doAuthenticated("DELETE", url, nil, nil)
+
+	var rErr *ResponseError
+	if errors.As(err, &rErr) && rErr.StatusCode == http.StatusNotFound {
+		err = nil
+	}
+
+	return err
+}
+
+func (w *NotifyWorker) listSubscriptions() ([]*eventSubSubscription, error) {
+	subs := make([]*eventSubSubscription, 0)
+
+	var cursor string
+	for {
+		url := fmt.Sprintf("%s/eventsub/subscriptions", w.conf.HelixEndpoint)
+		if cursor != "" {
+			url += "?after=" + cursor
+		}
+
+		data := new(eventSubSubscriptionsWrapper)
+		if err := w.doAuthenticated("GET", url, nil, data); err != nil {
+			return nil, err
+		}
+
+		subs = append(subs, data.Data...)
+
+		cursor = data.Pagination.Cursor
+		if cursor == "" || len(data.Data) == 0 {
+			break
+		}
+	}
+
+	return subs, nil
+}
+
+// setSubscription records the given subscription. Calls
+// for subscriptions with unknown event types are ignored.
+func (w *NotifyWorker) setSubscription(s eventSubSubscription, enabled bool) {
+	if s.ID == "" || !isStreamEventType(s.Type) {
+		return
+	}
+
+	sub := newSubscription(s)
+	sub.Enabled = enabled
+
+	w.mx.Lock()
+	w.subscriptions[s.ID] = sub
+	w.mx.Unlock()
+}
+
+func (w *NotifyWorker) handleNotification(payload eventSubPayload) {
+	ev := payload.Event
+
+	switch payload.Subscription.Type {
+	case eventTypeStreamOnline:
+		w.wentOnline(w.streamFromEvent(ev))
+	case eventTypeStreamOffline:
+		w.wentOffline(ev.BroadcasterUserID)
+	}
+}
+
+// streamFromEvent requests the stream details of the
+// event. Because the stream might not be available via
+// the API immediately after it went online, a stream
+// object assembled from the event is returned in this
+// case.
+func (w *NotifyWorker) streamFromEvent(ev eventSubStreamEvent) *Stream {
+	streams, err := w.getStreams([]string{ev.BroadcasterUserID})
+	if err == nil {
+		for _, s := range streams {
+			if s != nil && s.ID == ev.ID {
+				return s
+			}
+		}
+	}
+
+	return &Stream{
+		ID:        ev.ID,
+		UserID:    ev.BroadcasterUserID,
+		UserName:  ev.BroadcasterUserName,
+		Type:      ev.Type,
+		StartedAt: ev.StartedAt,
+		ThumbnailURL: fmt.Sprintf("https://static-cdn.jtvnw.net/previews-ttv/live_user_%s-{width}x{height}.jpg",
+			ev.BroadcasterUserLogin),
+	}
+}
+
+// isDuplicate returns true if a message with the given ID
+// has already been received. Message IDs are remembered
+// for maxMessageAge because older messages are rejected
+// anyway.
+//
+// The ID is cloned before it is stored because it might
+// reference a request buffer which is re-used afterwards.
+func (w *NotifyWorker) isDuplicate(id string, now time.Time) bool {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	for mid, t := range w.seenMessages {
+		if now.Sub(t) > maxMessageAge {
+			delete(w.seenMessages, mid)
+		}
+	}
+
+	if _, ok := w.seenMessages[id]; ok {
+		return true
+	}
+
+	w.seenMessages[strings.Clone(id)] = now
+	return false
+}
+
+func newSubscription(s eventSubSubscription) *subscription {
+	return &subscription{
+		ID:      s.ID,
+		Type:    s.Type,
+		UserID:  s.Condition.BroadcasterUserID,
+		Enabled: s.Status == statusEnabled,
+	}
+}
+
+func isStreamEventType(typ string) bool {
+	return typ == eventTypeStreamOnline || typ == eventTypeStreamOffline
+}
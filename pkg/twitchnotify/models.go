@@ -57,3 +57,50 @@ type bearerTokenResponse struct {
 	AccessToken string `json:"access_token"`
 	ExpiresIn   int    `json:"expires_in"`
 }
+
+type eventSubSubscription struct {
+	ID        string            `json:"id"`
+	Status    string            `json:"status"`
+	Type      string            `json:"type"`
+	Version   string            `json:"version"`
+	Condition eventSubCondition `json:"condition"`
+	Transport eventSubTransport `json:"transport"`
+}
+
+type eventSubCondition struct {
+	BroadcasterUserID string `json:"broadcaster_user_id"`
+}
+
+type eventSubTransport struct {
+	Method   string `json:"method"`
+	Callback string `json:"callback"`
+	Secret   string `json:"secret,omitempty"`
+}
+
+type eventSubSubscriptionsWrapper struct {
+	Data       []*eventSubSubscription `json:"data"`
+	Pagination struct {
+		Cursor string `json:"cursor"`
+	} `json:"pagination"`
+}
+
+type eventSubStreamEvent struct {
+	ID                   string `json:"id"`
+	BroadcasterUserID    string `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string `json:"broadcaster_user_login"`
+	BroadcasterUserName  string `json:"broadcaster_user_name"`
+	Type                 string `json:"type"`
+	StartedAt            string `json:"started_at"`
+}
+
+type eventSubPayload struct {
+	Challenge    string               `json:"challenge"`
+	Subscription eventSubSubscription `json:"subscription"`
+	Event        eventSubStreamEvent  `json:"event"`
+}
+
+type errorResponse struct {
+	Error   string `json:"error"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
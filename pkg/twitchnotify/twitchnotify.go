@@ -1,7 +1,7 @@
 // Package twitchnotify provides functionalities
 // to watch the state of twitch streams and
-// notifying changes by polling the twitch REST
-// API.
+// notifying changes by either polling the twitch
+// REST API or by receiving EventSub webhooks.
 package twitchnotify
 
 import (
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"image"
 	"math/rand"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -27,8 +28,14 @@ const (
 	IdentID    UserIdent = "id"
 	IdentLogin UserIdent = "login"
 
+	// maxUserCap is only applied when EventSub is
+	// disabled, because then all users are polled.
 	maxUserCap = 1000
 
+	// maxStreamsPerRequest is the maximum number of user
+	// IDs which can be passed to the streams endpoint.
+	maxStreamsPerRequest = 100
+
 	oAuth2Endpoint = "https://id.twitch.tv/oauth2/token"
 	helixEndpoint  = "https://api.twitch.tv/helix"
 )
@@ -40,6 +47,17 @@ var (
 	ErrMaxUsersReached     = errors.New("max registered users reached")
 )
 
+// ResponseError is returned when the Twitch API
+// responds with an error status code.
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("twitch api error (%d): %s", e.StatusCode, e.Message)
+}
+
 // NotifyHandler describes a callback handler when a
 // stream either goes online or offline passing the
 // stream data as well as the user data of the streamer.
@@ -55,17 +73,26 @@ type Credentials struct {
 // NotifyWorker provides general utilities to fetch
 // watched online streamers and call notify handler
 // callbacks when a stream goes online or offline.
+//
+// When EventSub is configured, stream state changes
+// are received via webhooks. Users without an active
+// subscription are still polled on Handle.
 type NotifyWorker struct {
 	creds              *Credentials
+	conf               Config
 	wentOnlineHandler  NotifyHandler
 	wentOfflineHandler NotifyHandler
 
-	mx        *sync.Mutex
-	timer     *time.Ticker
-	users     map[string]*User
-	wereLive  []*Stream
-	gameCache map[string]*Game
+	mx            *sync.Mutex
+	timer         *time.Ticker
+	users         map[string]*User
+	live          map[string]*Stream
+	gameCache     map[string]*Game
+	subscriptions map[string]*subscription
+	seenMessages  map[string]time.Time
+	synced        bool
 
+	tokenMx     *sync.Mutex
 	bearerToken string
 	bearerValid time.Time
 }
@@ -80,15 +107,24 @@ func New(
 ) (worker *NotifyWorker, err error) {
 	conf := defaultConfig(config)
 
+	if conf.EventSub.Enabled() && (len(conf.EventSub.Secret) < 10 || len(conf.EventSub.Secret) > 100) {
+		return nil, ErrInvalidSecret
+	}
+
 	worker = &NotifyWorker{
 		creds:              &creds,
+		conf:               conf,
 		wentOfflineHandler: wentOfflineHandler,
 		wentOnlineHandler:  wentOnlineHandler,
 
-		mx:        &sync.Mutex{},
-		users:     make(map[string]*User),
-		wereLive:  make([]*Stream, 0),
-		gameCache: make(map[string]*Game),
+		mx:            &sync.Mutex{},
+		users:         make(map[string]*User),
+		live:          make(map[string]*Stream),
+		gameCache:     make(map[string]*Game),
+		subscriptions: make(map[string]*subscription),
+		seenMessages:  make(map[string]time.Time),
+
+		tokenMx: &sync.Mutex{},
 	}
 
 	if err = worker.getBearerToken(); err != nil {
@@ -113,10 +149,10 @@ func New(
 // typ which one of these methods is used.
 // Returns the fetched user object and occured errors during fetch.
 func (w *NotifyWorker) GetUser(identifyer string, typ UserIdent) (*User, error) {
-	url := fmt.Sprintf("%s/users?%s=%s", helixEndpoint, typ, identifyer)
+	url := fmt.Sprintf("%s/users?%s=%s", w.conf.HelixEndpoint, typ, url.QueryEscape(identifyer))
 
 	data := new(usersDataWrapper)
-	if err := w.doAuthenticatedGet(url, data); err != nil {
+	if err := w.doAuthenticated("GET", url, nil, data); err != nil {
 		return nil, err
 	}
 
@@ -128,18 +164,63 @@ func (w *NotifyWorker) GetUser(identifyer string, typ UserIdent) (*User, error)
 }
 
 // AddUser adds the specified twitch User to the watch
-// list. If maxUserCap is reached, an ErrMaxUsersreached
-// error is returned.
+// list. If EventSub is disabled and maxUserCap is reached,
+// an ErrMaxUsersreached error is returned.
+//
+// If EventSub is enabled, subscriptions for the user are
+// created. As long as they are not active, the user is
+// polled. Before the first SyncSubscriptions call, and
+// if creating a subscription fails, subscriptions are
+// created on the next SyncSubscriptions call.
 func (w *NotifyWorker) AddUser(u *User) error {
-	if len(w.users) >= maxUserCap {
+	w.mx.Lock()
+	if _, ok := w.users[u.ID]; ok {
+		w.mx.Unlock()
+		return nil
+	}
+	if !w.conf.EventSub.Enabled() && len(w.users) >= maxUserCap {
+		w.mx.Unlock()
 		return ErrMaxUsersReached
 	}
-
 	w.users[u.ID] = u
+	synced := w.synced
+	w.mx.Unlock()
+
+	if w.conf.EventSub.Enabled() && synced {
+		w.subscribeUser(u.ID)
+	}
 
 	return nil
 }
 
+// RemoveUser removes the user with the given ID from
+// the watch list and deletes all of its subscriptions.
+func (w *NotifyWorker) RemoveUser(userID string) error {
+	w.mx.Lock()
+	delete(w.users, userID)
+	delete(w.live, userID)
+	subIDs := make([]string, 0, 2)
+	for id, sub := range w.subscriptions {
+		if sub.UserID == userID {
+			subIDs = append(subIDs, id)
+		}
+	}
+	w.mx.Unlock()
+
+	mErr := multierror.New()
+	for _, id := range subIDs {
+		mErr.Append(w.deleteSubscription(id))
+	}
+
+	return mErr.Nillify()
+}
+
+// EventSubEnabled returns true if stream notifications
+// are received via EventSub webhooks.
+func (w *NotifyWorker) EventSubEnabled() bool {
+	return w.conf.EventSub.Enabled()
+}
+
 // GetEmbed assembles and returns an embed reference
 // from the given Stream and User objects.
 func GetEmbed(d *Stream, u *User) *discordgo.MessageEmbed {
@@ -155,10 +236,13 @@ func GetEmbed(d *Stream, u *User) *discordgo.MessageEmbed {
 			Width:  1280,
 			Height: 720,
 		},
-		Footer: &discordgo.MessageEmbedFooter{
+	}
+
+	if d.Game != nil && d.Game.Name != "" {
+		emb.Footer = &discordgo.MessageEmbedFooter{
 			IconURL: strings.Replace(d.Game.IconURL, "{width}x{height}", "16x16", 1),
 			Text:    "Playing " + d.Game.Name,
-		},
+		}
 	}
 
 	if body, _, err := httpreq.GetFile(u.AviURL, nil); err == nil {
@@ -180,8 +264,11 @@ func GetEmbed(d *Stream, u *User) *discordgo.MessageEmbed {
 // twitch app credentials and retrieves a bearer token which
 // is then used for further request authentication.
 func (w *NotifyWorker) getBearerToken() error {
+	w.tokenMx.Lock()
+	defer w.tokenMx.Unlock()
+
 	url := fmt.Sprintf("%s?client_id=%s&client_secret=%s&grant_type=client_credentials",
-		oAuth2Endpoint, w.creds.ClientID, w.creds.ClientSecret)
+		w.conf.OAuth2Endpoint, w.creds.ClientID, w.creds.ClientSecret)
 
 	res, err := httpreq.Post(url, nil, nil)
 	if err != nil {
@@ -189,6 +276,10 @@ func (w *NotifyWorker) getBearerToken() error {
 	}
 	defer res.Release()
 
+	if err = responseError(res); err != nil {
+		return err
+	}
+
 	var token bearerTokenResponse
 	if err = res.JSON(&token); err != nil {
 		return err
@@ -200,51 +291,74 @@ func (w *NotifyWorker) getBearerToken() error {
 	return nil
 }
 
-// doAuthenticatedGet executes a GET request to the twitch API
+// doAuthenticated executes a request to the twitch API
 // using the retrieved bearer token for authentication.
 // If the token is unset or has expired, a new token will be
 // retrieved and the request fill be executed afterwards.
-// The request result will be put in the passed data reference
+// If body is not nil, it is sent JSON encoded. The request
+// result will be put in the passed data reference, if not nil,
 // and errors occured are returned.
-func (w *NotifyWorker) doAuthenticatedGet(url string, data interface{}) (err error) {
-	if w.bearerToken == "" || time.Now().After(w.bearerValid) {
+func (w *NotifyWorker) doAuthenticated(method, url string, body, data interface{}) (err error) {
+	w.tokenMx.Lock()
+	expired := w.bearerToken == "" || time.Now().After(w.bearerValid)
+	w.tokenMx.Unlock()
+
+	if expired {
 		if err = w.getBearerToken(); err != nil {
 			return
 		}
 	}
 
-	res, err := httpreq.Get(url, map[string]string{
+	w.tokenMx.Lock()
+	headers := map[string]string{
 		"Authorization": fmt.Sprintf("Bearer %s", w.bearerToken),
 		"Client-ID":     w.creds.ClientID,
-	})
+	}
+	w.tokenMx.Unlock()
+
+	if body != nil {
+		headers["Content-Type"] = "application/json"
+	}
+
+	res, err := httpreq.Request(method, url, headers, body)
 	if err != nil {
 		return
 	}
 	defer res.Release()
 
-	err = res.JSON(data)
+	if err = responseError(res); err != nil {
+		return
+	}
+
+	if data != nil {
+		err = res.JSON(data)
+	}
 
 	return
 }
 
-func (w *NotifyWorker) getStreams() ([]*Stream, error) {
-	userIDs := make([]string, len(w.users))
-	var i int
-	for k := range w.users {
-		userIDs[i] = k
-		i++
-	}
+// getStreams returns the streams of the given users
+// which are currently live. The users are requested
+// in batches of maxStreamsPerRequest.
+func (w *NotifyWorker) getStreams(userIDs []string) ([]*Stream, error) {
+	streams := make([]*Stream, 0)
+
+	for i := 0; i < len(userIDs); i += maxStreamsPerRequest {
+		end := i + maxStreamsPerRequest
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
 
-	url := fmt.Sprintf("%s/streams?user_id=%s",
-		helixEndpoint, strings.Join(userIDs, "&user_id="))
+		url := fmt.Sprintf("%s/streams?first=%d&user_id=%s",
+			w.conf.HelixEndpoint, maxStreamsPerRequest, strings.Join(userIDs[i:end], "&user_id="))
 
-	data := new(streamsDataWrapper)
-	if err := w.doAuthenticatedGet(url, data); err != nil {
-		return nil, err
-	}
+		data := new(streamsDataWrapper)
+		if err := w.doAuthenticated("GET", url, nil, data); err != nil {
+			return nil, err
+		}
 
-	streams := make([]*Stream, len(data.Data))
-	copy(streams, data.Data)
+		streams = append(streams, data.Data...)
+	}
 
 	return streams, nil
 }
@@ -253,14 +367,16 @@ func (w *NotifyWorker) getStreams() ([]*Stream, error) {
 // gameID. The fetched game is returned as well as occured
 // errors during fetch.
 func (w *NotifyWorker) getGame(gameID string) (*Game, error) {
+	w.mx.Lock()
 	game, ok := w.gameCache[gameID]
+	w.mx.Unlock()
 	if ok {
 		return game, nil
 	}
 
-	url := fmt.Sprintf("%s/games?id=%s", helixEndpoint, gameID)
+	url := fmt.Sprintf("%s/games?id=%s", w.conf.HelixEndpoint, gameID)
 	data := new(gamesDataWrapper)
-	if err := w.doAuthenticatedGet(url, data); err != nil {
+	if err := w.doAuthenticated("GET", url, nil, data); err != nil {
 		return nil, err
 	}
 
@@ -269,19 +385,26 @@ func (w *NotifyWorker) getGame(gameID string) (*Game, error) {
 	}
 
 	game = data.Data[0]
+
+	w.mx.Lock()
 	w.gameCache[gameID] = game
+	w.mx.Unlock()
 
 	return game, nil
 }
 
 // Handle is the callback function executed on ech timer tick.
+//
+// It polls the streams of all users which are not covered
+// by active EventSub subscriptions.
 func (w *NotifyWorker) Handle() error {
-	if len(w.users) < 1 {
+	userIDs := w.polledUsers()
+	if len(userIDs) < 1 {
 		return nil
 	}
 
 	// Request watched streams which are currently live.
-	streams, err := w.getStreams()
+	streams, err := w.getStreams(userIDs)
 	if err != nil {
 		return err
 	}
@@ -289,60 +412,109 @@ func (w *NotifyWorker) Handle() error {
 	// Execute wentOnlineHandler for each stream which
 	// is now live and was not live in the request before.
 	mErr := multierror.New()
+	online := make(map[string]struct{}, len(streams))
 	for _, stream := range streams {
 		if stream == nil {
 			continue
 		}
+		online[stream.UserID] = struct{}{}
+		mErr.Append(w.wentOnline(stream))
+	}
+
+	// Execute wentOfflineHandler for each stream which was
+	// online in the request before and is now offline.
+	for _, id := range userIDs {
+		if _, ok := online[id]; !ok {
+			w.wentOffline(id)
+		}
+	}
 
-		var wasOnline bool
+	return mErr.Nillify()
+}
 
-		w.mx.Lock()
-		for _, nd := range w.wereLive {
-			if nd.ID == stream.ID {
-				wasOnline = true
-				break
-			}
+// polledUsers returns the IDs of all watched users
+// which are not covered by active subscriptions.
+func (w *NotifyWorker) polledUsers() []string {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	covered := make(map[string]int)
+	for _, sub := range w.subscriptions {
+		if sub.Enabled {
+			covered[sub.UserID]++
 		}
-		w.mx.Unlock()
+	}
 
-		if wasOnline {
-			continue
+	userIDs := make([]string, 0, len(w.users))
+	for id := range w.users {
+		if covered[id] < len(eventSubStreamTypes) {
+			userIDs = append(userIDs, id)
 		}
+	}
 
-		game, err := w.getGame(stream.GameID)
-		mErr.Append(err)
+	return userIDs
+}
 
-		stream.Game = game
-		stream.ThumbnailURL = strings.Replace(stream.ThumbnailURL, "{width}x{height}", "1280x720", 1)
+// wentOnline calls the wentOnlineHandler if the given
+// stream was not recorded as live before. If another
+// stream of the user was live, the wentOfflineHandler
+// is called for it first.
+func (w *NotifyWorker) wentOnline(stream *Stream) (err error) {
+	w.mx.Lock()
+	user, ok := w.users[stream.UserID]
+	prev, wasLive := w.live[stream.UserID]
+	if !ok || (wasLive && prev.ID == stream.ID) {
+		w.mx.Unlock()
+		return
+	}
+	w.live[stream.UserID] = stream
+	w.mx.Unlock()
 
-		user := w.users[stream.UserID]
+	if wasLive {
+		w.wentOfflineHandler(prev, user)
+	}
 
-		w.wentOnlineHandler(stream, user)
+	if stream.GameID != "" {
+		stream.Game, err = w.getGame(stream.GameID)
 	}
+	stream.ThumbnailURL = strings.Replace(stream.ThumbnailURL, "{width}x{height}", "1280x720", 1)
 
-	// Execute wentOfflineHandler for each stream which was
-	// online in the request before and is now offline.
+	w.wentOnlineHandler(stream, user)
+
+	return
+}
+
+// wentOffline calls the wentOfflineHandler if the user
+// with the given ID was recorded as live before.
+func (w *NotifyWorker) wentOffline(userID string) {
 	w.mx.Lock()
-	defer w.mx.Unlock()
-	for _, nd := range w.wereLive {
-		var stillOnline bool
+	user, ok := w.users[userID]
+	stream, wasLive := w.live[userID]
+	delete(w.live, userID)
+	w.mx.Unlock()
 
-		for _, stream := range streams {
-			if stream.ID == nd.ID {
-				stillOnline = true
-				break
-			}
-		}
+	if ok && wasLive {
+		w.wentOfflineHandler(stream, user)
+	}
+}
 
-		if !stillOnline {
-			user := w.users[nd.UserID]
-			w.wentOfflineHandler(nd, user)
-		}
+// responseError returns a ResponseError if the
+// response has an error status code.
+func responseError(res *httpreq.Response) error {
+	if res.StatusCode() < 400 {
+		return nil
 	}
 
-	// Update the last request state.
-	w.wereLive = make([]*Stream, len(streams))
-	copy(w.wereLive, streams)
+	var body errorResponse
+	res.JSON(&body)
 
-	return mErr.Nillify()
+	msg := body.Message
+	if msg == "" {
+		msg = body.Error
+	}
+
+	return &ResponseError{
+		StatusCode: res.StatusCode(),
+		Message:    msg,
+	}
 }
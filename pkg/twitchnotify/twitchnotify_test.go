@@ -0,0 +1,493 @@
+package twitchnotify
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCallbackURL = "https://example.com/twitch/eventsub"
+	testSecret      = "super-secret-value"
+)
+
+// fakeTwitch is a local fake of the OAuth2, Helix
+// and EventSub subscription endpoints.
+type fakeTwitch struct {
+	*httptest.Server
+
+	mx             sync.Mutex
+	streams        map[string]*Stream
+	subs           map[string]*eventSubSubscription
+	subCounter     int
+	streamRequests [][]string
+}
+
+func newFakeTwitch(t *testing.T) *fakeTwitch {
+	f := &fakeTwitch{
+		streams: make(map[string]*Stream),
+		subs:    make(map[string]*eventSubSubscription),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, bearerTokenResponse{AccessToken: "token", ExpiresIn: 3600})
+	})
+	mux.HandleFunc("/helix/users", f.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			id = "id-" + r.URL.Query().Get("login")
+		}
+		writeJSON(w, http.StatusOK, usersDataWrapper{Data: []*User{{ID: id, LoginName: id}}})
+	}))
+	mux.HandleFunc("/helix/games", f.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		writeJSON(w, http.StatusOK, gamesDataWrapper{Data: []*Game{{ID: id, Name: "Game " + id}}})
+	}))
+	mux.HandleFunc("/helix/streams", f.authenticated(f.handleStreams))
+	mux.HandleFunc("/helix/eventsub/subscriptions", f.authenticated(f.handleSubscriptions))
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeTwitch) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Client-ID") != "client" {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Message: "unauthorized"})
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (f *fakeTwitch) handleStreams(w http.ResponseWriter, r *http.Request) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	userIDs := r.URL.Query()["user_id"]
+	f.streamRequests = append(f.streamRequests, userIDs)
+
+	res := streamsDataWrapper{Data: []*Stream{}}
+	for _, id := range userIDs {
+		if s, ok := f.streams[id]; ok {
+			c := *s
+			res.Data = append(res.Data, &c)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (f *fakeTwitch) handleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		// Paginate with a page size of 2 to cover cursors.
+		ids := make([]string, 0, len(f.subs))
+		for id := range f.subs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		start, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		res := eventSubSubscriptionsWrapper{Data: []*eventSubSubscription{}}
+		for i := start; i < len(ids) && i < start+2; i++ {
+			res.Data = append(res.Data, f.subs[ids[i]])
+		}
+		if start+2 < len(ids) {
+			res.Pagination.Cursor = strconv.Itoa(start + 2)
+		}
+		writeJSON(w, http.StatusOK, res)
+
+	case http.MethodPost:
+		var sub eventSubSubscription
+		if err := json.NewDecoder(r.Body).Decode(&sub); err != nil || sub.Transport.Secret == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Message: "bad request"})
+			return
+		}
+		f.subCounter++
+		sub.ID = fmt.Sprintf("sub-%03d", f.subCounter)
+		sub.Status = statusVerificationPending
+		sub.Transport.Secret = ""
+		f.subs[sub.ID] = &sub
+		writeJSON(w, http.StatusAccepted, eventSubSubscriptionsWrapper{Data: []*eventSubSubscription{&sub}})
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if _, ok := f.subs[id]; !ok {
+			writeJSON(w, http.StatusNotFound, errorResponse{Message: "not found"})
+			return
+		}
+		delete(f.subs, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeTwitch) setStream(userID string, s *Stream) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if s == nil {
+		delete(f.streams, userID)
+	} else {
+		f.streams[userID] = s
+	}
+}
+
+func (f *fakeTwitch) subscriptions() []eventSubSubscription {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	res := make([]eventSubSubscription, 0, len(f.subs))
+	for _, s := range f.subs {
+		res = append(res, *s)
+	}
+	return res
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+type recorder struct {
+	mx      sync.Mutex
+	online  []*Stream
+	offline []*Stream
+	events  chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{events: make(chan struct{}, 100)}
+}
+
+func (r *recorder) onOnline(s *Stream, _ *User) {
+	r.mx.Lock()
+	r.online = append(r.online, s)
+	r.mx.Unlock()
+	r.events <- struct{}{}
+}
+
+func (r *recorder) onOffline(s *Stream, _ *User) {
+	r.mx.Lock()
+	r.offline = append(r.offline, s)
+	r.mx.Unlock()
+	r.events <- struct{}{}
+}
+
+func (r *recorder) wait(t *testing.T) {
+	select {
+	case <-r.events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for handler call")
+	}
+}
+
+func newTestWorker(t *testing.T, f *fakeTwitch, rec *recorder, eventSub bool) *NotifyWorker {
+	conf := Config{
+		HelixEndpoint:  f.URL + "/helix",
+		OAuth2Endpoint: f.URL + "/oauth2/token",
+	}
+	if eventSub {
+		conf.EventSub = EventSubConfig{CallbackURL: testCallbackURL, Secret: testSecret}
+	}
+
+	w, err := New(Credentials{ClientID: "client", ClientSecret: "secret"},
+		rec.onOnline, rec.onOffline, conf)
+	require.NoError(t, err)
+	return w
+}
+
+func signedMessage(id, typ string, ts time.Time, payload interface{}) EventSubMessage {
+	body, _ := json.Marshal(payload)
+	msg := EventSubMessage{
+		ID:        id,
+		Type:      typ,
+		Timestamp: ts.UTC().Format(time.RFC3339Nano),
+		Body:      body,
+	}
+	msg.Signature = "sha256=" + hex.EncodeToString(sign(testSecret, msg))
+	return msg
+}
+
+func TestPolling(t *testing.T) {
+	f := newFakeTwitch(t)
+	rec := newRecorder()
+	w := newTestWorker(t, f, rec, false)
+
+	u, err := w.GetUser("zekro", IdentLogin)
+	require.NoError(t, err)
+	require.NoError(t, w.AddUser(u))
+
+	require.NoError(t, w.Handle())
+	assert.Empty(t, rec.online)
+
+	f.setStream(u.ID, &Stream{ID: "s1", UserID: u.ID, GameID: "g1", ThumbnailURL: "thumb-{width}x{height}"})
+	require.NoError(t, w.Handle())
+	require.NoError(t, w.Handle())
+	require.Len(t, rec.online, 1)
+	assert.Equal(t, "Game g1", rec.online[0].Game.Name)
+	assert.Equal(t, "thumb-1280x720", rec.online[0].ThumbnailURL)
+
+	f.setStream(u.ID, nil)
+	require.NoError(t, w.Handle())
+	require.Len(t, rec.offline, 1)
+	assert.Equal(t, "s1", rec.offline[0].ID)
+}
+
+func TestPollingBatches(t *testing.T) {
+	f := newFakeTwitch(t)
+	w := newTestWorker(t, f, newRecorder(), false)
+
+	for i := 0; i < 250; i++ {
+		require.NoError(t, w.AddUser(&User{ID: strconv.Itoa(i)}))
+	}
+
+	require.NoError(t, w.Handle())
+	require.Len(t, f.streamRequests, 3)
+	for _, ids := range f.streamRequests {
+		assert.LessOrEqual(t, len(ids), maxStreamsPerRequest)
+	}
+}
+
+func TestUserCap(t *testing.T) {
+	f := newFakeTwitch(t)
+
+	w := newTestWorker(t, f, newRecorder(), false)
+	for i := 0; i < maxUserCap; i++ {
+		w.users[strconv.Itoa(i)] = &User{}
+	}
+	assert.ErrorIs(t, w.AddUser(&User{ID: "over"}), ErrMaxUsersReached)
+
+	w = newTestWorker(t, f, newRecorder(), true)
+	for i := 0; i < maxUserCap; i++ {
+		w.users[strconv.Itoa(i)] = &User{}
+	}
+	assert.NoError(t, w.AddUser(&User{ID: "over"}))
+}
+
+func TestInvalidSecret(t *testing.T) {
+	_, err := New(Credentials{}, nil, nil, Config{
+		EventSub: EventSubConfig{CallbackURL: testCallbackURL, Secret: "short"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestVerifySignature(t *testing.T) {
+	msg := signedMessage("m1", messageTypeNotification, time.Now(), map[string]string{"a": "b"})
+	assert.True(t, VerifySignature(testSecret, msg))
+	assert.False(t, VerifySignature("other-secret", msg))
+
+	tampered := msg
+	tampered.Body = []byte(`{"a":"c"}`)
+	assert.False(t, VerifySignature(testSecret, tampered))
+
+	tampered = msg
+	tampered.Signature = msg.Signature[len("sha256="):]
+	assert.False(t, VerifySignature(testSecret, tampered))
+
+	tampered.Signature = "sha256=not-hex"
+	assert.False(t, VerifySignature(testSecret, tampered))
+}
+
+func TestEventSubLifecycle(t *testing.T) {
+	f := newFakeTwitch(t)
+	rec := newRecorder()
+	w := newTestWorker(t, f, rec, true)
+
+	// Subscriptions are only created after the
+	// existing ones have been synced.
+	require.NoError(t, w.AddUser(&User{ID: "0"}))
+	assert.Empty(t, f.subscriptions())
+	require.NoError(t, w.RemoveUser("0"))
+	require.NoError(t, w.SyncSubscriptions())
+
+	require.NoError(t, w.AddUser(&User{ID: "1"}))
+
+	subs := f.subscriptions()
+	require.Len(t, subs, 2)
+	for _, s := range subs {
+		assert.Equal(t, "1", s.Condition.BroadcasterUserID)
+		assert.Equal(t, testCallbackURL, s.Transport.Callback)
+	}
+
+	// Pending subscriptions do not cover the user yet.
+	assert.Equal(t, []string{"1"}, w.polledUsers())
+
+	for i, s := range subs {
+		challenge, err := w.HandleEventSub(signedMessage(
+			fmt.Sprintf("verify-%d", i), messageTypeVerification, time.Now(),
+			eventSubPayload{Challenge: "challenge-" + s.ID, Subscription: s}))
+		require.NoError(t, err)
+		assert.Equal(t, "challenge-"+s.ID, challenge)
+	}
+	assert.Empty(t, w.polledUsers())
+
+	online := subs[0]
+	if online.Type != eventTypeStreamOnline {
+		online = subs[1]
+	}
+	f.setStream("1", &Stream{ID: "s1", UserID: "1", Title: "hello"})
+
+	_, err := w.HandleEventSub(signedMessage("n1", messageTypeNotification, time.Now(), eventSubPayload{
+		Subscription: online,
+		Event:        eventSubStreamEvent{ID: "s1", BroadcasterUserID: "1"},
+	}))
+	require.NoError(t, err)
+	rec.wait(t)
+	require.Len(t, rec.online, 1)
+	assert.Equal(t, "hello", rec.online[0].Title)
+
+	// Redelivered messages are ignored.
+	_, err = w.HandleEventSub(signedMessage("n1", messageTypeNotification, time.Now(), eventSubPayload{
+		Subscription: online,
+		Event:        eventSubStreamEvent{ID: "s1", BroadcasterUserID: "1"},
+	}))
+	require.NoError(t, err)
+
+	_, err = w.HandleEventSub(signedMessage("n2", messageTypeNotification, time.Now(), eventSubPayload{
+		Subscription: eventSubSubscription{ID: "other", Type: eventTypeStreamOffline},
+		Event:        eventSubStreamEvent{BroadcasterUserID: "1"},
+	}))
+	require.NoError(t, err)
+	rec.wait(t)
+	require.Len(t, rec.online, 1)
+	require.Len(t, rec.offline, 1)
+	assert.Equal(t, "s1", rec.offline[0].ID)
+
+	// A revoked subscription falls back to polling.
+	f.mx.Lock()
+	delete(f.subs, online.ID)
+	f.mx.Unlock()
+	_, err = w.HandleEventSub(signedMessage("r1", messageTypeRevocation, time.Now(), eventSubPayload{
+		Subscription: online,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, w.polledUsers())
+
+	require.NoError(t, w.RemoveUser("1"))
+	for _, s := range f.subscriptions() {
+		assert.NotEqual(t, "1", s.Condition.BroadcasterUserID)
+	}
+}
+
+func TestEventSubStreamFallback(t *testing.T) {
+	f := newFakeTwitch(t)
+	rec := newRecorder()
+	w := newTestWorker(t, f, rec, true)
+	require.NoError(t, w.AddUser(&User{ID: "1"}))
+
+	// The stream is not yet available via the API.
+	_, err := w.HandleEventSub(signedMessage("n1", messageTypeNotification, time.Now(), eventSubPayload{
+		Subscription: eventSubSubscription{ID: "x", Type: eventTypeStreamOnline},
+		Event:        eventSubStreamEvent{ID: "s1", BroadcasterUserID: "1", BroadcasterUserLogin: "zekro"},
+	}))
+	require.NoError(t, err)
+	rec.wait(t)
+	require.Len(t, rec.online, 1)
+	assert.Equal(t, "s1", rec.online[0].ID)
+	assert.Equal(t, "https://static-cdn.jtvnw.net/previews-ttv/live_user_zekro-1280x720.jpg",
+		rec.online[0].ThumbnailURL)
+}
+
+func TestEventSubRejected(t *testing.T) {
+	f := newFakeTwitch(t)
+	w := newTestWorker(t, f, newRecorder(), true)
+
+	msg := signedMessage("m1", messageTypeVerification, time.Now(), eventSubPayload{Challenge: "c"})
+	msg.Signature = "sha256=" + hex.EncodeToString(sign("other-secret", msg))
+	_, err := w.HandleEventSub(msg)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	msg = signedMessage("m2", messageTypeVerification, time.Now().Add(-11*time.Minute), eventSubPayload{Challenge: "c"})
+	_, err = w.HandleEventSub(msg)
+	assert.ErrorIs(t, err, ErrMessageExpired)
+
+	w = newTestWorker(t, f, newRecorder(), false)
+	_, err = w.HandleEventSub(signedMessage("m3", messageTypeVerification, time.Now(), eventSubPayload{}))
+	assert.ErrorIs(t, err, ErrEventSubDisabled)
+}
+
+func TestEventSubDuplicateReusedBuffer(t *testing.T) {
+	f := newFakeTwitch(t)
+	w := newTestWorker(t, f, newRecorder(), true)
+
+	// Mimics fiber's header values which reference the
+	// request buffer without copying it.
+	buf := []byte("m1")
+	id := *(*string)(unsafe.Pointer(&buf))
+
+	challenge, err := w.HandleEventSub(signedMessage(id, messageTypeVerification, time.Now(), eventSubPayload{Challenge: "c"}))
+	require.NoError(t, err)
+	assert.Equal(t, "c", challenge)
+
+	copy(buf, "m2")
+
+	challenge, err = w.HandleEventSub(signedMessage("m1", messageTypeVerification, time.Now(), eventSubPayload{Challenge: "c"}))
+	require.NoError(t, err)
+	assert.Empty(t, challenge)
+
+	challenge, err = w.HandleEventSub(signedMessage("m2", messageTypeVerification, time.Now(), eventSubPayload{Challenge: "c"}))
+	require.NoError(t, err)
+	assert.Equal(t, "c", challenge)
+}
+
+func TestSyncSubscriptions(t *testing.T) {
+	f := newFakeTwitch(t)
+	w := newTestWorker(t, f, newRecorder(), true)
+
+	w.users["1"] = &User{ID: "1"}
+	w.users["2"] = &User{ID: "2"}
+
+	sub := func(id, typ, userID, status, callback string) {
+		f.subs[id] = &eventSubSubscription{
+			ID: id, Type: typ, Status: status,
+			Condition: eventSubCondition{BroadcasterUserID: userID},
+			Transport: eventSubTransport{Method: "webhook", Callback: callback},
+		}
+	}
+	sub("a", eventTypeStreamOnline, "1", statusEnabled, testCallbackURL)
+	sub("b", eventTypeStreamOnline, "1", statusEnabled, testCallbackURL)
+	sub("c", eventTypeStreamOffline, "1", "notification_failures_exceeded", testCallbackURL)
+	sub("d", eventTypeStreamOnline, "3", statusEnabled, testCallbackURL)
+	sub("e", eventTypeStreamOnline, "3", statusEnabled, "https://other.example.com")
+	sub("f", "channel.follow", "1", statusEnabled, testCallbackURL)
+
+	require.NoError(t, w.SyncSubscriptions())
+
+	got := make(map[string]string)
+	for _, s := range f.subscriptions() {
+		if s.Transport.Callback == testCallbackURL && isStreamEventType(s.Type) {
+			got[s.Condition.BroadcasterUserID+"/"+s.Type] = s.Status
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"1/" + eventTypeStreamOnline:  statusEnabled,
+		"1/" + eventTypeStreamOffline: statusVerificationPending,
+		"2/" + eventTypeStreamOnline:  statusVerificationPending,
+		"2/" + eventTypeStreamOffline: statusVerificationPending,
+	}, got)
+	assert.Contains(t, f.subs, "e")
+	assert.Contains(t, f.subs, "f")
+
+	// Only the enabled online subscription of user 1
+	// is active, so both users are still polled.
+	polled := w.polledUsers()
+	sort.Strings(polled)
+	assert.Equal(t, []string{"1", "2"}, polled)
+}
@@ -3,6 +3,9 @@ import {
   AccessTokenModel,
   AntiraidAction,
   AntiraidSettings,
+  AutomodDryRunRequest,
+  AutomodDryRunResponse,
+  AutomodRule,
   Channel,
   CodeExecSettings,
   CodeResponse,
@@ -14,8 +17,11 @@ import {
   GuildSettings,
   GuildSettingsApi,
   GuildStarboardEntry,
+  InviteBlockSettings,
   InviteSettingsRequest,
   InviteSettingsResponse,
+  JoinlogActionProgress,
+  JoinlogActionRequest,
   JoinlogEntry,
   KarmaRule,
   KarmaSettings,
@@ -238,6 +244,17 @@ export class GuildsClient extends SubClient {
     return this.req('DELETE', `${id}/antiraid/joinlog`);
   }
 
+  antiraidJoinlogAction(id: string): Promise<JoinlogActionProgress> {
+    return this.req('GET', `${id}/antiraid/joinlog/action`);
+  }
+
+  applyAntiraidJoinlogAction(
+    id: string,
+    req: JoinlogActionRequest,
+  ): Promise<JoinlogActionProgress> {
+    return this.req('POST', `${id}/antiraid/joinlog/action`, req);
+  }
+
   setInviteBlock(id: string, enabled: boolean): Promise<ListResponse<JoinlogEntry>> {
     return this.req('POST', `${id}/inviteblock`, { enabled });
   }
@@ -357,6 +374,34 @@ export class GuildSettingsClient extends SubClient {
     return this.req('POST', 'flushguilddata', { leave_after, validation });
   }
 
+  inviteblock(): Promise<InviteBlockSettings> {
+    return this.req('GET', 'inviteblock');
+  }
+
+  setInviteblock(settings: InviteBlockSettings): Promise<InviteBlockSettings> {
+    return this.req('POST', 'inviteblock', settings);
+  }
+
+  automodRules(): Promise<ListResponse<AutomodRule>> {
+    return this.req('GET', 'automod');
+  }
+
+  addAutomodRule(rule: AutomodRule): Promise<AutomodRule> {
+    return this.req('POST', 'automod', rule);
+  }
+
+  updateAutomodRule(rule: AutomodRule): Promise<AutomodRule> {
+    return this.req('POST', `automod/${rule.id}`, rule);
+  }
+
+  removeAutomodRule(id: string): Promise<CodeResponse> {
+    return this.req('DELETE', `automod/${id}`);
+  }
+
+  dryRunAutomodRule(req: AutomodDryRunRequest): Promise<AutomodDryRunResponse> {
+    return this.req('POST', 'automod/dryrun', req);
+  }
+
   karma(): Promise<KarmaSettings> {
     return this.req('GET', 'karma');
   }
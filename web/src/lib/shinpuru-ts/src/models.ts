@@ -284,6 +284,54 @@ export interface AntiraidSettings {
   regeneration_period: number;
   burst: number;
   verification: boolean;
+  signals: AntiraidSignals;
+  lockdown: AntiraidLockdown;
+}
+
+export enum AntiraidResponse {
+  NONE,
+  QUARANTINE,
+  TIMEOUT,
+  KICK,
+  BAN,
+}
+
+export interface AntiraidSignals {
+  account_age: {
+    response: AntiraidResponse;
+    min_age: number;
+  };
+  similarity: {
+    response: AntiraidResponse;
+    threshold: number;
+    window: number;
+    min_matches: number;
+  };
+  spam: {
+    response: AntiraidResponse;
+    window: number;
+    messages: number;
+    mentions: number;
+  };
+  quarantine_role_id: string;
+  timeout_duration: number;
+}
+
+export interface AntiraidLockdown {
+  auto: boolean;
+  pause_invites: boolean;
+  lock_channels: string[];
+  slowmode_channels: string[];
+  slowmode: number;
+  quiet_period: number;
+}
+
+export interface InviteBlockSettings {
+  allowed_guilds: string[];
+  channels: string[];
+  delete: boolean;
+  warn: boolean;
+  timeout: number;
 }
 
 export interface JoinlogEntry {
@@ -296,6 +344,36 @@ export interface JoinlogEntry {
   selected: boolean;
 }
 
+export enum JoinlogAction {
+  BAN = 'ban',
+  KICK = 'kick',
+  VERIFY = 'verify',
+}
+
+export interface JoinlogFilter {
+  user_ids?: string[];
+  max_account_age?: number;
+  name_pattern?: string;
+}
+
+export interface JoinlogActionRequest {
+  action: JoinlogAction;
+  filter: JoinlogFilter;
+  reason?: string;
+}
+
+export interface JoinlogActionProgress {
+  guild_id: string;
+  executor_id: string;
+  action: JoinlogAction;
+  total: number;
+  done: number;
+  failed: number;
+  errors: string[];
+  started: Date;
+  finished?: Date;
+}
+
 export interface LandingPageInfo {
   localinvite: string;
   publicmaininvite: string;
@@ -364,6 +442,55 @@ export interface KarmaRule {
   argument: string;
 }
 
+export type AutomodMatcherType =
+  | 'WORDS'
+  | 'REGEX'
+  | 'LINKS'
+  | 'CAPS'
+  | 'ZALGO'
+  | 'MENTIONS'
+  | 'DUPLICATES';
+
+export interface AutomodMatcher {
+  type: AutomodMatcherType;
+  words: string[];
+  pattern: string;
+  domains: string[];
+  allow_domains: boolean;
+  threshold: number;
+  min_length: number;
+  window: number;
+}
+
+export type AutomodActionType = 'DELETE' | 'WARN' | 'TIMEOUT' | 'KICK';
+
+export interface AutomodAction {
+  type: AutomodActionType;
+  duration: number;
+}
+
+export interface AutomodRule {
+  id: string;
+  name: string;
+  enabled: boolean;
+  matcher: AutomodMatcher;
+  channels: string[];
+  exempt_roles: string[];
+  actions: AutomodAction[];
+}
+
+export interface AutomodDryRunRequest {
+  rule: AutomodRule;
+  content: string;
+  history: string[];
+}
+
+export interface AutomodDryRunResponse {
+  matched: boolean;
+  reason: string;
+  actions: AutomodAction[];
+}
+
 export interface State {
   state: boolean;
 }